@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meedamian/fat/internal/auth"
+	"github.com/meedamian/fat/internal/db"
+)
+
+func newAccessKeysCommand(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "access-keys",
+		Short: "Manage the per-user keys that can ask questions and use the admin endpoints",
+		Long: `Manage the per-user keys accepted next to FAT_AUTH_TOKEN. Once a key exists, the server requires
+one of them (or the token) for asking questions, the shutdown endpoints, stats and admin endpoints.`,
+		Args: noArgs,
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "create user",
+			Short: "Create a key for user and print it; it can't be shown again",
+			Args: func(_ *cobra.Command, args []string) error {
+				if len(args) != 1 || args[0] == "" {
+					return usageError("usage: fat access-keys create user")
+				}
+				return nil
+			},
+			RunE: func(_ *cobra.Command, args []string) error {
+				return c.createAccessKey(args[0])
+			},
+		},
+		&cobra.Command{
+			Use:   "list",
+			Short: "List every key, revoked ones included",
+			Args:  noArgs,
+			RunE: func(*cobra.Command, []string) error {
+				return c.listAccessKeys()
+			},
+		},
+		&cobra.Command{
+			Use:   "revoke id",
+			Short: "Stop accepting a key",
+			Args: func(_ *cobra.Command, args []string) error {
+				if len(args) != 1 {
+					return usageError("usage: fat access-keys revoke id")
+				}
+				return nil
+			},
+			RunE: func(_ *cobra.Command, args []string) error {
+				id, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil {
+					return usageError("invalid key id %q: must be a number from `fat access-keys list`", args[0])
+				}
+				return c.revokeAccessKey(id)
+			},
+		},
+	)
+	return cmd
+}
+
+// openAccessKeys opens the database for the access-keys commands, which need nothing else
+func (c *cli) openAccessKeys() (*db.DB, func(), error) {
+	logger, err := c.logger(os.Stderr)
+	if err != nil {
+		return nil, nil, err
+	}
+	database, err := c.openDB(logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return database, func() { closeStore(logger, database) }, nil
+}
+
+// createAccessKey stores a new key for user and prints it
+func (c *cli) createAccessKey(user string) error {
+	database, closeDB, err := c.openAccessKeys()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	key, hash, prefix, err := auth.NewKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	ctx, stop := signalContext()
+	defer stop()
+	id, err := database.CreateAccessKey(ctx, user, hash, prefix)
+	if err != nil {
+		return err
+	}
+
+	if c.jsonOutput {
+		return printJSON(map[string]any{"id": id, "user": user, "key": key})
+	}
+	fmt.Printf("Created key %d for %s - it won't be shown again:\n%s\n", id, user, key)
+	return nil
+}
+
+// listAccessKeys prints every key without the keys themselves
+func (c *cli) listAccessKeys() error {
+	database, closeDB, err := c.openAccessKeys()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, stop := signalContext()
+	defer stop()
+	keys, err := database.GetAccessKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.jsonOutput {
+		if keys == nil {
+			keys = []db.AccessKey{}
+		}
+		return printJSON(keys)
+	}
+	if len(keys) == 0 {
+		fmt.Println("No access keys; create one with `fat access-keys create user`")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSER\tKEY\tCREATED\tLAST USED\tSTATUS")
+	for _, k := range keys {
+		lastUsed, status := "never", "active"
+		if k.LastUsedAt != nil {
+			lastUsed = k.LastUsedAt.Local().Format(time.DateTime)
+		}
+		if k.RevokedAt != nil {
+			status = "revoked " + k.RevokedAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s…\t%s\t%s\t%s\n", k.ID, k.User, k.Prefix, k.CreatedAt.Local().Format(time.DateTime), lastUsed, status)
+	}
+	return w.Flush()
+}
+
+// revokeAccessKey stops the server from accepting a key
+func (c *cli) revokeAccessKey(id int64) error {
+	database, closeDB, err := c.openAccessKeys()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, stop := signalContext()
+	defer stop()
+	err = database.RevokeAccessKey(ctx, id)
+	if errors.Is(err, db.ErrAccessKeyNotFound) {
+		return usageError("no active access key with id %d", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.jsonOutput {
+		return printJSON(map[string]any{"id": id, "revoked": true})
+	}
+	fmt.Printf("Revoked key %d\n", id)
+	return nil
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/htmlexport"
+	"github.com/meedamian/fat/internal/jsonexport"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/orchestrator"
+	"github.com/meedamian/fat/internal/ranking"
+	"github.com/meedamian/fat/internal/server"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+	"github.com/meedamian/fat/web"
+)
+
+// askOptions are the flags of `fat ask`
+type askOptions struct {
+	rounds      int
+	models      string
+	out         string
+	notifyCmd   string
+	desktop     bool
+	maxCost     float64
+	strategy    string
+	metaJudge   string
+	synthesizer string
+	audit       bool
+	verify      bool
+}
+
+func newAskCommand(c *cli) *cobra.Command {
+	var opts askOptions
+	cmd := &cobra.Command{
+		Use:   `ask "question"`,
+		Short: "Ask one question from the terminal without the HTTP server",
+		Long: `Ask one question without the HTTP server, with round progress on stdout and the export copied
+to --out (.md, .html, .svg or .json). The notifications fire whether the run finished or failed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.ask(strings.TrimSpace(strings.Join(args, " ")), opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&opts.rounds, "rounds", 3, "number of discussion rounds (3-10)")
+	flags.StringVar(&opts.models, "models", "", "comma-separated families or variants to ask, several variants of a family side by side (default: every family)")
+	flags.StringVar(&opts.out, "out", "", "file to write the result to (.md, .html, .svg or .json)")
+	flags.StringVar(&opts.notifyCmd, "notify-cmd", c.cfg.NotifyCommand, "shell command run when the run ends, with a JSON summary on stdin")
+	flags.BoolVar(&opts.desktop, "notify", false, "show a desktop notification when the run ends")
+	flags.Float64Var(&opts.maxCost, "max-cost", 0, "exit with code 3 if the run cost more than this many dollars")
+	flags.StringVar(&opts.strategy, "strategy", c.cfg.WinnerStrategy, "how the judges' rankings pick the winner: "+strings.Join(ranking.StrategyNames(), ", "))
+	flags.StringVar(&opts.metaJudge, "meta-judge", c.cfg.MetaJudge, "variant with the final say under the "+ranking.JudgeOfJudges+" strategy")
+	flags.StringVar(&opts.synthesizer, "synthesizer", c.cfg.Synthesizer, "variant merging the top answers into one after ranking")
+	flags.BoolVar(&opts.audit, "audit", c.cfg.AuditRankings, "require a one-sentence reason from the judges for every placement")
+	flags.BoolVar(&opts.verify, "verify", c.cfg.Verification, "have every model fact-check the winning answer after ranking")
+	cmd.RegisterFlagCompletionFunc("models", completeModels)
+	cmd.RegisterFlagCompletionFunc("out", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"md", "html", "svg", "json"}, cobra.ShellCompDirectiveFilterFileExt
+	})
+	return cmd
+}
+
+// ask runs one question to completion and exits with the code matching how it went
+func (c *cli) ask(question string, opts askOptions) error {
+	if question == "" {
+		return usageError(`usage: fat ask "question" [--rounds N] [--models grok,claude] [--out result.md]`)
+	}
+	if opts.rounds < 3 || opts.rounds > 10 {
+		return usageError("invalid --rounds value %d: must be between 3 and 10", opts.rounds)
+	}
+	switch ext := filepath.Ext(opts.out); ext {
+	case "", ".md", ".html", ".svg", ".json":
+	default:
+		return usageError("invalid --out extension %q: must be .md, .html, .svg or .json", ext)
+	}
+	if opts.maxCost < 0 {
+		return usageError("invalid --max-cost value %v: must not be negative", opts.maxCost)
+	}
+	if _, err := ranking.StrategyFor(opts.strategy); err != nil {
+		return usageError("invalid --strategy value: %v", err)
+	}
+	if opts.metaJudge != "" && models.FamilyForVariant(opts.metaJudge) == "" {
+		return usageError("invalid --meta-judge value: unknown variant %q", opts.metaJudge)
+	}
+	if opts.strategy == ranking.JudgeOfJudges && opts.metaJudge == "" {
+		return usageError("--strategy %s needs a --meta-judge", ranking.JudgeOfJudges)
+	}
+	if opts.synthesizer != "" && models.FamilyForVariant(opts.synthesizer) == "" {
+		return usageError("invalid --synthesizer value: unknown variant %q", opts.synthesizer)
+	}
+	picks := splitList(opts.models)
+	if err := checkPicks(picks); err != nil {
+		return err
+	}
+
+	logger, err := c.logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+	database, err := c.openStore(logger)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, database)
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	c.cfg.WinnerStrategy = opts.strategy
+	c.cfg.MetaJudge = opts.metaJudge
+	c.cfg.Synthesizer = opts.synthesizer
+	c.cfg.AuditRankings = opts.audit
+	c.cfg.Verification = opts.verify
+	// Exports uploaded without local copies are kept until the one asked for was copied from h/
+	uploadOnly := c.cfg.S3Bucket != "" && !c.cfg.S3KeepLocal
+	keptForOut := uploadOnly && opts.out != "" && filepath.Ext(opts.out) != ".json"
+	if keptForOut {
+		c.cfg.S3KeepLocal = true
+	}
+	srv := server.New(logger, c.cfg, database, web.Static)
+	var winner map[string]any
+	var exportURL string
+	result, err := srv.Ask(ctx, question, opts.rounds, picks, "", func(message map[string]any) {
+		switch message["type"] {
+		case "winner":
+			winner = message
+		case "export":
+			if message["status"] == orchestrator.ExportDone {
+				exportURL, _ = message["url"].(string)
+			}
+		}
+		if !c.jsonOutput {
+			printProgress(os.Stdout, message)
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		err = exitError{code: exitProvider, err: err}
+	}
+
+	var saved string
+	if err == nil {
+		saved, err = saveResult(ctx, database, c.cfg.DataDir, result, question, opts.out)
+		if opts.out == "" && uploadOnly && exportURL != "" {
+			saved = exportURL
+		}
+	}
+	// Only once uploaded: exports that failed to upload stay in h/
+	if keptForOut && exportURL != "" {
+		removeLocalExports(logger, c.cfg.DataDir, result.QuestionTS, question)
+	}
+
+	summary := newRunSummary(question, result, winner, saved, err)
+	if req, reqErr := database.GetRequest(ctx, result.RequestID); reqErr == nil && req != nil {
+		summary.Cost = req.TotalCost
+		if err == nil && req.ErrorCount > 0 {
+			summary.Status = "partial"
+		}
+	}
+	summary.OverBudget = opts.maxCost > 0 && summary.Cost > opts.maxCost
+
+	if opts.notifyCmd != "" || opts.desktop {
+		notify(logger, opts.notifyCmd, opts.desktop, summary)
+	}
+	if c.jsonOutput {
+		if printErr := printJSON(summary); printErr != nil && err == nil {
+			err = printErr
+		}
+	} else if err == nil {
+		fmt.Printf("\nSaved to %s\n", saved)
+	}
+
+	switch {
+	case err != nil:
+		return err
+	case summary.OverBudget:
+		return exitError{code: exitBudget, err: fmt.Errorf("run cost $%.4f, over --max-cost $%.4f", summary.Cost, opts.maxCost)}
+	case summary.Status == "partial":
+		return exitError{code: exitPartial, err: errors.New("run finished, but some model calls failed")}
+	}
+	return nil
+}
+
+// checkPicks rejects --models entries that are neither a family nor a variant
+func checkPicks(picks []string) error {
+	for _, pick := range picks {
+		if _, ok := models.ModelFamilies[pick]; !ok && models.FamilyForVariant(pick) == "" {
+			return usageError("unknown model %q", pick)
+		}
+	}
+	return nil
+}
+
+// completeModels completes --models with family IDs and variant names, after any already listed
+func completeModels(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix = toComplete[:i+1]
+	}
+
+	var names []string
+	for familyID, family := range models.ModelFamilies {
+		names = append(names, prefix+familyID)
+		for variant := range family.Variants {
+			names = append(names, prefix+variant)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// saveResult copies the run's export to out, or just returns where the HTML export is when out is empty
+func saveResult(ctx context.Context, database *db.DB, dataDir string, result server.AskResult, question, out string) (string, error) {
+	if out == "" {
+		return filepath.Join(dataDir, htmlexport.OutputPath(result.QuestionTS, htmlexport.Slug(question), "html")), nil
+	}
+
+	var data []byte
+	if filepath.Ext(out) == ".json" {
+		doc, err := jsonexport.Build(ctx, database, result.RequestID)
+		if err != nil {
+			return "", err
+		}
+		if data, err = json.MarshalIndent(doc, "", "  "); err != nil {
+			return "", err
+		}
+	} else {
+		export := filepath.Join(dataDir, htmlexport.OutputPath(result.QuestionTS, htmlexport.Slug(question), strings.TrimPrefix(filepath.Ext(out), ".")))
+		var err error
+		if data, err = os.ReadFile(export); err != nil {
+			return "", fmt.Errorf("failed to read export: %w", err)
+		}
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+// removeLocalExports deletes the local copies of a run's uploaded exports
+func removeLocalExports(logger *slog.Logger, dataDir string, questionTS int64, question string) {
+	slug := htmlexport.Slug(question)
+	for _, ext := range []string{"svg", "html", "md"} {
+		path := filepath.Join(dataDir, htmlexport.OutputPath(questionTS, slug, ext))
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("failed to remove local export", slog.String("path", path), slog.Any("error", err))
+		}
+	}
+}
+
+// printProgress writes a one-line summary of the run messages worth following in a terminal
+func printProgress(w io.Writer, message map[string]any) {
+	switch message["type"] {
+	case "accepted":
+		fmt.Fprintf(w, "Request %v\n", message["request_id"])
+	case "queue":
+		fmt.Fprintf(w, "Waiting in queue (position %v)\n", message["position"])
+	case "round_start":
+		fmt.Fprintf(w, "\nRound %v/%v\n", message["round"], message["total"])
+	case "response":
+		fmt.Fprintf(w, "  ✓ %v answered (%v tokens, $%.4f)\n", message["model"], message["tokens_out"], message["cost"])
+	case "error":
+		if model, ok := message["model"]; ok {
+			fmt.Fprintf(w, "  ✗ %v failed: %v\n", model, message["error"])
+		}
+	case "fallback":
+		fmt.Fprintf(w, "  ↪ %v switched from %v to %v\n", message["model"], message["from"], message["to"])
+	case "stall":
+		elapsed, _ := message["elapsed_ms"].(int64)
+		p95, _ := message["p95_ms"].(int64)
+		fmt.Fprintf(w, "  ⏳ %v is taking %ds, over twice its usual %ds (Ctrl-C to stop)\n", message["variant"], elapsed/1000, p95/1000)
+	case "converged":
+		fmt.Fprintf(w, "Answers converged after round %v (%v), skipping the rest\n", message["round"], message["reason"])
+	case "ranking_start":
+		fmt.Fprintln(w, "\nRanking answers…")
+	case "verification_start":
+		fmt.Fprintf(w, "Fact-checking %v's answer…\n", message["model"])
+	case "export":
+		switch message["status"] {
+		case orchestrator.ExportRetrying:
+			fmt.Fprintf(w, "  ↻ export failed, retrying: %v\n", message["error"])
+		case orchestrator.ExportFailed:
+			fmt.Fprintf(w, "  ✗ export failed: %v\n", message["error"])
+		}
+	case "winner":
+		for _, medal := range []struct{ label, key string }{{"🥇", "gold"}, {"🥈", "silver"}, {"🥉", "bronze"}} {
+			if ids, _ := message[medal.key].([]string); len(ids) > 0 {
+				fmt.Fprintf(w, "%s %s\n", medal.label, strings.Join(ids, ", "))
+			}
+		}
+		if verdict, ok := message["verdict"].(*ranking.Verdict); ok && verdict != nil && verdict.Justification != "" {
+			fmt.Fprintf(w, "\n⚖️  %s: %s\n", verdict.Judge, verdict.Justification)
+		}
+		if synth, ok := message["synthesis"].(*orchestrator.Synthesis); ok && synth != nil {
+			fmt.Fprintf(w, "\n🧩 Merged by %s:\n\n%s\n", synth.Synthesizer, synth.Answer)
+		}
+		if reply, ok := message["answer"].(types.Reply); ok && reply.Answer != "" {
+			fmt.Fprintf(w, "\n%s\n", reply.Answer)
+		}
+		if verifications, ok := message["verification"].([]db.Verification); ok {
+			printVerification(w, verifications)
+		}
+		if awaiting, _ := message["awaiting_human"].(bool); awaiting {
+			fmt.Fprintf(w, "\nThe judges' pick is provisional: POST /requests/%v/winner to pick the winner\n", message["request_id"])
+		}
+	}
+}
+
+// printVerification lists the winning answer's claims that a verifier didn't find supported
+func printVerification(w io.Writer, verifications []db.Verification) {
+	var flagged []string
+	checked := 0
+	for _, v := range verifications {
+		if v.Error == "" {
+			checked++
+		}
+		for _, c := range v.Claims {
+			if c.Status == shared.ClaimSupported {
+				continue
+			}
+			line := fmt.Sprintf("  %s (%s, %s)", c.Text, c.Status, v.VerifierName)
+			if c.Note != "" {
+				line += ": " + c.Note
+			}
+			flagged = append(flagged, line)
+		}
+	}
+	switch {
+	case len(verifications) == 0:
+		return
+	case checked == 0:
+		fmt.Fprintln(w, "\n✗ Every verifier failed to fact-check the answer")
+		return
+	case len(flagged) == 0:
+		fmt.Fprintf(w, "\n✓ No claims flagged by %d verifiers\n", checked)
+		return
+	}
+	fmt.Fprintf(w, "\n⚠️  %d flagged claims:\n%s\n", len(flagged), strings.Join(flagged, "\n"))
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meedamian/fat/internal/benchmark"
+	"github.com/meedamian/fat/internal/constants"
+	"github.com/meedamian/fat/internal/server"
+	"github.com/meedamian/fat/web"
+)
+
+// benchOptions are the flags of `fat bench`
+type benchOptions struct {
+	questions string
+	rounds    int
+	models    string
+	tag       string
+	out       string
+}
+
+func newBenchCommand(c *cli) *cobra.Command {
+	var opts benchOptions
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Run a file of questions one by one and report each model's win rate, cost and latency",
+		Long: `Run every question in --questions (one per line, # for comments; the sample questions by
+default) one after another under --tag, then report each model's win rate, answer cost and latency
+across them. The report is printed, and written to --out as .csv or .json.`,
+		Args: noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.bench(opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.questions, "questions", "", "file with one question per line (default: the sample questions)")
+	flags.IntVar(&opts.rounds, "rounds", 3, "number of discussion rounds per question (3-10)")
+	flags.StringVar(&opts.models, "models", "", "comma-separated families or variants to ask (default: every family)")
+	flags.StringVar(&opts.tag, "tag", "", "question set the runs are filed under (default: bench-<date>-<time>)")
+	flags.StringVar(&opts.out, "out", "", "file to write the report to (.csv or .json)")
+	cmd.RegisterFlagCompletionFunc("models", completeModels)
+	cmd.RegisterFlagCompletionFunc("out", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"csv", "json"}, cobra.ShellCompDirectiveFilterFileExt
+	})
+	return cmd
+}
+
+// bench runs the question set, carrying on past failed questions, and reports on the runs that finished
+func (c *cli) bench(opts benchOptions) error {
+	if opts.rounds < 3 || opts.rounds > 10 {
+		return usageError("invalid --rounds value %d: must be between 3 and 10", opts.rounds)
+	}
+	switch ext := filepath.Ext(opts.out); ext {
+	case "", ".csv", ".json":
+	default:
+		return usageError("invalid --out extension %q: must be .csv or .json", ext)
+	}
+	picks := splitList(opts.models)
+	if err := checkPicks(picks); err != nil {
+		return err
+	}
+
+	questions := constants.SampleQuestions
+	if opts.questions != "" {
+		f, err := os.Open(opts.questions)
+		if err != nil {
+			return usageError("invalid --questions file: %v", err)
+		}
+		questions, err = benchmark.ReadQuestions(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read questions: %w", err)
+		}
+	}
+	if len(questions) == 0 {
+		return usageError("no questions to run")
+	}
+	tag := opts.tag
+	if tag == "" {
+		tag = "bench-" + time.Now().Format("20060102-1504")
+	}
+
+	logger, err := c.logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+	database, err := c.openStore(logger)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, database)
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	srv := server.New(logger, c.cfg, database, web.Static)
+	if !c.jsonOutput {
+		fmt.Printf("Running %d questions as %s\n", len(questions), tag)
+	}
+	failed := 0
+	for i, question := range questions {
+		start := time.Now()
+		var winner any
+		_, err := srv.Ask(ctx, question, opts.rounds, picks, tag, func(message map[string]any) {
+			if message["type"] == "winner" {
+				winner = message["model"]
+			}
+		})
+		if ctx.Err() != nil {
+			break
+		}
+		if c.jsonOutput {
+			continue
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("[%d/%d] ✗ %s: %v\n", i+1, len(questions), truncate(question, 60), err)
+			continue
+		}
+		fmt.Printf("[%d/%d] %s → %v (%s)\n", i+1, len(questions), truncate(question, 60), winner, time.Since(start).Round(time.Second))
+	}
+
+	report, err := benchmark.Suite(ctx, database, tag)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return exitError{code: exitProvider, err: err}
+	}
+
+	if opts.out != "" {
+		var buf bytes.Buffer
+		if filepath.Ext(opts.out) == ".csv" {
+			err = report.WriteCSV(&buf)
+		} else {
+			enc := json.NewEncoder(&buf)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(report)
+		}
+		if err == nil {
+			err = os.WriteFile(opts.out, buf.Bytes(), 0644)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.jsonOutput {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		printSuiteReport(os.Stdout, report)
+		if opts.out != "" {
+			fmt.Printf("\nSaved to %s\n", opts.out)
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case failed > 0:
+		return exitError{code: exitPartial, err: fmt.Errorf("%d of %d questions failed", failed, len(questions))}
+	case report.Won < report.Runs:
+		return exitError{code: exitPartial, err: errors.New("some runs ended without a winner")}
+	}
+	for _, m := range report.Models {
+		if m.Errors > 0 {
+			return exitError{code: exitPartial, err: errors.New("suite finished, but some model calls failed")}
+		}
+	}
+	return nil
+}
+
+// printSuiteReport writes the report as a table, best win rate first
+func printSuiteReport(w io.Writer, report *benchmark.SuiteReport) {
+	fmt.Fprintf(w, "\n%d runs, %d with a winner, $%.4f total ($%.4f per run, %s on average)\n\n",
+		report.Runs, report.Won, report.TotalCost, report.MeanCost, (time.Duration(report.MeanLatencyMs) * time.Millisecond).Round(time.Second))
+	fmt.Fprintf(w, "%-32s %6s %6s %8s %10s %8s %10s\n", "MODEL", "RUNS", "WINS", "WIN %", "COST", "ERRORS", "LATENCY")
+	for _, m := range report.Models {
+		fmt.Fprintf(w, "%-32s %6d %6d %7.1f%% %10s %8d %10s\n",
+			m.ModelName, m.Runs, m.Wins, 100*m.WinRate, fmt.Sprintf("$%.4f", m.Cost), m.Errors, (time.Duration(m.MeanLatencyMs) * time.Millisecond).Round(100*time.Millisecond))
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meedamian/fat/internal/apikeys"
+)
+
+// encryptKeys merges keys.json into keys.json.enc, encrypted with the passphrase from
+// FAT_KEYS_PASSPHRASE, and removes the plaintext file once the result decrypts correctly
+func (c *cli) encryptKeys(_ *cobra.Command, _ []string) error {
+	logger, err := c.logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	passphrase := os.Getenv(apikeys.PassphraseEnvVar)
+	if passphrase == "" {
+		return usageError("set %s to the passphrase for %s", apikeys.PassphraseEnvVar, apikeys.EncryptedKeysFile)
+	}
+
+	data, err := os.ReadFile(apikeys.KeysFile)
+	if err != nil {
+		return err
+	}
+	var keys map[string]string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", apikeys.KeysFile, err)
+	}
+
+	if err := apikeys.SaveEncrypted(apikeys.EncryptedKeysFile, passphrase, keys); err != nil {
+		return err
+	}
+
+	// Only drop the plaintext once every key can be read back
+	stored, err := apikeys.ReadEncrypted(apikeys.EncryptedKeysFile, passphrase)
+	if err != nil {
+		return err
+	}
+	for familyID, key := range keys {
+		if stored[familyID] != key {
+			return fmt.Errorf("key for %s did not round-trip, keeping %s", familyID, apikeys.KeysFile)
+		}
+	}
+	if err := os.Remove(apikeys.KeysFile); err != nil {
+		return err
+	}
+
+	logger.Info("api keys encrypted",
+		slog.String("file", apikeys.EncryptedKeysFile),
+		slog.Int("keys", len(keys)),
+		slog.String("removed", apikeys.KeysFile))
+	if c.jsonOutput {
+		return printJSON(map[string]any{
+			"file":    apikeys.EncryptedKeysFile,
+			"keys":    len(keys),
+			"removed": apikeys.KeysFile,
+		})
+	}
+	return nil
+}
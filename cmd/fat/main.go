@@ -1,71 +1,363 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
 
 	"github.com/meedamian/fat/internal/apikeys"
 	"github.com/meedamian/fat/internal/archiver"
 	"github.com/meedamian/fat/internal/config"
+	"github.com/meedamian/fat/internal/constants"
 	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/extras"
+	"github.com/meedamian/fat/internal/faults"
+	"github.com/meedamian/fat/internal/generation"
+	"github.com/meedamian/fat/internal/headers"
 	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/personas"
 	"github.com/meedamian/fat/internal/server"
 	"github.com/meedamian/fat/internal/types"
+	"github.com/meedamian/fat/internal/utils"
 	"github.com/meedamian/fat/web"
 )
 
 var BuildTime = "dev"
 
+// Exit codes, kept stable for scripts and CI
+const (
+	exitOK       = 0
+	exitFailure  = 1 // Anything not covered below, including server shutdowns via /die
+	exitUsage    = 2 // Bad arguments or flags
+	exitBudget   = 3 // The run cost more than --max-cost
+	exitProvider = 4 // The run failed, e.g. no model could answer
+	exitPartial  = 5 // The run finished, but some model calls failed
+)
+
+// exitError ends the process with code, printing err first if there is one
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit code %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e exitError) Unwrap() error { return e.err }
+
+// usageError is an exitUsage error
+func usageError(format string, args ...any) error {
+	return exitError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+// cli holds what every command shares
+type cli struct {
+	cfg        config.Config
+	cfgErr     error // Why the environment couldn't be loaded; only `fat config validate` runs despite it
+	jsonOutput bool  // --json: machine-readable output on stdout
+}
+
 func main() {
-	// Load configuration
-	cfg, err := config.Load()
+	os.Exit(run(os.Args[1:]))
+}
+
+// run executes the command line and returns the process exit code
+func run(args []string) int {
+	cfg, cfgErr := config.Load()
+	root := newRootCommand(&cli{cfg: cfg, cfgErr: cfgErr})
+	root.SetArgs(args)
+	err := root.Execute()
+	if err == nil {
+		return exitOK
+	}
+
+	var exit exitError
+	if !errors.As(err, &exit) {
+		exit = exitError{code: exitFailure, err: err}
+	}
+	if exit.err != nil {
+		fmt.Fprintln(os.Stderr, exit.err)
+	}
+	return exit.code
+}
+
+// newRootCommand builds the command tree; without a subcommand fat runs the server
+func newRootCommand(c *cli) *cobra.Command {
+	root := &cobra.Command{
+		Use:           "fat",
+		Short:         "Multi-agent AI collaboration: models answer, discuss and rank each other",
+		Version:       BuildTime,
+		Args:          noArgs,
+		RunE:          c.serve,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			if c.cfgErr != nil {
+				return fmt.Errorf("failed to load config: %w", c.cfgErr)
+			}
+			return nil
+		},
+	}
+	root.PersistentFlags().BoolVar(&c.jsonOutput, "json", false, "machine-readable output: JSON results on stdout, JSON logs")
+	root.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return exitError{code: exitUsage, err: err}
+	})
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "serve",
+			Short: "Run the web server (the default)",
+			Args:  noArgs,
+			RunE:  c.serve,
+		},
+		newAskCommand(c),
+		newTournamentCommand(c),
+		newBenchCommand(c),
+		newTUICommand(c),
+		newConfigCommand(c),
+		newSelfTestCommand(c),
+		newPipelineCommand(c),
+		newExportSiteCommand(c),
+		newAccessKeysCommand(c),
+		&cobra.Command{
+			Use:   "encrypt-keys",
+			Short: "Move keys.json into the encrypted key store (needs FAT_KEYS_PASSPHRASE)",
+			Args:  noArgs,
+			RunE:  c.encryptKeys,
+		},
+	)
+	return root
+}
+
+// noArgs rejects positional arguments as a usage error
+func noArgs(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return usageError("unknown command %q for %q", args[0], cmd.CommandPath())
+	}
+	return nil
+}
+
+// logger creates the logger for a command; commands that own stdout log to stderr
+func (c *cli) logger(out *os.File) (*slog.Logger, error) {
+	logger, err := config.NewLoggerTo(out, c.cfg, c.jsonOutput)
 	if err != nil {
-		panic(fmt.Errorf("failed to load config: %w", err))
+		return nil, fmt.Errorf("failed to create logger: %w", err)
 	}
+	return logger, nil
+}
 
-	// Initialize logger
-	logger, err := config.NewLogger(cfg.LogLevel)
+// printJSON writes v to stdout as indented JSON
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// signalContext is cancelled on SIGINT/SIGTERM; a second signal kills the process right away
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
+// serve runs the web server until it's shut down, exiting with the code the shutdown asked for
+func (c *cli) serve(_ *cobra.Command, _ []string) error {
+	logger, err := c.logger(os.Stdout)
 	if err != nil {
-		panic(fmt.Errorf("failed to create logger: %w", err))
+		return err
 	}
 
 	// Log build info
 	logger.Info("starting application", slog.String("build_time", BuildTime))
 
+	database, err := c.openStore(logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	// Start background archiver for answers/ directory
+	archiver.StartBackgroundArchiver(c.cfg.DataDir, logger)
+
+	// Create and run server with embedded static files
+	srv := server.New(logger, c.cfg, database, web.Static)
+	runErr := srv.Run(ctx)
+	if runErr != nil {
+		logger.Error("server exited with error", slog.Any("error", runErr))
+	}
+
+	if err := database.Close(); err != nil {
+		logger.Warn("failed to close database", slog.Any("error", err))
+	}
+
+	// A server that never started, e.g. as its port was taken, fails; the exit code only covers clean shutdowns
+	if runErr != nil {
+		return exitError{code: exitFailure, err: runErr}
+	}
+	if code := srv.ExitCode(); code != 0 {
+		return exitError{code: code}
+	}
+	return nil
+}
+
+// openStore loads the models' keys and options, then opens and prepares the database
+func (c *cli) openStore(logger *slog.Logger) (*db.DB, error) {
+	cfg := c.cfg
+
+	// Apply default model selection saved by the setup flow
+	if err := models.LoadDefaults(cfg.DefaultsFile); err != nil {
+		logger.Warn("failed to load default models", slog.String("file", cfg.DefaultsFile), slog.Any("error", err))
+	}
+
 	// Load API keys
 	logger.Info("loading API keys")
 	allModels := make([]*types.ModelInfo, 0, len(models.AllModels))
 	for _, mi := range models.AllModels {
-		mi.Logger = logger.With("model", mi.Name)
+		mi.Logger = logger.With(config.SubsystemKey, config.SubsystemProviders, "model", mi.Name)
 		mi.RequestTimeout = cfg.ModelRequestTimeout
+		mi.Safety = cfg.GeminiSafety
+		mi.ThinkingBudget = models.ThinkingBudget(mi.ID, mi.Name, cfg.ClaudeThinkingBudget)
+		mi.Structured = cfg.Structured(mi.ID, mi.Name)
 		allModels = append(allModels, mi)
 	}
-	apikeys.Load(allModels)
+	if err := apikeys.Load(allModels); err != nil {
+		return nil, fmt.Errorf("failed to load API keys: %w", err)
+	}
 
-	// Log warnings for missing keys
+	// Point at the setup flow instead of failing requests later
+	var missing []string
 	for _, mi := range allModels {
 		if mi.APIKey == "" {
-			mi.Logger.Warn("api key missing")
+			missing = append(missing, mi.ID)
 		}
 	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		logger.Warn("api keys missing, add them at /setup",
+			slog.Any("families", missing),
+			slog.String("setup", setupURL(cfg.ServerAddress)))
+	}
 	logger.Info("api keys loaded")
 
+	// Resilience testing: make provider calls slow, fail or break off on purpose
+	faults.Set(cfg.Faults)
+	if cfg.Faults.Enabled() {
+		logger.Warn("injecting faults into provider calls", slog.String("faults", cfg.Faults.String()))
+	}
+
+	// Load optional agent personas
+	if err := personas.Load(cfg.PersonasFile, allModels); err != nil {
+		logger.Warn("failed to load personas", slog.String("file", cfg.PersonasFile), slog.Any("error", err))
+	}
+
+	// Load optional provider-specific request options
+	if err := extras.Load(cfg.ExtrasFile, allModels); err != nil {
+		logger.Warn("failed to load extras", slog.String("file", cfg.ExtrasFile), slog.Any("error", err))
+	}
+
+	// Load optional sampling parameters
+	if err := generation.Load(cfg.GenerationFile, allModels); err != nil {
+		logger.Warn("failed to load generation parameters", slog.String("file", cfg.GenerationFile), slog.Any("error", err))
+	}
+
+	// Load optional extra request headers, e.g. for an LLM gateway
+	if err := headers.Load(cfg.HeadersFile, allModels); err != nil {
+		logger.Warn("failed to load headers", slog.String("file", cfg.HeadersFile), slog.Any("error", err))
+	}
+
+	// Conversation logs go to the data directory, next to the exports
+	utils.SetDataDir(cfg.DataDir)
+
 	// Initialize database
-	logger.Info("initializing database")
-	database, err := db.New("fat.db", logger)
+	logger.Info("initializing database", slog.String("path", cfg.DBPath))
+	database, err := c.openDB(logger)
 	if err != nil {
-		logger.Error("failed to initialize database", slog.Any("error", err))
-		panic(fmt.Errorf("failed to initialize database: %w", err))
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
-	defer database.Close()
 	logger.Info("database initialized")
 
-	// Start background archiver for answers/ directory
-	archiver.StartBackgroundArchiver(logger)
+	// Seed the random question pool on first run; afterwards it is managed via /admin/questions
+	if added, err := database.SeedSampleQuestions(context.Background(), constants.SampleQuestions); err != nil {
+		logger.Warn("failed to seed sample questions", slog.Any("error", err))
+	} else if added > 0 {
+		logger.Info("sample questions seeded", slog.Int("count", added))
+	}
 
-	// Create and run server with embedded static files
-	srv := server.New(logger, cfg, database, web.Static)
-	if err := srv.Run(); err != nil {
-		logger.Error("server exited with error", slog.Any("error", err))
+	// Keep a history of list prices so past costs can be recomputed at the rates in force back then
+	if recorded, err := database.RecordPrices(context.Background(), catalogPrices(time.Now())); err != nil {
+		logger.Warn("failed to record prices", slog.Any("error", err))
+	} else if recorded > 0 {
+		logger.Info("price changes recorded", slog.Int("count", recorded))
+	}
+
+	return database, nil
+}
+
+// closeStore closes the database of a command that ran a question in-process
+func closeStore(logger *slog.Logger, database *db.DB) {
+	if err := database.Close(); err != nil {
+		logger.Warn("failed to close database", slog.Any("error", err))
+	}
+}
+
+// openDB opens the database at FAT_DB_PATH, creating its directory first
+func (c *cli) openDB(logger *slog.Logger) (*db.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(c.cfg.DBPath), 0755); err != nil {
+		return nil, err
+	}
+	return db.New(c.cfg.DBPath, logger.With(config.SubsystemKey, config.SubsystemDB))
+}
+
+// setupURL returns the address of the setup page for a listen address like ":4444"
+func setupURL(addr string) string {
+	return localURL("http", addr, "/setup")
+}
+
+// localURL returns the URL of a path on this machine's server for a listen address like ":4444"
+func localURL(scheme, addr, path string) string {
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	return scheme + "://" + addr + path
+}
+
+// catalogPrices lists the list rate of every priced variant, effective from the rate's timestamp or now
+func catalogPrices(now time.Time) []db.PriceSnapshot {
+	var snapshots []db.PriceSnapshot
+	for familyID, family := range models.ModelFamilies {
+		for name, variant := range family.Variants {
+			if variant.Rate.In == 0 && variant.Rate.Out == 0 {
+				continue // Not priced yet
+			}
+			effective := now
+			if variant.Rate.TS > 0 {
+				effective = time.Unix(variant.Rate.TS, 0)
+			}
+			snapshots = append(snapshots, db.PriceSnapshot{
+				ModelID:       familyID,
+				ModelName:     name,
+				RateIn:        variant.Rate.In,
+				RateOut:       variant.Rate.Out,
+				EffectiveFrom: effective,
+			})
+		}
 	}
+	return snapshots
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/meedamian/fat/internal/server"
+)
+
+// notifyTimeout bounds the notification command and the desktop notifier
+const notifyTimeout = 30 * time.Second
+
+// runSummary is what the notification command reads on stdin when a run ends
+type runSummary struct {
+	RequestID string         `json:"request_id,omitempty"`
+	Question  string         `json:"question"`
+	Status    string         `json:"status"` // "complete", "partial" (some model calls failed) or "failed"
+	Error     string         `json:"error,omitempty"`
+	Gold      []string       `json:"gold,omitempty"`
+	Silver    []string       `json:"silver,omitempty"`
+	Bronze    []string       `json:"bronze,omitempty"`
+	Metrics   map[string]any `json:"metrics,omitempty"` // Duration, tokens and cost of the run
+	Output    string         `json:"output,omitempty"`  // Where the result was saved
+
+	Cost       float64 `json:"cost"`                  // Total cost of the run in dollars
+	OverBudget bool    `json:"over_budget,omitempty"` // Cost more than --max-cost
+}
+
+// newRunSummary summarizes a run from its winner message (nil if it never got there) and its error
+func newRunSummary(question string, result server.AskResult, winner map[string]any, output string, err error) runSummary {
+	summary := runSummary{
+		RequestID: result.RequestID,
+		Question:  question,
+		Status:    "complete",
+		Output:    output,
+	}
+	if err != nil {
+		summary.Status = "failed"
+		summary.Error = err.Error()
+	}
+	if winner != nil {
+		summary.Gold, _ = winner["gold"].([]string)
+		summary.Silver, _ = winner["silver"].([]string)
+		summary.Bronze, _ = winner["bronze"].([]string)
+		summary.Metrics, _ = winner["metrics"].(map[string]any)
+	}
+	return summary
+}
+
+// notify runs command with the summary as JSON on stdin and shows a desktop notification if desktop is set
+// Failures are only logged, so a broken hook never fails the run.
+func notify(logger *slog.Logger, command string, desktop bool, summary runSummary) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	if command != "" {
+		payload, err := json.Marshal(summary)
+		if err != nil {
+			logger.Warn("failed to encode run summary", slog.Any("error", err))
+			return
+		}
+
+		cmd := shellCommand(ctx, command)
+		cmd.Stdin = bytes.NewReader(payload)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			logger.Warn("notification command failed", slog.String("command", command), slog.Any("error", err))
+		}
+	}
+
+	if desktop {
+		title := "fat: " + truncate(summary.Question, 60)
+		body := "Gold: " + strings.Join(summary.Gold, ", ")
+		if summary.Status == "failed" {
+			body = "Failed: " + summary.Error
+		}
+		if err := desktopNotify(ctx, title, body); err != nil {
+			logger.Warn("desktop notification failed", slog.Any("error", err))
+		}
+	}
+}
+
+// shellCommand runs command through the platform's shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// desktopNotify shows a notification with osascript on macOS or notify-send elsewhere
+func desktopNotify(ctx context.Context, title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", body, title)
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		return fmt.Errorf("desktop notifications are not supported on %s, use --notify-cmd", runtime.GOOS)
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=fat", title, body)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil && len(bytes.TrimSpace(out)) > 0 {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return err
+}
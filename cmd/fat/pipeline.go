@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meedamian/fat/internal/pipeline"
+	"github.com/meedamian/fat/internal/server"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+	"github.com/meedamian/fat/web"
+)
+
+// pipelineReport is what `fat pipeline run --json` prints
+type pipelineReport struct {
+	Pipeline string            `json:"pipeline"`
+	Stages   []pipeline.Result `json:"stages"`
+	Partial  bool              `json:"partial"` // Some model calls failed in a stage that still finished
+	Error    string            `json:"error,omitempty"`
+}
+
+func newPipelineCommand(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pipeline",
+		Short: "Chain runs defined in a YAML file, each stage building on the answers before it",
+		Args:  noArgs,
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   `run pipeline.yaml "input"`,
+			Short: "Run every stage of a pipeline in order",
+			Long: `Run every stage of a pipeline in order, each with its own panel of models. A stage's question is
+rendered from the input and the winning answers of earlier stages, and its winning answer feeds the next.`,
+			Args: func(_ *cobra.Command, args []string) error {
+				if len(args) == 0 {
+					return usageError(`usage: fat pipeline run pipeline.yaml "input"`)
+				}
+				return nil
+			},
+			RunE: func(_ *cobra.Command, args []string) error {
+				return c.runPipeline(args[0], strings.TrimSpace(strings.Join(args[1:], " ")))
+			},
+		},
+		&cobra.Command{
+			Use:   "validate pipeline.yaml",
+			Short: "Check a pipeline definition without running it",
+			Args: func(_ *cobra.Command, args []string) error {
+				if len(args) != 1 {
+					return usageError("usage: fat pipeline validate pipeline.yaml")
+				}
+				return nil
+			},
+			RunE: func(_ *cobra.Command, args []string) error {
+				p, err := pipeline.Load(args[0])
+				if err != nil {
+					return exitError{code: exitFailure, err: err}
+				}
+				if c.jsonOutput {
+					return printJSON(p)
+				}
+				for i, stage := range p.Stages {
+					fmt.Printf("%d. %s: %d rounds, models: %s\n", i+1, stage.Name, stage.Rounds, strings.Join(stageModels(stage), ", "))
+				}
+				return nil
+			},
+		},
+	)
+	return cmd
+}
+
+// runPipeline runs a pipeline to completion and exits with the code matching how it went
+func (c *cli) runPipeline(path, input string) error {
+	p, err := pipeline.Load(path)
+	if err != nil {
+		return usageError("%v", err)
+	}
+
+	logger, err := c.logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+	database, err := c.openStore(logger)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, database)
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	srv := server.New(logger, c.cfg, database, web.Static)
+	report := pipelineReport{Pipeline: p.Name}
+
+	ask := func(ctx context.Context, stage pipeline.Stage, question string) (pipeline.Result, error) {
+		var winner map[string]any
+		result, err := srv.Ask(ctx, question, stage.Rounds, stage.Models, "", func(message map[string]any) {
+			if message["type"] == "winner" {
+				winner = message
+			}
+			if !c.jsonOutput {
+				printProgress(os.Stdout, message)
+			}
+		})
+		if err != nil {
+			return pipeline.Result{RequestID: result.RequestID}, err
+		}
+
+		if req, err := database.GetRequest(ctx, result.RequestID); err == nil && req != nil && req.ErrorCount > 0 {
+			report.Partial = true
+		}
+		out := pipeline.Result{RequestID: result.RequestID}
+		out.Winner, _ = winner["model"].(string)
+		if reply, ok := winner["answer"].(types.Reply); ok {
+			attribution, _ := winner["attribution"].(string)
+			out.Answer = shared.WithoutAttribution(reply.Answer, attribution)
+		}
+		if out.Answer == "" {
+			return out, errors.New("the winner has no answer to pass on")
+		}
+		return out, nil
+	}
+
+	report.Stages, err = p.Run(ctx, input, ask, func(i int, stage pipeline.Stage, question string) {
+		if !c.jsonOutput {
+			fmt.Printf("\n━━ Stage %d/%d: %s (%s) ━━\n%s\n", i+1, len(p.Stages), stage.Name, strings.Join(stageModels(stage), ", "), question)
+		}
+	})
+	if err != nil {
+		report.Error = err.Error()
+		if ctx.Err() == nil {
+			err = exitError{code: exitProvider, err: err}
+		}
+	}
+
+	if c.jsonOutput {
+		if printErr := printJSON(report); printErr != nil && err == nil {
+			err = printErr
+		}
+	} else if err == nil {
+		fmt.Printf("\nPipeline %q finished all %d stages\n", p.Name, len(report.Stages))
+	}
+
+	switch {
+	case err != nil:
+		return err
+	case report.Partial:
+		return exitError{code: exitPartial, err: errors.New("pipeline finished, but some model calls failed")}
+	}
+	return nil
+}
+
+// stageModels lists a stage's panel for display
+func stageModels(stage pipeline.Stage) []string {
+	if len(stage.Models) == 0 {
+		return []string{"every family"}
+	}
+	return stage.Models
+}
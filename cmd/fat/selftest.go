@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meedamian/fat/internal/apikeys"
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/htmlexport"
+	"github.com/meedamian/fat/internal/jsonexport"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/server"
+	"github.com/meedamian/fat/web"
+)
+
+// selfTestQuestion costs each provider a handful of tokens
+const selfTestQuestion = "This is a connectivity self-test. Reply with OK as your answer and nothing else."
+
+// selfTestTag files self-test runs apart from real questions
+const selfTestTag = "selftest"
+
+// providerCheck is one row of the self-test matrix
+type providerCheck struct {
+	Family    string `json:"family"`
+	Variant   string `json:"variant"`
+	Key       bool   `json:"key"`   // A key is configured
+	Call      bool   `json:"call"`  // The provider answered
+	Parse     bool   `json:"parse"` // The reply had an answer
+	Saved     bool   `json:"saved"` // The reply is in the database
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (p providerCheck) passed() bool {
+	return p.Key && p.Call && p.Parse && p.Saved
+}
+
+// selfTestReport is what `fat selftest` found
+type selfTestReport struct {
+	Passed    bool            `json:"passed"`
+	RequestID string          `json:"request_id,omitempty"`
+	Providers []providerCheck `json:"providers"`
+	Saved     bool            `json:"saved"`            // The request is in the database
+	Exports   map[string]bool `json:"exports"`          // Export format -> generated
+	Errors    []string        `json:"errors,omitempty"` // Failures outside a single provider
+}
+
+func newSelfTestCommand(c *cli) *cobra.Command {
+	var picks string
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Run one tiny round across the configured providers and print a pass/fail matrix",
+		Long: `Ask every provider with a key to reply "OK" in a single round, then check the replies were parsed,
+saved to the database and exported. Costs a few hundred tokens per provider; the run is tagged "selftest".`,
+		Args: noArgs,
+		RunE: func(*cobra.Command, []string) error {
+			return c.selfTest(splitList(picks))
+		},
+	}
+	cmd.Flags().StringVar(&picks, "models", "", "comma-separated families or variants to test (default: every family with a key)")
+	cmd.RegisterFlagCompletionFunc("models", completeModels)
+	return cmd
+}
+
+// selfTest runs the self-test round and exits with the code matching how it went
+func (c *cli) selfTest(picks []string) error {
+	if err := checkPicks(picks); err != nil {
+		return err
+	}
+
+	logger, err := c.logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+	database, err := c.openStore(logger)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, database)
+
+	if len(picks) == 0 {
+		for familyID := range models.ModelFamilies {
+			if apikeys.Source(familyID) != "" {
+				picks = append(picks, familyID)
+			}
+		}
+		if len(picks) == 0 {
+			return exitError{code: exitFailure, err: errors.New("no provider has an API key, add them at " + setupURL(c.cfg.ServerAddress))}
+		}
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	if !c.jsonOutput {
+		fmt.Printf("Testing %d providers…\n\n", len(picks))
+	}
+	srv := server.New(logger, c.cfg, database, web.Static)
+	result, runErr := srv.Ask(ctx, selfTestQuestion, 1, picks, selfTestTag, func(map[string]any) {})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	report := checkSelfTest(ctx, database, c.cfg.DataDir, result, picks, runErr)
+	if c.jsonOutput {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		printSelfTest(report)
+	}
+
+	passing := 0
+	for _, p := range report.Providers {
+		if p.passed() {
+			passing++
+		}
+	}
+	switch {
+	case report.Passed:
+		return nil
+	case passing == 0:
+		return exitError{code: exitProvider, err: errors.New("self-test failed: no provider passed")}
+	case len(report.Errors) > 0:
+		return exitError{code: exitFailure, err: errors.New("self-test failed")}
+	}
+	return exitError{code: exitPartial, err: fmt.Errorf("self-test failed for %d of %d providers", len(report.Providers)-passing, len(report.Providers))}
+}
+
+// checkSelfTest reads the run back from the database and the exports under dataDir
+func checkSelfTest(ctx context.Context, database *db.DB, dataDir string, result server.AskResult, picks []string, runErr error) selfTestReport {
+	report := selfTestReport{RequestID: result.RequestID, Exports: map[string]bool{}}
+	if runErr != nil {
+		report.Errors = append(report.Errors, runErr.Error())
+	}
+	if result.RequestID == "" {
+		return report // The run never started, so there is nothing to read back
+	}
+
+	rounds, err := database.GetRoundReplies(ctx, result.RequestID)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("failed to read replies: %v", err))
+	}
+	for _, pick := range picks {
+		familyID, variant := pick, ""
+		if _, ok := models.ModelFamilies[pick]; !ok {
+			familyID, variant = models.FamilyForVariant(pick), pick
+		}
+
+		check := providerCheck{Family: familyID, Variant: variant, Key: apikeys.Source(familyID) != ""}
+		agentID := familyID
+		if _, ok := rounds[pick]; ok {
+			agentID = pick // One of several variants of its family, going by its own name
+		}
+		if round, ok := rounds[agentID][1]; ok {
+			check.Variant = round.ModelName
+			check.Saved = true
+			check.LatencyMS = round.DurationMs
+			check.Error = round.Error
+			check.Call = round.Error == ""
+			check.Parse = check.Call && strings.TrimSpace(round.Answer) != ""
+			if check.Call && !check.Parse {
+				check.Error = "reply had no answer"
+			}
+		} else if !check.Key {
+			check.Error = "no API key"
+		} else {
+			check.Error = "no reply saved"
+		}
+		report.Providers = append(report.Providers, check)
+	}
+	sort.Slice(report.Providers, func(i, j int) bool { return report.Providers[i].Family < report.Providers[j].Family })
+
+	if req, err := database.GetRequest(ctx, result.RequestID); err == nil && req != nil {
+		report.Saved = true
+	} else {
+		report.Errors = append(report.Errors, "request not saved to the database")
+	}
+
+	slug := htmlexport.Slug(selfTestQuestion)
+	for _, ext := range []string{"html", "md", "svg"} {
+		if _, err := os.Stat(filepath.Join(dataDir, htmlexport.OutputPath(result.QuestionTS, slug, ext))); err == nil {
+			report.Exports[ext] = true
+		} else {
+			report.Exports[ext] = false
+			report.Errors = append(report.Errors, fmt.Sprintf("%s export missing", ext))
+		}
+	}
+	if _, err := jsonexport.Build(ctx, database, result.RequestID); err == nil {
+		report.Exports["json"] = true
+	} else {
+		report.Exports["json"] = false
+		report.Errors = append(report.Errors, fmt.Sprintf("json export failed: %v", err))
+	}
+
+	report.Passed = len(report.Errors) == 0
+	for _, p := range report.Providers {
+		report.Passed = report.Passed && p.passed()
+	}
+	return report
+}
+
+// printSelfTest writes the pass/fail matrix for people
+func printSelfTest(report selfTestReport) {
+	mark := func(ok bool) string {
+		if ok {
+			return "✓"
+		}
+		return "✗"
+	}
+
+	fmt.Printf("%-10s %-32s %-4s %-5s %-6s %-6s %8s\n", "FAMILY", "VARIANT", "KEY", "CALL", "PARSE", "SAVED", "LATENCY")
+	for _, p := range report.Providers {
+		fmt.Printf("%-10s %-32s %-4s %-5s %-6s %-6s %7dms\n", p.Family, p.Variant, mark(p.Key), mark(p.Call), mark(p.Parse), mark(p.Saved), p.LatencyMS)
+	}
+	fmt.Println()
+
+	for _, p := range report.Providers {
+		if p.Error != "" {
+			fmt.Printf("✗ %s: %s\n", p.Family, p.Error)
+		}
+	}
+	formats := make([]string, 0, len(report.Exports))
+	for format := range report.Exports {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	exports := make([]string, 0, len(formats))
+	for _, format := range formats {
+		exports = append(exports, mark(report.Exports[format])+" "+format)
+	}
+	fmt.Printf("%s database   exports: %s\n", mark(report.Saved), strings.Join(exports, "  "))
+	for _, err := range report.Errors {
+		fmt.Printf("✗ %s\n", err)
+	}
+	if report.Passed {
+		fmt.Println("✓ self-test passed")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meedamian/fat/internal/locale"
+	"github.com/meedamian/fat/internal/site"
+)
+
+func newExportSiteCommand(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "export-site dir",
+		Short: "Render the answers archive as a static site, ready for GitHub Pages or S3",
+		Long: `Render every past question from the database into dir: a searchable index.html grouped by day and tag,
+plus a page per question under q/. The server can keep the same site up to date with FAT_SITE_DIR.`,
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return usageError("usage: fat export-site dir")
+			}
+			return nil
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return c.exportSite(args[0])
+		},
+	}
+}
+
+// exportSite writes the answers site into dir
+func (c *cli) exportSite(dir string) error {
+	logger, err := c.logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	// Only the stored history is needed, so no keys or model settings are loaded
+	database, err := c.openDB(logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer closeStore(logger, database)
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	format, err := locale.New(c.cfg.Timezone, c.cfg.Locale)
+	if err != nil {
+		return err
+	}
+	pages, err := site.Generate(ctx, database, dir, format)
+	if err != nil {
+		return err
+	}
+
+	if c.jsonOutput {
+		return printJSON(map[string]any{"dir": dir, "pages": pages})
+	}
+	fmt.Printf("Wrote %d question pages and index.html to %s\n", pages, dir)
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/htmlexport"
+	"github.com/meedamian/fat/internal/server"
+	"github.com/meedamian/fat/web"
+)
+
+// tournamentOptions are the flags of `fat tournament`
+type tournamentOptions struct {
+	models string
+	judges string
+	out    string
+}
+
+func newTournamentCommand(c *cli) *cobra.Command {
+	var opts tournamentOptions
+	cmd := &cobra.Command{
+		Use:   `tournament "question"`,
+		Short: "Pit models against each other in a single-elimination bracket",
+		Long: `Have every model in --models answer once, then pit the answers against each other in a
+single-elimination bracket, seeded in the order listed, with every match decided by the --judges.
+The bracket is exported next to the run exports and copied to --out (.md, .html or .json).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.tournament(strings.TrimSpace(strings.Join(args, " ")), opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.models, "models", "", "comma-separated families or variants to enter, best seed first")
+	flags.StringVar(&opts.judges, "judges", strings.Join(c.cfg.Judges, ","), "comma-separated families or variants judging every match")
+	flags.StringVar(&opts.out, "out", "", "file to write the bracket to (.md, .html or .json)")
+	cmd.RegisterFlagCompletionFunc("models", completeModels)
+	cmd.RegisterFlagCompletionFunc("judges", completeModels)
+	cmd.RegisterFlagCompletionFunc("out", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"md", "html", "json"}, cobra.ShellCompDirectiveFilterFileExt
+	})
+	return cmd
+}
+
+// tournament runs a bracket to completion, printing each match as it's decided
+func (c *cli) tournament(question string, opts tournamentOptions) error {
+	usage := `usage: fat tournament "question" --models gpt-5,gpt-5-mini,gpt-4.1 [--judges claude,grok] [--out bracket.md]`
+	if question == "" {
+		return usageError("%s", usage)
+	}
+	switch ext := filepath.Ext(opts.out); ext {
+	case "", ".md", ".html", ".json":
+	default:
+		return usageError("invalid --out extension %q: must be .md, .html or .json", ext)
+	}
+	entrants, jury := splitList(opts.models), splitList(opts.judges)
+	if len(entrants) < 2 {
+		return usageError("a tournament needs at least 2 --models; %s", usage)
+	}
+	if len(jury) == 0 {
+		return usageError("a tournament needs --judges, or FAT_JUDGES to be set")
+	}
+	if err := checkPicks(append(entrants, jury...)); err != nil {
+		return err
+	}
+
+	logger, err := c.logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+	database, err := c.openStore(logger)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, database)
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	srv := server.New(logger, c.cfg, database, web.Static)
+	t, err := srv.Tournament(ctx, question, entrants, jury, func(message map[string]any) {
+		if !c.jsonOutput {
+			printTournamentProgress(os.Stdout, message)
+		}
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			err = exitError{code: exitProvider, err: err}
+		}
+		return err
+	}
+
+	saved, err := saveTournament(c.cfg.DataDir, t, opts.out)
+	if err != nil {
+		return err
+	}
+	if t.Winner == "" {
+		err = exitError{code: exitProvider, err: errors.New("no model could answer")}
+	}
+	if c.jsonOutput {
+		if printErr := printJSON(t); printErr != nil && err == nil {
+			err = printErr
+		}
+	} else {
+		fmt.Printf("\nSaved to %s\n", saved)
+	}
+	return err
+}
+
+// saveTournament copies the tournament's export to out, or just returns where the HTML bracket is when out is empty
+func saveTournament(dataDir string, t *db.Tournament, out string) (string, error) {
+	if out == "" {
+		return filepath.Join(dataDir, htmlexport.TournamentPath(t.QuestionTS, t.Question, "html")), nil
+	}
+
+	var data []byte
+	if filepath.Ext(out) == ".json" {
+		var err error
+		if data, err = json.MarshalIndent(t, "", "  "); err != nil {
+			return "", err
+		}
+	} else {
+		export := filepath.Join(dataDir, htmlexport.TournamentPath(t.QuestionTS, t.Question, strings.TrimPrefix(filepath.Ext(out), ".")))
+		var err error
+		if data, err = os.ReadFile(export); err != nil {
+			return "", fmt.Errorf("failed to read export: %w", err)
+		}
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+// printTournamentProgress writes a one-line summary of the tournament messages worth following in a terminal
+func printTournamentProgress(w io.Writer, message map[string]any) {
+	switch message["type"] {
+	case "tournament_start":
+		jury, _ := message["jury"].([]string)
+		fmt.Fprintf(w, "Tournament %v: %v rounds, judged by %s\n\n", message["tournament_id"], message["rounds"], strings.Join(jury, ", "))
+	case "tournament_answer":
+		if err, _ := message["error"].(string); err != "" {
+			fmt.Fprintf(w, "  ✗ %v failed to answer and forfeits: %v\n", message["model"], err)
+		} else {
+			fmt.Fprintf(w, "  ✓ %v answered\n", message["model"])
+		}
+	case "tournament_match":
+		winner := message["winner"]
+		votes, _ := message["votes"].([]db.MatchVote)
+		switch {
+		case winner == "":
+			fmt.Fprintf(w, "Round %v: neither %v nor %v could play\n", message["round"], message["model_a"], message["model_b"])
+		case message["model_b"] == "":
+			fmt.Fprintf(w, "Round %v: %v advances on a bye\n", message["round"], winner)
+		case len(votes) == 0:
+			fmt.Fprintf(w, "Round %v: %v advances, its opponent forfeited\n", message["round"], winner)
+		default:
+			tally := 0
+			for _, v := range votes {
+				if v.Vote == winner {
+					tally++
+				}
+			}
+			fmt.Fprintf(w, "Round %v: %v vs %v → %v (%d/%d votes)\n", message["round"], message["model_a"], message["model_b"], winner, tally, len(votes))
+		}
+	case "tournament_winner":
+		if message["winner"] == "" {
+			fmt.Fprintln(w, "\nNo winner: no model could answer")
+			return
+		}
+		fmt.Fprintf(w, "\n🏆 %v ($%.4f)\n", message["winner"], message["total_cost"])
+	}
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/meedamian/fat/internal/config"
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/server"
+	"github.com/meedamian/fat/web"
+)
+
+// tuiOptions are the flags of `fat tui`
+type tuiOptions struct {
+	question string
+	rounds   int
+	models   string
+	server   string // WebSocket URL of the server to connect to
+	token    string // Bearer token or access key for servers that require one
+	embedded bool   // Run the question in this process instead
+}
+
+func newTUICommand(c *cli) *cobra.Command {
+	var opts tuiOptions
+	cmd := &cobra.Command{
+		Use:   `tui ["question"]`,
+		Short: "Follow a run in the terminal, one pane per model",
+		Long: `Follow a run in the terminal, on a running server or --embedded in this process.
+The question is asked for on stdin when it isn't given. With --json every run message is written
+to stdout as one JSON object per line instead of drawing the panes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.question = strings.TrimSpace(strings.Join(args, " "))
+			return c.tui(opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&opts.rounds, "rounds", 3, "number of discussion rounds (3-10)")
+	flags.StringVar(&opts.models, "models", "", "comma-separated families or variants to ask, several variants of a family side by side (default: every family)")
+	flags.StringVar(&opts.server, "server", localURL("ws", c.cfg.ServerAddress, "/ws"), "WebSocket URL of the fat server")
+	flags.StringVar(&opts.token, "token", "", "bearer token or access key for the server (default: FAT_AUTH_TOKEN)")
+	flags.BoolVar(&opts.embedded, "embedded", false, "run the question in this process instead of on a server")
+	cmd.RegisterFlagCompletionFunc("models", completeModels)
+	return cmd
+}
+
+// tui shows a run on a server without touching local state, unless it runs the question itself
+func (c *cli) tui(opts tuiOptions) error {
+	if opts.rounds < 3 || opts.rounds > 10 {
+		return usageError("invalid --rounds value %d: must be between 3 and 10", opts.rounds)
+	}
+	if err := checkPicks(splitList(opts.models)); err != nil {
+		return err
+	}
+
+	if opts.question == "" {
+		prompt := os.Stdout
+		if c.jsonOutput {
+			prompt = os.Stderr
+		}
+		fmt.Fprint(prompt, "Question: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if opts.question = strings.TrimSpace(line); opts.question == "" {
+			return usageError(`usage: fat tui "question" [--rounds N] [--models grok,claude] [--server URL] [--embedded]`)
+		}
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	st := newTUIState(opts.question, c.jsonOutput)
+	if !opts.embedded {
+		if opts.token == "" {
+			opts.token = c.cfg.AuthToken // Not the flag's default, which --help would print
+		}
+		if err := tuiRemote(ctx, opts, st); err != nil {
+			return err
+		}
+		return st.finish(os.Stdout)
+	}
+
+	logger, err := c.logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+	database, err := c.openStore(logger)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, database)
+
+	if err := tuiEmbedded(ctx, logger, c.cfg, database, opts, st); err != nil && ctx.Err() != nil {
+		return err
+	}
+	return st.finish(os.Stdout)
+}
+
+// tuiRemote asks a running server over its WebSocket and shows the run until it finishes
+// Families can't be left out over the WebSocket, so picks only choose variants there.
+func tuiRemote(ctx context.Context, opts tuiOptions, st *tuiState) error {
+	var header http.Header
+	if opts.token != "" {
+		header = http.Header{"Authorization": {"Bearer " + opts.token}}
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, opts.server, header)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", opts.server, err)
+	}
+	defer conn.Close()
+	context.AfterFunc(ctx, func() { conn.Close() })
+
+	variants := make(map[string][]string)
+	for _, pick := range splitList(opts.models) {
+		if familyID := models.FamilyForVariant(pick); familyID != "" && !slices.Contains(variants[familyID], pick) {
+			variants[familyID] = append(variants[familyID], pick)
+		}
+	}
+	if err := conn.WriteJSON(map[string]any{
+		"type":     "question",
+		"question": opts.question,
+		"rounds":   opts.rounds,
+		"models":   variants,
+		"force":    true,
+	}); err != nil {
+		return err
+	}
+
+	for !st.done {
+		var message map[string]any
+		if err := conn.ReadJSON(&message); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("connection lost: %w", err)
+		}
+		st.apply(message)
+		st.draw(os.Stdout)
+	}
+	return nil
+}
+
+// tuiEmbedded runs the question in this process and shows it like tuiRemote does
+// Run failures end up in st, which reports them when finishing.
+func tuiEmbedded(ctx context.Context, logger *slog.Logger, cfg config.Config, database *db.DB, opts tuiOptions, st *tuiState) error {
+	srv := server.New(logger, cfg, database, web.Static)
+	_, err := srv.Ask(ctx, opts.question, opts.rounds, splitList(opts.models), "", func(message map[string]any) {
+		// Round-trip through JSON so messages look the same as over the WebSocket
+		var decoded map[string]any
+		if data, err := json.Marshal(message); err == nil && json.Unmarshal(data, &decoded) == nil {
+			st.apply(decoded)
+			st.draw(os.Stdout)
+		}
+	})
+	if err != nil && st.err == "" {
+		st.err = err.Error()
+	}
+	return err
+}
+
+// tuiPane is what a model's pane shows
+type tuiPane struct {
+	model  string
+	status string // "…" waiting, "✓" answered, "✗" failed
+	round  int    // Round of the latest answer
+	text   string // Latest answer, or the error of the current round
+}
+
+// tuiState is the run as seen from the messages received so far
+type tuiState struct {
+	question  string
+	requestID string
+	round     int
+	total     int
+	phase     string
+	notice    string // Latest fallback or convergence
+	panes     map[string]*tuiPane
+	medals    [3][]string
+	answer    string // Winning answer
+	err       string
+	failures  int // Model calls that failed
+	done      bool
+	stream    bool // Write messages as JSON lines instead of drawing
+	last      map[string]any
+}
+
+func newTUIState(question string, stream bool) *tuiState {
+	return &tuiState{question: question, phase: "waiting", panes: make(map[string]*tuiPane), stream: stream}
+}
+
+// apply updates the state from one message, ignoring messages of other runs
+// The run is recognized by the server accepting the question, or on older servers by its queue entry
+// or as the first run to start after the question was sent.
+func (st *tuiState) apply(message map[string]any) {
+	requestID, _ := message["request_id"].(string)
+	msgType, _ := message["type"].(string)
+	if st.requestID == "" && requestID != "" {
+		question, _ := message["question"].(string)
+		if msgType == "accepted" || msgType == "clear" || (msgType == "queue" && question == st.question) {
+			st.requestID = requestID
+		}
+	}
+	if requestID != "" && requestID != st.requestID {
+		return
+	}
+	st.last = message
+
+	pane := func() *tuiPane {
+		model, _ := message["model"].(string)
+		if model == "" {
+			return nil
+		}
+		if st.panes[model] == nil {
+			st.panes[model] = &tuiPane{model: model, status: "…"}
+		}
+		return st.panes[model]
+	}
+
+	switch msgType {
+	case "queue":
+		st.phase = fmt.Sprintf("queued (position %v)", message["position"])
+	case "loading":
+		pane()
+	case "round_start":
+		st.round = intValue(message["round"])
+		st.total = intValue(message["total"])
+		st.phase = "discussing"
+		for _, p := range st.panes {
+			p.status = "…"
+		}
+	case "response":
+		if p := pane(); p != nil {
+			p.status = "✓"
+			p.round = intValue(message["round"])
+			p.text, _ = message["response"].(string)
+		}
+	case "error":
+		if p := pane(); p != nil {
+			p.status = "✗"
+			p.text = fmt.Sprint(message["error"])
+			st.failures++
+		} else {
+			st.err = fmt.Sprint(message["error"])
+			st.done = true
+		}
+	case "fallback":
+		st.notice = fmt.Sprintf("%v switched from %v to %v", message["model"], message["from"], message["to"])
+	case "stall":
+		if p := pane(); p != nil {
+			p.status = "⏳"
+		}
+		st.notice = fmt.Sprintf("%v is taking %ds, over twice its usual %ds - press Ctrl-C to stop", message["variant"], intValue(message["elapsed_ms"])/1000, intValue(message["p95_ms"])/1000)
+	case "converged":
+		st.total = intValue(message["round"])
+		st.notice = fmt.Sprintf("answers converged (%v), skipping the remaining rounds", message["reason"])
+	case "ranking_start":
+		st.phase = "ranking"
+	case "winner":
+		for i, key := range []string{"gold", "silver", "bronze"} {
+			ids, _ := message[key].([]any)
+			for _, id := range ids {
+				st.medals[i] = append(st.medals[i], fmt.Sprint(id))
+			}
+		}
+		if answer, ok := message["answer"].(map[string]any); ok {
+			st.answer, _ = answer["answer"].(string)
+		}
+		st.phase = "done"
+		st.done = true
+	}
+}
+
+// draw redraws the whole screen: the question, one pane per model and the status line
+// When streaming it writes the message just applied instead.
+func (st *tuiState) draw(w io.Writer) {
+	if st.stream {
+		if st.last != nil {
+			json.NewEncoder(w).Encode(st.last)
+			st.last = nil
+		}
+		return
+	}
+
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 100, 30
+	}
+
+	var lines []string
+	lines = append(lines, bold(truncate(st.question, width)))
+	status := st.phase
+	if st.total > 0 {
+		status = fmt.Sprintf("Round %d/%d · %s", st.round, st.total, st.phase)
+	}
+	lines = append(lines, truncate(status, width), "")
+
+	names := make([]string, 0, len(st.panes))
+	for name := range st.panes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Panes at least 30 columns wide, filling the rows left below the header and above the footer
+	cols := max(1, min(len(names), width/30))
+	paneWidth := width / cols
+	rows := (len(names) + cols - 1) / cols
+	paneHeight := max(3, (height-len(lines)-3)/max(1, rows))
+	for start := 0; start < len(names); start += cols {
+		row := names[start:min(start+cols, len(names))]
+		cells := make([][]string, len(row))
+		for i, name := range row {
+			p := st.panes[name]
+			header := fmt.Sprintf("%s %s", p.status, name)
+			if p.round > 0 {
+				header += fmt.Sprintf(" · r%d", p.round)
+			}
+			cells[i] = append([]string{header}, wrap(p.text, paneWidth-2)...)
+			if len(cells[i]) > paneHeight-1 {
+				cells[i] = append(cells[i][:paneHeight-2], "…")
+			}
+		}
+		for l := 0; l < paneHeight-1; l++ {
+			var line strings.Builder
+			for i := range row {
+				cell := ""
+				if l < len(cells[i]) {
+					cell = cells[i][l]
+				}
+				cell = pad(truncate(cell, paneWidth-2), paneWidth-1)
+				if l == 0 {
+					cell = bold(cell)
+				}
+				line.WriteString(cell + " ")
+			}
+			lines = append(lines, strings.TrimRight(line.String(), " "))
+		}
+		lines = append(lines, "")
+	}
+
+	if st.notice != "" {
+		lines = append(lines, truncate("» "+st.notice, width))
+	}
+
+	// Clear the screen and draw from the top
+	fmt.Fprint(w, "\x1b[H\x1b[2J"+strings.Join(lines, "\n")+"\n")
+}
+
+// finish prints the medals and the winning answer below the last frame, and returns how the run went
+func (st *tuiState) finish(w io.Writer) error {
+	if st.err != "" {
+		return exitError{code: exitProvider, err: errors.New(st.err)}
+	}
+	if st.stream {
+		return st.partial()
+	}
+
+	for i, label := range []string{"🥇", "🥈", "🥉"} {
+		if len(st.medals[i]) > 0 {
+			fmt.Fprintf(w, "%s %s\n", label, strings.Join(st.medals[i], ", "))
+		}
+	}
+	if st.answer != "" {
+		fmt.Fprintf(w, "\n%s\n", st.answer)
+	}
+	return st.partial()
+}
+
+// partial is the exitPartial error of a run where some model calls failed, nil if none did
+func (st *tuiState) partial() error {
+	if st.failures > 0 {
+		return exitError{code: exitPartial, err: fmt.Errorf("run finished, but %d model calls failed", st.failures)}
+	}
+	return nil
+}
+
+// wrap breaks text into lines of at most width characters, keeping its own line breaks
+func wrap(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				lines = append(lines, string([]rune(word)[:width]))
+				word = string([]rune(word)[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// truncate cuts s to width characters, ending with "…" if it had to cut
+func truncate(s string, width int) string {
+	if s = strings.ReplaceAll(s, "\n", " "); utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// pad fills s with spaces up to width characters
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func bold(s string) string {
+	return "\x1b[1m" + s + "\x1b[0m"
+}
+
+// intValue reads a JSON number as an int
+func intValue(v any) int {
+	f, _ := v.(float64)
+	return int(f)
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meedamian/fat/internal/apikeys"
+	"github.com/meedamian/fat/internal/embeddings"
+	"github.com/meedamian/fat/internal/extras"
+	"github.com/meedamian/fat/internal/generation"
+	"github.com/meedamian/fat/internal/headers"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/personas"
+	"github.com/meedamian/fat/internal/postprocess"
+	"github.com/meedamian/fat/internal/ranking"
+	"github.com/meedamian/fat/internal/ratelimit"
+	"github.com/meedamian/fat/internal/scorer"
+	"github.com/meedamian/fat/internal/search"
+	"github.com/meedamian/fat/internal/storage"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// secretFields are Config fields shown masked
+var secretFields = map[string]bool{
+	"OpenAIAdminKey":    true,
+	"AnthropicAdminKey": true,
+	"SearchAPIKey":      true,
+	"EmbeddingsAPIKey":  true,
+	"S3AccessKey":       true,
+	"S3SecretKey":       true,
+	"FirehoseToken":     true,
+	"AuthToken":         true,
+}
+
+// configReport is what `fat config validate` found
+type configReport struct {
+	Valid    bool              `json:"valid"`
+	Errors   []string          `json:"errors,omitempty"`   // Problems the server would fail on or silently work around
+	Warnings []string          `json:"warnings,omitempty"` // Runs work, but not fully
+	Config   map[string]any    `json:"config,omitempty"`   // Effective configuration, secrets masked
+	Keys     map[string]string `json:"keys"`               // Family ID -> where its key is loaded from, or "missing"
+	Defaults map[string]string `json:"defaults"`           // Family ID -> default variant
+}
+
+func newConfigCommand(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect fat's configuration",
+		Args:  noArgs,
+		// A broken configuration is what validate reports, so it mustn't stop the command
+		PersistentPreRunE: func(*cobra.Command, []string) error { return nil },
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Check the environment, key stores and config files, and print the effective configuration",
+		Long: `Check the environment, key stores and config files, and print the effective configuration with
+secrets masked. Exits with code 1 if anything is wrong, before a run finds out the costly way.`,
+		Args: noArgs,
+		RunE: c.validateConfig,
+	})
+	return cmd
+}
+
+// validateConfig runs every check and prints the report
+func (c *cli) validateConfig(_ *cobra.Command, _ []string) error {
+	report := c.checkConfig()
+
+	if c.jsonOutput {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		printConfigReport(report)
+	}
+
+	if !report.Valid {
+		return exitError{code: exitFailure, err: errors.New("configuration is invalid")}
+	}
+	return nil
+}
+
+// checkConfig validates the configuration the way the server would load it
+func (c *cli) checkConfig() configReport {
+	report := configReport{Keys: map[string]string{}, Defaults: map[string]string{}}
+	fail := func(format string, args ...any) {
+		report.Errors = append(report.Errors, fmt.Sprintf(format, args...))
+	}
+	warn := func(format string, args ...any) {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	if c.cfgErr != nil {
+		fail("%v", c.cfgErr)
+	} else {
+		report.Config = effectiveConfig(c.cfg)
+	}
+	cfg := c.cfg
+
+	if err := models.LoadDefaults(cfg.DefaultsFile); err != nil {
+		fail("%s: %v", cfg.DefaultsFile, err)
+	}
+	for familyID, variant := range models.DefaultModels {
+		report.Defaults[familyID] = variant
+	}
+
+	// Keys, including whether the encrypted store opens
+	infos := make([]*types.ModelInfo, 0, len(models.ModelFamilies))
+	for familyID := range models.ModelFamilies {
+		infos = append(infos, &types.ModelInfo{ID: familyID})
+	}
+	if err := apikeys.Load(infos); err != nil {
+		fail("%s: %v", apikeys.EncryptedKeysFile, err)
+	}
+	var missing []string
+	for _, mi := range infos {
+		if source := apikeys.Source(mi.ID); source != "" {
+			report.Keys[mi.ID] = source
+			continue
+		}
+		report.Keys[mi.ID] = "missing"
+		missing = append(missing, mi.ID)
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		warn("no API key for %s, so every call to them fails (add keys via /setup)", strings.Join(missing, ", "))
+	}
+
+	// Every variant named in the environment must exist
+	for _, judge := range cfg.Judges {
+		if models.FamilyForVariant(judge) == "" {
+			fail("FAT_JUDGES: unknown variant %q", judge)
+		}
+	}
+	if _, err := ranking.StrategyFor(cfg.WinnerStrategy); err != nil {
+		fail("FAT_WINNER_STRATEGY: %v", err)
+	}
+	if cfg.MetaJudge != "" && models.FamilyForVariant(cfg.MetaJudge) == "" {
+		fail("FAT_META_JUDGE: unknown variant %q", cfg.MetaJudge)
+	} else if cfg.WinnerStrategy == ranking.JudgeOfJudges && cfg.MetaJudge == "" {
+		fail("FAT_WINNER_STRATEGY: %s needs a meta judge, set FAT_META_JUDGE", ranking.JudgeOfJudges)
+	}
+	if cfg.Synthesizer != "" && models.FamilyForVariant(cfg.Synthesizer) == "" {
+		fail("FAT_SYNTHESIZER: unknown variant %q", cfg.Synthesizer)
+	}
+	if cfg.Summarizer != "" && models.FamilyForVariant(cfg.Summarizer) == "" {
+		fail("FAT_SUMMARIZER: unknown variant %q", cfg.Summarizer)
+	}
+	for familyID, variant := range cfg.Fallbacks {
+		if err := models.ValidateDefaults(map[string]string{familyID: variant}); err != nil {
+			fail("FAT_FALLBACK_MODELS: %v", err)
+		}
+	}
+	for _, model := range cfg.StructuredReplies {
+		if _, ok := models.ModelFamilies[model]; !ok && model != "*" && models.FamilyForVariant(model) == "" {
+			fail("FAT_STRUCTURED_REPLIES: unknown family or variant %q", model)
+		}
+	}
+
+	// Files the server falls back from with only a warning
+	files := []struct {
+		path string
+		load func(string) error
+	}{
+		{cfg.PersonasFile, func(path string) error { return personas.Load(path, nil) }},
+		{cfg.HeadersFile, func(path string) error { return headers.Load(path, nil) }},
+		{cfg.ExtrasFile, func(path string) error { return extras.Load(path, nil) }},
+		{cfg.GenerationFile, func(path string) error { return generation.Load(path, nil) }},
+		{cfg.PostProcessFile, func(path string) error { _, err := postprocess.Load(path); return err }},
+		{cfg.RateLimitsFile, func(path string) error { _, err := ratelimit.Load(path); return err }},
+		{cfg.ScorersFile, func(path string) error { _, err := scorer.Load(path); return err }},
+	}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		if err := f.load(f.path); err != nil {
+			fail("%s: %v", f.path, err)
+		}
+	}
+
+	if _, err := search.New(cfg.SearchProvider, cfg.SearchURL, cfg.SearchAPIKey, cfg.SearchResults); err != nil {
+		fail("web search: %v", err)
+	}
+	embeddingsKey := cfg.EmbeddingsAPIKey
+	if embeddingsKey == "" && strings.EqualFold(strings.TrimSpace(cfg.EmbeddingsProvider), embeddings.OpenAI) {
+		embeddingsKey = apikeys.GetForFamily("gpt")
+	}
+	if _, err := embeddings.New(cfg.EmbeddingsProvider, cfg.EmbeddingsURL, cfg.EmbeddingsModel, embeddingsKey); err != nil {
+		fail("answer embeddings: %v", err)
+	}
+	if _, err := storage.NewS3(storage.S3Config{Bucket: cfg.S3Bucket, Endpoint: cfg.S3Endpoint, Region: cfg.S3Region, AccessKey: cfg.S3AccessKey, SecretKey: cfg.S3SecretKey}); err != nil {
+		fail("export uploads: %v", err)
+	}
+
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+// effectiveConfig lists every Config field by name, with durations readable and secrets masked
+func effectiveConfig(cfg any) map[string]any {
+	values := map[string]any{}
+	v := reflect.ValueOf(cfg)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		value := v.Field(i).Interface()
+		switch typed := value.(type) {
+		case time.Duration:
+			value = typed.String()
+		case string:
+			if secretFields[name] {
+				value = maskSecret(typed)
+			}
+		}
+		values[name] = value
+	}
+	return values
+}
+
+// maskSecret hides all but the last 4 characters of a secret, and short secrets entirely
+func maskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) < 12 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// printConfigReport writes the report for people
+func printConfigReport(report configReport) {
+	section := func(title string, values map[string]string) {
+		fmt.Println(title)
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %-24s %s\n", name, values[name])
+		}
+		fmt.Println()
+	}
+
+	if report.Config != nil {
+		values := make(map[string]string, len(report.Config))
+		for name, value := range report.Config {
+			values[name] = fmt.Sprintf("%v", value)
+		}
+		section("Configuration", values)
+	}
+	section("API keys", report.Keys)
+	section("Default models", report.Defaults)
+
+	for _, warning := range report.Warnings {
+		fmt.Printf("! %s\n", warning)
+	}
+	for _, err := range report.Errors {
+		fmt.Printf("✗ %s\n", err)
+	}
+	if report.Valid {
+		fmt.Println("✓ configuration is valid")
+	}
+}
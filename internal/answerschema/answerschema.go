@@ -0,0 +1,85 @@
+// Package answerschema checks answers against the JSON schema a question asks them to follow.
+package answerschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// Schema is a question's answer schema, ready to validate answers
+type Schema struct {
+	data     []byte // The schema as submitted
+	resolved *jsonschema.Resolved
+}
+
+// Parse checks a schema given as a decoded JSON object, e.g. from a question message
+// Returns nil without an error when raw is nil or an empty map.
+func Parse(raw any) (*Schema, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid answer schema: must be a JSON object")
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid answer schema: %w", err)
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid answer schema: %w", err)
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid answer schema: %w", err)
+	}
+
+	return &Schema{data: data, resolved: resolved}, nil
+}
+
+// Map returns the schema as sent to providers and shown in prompts
+// A new map is returned on every call.
+func (s *Schema) Map() map[string]any {
+	var m map[string]any
+	json.Unmarshal(s.data, &m)
+	return m
+}
+
+// Check validates an answer, which may be wrapped in a code fence
+func (s *Schema) Check(answer string) types.SchemaCheck {
+	var instance any
+	if err := json.Unmarshal([]byte(unfence(answer)), &instance); err != nil {
+		// Structured replies carry string answers unquoted, which a string schema accepts
+		if s.resolved.Validate(answer) == nil {
+			return types.SchemaCheck{Valid: true}
+		}
+		return types.SchemaCheck{Error: fmt.Sprintf("answer is not valid JSON: %v", err)}
+	}
+	if err := s.resolved.Validate(instance); err != nil {
+		return types.SchemaCheck{Error: err.Error()}
+	}
+	return types.SchemaCheck{Valid: true}
+}
+
+// unfence returns the content of a ```json code fence around text, or text itself
+func unfence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") || len(text) < 6 {
+		return text
+	}
+	text = strings.TrimSuffix(text[3:], "```")
+	if newline := strings.IndexByte(text, '\n'); newline >= 0 {
+		text = text[newline+1:] // Drop the language tag
+	}
+	return strings.TrimSpace(text)
+}
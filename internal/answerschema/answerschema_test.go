@@ -0,0 +1,81 @@
+package answerschema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	if s, err := Parse(nil); s != nil || err != nil {
+		t.Errorf("Expected no schema for nil, got %v, %v", s, err)
+	}
+	if s, err := Parse(map[string]any(nil)); s != nil || err != nil {
+		t.Errorf("Expected no schema for a nil map, got %v, %v", s, err)
+	}
+	if _, err := Parse("object"); err == nil {
+		t.Error("Expected an error for a schema that isn't an object")
+	}
+	if _, err := Parse(map[string]any{"type": 5}); err == nil {
+		t.Error("Expected an error for an invalid type")
+	}
+
+	s, err := Parse(map[string]any{"type": "object"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if m := s.Map(); m["type"] != "object" {
+		t.Errorf("Expected the schema back from Map, got %v", m)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s, err := Parse(map[string]any{
+		"type":     "object",
+		"required": []any{"city"},
+		"properties": map[string]any{
+			"city":       map[string]any{"type": "string"},
+			"population": map[string]any{"type": "integer"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		answer string
+		valid  bool
+		error  string
+	}{
+		{"valid", `{"city": "Paris", "population": 2100000}`, true, ""},
+		{"fenced", "```json\n{\"city\": \"Paris\"}\n```", true, ""},
+		{"missing required", `{"population": 2100000}`, false, "city"},
+		{"wrong type", `{"city": "Paris", "population": "many"}`, false, "population"},
+		{"not JSON", "Paris", false, "not valid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := s.Check(tt.answer)
+			if check.Valid != tt.valid {
+				t.Fatalf("Expected valid=%v, got %+v", tt.valid, check)
+			}
+			if !strings.Contains(check.Error, tt.error) {
+				t.Errorf("Expected error mentioning %q, got %q", tt.error, check.Error)
+			}
+		})
+	}
+}
+
+func TestCheckString(t *testing.T) {
+	s, err := Parse(map[string]any{"type": "string", "enum": []any{"yes", "no"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if check := s.Check("yes"); !check.Valid {
+		t.Errorf("Expected an unquoted string answer to match, got %+v", check)
+	}
+	if check := s.Check("maybe"); check.Valid {
+		t.Error("Expected a string outside the enum to fail")
+	}
+}
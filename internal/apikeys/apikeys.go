@@ -9,6 +9,9 @@ import (
 	"github.com/meedamian/fat/internal/types"
 )
 
+// KeysFile is the JSON key store, mapping family IDs to API keys
+const KeysFile = "keys.json"
+
 // familyEnvVars maps model family IDs to their environment variable names
 var familyEnvVars = map[string]string{
 	models.Grok:     "GROK_KEY",
@@ -19,9 +22,10 @@ var familyEnvVars = map[string]string{
 	models.Mistral:  "MISTRAL_KEY",
 }
 
-// Load loads API keys from environment variables, .env file, and keys.json
-// and assigns them to the provided model infos
-func Load(modelInfos []*types.ModelInfo) {
+// Load loads API keys from environment variables, .env file, keys.json and the encrypted
+// keys.json.enc, and assigns them to the provided model infos. Keys that were found are
+// assigned even if the encrypted store can't be decrypted, which is reported as an error.
+func Load(modelInfos []*types.ModelInfo) error {
 	// Try environment variables first
 	for _, mi := range modelInfos {
 		if envVar, ok := familyEnvVars[mi.ID]; ok {
@@ -49,7 +53,7 @@ func Load(modelInfos []*types.ModelInfo) {
 	}
 
 	// Try keys.json (uses family ID as key)
-	if file, err := os.Open("keys.json"); err == nil {
+	if file, err := os.Open(KeysFile); err == nil {
 		defer file.Close()
 		var keys map[string]string
 		json.NewDecoder(file).Decode(&keys)
@@ -62,6 +66,18 @@ func Load(modelInfos []*types.ModelInfo) {
 			}
 		}
 	}
+
+	// Try keys.json.enc, decrypted with the passphrase from the environment
+	if err := loadEncrypted(); err != nil {
+		return err
+	}
+	for _, mi := range modelInfos {
+		if mi.APIKey == "" {
+			mi.APIKey = encryptedKey(mi.ID)
+		}
+	}
+
+	return nil
 }
 
 // GetForFamily retrieves the API key for a specific model family
@@ -77,7 +93,7 @@ func GetForFamily(familyID string) string {
 	}
 
 	// Try keys.json
-	if file, err := os.Open("keys.json"); err == nil {
+	if file, err := os.Open(KeysFile); err == nil {
 		defer file.Close()
 		var keys map[string]string
 		if json.NewDecoder(file).Decode(&keys) == nil {
@@ -87,5 +103,6 @@ func GetForFamily(familyID string) string {
 		}
 	}
 
-	return ""
+	// Try keys decrypted at startup
+	return encryptedKey(familyID)
 }
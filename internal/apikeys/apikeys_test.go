@@ -0,0 +1,252 @@
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestSaveJSON(t *testing.T) {
+	t.Setenv("GROK_KEY", "")
+	t.Setenv("GPT_KEY", "")
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(`{"claude": "existing"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveJSON(path, map[string]string{models.Grok: "xai-new", models.GPT: "sk-new"}); err != nil {
+		t.Fatalf("SaveJSON failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys map[string]string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		t.Fatalf("Invalid keys file: %v", err)
+	}
+	if keys["claude"] != "existing" || keys["grok"] != "xai-new" || keys["gpt"] != "sk-new" {
+		t.Errorf("Expected keys to be merged, got %v", keys)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected keys file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	if got := GetForFamily(models.Grok); got != "xai-new" {
+		t.Errorf("Expected saved key to take effect immediately, got %q", got)
+	}
+	if Source(models.Grok) != SourceEnv {
+		t.Errorf("Expected saved key to be visible in the environment")
+	}
+}
+
+func TestSaveEnv(t *testing.T) {
+	t.Setenv("MISTRAL_KEY", "")
+
+	path := filepath.Join(t.TempDir(), "fat.env")
+	if err := os.WriteFile(path, []byte("FAT_LOG_LEVEL=debug\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveEnv(path, map[string]string{models.Mistral: "m-key"}); err != nil {
+		t.Fatalf("SaveEnv failed: %v", err)
+	}
+
+	env, err := godotenv.Read(path)
+	if err != nil {
+		t.Fatalf("Invalid env file: %v", err)
+	}
+	if env["FAT_LOG_LEVEL"] != "debug" || env["MISTRAL_KEY"] != "m-key" {
+		t.Errorf("Expected env file to be merged, got %v", env)
+	}
+
+	if err := SaveEnv(path, map[string]string{"unknown": "x"}); err == nil {
+		t.Error("Expected error for unknown family")
+	}
+}
+
+func TestSourceMissing(t *testing.T) {
+	t.Setenv("DEEPSEEK_KEY", "")
+	t.Chdir(t.TempDir())
+
+	if got := Source(models.DeepSeek); got != "" {
+		t.Errorf("Expected missing key, got source %q", got)
+	}
+
+	if err := os.WriteFile(KeysFile, []byte(`{"deepseek": "ds-key"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := Source(models.DeepSeek); got != SourceFile {
+		t.Errorf("Expected source %q, got %q", SourceFile, got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer good":
+			w.Write([]byte(`{"data": []}`))
+		case "Bearer broken":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	original := validationEndpoints[models.GPT]
+	validationEndpoints[models.GPT] = validationEndpoint{url: srv.URL, authorize: bearer}
+	defer func() { validationEndpoints[models.GPT] = original }()
+
+	ctx := context.Background()
+	if err := Validate(ctx, srv.Client(), models.GPT, "good"); err != nil {
+		t.Errorf("Expected valid key, got %v", err)
+	}
+	if err := Validate(ctx, srv.Client(), models.GPT, "bad"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+	if err := Validate(ctx, srv.Client(), models.GPT, "broken"); err == nil || errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected a provider error distinct from ErrInvalidKey, got %v", err)
+	}
+	if err := Validate(ctx, srv.Client(), "unknown", "good"); err == nil {
+		t.Error("Expected error for unknown family")
+	}
+}
+
+func TestListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-goog-api-key") != "" {
+			w.Write([]byte(`{"models": [{"name": "models/gemini-2.5-pro"}, {"name": "models/gemini-2.5-flash"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data": [{"id": "gpt-5"}, {"id": "gpt-5-mini"}]}`))
+	}))
+	defer srv.Close()
+
+	for _, familyID := range []string{models.GPT, models.Gemini} {
+		original := validationEndpoints[familyID]
+		defer func() { validationEndpoints[familyID] = original }()
+		validationEndpoints[familyID] = validationEndpoint{url: srv.URL, authorize: original.authorize}
+	}
+
+	ids, err := ListModels(context.Background(), srv.Client(), models.GPT, "good")
+	if err != nil || !slices.Equal(ids, []string{"gpt-5", "gpt-5-mini"}) {
+		t.Errorf("Expected OpenAI model IDs, got %v (%v)", ids, err)
+	}
+	ids, err = ListModels(context.Background(), srv.Client(), models.Gemini, "good")
+	if err != nil || !slices.Equal(ids, []string{"gemini-2.5-flash", "gemini-2.5-pro"}) {
+		t.Errorf("Expected Gemini model names without the models/ prefix, got %v (%v)", ids, err)
+	}
+}
+
+func TestServes(t *testing.T) {
+	listed := []string{"claude-3-5-haiku-20241022", "gpt-4o-2024-11-20", "grok-4-0709", "grok-4-fast-reasoning", "mistral-large-latest"}
+
+	for _, variant := range []string{"claude-3-5-haiku-latest", "gpt-4o", "grok-4", "mistral-large-latest"} {
+		if !Serves(listed, variant) {
+			t.Errorf("Expected %q to be served", variant)
+		}
+	}
+	for _, variant := range []string{"grok-4-fast", "claude-3-5", "gpt-5"} {
+		if Serves(listed, variant) {
+			t.Errorf("Expected %q not to be served", variant)
+		}
+	}
+}
+
+func TestValidationEndpointsCoverAllFamilies(t *testing.T) {
+	for familyID := range models.ModelFamilies {
+		if _, ok := validationEndpoints[familyID]; !ok {
+			t.Errorf("No validation endpoint for family %q", familyID)
+		}
+		if EnvVar(familyID) == "" {
+			t.Errorf("No environment variable for family %q", familyID)
+		}
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	defer func(n int) { kdfIterations = n }(kdfIterations)
+	kdfIterations = 1000
+
+	sealed, err := Encrypt([]byte(`{"grok":"secret"}`), "hunter2")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if strings.Contains(string(sealed), "secret") {
+		t.Error("Expected ciphertext not to contain the plaintext")
+	}
+
+	data, err := Decrypt(sealed, "hunter2")
+	if err != nil || string(data) != `{"grok":"secret"}` {
+		t.Errorf("Expected round-trip, got %q (err %v)", data, err)
+	}
+
+	if _, err := Decrypt(sealed, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+	if _, err := Encrypt([]byte("x"), ""); err == nil {
+		t.Error("Expected error for empty passphrase")
+	}
+}
+
+func TestLoadEncrypted(t *testing.T) {
+	defer func(n int) { kdfIterations = n }(kdfIterations)
+	kdfIterations = 1000
+	defer setEncryptedKeys(nil)
+
+	t.Setenv("GEMINI_KEY", "")
+	t.Setenv("CLAUDE_KEY", "")
+	t.Setenv(PassphraseEnvVar, "hunter2")
+	t.Chdir(t.TempDir())
+
+	if err := SaveEncrypted(EncryptedKeysFile, "hunter2", map[string]string{models.Gemini: "g-key"}); err != nil {
+		t.Fatalf("SaveEncrypted failed: %v", err)
+	}
+	if err := SaveEncrypted(EncryptedKeysFile, "hunter2", map[string]string{models.Claude: "c-key"}); err != nil {
+		t.Fatalf("SaveEncrypted failed: %v", err)
+	}
+	os.Unsetenv("GEMINI_KEY")
+	os.Unsetenv("CLAUDE_KEY")
+	setEncryptedKeys(nil)
+
+	gemini := &types.ModelInfo{ID: models.Gemini}
+	claude := &types.ModelInfo{ID: models.Claude}
+	if err := Load([]*types.ModelInfo{gemini, claude}); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if gemini.APIKey != "g-key" || claude.APIKey != "c-key" {
+		t.Errorf("Expected keys from the encrypted store, got %q and %q", gemini.APIKey, claude.APIKey)
+	}
+	if Source(models.Gemini) != SourceEncrypted || GetForFamily(models.Claude) != "c-key" {
+		t.Errorf("Expected decrypted keys to be served from memory")
+	}
+
+	t.Setenv(PassphraseEnvVar, "wrong")
+	if err := Load(nil); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+
+	t.Setenv(PassphraseEnvVar, "")
+	if err := Load(nil); err == nil {
+		t.Error("Expected error when the encrypted store exists without a passphrase")
+	}
+}
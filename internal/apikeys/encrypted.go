@@ -0,0 +1,201 @@
+package apikeys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// EncryptedKeysFile is the encrypted counterpart of KeysFile
+const EncryptedKeysFile = "keys.json.enc"
+
+// PassphraseEnvVar holds the passphrase that unlocks EncryptedKeysFile
+const PassphraseEnvVar = "FAT_KEYS_PASSPHRASE"
+
+// ErrWrongPassphrase is returned when the encrypted key store can't be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted key store")
+
+// kdfIterations is the PBKDF2-SHA256 work factor for new key stores; stored ones keep their own
+var kdfIterations = 600_000
+
+// Keys decrypted from EncryptedKeysFile by Load, kept in memory only
+var (
+	encryptedKeys   map[string]string
+	encryptedKeysMu sync.RWMutex
+)
+
+// envelope is the on-disk format of the encrypted key store
+type envelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Encrypt seals data with AES-256-GCM under a key derived from passphrase
+func Encrypt(data []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("%s is empty", PassphraseEnvVar)
+	}
+
+	env := envelope{
+		Version:    1,
+		KDF:        "pbkdf2-sha256",
+		Iterations: kdfIterations,
+		Salt:       make([]byte, 16),
+	}
+	if _, err := rand.Read(env.Salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	env.Ciphertext = gcm.Seal(nil, env.Nonce, data, nil)
+
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// Decrypt opens data sealed by Encrypt
+func Decrypt(sealed []byte, passphrase string) ([]byte, error) {
+	var env envelope
+	if err := json.Unmarshal(sealed, &env); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted key store: %w", err)
+	}
+	if env.Version != 1 || env.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unsupported encrypted key store version %d (%s)", env.Version, env.KDF)
+	}
+
+	gcm, err := newGCM(passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != gcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+
+	data, err := gcm.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return data, nil
+}
+
+// ReadEncrypted decrypts an encrypted key store (family ID -> key); a missing file is an empty store
+func ReadEncrypted(path, passphrase string) (map[string]string, error) {
+	sealed, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := Decrypt(sealed, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]string{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted key store: %w", err)
+	}
+	return keys, nil
+}
+
+// SaveEncrypted merges keys into the encrypted key store at path
+// The keys also take effect for the running process.
+func SaveEncrypted(path, passphrase string, keys map[string]string) error {
+	stored, err := ReadEncrypted(path, passphrase)
+	if err != nil {
+		return err
+	}
+	for familyID, key := range keys {
+		stored[familyID] = key
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	sealed, err := Encrypt(data, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := writePrivate(path, append(sealed, '\n')); err != nil {
+		return err
+	}
+
+	setEncryptedKeys(stored)
+	return apply(keys)
+}
+
+// Encrypted reports whether keys are kept in the encrypted store, i.e. a passphrase is configured
+func Encrypted() bool {
+	return os.Getenv(PassphraseEnvVar) != ""
+}
+
+// loadEncrypted decrypts EncryptedKeysFile into memory if it exists
+func loadEncrypted() error {
+	if _, err := os.Stat(EncryptedKeysFile); os.IsNotExist(err) {
+		return nil
+	}
+
+	passphrase := os.Getenv(PassphraseEnvVar)
+	if passphrase == "" {
+		return fmt.Errorf("%s exists but %s is not set", EncryptedKeysFile, PassphraseEnvVar)
+	}
+
+	keys, err := ReadEncrypted(EncryptedKeysFile, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", EncryptedKeysFile, err)
+	}
+
+	setEncryptedKeys(keys)
+	return nil
+}
+
+func setEncryptedKeys(keys map[string]string) {
+	encryptedKeysMu.Lock()
+	defer encryptedKeysMu.Unlock()
+
+	encryptedKeys = keys
+}
+
+func encryptedKey(familyID string) string {
+	encryptedKeysMu.RLock()
+	defer encryptedKeysMu.RUnlock()
+
+	return encryptedKeys[familyID]
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations < 1 {
+		return nil, ErrWrongPassphrase
+	}
+
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,146 @@
+package apikeys
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+)
+
+// Key sources reported by Source
+const (
+	SourceEnv       = "env"
+	SourceFile      = "keys.json"
+	SourceEncrypted = "keys.json.enc"
+)
+
+// EnvVar returns the environment variable holding a family's key, or "" for unknown families
+func EnvVar(familyID string) string {
+	return familyEnvVars[familyID]
+}
+
+// Source reports where a family's key is loaded from: SourceEnv (including .env),
+// SourceFile, SourceEncrypted, or "" if it's missing
+func Source(familyID string) string {
+	if envVar, ok := familyEnvVars[familyID]; ok && os.Getenv(envVar) != "" {
+		return SourceEnv
+	}
+
+	keys, err := readKeysFile(KeysFile)
+	if err == nil && keys[familyID] != "" {
+		return SourceFile
+	}
+
+	if encryptedKey(familyID) != "" {
+		return SourceEncrypted
+	}
+
+	return ""
+}
+
+// SaveJSON merges keys (family ID -> key) into the JSON key store at path
+// The keys also take effect for the running process.
+func SaveJSON(path string, keys map[string]string) error {
+	stored, err := readKeysFile(path)
+	if err != nil {
+		return err
+	}
+	for familyID, key := range keys {
+		stored[familyID] = key
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writePrivate(path, append(data, '\n')); err != nil {
+		return err
+	}
+
+	return apply(keys)
+}
+
+// SaveEnv merges keys (family ID -> key) into an env file at path, as their environment variables
+// The keys also take effect for the running process.
+func SaveEnv(path string, keys map[string]string) error {
+	env := map[string]string{}
+	if _, err := os.Stat(path); err == nil {
+		if env, err = godotenv.Read(path); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+
+	for familyID, key := range keys {
+		envVar, ok := familyEnvVars[familyID]
+		if !ok {
+			return fmt.Errorf("unknown model family %q", familyID)
+		}
+		env[envVar] = key
+	}
+
+	content, err := godotenv.Marshal(env)
+	if err != nil {
+		return err
+	}
+	if err := writePrivate(path, []byte(content+"\n")); err != nil {
+		return err
+	}
+
+	return apply(keys)
+}
+
+// apply exports keys to the environment so GetForFamily returns them without a restart
+func apply(keys map[string]string) error {
+	for familyID, key := range keys {
+		envVar, ok := familyEnvVars[familyID]
+		if !ok {
+			return fmt.Errorf("unknown model family %q", familyID)
+		}
+		if err := os.Setenv(envVar, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readKeysFile parses a JSON key store; a missing file is an empty store
+func readKeysFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]string{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// writePrivate atomically replaces path with data readable only by the owner
+func writePrivate(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
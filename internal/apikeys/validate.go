@@ -0,0 +1,134 @@
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/meedamian/fat/internal/models"
+)
+
+// ErrInvalidKey is returned when a provider rejects a key
+var ErrInvalidKey = errors.New("key rejected by provider")
+
+// validationEndpoint is a cheap authenticated request used to check a key
+type validationEndpoint struct {
+	url       string
+	authorize func(req *http.Request, key string)
+}
+
+func bearer(req *http.Request, key string) {
+	req.Header.Set("Authorization", "Bearer "+key)
+}
+
+// validationEndpoints lists each family's model listing endpoint, which costs nothing to call
+var validationEndpoints = map[string]validationEndpoint{
+	models.Grok:     {url: "https://api.x.ai/v1/models", authorize: bearer},
+	models.GPT:      {url: "https://api.openai.com/v1/models", authorize: bearer},
+	models.DeepSeek: {url: "https://api.deepseek.com/models", authorize: bearer},
+	models.Mistral:  {url: "https://api.mistral.ai/v1/models", authorize: bearer},
+	models.Claude: {url: "https://api.anthropic.com/v1/models?limit=1000", authorize: func(req *http.Request, key string) {
+		req.Header.Set("x-api-key", key)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	}},
+	models.Gemini: {url: "https://generativelanguage.googleapis.com/v1beta/models?pageSize=1000", authorize: func(req *http.Request, key string) {
+		req.Header.Set("x-goog-api-key", key)
+	}},
+}
+
+// Validate checks a key with its provider by listing the available models
+// Returns ErrInvalidKey if the provider rejects it.
+func Validate(ctx context.Context, client *http.Client, familyID, key string) error {
+	_, err := ListModels(ctx, client, familyID, key)
+	return err
+}
+
+// ListModels returns the IDs of the models the provider serves to the key, sorted
+// Returns ErrInvalidKey if the provider rejects the key.
+func ListModels(ctx context.Context, client *http.Client, familyID, key string) ([]string, error) {
+	endpoint, ok := validationEndpoints[familyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown model family %q", familyID)
+	}
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.authorize(req, key)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden,
+		resp.StatusCode == http.StatusBadRequest && familyID == models.Gemini: // Gemini answers 400 for malformed keys
+		io.Copy(io.Discard, resp.Body)
+		return nil, ErrInvalidKey
+	default:
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("unexpected provider response: %s", resp.Status)
+	}
+
+	// OpenAI-compatible APIs and Anthropic list {"data": [{"id"}]}, Gemini lists {"models": [{"name": "models/..."}]}
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	ids := make([]string, 0, len(list.Data)+len(list.Models))
+	for _, m := range list.Data {
+		ids = append(ids, m.ID)
+	}
+	for _, m := range list.Models {
+		ids = append(ids, strings.TrimPrefix(m.Name, "models/"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Serves reports whether a model list from ListModels includes a variant
+// Providers list dated snapshots rather than aliases, so "claude-3-5-haiku-latest" matches
+// "claude-3-5-haiku-20241022" and "grok-4" matches "grok-4-0709".
+func Serves(listed []string, variant string) bool {
+	base := strings.TrimSuffix(variant, "-latest")
+	for _, id := range listed {
+		if id == variant || id == base || strings.HasPrefix(id, base+"-") && isSnapshot(id[len(base)+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSnapshot reports whether a model ID suffix is a date or version stamp like "20241022", "0709" or "2024-11-20"
+func isSnapshot(suffix string) bool {
+	digits := strings.ReplaceAll(suffix, "-", "")
+	if len(digits) < 4 {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
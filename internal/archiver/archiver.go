@@ -13,6 +13,7 @@ import (
 	"time"
 )
 
+// Where the logs are kept, relative to the data directory
 const (
 	answersDir = "answers"
 	recentDir  = "answers/recent"
@@ -20,11 +21,12 @@ const (
 )
 
 // StartBackgroundArchiver starts a goroutine that runs archive operations every hour
-func StartBackgroundArchiver(logger *slog.Logger) {
+// dataDir is the directory holding answers/.
+func StartBackgroundArchiver(dataDir string, logger *slog.Logger) {
 	logger.Info("starting background archiver", slog.Duration("interval", time.Hour))
 
 	// Run immediately on startup
-	if err := ArchiveOldFolders(logger); err != nil {
+	if err := ArchiveOldFolders(dataDir, logger); err != nil {
 		logger.Error("initial archive run failed", slog.Any("error", err))
 	}
 
@@ -32,7 +34,7 @@ func StartBackgroundArchiver(logger *slog.Logger) {
 	ticker := time.NewTicker(time.Hour)
 	go func() {
 		for range ticker.C {
-			if err := ArchiveOldFolders(logger); err != nil {
+			if err := ArchiveOldFolders(dataDir, logger); err != nil {
 				logger.Error("archive run failed", slog.Any("error", err))
 			}
 		}
@@ -42,7 +44,7 @@ func StartBackgroundArchiver(logger *slog.Logger) {
 // ArchiveOldFolders moves folders based on their age:
 // - Folders older than 1 month → answers/archive/YYYY-MM/
 // - Folders older than 1 week → answers/recent/
-func ArchiveOldFolders(logger *slog.Logger) error {
+func ArchiveOldFolders(dataDir string, logger *slog.Logger) error {
 	now := time.Now()
 	oneWeekAgo := now.AddDate(0, 0, -7)
 	oneMonthAgo := now.AddDate(0, -1, 0)
@@ -52,29 +54,33 @@ func ArchiveOldFolders(logger *slog.Logger) error {
 		slog.Time("one_week_ago", oneWeekAgo),
 		slog.Time("one_month_ago", oneMonthAgo))
 
+	answers := filepath.Join(dataDir, answersDir)
+	recent := filepath.Join(dataDir, recentDir)
+	archive := filepath.Join(dataDir, archiveDir)
+
 	// Ensure archive and recent directories exist
-	if err := os.MkdirAll(recentDir, 0755); err != nil {
+	if err := os.MkdirAll(recent, 0755); err != nil {
 		return fmt.Errorf("failed to create recent dir: %w", err)
 	}
-	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+	if err := os.MkdirAll(archive, 0755); err != nil {
 		return fmt.Errorf("failed to create archive dir: %w", err)
 	}
 
 	// Check folders in answers/recent/
-	if err := processDirectory(recentDir, oneMonthAgo, logger, true); err != nil {
+	if err := processDirectory(recent, archive, oneMonthAgo, logger, true); err != nil {
 		logger.Error("failed to process recent directory", slog.Any("error", err))
 	}
 
 	// Check folders in answers/
-	if err := processDirectory(answersDir, oneWeekAgo, logger, false); err != nil {
+	if err := processDirectory(answers, recent, oneWeekAgo, logger, false); err != nil {
 		logger.Error("failed to process answers directory", slog.Any("error", err))
 	}
 
 	return nil
 }
 
-// processDirectory scans a directory and moves old folders
-func processDirectory(dirPath string, ageThreshold time.Time, logger *slog.Logger, isRecentDir bool) error {
+// processDirectory scans a directory and moves old folders to destDir
+func processDirectory(dirPath, destDir string, ageThreshold time.Time, logger *slog.Logger, isRecentDir bool) error {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -108,7 +114,7 @@ func processDirectory(dirPath string, ageThreshold time.Time, logger *slog.Logge
 		if isRecentDir {
 			// From recent/ - move to archive if older than 1 month
 			if modTime.Before(ageThreshold) {
-				if err := moveToArchive(fullPath, name, modTime, logger); err != nil {
+				if err := moveToArchiveWithBase(fullPath, name, modTime, destDir, logger); err != nil {
 					logger.Error("failed to move to archive",
 						slog.String("path", fullPath),
 						slog.Any("error", err))
@@ -117,7 +123,7 @@ func processDirectory(dirPath string, ageThreshold time.Time, logger *slog.Logge
 		} else {
 			// From answers/ - move to recent if older than 1 week
 			if modTime.Before(ageThreshold) {
-				if err := moveToRecent(fullPath, name, logger); err != nil {
+				if err := moveToRecentWithBase(fullPath, name, destDir, logger); err != nil {
 					logger.Error("failed to move to recent",
 						slog.String("path", fullPath),
 						slog.Any("error", err))
@@ -129,12 +135,7 @@ func processDirectory(dirPath string, ageThreshold time.Time, logger *slog.Logge
 	return nil
 }
 
-// moveToArchive moves a folder to answers/archive/YYYY-MM/
-func moveToArchive(srcPath, name string, modTime time.Time, logger *slog.Logger) error {
-	return moveToArchiveWithBase(srcPath, name, modTime, archiveDir, logger)
-}
-
-// moveToArchiveWithBase is the testable version that accepts a base directory
+// moveToArchiveWithBase moves a folder to baseArchiveDir/YYYY-MM/
 func moveToArchiveWithBase(srcPath, name string, modTime time.Time, baseArchiveDir string, logger *slog.Logger) error {
 	// Create YYYY-MM directory
 	yearMonth := modTime.Format("2006-01")
@@ -166,12 +167,7 @@ func moveToArchiveWithBase(srcPath, name string, modTime time.Time, baseArchiveD
 	return nil
 }
 
-// moveToRecent moves a folder to answers/recent/
-func moveToRecent(srcPath, name string, logger *slog.Logger) error {
-	return moveToRecentWithBase(srcPath, name, recentDir, logger)
-}
-
-// moveToRecentWithBase is the testable version that accepts a base directory
+// moveToRecentWithBase moves a folder to baseRecentDir/
 func moveToRecentWithBase(srcPath, name string, baseRecentDir string, logger *slog.Logger) error {
 	destPath := filepath.Join(baseRecentDir, name)
 
@@ -0,0 +1,98 @@
+// Package auth gates the server's costly and administrative endpoints behind a static bearer token
+// (FAT_AUTH_TOKEN) and per-user access keys stored in the database. While neither is configured,
+// every request is let through, as before authentication existed.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+// KeyPrefix starts every access key, so leaked keys are easy to recognise
+const KeyPrefix = "fat_"
+
+// TokenUser is the user a request holding the static token is attributed to
+const TokenUser = "admin"
+
+// ErrUnauthorized is returned for requests without a valid token or key
+var ErrUnauthorized = errors.New("a valid bearer token or access key is required")
+
+// NewKey generates an access key, returning it with the hash and prefix it's stored by
+func NewKey() (key, hash, prefix string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = KeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, Hash(key), key[:len(KeyPrefix)+6], nil
+}
+
+// Hash is how a key is looked up without storing it
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Credential is the token or key a request presents, from its Authorization header or, for WebSocket
+// connections from browsers, which can't set headers, its token query parameter
+func Credential(r *http.Request) string {
+	if scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(value)
+	}
+	return r.URL.Query().Get("token")
+}
+
+// Authenticator checks credentials against the static token and the stored access keys
+type Authenticator struct {
+	token    string
+	database *db.DB
+}
+
+// New returns an Authenticator; an empty token leaves only the access keys
+func New(token string, database *db.DB) *Authenticator {
+	return &Authenticator{token: token, database: database}
+}
+
+// Required reports whether protected endpoints need credentials, i.e. a token or a valid key exists
+func (a *Authenticator) Required(ctx context.Context) (bool, error) {
+	if a.token != "" {
+		return true, nil
+	}
+	return a.database.HasAccessKeys(ctx)
+}
+
+// Check returns who credential belongs to, or ErrUnauthorized
+// With authentication not required, everyone is let through as "".
+func (a *Authenticator) Check(ctx context.Context, credential string) (string, error) {
+	required, err := a.Required(ctx)
+	if err != nil || !required {
+		return "", err
+	}
+	if credential == "" {
+		return "", ErrUnauthorized
+	}
+
+	if a.token != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(a.token)) == 1 {
+		return TokenUser, nil
+	}
+	if !strings.HasPrefix(credential, KeyPrefix) {
+		return "", ErrUnauthorized
+	}
+	key, err := a.database.UseAccessKey(ctx, Hash(credential))
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		return "", ErrUnauthorized
+	}
+	return key.User, nil
+}
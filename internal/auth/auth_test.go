@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+func openDB(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.New(filepath.Join(t.TempDir(), "auth.db"), slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	database := openDB(t)
+
+	open := New("", database)
+	if user, err := open.Check(ctx, ""); err != nil || user != "" {
+		t.Fatalf("Expected everyone through without a token or keys, got %q, %v", user, err)
+	}
+
+	key, hash, prefix, err := NewKey()
+	if err != nil {
+		t.Fatalf("NewKey failed: %v", err)
+	}
+	if !strings.HasPrefix(key, KeyPrefix) || !strings.HasPrefix(key, prefix) || hash != Hash(key) {
+		t.Fatalf("Unexpected key %q, hash %q, prefix %q", key, hash, prefix)
+	}
+	id, err := database.CreateAccessKey(ctx, "alice", hash, prefix)
+	if err != nil {
+		t.Fatalf("CreateAccessKey failed: %v", err)
+	}
+
+	// A stored key is enough to require authentication
+	if _, err := open.Check(ctx, ""); err != ErrUnauthorized {
+		t.Errorf("Expected a key to require authentication, got %v", err)
+	}
+	if user, err := open.Check(ctx, key); err != nil || user != "alice" {
+		t.Errorf("Expected the key to belong to alice, got %q, %v", user, err)
+	}
+
+	withToken := New("s3cret", database)
+	if user, err := withToken.Check(ctx, "s3cret"); err != nil || user != TokenUser {
+		t.Errorf("Expected the token to be accepted, got %q, %v", user, err)
+	}
+	for _, bad := range []string{"", "s3cre", KeyPrefix + "nope"} {
+		if _, err := withToken.Check(ctx, bad); err != ErrUnauthorized {
+			t.Errorf("Expected %q to be refused, got %v", bad, err)
+		}
+	}
+
+	if err := database.RevokeAccessKey(ctx, id); err != nil {
+		t.Fatalf("RevokeAccessKey failed: %v", err)
+	}
+	if _, err := withToken.Check(ctx, key); err != ErrUnauthorized {
+		t.Errorf("Expected a revoked key to be refused, got %v", err)
+	}
+	if user, err := open.Check(ctx, ""); err != nil || user != "" {
+		t.Errorf("Expected everyone through once the last key is revoked, got %q, %v", user, err)
+	}
+}
+
+func TestCredential(t *testing.T) {
+	r := httptest.NewRequest("GET", "/ws?token=from-query", nil)
+	if got := Credential(r); got != "from-query" {
+		t.Errorf("Expected the query token, got %q", got)
+	}
+	r.Header.Set("Authorization", "bearer from-header")
+	if got := Credential(r); got != "from-header" {
+		t.Errorf("Expected the header to win, got %q", got)
+	}
+	r.Header.Set("Authorization", "Basic abc")
+	if got := Credential(r); got != "from-query" {
+		t.Errorf("Expected other schemes to be ignored, got %q", got)
+	}
+}
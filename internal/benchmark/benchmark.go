@@ -0,0 +1,168 @@
+// Package benchmark tracks model win-rates on tagged question sets over time
+// and flags statistically significant regressions against stored baselines.
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/stats"
+)
+
+// Alpha is the significance level below which a drop is reported as a regression
+const Alpha = 0.05
+
+// ModelReport compares a model variant's current win-rate against its baseline
+type ModelReport struct {
+	ModelID         string         `json:"model_id"`
+	ModelName       string         `json:"model_name"`
+	BaselineRuns    int64          `json:"baseline_runs"`
+	BaselineWinRate float64        `json:"baseline_win_rate"`
+	BaselineCI      stats.Interval `json:"baseline_ci"`
+	CurrentRuns     int64          `json:"current_runs"`
+	CurrentWinRate  float64        `json:"current_win_rate"`
+	CurrentCI       stats.Interval `json:"current_ci"`
+	Delta           float64        `json:"delta"`
+	PValue          float64        `json:"p_value"`
+	Regression      bool           `json:"regression"`
+}
+
+// Report is the regression report for a tagged question set
+type Report struct {
+	Tag         string        `json:"tag"`
+	BaselineAt  time.Time     `json:"baseline_at"`
+	Models      []ModelReport `json:"models"`
+	Regressions int           `json:"regressions"`
+}
+
+// SnapshotBaseline stores the win-rates accumulated since the previous baseline as the new baseline
+// If no baseline exists yet, all tagged requests are used
+func SnapshotBaseline(ctx context.Context, database *db.DB, tag string) ([]db.BenchmarkBaseline, error) {
+	since, err := latestBaselineTime(ctx, database, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := database.GetModelWinRates(ctx, tag, since)
+	if err != nil {
+		return nil, err
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no runs tagged %q since last baseline", tag)
+	}
+
+	baselines := make([]db.BenchmarkBaseline, 0, len(rates))
+	for _, r := range rates {
+		b := db.BenchmarkBaseline{
+			Tag:       tag,
+			ModelID:   r.ModelID,
+			ModelName: r.ModelName,
+			Runs:      r.Runs,
+			Wins:      r.Wins,
+		}
+		if err := database.SaveBenchmarkBaseline(ctx, b); err != nil {
+			return nil, err
+		}
+		baselines = append(baselines, b)
+	}
+
+	return baselines, nil
+}
+
+// DetectRegressions compares runs since the latest baseline against that baseline
+func DetectRegressions(ctx context.Context, database *db.DB, tag string) (*Report, error) {
+	baselines, err := database.GetLatestBaselines(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(baselines) == 0 {
+		return nil, fmt.Errorf("no baseline stored for tag %q", tag)
+	}
+
+	since := baselines[0].CreatedAt
+	for _, b := range baselines {
+		if b.CreatedAt.After(since) {
+			since = b.CreatedAt
+		}
+	}
+
+	rates, err := database.GetModelWinRates(ctx, tag, since)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]db.ModelWinRate, len(rates))
+	for _, r := range rates {
+		current[r.ModelID+"/"+r.ModelName] = r
+	}
+
+	report := &Report{Tag: tag, BaselineAt: since, Models: make([]ModelReport, 0, len(baselines))}
+	for _, b := range baselines {
+		c := current[b.ModelID+"/"+b.ModelName]
+		mr := Compare(b.Runs, b.Wins, c.Runs, c.Wins)
+		mr.ModelID = b.ModelID
+		mr.ModelName = b.ModelName
+		if mr.Regression {
+			report.Regressions++
+		}
+		report.Models = append(report.Models, mr)
+	}
+
+	return report, nil
+}
+
+// Compare runs a one-sided two-proportion z-test for a drop in win-rate
+func Compare(baselineRuns, baselineWins, currentRuns, currentWins int64) ModelReport {
+	mr := ModelReport{
+		BaselineRuns: baselineRuns,
+		BaselineCI:   stats.Wilson(baselineWins, baselineRuns, stats.Z95),
+		CurrentRuns:  currentRuns,
+		CurrentCI:    stats.Wilson(currentWins, currentRuns, stats.Z95),
+		PValue:       1,
+	}
+	if baselineRuns == 0 || currentRuns == 0 {
+		return mr
+	}
+
+	p1 := float64(baselineWins) / float64(baselineRuns)
+	p2 := float64(currentWins) / float64(currentRuns)
+	mr.BaselineWinRate = p1
+	mr.CurrentWinRate = p2
+	mr.Delta = p2 - p1
+
+	pooled := float64(baselineWins+currentWins) / float64(baselineRuns+currentRuns)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(baselineRuns) + 1/float64(currentRuns)))
+	if se == 0 {
+		return mr
+	}
+
+	z := (p2 - p1) / se
+	mr.PValue = normalCDF(z)
+	mr.Regression = mr.Delta < 0 && mr.PValue < Alpha
+
+	return mr
+}
+
+// latestBaselineTime returns when the newest baseline for a tag was taken, or the zero time
+func latestBaselineTime(ctx context.Context, database *db.DB, tag string) (time.Time, error) {
+	baselines, err := database.GetLatestBaselines(ctx, tag)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var latest time.Time
+	for _, b := range baselines {
+		if b.CreatedAt.After(latest) {
+			latest = b.CreatedAt
+		}
+	}
+	return latest, nil
+}
+
+// normalCDF is the standard normal cumulative distribution function
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
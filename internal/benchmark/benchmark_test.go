@@ -0,0 +1,56 @@
+package benchmark
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name           string
+		baseRuns       int64
+		baseWins       int64
+		curRuns        int64
+		curWins        int64
+		wantRegression bool
+	}{
+		{"significant drop", 100, 60, 100, 30, true},
+		{"small sample drop", 5, 3, 5, 1, false},
+		{"improvement", 100, 30, 100, 60, false},
+		{"unchanged", 50, 25, 50, 25, false},
+		{"no current runs", 50, 25, 0, 0, false},
+		{"always lost", 20, 0, 20, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(tt.baseRuns, tt.baseWins, tt.curRuns, tt.curWins)
+			if got.Regression != tt.wantRegression {
+				t.Errorf("Regression = %v, want %v (p=%.4f, delta=%.2f)", got.Regression, tt.wantRegression, got.PValue, got.Delta)
+			}
+		})
+	}
+}
+
+func TestCompareWinRates(t *testing.T) {
+	got := Compare(10, 5, 20, 5)
+
+	if got.BaselineWinRate != 0.5 {
+		t.Errorf("Expected baseline win rate 0.5, got %f", got.BaselineWinRate)
+	}
+	if got.CurrentWinRate != 0.25 {
+		t.Errorf("Expected current win rate 0.25, got %f", got.CurrentWinRate)
+	}
+	if math.Abs(got.Delta+0.25) > 1e-9 {
+		t.Errorf("Expected delta -0.25, got %f", got.Delta)
+	}
+}
+
+func TestNormalCDF(t *testing.T) {
+	if math.Abs(normalCDF(0)-0.5) > 1e-9 {
+		t.Errorf("normalCDF(0) = %f, want 0.5", normalCDF(0))
+	}
+	if math.Abs(normalCDF(-1.6449)-0.05) > 1e-3 {
+		t.Errorf("normalCDF(-1.6449) = %f, want ~0.05", normalCDF(-1.6449))
+	}
+}
@@ -0,0 +1,253 @@
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/difficulty"
+	"github.com/meedamian/fat/internal/stats"
+)
+
+// MedalCounts is how often a model placed in each tier over a tag's runs
+type MedalCounts struct {
+	Gold   int64 `json:"gold"`
+	Silver int64 `json:"silver"`
+	Bronze int64 `json:"bronze"`
+	None   int64 `json:"none"`
+}
+
+// MetricComparison is a per-run mean under each tag and whether they differ significantly
+// PValue is two-sided, from a permutation test on the per-run values
+type MetricComparison struct {
+	A           float64 `json:"a"`
+	B           float64 `json:"b"`
+	Delta       float64 `json:"delta"` // B - A
+	PValue      float64 `json:"p_value"`
+	Significant bool    `json:"significant"`
+}
+
+// ModelComparison compares one model's results under two tags
+type ModelComparison struct {
+	ModelID   string      `json:"model_id"`
+	ModelName string      `json:"model_name"`
+	RunsA     int64       `json:"runs_a"`
+	RunsB     int64       `json:"runs_b"`
+	MedalsA   MedalCounts `json:"medals_a"`
+	MedalsB   MedalCounts `json:"medals_b"`
+
+	// Chi-square test of whether the medal distribution changed
+	MedalPValue       float64 `json:"medal_p_value"`
+	MedalsSignificant bool    `json:"medals_significant"`
+
+	Cost MetricComparison `json:"cost"` // Mean cost of the model's answers per run
+}
+
+// TagComparison is the A/B report between two tagged question sets, e.g. two prompt versions
+type TagComparison struct {
+	TagA           string            `json:"tag_a"`
+	TagB           string            `json:"tag_b"`
+	RunsA          int               `json:"runs_a"`
+	RunsB          int               `json:"runs_b"`
+	Cost           MetricComparison  `json:"cost"`            // Mean total cost per run
+	JudgeAgreement MetricComparison  `json:"judge_agreement"` // Mean 1 - Kendall tau distance between judges per run
+	Models         []ModelComparison `json:"models"`
+}
+
+// tagSamples holds the per-run values of one tag
+type tagSamples struct {
+	runs      int
+	cost      []float64
+	agreement []float64
+	models    map[string]*modelSamples
+}
+
+type modelSamples struct {
+	name   string
+	medals MedalCounts
+	cost   []float64
+}
+
+// CompareTags compares the runs tagged tagA against those tagged tagB
+// Runs without a winner are left out; either tag without runs is an error.
+func CompareTags(ctx context.Context, database *db.DB, tagA, tagB string) (*TagComparison, error) {
+	a, err := collectTag(ctx, database, tagA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := collectTag(ctx, database, tagB)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TagComparison{
+		TagA:           tagA,
+		TagB:           tagB,
+		RunsA:          a.runs,
+		RunsB:          b.runs,
+		Cost:           compareMetric(a.cost, b.cost),
+		JudgeAgreement: compareMetric(a.agreement, b.agreement),
+	}
+
+	ids := make(map[string]bool, len(a.models)+len(b.models))
+	for id := range a.models {
+		ids[id] = true
+	}
+	for id := range b.models {
+		ids[id] = true
+	}
+
+	for id := range ids {
+		ma, mb := a.models[id], b.models[id]
+		if ma == nil {
+			ma = &modelSamples{}
+		}
+		if mb == nil {
+			mb = &modelSamples{}
+		}
+
+		mc := ModelComparison{
+			ModelID:   id,
+			ModelName: mb.name,
+			RunsA:     int64(len(ma.cost)),
+			RunsB:     int64(len(mb.cost)),
+			MedalsA:   ma.medals,
+			MedalsB:   mb.medals,
+			Cost:      compareMetric(ma.cost, mb.cost),
+		}
+		if mc.ModelName == "" {
+			mc.ModelName = ma.name
+		}
+		_, _, mc.MedalPValue = stats.ChiSquare([][]int64{medalRow(ma.medals), medalRow(mb.medals)})
+		mc.MedalsSignificant = mc.MedalPValue < Alpha
+		report.Models = append(report.Models, mc)
+	}
+	sort.Slice(report.Models, func(i, j int) bool {
+		return report.Models[i].ModelID < report.Models[j].ModelID
+	})
+
+	return report, nil
+}
+
+// collectTag gathers the per-run medals, costs and judge agreement of a tag's runs
+func collectTag(ctx context.Context, database *db.DB, tag string) (*tagSamples, error) {
+	requests, err := database.GetRequests(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &tagSamples{models: make(map[string]*modelSamples)}
+	for _, req := range requests {
+		if req.WinnerModel == "" {
+			continue
+		}
+
+		replies, err := database.GetRoundReplies(ctx, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		medals, err := runMedals(ctx, database, req)
+		if err != nil {
+			return nil, err
+		}
+
+		s.runs++
+		s.cost = append(s.cost, req.TotalCost)
+
+		for id, rounds := range replies {
+			m := s.models[id]
+			if m == nil {
+				m = &modelSamples{}
+				s.models[id] = m
+			}
+			var cost float64
+			for _, mr := range rounds {
+				cost += mr.Cost
+				m.name = mr.ModelName
+			}
+			m.cost = append(m.cost, cost)
+
+			switch medals[id] {
+			case "gold":
+				m.medals.Gold++
+			case "silver":
+				m.medals.Silver++
+			case "bronze":
+				m.medals.Bronze++
+			default:
+				m.medals.None++
+			}
+		}
+
+		rankings, err := database.GetRequestRankings(ctx, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		var ranked [][]string
+		for _, r := range rankings {
+			var order []string
+			if err := json.Unmarshal([]byte(r.RankedModels), &order); err == nil {
+				ranked = append(ranked, order)
+			}
+		}
+		if d, ok := difficulty.JudgeDisagreement(ranked); ok {
+			s.agreement = append(s.agreement, 1-d)
+		}
+	}
+
+	if s.runs == 0 {
+		return nil, fmt.Errorf("no finished runs tagged %q", tag)
+	}
+	return s, nil
+}
+
+// runMedals returns the tier each model ID was awarded in a run, as announced in its winner event
+// Runs recorded before the event log only know their winner, who is counted as the sole gold.
+func runMedals(ctx context.Context, database *db.DB, req db.Request) (map[string]string, error) {
+	events, err := database.GetEvents(ctx, req.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type != "winner" {
+			continue
+		}
+		var winner struct {
+			Gold   []string `json:"gold"`
+			Silver []string `json:"silver"`
+			Bronze []string `json:"bronze"`
+		}
+		if err := json.Unmarshal(events[i].Payload, &winner); err != nil {
+			break
+		}
+		medals := make(map[string]string)
+		for tier, ids := range map[string][]string{"gold": winner.Gold, "silver": winner.Silver, "bronze": winner.Bronze} {
+			for _, id := range ids {
+				medals[id] = tier
+			}
+		}
+		return medals, nil
+	}
+
+	return map[string]string{req.WinnerModel: "gold"}, nil
+}
+
+// medalRow is a model's medal counts as a contingency table row
+func medalRow(m MedalCounts) []int64 {
+	return []int64{m.Gold, m.Silver, m.Bronze, m.None}
+}
+
+// compareMetric compares the per-run values of a metric under two tags
+func compareMetric(a, b []float64) MetricComparison {
+	mc := MetricComparison{
+		A:      stats.Mean(a),
+		B:      stats.Mean(b),
+		PValue: stats.PermutationTest(a, b, stats.PermutationIterations, 1),
+	}
+	mc.Delta = mc.B - mc.A
+	mc.Significant = mc.PValue < Alpha
+	return mc
+}
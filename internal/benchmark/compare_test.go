@@ -0,0 +1,86 @@
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+func TestCompareTags(t *testing.T) {
+	dbPath := "test_compare.db"
+	defer os.Remove(dbPath)
+
+	database, err := db.New(dbPath, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	// Under prompt-v1 grok always wins and costs 0.1; under prompt-v2 gpt always wins and grok costs 0.3
+	for i := range 12 {
+		for _, tag := range []string{"prompt-v1", "prompt-v2"} {
+			id := fmt.Sprintf("%s-%d", tag, i)
+			winner, runnerUp, grokCost := "grok", "gpt", 0.1
+			if tag == "prompt-v2" {
+				winner, runnerUp, grokCost = "gpt", "grok", 0.3
+			}
+
+			if err := database.SaveRequest(ctx, db.Request{ID: id, Question: "Q?", NumRounds: 1, NumModels: 2, WinnerModel: winner, TotalCost: grokCost + 0.2, Tag: tag}); err != nil {
+				t.Fatalf("Failed to save request: %v", err)
+			}
+			for _, mr := range []db.ModelRound{
+				{RequestID: id, ModelID: "grok", ModelName: "grok-4", Round: 1, Answer: "A", Cost: grokCost},
+				{RequestID: id, ModelID: "gpt", ModelName: "gpt-5", Round: 1, Answer: "B", Cost: 0.2},
+			} {
+				if err := database.SaveModelRound(ctx, mr); err != nil {
+					t.Fatalf("Failed to save model round: %v", err)
+				}
+			}
+			payload, _ := json.Marshal(map[string]any{"type": "winner", "gold": []string{winner}, "silver": []string{runnerUp}, "bronze": []string{}})
+			if err := database.SaveEvent(ctx, db.Event{RequestID: id, Type: "winner", Payload: payload}); err != nil {
+				t.Fatalf("Failed to save event: %v", err)
+			}
+			for _, judge := range []string{"grok-4", "gpt-5"} {
+				if err := database.SaveRanking(ctx, db.Ranking{RequestID: id, RankerModel: judge, RankedModels: `["grok-4","gpt-5"]`}); err != nil {
+					t.Fatalf("Failed to save ranking: %v", err)
+				}
+			}
+		}
+	}
+
+	if _, err := CompareTags(ctx, database, "prompt-v1", "prompt-v3"); err == nil {
+		t.Error("Expected error for a tag without runs")
+	}
+
+	report, err := CompareTags(ctx, database, "prompt-v1", "prompt-v2")
+	if err != nil {
+		t.Fatalf("CompareTags failed: %v", err)
+	}
+	if report.RunsA != 12 || report.RunsB != 12 {
+		t.Errorf("Expected 12 runs per tag, got %d and %d", report.RunsA, report.RunsB)
+	}
+	if len(report.Models) != 2 || report.Models[0].ModelID != "gpt" || report.Models[1].ModelID != "grok" {
+		t.Fatalf("Expected gpt and grok sorted by ID, got %+v", report.Models)
+	}
+
+	grok := report.Models[1]
+	if grok.MedalsA.Gold != 12 || grok.MedalsB.Silver != 12 || !grok.MedalsSignificant {
+		t.Errorf("Expected grok's drop from gold to silver to be significant, got %+v", grok)
+	}
+	if !grok.Cost.Significant || grok.Cost.Delta < 0.199 || grok.Cost.Delta > 0.201 {
+		t.Errorf("Expected grok's cost to rise significantly by 0.2, got %+v", grok.Cost)
+	}
+	if gpt := report.Models[0]; gpt.Cost.Significant || gpt.ModelName != "gpt-5" {
+		t.Errorf("Expected gpt's unchanged cost not to be significant, got %+v", gpt)
+	}
+	if report.JudgeAgreement.A != 1 || report.JudgeAgreement.Significant {
+		t.Errorf("Expected unanimous judges under both tags, got %+v", report.JudgeAgreement)
+	}
+}
@@ -0,0 +1,124 @@
+package benchmark
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/stats"
+)
+
+// SuiteModel is how a model variant did across a benchmark suite
+type SuiteModel struct {
+	ModelID       string         `json:"model_id"`
+	ModelName     string         `json:"model_name"`
+	Runs          int64          `json:"runs"`
+	Wins          int64          `json:"wins"`
+	WinRate       float64        `json:"win_rate"`
+	WinRateCI     stats.Interval `json:"win_rate_ci"`
+	Cost          float64        `json:"cost"` // Of its answers, not counting its rankings
+	Errors        int64          `json:"errors"`
+	MeanLatencyMs float64        `json:"mean_latency_ms"` // Per answered round
+}
+
+// SuiteReport aggregates every run of a question set run as a benchmark suite
+type SuiteReport struct {
+	Tag           string       `json:"tag"`
+	Runs          int          `json:"runs"`
+	Won           int          `json:"won"` // Runs that ended with a winner
+	TotalCost     float64      `json:"total_cost"`
+	MeanCost      float64      `json:"mean_cost"`
+	MeanLatencyMs float64      `json:"mean_latency_ms"` // Per run, start to finish
+	Models        []SuiteModel `json:"models"`          // Best win rate first
+}
+
+// Suite reports the win rates, costs and latencies of the runs tagged tag
+func Suite(ctx context.Context, database *db.DB, tag string) (*SuiteReport, error) {
+	requests, err := database.GetRequests(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" || len(requests) == 0 {
+		return nil, fmt.Errorf("no runs tagged %q", tag)
+	}
+
+	results, err := database.GetSuiteResults(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SuiteReport{Tag: tag, Runs: len(requests), Models: make([]SuiteModel, 0, len(results))}
+	var duration int64
+	for _, r := range requests {
+		if r.WinnerModel != "" {
+			report.Won++
+		}
+		report.TotalCost += r.TotalCost
+		duration += r.TotalDurationMs
+	}
+	report.MeanCost = report.TotalCost / float64(report.Runs)
+	report.MeanLatencyMs = float64(duration) / float64(report.Runs)
+
+	for _, r := range results {
+		m := SuiteModel{
+			ModelID:       r.ModelID,
+			ModelName:     r.ModelName,
+			Runs:          r.Runs,
+			Wins:          r.Wins,
+			WinRateCI:     stats.Wilson(r.Wins, r.Runs, stats.Z95),
+			Cost:          r.Cost,
+			Errors:        r.Errors,
+			MeanLatencyMs: r.MeanLatencyMs,
+		}
+		if r.Runs > 0 {
+			m.WinRate = float64(r.Wins) / float64(r.Runs)
+		}
+		report.Models = append(report.Models, m)
+	}
+	sort.SliceStable(report.Models, func(i, j int) bool { return report.Models[i].WinRate > report.Models[j].WinRate })
+
+	return report, nil
+}
+
+// WriteCSV writes one row per model variant, with a header
+func (r *SuiteReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"model_id", "model_name", "runs", "wins", "win_rate", "win_rate_low", "win_rate_high", "cost", "errors", "mean_latency_ms"})
+	for _, m := range r.Models {
+		cw.Write([]string{
+			m.ModelID,
+			m.ModelName,
+			strconv.FormatInt(m.Runs, 10),
+			strconv.FormatInt(m.Wins, 10),
+			strconv.FormatFloat(m.WinRate, 'f', 4, 64),
+			strconv.FormatFloat(m.WinRateCI.Low, 'f', 4, 64),
+			strconv.FormatFloat(m.WinRateCI.High, 'f', 4, 64),
+			strconv.FormatFloat(m.Cost, 'f', 6, 64),
+			strconv.FormatInt(m.Errors, 10),
+			strconv.FormatFloat(m.MeanLatencyMs, 'f', 0, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadQuestions reads a question file: one question per line, skipping blank lines and # comments
+func ReadQuestions(r io.Reader) ([]string, error) {
+	var questions []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		questions = append(questions, line)
+	}
+	return questions, scanner.Err()
+}
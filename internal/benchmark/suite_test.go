@@ -0,0 +1,80 @@
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+func TestSuite(t *testing.T) {
+	dbPath := "test_suite.db"
+	defer os.Remove(dbPath)
+
+	database, err := db.New(dbPath, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	// grok wins 2 of 4 runs, gpt 1, the last ends without a winner
+	for i, winner := range []string{"grok", "grok", "gpt", ""} {
+		id := fmt.Sprintf("suite-%d", i)
+		if err := database.SaveRequest(ctx, db.Request{ID: id, Question: "Q?", NumRounds: 1, NumModels: 2, WinnerModel: winner, TotalCost: 0.5, TotalDurationMs: 4000, Tag: "bench"}); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+		for _, mr := range []db.ModelRound{
+			{RequestID: id, ModelID: "grok", ModelName: "grok-4", Round: 1, DurationMs: 2000, Cost: 0.1},
+			{RequestID: id, ModelID: "gpt", ModelName: "gpt-5", Round: 1, DurationMs: 3000, Cost: 0.2},
+		} {
+			if err := database.SaveModelRound(ctx, mr); err != nil {
+				t.Fatalf("Failed to save model round: %v", err)
+			}
+		}
+	}
+
+	if _, err := Suite(ctx, database, "other"); err == nil {
+		t.Error("Expected error for a tag without runs")
+	}
+
+	report, err := Suite(ctx, database, "bench")
+	if err != nil {
+		t.Fatalf("Suite failed: %v", err)
+	}
+	if report.Runs != 4 || report.Won != 3 || report.TotalCost != 2 || report.MeanCost != 0.5 || report.MeanLatencyMs != 4000 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+	if len(report.Models) != 2 || report.Models[0].ModelName != "grok-4" || report.Models[0].WinRate != 0.5 || report.Models[1].WinRate != 0.25 {
+		t.Fatalf("Expected grok-4 ahead of gpt-5, got %+v", report.Models)
+	}
+	if ci := report.Models[0].WinRateCI; ci.Low >= 0.5 || ci.High <= 0.5 {
+		t.Errorf("Expected the interval to contain the win rate, got %+v", ci)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "model_id,model_name,runs,wins,win_rate") || !strings.HasPrefix(lines[1], "grok,grok-4,4,2,0.5000,") || !strings.HasSuffix(lines[1], ",0.400000,0,2000") {
+		t.Errorf("Unexpected CSV:\n%s", buf.String())
+	}
+}
+
+func TestReadQuestions(t *testing.T) {
+	questions, err := ReadQuestions(strings.NewReader("# Warm-up\nWhy is the sky blue?\n\n  What is 2+2?  \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Why is the sky blue?", "What is 2+2?"}; !reflect.DeepEqual(questions, want) {
+		t.Errorf("Expected %q, got %q", want, questions)
+	}
+}
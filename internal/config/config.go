@@ -2,26 +2,240 @@ package config
 
 import (
 	"fmt"
-	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/lmittmann/tint"
-	"golang.org/x/term"
+	"github.com/meedamian/fat/internal/faults"
+	"golang.org/x/text/language"
 )
 
+// DefaultAttribution is the attribution line appended to winning answers unless FAT_ATTRIBUTION_FORMAT replaces it
+const DefaultAttribution = "Generated by {models} models via fat, winner: {winner}, cost: ${cost}"
+
 type Config struct {
-	ServerAddress       string
-	ModelRequestTimeout time.Duration
-	LogLevel            string
+	ServerAddress        string
+	ModelRequestTimeout  time.Duration
+	MaxOutputTokens      int64 // Cap on output tokens per model call, 0 leaves it to each variant
+	LogLevel             string
+	LogFile              string            // JSON logs are also written here, rotated, when set
+	LogMaxSize           int64             // Megabytes the log file grows to before it's rotated, 0 for no limit
+	LogMaxAge            time.Duration     // Age of the log file at which it's rotated, 0 for no limit
+	LogMaxBackups        int               // Rotated log files kept, 0 keeps them all
+	LogLevels            map[string]string // Level overrides by subsystem (db, orchestrator, providers)
+	PersonasFile         string
+	HeadersFile          string // Extra provider request headers per family
+	ExtrasFile           string // Provider-specific request body options per family or variant
+	GenerationFile       string // Temperature, top_p, max_tokens and reasoning effort per family or variant
+	PostProcessFile      string
+	DefaultsFile         string // Default model variant per family, written by the setup flow
+	RateLimitsFile       string // Per-provider requests/tokens per minute
+	ScorersFile          string // Operator-defined metrics run over the final answers
+	DataDir              string // Holds answers/, the h/ exports and, unless moved, the database and diagnostics
+	DBPath               string // SQLite database file
+	DiagnosticsDir       string // Where a diagnostic bundle is written for every failed run
+	GeminiSafety         string // Gemini safety threshold: off, none, high, medium or low; empty keeps Google's defaults
+	ClaudeThinkingBudget int64  // Extended thinking tokens per Claude call, 0 disables thinking
+
+	// Lets a WebSocket client subscribe to every request's events, empty disables that
+	FirehoseToken string
+
+	// Bearer token for asking questions, the shutdown endpoints, stats and admin endpoints; empty
+	// leaves them open unless access keys were created
+	AuthToken string
+
+	// Request queue limits
+	MaxConcurrentRequests int
+	MaxQueuedRequests     int
+
+	// Longest question accepted, in characters, counting its sub-questions
+	MaxQuestionLength int
+
+	// Minimum similarity (0-1) for a past question to be offered instead of a new run, 0 disables
+	DuplicateThreshold float64
+
+	// How long an identical question to the same models over the same rounds replays its earlier run instead of starting one, 0 disables
+	AnswerCacheTTL time.Duration
+
+	// Minimum similarity (0-1) of every model's consecutive answers at which the remaining rounds are skipped, 0 disables
+	ConvergenceThreshold float64
+
+	// Scales every list price when costing runs (e.g. 0.8 for a negotiated 20% discount), 0 keeps list prices
+	PriceMultiplier float64
+
+	// Count judges' rankings of their own answers towards the result instead of only recording their bias
+	CountSelfVotes bool
+
+	// Ask judges for a one-line reason with every placement in their ranking
+	JudgeJustifications bool
+
+	// Require a one-sentence reason for every placement unless a question says otherwise, discarding rankings without
+	AuditRankings bool
+
+	// Have every participant fact-check the winning answer after ranking unless a question says otherwise
+	Verification bool
+
+	// Weigh each judge's ranking by how well its past rankings agreed with the other judges
+	WeightJudges bool
+
+	// How often judge weights are recomputed from stored rankings in the background, 0 disables
+	JudgeWeightsInterval time.Duration
+
+	// How often every model's stats are snapshotted into the daily history in the background, 0 disables
+	StatsSnapshotInterval time.Duration
+
+	// Directory the answers site is regenerated into in the background, empty disables
+	SiteDir string
+
+	// IANA time zone timestamps in exports and the history listing are shown in, empty for the server's own
+	Timezone string
+
+	// BCP 47 language tag whose number formatting costs in exports and the history listing follow, empty for English
+	Locale string
+
+	// How often the answers site is regenerated
+	SiteInterval time.Duration
+
+	// Line appended to winning answers disclosing how they were made, e.g. for AI-disclosure policies; empty disables.
+	// {models}, {winner} and {cost} are filled in per run.
+	Attribution string
+
+	// Check every provider's key and default variant at startup, before a run finds out halfway
+	Preflight bool
+
+	// Model variants that rank answers instead of the participants, empty means participants rank each other
+	Judges []string
+
+	// How the judges' rankings pick the winner unless a question names a strategy; empty counts Borda points
+	WinnerStrategy string
+
+	// Model variant that reviews the judges' rankings and has the final say under the judge-of-judges strategy
+	MetaJudge string
+
+	// Model variant that merges the top answers into one after ranking unless a question names one; empty skips it
+	Synthesizer string
+
+	// Model variant that summarizes older rounds of prompts overflowing a context window; empty only trims them
+	Summarizer string
+
+	// Families or variants asked for JSON replies instead of markdown sections, "*" for all
+	StructuredReplies []string
+
+	// Variant to switch a family to when its provider no longer knows the requested one (family ID -> variant)
+	Fallbacks map[string]string
+
+	// How long shutdown waits for running requests before cancelling them
+	ShutdownTimeout time.Duration
+
+	// How long an interrupted request stays resumable before its snapshot is deleted, 0 keeps it forever
+	ResumeTTL time.Duration
+
+	// Latency, timeouts, errors and malformed replies injected into provider calls for resilience testing; none by default
+	Faults faults.Config
+
+	// Shell command `fat ask` runs when a run ends, with a JSON summary on stdin; empty runs nothing
+	NotifyCommand string
+
+	// Spend reconciliation against provider usage APIs, which need admin keys
+	OpenAIAdminKey     string
+	AnthropicAdminKey  string
+	ReconcileInterval  time.Duration // How often the previous day is reconciled in the background, 0 disables
+	ReconcileThreshold float64       // Drift in percent of the billed spend that is logged as a warning
+
+	// Web search for agents: searxng, brave or tavily; empty disables search
+	SearchProvider string
+	SearchURL      string // SearxNG instance URL, or an override of the provider's API endpoint
+	SearchAPIKey   string
+	SearchResults  int // Results per query
+
+	// Embeddings comparing final answers: openai or local (any OpenAI-compatible endpoint); empty disables them
+	EmbeddingsProvider string
+	EmbeddingsURL      string // Endpoint of a local server, or an override of OpenAI's
+	EmbeddingsModel    string
+	EmbeddingsAPIKey   string // Defaults to the gpt family's key for openai
+
+	// S3-compatible bucket exports are uploaded to; empty keeps them local only
+	S3Bucket    string
+	S3Endpoint  string // Defaults to AWS's endpoint for the region
+	S3Region    string
+	S3Prefix    string
+	S3AccessKey string // Defaults to AWS_ACCESS_KEY_ID
+	S3SecretKey string // Defaults to AWS_SECRET_ACCESS_KEY
+	S3PublicURL string // Base of the export URLs sent to clients, e.g. a CDN; defaults to the bucket's own URL
+	S3KeepLocal bool   // Keep uploaded exports in h/ too
 }
 
 func Load() (Config, error) {
+	dataDir := envOrDefault("FAT_DATA_DIR", ".")
 	cfg := Config{
 		ServerAddress:       envOrDefault("FAT_SERVER_ADDR", ":4444"),
 		ModelRequestTimeout: 120 * time.Second, // Increased to 120s for GPT-5 models
 		LogLevel:            envOrDefault("FAT_LOG_LEVEL", "info"),
+		LogFile:             os.Getenv("FAT_LOG_FILE"),
+		LogMaxSize:          100,
+		LogMaxAge:           24 * time.Hour,
+		LogMaxBackups:       7,
+		PersonasFile:        envOrDefault("FAT_PERSONAS_FILE", "personas.json"),
+		HeadersFile:         envOrDefault("FAT_HEADERS_FILE", "headers.json"),
+		ExtrasFile:          envOrDefault("FAT_EXTRAS_FILE", "extras.json"),
+		GenerationFile:      envOrDefault("FAT_GENERATION_FILE", "generation.json"),
+		PostProcessFile:     envOrDefault("FAT_POSTPROCESS_FILE", "postprocess.json"),
+		DefaultsFile:        envOrDefault("FAT_DEFAULTS_FILE", "defaults.json"),
+		RateLimitsFile:      envOrDefault("FAT_RATE_LIMITS_FILE", "ratelimits.json"),
+		ScorersFile:         envOrDefault("FAT_SCORERS_FILE", "scorers.json"),
+		DataDir:             dataDir,
+		DBPath:              envOrDefault("FAT_DB_PATH", filepath.Join(dataDir, "fat.db")),
+		DiagnosticsDir:      envOrDefault("FAT_DIAGNOSTICS_DIR", filepath.Join(dataDir, "diagnostics")),
+		FirehoseToken:       os.Getenv("FAT_FIREHOSE_TOKEN"),
+		AuthToken:           os.Getenv("FAT_AUTH_TOKEN"),
+
+		MaxConcurrentRequests: 1,
+		MaxQueuedRequests:     20,
+		MaxQuestionLength:     20000,
+
+		DuplicateThreshold: 0.9,
+
+		ShutdownTimeout: 2 * time.Minute,
+		ResumeTTL:       7 * 24 * time.Hour,
+
+		NotifyCommand: os.Getenv("FAT_NOTIFY_CMD"),
+
+		WinnerStrategy: strings.TrimSpace(os.Getenv("FAT_WINNER_STRATEGY")),
+		MetaJudge:      strings.TrimSpace(os.Getenv("FAT_META_JUDGE")),
+		Synthesizer:    strings.TrimSpace(os.Getenv("FAT_SYNTHESIZER")),
+		Summarizer:     strings.TrimSpace(os.Getenv("FAT_SUMMARIZER")),
+
+		OpenAIAdminKey:        os.Getenv("FAT_OPENAI_ADMIN_KEY"),
+		AnthropicAdminKey:     os.Getenv("FAT_ANTHROPIC_ADMIN_KEY"),
+		ReconcileThreshold:    5,
+		JudgeWeightsInterval:  time.Hour,
+		StatsSnapshotInterval: time.Hour,
+		SiteDir:               os.Getenv("FAT_SITE_DIR"),
+		Timezone:              strings.TrimSpace(os.Getenv("FAT_TIMEZONE")),
+		Locale:                strings.TrimSpace(os.Getenv("FAT_LOCALE")),
+		SiteInterval:          time.Hour,
+
+		SearchProvider: os.Getenv("FAT_SEARCH_PROVIDER"),
+		SearchURL:      os.Getenv("FAT_SEARCH_URL"),
+		SearchAPIKey:   os.Getenv("FAT_SEARCH_API_KEY"),
+		SearchResults:  5,
+
+		EmbeddingsProvider: os.Getenv("FAT_EMBEDDINGS_PROVIDER"),
+		EmbeddingsURL:      os.Getenv("FAT_EMBEDDINGS_URL"),
+		EmbeddingsModel:    os.Getenv("FAT_EMBEDDINGS_MODEL"),
+		EmbeddingsAPIKey:   os.Getenv("FAT_EMBEDDINGS_API_KEY"),
+
+		S3Bucket:    os.Getenv("FAT_S3_BUCKET"),
+		S3Endpoint:  os.Getenv("FAT_S3_ENDPOINT"),
+		S3Region:    os.Getenv("FAT_S3_REGION"),
+		S3Prefix:    os.Getenv("FAT_S3_PREFIX"),
+		S3AccessKey: envOrDefault("FAT_S3_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")),
+		S3SecretKey: envOrDefault("FAT_S3_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		S3PublicURL: os.Getenv("FAT_S3_PUBLIC_URL"),
+		S3KeepLocal: true,
 	}
 
 	if timeoutStr := os.Getenv("FAT_MODEL_TIMEOUT"); timeoutStr != "" {
@@ -32,46 +246,336 @@ func Load() (Config, error) {
 		cfg.ModelRequestTimeout = duration
 	}
 
+	if maxOutStr := os.Getenv("FAT_MAX_OUTPUT_TOKENS"); maxOutStr != "" {
+		n, err := strconv.ParseInt(maxOutStr, 10, 64)
+		if err != nil || n < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_MAX_OUTPUT_TOKENS value %q: must be a non-negative integer", maxOutStr)
+		}
+		cfg.MaxOutputTokens = n
+	}
+
+	if budgetStr := os.Getenv("FAT_CLAUDE_THINKING_BUDGET"); budgetStr != "" {
+		n, err := strconv.ParseInt(budgetStr, 10, 64)
+		if err != nil || n < 0 || (n > 0 && n < 1024) {
+			return Config{}, fmt.Errorf("invalid FAT_CLAUDE_THINKING_BUDGET value %q: must be 0 or at least 1024", budgetStr)
+		}
+		cfg.ClaudeThinkingBudget = n
+	}
+
+	if safetyStr := os.Getenv("FAT_GEMINI_SAFETY"); safetyStr != "" {
+		switch safety := strings.ToLower(strings.TrimSpace(safetyStr)); safety {
+		case "off", "none", "high", "medium", "low":
+			cfg.GeminiSafety = safety
+		default:
+			return Config{}, fmt.Errorf("invalid FAT_GEMINI_SAFETY value %q: must be off, none, high, medium or low", safetyStr)
+		}
+	}
+
+	if concurrentStr := os.Getenv("FAT_MAX_CONCURRENT"); concurrentStr != "" {
+		n, err := strconv.Atoi(concurrentStr)
+		if err != nil || n < 1 {
+			return Config{}, fmt.Errorf("invalid FAT_MAX_CONCURRENT value %q: must be a positive integer", concurrentStr)
+		}
+		cfg.MaxConcurrentRequests = n
+	}
+
+	if queueStr := os.Getenv("FAT_MAX_QUEUE"); queueStr != "" {
+		n, err := strconv.Atoi(queueStr)
+		if err != nil || n < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_MAX_QUEUE value %q: must be a non-negative integer", queueStr)
+		}
+		cfg.MaxQueuedRequests = n
+	}
+
+	if lengthStr := os.Getenv("FAT_MAX_QUESTION_LENGTH"); lengthStr != "" {
+		n, err := strconv.Atoi(lengthStr)
+		if err != nil || n <= 0 {
+			return Config{}, fmt.Errorf("invalid FAT_MAX_QUESTION_LENGTH value %q: must be a positive integer", lengthStr)
+		}
+		cfg.MaxQuestionLength = n
+	}
+
+	if thresholdStr := os.Getenv("FAT_DUPLICATE_THRESHOLD"); thresholdStr != "" {
+		f, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || f < 0 || f > 1 {
+			return Config{}, fmt.Errorf("invalid FAT_DUPLICATE_THRESHOLD value %q: must be between 0 and 1", thresholdStr)
+		}
+		cfg.DuplicateThreshold = f
+	}
+
+	if thresholdStr := os.Getenv("FAT_CONVERGENCE_THRESHOLD"); thresholdStr != "" {
+		f, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || f < 0 || f > 1 {
+			return Config{}, fmt.Errorf("invalid FAT_CONVERGENCE_THRESHOLD value %q: must be between 0 and 1", thresholdStr)
+		}
+		cfg.ConvergenceThreshold = f
+	}
+
+	if multiplierStr := os.Getenv("FAT_PRICE_MULTIPLIER"); multiplierStr != "" {
+		f, err := strconv.ParseFloat(multiplierStr, 64)
+		if err != nil || f <= 0 {
+			return Config{}, fmt.Errorf("invalid FAT_PRICE_MULTIPLIER value %q: must be a positive number", multiplierStr)
+		}
+		cfg.PriceMultiplier = f
+	}
+
+	if selfStr := os.Getenv("FAT_COUNT_SELF_VOTES"); selfStr != "" {
+		b, err := strconv.ParseBool(selfStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_COUNT_SELF_VOTES value %q: must be true or false", selfStr)
+		}
+		cfg.CountSelfVotes = b
+	}
+
+	if justifyStr := os.Getenv("FAT_JUDGE_JUSTIFICATIONS"); justifyStr != "" {
+		b, err := strconv.ParseBool(justifyStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_JUDGE_JUSTIFICATIONS value %q: must be true or false", justifyStr)
+		}
+		cfg.JudgeJustifications = b
+	}
+
+	if auditStr := os.Getenv("FAT_AUDIT_RANKINGS"); auditStr != "" {
+		b, err := strconv.ParseBool(auditStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_AUDIT_RANKINGS value %q: must be true or false", auditStr)
+		}
+		cfg.AuditRankings = b
+	}
+
+	if verifyStr := os.Getenv("FAT_VERIFICATION"); verifyStr != "" {
+		b, err := strconv.ParseBool(verifyStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_VERIFICATION value %q: must be true or false", verifyStr)
+		}
+		cfg.Verification = b
+	}
+
+	if cfg.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_TIMEZONE value %q: must be an IANA time zone like Europe/Warsaw", cfg.Timezone)
+		}
+	}
+
+	if cfg.Locale != "" {
+		if _, err := language.Parse(cfg.Locale); err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_LOCALE value %q: must be a language tag like en-US or de", cfg.Locale)
+		}
+	}
+
+	if weightStr := os.Getenv("FAT_WEIGHT_JUDGES"); weightStr != "" {
+		b, err := strconv.ParseBool(weightStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_WEIGHT_JUDGES value %q: must be true or false", weightStr)
+		}
+		cfg.WeightJudges = b
+	}
+
+	if intervalStr := os.Getenv("FAT_JUDGE_WEIGHTS_INTERVAL"); intervalStr != "" {
+		duration, err := time.ParseDuration(intervalStr)
+		if err != nil || duration < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_JUDGE_WEIGHTS_INTERVAL value %q: must be a non-negative duration", intervalStr)
+		}
+		cfg.JudgeWeightsInterval = duration
+	}
+
+	if intervalStr := os.Getenv("FAT_STATS_SNAPSHOT_INTERVAL"); intervalStr != "" {
+		duration, err := time.ParseDuration(intervalStr)
+		if err != nil || duration < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_STATS_SNAPSHOT_INTERVAL value %q: must be a non-negative duration", intervalStr)
+		}
+		cfg.StatsSnapshotInterval = duration
+	}
+
+	if intervalStr := os.Getenv("FAT_SITE_INTERVAL"); intervalStr != "" {
+		duration, err := time.ParseDuration(intervalStr)
+		if err != nil || duration <= 0 {
+			return Config{}, fmt.Errorf("invalid FAT_SITE_INTERVAL value %q: must be a positive duration", intervalStr)
+		}
+		cfg.SiteInterval = duration
+	}
+
+	if keepStr := os.Getenv("FAT_S3_KEEP_LOCAL"); keepStr != "" {
+		b, err := strconv.ParseBool(keepStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_S3_KEEP_LOCAL value %q: must be true or false", keepStr)
+		}
+		cfg.S3KeepLocal = b
+	}
+
+	if attributionStr := os.Getenv("FAT_ATTRIBUTION"); attributionStr != "" {
+		b, err := strconv.ParseBool(attributionStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_ATTRIBUTION value %q: must be true or false", attributionStr)
+		}
+		if b {
+			cfg.Attribution = envOrDefault("FAT_ATTRIBUTION_FORMAT", DefaultAttribution)
+		}
+	}
+
+	if preflightStr := os.Getenv("FAT_PREFLIGHT"); preflightStr != "" {
+		b, err := strconv.ParseBool(preflightStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_PREFLIGHT value %q: must be true or false", preflightStr)
+		}
+		cfg.Preflight = b
+	}
+
+	if judgesStr := os.Getenv("FAT_JUDGES"); judgesStr != "" {
+		for _, judge := range strings.Split(judgesStr, ",") {
+			if judge = strings.TrimSpace(judge); judge != "" {
+				cfg.Judges = append(cfg.Judges, judge)
+			}
+		}
+	}
+
+	if structuredStr := os.Getenv("FAT_STRUCTURED_REPLIES"); structuredStr != "" {
+		for _, model := range strings.Split(structuredStr, ",") {
+			if model = strings.TrimSpace(model); model != "" {
+				cfg.StructuredReplies = append(cfg.StructuredReplies, model)
+			}
+		}
+	}
+
+	if sizeStr := os.Getenv("FAT_LOG_MAX_SIZE"); sizeStr != "" {
+		n, err := strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil || n < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_LOG_MAX_SIZE value %q: must be a non-negative number of megabytes", sizeStr)
+		}
+		cfg.LogMaxSize = n
+	}
+
+	if ageStr := os.Getenv("FAT_LOG_MAX_AGE"); ageStr != "" {
+		duration, err := time.ParseDuration(ageStr)
+		if err != nil || duration < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_LOG_MAX_AGE value %q: must be a non-negative duration", ageStr)
+		}
+		cfg.LogMaxAge = duration
+	}
+
+	if backupsStr := os.Getenv("FAT_LOG_MAX_BACKUPS"); backupsStr != "" {
+		n, err := strconv.Atoi(backupsStr)
+		if err != nil || n < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_LOG_MAX_BACKUPS value %q: must be a non-negative integer", backupsStr)
+		}
+		cfg.LogMaxBackups = n
+	}
+
+	if levelsStr := os.Getenv("FAT_LOG_LEVELS"); levelsStr != "" {
+		cfg.LogLevels = make(map[string]string)
+		for _, pair := range strings.Split(levelsStr, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			subsystem, level, ok := strings.Cut(pair, "=")
+			subsystem, level = strings.TrimSpace(subsystem), strings.TrimSpace(level)
+			if !ok || !slices.Contains(Subsystems, subsystem) {
+				return Config{}, fmt.Errorf("invalid FAT_LOG_LEVELS entry %q: must be subsystem=level with subsystem one of %s", pair, strings.Join(Subsystems, ", "))
+			}
+			if _, err := parseLevel(level); err != nil {
+				return Config{}, fmt.Errorf("invalid FAT_LOG_LEVELS entry %q: %w", pair, err)
+			}
+			cfg.LogLevels[subsystem] = level
+		}
+	}
+
+	if fallbacksStr := os.Getenv("FAT_FALLBACK_MODELS"); fallbacksStr != "" {
+		cfg.Fallbacks = make(map[string]string)
+		for _, pair := range strings.Split(fallbacksStr, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			family, variant, ok := strings.Cut(pair, "=")
+			family, variant = strings.TrimSpace(family), strings.TrimSpace(variant)
+			if !ok || family == "" || variant == "" {
+				return Config{}, fmt.Errorf("invalid FAT_FALLBACK_MODELS entry %q: must be family=variant", pair)
+			}
+			cfg.Fallbacks[family] = variant
+		}
+	}
+
+	if timeoutStr := os.Getenv("FAT_SHUTDOWN_TIMEOUT"); timeoutStr != "" {
+		duration, err := time.ParseDuration(timeoutStr)
+		if err != nil || duration < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_SHUTDOWN_TIMEOUT value %q: must be a non-negative duration", timeoutStr)
+		}
+		cfg.ShutdownTimeout = duration
+	}
+
+	if ttlStr := os.Getenv("FAT_RESUME_TTL"); ttlStr != "" {
+		duration, err := time.ParseDuration(ttlStr)
+		if err != nil || duration < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_RESUME_TTL value %q: must be a non-negative duration", ttlStr)
+		}
+		cfg.ResumeTTL = duration
+	}
+
+	if ttlStr := os.Getenv("FAT_ANSWER_CACHE_TTL"); ttlStr != "" {
+		duration, err := time.ParseDuration(ttlStr)
+		if err != nil || duration < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_ANSWER_CACHE_TTL value %q: must be a non-negative duration", ttlStr)
+		}
+		cfg.AnswerCacheTTL = duration
+	}
+
+	if intervalStr := os.Getenv("FAT_RECONCILE_INTERVAL"); intervalStr != "" {
+		duration, err := time.ParseDuration(intervalStr)
+		if err != nil || duration < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_RECONCILE_INTERVAL value %q: must be a non-negative duration", intervalStr)
+		}
+		cfg.ReconcileInterval = duration
+	}
+
+	if thresholdStr := os.Getenv("FAT_RECONCILE_THRESHOLD"); thresholdStr != "" {
+		f, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || f < 0 {
+			return Config{}, fmt.Errorf("invalid FAT_RECONCILE_THRESHOLD value %q: must be a non-negative percentage", thresholdStr)
+		}
+		cfg.ReconcileThreshold = f
+	}
+
+	if resultsStr := os.Getenv("FAT_SEARCH_RESULTS"); resultsStr != "" {
+		n, err := strconv.Atoi(resultsStr)
+		if err != nil || n < 1 {
+			return Config{}, fmt.Errorf("invalid FAT_SEARCH_RESULTS value %q: must be a positive integer", resultsStr)
+		}
+		cfg.SearchResults = n
+	}
+
+	if faultsStr := os.Getenv("FAT_FAULTS"); faultsStr != "" {
+		c, err := faults.Parse(faultsStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid FAT_FAULTS value %q: %w", faultsStr, err)
+		}
+		cfg.Faults = c
+	}
+
 	return cfg, nil
 }
 
+// Structured reports whether a variant of a family should be asked for JSON replies
+func (c Config) Structured(familyID, variant string) bool {
+	for _, model := range c.StructuredReplies {
+		if model == "*" || model == familyID || model == variant {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted is a copy of the config safe to share in bug reports, with every key and the notify command masked
+func (c Config) Redacted() Config {
+	for _, secret := range []*string{&c.OpenAIAdminKey, &c.AnthropicAdminKey, &c.SearchAPIKey, &c.EmbeddingsAPIKey, &c.S3AccessKey, &c.S3SecretKey, &c.FirehoseToken, &c.AuthToken, &c.NotifyCommand} {
+		if *secret != "" {
+			*secret = "[redacted]"
+		}
+	}
+	return c
+}
+
 func envOrDefault(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return fallback
 }
-
-func NewLogger(level string) (*slog.Logger, error) {
-	var slogLevel slog.Level
-	switch strings.ToLower(level) {
-	case "debug":
-		slogLevel = slog.LevelDebug
-	case "warn":
-		slogLevel = slog.LevelWarn
-	case "error":
-		slogLevel = slog.LevelError
-	case "info", "":
-		slogLevel = slog.LevelInfo
-	default:
-		return nil, fmt.Errorf("unknown log level %q", level)
-	}
-
-	// Use beautiful colored output for terminal, JSON for pipes/files
-	var handler slog.Handler
-	if term.IsTerminal(int(os.Stdout.Fd())) {
-		// Terminal: use tint for beautiful colored output
-		handler = tint.NewHandler(os.Stdout, &tint.Options{
-			Level:      slogLevel,
-			TimeFormat: "15:04", // 24-hour format
-			AddSource:  slogLevel == slog.LevelDebug,
-		})
-	} else {
-		// Non-terminal (pipe, file, production): use JSON
-		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slogLevel,
-		})
-	}
-
-	return slog.New(handler), nil
-}
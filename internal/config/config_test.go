@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -11,6 +13,16 @@ func TestLoad(t *testing.T) {
 	os.Unsetenv("FAT_SERVER_ADDR")
 	os.Unsetenv("FAT_MODEL_TIMEOUT")
 	os.Unsetenv("FAT_LOG_LEVEL")
+	os.Unsetenv("FAT_PERSONAS_FILE")
+	os.Unsetenv("FAT_POSTPROCESS_FILE")
+	os.Unsetenv("FAT_DEFAULTS_FILE")
+	os.Unsetenv("FAT_RATE_LIMITS_FILE")
+	os.Unsetenv("FAT_MAX_CONCURRENT")
+	os.Unsetenv("FAT_MAX_QUEUE")
+	os.Unsetenv("FAT_DUPLICATE_THRESHOLD")
+	os.Unsetenv("FAT_JUDGES")
+	os.Unsetenv("FAT_SHUTDOWN_TIMEOUT")
+	os.Unsetenv("FAT_MAX_OUTPUT_TOKENS")
 
 	cfg, err := Load()
 	if err != nil {
@@ -29,6 +41,50 @@ func TestLoad(t *testing.T) {
 	if cfg.LogLevel != "info" {
 		t.Errorf("Expected default LogLevel 'info', got %s", cfg.LogLevel)
 	}
+
+	if cfg.PersonasFile != "personas.json" {
+		t.Errorf("Expected default PersonasFile 'personas.json', got %s", cfg.PersonasFile)
+	}
+
+	if cfg.PostProcessFile != "postprocess.json" {
+		t.Errorf("Expected default PostProcessFile 'postprocess.json', got %s", cfg.PostProcessFile)
+	}
+
+	if cfg.DefaultsFile != "defaults.json" {
+		t.Errorf("Expected default DefaultsFile 'defaults.json', got %s", cfg.DefaultsFile)
+	}
+
+	if cfg.RateLimitsFile != "ratelimits.json" {
+		t.Errorf("Expected default RateLimitsFile 'ratelimits.json', got %s", cfg.RateLimitsFile)
+	}
+
+	if cfg.ScorersFile != "scorers.json" {
+		t.Errorf("Expected default ScorersFile 'scorers.json', got %s", cfg.ScorersFile)
+	}
+
+	if cfg.MaxConcurrentRequests != 1 {
+		t.Errorf("Expected default MaxConcurrentRequests 1, got %d", cfg.MaxConcurrentRequests)
+	}
+
+	if cfg.MaxQueuedRequests != 20 {
+		t.Errorf("Expected default MaxQueuedRequests 20, got %d", cfg.MaxQueuedRequests)
+	}
+
+	if cfg.DuplicateThreshold != 0.9 {
+		t.Errorf("Expected default DuplicateThreshold 0.9, got %f", cfg.DuplicateThreshold)
+	}
+
+	if len(cfg.Judges) != 0 {
+		t.Errorf("Expected no default Judges, got %v", cfg.Judges)
+	}
+
+	if cfg.ShutdownTimeout != 2*time.Minute {
+		t.Errorf("Expected default ShutdownTimeout 2m, got %v", cfg.ShutdownTimeout)
+	}
+
+	if cfg.ResumeTTL != 7*24*time.Hour {
+		t.Errorf("Expected default ResumeTTL 168h, got %v", cfg.ResumeTTL)
+	}
 }
 
 func TestLoadWithEnvVars(t *testing.T) {
@@ -69,6 +125,420 @@ func TestLoadWithInvalidTimeout(t *testing.T) {
 	}
 }
 
+func TestLoadWithInvalidDuplicateThreshold(t *testing.T) {
+	os.Setenv("FAT_DUPLICATE_THRESHOLD", "1.5")
+	defer os.Unsetenv("FAT_DUPLICATE_THRESHOLD")
+
+	_, err := Load()
+	if err == nil {
+		t.Error("Expected error for out-of-range duplicate threshold, got nil")
+	}
+}
+
+func TestLoadWithInvalidShutdownTimeout(t *testing.T) {
+	os.Setenv("FAT_SHUTDOWN_TIMEOUT", "-1s")
+	defer os.Unsetenv("FAT_SHUTDOWN_TIMEOUT")
+
+	_, err := Load()
+	if err == nil {
+		t.Error("Expected error for negative shutdown timeout, got nil")
+	}
+}
+
+func TestLoadMaxOutputTokens(t *testing.T) {
+	t.Setenv("FAT_MAX_OUTPUT_TOKENS", "4096")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.MaxOutputTokens != 4096 {
+		t.Errorf("Expected MaxOutputTokens 4096, got %d", cfg.MaxOutputTokens)
+	}
+
+	t.Setenv("FAT_MAX_OUTPUT_TOKENS", "-1")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for negative max output tokens, got nil")
+	}
+}
+
+func TestLoadJudges(t *testing.T) {
+	os.Setenv("FAT_JUDGES", "gpt-5, claude-opus-4-5,,")
+	defer os.Unsetenv("FAT_JUDGES")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(cfg.Judges) != 2 || cfg.Judges[0] != "gpt-5" || cfg.Judges[1] != "claude-opus-4-5" {
+		t.Errorf("Expected Judges [gpt-5 claude-opus-4-5], got %v", cfg.Judges)
+	}
+}
+
+func TestLoadWinnerStrategy(t *testing.T) {
+	t.Setenv("FAT_WINNER_STRATEGY", " consensus ")
+	t.Setenv("FAT_META_JUDGE", " claude-opus-4-6 ")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.WinnerStrategy != "consensus" {
+		t.Errorf("Expected WinnerStrategy consensus, got %q", cfg.WinnerStrategy)
+	}
+	if cfg.MetaJudge != "claude-opus-4-6" {
+		t.Errorf("Expected MetaJudge claude-opus-4-6, got %q", cfg.MetaJudge)
+	}
+}
+
+func TestLoadSynthesizer(t *testing.T) {
+	t.Setenv("FAT_SYNTHESIZER", " claude-opus-4-6 ")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Synthesizer != "claude-opus-4-6" {
+		t.Errorf("Expected Synthesizer claude-opus-4-6, got %q", cfg.Synthesizer)
+	}
+}
+
+func TestLoadSummarizer(t *testing.T) {
+	t.Setenv("FAT_SUMMARIZER", " gpt-5-nano ")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Summarizer != "gpt-5-nano" {
+		t.Errorf("Expected Summarizer gpt-5-nano, got %q", cfg.Summarizer)
+	}
+}
+
+func TestLoadLocale(t *testing.T) {
+	t.Setenv("FAT_TIMEZONE", " Europe/Warsaw ")
+	t.Setenv("FAT_LOCALE", "de-DE")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Timezone != "Europe/Warsaw" || cfg.Locale != "de-DE" {
+		t.Errorf("Expected Europe/Warsaw and de-DE, got %q and %q", cfg.Timezone, cfg.Locale)
+	}
+
+	t.Setenv("FAT_TIMEZONE", "Mars/Olympus_Mons")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an unknown time zone to be rejected")
+	}
+
+	t.Setenv("FAT_TIMEZONE", "")
+	t.Setenv("FAT_LOCALE", "not a tag!")
+	if _, err := Load(); err == nil {
+		t.Error("Expected a malformed locale to be rejected")
+	}
+}
+
+func TestLoadClaudeThinkingBudget(t *testing.T) {
+	t.Setenv("FAT_CLAUDE_THINKING_BUDGET", "16000")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.ClaudeThinkingBudget != 16000 {
+		t.Errorf("Expected ClaudeThinkingBudget 16000, got %d", cfg.ClaudeThinkingBudget)
+	}
+
+	t.Setenv("FAT_CLAUDE_THINKING_BUDGET", "500")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a budget below 1024, got nil")
+	}
+}
+
+func TestLoadGeminiSafety(t *testing.T) {
+	t.Setenv("FAT_GEMINI_SAFETY", " None ")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.GeminiSafety != "none" {
+		t.Errorf("Expected GeminiSafety 'none', got %q", cfg.GeminiSafety)
+	}
+
+	t.Setenv("FAT_GEMINI_SAFETY", "strict")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unknown safety threshold, got nil")
+	}
+}
+
+func TestLoadSearch(t *testing.T) {
+	t.Setenv("FAT_SEARCH_PROVIDER", "brave")
+	t.Setenv("FAT_SEARCH_API_KEY", "secret")
+	t.Setenv("FAT_SEARCH_RESULTS", "3")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.SearchProvider != "brave" || cfg.SearchAPIKey != "secret" || cfg.SearchResults != 3 {
+		t.Errorf("Expected brave search with 3 results, got %q/%q/%d", cfg.SearchProvider, cfg.SearchAPIKey, cfg.SearchResults)
+	}
+
+	t.Setenv("FAT_SEARCH_RESULTS", "0")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for zero search results, got nil")
+	}
+}
+
+func TestLoadStructuredReplies(t *testing.T) {
+	t.Setenv("FAT_STRUCTURED_REPLIES", "gpt, gemini-2.5-pro,")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if !cfg.Structured("gpt", "gpt-5") || !cfg.Structured("gemini", "gemini-2.5-pro") {
+		t.Error("Expected structured replies for the listed family and variant")
+	}
+	if cfg.Structured("gemini", "gemini-2.5-flash") || cfg.Structured("grok", "grok-4") {
+		t.Error("Expected markdown replies for unlisted models")
+	}
+
+	cfg.StructuredReplies = []string{"*"}
+	if !cfg.Structured("grok", "grok-4") {
+		t.Error("Expected \"*\" to enable structured replies for every model")
+	}
+}
+
+func TestLoadConvergenceThreshold(t *testing.T) {
+	t.Setenv("FAT_CONVERGENCE_THRESHOLD", "0.85")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.ConvergenceThreshold != 0.85 {
+		t.Errorf("Expected ConvergenceThreshold 0.85, got %v", cfg.ConvergenceThreshold)
+	}
+
+	t.Setenv("FAT_CONVERGENCE_THRESHOLD", "1.5")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for threshold above 1, got nil")
+	}
+}
+
+func TestLoadPriceMultiplier(t *testing.T) {
+	t.Setenv("FAT_PRICE_MULTIPLIER", "0.8")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.PriceMultiplier != 0.8 {
+		t.Errorf("Expected PriceMultiplier 0.8, got %v", cfg.PriceMultiplier)
+	}
+
+	t.Setenv("FAT_PRICE_MULTIPLIER", "0")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for zero multiplier, got nil")
+	}
+}
+
+func TestLoadCountSelfVotes(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.CountSelfVotes {
+		t.Error("Expected self-votes to be excluded by default")
+	}
+
+	t.Setenv("FAT_COUNT_SELF_VOTES", "true")
+	if cfg, err = Load(); err != nil || !cfg.CountSelfVotes {
+		t.Errorf("Expected self-votes to count, got %v (%v)", cfg.CountSelfVotes, err)
+	}
+
+	t.Setenv("FAT_COUNT_SELF_VOTES", "maybe")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a non-boolean value, got nil")
+	}
+}
+
+func TestLoadJudgeJustifications(t *testing.T) {
+	t.Setenv("FAT_JUDGE_JUSTIFICATIONS", "true")
+	if cfg, err := Load(); err != nil || !cfg.JudgeJustifications {
+		t.Errorf("Expected judge justifications on, got %v (%v)", cfg.JudgeJustifications, err)
+	}
+
+	t.Setenv("FAT_JUDGE_JUSTIFICATIONS", "sometimes")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a non-boolean value, got nil")
+	}
+}
+
+func TestLoadAuditRankings(t *testing.T) {
+	if cfg, err := Load(); err != nil || cfg.AuditRankings {
+		t.Errorf("Expected audited rankings off by default, got %v (%v)", cfg.AuditRankings, err)
+	}
+
+	t.Setenv("FAT_AUDIT_RANKINGS", "true")
+	if cfg, err := Load(); err != nil || !cfg.AuditRankings {
+		t.Errorf("Expected audited rankings on, got %v (%v)", cfg.AuditRankings, err)
+	}
+
+	t.Setenv("FAT_AUDIT_RANKINGS", "maybe")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a non-boolean value, got nil")
+	}
+}
+
+func TestLoadVerification(t *testing.T) {
+	if cfg, err := Load(); err != nil || cfg.Verification {
+		t.Errorf("Expected verification off by default, got %v (%v)", cfg.Verification, err)
+	}
+
+	t.Setenv("FAT_VERIFICATION", "true")
+	if cfg, err := Load(); err != nil || !cfg.Verification {
+		t.Errorf("Expected verification on, got %v (%v)", cfg.Verification, err)
+	}
+
+	t.Setenv("FAT_VERIFICATION", "maybe")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a non-boolean value, got nil")
+	}
+}
+
+func TestLoadMaxQuestionLength(t *testing.T) {
+	if cfg, err := Load(); err != nil || cfg.MaxQuestionLength != 20000 {
+		t.Errorf("Expected MaxQuestionLength 20000 by default, got %d (%v)", cfg.MaxQuestionLength, err)
+	}
+
+	t.Setenv("FAT_MAX_QUESTION_LENGTH", "500")
+	if cfg, err := Load(); err != nil || cfg.MaxQuestionLength != 500 {
+		t.Errorf("Expected MaxQuestionLength 500, got %d (%v)", cfg.MaxQuestionLength, err)
+	}
+
+	t.Setenv("FAT_MAX_QUESTION_LENGTH", "0")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a zero max question length, got nil")
+	}
+}
+
+func TestLoadWeightJudges(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.WeightJudges || cfg.JudgeWeightsInterval != time.Hour {
+		t.Errorf("Expected unweighted judges with hourly weights by default, got %v, %v (%v)", cfg.WeightJudges, cfg.JudgeWeightsInterval, err)
+	}
+
+	t.Setenv("FAT_WEIGHT_JUDGES", "true")
+	t.Setenv("FAT_JUDGE_WEIGHTS_INTERVAL", "15m")
+	if cfg, err := Load(); err != nil || !cfg.WeightJudges || cfg.JudgeWeightsInterval != 15*time.Minute {
+		t.Errorf("Expected weighted judges every 15m, got %v, %v (%v)", cfg.WeightJudges, cfg.JudgeWeightsInterval, err)
+	}
+
+	t.Setenv("FAT_JUDGE_WEIGHTS_INTERVAL", "-1m")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a negative interval, got nil")
+	}
+
+	t.Setenv("FAT_JUDGE_WEIGHTS_INTERVAL", "")
+	t.Setenv("FAT_WEIGHT_JUDGES", "mostly")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a non-boolean value, got nil")
+	}
+}
+
+func TestLoadStatsSnapshotInterval(t *testing.T) {
+	if cfg, err := Load(); err != nil || cfg.StatsSnapshotInterval != time.Hour {
+		t.Errorf("Expected hourly snapshots by default, got %v (%v)", cfg.StatsSnapshotInterval, err)
+	}
+
+	t.Setenv("FAT_STATS_SNAPSHOT_INTERVAL", "0")
+	if cfg, err := Load(); err != nil || cfg.StatsSnapshotInterval != 0 {
+		t.Errorf("Expected snapshots disabled, got %v (%v)", cfg.StatsSnapshotInterval, err)
+	}
+
+	t.Setenv("FAT_STATS_SNAPSHOT_INTERVAL", "daily")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a non-duration value, got nil")
+	}
+}
+
+func TestLoadPreflight(t *testing.T) {
+	t.Setenv("FAT_PREFLIGHT", "1")
+	if cfg, err := Load(); err != nil || !cfg.Preflight {
+		t.Errorf("Expected preflight to be enabled, got %v (%v)", cfg.Preflight, err)
+	}
+
+	t.Setenv("FAT_PREFLIGHT", "soon")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a non-boolean value, got nil")
+	}
+}
+
+func TestLoadAnswerCacheTTL(t *testing.T) {
+	t.Setenv("FAT_ANSWER_CACHE_TTL", "24h")
+	if cfg, err := Load(); err != nil || cfg.AnswerCacheTTL != 24*time.Hour {
+		t.Errorf("Expected a 24h answer cache, got %v (%v)", cfg.AnswerCacheTTL, err)
+	}
+
+	t.Setenv("FAT_ANSWER_CACHE_TTL", "-1h")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a negative duration, got nil")
+	}
+}
+
+func TestLoadResumeTTL(t *testing.T) {
+	t.Setenv("FAT_RESUME_TTL", "0")
+	if cfg, err := Load(); err != nil || cfg.ResumeTTL != 0 {
+		t.Errorf("Expected snapshots kept forever, got %v (%v)", cfg.ResumeTTL, err)
+	}
+
+	t.Setenv("FAT_RESUME_TTL", "-1h")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a negative duration, got nil")
+	}
+}
+
+func TestLoadReconcile(t *testing.T) {
+	t.Setenv("FAT_RECONCILE_INTERVAL", "6h")
+	t.Setenv("FAT_RECONCILE_THRESHOLD", "2.5")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.ReconcileInterval != 6*time.Hour || cfg.ReconcileThreshold != 2.5 {
+		t.Errorf("Expected reconciliation every 6h above 2.5%%, got %v above %v%%", cfg.ReconcileInterval, cfg.ReconcileThreshold)
+	}
+
+	t.Setenv("FAT_RECONCILE_THRESHOLD", "-1")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for negative threshold, got nil")
+	}
+}
+
+func TestLoadFallbacks(t *testing.T) {
+	t.Setenv("FAT_FALLBACK_MODELS", "gpt=gpt-5, claude = claude-sonnet-4-5,")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Fallbacks["gpt"] != "gpt-5" || cfg.Fallbacks["claude"] != "claude-sonnet-4-5" || len(cfg.Fallbacks) != 2 {
+		t.Errorf("Expected fallbacks for gpt and claude, got %v", cfg.Fallbacks)
+	}
+
+	t.Setenv("FAT_FALLBACK_MODELS", "gpt")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for fallback entry without a variant, got nil")
+	}
+}
+
 func TestEnvOrDefault(t *testing.T) {
 	os.Unsetenv("TEST_VAR")
 
@@ -116,3 +586,197 @@ func TestNewLogger(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadLogSinks(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.LogFile != "" || cfg.LogMaxSize != 100 || cfg.LogMaxAge != 24*time.Hour || cfg.LogMaxBackups != 7 {
+		t.Errorf("Expected stdout only with daily 100 MB rotation by default, got %+v (%v)", cfg, err)
+	}
+
+	t.Setenv("FAT_LOG_FILE", "logs/fat.log")
+	t.Setenv("FAT_LOG_MAX_SIZE", "10")
+	t.Setenv("FAT_LOG_MAX_AGE", "0")
+	t.Setenv("FAT_LOG_MAX_BACKUPS", "3")
+	t.Setenv("FAT_LOG_LEVELS", " db=warn , providers=debug")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.LogFile != "logs/fat.log" || cfg.LogMaxSize != 10 || cfg.LogMaxAge != 0 || cfg.LogMaxBackups != 3 {
+		t.Errorf("Expected the log file settings, got %+v", cfg)
+	}
+	if len(cfg.LogLevels) != 2 || cfg.LogLevels["db"] != "warn" || cfg.LogLevels["providers"] != "debug" {
+		t.Errorf("Expected levels for db and providers, got %v", cfg.LogLevels)
+	}
+
+	for _, levels := range []string{"http=debug", "db=loud", "db"} {
+		t.Setenv("FAT_LOG_LEVELS", levels)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected error for FAT_LOG_LEVELS %q, got nil", levels)
+		}
+	}
+	t.Setenv("FAT_LOG_LEVELS", "")
+
+	t.Setenv("FAT_LOG_MAX_SIZE", "-1")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a negative size, got nil")
+	}
+}
+
+func TestNewLoggerSubsystemLevels(t *testing.T) {
+	dir := t.TempDir()
+	out, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatalf("Failed to create output: %v", err)
+	}
+	defer out.Close()
+
+	cfg := Config{
+		LogLevel:  "info",
+		LogFile:   filepath.Join(dir, "fat.log"),
+		LogLevels: map[string]string{SubsystemDB: "error", SubsystemProviders: "debug"},
+	}
+	logger, err := NewLoggerTo(out, cfg, true)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Debug("root debug")
+	logger.Info("root info")
+	logger.With(SubsystemKey, SubsystemDB).Warn("db warn")
+	logger.With(SubsystemKey, SubsystemProviders, "model", "grok-4").Debug("provider debug")
+
+	for _, path := range []string{out.Name(), cfg.LogFile} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		logs := string(data)
+		for _, want := range []string{"root info", "provider debug", `"model":"grok-4"`} {
+			if !strings.Contains(logs, want) {
+				t.Errorf("Expected %s to contain %q, got %s", path, want, logs)
+			}
+		}
+		for _, unwanted := range []string{"root debug", "db warn"} {
+			if strings.Contains(logs, unwanted) {
+				t.Errorf("Expected %s to leave out %q, got %s", path, unwanted, logs)
+			}
+		}
+	}
+}
+
+func TestLoadAttribution(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.Attribution != "" {
+		t.Errorf("Expected no attribution by default, got %q (%v)", cfg.Attribution, err)
+	}
+
+	t.Setenv("FAT_ATTRIBUTION_FORMAT", "Written by {winner}")
+	if cfg, err := Load(); err != nil || cfg.Attribution != "" {
+		t.Errorf("Expected a format alone to leave attribution off, got %q (%v)", cfg.Attribution, err)
+	}
+
+	t.Setenv("FAT_ATTRIBUTION", "true")
+	if cfg, err := Load(); err != nil || cfg.Attribution != "Written by {winner}" {
+		t.Errorf("Expected the custom format, got %q (%v)", cfg.Attribution, err)
+	}
+
+	t.Setenv("FAT_ATTRIBUTION_FORMAT", "")
+	if cfg, err := Load(); err != nil || cfg.Attribution != DefaultAttribution {
+		t.Errorf("Expected the default format, got %q (%v)", cfg.Attribution, err)
+	}
+
+	t.Setenv("FAT_ATTRIBUTION", "maybe")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a non-boolean value, got nil")
+	}
+}
+
+func TestLoadSite(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.SiteDir != "" || cfg.SiteInterval != time.Hour {
+		t.Errorf("Expected no site regenerated hourly by default, got %q, %v (%v)", cfg.SiteDir, cfg.SiteInterval, err)
+	}
+
+	t.Setenv("FAT_SITE_DIR", "public")
+	t.Setenv("FAT_SITE_INTERVAL", "10m")
+	if cfg, err := Load(); err != nil || cfg.SiteDir != "public" || cfg.SiteInterval != 10*time.Minute {
+		t.Errorf("Expected the site in public every 10m, got %q, %v (%v)", cfg.SiteDir, cfg.SiteInterval, err)
+	}
+
+	t.Setenv("FAT_SITE_INTERVAL", "0s")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a zero interval, got nil")
+	}
+}
+
+func TestLoadDataDir(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.DataDir != "." || cfg.DBPath != "fat.db" || cfg.DiagnosticsDir != "diagnostics" {
+		t.Errorf("Expected everything in the working directory by default, got %q, %q, %q (%v)", cfg.DataDir, cfg.DBPath, cfg.DiagnosticsDir, err)
+	}
+
+	t.Setenv("FAT_DATA_DIR", "/data")
+	cfg, err = Load()
+	if err != nil || cfg.DataDir != "/data" || cfg.DBPath != "/data/fat.db" || cfg.DiagnosticsDir != "/data/diagnostics" {
+		t.Errorf("Expected the database and diagnostics under /data, got %q, %q (%v)", cfg.DBPath, cfg.DiagnosticsDir, err)
+	}
+
+	t.Setenv("FAT_DB_PATH", "/db/fat.db")
+	if cfg, err := Load(); err != nil || cfg.DBPath != "/db/fat.db" {
+		t.Errorf("Expected FAT_DB_PATH to win, got %q (%v)", cfg.DBPath, err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Config{LogLevel: "debug", SearchAPIKey: "sk-search", OpenAIAdminKey: "sk-admin", NotifyCommand: "curl https://hooks.example/secret"}
+
+	redacted := cfg.Redacted()
+	if redacted.SearchAPIKey != "[redacted]" || redacted.OpenAIAdminKey != "[redacted]" || redacted.NotifyCommand != "[redacted]" {
+		t.Errorf("Expected secrets masked, got %+v", redacted)
+	}
+	if redacted.AnthropicAdminKey != "" || redacted.LogLevel != "debug" {
+		t.Errorf("Expected unset secrets and other settings left alone, got %+v", redacted)
+	}
+	if cfg.SearchAPIKey != "sk-search" {
+		t.Error("Expected the original config untouched")
+	}
+}
+
+func TestLoadS3(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "aws-id")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "aws-secret")
+	cfg, err := Load()
+	if err != nil || cfg.S3Bucket != "" || !cfg.S3KeepLocal || cfg.S3AccessKey != "aws-id" || cfg.S3SecretKey != "aws-secret" {
+		t.Errorf("Expected no bucket, local copies and AWS's credentials by default, got %+v (%v)", cfg, err)
+	}
+
+	t.Setenv("FAT_S3_BUCKET", "exports")
+	t.Setenv("FAT_S3_ACCESS_KEY_ID", "fat-id")
+	t.Setenv("FAT_S3_KEEP_LOCAL", "false")
+	if cfg, err := Load(); err != nil || cfg.S3Bucket != "exports" || cfg.S3AccessKey != "fat-id" || cfg.S3KeepLocal {
+		t.Errorf("Expected the bucket with fat's own key ID and no local copies, got %+v (%v)", cfg, err)
+	}
+
+	t.Setenv("FAT_S3_KEEP_LOCAL", "sometimes")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a non-boolean value, got nil")
+	}
+}
+
+func TestLoadFaults(t *testing.T) {
+	if cfg, err := Load(); err != nil || cfg.Faults.Enabled() {
+		t.Errorf("Expected no faults by default, got %v (%v)", cfg.Faults, err)
+	}
+
+	t.Setenv("FAT_FAULTS", "latency=2s, error=0.25, models=grok+gpt-5-mini")
+	cfg, err := Load()
+	if err != nil || cfg.Faults.Latency != 2*time.Second || cfg.Faults.Error != 0.25 || len(cfg.Faults.Models) != 2 {
+		t.Errorf("Expected 2s latency and a quarter of grok and gpt-5-mini calls failing, got %+v (%v)", cfg.Faults, err)
+	}
+
+	t.Setenv("FAT_FAULTS", "error=0.8,timeout=0.5")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for rates adding up to over 1, got nil")
+	}
+}
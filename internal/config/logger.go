@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/lmittmann/tint"
+	"github.com/meedamian/fat/internal/logfile"
+	"golang.org/x/term"
+)
+
+// SubsystemKey is the log attribute naming the subsystem a logger belongs to, whose level FAT_LOG_LEVELS can override
+const SubsystemKey = "subsystem"
+
+// Subsystems whose log level can be set on its own
+const (
+	SubsystemDB           = "db"
+	SubsystemOrchestrator = "orchestrator"
+	SubsystemProviders    = "providers"
+)
+
+// Subsystems lists every subsystem FAT_LOG_LEVELS accepts
+var Subsystems = []string{SubsystemDB, SubsystemOrchestrator, SubsystemProviders}
+
+func NewLogger(level string) (*slog.Logger, error) {
+	return NewLoggerTo(os.Stdout, Config{LogLevel: level}, false)
+}
+
+// NewLoggerTo creates a logger writing to out, colored when out is a terminal unless jsonOutput is set
+// With cfg.LogFile set, JSON logs are also written to that file, rotated by size and age. Loggers tagged
+// with a subsystem (logger.With(SubsystemKey, SubsystemDB)) log at that subsystem's level from cfg.LogLevels.
+func NewLoggerTo(out *os.File, cfg Config, jsonOutput bool) (*slog.Logger, error) {
+	slogLevel, err := parseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	levels := make(map[string]slog.Level, len(cfg.LogLevels))
+	lowest := slogLevel
+	for subsystem, level := range cfg.LogLevels {
+		l, err := parseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("subsystem %s: %w", subsystem, err)
+		}
+		levels[subsystem] = l
+		lowest = min(lowest, l)
+	}
+
+	// Use beautiful colored output for terminal, JSON for pipes/files
+	// The sinks let everything through that any subsystem logs; subsystemHandler filters by level.
+	var handler slog.Handler
+	if !jsonOutput && term.IsTerminal(int(out.Fd())) {
+		// Terminal: use tint for beautiful colored output
+		handler = tint.NewHandler(out, &tint.Options{
+			Level:      lowest,
+			TimeFormat: "15:04", // 24-hour format
+			AddSource:  slogLevel == slog.LevelDebug,
+		})
+	} else {
+		// Non-terminal (pipe, file, production): use JSON
+		handler = slog.NewJSONHandler(out, &slog.HandlerOptions{
+			Level: lowest,
+		})
+	}
+
+	if cfg.LogFile != "" {
+		file, err := logfile.Open(cfg.LogFile, cfg.LogMaxSize*1024*1024, cfg.LogMaxAge, cfg.LogMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		handler = fanoutHandler{handler, slog.NewJSONHandler(file, &slog.HandlerOptions{Level: lowest})}
+	}
+
+	return slog.New(&subsystemHandler{next: handler, level: slogLevel, levels: levels}), nil
+}
+
+// parseLevel parses a log level name, empty meaning info
+func parseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", level)
+}
+
+// subsystemHandler drops records below its level, which becomes a subsystem's own once a logger is tagged with it
+type subsystemHandler struct {
+	next   slog.Handler
+	level  slog.Level
+	levels map[string]slog.Level // By subsystem
+}
+
+func (h *subsystemHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	level := h.level
+	for _, a := range attrs {
+		if a.Key != SubsystemKey {
+			continue
+		}
+		if l, ok := h.levels[a.Value.String()]; ok {
+			level = l
+		}
+	}
+	return &subsystemHandler{next: h.next.WithAttrs(attrs), level: level, levels: h.levels}
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	return &subsystemHandler{next: h.next.WithGroup(name), level: h.level, levels: h.levels}
+}
+
+// fanoutHandler passes every record to each of its handlers
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, next := range h {
+		if next.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, next := range h {
+		if next.Enabled(ctx, r.Level) {
+			if err := next.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, next := range h {
+		handlers[i] = next.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, next := range h {
+		handlers[i] = next.WithGroup(name)
+	}
+	return handlers
+}
@@ -0,0 +1,153 @@
+// Package costs rolls the stored spend of rounds and rankings up into day, week or month buckets per
+// provider and model, to check against provider invoices. Periods are in UTC, weeks start on Monday.
+package costs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/models"
+)
+
+// Granularities buckets can span
+const (
+	Day   = "day"
+	Week  = "week"
+	Month = "month"
+)
+
+// UnknownProvider is reported for variants no longer in the model list
+const UnknownProvider = "unknown"
+
+// Totals is what a set of calls used and cost
+type Totals struct {
+	Calls     int64   `json:"calls"`
+	TokensIn  int64   `json:"tokens_in"`
+	TokensOut int64   `json:"tokens_out"`
+	Cost      float64 `json:"cost"`
+}
+
+func (t *Totals) add(r db.CostRow) {
+	t.Calls += r.Calls
+	t.TokensIn += r.TokensIn
+	t.TokensOut += r.TokensOut
+	t.Cost += r.Cost
+}
+
+// ProviderCost is one provider's spend
+type ProviderCost struct {
+	Provider string `json:"provider"`
+	Totals
+}
+
+// ModelCost is one model variant's spend
+type ModelCost struct {
+	Model    string `json:"model"`
+	Family   string `json:"family,omitempty"`
+	Provider string `json:"provider"`
+	Totals
+}
+
+// Bucket is the spend in [Start, End)
+type Bucket struct {
+	Start     string         `json:"start"` // YYYY-MM-DD
+	End       string         `json:"end"`
+	Providers []ProviderCost `json:"providers"`
+	Models    []ModelCost    `json:"models"`
+	Totals
+}
+
+// Report is the spend over a range, bucketed and in total
+type Report struct {
+	Granularity string         `json:"granularity"`
+	Buckets     []Bucket       `json:"buckets"`
+	Providers   []ProviderCost `json:"providers"` // Over the whole range
+	Totals
+}
+
+// Build reports the spend in [start, end) per granularity bucket; zero times leave the range open
+// Buckets without any calls are left out.
+func Build(ctx context.Context, database *db.DB, granularity string, start, end time.Time) (*Report, error) {
+	rows, err := database.GetCostsByPeriod(ctx, granularity, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Granularity: granularity, Buckets: []Bucket{}, Providers: []ProviderCost{}}
+	overall := make(map[string]*Totals)
+	for i := 0; i < len(rows); {
+		period := rows[i].Period
+		bucketEnd, err := periodEnd(granularity, period)
+		if err != nil {
+			return nil, err
+		}
+		bucket := Bucket{Start: period, End: bucketEnd, Models: []ModelCost{}}
+		providers := make(map[string]*Totals)
+
+		for ; i < len(rows) && rows[i].Period == period; i++ {
+			r := rows[i]
+			family := models.FamilyForVariant(r.ModelName)
+			provider := UnknownProvider
+			if family != "" {
+				provider = models.ModelFamilies[family].Provider
+			}
+
+			m := ModelCost{Model: r.ModelName, Family: family, Provider: provider}
+			m.add(r)
+			bucket.Models = append(bucket.Models, m)
+			bucket.add(r)
+			report.add(r)
+			addTo(providers, provider, r)
+			addTo(overall, provider, r)
+		}
+
+		sort.SliceStable(bucket.Models, func(a, b int) bool { return bucket.Models[a].Cost > bucket.Models[b].Cost })
+		bucket.Providers = sortedProviders(providers)
+		report.Buckets = append(report.Buckets, bucket)
+	}
+	report.Providers = sortedProviders(overall)
+	return report, nil
+}
+
+// periodEnd is the first day after the bucket starting on period
+func periodEnd(granularity, period string) (string, error) {
+	start, err := time.Parse(time.DateOnly, period)
+	if err != nil {
+		return "", fmt.Errorf("invalid cost period %q: %w", period, err)
+	}
+	switch granularity {
+	case Week:
+		return start.AddDate(0, 0, 7).Format(time.DateOnly), nil
+	case Month:
+		return start.AddDate(0, 1, 0).Format(time.DateOnly), nil
+	default:
+		return start.AddDate(0, 0, 1).Format(time.DateOnly), nil
+	}
+}
+
+func addTo(totals map[string]*Totals, provider string, r db.CostRow) {
+	t, ok := totals[provider]
+	if !ok {
+		t = &Totals{}
+		totals[provider] = t
+	}
+	t.add(r)
+}
+
+// sortedProviders lists per-provider totals, most expensive first
+func sortedProviders(totals map[string]*Totals) []ProviderCost {
+	providers := make([]ProviderCost, 0, len(totals))
+	for provider, t := range totals {
+		providers = append(providers, ProviderCost{Provider: provider, Totals: *t})
+	}
+	sort.Slice(providers, func(a, b int) bool {
+		if providers[a].Cost != providers[b].Cost {
+			return providers[a].Cost > providers[b].Cost
+		}
+		return providers[a].Provider < providers[b].Provider
+	})
+	return providers
+}
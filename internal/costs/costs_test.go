@@ -0,0 +1,66 @@
+package costs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+func TestBuild(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "costs.db"), slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	rounds := []db.ModelRound{
+		{RequestID: "req", ModelID: "gpt", ModelName: "gpt-5", Round: 1, DurationMs: 1, TokensIn: 100, TokensOut: 10, Cost: 0.5},
+		{RequestID: "req", ModelID: "claude", ModelName: "claude-opus-4-6", Round: 1, DurationMs: 1, TokensIn: 200, TokensOut: 20, Cost: 2},
+		{RequestID: "req", ModelID: "gone", ModelName: "retired-model", Round: 1, DurationMs: 1, TokensIn: 5, TokensOut: 1, Cost: 0.01},
+	}
+	for _, r := range rounds {
+		if err := database.SaveModelRound(ctx, r); err != nil {
+			t.Fatalf("Failed to save round: %v", err)
+		}
+	}
+	if err := database.SaveRanking(ctx, db.Ranking{RequestID: "req", RankerModel: "gpt-5", RankedModels: "[]", DurationMs: 1, TokensIn: 50, TokensOut: 5, Cost: 0.25}); err != nil {
+		t.Fatalf("Failed to save ranking: %v", err)
+	}
+
+	report, err := Build(ctx, database, Month, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(report.Buckets) != 1 {
+		t.Fatalf("Expected one month, got %+v", report.Buckets)
+	}
+	bucket := report.Buckets[0]
+	month := time.Now().UTC().Format("2006-01") + "-01"
+	if bucket.Start != month || bucket.End <= bucket.Start {
+		t.Errorf("Expected the bucket to span this month, got %s to %s", bucket.Start, bucket.End)
+	}
+	if report.Calls != 4 || report.TokensIn != 355 || report.Cost < 2.759 || report.Cost > 2.761 {
+		t.Errorf("Unexpected totals %+v", report.Totals)
+	}
+
+	if len(bucket.Models) != 3 || bucket.Models[0].Model != "claude-opus-4-6" || bucket.Models[1].Calls != 2 {
+		t.Errorf("Expected models most expensive first, with the ranking counted, got %+v", bucket.Models)
+	}
+	if p := bucket.Providers; len(p) != 3 || p[0].Provider != "Anthropic" || p[1].Provider != "OpenAI" || p[2].Provider != UnknownProvider {
+		t.Errorf("Expected providers most expensive first, got %+v", p)
+	}
+	if len(report.Providers) != 3 || report.Providers[1].Cost != 0.75 {
+		t.Errorf("Expected the range's providers, got %+v", report.Providers)
+	}
+
+	empty, err := Build(ctx, database, Day, time.Now().AddDate(1, 0, 0), time.Time{})
+	if err != nil || len(empty.Buckets) != 0 || empty.Calls != 0 {
+		t.Errorf("Expected nothing in the future, got %+v, %v", empty, err)
+	}
+}
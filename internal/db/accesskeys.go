@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAccessKeyNotFound is returned when revoking a key that doesn't exist or was already revoked
+var ErrAccessKeyNotFound = errors.New("access key not found")
+
+// AccessKey is a per-user key for the server's protected endpoints; only its hash is stored
+type AccessKey struct {
+	ID         int64
+	User       string
+	Prefix     string // Start of the key, to tell keys apart
+	CreatedAt  time.Time
+	LastUsedAt *time.Time // nil if never used
+	RevokedAt  *time.Time // nil while the key is valid
+}
+
+// CreateAccessKey stores a new key by its hash and returns its ID
+func (db *DB) CreateAccessKey(ctx context.Context, user, keyHash, prefix string) (int64, error) {
+	result, err := db.conn.ExecContext(ctx,
+		`INSERT INTO access_keys (user, key_hash, prefix) VALUES (?, ?, ?)`,
+		user, keyHash, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create access key: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// UseAccessKey returns the valid key with keyHash and marks it used, or nil if there is none
+func (db *DB) UseAccessKey(ctx context.Context, keyHash string) (*AccessKey, error) {
+	query := `
+		SELECT id, user, prefix, created_at
+		FROM access_keys
+		WHERE key_hash = ? AND revoked_at IS NULL
+	`
+	var k AccessKey
+	err := db.conn.QueryRowContext(ctx, query, keyHash).Scan(&k.ID, &k.User, &k.Prefix, &k.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query access key: %w", err)
+	}
+
+	if _, err := db.conn.ExecContext(ctx, `UPDATE access_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?`, k.ID); err != nil {
+		return nil, fmt.Errorf("failed to update access key: %w", err)
+	}
+	now := time.Now().UTC()
+	k.LastUsedAt = &now
+	return &k, nil
+}
+
+// HasAccessKeys reports whether any key is still valid
+func (db *DB) HasAccessKeys(ctx context.Context) (bool, error) {
+	var exists bool
+	err := db.conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM access_keys WHERE revoked_at IS NULL)`).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to query access keys: %w", err)
+	}
+	return exists, nil
+}
+
+// GetAccessKeys retrieves every key, revoked ones included, oldest first
+func (db *DB) GetAccessKeys(ctx context.Context) ([]AccessKey, error) {
+	query := `
+		SELECT id, user, prefix, created_at, last_used_at, revoked_at
+		FROM access_keys
+		ORDER BY id
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query access keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []AccessKey
+	for rows.Next() {
+		var k AccessKey
+		var lastUsed, revoked sql.NullTime
+		if err := rows.Scan(&k.ID, &k.User, &k.Prefix, &k.CreatedAt, &lastUsed, &revoked); err != nil {
+			return nil, fmt.Errorf("failed to scan access key: %w", err)
+		}
+		if lastUsed.Valid {
+			k.LastUsedAt = &lastUsed.Time
+		}
+		if revoked.Valid {
+			k.RevokedAt = &revoked.Time
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAccessKey stops a key from being accepted
+func (db *DB) RevokeAccessKey(ctx context.Context, id int64) error {
+	result, err := db.conn.ExecContext(ctx,
+		`UPDATE access_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access key: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAccessKeyNotFound
+	}
+	return nil
+}
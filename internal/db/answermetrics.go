@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// AnswerMetric is an operator-defined scorer's value for one model's final answer in a request
+type AnswerMetric struct {
+	RequestID string
+	ModelID   string
+	Metric    string  // Scorer name
+	Value     float64 // 0 when Error is set
+	Error     string  // Why the scorer failed, empty on success
+}
+
+// SaveAnswerMetrics saves scorer results for a request's final answers, replacing earlier ones
+func (db *DB) SaveAnswerMetrics(ctx context.Context, metrics []AnswerMetric) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO answer_metrics (request_id, model_id, metric, value, error)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(request_id, model_id, metric) DO UPDATE SET
+			value = excluded.value,
+			error = excluded.error
+	`
+	for _, m := range metrics {
+		if _, err := tx.ExecContext(ctx, query, m.RequestID, m.ModelID, m.Metric, m.Value, m.Error); err != nil {
+			return fmt.Errorf("failed to save answer metric: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetAnswerMetrics retrieves the scorer results for a request's final answers, ordered by model and metric
+func (db *DB) GetAnswerMetrics(ctx context.Context, requestID string) ([]AnswerMetric, error) {
+	query := `
+		SELECT request_id, model_id, metric, value, error
+		FROM answer_metrics
+		WHERE request_id = ?
+		ORDER BY model_id, metric
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query answer metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []AnswerMetric
+	for rows.Next() {
+		var m AnswerMetric
+		if err := rows.Scan(&m.RequestID, &m.ModelID, &m.Metric, &m.Value, &m.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan answer metric: %w", err)
+		}
+		metrics = append(metrics, m)
+	}
+
+	return metrics, rows.Err()
+}
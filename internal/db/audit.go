@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+// Justification is a judge's placement of one answer with its reason for it
+type Justification struct {
+	Judge  string `json:"judge"` // Variant of the judge
+	Model  string `json:"model"` // Variant whose answer was placed
+	Place  int    `json:"place"` // 1-based
+	Of     int    `json:"of"`    // Answers the judge ranked
+	Reason string `json:"reason"`
+}
+
+// JustifiedPlacements decodes the ranking into a record per placement the judge gave a reason for, best first
+// A ranking that can't be decoded has none.
+func (r Ranking) JustifiedPlacements() []Justification {
+	if r.Justifications == "" {
+		return nil
+	}
+	var ranked []string
+	var reasons map[string]string
+	if json.Unmarshal([]byte(r.RankedModels), &ranked) != nil || json.Unmarshal([]byte(r.Justifications), &reasons) != nil {
+		return nil
+	}
+
+	var placements []Justification
+	for i, model := range ranked {
+		if reasons[model] == "" {
+			continue
+		}
+		placements = append(placements, Justification{Judge: r.RankerModel, Model: model, Place: i + 1, Of: len(ranked), Reason: reasons[model]})
+	}
+	return placements
+}
+
+// GetJustifications retrieves every justified placement the judges of a request gave, by judge and then place
+// The meta judge's verdict isn't included.
+func (db *DB) GetJustifications(ctx context.Context, requestID string) ([]Justification, error) {
+	rankings, err := db.GetRequestRankings(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	var justifications []Justification
+	for _, r := range rankings {
+		justifications = append(justifications, r.JustifiedPlacements()...)
+	}
+	sort.SliceStable(justifications, func(i, j int) bool {
+		if justifications[i].Judge != justifications[j].Judge {
+			return justifications[i].Judge < justifications[j].Judge
+		}
+		return justifications[i].Place < justifications[j].Place
+	})
+	return justifications, nil
+}
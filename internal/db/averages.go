@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CallAverage is what a model variant produced per successful round call across requests
+type CallAverage struct {
+	ModelID       string
+	ModelName     string
+	Calls         int64
+	AvgTokensIn   float64
+	AvgTokensOut  float64
+	AvgDurationMs float64
+}
+
+// GetCallAverages retrieves per-call averages of every variant with a successful round, keyed by variant
+func (db *DB) GetCallAverages(ctx context.Context) (map[string]CallAverage, error) {
+	query := `
+		SELECT model_id, model_name, COUNT(*), AVG(tokens_in), AVG(tokens_out), AVG(duration_ms)
+		FROM model_rounds
+		WHERE COALESCE(error, '') = '' AND tokens_out > 0
+		GROUP BY model_id, model_name
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query call averages: %w", err)
+	}
+	defer rows.Close()
+
+	averages := make(map[string]CallAverage)
+	for rows.Next() {
+		var a CallAverage
+		if err := rows.Scan(&a.ModelID, &a.ModelName, &a.Calls, &a.AvgTokensIn, &a.AvgTokensOut, &a.AvgDurationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan call average: %w", err)
+		}
+		averages[a.ModelName] = a
+	}
+
+	return averages, rows.Err()
+}
+
+// GetLatencyP95 retrieves the 95th percentile duration of every variant's last samples successful round calls,
+// keyed by variant; variants with fewer than minSamples calls are left out
+func (db *DB) GetLatencyP95(ctx context.Context, samples, minSamples int) (map[string]time.Duration, error) {
+	query := `
+		SELECT model_name, duration_ms FROM (
+			SELECT model_name, duration_ms,
+				ROW_NUMBER() OVER (PARTITION BY model_name ORDER BY created_at DESC, id DESC) AS n
+			FROM model_rounds
+			WHERE COALESCE(error, '') = '' AND duration_ms > 0
+		)
+		WHERE n <= ?
+		ORDER BY model_name, duration_ms
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, samples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query call latencies: %w", err)
+	}
+	defer rows.Close()
+
+	durations := make(map[string][]int64)
+	for rows.Next() {
+		var name string
+		var ms int64
+		if err := rows.Scan(&name, &ms); err != nil {
+			return nil, fmt.Errorf("failed to scan call latency: %w", err)
+		}
+		durations[name] = append(durations[name], ms)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	p95 := make(map[string]time.Duration, len(durations))
+	for name, sorted := range durations {
+		if len(sorted) < minSamples {
+			continue
+		}
+		// Nearest rank
+		rank := (len(sorted)*95 + 99) / 100
+		p95[name] = time.Duration(sorted[rank-1]) * time.Millisecond
+	}
+	return p95, nil
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ModelWinRate holds how often a model variant won requests with a given tag
+type ModelWinRate struct {
+	ModelID   string
+	ModelName string
+	Runs      int64
+	Wins      int64
+
+	// Difficulty-weighted counterparts, filled in by GetAllWinRates
+	WeightedRuns  float64
+	WeightedWins  float64
+	EffectiveRuns float64 // Kish effective sample size of the weights
+}
+
+// UnknownDifficulty is assumed for requests stored before difficulty was estimated
+const UnknownDifficulty = 0.5
+
+// MinDifficultyWeight keeps trivially easy questions from being ignored entirely
+const MinDifficultyWeight = 0.05
+
+// BenchmarkBaseline is a stored win-rate snapshot for a model on a tagged question set
+type BenchmarkBaseline struct {
+	ID        int64
+	Tag       string
+	ModelID   string
+	ModelName string
+	Runs      int64
+	Wins      int64
+	CreatedAt time.Time
+}
+
+// GetModelWinRates aggregates per-variant runs and wins for tagged requests created after since
+func (db *DB) GetModelWinRates(ctx context.Context, tag string, since time.Time) ([]ModelWinRate, error) {
+	query := `
+		SELECT mr.model_id, mr.model_name,
+		       COUNT(DISTINCT r.id),
+		       COUNT(DISTINCT CASE WHEN r.winner_model = mr.model_id THEN r.id END)
+		FROM requests r
+		JOIN model_rounds mr ON mr.request_id = r.id
+		WHERE r.tag = ? AND r.created_at > ?
+		GROUP BY mr.model_id, mr.model_name
+		ORDER BY mr.model_id, mr.model_name
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, tag, since.UTC().Format(time.DateTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query win rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []ModelWinRate
+	for rows.Next() {
+		var r ModelWinRate
+		if err := rows.Scan(&r.ModelID, &r.ModelName, &r.Runs, &r.Wins); err != nil {
+			return nil, fmt.Errorf("failed to scan win rate: %w", err)
+		}
+		rates = append(rates, r)
+	}
+
+	return rates, rows.Err()
+}
+
+// GetAllWinRates aggregates per-variant runs and wins across every request
+// Each request is also weighted by its difficulty so wins on hard questions count for more
+func (db *DB) GetAllWinRates(ctx context.Context) ([]ModelWinRate, error) {
+	query := `
+		SELECT model_id, model_name,
+		       COUNT(*), SUM(won),
+		       SUM(weight), SUM(weight * won),
+		       SUM(weight) * SUM(weight) / SUM(weight * weight)
+		FROM (
+			SELECT DISTINCT mr.model_id, mr.model_name, r.id,
+			       CASE WHEN r.winner_model = mr.model_id THEN 1 ELSE 0 END AS won,
+			       MAX(COALESCE(r.difficulty, ?), ?) AS weight
+			FROM requests r
+			JOIN model_rounds mr ON mr.request_id = r.id
+		)
+		GROUP BY model_id, model_name
+		ORDER BY model_id, model_name
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, UnknownDifficulty, MinDifficultyWeight)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query win rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []ModelWinRate
+	for rows.Next() {
+		var r ModelWinRate
+		if err := rows.Scan(&r.ModelID, &r.ModelName, &r.Runs, &r.Wins,
+			&r.WeightedRuns, &r.WeightedWins, &r.EffectiveRuns); err != nil {
+			return nil, fmt.Errorf("failed to scan win rate: %w", err)
+		}
+		rates = append(rates, r)
+	}
+
+	return rates, rows.Err()
+}
+
+// SaveBenchmarkBaseline stores a new baseline snapshot
+func (db *DB) SaveBenchmarkBaseline(ctx context.Context, b BenchmarkBaseline) error {
+	query := `
+		INSERT INTO benchmark_baselines (tag, model_id, model_name, runs, wins)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err := db.conn.ExecContext(ctx, query, b.Tag, b.ModelID, b.ModelName, b.Runs, b.Wins)
+	if err != nil {
+		return fmt.Errorf("failed to save benchmark baseline: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestBaselines retrieves the most recent baseline of every model variant for a tag
+func (db *DB) GetLatestBaselines(ctx context.Context, tag string) ([]BenchmarkBaseline, error) {
+	query := `
+		SELECT b.id, b.tag, b.model_id, b.model_name, b.runs, b.wins, b.created_at
+		FROM benchmark_baselines b
+		WHERE b.tag = ? AND b.id = (
+			SELECT MAX(id) FROM benchmark_baselines
+			WHERE tag = b.tag AND model_id = b.model_id AND model_name = b.model_name
+		)
+		ORDER BY b.model_id, b.model_name
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query benchmark baselines: %w", err)
+	}
+	defer rows.Close()
+
+	var baselines []BenchmarkBaseline
+	for rows.Next() {
+		var b BenchmarkBaseline
+		if err := rows.Scan(&b.ID, &b.Tag, &b.ModelID, &b.ModelName, &b.Runs, &b.Wins, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan benchmark baseline: %w", err)
+		}
+		baselines = append(baselines, b)
+	}
+
+	return baselines, rows.Err()
+}
+
+// SuiteResult is how a model variant did across the requests with a given tag
+type SuiteResult struct {
+	ModelID       string
+	ModelName     string
+	Runs          int64
+	Wins          int64
+	Cost          float64 // Of its answers, not counting its rankings
+	Calls         int64   // Rounds it answered
+	Errors        int64   // Rounds it failed
+	MeanLatencyMs float64 // Of the rounds it answered
+}
+
+// GetSuiteResults aggregates per-variant wins, answer cost and latency for the requests with tag
+func (db *DB) GetSuiteResults(ctx context.Context, tag string) ([]SuiteResult, error) {
+	query := `
+		SELECT mr.model_id, mr.model_name,
+		       COUNT(DISTINCT r.id),
+		       COUNT(DISTINCT CASE WHEN r.winner_model = mr.model_id THEN r.id END),
+		       COALESCE(SUM(mr.cost), 0),
+		       SUM(CASE WHEN COALESCE(mr.error, '') = '' THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN COALESCE(mr.error, '') = '' THEN 0 ELSE 1 END),
+		       COALESCE(AVG(CASE WHEN COALESCE(mr.error, '') = '' THEN mr.duration_ms END), 0)
+		FROM requests r
+		JOIN model_rounds mr ON mr.request_id = r.id
+		WHERE r.tag = ? AND r.deleted_at IS NULL
+		GROUP BY mr.model_id, mr.model_name
+		ORDER BY mr.model_id, mr.model_name
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suite results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SuiteResult
+	for rows.Next() {
+		var r SuiteResult
+		if err := rows.Scan(&r.ModelID, &r.ModelName, &r.Runs, &r.Wins, &r.Cost, &r.Calls, &r.Errors, &r.MeanLatencyMs); err != nil {
+			return nil, fmt.Errorf("failed to scan suite result: %w", err)
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
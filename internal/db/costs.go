@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// costPeriods is the SQL for the first day of the day, week (starting Monday) or month a timestamp falls in
+var costPeriods = map[string]string{
+	"day":   "date(created_at)",
+	"week":  "date(created_at, 'weekday 0', '-6 days')",
+	"month": "date(created_at, 'start of month')",
+}
+
+// CostRow is the spend of one model variant in one period
+type CostRow struct {
+	Period    string // First day of the period, YYYY-MM-DD in UTC
+	ModelName string // Variant that answered or ranked
+	Calls     int64
+	TokensIn  int64
+	TokensOut int64
+	Cost      float64
+}
+
+// GetCostsByPeriod sums the rounds and rankings created in [start, end) per day, week or month and variant
+// A zero start or end leaves that side unbounded. Rows are ordered by period, then variant.
+func (db *DB) GetCostsByPeriod(ctx context.Context, granularity string, start, end time.Time) ([]CostRow, error) {
+	period, ok := costPeriods[granularity]
+	if !ok {
+		return nil, fmt.Errorf("unknown granularity %q: must be day, week or month", granularity)
+	}
+
+	where := "1 = 1"
+	var args []any
+	if !start.IsZero() {
+		where += " AND created_at >= ?"
+		args = append(args, start.UTC().Format(time.DateTime))
+	}
+	if !end.IsZero() {
+		where += " AND created_at < ?"
+		args = append(args, end.UTC().Format(time.DateTime))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT period, model_name, SUM(calls), SUM(tokens_in), SUM(tokens_out), SUM(cost) FROM (
+			SELECT %[1]s AS period, model_name, COUNT(*) AS calls,
+				SUM(tokens_in) AS tokens_in, SUM(tokens_out) AS tokens_out, SUM(COALESCE(cost, 0)) AS cost
+			FROM model_rounds WHERE %[2]s
+			GROUP BY period, model_name
+			UNION ALL
+			SELECT %[1]s AS period, ranker_model, COUNT(*),
+				SUM(tokens_in), SUM(tokens_out), SUM(COALESCE(cost, 0))
+			FROM rankings WHERE %[2]s
+			GROUP BY period, ranker_model
+		)
+		GROUP BY period, model_name
+		ORDER BY period, model_name
+	`, period, where)
+
+	rows, err := db.conn.QueryContext(ctx, query, append(args, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query costs: %w", err)
+	}
+	defer rows.Close()
+
+	var costs []CostRow
+	for rows.Next() {
+		var r CostRow
+		if err := rows.Scan(&r.Period, &r.ModelName, &r.Calls, &r.TokensIn, &r.TokensOut, &r.Cost); err != nil {
+			return nil, fmt.Errorf("failed to scan cost: %w", err)
+		}
+		costs = append(costs, r)
+	}
+	return costs, rows.Err()
+}
+
+// CostBreakdown is where a request's money went, per phase and per model variant
+// Unlike the request's total cost, it includes judges that didn't take part in the rounds.
+type CostBreakdown struct {
+	Rounds  float64     `json:"rounds"`  // Answering and discussing
+	Ranking float64     `json:"ranking"` // Judges ranking the answers
+	Total   float64     `json:"total"`
+	Models  []ModelCost `json:"models"` // Most expensive first
+}
+
+// ModelCost is what one variant cost in a request
+type ModelCost struct {
+	Model   string  `json:"model"`
+	Rounds  float64 `json:"rounds"`
+	Ranking float64 `json:"ranking"`
+	Total   float64 `json:"total"`
+}
+
+// add counts cost towards model in the rounds or the ranking phase
+func (b *CostBreakdown) add(model string, ranking bool, cost float64) {
+	i := 0
+	for i < len(b.Models) && b.Models[i].Model != model {
+		i++
+	}
+	if i == len(b.Models) {
+		b.Models = append(b.Models, ModelCost{Model: model})
+	}
+	m := &b.Models[i]
+	if ranking {
+		m.Ranking += cost
+		b.Ranking += cost
+	} else {
+		m.Rounds += cost
+		b.Rounds += cost
+	}
+	m.Total += cost
+	b.Total += cost
+}
+
+// sort orders the models most expensive first
+func (b *CostBreakdown) sort() {
+	sort.SliceStable(b.Models, func(i, j int) bool {
+		if b.Models[i].Total != b.Models[j].Total {
+			return b.Models[i].Total > b.Models[j].Total
+		}
+		return b.Models[i].Model < b.Models[j].Model
+	})
+}
+
+// StoreCostBreakdown sums a request's stored round and ranking costs and keeps the breakdown on the request
+func (db *DB) StoreCostBreakdown(ctx context.Context, requestID string) (*CostBreakdown, error) {
+	query := `
+		SELECT model_name, 0, SUM(COALESCE(cost, 0)) FROM model_rounds WHERE request_id = ? GROUP BY model_name
+		UNION ALL
+		SELECT ranker_model, 1, SUM(COALESCE(cost, 0)) FROM rankings WHERE request_id = ? GROUP BY ranker_model
+	`
+	rows, err := db.conn.QueryContext(ctx, query, requestID, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request costs: %w", err)
+	}
+	defer rows.Close()
+
+	b := &CostBreakdown{Models: []ModelCost{}}
+	for rows.Next() {
+		var model string
+		var ranking bool
+		var cost float64
+		if err := rows.Scan(&model, &ranking, &cost); err != nil {
+			return nil, fmt.Errorf("failed to scan request cost: %w", err)
+		}
+		b.add(model, ranking, cost)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	b.sort()
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.conn.ExecContext(ctx, "UPDATE requests SET cost_breakdown = ? WHERE id = ?", string(data), requestID); err != nil {
+		return nil, fmt.Errorf("failed to save cost breakdown: %w", err)
+	}
+	return b, nil
+}
@@ -18,7 +18,8 @@ type DB struct {
 
 // New creates a new database connection and initializes schema
 func New(dbPath string, logger *slog.Logger) (*DB, error) {
-	conn, err := sql.Open("sqlite", dbPath)
+	// Wait on locks instead of failing when concurrent requests write at once
+	conn, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -53,6 +54,14 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// Checkpoint flushes the write-ahead log into the main database file and truncates it
+func (db *DB) Checkpoint(ctx context.Context) error {
+	if _, err := db.conn.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint WAL: %w", err)
+	}
+	return nil
+}
+
 // initSchema creates all necessary tables
 func (db *DB) initSchema() error {
 	schema := `
@@ -116,11 +125,189 @@ func (db *DB) initSchema() error {
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
 
+	CREATE TABLE IF NOT EXISTS model_stats_history (
+		day TEXT NOT NULL, -- UTC date of the snapshot, YYYY-MM-DD
+		model_id TEXT NOT NULL,
+		model_name TEXT NOT NULL,
+		total_requests INTEGER NOT NULL,
+		total_wins INTEGER NOT NULL,
+		total_tokens_in INTEGER NOT NULL,
+		total_tokens_out INTEGER NOT NULL,
+		total_cost REAL NOT NULL,
+		avg_response_time_ms INTEGER NOT NULL,
+		error_count INTEGER NOT NULL,
+		PRIMARY KEY (day, model_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id TEXT NOT NULL,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL, -- JSON of the broadcast message
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS request_state (
+		request_id TEXT PRIMARY KEY,
+		question TEXT NOT NULL,
+		num_rounds INTEGER NOT NULL,
+		question_ts INTEGER NOT NULL,
+		models TEXT NOT NULL, -- JSON map of model ID -> variant name
+		round INTEGER NOT NULL, -- last fully completed round
+		replies TEXT NOT NULL,
+		discussion TEXT NOT NULL,
+		private_notes TEXT NOT NULL,
+		status TEXT NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS benchmark_baselines (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tag TEXT NOT NULL,
+		model_id TEXT NOT NULL,
+		model_name TEXT NOT NULL,
+		runs INTEGER NOT NULL,
+		wins INTEGER NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS model_elo (
+		model_name TEXT PRIMARY KEY, -- variant name, rated separately from other variants of the family
+		model_id TEXT NOT NULL,
+		rating REAL NOT NULL,
+		games INTEGER NOT NULL DEFAULT 0,
+		wins INTEGER NOT NULL DEFAULT 0,
+		losses INTEGER NOT NULL DEFAULT 0,
+		draws INTEGER NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS sample_questions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		question TEXT NOT NULL UNIQUE,
+		category TEXT NOT NULL DEFAULT '',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS self_preference (
+		request_id TEXT NOT NULL,
+		judge_model TEXT NOT NULL, -- variant name of the judge
+		self_position INTEGER NOT NULL, -- 0-based position of its own answer in its ranking
+		ranked INTEGER NOT NULL,
+		self_score REAL NOT NULL, -- 1 = ranked itself first, 0 = last
+		peer_score REAL NOT NULL, -- mean score the other judges gave its answer
+		bias REAL NOT NULL, -- self_score - peer_score
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (request_id, judge_model)
+	);
+
+	CREATE TABLE IF NOT EXISTS answer_similarity (
+		request_id TEXT NOT NULL,
+		model_a TEXT NOT NULL, -- model IDs, model_a < model_b
+		model_b TEXT NOT NULL,
+		similarity REAL NOT NULL, -- cosine similarity of the two final answers' embeddings
+		embedding_model TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (request_id, model_a, model_b)
+	);
+
+	CREATE TABLE IF NOT EXISTS answer_metrics (
+		request_id TEXT NOT NULL,
+		model_id TEXT NOT NULL,
+		metric TEXT NOT NULL, -- scorer name from the scorers file
+		value REAL NOT NULL,
+		error TEXT NOT NULL DEFAULT '', -- why the scorer failed, value is 0 then
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (request_id, model_id, metric)
+	);
+
+	CREATE TABLE IF NOT EXISTS verifications (
+		request_id TEXT NOT NULL,
+		verifier TEXT NOT NULL, -- model ID of the participant that fact-checked the answer
+		verifier_name TEXT NOT NULL, -- its variant
+		answer_model TEXT NOT NULL, -- model ID of the leading answer that was checked
+		claims TEXT NOT NULL DEFAULT '[]', -- JSON array of {text, status, note}
+		tokens_in INTEGER NOT NULL DEFAULT 0,
+		tokens_out INTEGER NOT NULL DEFAULT 0,
+		cost REAL NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '', -- why the check failed, claims are empty then
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (request_id, verifier)
+	);
+
+	CREATE TABLE IF NOT EXISTS tournaments (
+		id TEXT PRIMARY KEY,
+		question TEXT NOT NULL,
+		jury TEXT NOT NULL DEFAULT '[]', -- JSON array of the variants judging every match
+		winner TEXT NOT NULL DEFAULT '', -- variant left at the end, empty while running or if nobody could play
+		question_ts INTEGER NOT NULL, -- names the tournament's exports in h/
+		total_cost REAL NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS tournament_entries (
+		tournament_id TEXT NOT NULL,
+		model_name TEXT NOT NULL, -- variant
+		model_id TEXT NOT NULL,
+		seed INTEGER NOT NULL, -- 1-based, in the order the variants were entered
+		answer TEXT NOT NULL DEFAULT '',
+		tokens_in INTEGER NOT NULL DEFAULT 0,
+		tokens_out INTEGER NOT NULL DEFAULT 0,
+		cost REAL NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '', -- why the variant couldn't answer; it forfeits its first match then
+		PRIMARY KEY (tournament_id, model_name)
+	);
+
+	CREATE TABLE IF NOT EXISTS tournament_matches (
+		tournament_id TEXT NOT NULL,
+		round INTEGER NOT NULL, -- 1-based
+		slot INTEGER NOT NULL, -- 0-based position in the round, top to bottom
+		model_a TEXT NOT NULL,
+		model_b TEXT NOT NULL DEFAULT '', -- empty for a bye
+		winner TEXT NOT NULL DEFAULT '',
+		votes TEXT NOT NULL DEFAULT '[]', -- JSON array of {juror, vote, reason, error}
+		tokens_in INTEGER NOT NULL DEFAULT 0,
+		tokens_out INTEGER NOT NULL DEFAULT 0,
+		cost REAL NOT NULL DEFAULT 0,
+		PRIMARY KEY (tournament_id, round, slot)
+	);
+
+	CREATE TABLE IF NOT EXISTS judge_weights (
+		judge TEXT PRIMARY KEY, -- ranker model name
+		ballots INTEGER NOT NULL, -- rankings compared against the consensus
+		agreement REAL NOT NULL, -- mean Kendall tau correlation with the other judges' consensus, -1 to 1
+		weight REAL NOT NULL, -- multiplier on the judge's Borda points
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS pricing_history (
+		model_name TEXT NOT NULL, -- variant name
+		model_id TEXT NOT NULL,
+		rate_in REAL NOT NULL, -- USD per million input tokens
+		rate_out REAL NOT NULL, -- USD per million output tokens
+		effective_from TIMESTAMP NOT NULL,
+		PRIMARY KEY (model_name, effective_from)
+	);
+
+	CREATE TABLE IF NOT EXISTS access_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user TEXT NOT NULL, -- who the key was issued to
+		key_hash TEXT NOT NULL UNIQUE, -- hex SHA-256 of the key, which itself is never stored
+		prefix TEXT NOT NULL, -- start of the key, to tell keys apart
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_used_at TIMESTAMP,
+		revoked_at TIMESTAMP
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_requests_created ON requests(created_at);
 	CREATE INDEX IF NOT EXISTS idx_model_rounds_request ON model_rounds(request_id);
 	CREATE INDEX IF NOT EXISTS idx_model_rounds_model ON model_rounds(model_id);
 	CREATE INDEX IF NOT EXISTS idx_model_rounds_model_round ON model_rounds(model_id, round);
 	CREATE INDEX IF NOT EXISTS idx_rankings_request ON rankings(request_id);
+	CREATE INDEX IF NOT EXISTS idx_events_request ON events(request_id, id);
+	CREATE INDEX IF NOT EXISTS idx_requests_question ON requests(question);
+	CREATE INDEX IF NOT EXISTS idx_benchmark_baselines_tag ON benchmark_baselines(tag, model_id, model_name);
 	`
 
 	_, err := db.conn.Exec(schema)
@@ -129,17 +316,28 @@ func (db *DB) initSchema() error {
 
 // Request represents a complete request record
 type Request struct {
-	ID              string
-	Question        string
-	NumRounds       int
-	NumModels       int
-	WinnerModel     string
-	TotalDurationMs int64
-	TotalTokensIn   int64
-	TotalTokensOut  int64
-	TotalCost       float64
-	ErrorCount      int
-	CreatedAt       time.Time
+	ID                string
+	Question          string
+	NumRounds         int
+	NumModels         int
+	WinnerModel       string
+	TotalDurationMs   int64
+	TotalTokensIn     int64
+	TotalTokensOut    int64
+	TotalCost         float64
+	ErrorCount        int
+	Tag               string   // Question set tag used for benchmark tracking
+	Difficulty        *float64 // Estimated question difficulty in [0, 1], nil if unknown
+	ParentRequestID   string   // Request this one follows up on, empty for a fresh question
+	CacheKey          string   // Identifies the question, models and rounds for the answer cache; only written, not read back
+	FinalRanking      string   // JSON array of every model's aggregated place and score, best first; empty before it was stored
+	Strategy          string   // Winner-selection strategy the run used, empty for runs from before strategies
+	AwaitingHuman     bool     // The strategy left the winner to a person, who hasn't picked one yet
+	SynthesizedAnswer string   // The top answers merged into one, empty when the run had no synthesizer or it failed
+	Synthesizer       string   // Model variant that wrote SynthesizedAnswer
+	Provenance        string   // JSON array of SynthesizedAnswer's paragraphs and the answers each draws on, empty if none were cited
+	ClientRef         string   // Opaque reference the submitter attached to correlate the run with its own records
+	CreatedAt         time.Time
 }
 
 // ModelRound represents a single model's performance in one round
@@ -159,20 +357,24 @@ type ModelRound struct {
 	Rationale    string
 	Discussion   string // JSON map of target_agent -> messages
 	PrivateNotes string // Private notes (never shared with other agents)
+	Transforms   string // JSON array of post-processing steps that changed the reply
 	CreatedAt    time.Time
 }
 
 // Ranking represents a model's ranking of all agents
 type Ranking struct {
-	ID           int64
-	RequestID    string
-	RankerModel  string
-	RankedModels string // JSON array
-	DurationMs   int64
-	TokensIn     int64
-	TokensOut    int64
-	Cost         float64
-	CreatedAt    time.Time
+	ID             int64
+	RequestID      string
+	RankerModel    string
+	RankedModels   string // JSON array
+	Justifications string // JSON object of model name -> the judge's one-line reason for its placement, empty if not asked for
+	Meta           bool   // A meta judge's final verdict over the other judges' rankings rather than a judge's ranking
+	Verdict        string // The meta judge's written justification of its verdict
+	DurationMs     int64
+	TokensIn       int64
+	TokensOut      int64
+	Cost           float64
+	CreatedAt      time.Time
 }
 
 // ModelStats represents aggregate statistics for a model
@@ -196,14 +398,16 @@ func (db *DB) SaveRequest(ctx context.Context, req Request) error {
 		INSERT INTO requests (
 			id, question, num_rounds, num_models, winner_model,
 			total_duration_ms, total_tokens_in, total_tokens_out,
-			total_cost, error_count
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			total_cost, error_count, tag, difficulty, parent_request_id, cache_key, final_ranking,
+			strategy, awaiting_human, synthesized_answer, synthesizer, synthesis_provenance, client_ref
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := db.conn.ExecContext(ctx, query,
 		req.ID, req.Question, req.NumRounds, req.NumModels, req.WinnerModel,
 		req.TotalDurationMs, req.TotalTokensIn, req.TotalTokensOut,
-		req.TotalCost, req.ErrorCount,
+		req.TotalCost, req.ErrorCount, req.Tag, req.Difficulty, req.ParentRequestID, req.CacheKey, req.FinalRanking,
+		req.Strategy, req.AwaitingHuman, req.SynthesizedAnswer, req.Synthesizer, req.Provenance, req.ClientRef,
 	)
 
 	if err != nil {
@@ -223,8 +427,8 @@ func (db *DB) SaveModelRound(ctx context.Context, mr ModelRound) error {
 		INSERT INTO model_rounds (
 			request_id, model_id, model_name, round,
 			duration_ms, tokens_in, tokens_out, cost, error,
-			answer, rationale, discussion, private_notes
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			answer, rationale, discussion, private_notes, transforms
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(request_id, model_id, round) DO UPDATE SET
 			duration_ms = CASE WHEN excluded.duration_ms > 0 THEN excluded.duration_ms ELSE model_rounds.duration_ms END,
 			tokens_in = CASE WHEN excluded.tokens_in > 0 THEN excluded.tokens_in ELSE model_rounds.tokens_in END,
@@ -234,13 +438,14 @@ func (db *DB) SaveModelRound(ctx context.Context, mr ModelRound) error {
 			answer = CASE WHEN excluded.answer != '' THEN excluded.answer ELSE model_rounds.answer END,
 			rationale = CASE WHEN excluded.rationale != '' THEN excluded.rationale ELSE model_rounds.rationale END,
 			discussion = CASE WHEN excluded.discussion != '' THEN excluded.discussion ELSE model_rounds.discussion END,
-			private_notes = CASE WHEN excluded.private_notes != '' THEN excluded.private_notes ELSE model_rounds.private_notes END
+			private_notes = CASE WHEN excluded.private_notes != '' THEN excluded.private_notes ELSE model_rounds.private_notes END,
+			transforms = CASE WHEN excluded.transforms != '' THEN excluded.transforms ELSE model_rounds.transforms END
 	`
 
 	_, err := db.conn.ExecContext(ctx, query,
 		mr.RequestID, mr.ModelID, mr.ModelName, mr.Round,
 		mr.DurationMs, mr.TokensIn, mr.TokensOut, mr.Cost, mr.Error,
-		mr.Answer, mr.Rationale, mr.Discussion, mr.PrivateNotes,
+		mr.Answer, mr.Rationale, mr.Discussion, mr.PrivateNotes, mr.Transforms,
 	)
 
 	if err != nil {
@@ -254,13 +459,13 @@ func (db *DB) SaveModelRound(ctx context.Context, mr ModelRound) error {
 func (db *DB) SaveRanking(ctx context.Context, r Ranking) error {
 	query := `
 		INSERT INTO rankings (
-			request_id, ranker_model, ranked_models,
+			request_id, ranker_model, ranked_models, justifications, meta, verdict,
 			duration_ms, tokens_in, tokens_out, cost
-		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := db.conn.ExecContext(ctx, query,
-		r.RequestID, r.RankerModel, r.RankedModels,
+		r.RequestID, r.RankerModel, r.RankedModels, r.Justifications, r.Meta, r.Verdict,
 		r.DurationMs, r.TokensIn, r.TokensOut, r.Cost,
 	)
 
@@ -271,12 +476,53 @@ func (db *DB) SaveRanking(ctx context.Context, r Ranking) error {
 	return nil
 }
 
+// GetRankings retrieves every stored judge's ranking, oldest first; meta judges' verdicts aren't included
+func (db *DB) GetRankings(ctx context.Context) ([]Ranking, error) {
+	return db.queryRankings(ctx, "")
+}
+
+// GetRequestRankings retrieves the rankings submitted by each judge of a request, without the meta judge's verdict
+func (db *DB) GetRequestRankings(ctx context.Context, requestID string) ([]Ranking, error) {
+	return db.queryRankings(ctx, requestID)
+}
+
+// queryRankings retrieves judges' rankings for one request, or for all requests if requestID is empty
+func (db *DB) queryRankings(ctx context.Context, requestID string) ([]Ranking, error) {
+	query := `
+		SELECT id, request_id, ranker_model, ranked_models, justifications,
+		       duration_ms, tokens_in, tokens_out, cost, created_at
+		FROM rankings
+		WHERE (? = '' OR request_id = ?) AND meta = 0
+		ORDER BY id
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, requestID, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rankings: %w", err)
+	}
+	defer rows.Close()
+
+	var rankings []Ranking
+	for rows.Next() {
+		var r Ranking
+		if err := rows.Scan(
+			&r.ID, &r.RequestID, &r.RankerModel, &r.RankedModels, &r.Justifications,
+			&r.DurationMs, &r.TokensIn, &r.TokensOut, &r.Cost, &r.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan ranking: %w", err)
+		}
+		rankings = append(rankings, r)
+	}
+
+	return rankings, rows.Err()
+}
+
 // GetRoundReplies retrieves all round data for a request
 func (db *DB) GetRoundReplies(ctx context.Context, requestID string) (map[string]map[int]ModelRound, error) {
 	query := `
 		SELECT id, request_id, model_id, model_name, round,
 		       duration_ms, tokens_in, tokens_out, cost, error,
-		       answer, rationale, discussion, COALESCE(private_notes, ''), created_at
+		       answer, rationale, discussion, COALESCE(private_notes, ''), COALESCE(transforms, ''), created_at
 		FROM model_rounds
 		WHERE request_id = ?
 		ORDER BY model_id, round
@@ -296,7 +542,7 @@ func (db *DB) GetRoundReplies(ctx context.Context, requestID string) (map[string
 		err := rows.Scan(
 			&mr.ID, &mr.RequestID, &mr.ModelID, &mr.ModelName, &mr.Round,
 			&mr.DurationMs, &mr.TokensIn, &mr.TokensOut, &mr.Cost, &mr.Error,
-			&mr.Answer, &mr.Rationale, &mr.Discussion, &mr.PrivateNotes, &mr.CreatedAt,
+			&mr.Answer, &mr.Rationale, &mr.Discussion, &mr.PrivateNotes, &mr.Transforms, &mr.CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan round data: %w", err)
@@ -315,6 +561,29 @@ func (db *DB) GetRoundReplies(ctx context.Context, requestID string) (map[string
 	return replies, nil
 }
 
+// GetFinalAnswer retrieves a model's answer from the last round it answered in a request
+// Returns an empty string if the model never answered
+func (db *DB) GetFinalAnswer(ctx context.Context, requestID, modelID string) (string, error) {
+	query := `
+		SELECT COALESCE(answer, '')
+		FROM model_rounds
+		WHERE request_id = ? AND model_id = ? AND COALESCE(answer, '') != ''
+		ORDER BY round DESC
+		LIMIT 1
+	`
+
+	var answer string
+	err := db.conn.QueryRowContext(ctx, query, requestID, modelID).Scan(&answer)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get final answer: %w", err)
+	}
+
+	return answer, nil
+}
+
 // UpdateModelStats updates aggregate statistics for a model
 func (db *DB) UpdateModelStats(ctx context.Context, modelID, modelName string, won bool, tokensIn, tokensOut int64, cost float64, responseTimeMs int64) error {
 	// Upsert model stats
@@ -411,13 +680,97 @@ func (db *DB) GetAllModelStats(ctx context.Context) ([]ModelStats, error) {
 	return stats, rows.Err()
 }
 
-// GetRecentRequests retrieves the most recent N requests
+// GetRequest retrieves a single request record, or nil if it does not exist or was deleted
+func (db *DB) GetRequest(ctx context.Context, id string) (*Request, error) {
+	query := `
+		SELECT id, question, num_rounds, num_models, winner_model,
+			   total_duration_ms, total_tokens_in, total_tokens_out,
+			   total_cost, error_count, tag, difficulty, parent_request_id, final_ranking,
+			   strategy, awaiting_human, synthesized_answer, synthesizer, synthesis_provenance, client_ref, created_at
+		FROM requests
+		WHERE id = ? AND deleted_at IS NULL
+	`
+
+	var r Request
+	err := db.conn.QueryRowContext(ctx, query, id).Scan(
+		&r.ID, &r.Question, &r.NumRounds, &r.NumModels, &r.WinnerModel,
+		&r.TotalDurationMs, &r.TotalTokensIn, &r.TotalTokensOut,
+		&r.TotalCost, &r.ErrorCount, &r.Tag, &r.Difficulty, &r.ParentRequestID, &r.FinalRanking,
+		&r.Strategy, &r.AwaitingHuman, &r.SynthesizedAnswer, &r.Synthesizer, &r.Provenance, &r.ClientRef, &r.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	return &r, nil
+}
+
+// GetCachedRequestID returns the newest error-free request stored with cacheKey since the given time, or "" if there is none
+func (db *DB) GetCachedRequestID(ctx context.Context, cacheKey string, since time.Time) (string, error) {
+	query := `
+		SELECT id
+		FROM requests
+		WHERE cache_key = ? AND error_count = 0 AND created_at >= ? AND deleted_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var id string
+	err := db.conn.QueryRowContext(ctx, query, cacheKey, since.UTC().Format(time.DateTime)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up cached request: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetRequests retrieves every request not deleted, oldest first, limited to one question set tag unless tag is empty
+func (db *DB) GetRequests(ctx context.Context, tag string) ([]Request, error) {
+	query := `
+		SELECT id, question, num_rounds, num_models, winner_model,
+			   total_duration_ms, total_tokens_in, total_tokens_out,
+			   total_cost, error_count, tag, difficulty, parent_request_id, final_ranking, created_at
+		FROM requests
+		WHERE (? = '' OR tag = ?) AND deleted_at IS NULL
+		ORDER BY created_at, id
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, tag, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []Request
+	for rows.Next() {
+		var r Request
+		if err := rows.Scan(
+			&r.ID, &r.Question, &r.NumRounds, &r.NumModels, &r.WinnerModel,
+			&r.TotalDurationMs, &r.TotalTokensIn, &r.TotalTokensOut,
+			&r.TotalCost, &r.ErrorCount, &r.Tag, &r.Difficulty, &r.ParentRequestID, &r.FinalRanking, &r.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan request: %w", err)
+		}
+		requests = append(requests, r)
+	}
+
+	return requests, rows.Err()
+}
+
+// GetRecentRequests retrieves the most recent N requests not deleted
 func (db *DB) GetRecentRequests(ctx context.Context, limit int) ([]Request, error) {
 	query := `
 		SELECT id, question, num_rounds, num_models, winner_model,
 			   total_duration_ms, total_tokens_in, total_tokens_out,
-			   total_cost, error_count, created_at
+			   total_cost, error_count, tag, difficulty, parent_request_id, final_ranking, created_at
 		FROM requests
+		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT ?
 	`
@@ -434,7 +787,7 @@ func (db *DB) GetRecentRequests(ctx context.Context, limit int) ([]Request, erro
 		if err := rows.Scan(
 			&r.ID, &r.Question, &r.NumRounds, &r.NumModels, &r.WinnerModel,
 			&r.TotalDurationMs, &r.TotalTokensIn, &r.TotalTokensOut,
-			&r.TotalCost, &r.ErrorCount, &r.CreatedAt,
+			&r.TotalCost, &r.ErrorCount, &r.Tag, &r.Difficulty, &r.ParentRequestID, &r.FinalRanking, &r.CreatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan request: %w", err)
 		}
@@ -2,11 +2,17 @@ package db
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"log/slog"
+
+	"github.com/meedamian/fat/internal/types"
 )
 
 func TestNew(t *testing.T) {
@@ -54,6 +60,22 @@ func TestSaveRequest(t *testing.T) {
 		t.Fatalf("Failed to save request: %v", err)
 	}
 
+	saved, err := db.GetRequest(ctx, "test-123")
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if saved == nil || saved.Question != req.Question {
+		t.Errorf("Expected saved request with question %q, got %+v", req.Question, saved)
+	}
+
+	missing, err := db.GetRequest(ctx, "missing")
+	if err != nil {
+		t.Fatalf("Failed to get missing request: %v", err)
+	}
+	if missing != nil {
+		t.Error("Expected nil for missing request")
+	}
+
 	// Verify it was saved
 	requests, err := db.GetRecentRequests(ctx, 1)
 	if err != nil {
@@ -114,11 +136,38 @@ func TestSaveModelRound(t *testing.T) {
 		TokensOut:  50,
 		Cost:       0.01,
 		Error:      "",
+		Answer:     "First draft",
+		Transforms: `["strip-fences"]`,
 	}
 
 	if err := db.SaveModelRound(ctx, mr); err != nil {
 		t.Fatalf("Failed to save model round: %v", err)
 	}
+
+	// A later round without an answer (e.g. failed) must not replace the final answer
+	if err := db.SaveModelRound(ctx, ModelRound{RequestID: "test-456", ModelID: "grok", ModelName: "grok-4-fast", Round: 2, Error: "timeout"}); err != nil {
+		t.Fatalf("Failed to save model round: %v", err)
+	}
+
+	answer, err := db.GetFinalAnswer(ctx, "test-456", "grok")
+	if err != nil {
+		t.Fatalf("Failed to get final answer: %v", err)
+	}
+	if answer != "First draft" {
+		t.Errorf("Expected final answer 'First draft', got %q", answer)
+	}
+
+	// Metrics saved later must not erase the recorded post-processing steps
+	if err := db.SaveModelRound(ctx, ModelRound{RequestID: "test-456", ModelID: "grok", ModelName: "grok-4-fast", Round: 1, DurationMs: 1200}); err != nil {
+		t.Fatalf("Failed to save model round metrics: %v", err)
+	}
+	rounds, err := db.GetRoundReplies(ctx, "test-456")
+	if err != nil {
+		t.Fatalf("Failed to get round replies: %v", err)
+	}
+	if got := rounds["grok"][1].Transforms; got != `["strip-fences"]` {
+		t.Errorf("Expected transforms to survive metrics update, got %q", got)
+	}
 }
 
 func TestUpdateModelStats(t *testing.T) {
@@ -231,6 +280,69 @@ func TestGetAllModelStats(t *testing.T) {
 	}
 }
 
+func TestMigrateRekeyModelStats(t *testing.T) {
+	dbPath := "test_rekey_stats.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// A matchup of two gpt variants next to grok, whose stats went to the gpt family's row
+	if err := db.SaveRequest(ctx, Request{ID: "matchup", Question: "Q", NumRounds: 2, NumModels: 3, WinnerModel: "gpt-5-mini"}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	for _, r := range []ModelRound{
+		{ModelID: "gpt-5", ModelName: "gpt-5", Round: 1, DurationMs: 1000, TokensIn: 10, TokensOut: 5, Cost: 0.5},
+		{ModelID: "gpt-5", ModelName: "gpt-5", Round: 2, DurationMs: 3000, TokensIn: 20, TokensOut: 5, Cost: 0.5},
+		{ModelID: "gpt-5-mini", ModelName: "gpt-5-mini", Round: 1, DurationMs: 500, TokensIn: 10, TokensOut: 5, Cost: 0.1},
+		{ModelID: "grok", ModelName: "grok-4-fast", Round: 1, DurationMs: 800, TokensIn: 10, TokensOut: 5, Cost: 0.2},
+	} {
+		r.RequestID = "matchup"
+		if err := db.SaveModelRound(ctx, r); err != nil {
+			t.Fatalf("Failed to save model round: %v", err)
+		}
+	}
+	for _, s := range []struct {
+		id, name string
+		won      bool
+	}{{"gpt", "gpt-5", false}, {"gpt", "gpt-5-mini", true}, {"grok", "grok-4-fast", false}} {
+		if err := db.UpdateModelStats(ctx, s.id, s.name, s.won, 10, 5, 0.5, 1000); err != nil {
+			t.Fatalf("Failed to update model stats: %v", err)
+		}
+	}
+
+	if err := db.MigrateRekeyModelStats(ctx); err != nil {
+		t.Fatalf("Failed to rekey model stats: %v", err)
+	}
+
+	allStats, err := db.GetAllModelStats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get all model stats: %v", err)
+	}
+	byID := make(map[string]ModelStats, len(allStats))
+	for _, s := range allStats {
+		byID[s.ModelID] = s
+	}
+	if _, ok := byID["gpt"]; ok || len(byID) != 3 {
+		t.Fatalf("Expected stats for gpt-5, gpt-5-mini and grok but not the gpt family, got %+v", allStats)
+	}
+	if s := byID["gpt-5"]; s.TotalRequests != 1 || s.TotalWins != 0 || s.TotalTokensIn != 30 || s.AvgResponseTimeMs != 2000 {
+		t.Errorf("Expected gpt-5's two rounds counted as one request, got %+v", s)
+	}
+	if s := byID["gpt-5-mini"]; s.TotalRequests != 1 || s.TotalWins != 1 || s.ModelName != "gpt-5-mini" {
+		t.Errorf("Expected gpt-5-mini's win, got %+v", s)
+	}
+	if s := byID["grok"]; s.TotalRequests != 1 || s.TotalCost != 0.2 || s.ModelName != "grok-4-fast" {
+		t.Errorf("Expected grok kept under its family ID, got %+v", s)
+	}
+}
+
 func TestGetRecentRequests(t *testing.T) {
 	dbPath := "test_recent.db"
 	defer os.Remove(dbPath)
@@ -312,16 +424,1527 @@ func TestSaveRanking(t *testing.T) {
 
 	// Save ranking
 	ranking := Ranking{
-		RequestID:    "test-789",
-		RankerModel:  "grok",
-		RankedModels: `["grok","gpt","claude"]`,
-		DurationMs:   500,
-		TokensIn:     50,
-		TokensOut:    25,
-		Cost:         0.005,
+		RequestID:      "test-789",
+		RankerModel:    "grok",
+		RankedModels:   `["grok","gpt","claude"]`,
+		Justifications: `{"claude":"Misses the edge case"}`,
+		DurationMs:     500,
+		TokensIn:       50,
+		TokensOut:      25,
+		Cost:           0.005,
 	}
 
 	if err := db.SaveRanking(ctx, ranking); err != nil {
 		t.Fatalf("Failed to save ranking: %v", err)
 	}
+
+	rankings, err := db.GetRankings(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get rankings: %v", err)
+	}
+	if len(rankings) != 1 {
+		t.Fatalf("Expected 1 ranking, got %d", len(rankings))
+	}
+	if rankings[0].RankedModels != ranking.RankedModels {
+		t.Errorf("Expected ranked models %s, got %s", ranking.RankedModels, rankings[0].RankedModels)
+	}
+	if rankings[0].Justifications != ranking.Justifications {
+		t.Errorf("Expected justifications %s, got %s", ranking.Justifications, rankings[0].Justifications)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	dbPath := "test_events.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	start := time.Date(2025, 1, 3, 12, 0, 0, 0, time.UTC)
+	for i, eventType := range []string{"clear", "round_start", "response"} {
+		e := Event{
+			RequestID: "test-events",
+			Type:      eventType,
+			Payload:   []byte(`{"type":"` + eventType + `"}`),
+			CreatedAt: start.Add(time.Duration(i) * 250 * time.Millisecond),
+		}
+		if err := db.SaveEvent(ctx, e); err != nil {
+			t.Fatalf("Failed to save event %s: %v", eventType, err)
+		}
+	}
+
+	// Events from other requests must not leak in
+	if err := db.SaveEvent(ctx, Event{RequestID: "other", Type: "clear", Payload: []byte(`{}`)}); err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+
+	events, err := db.GetEvents(ctx, "test-events", 0)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+
+	if events[0].Type != "clear" || events[2].Type != "response" {
+		t.Errorf("Events not in insertion order: %s, %s", events[0].Type, events[2].Type)
+	}
+
+	if string(events[1].Payload) != `{"type":"round_start"}` {
+		t.Errorf("Unexpected payload: %s", events[1].Payload)
+	}
+
+	// Events within a second keep their milliseconds for replays
+	if gap := events[2].CreatedAt.Sub(events[1].CreatedAt); gap != 250*time.Millisecond {
+		t.Errorf("Expected events 250ms apart, got %v", gap)
+	}
+
+	// Resume after the first event
+	events, err = db.GetEvents(ctx, "test-events", events[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events after cursor, got %d", len(events))
+	}
+}
+
+func TestRequestState(t *testing.T) {
+	dbPath := "test_state.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	st := RequestState{
+		RequestID:    "test-state",
+		Question:     "Resume me",
+		NumRounds:    3,
+		QuestionTS:   1700000000,
+		Models:       `{"grok":"grok-4-fast"}`,
+		Round:        1,
+		Replies:      `{}`,
+		Discussion:   `{}`,
+		PrivateNotes: `{}`,
+		Status:       StateRunning,
+	}
+	if err := db.SaveRequestState(ctx, st); err != nil {
+		t.Fatalf("Failed to save request state: %v", err)
+	}
+
+	// Later snapshot overwrites progress, and the round count when a run stops early
+	st.Round = 2
+	st.NumRounds = 2
+	if err := db.SaveRequestState(ctx, st); err != nil {
+		t.Fatalf("Failed to update request state: %v", err)
+	}
+
+	got, err := db.GetRequestState(ctx, "test-state")
+	if err != nil {
+		t.Fatalf("Failed to get request state: %v", err)
+	}
+	if got == nil {
+		t.Fatal("Expected request state, got nil")
+	}
+	if got.Round != 2 || got.NumRounds != 2 {
+		t.Errorf("Expected round 2 of 2, got %d of %d", got.Round, got.NumRounds)
+	}
+
+	resumable, err := db.GetResumableRequests(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get resumable requests: %v", err)
+	}
+	if len(resumable) != 1 {
+		t.Errorf("Expected 1 resumable request, got %d", len(resumable))
+	}
+
+	if err := db.SetRequestStateStatus(ctx, "test-state", StateComplete); err != nil {
+		t.Fatalf("Failed to set status: %v", err)
+	}
+
+	resumable, err = db.GetResumableRequests(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get resumable requests: %v", err)
+	}
+	if len(resumable) != 0 {
+		t.Errorf("Expected no resumable requests, got %d", len(resumable))
+	}
+
+	missing, err := db.GetRequestState(ctx, "missing")
+	if err != nil {
+		t.Fatalf("Failed to get missing state: %v", err)
+	}
+	if missing != nil {
+		t.Error("Expected nil for missing request state")
+	}
+
+	// Only unfinished snapshots older than the cutoff are pruned
+	if err := db.SaveRequestState(ctx, RequestState{RequestID: "abandoned", Question: "Q", NumRounds: 3, Models: "{}", Replies: "{}", Discussion: "{}", PrivateNotes: "{}", Options: "{}", Status: StateRunning}); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+	if pruned, err := db.PruneRequestStates(ctx, time.Now().Add(-time.Hour)); err != nil || pruned != 0 {
+		t.Errorf("Expected a fresh snapshot kept, got %d pruned (%v)", pruned, err)
+	}
+	if pruned, err := db.PruneRequestStates(ctx, time.Now().Add(time.Hour)); err != nil || pruned != 1 {
+		t.Errorf("Expected only the abandoned snapshot pruned, got %d (%v)", pruned, err)
+	}
+	if st, err := db.GetRequestState(ctx, "test-state"); err != nil || st == nil {
+		t.Errorf("Expected the completed snapshot kept, got %v (%v)", st, err)
+	}
+}
+
+func TestBenchmarkWinRates(t *testing.T) {
+	dbPath := "test_benchmark.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Three tagged requests: grok wins two, gpt wins one; one untagged request
+	requests := []struct {
+		id, winner, tag string
+	}{
+		{"bench-1", "grok", "math"},
+		{"bench-2", "grok", "math"},
+		{"bench-3", "gpt", "math"},
+		{"bench-4", "gpt", ""},
+	}
+	for _, r := range requests {
+		if err := db.SaveRequest(ctx, Request{ID: r.id, Question: "Q", NumRounds: 1, NumModels: 2, WinnerModel: r.winner, Tag: r.tag}); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+		for _, m := range []string{"grok", "gpt"} {
+			mr := ModelRound{RequestID: r.id, ModelID: m, ModelName: m + "-1", Round: 1}
+			if err := db.SaveModelRound(ctx, mr); err != nil {
+				t.Fatalf("Failed to save model round: %v", err)
+			}
+		}
+	}
+
+	rates, err := db.GetModelWinRates(ctx, "math", time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get win rates: %v", err)
+	}
+	if len(rates) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(rates))
+	}
+
+	for _, r := range rates {
+		if r.Runs != 3 {
+			t.Errorf("Expected 3 runs for %s, got %d", r.ModelID, r.Runs)
+		}
+		if r.ModelID == "grok" && r.Wins != 2 {
+			t.Errorf("Expected 2 wins for grok, got %d", r.Wins)
+		}
+	}
+
+	allRates, err := db.GetAllWinRates(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get all win rates: %v", err)
+	}
+	for _, r := range allRates {
+		if r.Runs != 4 {
+			t.Errorf("Expected 4 runs for %s across all tags, got %d", r.ModelID, r.Runs)
+		}
+		// No difficulty stored - every request weighs UnknownDifficulty
+		if r.WeightedRuns != 4*UnknownDifficulty {
+			t.Errorf("Expected weighted runs %f for %s, got %f", 4*UnknownDifficulty, r.ModelID, r.WeightedRuns)
+		}
+		if r.EffectiveRuns != 4 {
+			t.Errorf("Expected 4 effective runs for %s, got %f", r.ModelID, r.EffectiveRuns)
+		}
+	}
+
+	if err := db.SaveBenchmarkBaseline(ctx, BenchmarkBaseline{Tag: "math", ModelID: "grok", ModelName: "grok-1", Runs: 3, Wins: 1}); err != nil {
+		t.Fatalf("Failed to save baseline: %v", err)
+	}
+	if err := db.SaveBenchmarkBaseline(ctx, BenchmarkBaseline{Tag: "math", ModelID: "grok", ModelName: "grok-1", Runs: 3, Wins: 2}); err != nil {
+		t.Fatalf("Failed to save baseline: %v", err)
+	}
+
+	baselines, err := db.GetLatestBaselines(ctx, "math")
+	if err != nil {
+		t.Fatalf("Failed to get baselines: %v", err)
+	}
+	if len(baselines) != 1 || baselines[0].Wins != 2 {
+		t.Errorf("Expected latest baseline with 2 wins, got %+v", baselines)
+	}
+}
+
+func TestSuiteResults(t *testing.T) {
+	dbPath := "test_suite.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// grok wins both benchmark runs, gpt fails round 2 of the second; the untagged run doesn't count
+	for _, id := range []string{"suite-1", "suite-2", "other"} {
+		tag := "bench"
+		if id == "other" {
+			tag = ""
+		}
+		if err := db.SaveRequest(ctx, Request{ID: id, Question: "Q", NumRounds: 2, NumModels: 2, WinnerModel: "grok", Tag: tag}); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+		for round := 1; round <= 2; round++ {
+			for _, m := range []string{"grok", "gpt"} {
+				mr := ModelRound{RequestID: id, ModelID: m, ModelName: m + "-1", Round: round, DurationMs: int64(1000 * round), Cost: 0.01}
+				if id == "suite-2" && m == "gpt" && round == 2 {
+					mr.Error, mr.Cost = "timeout", 0
+				}
+				if err := db.SaveModelRound(ctx, mr); err != nil {
+					t.Fatalf("Failed to save model round: %v", err)
+				}
+			}
+		}
+	}
+
+	results, err := db.GetSuiteResults(ctx, "bench")
+	if err != nil {
+		t.Fatalf("Failed to get suite results: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 models, got %+v", results)
+	}
+
+	gpt, grok := results[0], results[1]
+	if grok.Runs != 2 || grok.Wins != 2 || grok.Calls != 4 || grok.Errors != 0 || grok.MeanLatencyMs != 1500 {
+		t.Errorf("Unexpected grok results: %+v", grok)
+	}
+	if gpt.Wins != 0 || gpt.Calls != 3 || gpt.Errors != 1 || math.Abs(gpt.Cost-0.03) > 1e-9 || math.Round(gpt.MeanLatencyMs) != 1333 {
+		t.Errorf("Unexpected gpt results: %+v", gpt)
+	}
+}
+
+func TestSampleQuestions(t *testing.T) {
+	dbPath := "test_questions.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	added, err := db.SeedSampleQuestions(ctx, []string{"Why is the sky blue?", " ", "What is 2+2?", "What is 2+2?"})
+	if err != nil {
+		t.Fatalf("Failed to seed sample questions: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("Expected 2 seeded questions, got %d", added)
+	}
+
+	// Seeding again must not resurrect questions after admin edits
+	if added, err := db.SeedSampleQuestions(ctx, []string{"Another?"}); err != nil || added != 0 {
+		t.Errorf("Expected no reseed of a non-empty table, got %d (err %v)", added, err)
+	}
+
+	q, err := db.AddSampleQuestion(ctx, "Prove Fermat's last theorem", "math")
+	if err != nil {
+		t.Fatalf("Failed to add sample question: %v", err)
+	}
+	if q.ID == 0 || q.Category != "math" || !q.Enabled {
+		t.Errorf("Unexpected added question: %+v", q)
+	}
+	if _, err := db.AddSampleQuestion(ctx, "What is 2+2?", ""); err != ErrDuplicateQuestion {
+		t.Errorf("Expected ErrDuplicateQuestion, got %v", err)
+	}
+
+	for i, winner := range []string{"grok", "gpt", "grok"} {
+		req := Request{ID: "req-" + string(rune('a'+i)), Question: "Prove Fermat's last theorem", NumRounds: 1, NumModels: 2, WinnerModel: winner}
+		if err := db.SaveRequest(ctx, req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+
+	q, err = db.GetSampleQuestion(ctx, q.ID)
+	if err != nil {
+		t.Fatalf("Failed to get sample question: %v", err)
+	}
+	if q.TimesAsked != 3 || q.Wins["grok"] != 2 || q.TopWinner != "grok" {
+		t.Errorf("Unexpected stats: asked %d, wins %v, top %q", q.TimesAsked, q.Wins, q.TopWinner)
+	}
+
+	disabled := false
+	if _, err := db.UpdateSampleQuestion(ctx, q.ID, SampleQuestionUpdate{Enabled: &disabled}); err != nil {
+		t.Fatalf("Failed to disable sample question: %v", err)
+	}
+
+	mathQuestions, err := db.GetSampleQuestions(ctx, "math", false)
+	if err != nil {
+		t.Fatalf("Failed to list sample questions: %v", err)
+	}
+	if len(mathQuestions) != 0 {
+		t.Errorf("Expected disabled question to be hidden, got %d", len(mathQuestions))
+	}
+	all, err := db.GetSampleQuestions(ctx, "", true)
+	if err != nil {
+		t.Fatalf("Failed to list sample questions: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("Expected 3 questions including disabled, got %d", len(all))
+	}
+
+	if picked, err := db.RandomSampleQuestion(ctx, "math"); err != nil || picked != "" {
+		t.Errorf("Expected no enabled math question, got %q (err %v)", picked, err)
+	}
+	if picked, err := db.RandomSampleQuestion(ctx, ""); err != nil || picked == "" {
+		t.Errorf("Expected a random question, got %q (err %v)", picked, err)
+	}
+
+	if missing, err := db.UpdateSampleQuestion(ctx, 9999, SampleQuestionUpdate{Enabled: &disabled}); err != nil || missing != nil {
+		t.Errorf("Expected nil for missing question, got %+v (err %v)", missing, err)
+	}
+}
+
+func TestPriceHistory(t *testing.T) {
+	dbPath := "test_pricing.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	jan := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	jun := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	recorded, err := db.RecordPrices(ctx, []PriceSnapshot{
+		{ModelID: "gpt", ModelName: "gpt-5", RateIn: 2, RateOut: 10, EffectiveFrom: jan},
+		{ModelID: "grok", ModelName: "grok-4", RateIn: 3, RateOut: 15, EffectiveFrom: jan},
+	})
+	if err != nil || recorded != 2 {
+		t.Fatalf("Expected 2 prices recorded, got %d (%v)", recorded, err)
+	}
+
+	// Unchanged rates aren't recorded again, changed ones start a new period
+	recorded, err = db.RecordPrices(ctx, []PriceSnapshot{
+		{ModelID: "gpt", ModelName: "gpt-5", RateIn: 1, RateOut: 5, EffectiveFrom: jun},
+		{ModelID: "grok", ModelName: "grok-4", RateIn: 3, RateOut: 15, EffectiveFrom: jun},
+	})
+	if err != nil || recorded != 1 {
+		t.Fatalf("Expected 1 price change recorded, got %d (%v)", recorded, err)
+	}
+
+	history, err := db.GetPriceHistory(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get price history: %v", err)
+	}
+
+	tests := []struct {
+		at     time.Time
+		wantIn float64
+	}{
+		{jan.AddDate(-1, 0, 0), 2}, // Before any snapshot: earliest known rate
+		{jan.AddDate(0, 2, 0), 2},
+		{jun, 1},
+		{jun.AddDate(1, 0, 0), 1},
+	}
+	for _, tt := range tests {
+		rate, ok := history.At("gpt-5", tt.at)
+		if !ok || rate.In != tt.wantIn {
+			t.Errorf("At(gpt-5, %s) = %v, want input rate %v", tt.at.Format(time.DateOnly), rate, tt.wantIn)
+		}
+	}
+	if _, ok := history.At("unknown", jun); ok {
+		t.Error("Expected no rate for a variant without history")
+	}
+}
+
+func TestRecomputeCosts(t *testing.T) {
+	dbPath := "test_recompute.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	past := time.Now().AddDate(0, -1, 0)
+	future := time.Now().AddDate(0, 1, 0)
+
+	// The rate in force when the request runs, and a later price cut that mustn't apply to it
+	if _, err := db.RecordPrices(ctx, []PriceSnapshot{{ModelID: "gpt", ModelName: "gpt-5", RateIn: 2, RateOut: 10, EffectiveFrom: past}}); err != nil {
+		t.Fatalf("Failed to record prices: %v", err)
+	}
+	if _, err := db.RecordPrices(ctx, []PriceSnapshot{{ModelID: "gpt", ModelName: "gpt-5", RateIn: 1, RateOut: 5, EffectiveFrom: future}}); err != nil {
+		t.Fatalf("Failed to record prices: %v", err)
+	}
+
+	for _, id := range []string{"list", "discounted"} {
+		if err := db.SaveRequest(ctx, Request{ID: id, Question: "Q", NumRounds: 1, NumModels: 1, TotalCost: 99}); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+		if err := db.SaveModelRound(ctx, ModelRound{RequestID: id, ModelID: "gpt", ModelName: "gpt-5", Round: 1, TokensIn: 1_000_000, TokensOut: 100_000, Cost: 99}); err != nil {
+			t.Fatalf("Failed to save model round: %v", err)
+		}
+		if err := db.SaveRanking(ctx, Ranking{RequestID: id, RankerModel: "gpt-5", RankedModels: `["gpt-5"]`, TokensIn: 500_000, Cost: 99}); err != nil {
+			t.Fatalf("Failed to save ranking: %v", err)
+		}
+	}
+	if err := db.SaveRequestState(ctx, RequestState{RequestID: "discounted", Question: "Q", NumRounds: 1, Models: "{}", Replies: "{}", Discussion: "{}", PrivateNotes: "{}", Options: `{"pricing":{"multiplier":0.5}}`, Status: StateComplete}); err != nil {
+		t.Fatalf("Failed to save request state: %v", err)
+	}
+
+	recomputed, err := db.RecomputeCosts(ctx)
+	if err != nil {
+		t.Fatalf("Failed to recompute costs: %v", err)
+	}
+	if recomputed != 2 {
+		t.Errorf("Expected 2 requests recomputed, got %d", recomputed)
+	}
+
+	// 1M in at $2 + 100k out at $10 = $3 per round, 500k in at $2 = $1 per ranking
+	want := map[string]float64{"list": 4, "discounted": 2}
+	for id, cost := range want {
+		req, err := db.GetRequest(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to get request: %v", err)
+		}
+		if req.TotalCost != cost {
+			t.Errorf("Expected %s total cost %v, got %v", id, cost, req.TotalCost)
+		}
+	}
+}
+
+func TestSelfPreference(t *testing.T) {
+	dbPath := "test_self_preference.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	records := []SelfPreference{
+		{RequestID: "r1", JudgeModel: "grok-4", SelfPosition: 0, Ranked: 3, SelfScore: 1, PeerScore: 0.5, Bias: 0.5},
+		{RequestID: "r2", JudgeModel: "grok-4", SelfPosition: 1, Ranked: 3, SelfScore: 0.5, PeerScore: 0.5, Bias: 0},
+		{RequestID: "r1", JudgeModel: "gpt-5", SelfPosition: 2, Ranked: 3, SelfScore: 0, PeerScore: 0.25, Bias: -0.25},
+	}
+	for _, sp := range records {
+		if err := db.SaveSelfPreference(ctx, sp); err != nil {
+			t.Fatalf("Failed to save self-preference: %v", err)
+		}
+	}
+
+	stats, err := db.GetSelfPreferenceStats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get self-preference stats: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 judges, got %d", len(stats))
+	}
+	if stats[0].JudgeModel != "grok-4" || stats[0].Rankings != 2 || stats[0].SelfFirst != 1 || stats[0].MeanBias != 0.25 {
+		t.Errorf("Expected grok-4 first with 2 rankings, 1 self-first and bias 0.25, got %+v", stats[0])
+	}
+}
+
+func TestAnswerSimilarities(t *testing.T) {
+	dbPath := "test_answer_similarity.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	pairs := []AnswerSimilarity{
+		{RequestID: "r1", ModelA: "grok", ModelB: "gpt", Similarity: 0.8, EmbeddingModel: "text-embedding-3-small"},
+		{RequestID: "r1", ModelA: "claude", ModelB: "grok", Similarity: 0.6, EmbeddingModel: "text-embedding-3-small"},
+		{RequestID: "r2", ModelA: "claude", ModelB: "gpt", Similarity: 0.9, EmbeddingModel: "text-embedding-3-small"},
+	}
+	if err := db.SaveAnswerSimilarities(ctx, pairs); err != nil {
+		t.Fatalf("Failed to save answer similarities: %v", err)
+	}
+	// Saving again replaces the earlier score
+	if err := db.SaveAnswerSimilarities(ctx, []AnswerSimilarity{{RequestID: "r1", ModelA: "grok", ModelB: "gpt", Similarity: 0.7, EmbeddingModel: "nomic-embed-text"}}); err != nil {
+		t.Fatalf("Failed to update answer similarity: %v", err)
+	}
+
+	got, err := db.GetAnswerSimilarities(ctx, "r1")
+	if err != nil {
+		t.Fatalf("Failed to get answer similarities: %v", err)
+	}
+	if len(got) != 2 || got[0].ModelA != "claude" || got[1].Similarity != 0.7 || got[1].EmbeddingModel != "nomic-embed-text" {
+		t.Errorf("Expected r1's 2 pairs ordered by model with the update applied, got %+v", got)
+	}
+
+	if got, err := db.GetAnswerSimilarities(ctx, "missing"); err != nil || len(got) != 0 {
+		t.Errorf("Expected no pairs for an unknown request, got %+v, %v", got, err)
+	}
+}
+
+func TestAnswerMetrics(t *testing.T) {
+	dbPath := "test_answer_metrics.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.SaveAnswerMetrics(ctx, []AnswerMetric{
+		{RequestID: "r1", ModelID: "grok", Metric: "tests", Value: 7},
+		{RequestID: "r1", ModelID: "gpt", Metric: "tests", Error: "command timed out"},
+		{RequestID: "r2", ModelID: "gpt", Metric: "tests", Value: 3},
+	}); err != nil {
+		t.Fatalf("Failed to save answer metrics: %v", err)
+	}
+	// Scoring again replaces the earlier result
+	if err := db.SaveAnswerMetrics(ctx, []AnswerMetric{{RequestID: "r1", ModelID: "gpt", Metric: "tests", Value: 9}}); err != nil {
+		t.Fatalf("Failed to update answer metric: %v", err)
+	}
+
+	got, err := db.GetAnswerMetrics(ctx, "r1")
+	if err != nil {
+		t.Fatalf("Failed to get answer metrics: %v", err)
+	}
+	if len(got) != 2 || got[0].ModelID != "gpt" || got[0].Value != 9 || got[0].Error != "" || got[1].Value != 7 {
+		t.Errorf("Expected r1's metrics ordered by model with the update applied, got %+v", got)
+	}
+}
+
+func TestJudgeWeights(t *testing.T) {
+	dbPath := "test_judge_weights.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.SaveJudgeWeights(ctx, []JudgeWeight{
+		{Judge: "grok-4", Ballots: 10, Agreement: 0.2, Weight: 0.4},
+		{Judge: "gpt-5", Ballots: 12, Agreement: 0.9, Weight: 0.95},
+	}); err != nil {
+		t.Fatalf("Failed to save judge weights: %v", err)
+	}
+
+	got, err := db.GetJudgeWeights(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get judge weights: %v", err)
+	}
+	if len(got) != 2 || got[0].Judge != "gpt-5" || got[0].Ballots != 12 || got[1].Weight != 0.4 {
+		t.Errorf("Expected both weights, highest first, got %+v", got)
+	}
+
+	// Saving again replaces every earlier weight
+	if err := db.SaveJudgeWeights(ctx, []JudgeWeight{{Judge: "claude-4", Ballots: 3, Agreement: 1, Weight: 1}}); err != nil {
+		t.Fatalf("Failed to replace judge weights: %v", err)
+	}
+	if got, err := db.GetJudgeWeights(ctx); err != nil || len(got) != 1 || got[0].Judge != "claude-4" {
+		t.Errorf("Expected only the new weight, got %+v, %v", got, err)
+	}
+}
+
+func TestFinalRanking(t *testing.T) {
+	dbPath := "test_final_ranking.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	requests := []Request{
+		{ID: "r1", Question: "Q1", WinnerModel: "grok", FinalRanking: `[{"model":"grok","place":1,"score":9},{"model":"gpt","place":2,"score":6},{"model":"mistral","place":4,"score":1}]`},
+		{ID: "r2", Question: "Q2", WinnerModel: "gpt", FinalRanking: `[{"model":"gpt","place":1,"score":8,"tied":true},{"model":"grok","place":1,"score":8,"tied":true}]`},
+		{ID: "r3", Question: "Q3", WinnerModel: "gpt"}, // Stored before final rankings
+	}
+	for _, r := range requests {
+		if err := db.SaveRequest(ctx, r); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+
+	got, err := db.GetRequest(ctx, "r1")
+	if err != nil || got == nil || got.FinalRanking != requests[0].FinalRanking {
+		t.Fatalf("Expected the final ranking to be read back, got %+v, %v", got, err)
+	}
+
+	counts, err := db.GetPlacementCounts(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get placement counts: %v", err)
+	}
+	want := []PlacementCount{
+		{ModelID: "gpt", Place: 1, Count: 1},
+		{ModelID: "gpt", Place: 2, Count: 1},
+		{ModelID: "grok", Place: 1, Count: 2},
+		{ModelID: "mistral", Place: 4, Count: 1},
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("Expected %d placement counts, got %+v", len(want), counts)
+	}
+	for i := range want {
+		if counts[i] != want[i] {
+			t.Errorf("Placement %d: expected %+v, got %+v", i, want[i], counts[i])
+		}
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	dbPath := "test_history.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, r := range []Request{
+		{ID: "old", Question: "Old?", NumRounds: 1, NumModels: 2, WinnerModel: "grok"},
+		{ID: "mid", Question: "Mid?", NumRounds: 1, NumModels: 2, WinnerModel: "gpt"},
+		{ID: "new", Question: "New?", NumRounds: 1, NumModels: 2, WinnerModel: "grok"},
+	} {
+		if err := db.SaveRequest(ctx, r); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+	for id, day := range map[string]string{"old": "2025-01-01", "mid": "2025-01-02", "new": "2025-01-03"} {
+		if _, err := db.conn.ExecContext(ctx, "UPDATE requests SET created_at = ? WHERE id = ?", day+" 12:00:00", id); err != nil {
+			t.Fatalf("Failed to backdate request: %v", err)
+		}
+	}
+	if err := db.SaveModelRound(ctx, ModelRound{RequestID: "mid", ModelID: "claude", ModelName: "claude-sonnet-4-5", Round: 1}); err != nil {
+		t.Fatalf("Failed to save model round: %v", err)
+	}
+	if err := db.SaveRequestState(ctx, RequestState{RequestID: "new", Question: "New?", NumRounds: 1, QuestionTS: 1735905600, Status: StateComplete}); err != nil {
+		t.Fatalf("Failed to save request state: %v", err)
+	}
+
+	entries, total, err := db.GetHistory(ctx, HistoryFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if total != 3 || len(entries) != 2 || entries[0].ID != "new" || entries[1].ID != "mid" {
+		t.Fatalf("Expected newest 2 of 3 requests, got %d: %+v", total, entries)
+	}
+	if entries[0].QuestionTS != 1735905600 || entries[1].QuestionTS != 0 {
+		t.Errorf("Expected question timestamp only where state was saved, got %d and %d", entries[0].QuestionTS, entries[1].QuestionTS)
+	}
+
+	entries, _, err = db.GetHistory(ctx, HistoryFilter{Limit: 2, Offset: 2})
+	if err != nil || len(entries) != 1 || entries[0].ID != "old" {
+		t.Errorf("Expected the second page to hold the oldest request, got %+v (%v)", entries, err)
+	}
+
+	filters := map[string]HistoryFilter{
+		"winner": {Winner: "grok"},
+		"model":  {Model: "claude-sonnet-4-5"},
+		"dates":  {From: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	expected := map[string][]string{
+		"winner": {"new", "old"},
+		"model":  {"mid"},
+		"dates":  {"mid"},
+	}
+	for name, f := range filters {
+		entries, total, err := db.GetHistory(ctx, f)
+		if err != nil {
+			t.Fatalf("Failed to get %s history: %v", name, err)
+		}
+		var ids []string
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+		}
+		if total != len(expected[name]) || strings.Join(ids, ",") != strings.Join(expected[name], ",") {
+			t.Errorf("Expected %s filter to match %v, got %v (total %d)", name, expected[name], ids, total)
+		}
+	}
+}
+
+func TestGetCallAverages(t *testing.T) {
+	dbPath := "test_averages.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.SaveRequest(ctx, Request{ID: "avg", Question: "Q", NumRounds: 3, NumModels: 1}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	rounds := []ModelRound{
+		{RequestID: "avg", ModelID: "grok", ModelName: "grok-4", Round: 1, DurationMs: 1000, TokensIn: 100, TokensOut: 400},
+		{RequestID: "avg", ModelID: "grok", ModelName: "grok-4", Round: 2, DurationMs: 3000, TokensIn: 900, TokensOut: 600},
+		{RequestID: "avg", ModelID: "grok", ModelName: "grok-4", Round: 3, DurationMs: 9000, Error: "timeout"},
+	}
+	for _, mr := range rounds {
+		if err := db.SaveModelRound(ctx, mr); err != nil {
+			t.Fatalf("Failed to save model round: %v", err)
+		}
+	}
+
+	averages, err := db.GetCallAverages(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get call averages: %v", err)
+	}
+	got := averages["grok-4"]
+	if got.Calls != 2 || got.AvgTokensIn != 500 || got.AvgTokensOut != 500 || got.AvgDurationMs != 2000 {
+		t.Errorf("Expected failed calls to be left out of the averages, got %+v", got)
+	}
+}
+
+func TestGetLatencyP95(t *testing.T) {
+	dbPath := "test_latency.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	for i := 1; i <= 20; i++ {
+		id := fmt.Sprintf("lat-%d", i)
+		if err := db.SaveRequest(ctx, Request{ID: id, Question: "Q", NumRounds: 1, NumModels: 2}); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+		rounds := []ModelRound{
+			{RequestID: id, ModelID: "grok", ModelName: "grok-4", Round: 1, DurationMs: int64(i) * 1000},
+			{RequestID: id, ModelID: "gpt", ModelName: "gpt-5", Round: 1, DurationMs: 500},
+		}
+		if i == 20 {
+			rounds = append(rounds, ModelRound{RequestID: id, ModelID: "claude", ModelName: "claude-4", Round: 1, DurationMs: 100})
+			rounds[0].Error, rounds[0].DurationMs = "timeout", 120000
+		}
+		for _, mr := range rounds {
+			if err := db.SaveModelRound(ctx, mr); err != nil {
+				t.Fatalf("Failed to save model round: %v", err)
+			}
+		}
+	}
+
+	p95, err := db.GetLatencyP95(ctx, 100, 5)
+	if err != nil {
+		t.Fatalf("Failed to get latencies: %v", err)
+	}
+	if got := p95["grok-4"]; got != 19*time.Second {
+		t.Errorf("Expected failed calls left out of grok-4's p95 of 19s, got %v", got)
+	}
+	if got := p95["gpt-5"]; got != 500*time.Millisecond {
+		t.Errorf("Expected gpt-5's p95 of 500ms, got %v", got)
+	}
+	if _, ok := p95["claude-4"]; ok {
+		t.Error("Expected a variant with too few calls to be left out")
+	}
+}
+
+func TestGetCachedRequestID(t *testing.T) {
+	dbPath := "test_cache.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	requests := []Request{
+		{ID: "ok", Question: "Q", NumRounds: 3, NumModels: 2, CacheKey: "key"},
+		{ID: "failed", Question: "Q", NumRounds: 3, NumModels: 2, CacheKey: "key", ErrorCount: 1},
+		{ID: "other", Question: "Q", NumRounds: 2, NumModels: 2, CacheKey: "other-key"},
+	}
+	for _, req := range requests {
+		if err := db.SaveRequest(ctx, req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+
+	since := time.Now().Add(-time.Hour)
+	if id, err := db.GetCachedRequestID(ctx, "key", since); err != nil || id != "ok" {
+		t.Errorf("Expected the error-free request, got %q (%v)", id, err)
+	}
+	if id, err := db.GetCachedRequestID(ctx, "key", time.Now().Add(time.Hour)); err != nil || id != "" {
+		t.Errorf("Expected no request newer than the cutoff, got %q (%v)", id, err)
+	}
+	if id, err := db.GetCachedRequestID(ctx, "missing", since); err != nil || id != "" {
+		t.Errorf("Expected no request for an unknown key, got %q (%v)", id, err)
+	}
+}
+
+func TestDeleteRequest(t *testing.T) {
+	dbPath := "test_delete.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.SaveRequest(ctx, Request{ID: "req", Question: "Secret?", NumRounds: 1, NumModels: 2, WinnerModel: "grok", TotalCost: 0.5, CacheKey: "key", SynthesizedAnswer: "Secret merge", Synthesizer: "gpt-5", Provenance: `[{"paragraph":"Secret merge","sources":["grok-4"]}]`, ClientRef: "ticket-42"}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	if err := db.SaveModelRound(ctx, ModelRound{RequestID: "req", ModelID: "grok", ModelName: "grok-4", Round: 1, TokensIn: 10, Answer: "Secret answer", Discussion: `{"gpt":"psst"}`}); err != nil {
+		t.Fatalf("Failed to save model round: %v", err)
+	}
+	if err := db.SaveRanking(ctx, Ranking{RequestID: "req", RankerModel: "gpt-5", RankedModels: `["grok-4"]`, Justifications: `{"grok-4":"Secret reason"}`}); err != nil {
+		t.Fatalf("Failed to save ranking: %v", err)
+	}
+	if err := db.SaveRequestState(ctx, RequestState{RequestID: "req", Question: "Secret?", NumRounds: 1, QuestionTS: 1735905600, Replies: `{"grok":{}}`, Options: `{"tag":"ops","pricing":{"multiplier":0.5},"sub_questions":["Secret part?"],"answer_schema":{"description":"Secret schema"},"client_ref":"ticket-42"}`, Status: StateComplete}); err != nil {
+		t.Fatalf("Failed to save request state: %v", err)
+	}
+	if err := db.SaveEvent(ctx, Event{RequestID: "req", Type: "winner", Payload: []byte(`{"answer":"Secret answer"}`)}); err != nil {
+		t.Fatalf("Failed to save event: %v", err)
+	}
+	if err := db.SaveVerification(ctx, Verification{RequestID: "req", Verifier: "gpt", VerifierName: "gpt-5", AnswerModel: "grok", Claims: []types.Claim{{Text: "Secret claim", Status: "supported"}}, TokensIn: 5}); err != nil {
+		t.Fatalf("Failed to save verification: %v", err)
+	}
+
+	if _, err := db.DeleteRequest(ctx, "missing", false); err != ErrRequestNotFound {
+		t.Errorf("Expected ErrRequestNotFound, got %v", err)
+	}
+
+	deleted, err := db.DeleteRequest(ctx, "req", false)
+	if err != nil {
+		t.Fatalf("Failed to delete request: %v", err)
+	}
+	if deleted.Question != "Secret?" || deleted.QuestionTS != 1735905600 {
+		t.Errorf("Expected the question and its timestamp, got %+v", deleted)
+	}
+	if req, err := db.GetRequest(ctx, "req"); err != nil || req != nil {
+		t.Errorf("Expected a deleted request to be hidden, got %+v (%v)", req, err)
+	}
+	if _, total, err := db.GetHistory(ctx, HistoryFilter{}); err != nil || total != 0 {
+		t.Errorf("Expected an empty history, got %d (%v)", total, err)
+	}
+	if id, err := db.GetCachedRequestID(ctx, "key", time.Time{}); err != nil || id != "" {
+		t.Errorf("Expected a deleted request not to be served from the cache, got %q (%v)", id, err)
+	}
+	if events, err := db.GetEvents(ctx, "req", 0); err != nil || len(events) != 0 {
+		t.Errorf("Expected no events for a deleted request, got %d (%v)", len(events), err)
+	}
+
+	// Redacting a soft-deleted request scrubs its text but keeps its numbers
+	if _, err := db.DeleteRequest(ctx, "req", true); err != nil {
+		t.Fatalf("Failed to redact request: %v", err)
+	}
+	var question, synthesized, provenance, clientRef string
+	var cost float64
+	if err := db.conn.QueryRowContext(ctx, "SELECT question, synthesized_answer, synthesis_provenance, client_ref, total_cost FROM requests WHERE id = 'req'").Scan(&question, &synthesized, &provenance, &clientRef, &cost); err != nil {
+		t.Fatalf("Failed to read request: %v", err)
+	}
+	if question != RedactedText || synthesized != "" || provenance != "" || clientRef != "" || cost != 0.5 {
+		t.Errorf("Expected a redacted question, synthesized answer, provenance and client reference and the cost kept, got %q, %q, %q, %q and %f", question, synthesized, provenance, clientRef, cost)
+	}
+	var answer, discussion, justifications string
+	var tokensIn int64
+	if err := db.conn.QueryRowContext(ctx, "SELECT answer, discussion, tokens_in FROM model_rounds WHERE request_id = 'req'").Scan(&answer, &discussion, &tokensIn); err != nil {
+		t.Fatalf("Failed to read model round: %v", err)
+	}
+	if err := db.conn.QueryRowContext(ctx, "SELECT justifications FROM rankings WHERE request_id = 'req'").Scan(&justifications); err != nil {
+		t.Fatalf("Failed to read ranking: %v", err)
+	}
+	if answer != "" || discussion != "" || justifications != "" || tokensIn != 10 {
+		t.Errorf("Expected text scrubbed and tokens kept, got %q, %q, %q, %d", answer, discussion, justifications, tokensIn)
+	}
+	var options string
+	if err := db.conn.QueryRowContext(ctx, "SELECT options FROM request_state WHERE request_id = 'req'").Scan(&options); err != nil {
+		t.Fatalf("Failed to read request state: %v", err)
+	}
+	if strings.Contains(options, "Secret") || strings.Contains(options, "ticket-42") || !strings.Contains(options, `"multiplier":0.5`) {
+		t.Errorf("Expected the options quoting the question scrubbed and the pricing kept, got %s", options)
+	}
+	var events int
+	if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM events WHERE request_id = 'req'").Scan(&events); err != nil || events != 0 {
+		t.Errorf("Expected the event log removed, got %d (%v)", events, err)
+	}
+	if verifications, err := db.GetVerifications(ctx, "req"); err != nil || len(verifications) != 1 || len(verifications[0].Claims) != 0 || verifications[0].TokensIn != 5 {
+		t.Errorf("Expected verified claims scrubbed and tokens kept, got %+v (%v)", verifications, err)
+	}
+}
+
+func TestAccessKeys(t *testing.T) {
+	dbPath := "test_access_keys.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if has, err := db.HasAccessKeys(ctx); err != nil || has {
+		t.Fatalf("Expected no access keys, got %v, %v", has, err)
+	}
+
+	id, err := db.CreateAccessKey(ctx, "alice", "hash-a", "fat_abc")
+	if err != nil {
+		t.Fatalf("Failed to create access key: %v", err)
+	}
+	if _, err := db.CreateAccessKey(ctx, "bob", "hash-a", "fat_abc"); err == nil {
+		t.Error("Expected a duplicate hash to be rejected")
+	}
+
+	key, err := db.UseAccessKey(ctx, "hash-a")
+	if err != nil || key == nil || key.User != "alice" || key.LastUsedAt == nil {
+		t.Fatalf("Expected alice's key, got %+v, %v", key, err)
+	}
+	if key, err := db.UseAccessKey(ctx, "hash-b"); err != nil || key != nil {
+		t.Errorf("Expected no key for an unknown hash, got %+v, %v", key, err)
+	}
+
+	if err := db.RevokeAccessKey(ctx, id); err != nil {
+		t.Fatalf("Failed to revoke access key: %v", err)
+	}
+	if err := db.RevokeAccessKey(ctx, id); err != ErrAccessKeyNotFound {
+		t.Errorf("Expected revoking twice to fail, got %v", err)
+	}
+	if key, err := db.UseAccessKey(ctx, "hash-a"); err != nil || key != nil {
+		t.Errorf("Expected a revoked key to be refused, got %+v, %v", key, err)
+	}
+	if has, err := db.HasAccessKeys(ctx); err != nil || has {
+		t.Errorf("Expected no valid access keys, got %v, %v", has, err)
+	}
+
+	keys, err := db.GetAccessKeys(ctx)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("Expected one stored key, got %d, %v", len(keys), err)
+	}
+	if keys[0].RevokedAt == nil || keys[0].LastUsedAt == nil || keys[0].Prefix != "fat_abc" {
+		t.Errorf("Expected the revoked, used key, got %+v", keys[0])
+	}
+}
+
+func TestGetCostsByPeriod(t *testing.T) {
+	dbPath := "test_costs.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	// 2025-01-05 is a Sunday, 2025-01-06 the Monday after
+	for i, created := range []string{"2025-01-05 23:00:00", "2025-01-06 01:00:00", "2025-02-01 12:00:00"} {
+		_, err := db.conn.ExecContext(ctx, `
+			INSERT INTO model_rounds (request_id, model_id, model_name, round, duration_ms, tokens_in, tokens_out, cost, created_at)
+			VALUES ('req', 'gpt', 'gpt-5', ?, 1, 100, 10, 0.5, ?)`, i+1, created)
+		if err != nil {
+			t.Fatalf("Failed to insert round: %v", err)
+		}
+	}
+	if _, err := db.conn.ExecContext(ctx, `
+		INSERT INTO rankings (request_id, ranker_model, ranked_models, duration_ms, tokens_in, tokens_out, cost, created_at)
+		VALUES ('req', 'gpt-5', '[]', 1, 20, 2, 0.25, '2025-01-06 02:00:00')`); err != nil {
+		t.Fatalf("Failed to insert ranking: %v", err)
+	}
+
+	weeks, err := db.GetCostsByPeriod(ctx, "week", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("GetCostsByPeriod failed: %v", err)
+	}
+	want := []CostRow{
+		{Period: "2024-12-30", ModelName: "gpt-5", Calls: 1, TokensIn: 100, TokensOut: 10, Cost: 0.5},
+		{Period: "2025-01-06", ModelName: "gpt-5", Calls: 2, TokensIn: 120, TokensOut: 12, Cost: 0.75},
+		{Period: "2025-01-27", ModelName: "gpt-5", Calls: 1, TokensIn: 100, TokensOut: 10, Cost: 0.5},
+	}
+	if len(weeks) != len(want) {
+		t.Fatalf("Expected %d weeks, got %+v", len(want), weeks)
+	}
+	for i := range want {
+		if weeks[i] != want[i] {
+			t.Errorf("Week %d: expected %+v, got %+v", i, want[i], weeks[i])
+		}
+	}
+
+	months, err := db.GetCostsByPeriod(ctx, "month", time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetCostsByPeriod failed: %v", err)
+	}
+	if len(months) != 1 || months[0].Period != "2025-01-01" || months[0].Calls != 2 {
+		t.Errorf("Expected only January's calls from the 6th, got %+v", months)
+	}
+
+	if _, err := db.GetCostsByPeriod(ctx, "year", time.Time{}, time.Time{}); err == nil {
+		t.Error("Expected an unknown granularity to fail")
+	}
+}
+
+func TestStoreCostBreakdown(t *testing.T) {
+	dbPath := "test_cost_breakdown.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.SaveRequest(ctx, Request{ID: "req", Question: "Q?", NumRounds: 2, NumModels: 2}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	for _, mr := range []ModelRound{
+		{RequestID: "req", ModelID: "gpt", ModelName: "gpt-5", Round: 1, Cost: 0.5},
+		{RequestID: "req", ModelID: "gpt", ModelName: "gpt-5", Round: 2, Cost: 0.25},
+		{RequestID: "req", ModelID: "grok", ModelName: "grok-4", Round: 1, Cost: 0.125},
+	} {
+		if err := db.SaveModelRound(ctx, mr); err != nil {
+			t.Fatalf("Failed to save model round: %v", err)
+		}
+	}
+	// grok-4 ranks as a participant, claude as an outside judge
+	for _, r := range []Ranking{
+		{RequestID: "req", RankerModel: "grok-4", RankedModels: "[]", Cost: 0.0625},
+		{RequestID: "req", RankerModel: "claude-opus-4-6", RankedModels: "[]", Cost: 1},
+	} {
+		if err := db.SaveRanking(ctx, r); err != nil {
+			t.Fatalf("Failed to save ranking: %v", err)
+		}
+	}
+
+	b, err := db.StoreCostBreakdown(ctx, "req")
+	if err != nil {
+		t.Fatalf("StoreCostBreakdown failed: %v", err)
+	}
+	if b.Rounds != 0.875 || b.Ranking != 1.0625 || b.Total != 1.9375 {
+		t.Errorf("Unexpected phase costs %+v", b)
+	}
+	want := []ModelCost{
+		{Model: "claude-opus-4-6", Ranking: 1, Total: 1},
+		{Model: "gpt-5", Rounds: 0.75, Total: 0.75},
+		{Model: "grok-4", Rounds: 0.125, Ranking: 0.0625, Total: 0.1875},
+	}
+	if len(b.Models) != len(want) {
+		t.Fatalf("Expected %d models, got %+v", len(want), b.Models)
+	}
+	for i := range want {
+		if b.Models[i] != want[i] {
+			t.Errorf("Model %d: expected %+v, got %+v", i, want[i], b.Models[i])
+		}
+	}
+
+	var stored string
+	if err := db.conn.QueryRowContext(ctx, "SELECT cost_breakdown FROM requests WHERE id = 'req'").Scan(&stored); err != nil {
+		t.Fatalf("Failed to read cost breakdown: %v", err)
+	}
+	if !strings.Contains(stored, `"ranking":1.0625`) || !strings.Contains(stored, `"model":"claude-opus-4-6"`) {
+		t.Errorf("Expected the breakdown stored on the request, got %s", stored)
+	}
+}
+
+func TestSetHumanWinner(t *testing.T) {
+	dbPath := "test_human_winner.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.SaveRequest(ctx, Request{ID: "human", Question: "Q?", WinnerModel: "grok", Strategy: "human", AwaitingHuman: true}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	if err := db.SaveRequest(ctx, Request{ID: "borda", Question: "Q?", WinnerModel: "grok", Strategy: "borda"}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	for _, model := range []string{"grok", "gpt"} {
+		if err := db.SaveModelRound(ctx, ModelRound{RequestID: "human", ModelID: model, ModelName: model, Round: 1, Answer: "A"}); err != nil {
+			t.Fatalf("Failed to save model round: %v", err)
+		}
+	}
+
+	if err := db.SetHumanWinner(ctx, "missing", "gpt"); err != ErrRequestNotFound {
+		t.Errorf("Expected ErrRequestNotFound, got %v", err)
+	}
+	if err := db.SetHumanWinner(ctx, "borda", "gpt"); err != ErrNotAwaitingHuman {
+		t.Errorf("Expected ErrNotAwaitingHuman for a request the judges decided, got %v", err)
+	}
+	if err := db.SetHumanWinner(ctx, "human", "claude"); err != ErrNotParticipant {
+		t.Errorf("Expected ErrNotParticipant, got %v", err)
+	}
+
+	if err := db.SetHumanWinner(ctx, "human", "gpt"); err != nil {
+		t.Fatalf("Failed to set winner: %v", err)
+	}
+	req, err := db.GetRequest(ctx, "human")
+	if err != nil || req == nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if req.WinnerModel != "gpt" || req.AwaitingHuman || req.Strategy != "human" {
+		t.Errorf("Expected gpt picked and the request no longer awaiting, got %+v", req)
+	}
+	if err := db.SetHumanWinner(ctx, "human", "grok"); err != ErrNotAwaitingHuman {
+		t.Errorf("Expected a winner to be picked only once, got %v", err)
+	}
+}
+
+func TestSynthesizedAnswer(t *testing.T) {
+	dbPath := "test_synthesis.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.SaveRequest(ctx, Request{ID: "merged", Question: "Q?", WinnerModel: "grok", SynthesizedAnswer: "Paris, since 987", Synthesizer: "gpt-5", Provenance: `[{"paragraph":"Paris, since 987","sources":["grok-4"]}]`}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	if err := db.SaveRequest(ctx, Request{ID: "plain", Question: "Q?", WinnerModel: "grok"}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	req, err := db.GetRequest(ctx, "merged")
+	if err != nil || req == nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if req.SynthesizedAnswer != "Paris, since 987" || req.Synthesizer != "gpt-5" {
+		t.Errorf("Expected the synthesized answer stored, got %+v", req)
+	}
+	if req.Provenance != `[{"paragraph":"Paris, since 987","sources":["grok-4"]}]` {
+		t.Errorf("Expected the provenance stored, got %q", req.Provenance)
+	}
+
+	req, err = db.GetRequest(ctx, "plain")
+	if err != nil || req == nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if req.SynthesizedAnswer != "" || req.Synthesizer != "" || req.Provenance != "" {
+		t.Errorf("Expected no synthesized answer, got %+v", req)
+	}
+}
+
+func TestClientRef(t *testing.T) {
+	dbPath := "test_client_ref.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.SaveRequest(ctx, Request{ID: "req", Question: "Q?", WinnerModel: "grok", ClientRef: "tab-3/submission-7"}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	req, err := db.GetRequest(ctx, "req")
+	if err != nil || req == nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if req.ClientRef != "tab-3/submission-7" {
+		t.Errorf("Expected the client reference stored, got %q", req.ClientRef)
+	}
+}
+
+func TestVerifications(t *testing.T) {
+	dbPath := "test_verifications.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	claims := []types.Claim{
+		{Text: "Paris is the capital", Status: "supported"},
+		{Text: "It was founded in 1066", Status: "unsupported", Note: "Far older"},
+	}
+	if err := db.SaveVerification(ctx, Verification{RequestID: "req", Verifier: "gpt", VerifierName: "gpt-5", AnswerModel: "grok", Claims: claims, TokensIn: 100, TokensOut: 20, Cost: 0.01}); err != nil {
+		t.Fatalf("Failed to save verification: %v", err)
+	}
+	if err := db.SaveVerification(ctx, Verification{RequestID: "req", Verifier: "claude", VerifierName: "claude-sonnet-4-6", AnswerModel: "grok", Error: "timeout"}); err != nil {
+		t.Fatalf("Failed to save verification: %v", err)
+	}
+	// Checking again replaces the earlier check
+	if err := db.SaveVerification(ctx, Verification{RequestID: "req", Verifier: "gpt", VerifierName: "gpt-5", AnswerModel: "grok", Claims: claims[:1]}); err != nil {
+		t.Fatalf("Failed to save verification: %v", err)
+	}
+
+	verifications, err := db.GetVerifications(ctx, "req")
+	if err != nil {
+		t.Fatalf("Failed to get verifications: %v", err)
+	}
+	if len(verifications) != 2 || verifications[0].Verifier != "claude" || verifications[1].Verifier != "gpt" {
+		t.Fatalf("Expected two verifications by verifier, got %+v", verifications)
+	}
+	if v := verifications[0]; v.Error != "timeout" || v.Claims == nil || len(v.Claims) != 0 {
+		t.Errorf("Expected a failed check without claims, got %+v", v)
+	}
+	if v := verifications[1]; len(v.Claims) != 1 || v.Claims[0] != claims[0] || v.TokensIn != 0 {
+		t.Errorf("Expected the replaced check, got %+v", v)
+	}
+
+	if verifications, err := db.GetVerifications(ctx, "other"); err != nil || len(verifications) != 0 {
+		t.Errorf("Expected no verifications for another request, got %+v (%v)", verifications, err)
+	}
+}
+
+func TestGetVerdict(t *testing.T) {
+	dbPath := "test_verdict.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if verdict, err := db.GetVerdict(ctx, "req"); err != nil || verdict != nil {
+		t.Fatalf("Expected no verdict before the meta judge gave one, got %+v, %v", verdict, err)
+	}
+
+	if err := db.SaveRanking(ctx, Ranking{RequestID: "req", RankerModel: "gpt-5-mini", RankedModels: `["grok-4","gpt-5"]`, Cost: 0.01}); err != nil {
+		t.Fatalf("Failed to save ranking: %v", err)
+	}
+	err = db.SaveRanking(ctx, Ranking{
+		RequestID:    "req",
+		RankerModel:  "claude-opus-4-6",
+		RankedModels: `["gpt-5","grok-4"]`,
+		Meta:         true,
+		Verdict:      "The jury missed gpt-5's proof.",
+		Cost:         0.1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to save verdict: %v", err)
+	}
+
+	rankings, err := db.GetRequestRankings(ctx, "req")
+	if err != nil {
+		t.Fatalf("Failed to get rankings: %v", err)
+	}
+	if len(rankings) != 1 || rankings[0].RankerModel != "gpt-5-mini" {
+		t.Errorf("Expected only the judge's ranking, got %+v", rankings)
+	}
+
+	verdict, err := db.GetVerdict(ctx, "req")
+	if err != nil || verdict == nil {
+		t.Fatalf("Failed to get verdict: %v", err)
+	}
+	if verdict.RankerModel != "claude-opus-4-6" || !verdict.Meta || verdict.Verdict != "The jury missed gpt-5's proof." {
+		t.Errorf("Unexpected verdict %+v", verdict)
+	}
+}
+
+func TestGetJustifications(t *testing.T) {
+	dbPath := "test_justifications.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, r := range []Ranking{
+		{RequestID: "req", RankerModel: "gpt-5", RankedModels: `["grok-4","claude-opus-4-6"]`, Justifications: `{"grok-4":"Cites sources.","claude-opus-4-6":"Too vague."}`},
+		{RequestID: "req", RankerModel: "claude-opus-4-6", RankedModels: `["claude-opus-4-6","grok-4"]`},
+		{RequestID: "req", RankerModel: "gemini-2.5-pro", RankedModels: `["claude-opus-4-6","grok-4"]`, Justifications: `{"grok-4":"Wrong."}`},
+		{RequestID: "req", RankerModel: "gpt-5-pro", RankedModels: `["grok-4"]`, Justifications: `{"grok-4":"Overruled."}`, Meta: true},
+		{RequestID: "other", RankerModel: "gpt-5", RankedModels: `["grok-4"]`, Justifications: `{"grok-4":"Fine."}`},
+	} {
+		if err := db.SaveRanking(ctx, r); err != nil {
+			t.Fatalf("Failed to save ranking: %v", err)
+		}
+	}
+
+	justifications, err := db.GetJustifications(ctx, "req")
+	if err != nil {
+		t.Fatalf("Failed to get justifications: %v", err)
+	}
+	want := []Justification{
+		{Judge: "gemini-2.5-pro", Model: "grok-4", Place: 2, Of: 2, Reason: "Wrong."},
+		{Judge: "gpt-5", Model: "grok-4", Place: 1, Of: 2, Reason: "Cites sources."},
+		{Judge: "gpt-5", Model: "claude-opus-4-6", Place: 2, Of: 2, Reason: "Too vague."},
+	}
+	if !reflect.DeepEqual(justifications, want) {
+		t.Errorf("Expected %+v, got %+v", want, justifications)
+	}
+}
+
+func TestModelStatsHistory(t *testing.T) {
+	dbPath := "test_stats_history.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	day1 := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	if err := db.UpdateModelStats(ctx, "grok", "grok-4", true, 100, 50, 0.01, 1000); err != nil {
+		t.Fatalf("Failed to update model stats: %v", err)
+	}
+	if err := db.SnapshotModelStats(ctx, day1); err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+	if err := db.UpdateModelStats(ctx, "grok", "grok-4", false, 100, 50, 0.01, 1000); err != nil {
+		t.Fatalf("Failed to update model stats: %v", err)
+	}
+	// A later snapshot on the same day replaces the earlier one
+	if err := db.SnapshotModelStats(ctx, day1.Add(12*time.Hour)); err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+	if err := db.UpdateModelStats(ctx, "gpt", "gpt-5", true, 100, 50, 0.02, 1000); err != nil {
+		t.Fatalf("Failed to update model stats: %v", err)
+	}
+	if err := db.SnapshotModelStats(ctx, day2); err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+
+	history, err := db.GetModelStatsHistory(ctx, day1)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected grok on both days and gpt on the second, got %+v", history)
+	}
+	if h := history[0]; h.Day != "2025-03-01" || h.ModelID != "grok" || h.TotalRequests != 2 || h.TotalWins != 1 {
+		t.Errorf("Expected the day's last snapshot of grok first, got %+v", h)
+	}
+	if h := history[1]; h.Day != "2025-03-02" || h.ModelID != "gpt" || h.TotalCost != 0.02 {
+		t.Errorf("Expected gpt on the second day, got %+v", h)
+	}
+
+	recent, err := db.GetModelStatsHistory(ctx, day2)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(recent) != 2 {
+		t.Errorf("Expected only the second day's snapshots, got %+v", recent)
+	}
+}
+
+func TestTournaments(t *testing.T) {
+	dbPath := "test_tournaments.db"
+	defer os.Remove(dbPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	db, err := New(dbPath, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if tournament, err := db.GetTournament(ctx, "missing"); err != nil || tournament != nil {
+		t.Fatalf("Expected no tournament, got %+v, %v", tournament, err)
+	}
+
+	if err := db.SaveTournament(ctx, Tournament{ID: "t1", Question: "Q?", Jury: []string{"claude-opus-4-6"}, QuestionTS: 1700000000}); err != nil {
+		t.Fatalf("Failed to save tournament: %v", err)
+	}
+	for _, e := range []TournamentEntry{
+		{TournamentID: "t1", ModelName: "gpt-5-mini", ModelID: "gpt", Seed: 2, Answer: "B", Cost: 0.01},
+		{TournamentID: "t1", ModelName: "gpt-5", ModelID: "gpt", Seed: 1, Answer: "A", Cost: 0.02},
+	} {
+		if err := db.SaveTournamentEntry(ctx, e); err != nil {
+			t.Fatalf("Failed to save tournament entry: %v", err)
+		}
+	}
+	votes := []MatchVote{{Juror: "claude-opus-4-6", Vote: "gpt-5-mini", Reason: "Shorter"}}
+	if err := db.SaveTournamentMatch(ctx, TournamentMatch{TournamentID: "t1", Round: 1, Slot: 0, ModelA: "gpt-5", ModelB: "gpt-5-mini", Winner: "gpt-5-mini", Votes: votes, Cost: 0.005}); err != nil {
+		t.Fatalf("Failed to save tournament match: %v", err)
+	}
+	// Finishing it records the winner, keeping the question
+	if err := db.SaveTournament(ctx, Tournament{ID: "t1", Question: "ignored", Winner: "gpt-5-mini", TotalCost: 0.035}); err != nil {
+		t.Fatalf("Failed to save tournament: %v", err)
+	}
+
+	tournament, err := db.GetTournament(ctx, "t1")
+	if err != nil || tournament == nil {
+		t.Fatalf("Failed to get tournament: %v", err)
+	}
+	if tournament.Question != "Q?" || tournament.Winner != "gpt-5-mini" || tournament.TotalCost != 0.035 || len(tournament.Jury) != 1 {
+		t.Errorf("Expected the finished tournament, got %+v", tournament)
+	}
+	if len(tournament.Entries) != 2 || tournament.Entries[0].ModelName != "gpt-5" {
+		t.Errorf("Expected entries by seed, got %+v", tournament.Entries)
+	}
+	if len(tournament.Matches) != 1 || tournament.Matches[0].Votes[0].Vote != "gpt-5-mini" {
+		t.Errorf("Expected the match with its votes, got %+v", tournament.Matches)
+	}
 }
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// RedactedText replaces the question of a redacted request
+const RedactedText = "[redacted]"
+
+// ErrRequestNotFound is returned when deleting a request that was never stored
+var ErrRequestNotFound = errors.New("request not found")
+
+// redactedOptions drops the run options that quote the question, keeping the pricing costs are recomputed with
+const redactedOptions = `CASE WHEN json_valid(options)
+	THEN json_remove(options, '$.sub_questions', '$.answer_schema', '$.cache_key', '$.client_ref')
+	ELSE '{}' END`
+
+// DeletedRequest is what's needed to clean up a deleted request's files
+type DeletedRequest struct {
+	Question   string // As it was stored before this deletion, which names the request's exports
+	QuestionTS int64  // When the question was asked, 0 if unknown
+}
+
+// DeleteRequest hides a request from the history and exports, keeping its metrics in every aggregate
+// With redact, the question, client reference, answers, synthesized answer, discussion, notes, justifications, verdict, verified claims, synthesis provenance,
+// the run options quoting the question and the event log are scrubbed too.
+// Deleting a request again is allowed, so a soft-deleted one can still be redacted.
+func (db *DB) DeleteRequest(ctx context.Context, id string, redact bool) (*DeletedRequest, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var d DeletedRequest
+	err = tx.QueryRowContext(ctx, `
+		SELECT r.question, COALESCE(s.question_ts, 0)
+		FROM requests r
+		LEFT JOIN request_state s ON s.request_id = r.id
+		WHERE r.id = ?
+	`, id).Scan(&d.Question, &d.QuestionTS)
+	if err == sql.ErrNoRows {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	statements := []string{
+		"UPDATE requests SET deleted_at = COALESCE(deleted_at, CURRENT_TIMESTAMP) WHERE id = ?",
+	}
+	if redact {
+		statements = append(statements,
+			"UPDATE requests SET question = '"+RedactedText+"', cache_key = '', synthesized_answer = '', synthesis_provenance = '', client_ref = '' WHERE id = ?",
+			"UPDATE model_rounds SET answer = '', rationale = '', discussion = '', private_notes = '' WHERE request_id = ?",
+			"UPDATE rankings SET justifications = '', verdict = '' WHERE request_id = ?",
+			"UPDATE verifications SET claims = '[]' WHERE request_id = ?",
+			"UPDATE request_state SET question = '"+RedactedText+"', replies = '{}', discussion = '{}', private_notes = '{}', options = "+redactedOptions+" WHERE request_id = ?",
+			"DELETE FROM events WHERE request_id = ?",
+		)
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+			return nil, fmt.Errorf("failed to delete request: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit deletion: %w", err)
+	}
+	return &d, nil
+}
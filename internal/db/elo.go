@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EloRating is a model variant's rating and its pairwise record across requests
+type EloRating struct {
+	ModelID   string
+	ModelName string
+	Rating    float64
+	Games     int64 // Requests the variant was rated in
+	Wins      int64 // Pairwise outcomes against other participants
+	Losses    int64
+	Draws     int64
+	UpdatedAt time.Time
+}
+
+// GetEloRatings retrieves every rated model variant, highest rating first
+func (db *DB) GetEloRatings(ctx context.Context) ([]EloRating, error) {
+	query := `
+		SELECT model_id, model_name, rating, games, wins, losses, draws, updated_at
+		FROM model_elo
+		ORDER BY rating DESC, model_name
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query elo ratings: %w", err)
+	}
+	defer rows.Close()
+
+	var ratings []EloRating
+	for rows.Next() {
+		var r EloRating
+		if err := rows.Scan(&r.ModelID, &r.ModelName, &r.Rating, &r.Games, &r.Wins, &r.Losses, &r.Draws, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan elo rating: %w", err)
+		}
+		ratings = append(ratings, r)
+	}
+
+	return ratings, rows.Err()
+}
+
+// SaveEloRatings creates or replaces the given ratings in a single transaction
+// so a request's rating changes are applied all together or not at all
+func (db *DB) SaveEloRatings(ctx context.Context, ratings []EloRating) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO model_elo (model_name, model_id, rating, games, wins, losses, draws, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(model_name) DO UPDATE SET
+			model_id = excluded.model_id,
+			rating = excluded.rating,
+			games = excluded.games,
+			wins = excluded.wins,
+			losses = excluded.losses,
+			draws = excluded.draws,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	for _, r := range ratings {
+		if _, err := tx.ExecContext(ctx, query, r.ModelName, r.ModelID, r.Rating, r.Games, r.Wins, r.Losses, r.Draws); err != nil {
+			return fmt.Errorf("failed to save elo rating for %s: %w", r.ModelName, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit elo ratings: %w", err)
+	}
+
+	return nil
+}
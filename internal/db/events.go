@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Event represents a single orchestrator event in the append-only event log
+type Event struct {
+	ID        int64
+	RequestID string
+	Type      string
+	Payload   json.RawMessage // Broadcast message exactly as sent to clients
+	CreatedAt time.Time       // To the millisecond, so replays can pace events a second apart; now when saved without one
+}
+
+// SaveEvent appends an event to the event log
+func (db *DB) SaveEvent(ctx context.Context, e Event) error {
+	query := `
+		INSERT INTO events (request_id, type, payload, created_ms)
+		VALUES (?, ?, ?, ?)
+	`
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	_, err := db.conn.ExecContext(ctx, query, e.RequestID, e.Type, string(e.Payload), e.CreatedAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save event: %w", err)
+	}
+
+	return nil
+}
+
+// GetEvents retrieves events for a request in insertion order
+// Only events with ID greater than afterID are returned, so callers can poll for new events; a deleted request has none
+func (db *DB) GetEvents(ctx context.Context, requestID string, afterID int64) ([]Event, error) {
+	query := `
+		SELECT id, request_id, type, payload, created_at, created_ms
+		FROM events
+		WHERE request_id = ? AND id > ?
+		  AND NOT EXISTS (SELECT 1 FROM requests r WHERE r.id = events.request_id AND r.deleted_at IS NOT NULL)
+		ORDER BY id
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, requestID, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var payload string
+		var createdMs int64
+		if err := rows.Scan(&e.ID, &e.RequestID, &e.Type, &payload, &e.CreatedAt, &createdMs); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		e.Payload = json.RawMessage(payload)
+		// Events logged before created_ms was added only have created_at, to the second
+		if createdMs > 0 {
+			e.CreatedAt = time.UnixMilli(createdMs).UTC()
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HistoryFilter narrows the request history; zero values don't filter
+type HistoryFilter struct {
+	From   time.Time // Requests created at or after
+	To     time.Time // Requests created before
+	Model  string    // Model ID or variant name that took part in the request
+	Winner string    // Model ID of the winner
+	Limit  int       // Page size, 0 for every matching request
+	Offset int
+}
+
+// HistoryEntry is a completed request as listed in the history
+type HistoryEntry struct {
+	Request
+	QuestionTS int64 // When the question was asked (names its HTML export), 0 if unknown
+}
+
+// GetHistory retrieves the requests matching the filter, newest first, with the total number of matches
+// Deleted requests are never listed.
+func (db *DB) GetHistory(ctx context.Context, f HistoryFilter) ([]HistoryEntry, int, error) {
+	where := []string{"r.deleted_at IS NULL"}
+	var args []any
+	if !f.From.IsZero() {
+		where = append(where, "r.created_at >= ?")
+		args = append(args, f.From.UTC().Format(time.DateTime))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "r.created_at < ?")
+		args = append(args, f.To.UTC().Format(time.DateTime))
+	}
+	if f.Model != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM model_rounds mr WHERE mr.request_id = r.id AND (mr.model_id = ? OR mr.model_name = ?))")
+		args = append(args, f.Model, f.Model)
+	}
+	if f.Winner != "" {
+		where = append(where, "r.winner_model = ?")
+		args = append(args, f.Winner)
+	}
+
+	conditions := "WHERE " + strings.Join(where, " AND ")
+
+	var total int
+	if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM requests r "+conditions, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count history: %w", err)
+	}
+
+	query := `
+		SELECT r.id, r.question, r.num_rounds, r.num_models, r.winner_model,
+			   r.total_duration_ms, r.total_tokens_in, r.total_tokens_out,
+			   r.total_cost, r.error_count, r.tag, r.difficulty, r.parent_request_id, r.created_at,
+			   COALESCE(s.question_ts, 0)
+		FROM requests r
+		LEFT JOIN request_state s ON s.request_id = r.id
+		` + conditions + `
+		ORDER BY r.created_at DESC, r.id DESC
+	`
+	if f.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		if err := rows.Scan(
+			&e.ID, &e.Question, &e.NumRounds, &e.NumModels, &e.WinnerModel,
+			&e.TotalDurationMs, &e.TotalTokensIn, &e.TotalTokensOut,
+			&e.TotalCost, &e.ErrorCount, &e.Tag, &e.Difficulty, &e.ParentRequestID, &e.CreatedAt,
+			&e.QuestionTS,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan history entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, total, rows.Err()
+}
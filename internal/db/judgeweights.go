@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// JudgeWeight is how much a judge's ranking counts, from how well it has agreed with the other judges
+type JudgeWeight struct {
+	Judge     string  // Variant name of the judge
+	Ballots   int     // Rankings compared against the other judges' consensus
+	Agreement float64 // Mean Kendall tau correlation with the consensus, 1 always agreeing, 0 no better than random
+	Weight    float64 // Multiplier on the judge's Borda points
+	UpdatedAt time.Time
+}
+
+// SaveJudgeWeights replaces every stored judge weight with weights
+func (db *DB) SaveJudgeWeights(ctx context.Context, weights []JudgeWeight) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM judge_weights`); err != nil {
+		return fmt.Errorf("failed to clear judge weights: %w", err)
+	}
+
+	query := `
+		INSERT INTO judge_weights (judge, ballots, agreement, weight)
+		VALUES (?, ?, ?, ?)
+	`
+	for _, w := range weights {
+		if _, err := tx.ExecContext(ctx, query, w.Judge, w.Ballots, w.Agreement, w.Weight); err != nil {
+			return fmt.Errorf("failed to save judge weight: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetJudgeWeights retrieves every judge's weight, highest first
+func (db *DB) GetJudgeWeights(ctx context.Context) ([]JudgeWeight, error) {
+	query := `
+		SELECT judge, ballots, agreement, weight, updated_at
+		FROM judge_weights
+		ORDER BY weight DESC, judge
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query judge weights: %w", err)
+	}
+	defer rows.Close()
+
+	var weights []JudgeWeight
+	for rows.Next() {
+		var w JudgeWeight
+		if err := rows.Scan(&w.Judge, &w.Ballots, &w.Agreement, &w.Weight, &w.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan judge weight: %w", err)
+		}
+		weights = append(weights, w)
+	}
+
+	return weights, rows.Err()
+}
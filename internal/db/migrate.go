@@ -9,6 +9,19 @@ import (
 func (db *DB) MigrateConsolidateRounds(ctx context.Context) error {
 	db.logger.Info("starting database migration: consolidate rounds")
 
+	// Fresh databases are created with the consolidated schema already
+	var count int
+	err := db.conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='round_replies'").Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check table existence: %w", err)
+	}
+
+	if count == 0 {
+		db.logger.Info("round_replies table does not exist, skipping")
+		return nil
+	}
+
 	tx, err := db.conn.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -155,6 +168,253 @@ func (db *DB) RunMigrations(ctx context.Context) error {
 		db.logger.Info("migration completed", "new_version", 2)
 	}
 
+	if version < 3 {
+		db.logger.Info("running migration: add request tags and options")
+		if err := db.MigrateAddRequestTags(ctx); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 3); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 3)
+	}
+
+	if version < 4 {
+		db.logger.Info("running migration: add request difficulty")
+		if err := db.addColumnIfMissing(ctx, "requests", "difficulty", "REAL"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 4); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 4)
+	}
+
+	if version < 5 {
+		db.logger.Info("running migration: add reply transforms")
+		if err := db.addColumnIfMissing(ctx, "model_rounds", "transforms", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 5); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 5)
+	}
+
+	if version < 6 {
+		db.logger.Info("running migration: add follow-up requests")
+		if err := db.addColumnIfMissing(ctx, "requests", "parent_request_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 6); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 6)
+	}
+
+	if version < 7 {
+		db.logger.Info("running migration: add answer cache keys")
+		if err := db.MigrateAddCacheKeys(ctx); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 7); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 7)
+	}
+
+	if version < 8 {
+		db.logger.Info("running migration: add judge justifications")
+		if err := db.MigrateAddJustifications(ctx); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 8); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 8)
+	}
+
+	if version < 9 {
+		db.logger.Info("running migration: add final rankings")
+		if err := db.addColumnIfMissing(ctx, "requests", "final_ranking", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 9); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 9)
+	}
+
+	if version < 10 {
+		db.logger.Info("running migration: add request deletion")
+		if err := db.addColumnIfMissing(ctx, "requests", "deleted_at", "TIMESTAMP"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 10); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 10)
+	}
+
+	if version < 11 {
+		db.logger.Info("running migration: add cost breakdowns")
+		if err := db.addColumnIfMissing(ctx, "requests", "cost_breakdown", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 11); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 11)
+	}
+
+	if version < 12 {
+		db.logger.Info("running migration: add winner strategies")
+		if err := db.addColumnIfMissing(ctx, "requests", "strategy", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.addColumnIfMissing(ctx, "requests", "awaiting_human", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 12); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 12)
+	}
+
+	if version < 13 {
+		db.logger.Info("running migration: add meta judge verdicts")
+		if err := db.addColumnIfMissing(ctx, "rankings", "meta", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+		if err := db.addColumnIfMissing(ctx, "rankings", "verdict", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 13); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 13)
+	}
+
+	if version < 14 {
+		db.logger.Info("running migration: add synthesized answers")
+		if err := db.addColumnIfMissing(ctx, "requests", "synthesized_answer", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.addColumnIfMissing(ctx, "requests", "synthesizer", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 14); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 14)
+	}
+
+	if version < 15 {
+		db.logger.Info("running migration: add client references")
+		if err := db.addColumnIfMissing(ctx, "requests", "client_ref", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 15); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 15)
+	}
+
+	if version < 16 {
+		db.logger.Info("running migration: add millisecond event timestamps")
+		if err := db.addColumnIfMissing(ctx, "events", "created_ms", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 16); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 16)
+	}
+
+	if version < 17 {
+		db.logger.Info("running migration: add synthesis provenance")
+		if err := db.addColumnIfMissing(ctx, "requests", "synthesis_provenance", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 17); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 17)
+	}
+
+	if version < 18 {
+		db.logger.Info("running migration: rekey model stats by agent")
+		if err := db.MigrateRekeyModelStats(ctx); err != nil {
+			return err
+		}
+		if err := db.setSchemaVersion(ctx, 18); err != nil {
+			return err
+		}
+		db.logger.Info("migration completed", "new_version", 18)
+	}
+
+	return nil
+}
+
+// MigrateRekeyModelStats rebuilds model_stats per agent from the stored rounds
+// Variants competing in one run were briefly counted under their family's row; the rebuild splits them
+// between the variants that ran, as their rounds and Elo ratings already are. Databases without such
+// runs - whose rounds all go by a family ID - are left as they are.
+func (db *DB) MigrateRekeyModelStats(ctx context.Context) error {
+	var matchups int
+	err := db.conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM model_rounds WHERE model_id = model_name").Scan(&matchups)
+	if err != nil {
+		return fmt.Errorf("failed to count variant matchup rounds: %w", err)
+	}
+	if matchups == 0 {
+		db.logger.Info("no variant matchups, skipping")
+		return nil
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM model_stats"); err != nil {
+		return fmt.Errorf("failed to clear model stats: %w", err)
+	}
+
+	// Each finished request counts once per agent, with the average duration of its rounds, as it does at run time
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO model_stats (
+			model_id, model_name, total_requests, total_wins,
+			total_tokens_in, total_tokens_out, total_cost,
+			avg_response_time_ms, last_used, updated_at
+		)
+		SELECT per.model_id,
+			(SELECT l.model_name FROM model_rounds l WHERE l.model_id = per.model_id ORDER BY l.created_at DESC, l.id DESC LIMIT 1),
+			COUNT(*), SUM(per.won), SUM(per.tokens_in), SUM(per.tokens_out), SUM(per.cost),
+			CAST(AVG(per.avg_ms) AS INTEGER), MAX(per.last_used), CURRENT_TIMESTAMP
+		FROM (
+			SELECT mr.model_id,
+				COALESCE(MAX(r.winner_model = mr.model_id), 0) AS won,
+				SUM(mr.tokens_in) AS tokens_in,
+				SUM(mr.tokens_out) AS tokens_out,
+				SUM(COALESCE(mr.cost, 0)) AS cost,
+				AVG(mr.duration_ms) AS avg_ms,
+				MAX(mr.created_at) AS last_used
+			FROM model_rounds mr
+			JOIN requests r ON r.id = mr.request_id
+			GROUP BY mr.model_id, mr.request_id
+		) per
+		GROUP BY per.model_id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild model stats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	db.logger.Info("model stats rebuilt per agent", "matchup_rounds", matchups)
 	return nil
 }
 
@@ -183,3 +443,60 @@ func (db *DB) MigrateAddPrivateNotes(ctx context.Context) error {
 	db.logger.Info("added private_notes column to model_rounds")
 	return nil
 }
+
+// MigrateAddRequestTags adds the question set tag to requests and run options to request_state
+func (db *DB) MigrateAddRequestTags(ctx context.Context) error {
+	if err := db.addColumnIfMissing(ctx, "requests", "tag", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := db.addColumnIfMissing(ctx, "request_state", "options", "TEXT NOT NULL DEFAULT '{}'"); err != nil {
+		return err
+	}
+
+	if _, err := db.conn.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS idx_requests_tag ON requests(tag, created_at)"); err != nil {
+		return fmt.Errorf("failed to create tag index: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateAddCacheKeys adds the answer cache key to requests
+func (db *DB) MigrateAddCacheKeys(ctx context.Context) error {
+	if err := db.addColumnIfMissing(ctx, "requests", "cache_key", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	if _, err := db.conn.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS idx_requests_cache_key ON requests(cache_key, created_at)"); err != nil {
+		return fmt.Errorf("failed to create cache key index: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateAddJustifications adds the column holding a judge's reason for each placement to rankings
+func (db *DB) MigrateAddJustifications(ctx context.Context) error {
+	return db.addColumnIfMissing(ctx, "rankings", "justifications", "TEXT NOT NULL DEFAULT ''")
+}
+
+// addColumnIfMissing adds a column to a table unless it already exists
+func (db *DB) addColumnIfMissing(ctx context.Context, table, column, definition string) error {
+	var count int
+	err := db.conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check column existence: %w", err)
+	}
+
+	if count > 0 {
+		db.logger.Info("column already exists, skipping", "table", table, "column", column)
+		return nil
+	}
+
+	_, err = db.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add %s.%s column: %w", table, column, err)
+	}
+
+	db.logger.Info("added column", "table", table, "column", column)
+	return nil
+}
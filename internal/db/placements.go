@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// PlacementCount is how often a model finished in one place of the final ranking
+type PlacementCount struct {
+	ModelID string
+	Place   int // 1 for gold; tied models share a place
+	Count   int64
+}
+
+// GetPlacementCounts counts every model's places across the requests with a stored final ranking,
+// ordered by model and place
+func (db *DB) GetPlacementCounts(ctx context.Context) ([]PlacementCount, error) {
+	query := `
+		SELECT json_extract(p.value, '$.model') AS model_id,
+		       json_extract(p.value, '$.place') AS place,
+		       COUNT(*)
+		FROM requests r, json_each(r.final_ranking) p
+		WHERE r.final_ranking != ''
+		GROUP BY model_id, place
+		ORDER BY model_id, place
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query placements: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []PlacementCount
+	for rows.Next() {
+		var c PlacementCount
+		if err := rows.Scan(&c.ModelID, &c.Place, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan placement: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
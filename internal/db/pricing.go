@@ -0,0 +1,338 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// PriceSnapshot is a model variant's list rate from EffectiveFrom until the next snapshot
+type PriceSnapshot struct {
+	ModelID       string
+	ModelName     string
+	RateIn        float64 // USD per million input tokens
+	RateOut       float64 // USD per million output tokens
+	EffectiveFrom time.Time
+}
+
+// PriceHistory holds every variant's snapshots, oldest first, for looking up past rates
+type PriceHistory map[string][]PriceSnapshot
+
+// At returns the rate of a variant effective at a point in time
+// Times before the first snapshot get the earliest known rate; false if the variant has no history.
+func (h PriceHistory) At(modelName string, at time.Time) (types.Rate, bool) {
+	snapshots := h[modelName]
+	if len(snapshots) == 0 {
+		return types.Rate{}, false
+	}
+
+	snapshot := snapshots[0]
+	for _, s := range snapshots[1:] {
+		if s.EffectiveFrom.After(at) {
+			break
+		}
+		snapshot = s
+	}
+	return types.Rate{TS: snapshot.EffectiveFrom.Unix(), In: snapshot.RateIn, Out: snapshot.RateOut}, true
+}
+
+// RecordPrices stores the snapshots whose rate differs from their variant's latest recorded one
+// Unchanged rates are skipped, so the current catalog can be recorded on every start.
+// Returns the number of snapshots stored.
+func (db *DB) RecordPrices(ctx context.Context, snapshots []PriceSnapshot) (int, error) {
+	history, err := db.GetPriceHistory(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO pricing_history (model_name, model_id, rate_in, rate_out, effective_from)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(model_name, effective_from) DO UPDATE SET
+			rate_in = excluded.rate_in,
+			rate_out = excluded.rate_out
+	`
+
+	recorded := 0
+	for _, s := range snapshots {
+		if known := history[s.ModelName]; len(known) > 0 {
+			latest := known[len(known)-1]
+			if latest.RateIn == s.RateIn && latest.RateOut == s.RateOut {
+				continue
+			}
+		}
+		if _, err := tx.ExecContext(ctx, query, s.ModelName, s.ModelID, s.RateIn, s.RateOut, s.EffectiveFrom.UTC()); err != nil {
+			return 0, fmt.Errorf("failed to record price of %s: %w", s.ModelName, err)
+		}
+		recorded++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit prices: %w", err)
+	}
+
+	return recorded, nil
+}
+
+// GetPriceHistory retrieves every recorded snapshot, grouped by variant
+func (db *DB) GetPriceHistory(ctx context.Context) (PriceHistory, error) {
+	query := `
+		SELECT model_id, model_name, rate_in, rate_out, effective_from
+		FROM pricing_history
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pricing history: %w", err)
+	}
+	defer rows.Close()
+
+	history := make(PriceHistory)
+	for rows.Next() {
+		var s PriceSnapshot
+		if err := rows.Scan(&s.ModelID, &s.ModelName, &s.RateIn, &s.RateOut, &s.EffectiveFrom); err != nil {
+			return nil, fmt.Errorf("failed to scan price snapshot: %w", err)
+		}
+		history[s.ModelName] = append(history[s.ModelName], s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, snapshots := range history {
+		sort.Slice(snapshots, func(i, j int) bool {
+			return snapshots[i].EffectiveFrom.Before(snapshots[j].EffectiveFrom)
+		})
+	}
+
+	return history, nil
+}
+
+// RecomputeCosts recalculates every stored cost from its token counts and the list rate in force
+// when the request ran, with the request's own pricing overrides applied on top
+// Rounds and rankings of variants without pricing history keep their stored cost.
+// Request totals, cost breakdowns and model stats are rebuilt from the recomputed rounds and rankings.
+// Returns the number of requests whose costs were recomputed.
+func (db *DB) RecomputeCosts(ctx context.Context) (int, error) {
+	history, err := db.GetPriceHistory(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	type costed struct {
+		id        int64
+		requestID string
+		modelID   string
+		modelName string
+		tokensIn  int64
+		tokensOut int64
+		cost      float64
+	}
+
+	// When each request ran, and the pricing it ran with
+	ranAt := make(map[string]time.Time)
+	rows, err := db.conn.QueryContext(ctx, "SELECT id, created_at FROM requests")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query requests: %w", err)
+	}
+	for rows.Next() {
+		var id string
+		var createdAt time.Time
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan request: %w", err)
+		}
+		ranAt[id] = createdAt
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	pricing := make(map[string]*types.Pricing)
+	rows, err = db.conn.QueryContext(ctx, "SELECT request_id, options FROM request_state")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query request options: %w", err)
+	}
+	for rows.Next() {
+		var id, options string
+		if err := rows.Scan(&id, &options); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan request options: %w", err)
+		}
+		var opts struct {
+			Pricing *types.Pricing `json:"pricing"`
+		}
+		if json.Unmarshal([]byte(options), &opts) == nil {
+			pricing[id] = opts.Pricing
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	recompute := func(c *costed) {
+		at, ok := ranAt[c.requestID]
+		if !ok {
+			return
+		}
+		rate, ok := history.At(c.modelName, at)
+		if !ok {
+			return
+		}
+		rate = pricing[c.requestID].Apply(c.modelName, rate)
+		c.cost = (float64(c.tokensIn)*rate.In + float64(c.tokensOut)*rate.Out) / 1_000_000
+	}
+
+	load := func(query string) ([]costed, error) {
+		rows, err := db.conn.QueryContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var all []costed
+		for rows.Next() {
+			var c costed
+			if err := rows.Scan(&c.id, &c.requestID, &c.modelID, &c.modelName, &c.tokensIn, &c.tokensOut, &c.cost); err != nil {
+				return nil, err
+			}
+			recompute(&c)
+			all = append(all, c)
+		}
+		return all, rows.Err()
+	}
+
+	rounds, err := load(`
+		SELECT id, request_id, model_id, model_name, tokens_in, tokens_out, COALESCE(cost, 0)
+		FROM model_rounds
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load model rounds: %w", err)
+	}
+	rankings, err := load(`
+		SELECT id, request_id, '', ranker_model, tokens_in, tokens_out, COALESCE(cost, 0)
+		FROM rankings
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load rankings: %w", err)
+	}
+
+	// Totals follow how they're recorded at run time: participants' rounds plus their own rankings
+	participants := make(map[string]map[string]string) // request ID -> variant -> model ID
+	requestCosts := make(map[string]float64)
+	modelCosts := make(map[string]float64)
+	for _, r := range rounds {
+		if participants[r.requestID] == nil {
+			participants[r.requestID] = make(map[string]string)
+		}
+		participants[r.requestID][r.modelName] = r.modelID
+		requestCosts[r.requestID] += r.cost
+		modelCosts[r.modelID] += r.cost
+	}
+	for _, r := range rankings {
+		if modelID, ok := participants[r.requestID][r.modelName]; ok {
+			requestCosts[r.requestID] += r.cost
+			modelCosts[modelID] += r.cost
+		}
+	}
+
+	// Breakdowns count every round and ranking, outside judges included
+	breakdowns := make(map[string]*CostBreakdown)
+	for i, list := range [][]costed{rounds, rankings} {
+		for _, r := range list {
+			b, ok := breakdowns[r.requestID]
+			if !ok {
+				b = &CostBreakdown{Models: []ModelCost{}}
+				breakdowns[r.requestID] = b
+			}
+			b.add(r.modelName, i == 1, r.cost)
+		}
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, r := range rounds {
+		if _, err := tx.ExecContext(ctx, "UPDATE model_rounds SET cost = ? WHERE id = ?", r.cost, r.id); err != nil {
+			return 0, fmt.Errorf("failed to update round cost: %w", err)
+		}
+	}
+	for _, r := range rankings {
+		if _, err := tx.ExecContext(ctx, "UPDATE rankings SET cost = ? WHERE id = ?", r.cost, r.id); err != nil {
+			return 0, fmt.Errorf("failed to update ranking cost: %w", err)
+		}
+	}
+	for requestID, cost := range requestCosts {
+		if _, err := tx.ExecContext(ctx, "UPDATE requests SET total_cost = ? WHERE id = ?", cost, requestID); err != nil {
+			return 0, fmt.Errorf("failed to update request cost: %w", err)
+		}
+	}
+	for requestID, b := range breakdowns {
+		b.sort()
+		data, err := json.Marshal(b)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE requests SET cost_breakdown = ? WHERE id = ?", string(data), requestID); err != nil {
+			return 0, fmt.Errorf("failed to update cost breakdown: %w", err)
+		}
+	}
+	for modelID, cost := range modelCosts {
+		if _, err := tx.ExecContext(ctx, "UPDATE model_stats SET total_cost = ? WHERE model_id = ?", cost, modelID); err != nil {
+			return 0, fmt.Errorf("failed to update model stats cost: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit recomputed costs: %w", err)
+	}
+
+	return len(requestCosts), nil
+}
+
+// GetCostsByVariant sums the stored costs of rounds and rankings created in [start, end) per model variant
+func (db *DB) GetCostsByVariant(ctx context.Context, start, end time.Time) (map[string]float64, error) {
+	query := `
+		SELECT model_name, SUM(COALESCE(cost, 0)) FROM model_rounds
+		WHERE created_at >= ? AND created_at < ?
+		GROUP BY model_name
+		UNION ALL
+		SELECT ranker_model, SUM(COALESCE(cost, 0)) FROM rankings
+		WHERE created_at >= ? AND created_at < ?
+		GROUP BY ranker_model
+	`
+
+	from, to := start.UTC().Format(time.DateTime), end.UTC().Format(time.DateTime)
+	rows, err := db.conn.QueryContext(ctx, query, from, to, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query costs: %w", err)
+	}
+	defer rows.Close()
+
+	costs := make(map[string]float64)
+	for rows.Next() {
+		var variant string
+		var cost float64
+		if err := rows.Scan(&variant, &cost); err != nil {
+			return nil, fmt.Errorf("failed to scan cost: %w", err)
+		}
+		costs[variant] += cost
+	}
+
+	return costs, rows.Err()
+}
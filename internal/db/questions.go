@@ -0,0 +1,234 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrDuplicateQuestion is returned when a sample question with the same text already exists
+var ErrDuplicateQuestion = errors.New("sample question already exists")
+
+// SampleQuestion is a question offered by the random question picker
+type SampleQuestion struct {
+	ID        int64
+	Question  string
+	Category  string
+	Enabled   bool
+	CreatedAt time.Time
+
+	// Derived from requests asking exactly this question
+	TimesAsked int64
+	Wins       map[string]int64 // Winning model ID -> number of wins
+	TopWinner  string           // Model ID with the most wins, "" if never won
+}
+
+// SampleQuestionUpdate changes the non-nil fields of a sample question
+type SampleQuestionUpdate struct {
+	Question *string
+	Category *string
+	Enabled  *bool
+}
+
+// SeedSampleQuestions fills an empty sample question table with the given questions
+// It does nothing once any question exists, so admin edits survive restarts. Returns how many were added.
+func (db *DB) SeedSampleQuestions(ctx context.Context, questions []string) (int, error) {
+	var count int
+	if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM sample_questions").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count sample questions: %w", err)
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	added := 0
+	for _, q := range questions {
+		if q = strings.TrimSpace(q); q == "" {
+			continue
+		}
+		res, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO sample_questions (question) VALUES (?)", q)
+		if err != nil {
+			return 0, fmt.Errorf("failed to seed sample question: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit sample questions: %w", err)
+	}
+
+	return added, nil
+}
+
+// AddSampleQuestion stores a new enabled sample question
+func (db *DB) AddSampleQuestion(ctx context.Context, question, category string) (*SampleQuestion, error) {
+	res, err := db.conn.ExecContext(ctx,
+		"INSERT OR IGNORE INTO sample_questions (question, category) VALUES (?, ?)",
+		strings.TrimSpace(question), strings.TrimSpace(category))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add sample question: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrDuplicateQuestion
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sample question id: %w", err)
+	}
+
+	return db.GetSampleQuestion(ctx, id)
+}
+
+// UpdateSampleQuestion applies an update and returns the result, or nil if the question doesn't exist
+func (db *DB) UpdateSampleQuestion(ctx context.Context, id int64, upd SampleQuestionUpdate) (*SampleQuestion, error) {
+	var sets []string
+	var args []any
+	if upd.Question != nil {
+		sets = append(sets, "question = ?")
+		args = append(args, strings.TrimSpace(*upd.Question))
+	}
+	if upd.Category != nil {
+		sets = append(sets, "category = ?")
+		args = append(args, strings.TrimSpace(*upd.Category))
+	}
+	if upd.Enabled != nil {
+		sets = append(sets, "enabled = ?")
+		args = append(args, *upd.Enabled)
+	}
+
+	if len(sets) > 0 {
+		args = append(args, id)
+		_, err := db.conn.ExecContext(ctx,
+			"UPDATE sample_questions SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...)
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				return nil, ErrDuplicateQuestion
+			}
+			return nil, fmt.Errorf("failed to update sample question: %w", err)
+		}
+	}
+
+	return db.GetSampleQuestion(ctx, id)
+}
+
+// GetSampleQuestion retrieves one sample question with its stats, or nil if it doesn't exist
+func (db *DB) GetSampleQuestion(ctx context.Context, id int64) (*SampleQuestion, error) {
+	questions, err := db.querySampleQuestions(ctx, "WHERE sq.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, nil
+	}
+	return &questions[0], nil
+}
+
+// GetSampleQuestions lists sample questions with their stats, optionally limited to one category
+// Disabled questions are only included when includeDisabled is set
+func (db *DB) GetSampleQuestions(ctx context.Context, category string, includeDisabled bool) ([]SampleQuestion, error) {
+	return db.querySampleQuestions(ctx,
+		"WHERE (? = '' OR sq.category = ?) AND (? OR sq.enabled)",
+		category, category, includeDisabled)
+}
+
+// RandomSampleQuestion picks an enabled sample question, optionally from one category
+// Returns an empty string when there is none to pick
+func (db *DB) RandomSampleQuestion(ctx context.Context, category string) (string, error) {
+	query := `
+		SELECT question
+		FROM sample_questions
+		WHERE enabled AND (? = '' OR category = ?)
+		ORDER BY RANDOM()
+		LIMIT 1
+	`
+
+	var question string
+	err := db.conn.QueryRowContext(ctx, query, category, category).Scan(&question)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to pick sample question: %w", err)
+	}
+
+	return question, nil
+}
+
+// querySampleQuestions loads sample questions matching where, then attaches their win counts
+func (db *DB) querySampleQuestions(ctx context.Context, where string, args ...any) ([]SampleQuestion, error) {
+	query := `
+		SELECT sq.id, sq.question, sq.category, sq.enabled, sq.created_at,
+		       (SELECT COUNT(*) FROM requests r WHERE r.question = sq.question)
+		FROM sample_questions sq
+		` + where + `
+		ORDER BY sq.category, sq.id
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sample questions: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []SampleQuestion
+	byText := make(map[string]int)
+	for rows.Next() {
+		q := SampleQuestion{Wins: make(map[string]int64)}
+		if err := rows.Scan(&q.ID, &q.Question, &q.Category, &q.Enabled, &q.CreatedAt, &q.TimesAsked); err != nil {
+			return nil, fmt.Errorf("failed to scan sample question: %w", err)
+		}
+		byText[q.Question] = len(questions)
+		questions = append(questions, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return questions, nil
+	}
+
+	winsQuery := `
+		SELECT r.question, r.winner_model, COUNT(*)
+		FROM requests r
+		JOIN sample_questions sq ON sq.question = r.question
+		WHERE COALESCE(r.winner_model, '') != ''
+		GROUP BY r.question, r.winner_model
+	`
+
+	winRows, err := db.conn.QueryContext(ctx, winsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sample question winners: %w", err)
+	}
+	defer winRows.Close()
+
+	for winRows.Next() {
+		var text, winner string
+		var wins int64
+		if err := winRows.Scan(&text, &winner, &wins); err != nil {
+			return nil, fmt.Errorf("failed to scan sample question winner: %w", err)
+		}
+		i, ok := byText[text]
+		if !ok {
+			continue
+		}
+		q := &questions[i]
+		q.Wins[winner] = wins
+		if top := q.Wins[q.TopWinner]; wins > top || (wins == top && winner < q.TopWinner) {
+			q.TopWinner = winner
+		}
+	}
+
+	return questions, winRows.Err()
+}
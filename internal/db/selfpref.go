@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// SelfPreference records how a judge ranked its own answer in a request against the other judges
+// Scores run from 1 (ranked first) to 0 (ranked last); Bias is SelfScore - PeerScore.
+type SelfPreference struct {
+	RequestID    string
+	JudgeModel   string // Variant name of the judge
+	SelfPosition int    // 0-based position of its own answer in its ranking
+	Ranked       int    // Answers in its ranking
+	SelfScore    float64
+	PeerScore    float64 // Mean score the other judges gave the judge's answer
+	Bias         float64
+}
+
+// SelfPreferenceStats aggregates a judge's self-preference across requests
+type SelfPreferenceStats struct {
+	JudgeModel string
+	Rankings   int     // Rankings in which the judge ranked its own answer
+	SelfFirst  int     // Rankings that put its own answer first
+	MeanBias   float64 // Average of SelfScore - PeerScore; above 0 means it favors itself
+}
+
+// SaveSelfPreference saves a judge's self-preference for a request
+func (db *DB) SaveSelfPreference(ctx context.Context, sp SelfPreference) error {
+	query := `
+		INSERT INTO self_preference (
+			request_id, judge_model, self_position, ranked, self_score, peer_score, bias
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(request_id, judge_model) DO UPDATE SET
+			self_position = excluded.self_position,
+			ranked = excluded.ranked,
+			self_score = excluded.self_score,
+			peer_score = excluded.peer_score,
+			bias = excluded.bias
+	`
+
+	_, err := db.conn.ExecContext(ctx, query,
+		sp.RequestID, sp.JudgeModel, sp.SelfPosition, sp.Ranked, sp.SelfScore, sp.PeerScore, sp.Bias,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save self-preference: %w", err)
+	}
+
+	return nil
+}
+
+// GetSelfPreferenceStats retrieves every judge's aggregated self-preference, most biased first
+func (db *DB) GetSelfPreferenceStats(ctx context.Context) ([]SelfPreferenceStats, error) {
+	query := `
+		SELECT judge_model, COUNT(*), SUM(CASE WHEN self_position = 0 THEN 1 ELSE 0 END), AVG(bias)
+		FROM self_preference
+		GROUP BY judge_model
+		ORDER BY AVG(bias) DESC, judge_model
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query self-preference: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []SelfPreferenceStats
+	for rows.Next() {
+		var s SelfPreferenceStats
+		if err := rows.Scan(&s.JudgeModel, &s.Rankings, &s.SelfFirst, &s.MeanBias); err != nil {
+			return nil, fmt.Errorf("failed to scan self-preference: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// AnswerSimilarity is how similar two models' final answers in a request are, by embedding
+type AnswerSimilarity struct {
+	RequestID      string
+	ModelA         string // Model IDs, ModelA sorting before ModelB
+	ModelB         string
+	Similarity     float64 // Cosine similarity, 1 for answers of the same meaning
+	EmbeddingModel string
+}
+
+// SaveAnswerSimilarities saves the pairwise similarities of a request's final answers, replacing earlier ones
+func (db *DB) SaveAnswerSimilarities(ctx context.Context, pairs []AnswerSimilarity) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO answer_similarity (request_id, model_a, model_b, similarity, embedding_model)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(request_id, model_a, model_b) DO UPDATE SET
+			similarity = excluded.similarity,
+			embedding_model = excluded.embedding_model
+	`
+	for _, p := range pairs {
+		if _, err := tx.ExecContext(ctx, query, p.RequestID, p.ModelA, p.ModelB, p.Similarity, p.EmbeddingModel); err != nil {
+			return fmt.Errorf("failed to save answer similarity: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetAnswerSimilarities retrieves the pairwise similarities of a request's final answers, ordered by model
+func (db *DB) GetAnswerSimilarities(ctx context.Context, requestID string) ([]AnswerSimilarity, error) {
+	query := `
+		SELECT request_id, model_a, model_b, similarity, embedding_model
+		FROM answer_similarity
+		WHERE request_id = ?
+		ORDER BY model_a, model_b
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query answer similarity: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs []AnswerSimilarity
+	for rows.Next() {
+		var p AnswerSimilarity
+		if err := rows.Scan(&p.RequestID, &p.ModelA, &p.ModelB, &p.Similarity, &p.EmbeddingModel); err != nil {
+			return nil, fmt.Errorf("failed to scan answer similarity: %w", err)
+		}
+		pairs = append(pairs, p)
+	}
+
+	return pairs, rows.Err()
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Request state statuses
+const (
+	StateRunning  = "running"
+	StateComplete = "complete"
+)
+
+// RequestState is a resumable snapshot of an in-progress request
+type RequestState struct {
+	RequestID    string
+	Question     string
+	NumRounds    int
+	QuestionTS   int64
+	Models       string // JSON map of model ID -> variant name
+	Round        int    // Last fully completed round (0 = none yet)
+	Replies      string // JSON map of model ID -> latest reply
+	Discussion   string // JSON of discussion threads
+	PrivateNotes string // JSON map of model ID -> round -> notes
+	Options      string // JSON of the run options
+	Status       string
+	UpdatedAt    time.Time
+}
+
+// SaveRequestState creates or replaces the snapshot of a request
+func (db *DB) SaveRequestState(ctx context.Context, st RequestState) error {
+	query := `
+		INSERT INTO request_state (
+			request_id, question, num_rounds, question_ts, models,
+			round, replies, discussion, private_notes, options, status, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(request_id) DO UPDATE SET
+			num_rounds = excluded.num_rounds,
+			round = excluded.round,
+			replies = excluded.replies,
+			discussion = excluded.discussion,
+			private_notes = excluded.private_notes,
+			status = excluded.status,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	_, err := db.conn.ExecContext(ctx, query,
+		st.RequestID, st.Question, st.NumRounds, st.QuestionTS, st.Models,
+		st.Round, st.Replies, st.Discussion, st.PrivateNotes, st.Options, st.Status,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save request state: %w", err)
+	}
+
+	return nil
+}
+
+// SetRequestStateStatus updates only the status of a request snapshot
+func (db *DB) SetRequestStateStatus(ctx context.Context, requestID, status string) error {
+	_, err := db.conn.ExecContext(ctx,
+		"UPDATE request_state SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE request_id = ?",
+		status, requestID)
+	if err != nil {
+		return fmt.Errorf("failed to update request state status: %w", err)
+	}
+	return nil
+}
+
+// GetRequestState retrieves the snapshot of a request, or nil if none exists
+func (db *DB) GetRequestState(ctx context.Context, requestID string) (*RequestState, error) {
+	query := `
+		SELECT request_id, question, num_rounds, question_ts, models,
+		       round, replies, discussion, private_notes, options, status, updated_at
+		FROM request_state
+		WHERE request_id = ?
+	`
+
+	var st RequestState
+	err := db.conn.QueryRowContext(ctx, query, requestID).Scan(
+		&st.RequestID, &st.Question, &st.NumRounds, &st.QuestionTS, &st.Models,
+		&st.Round, &st.Replies, &st.Discussion, &st.PrivateNotes, &st.Options, &st.Status, &st.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request state: %w", err)
+	}
+
+	return &st, nil
+}
+
+// PruneRequestStates deletes the snapshots of requests that never completed and weren't updated since before,
+// returning how many were deleted. Completed requests keep theirs, which hold the options their costs are recomputed with.
+func (db *DB) PruneRequestStates(ctx context.Context, before time.Time) (int64, error) {
+	result, err := db.conn.ExecContext(ctx,
+		"DELETE FROM request_state WHERE status = ? AND updated_at < ?",
+		StateRunning, before.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune request states: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetResumableRequests retrieves snapshots of all requests that never completed
+func (db *DB) GetResumableRequests(ctx context.Context) ([]RequestState, error) {
+	query := `
+		SELECT request_id, question, num_rounds, question_ts, models,
+		       round, replies, discussion, private_notes, options, status, updated_at
+		FROM request_state
+		WHERE status = ?
+		ORDER BY updated_at DESC
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, StateRunning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []RequestState
+	for rows.Next() {
+		var st RequestState
+		if err := rows.Scan(
+			&st.RequestID, &st.Question, &st.NumRounds, &st.QuestionTS, &st.Models,
+			&st.Round, &st.Replies, &st.Discussion, &st.PrivateNotes, &st.Options, &st.Status, &st.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan request state: %w", err)
+		}
+		states = append(states, st)
+	}
+
+	return states, rows.Err()
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ModelStatsSnapshot is a model's aggregate statistics as they stood on a day
+type ModelStatsSnapshot struct {
+	Day               string // UTC date, YYYY-MM-DD
+	ModelID           string
+	ModelName         string // Variant last used by the model on that day
+	TotalRequests     int64
+	TotalWins         int64
+	TotalTokensIn     int64
+	TotalTokensOut    int64
+	TotalCost         float64
+	AvgResponseTimeMs int64
+	ErrorCount        int64
+}
+
+// SnapshotModelStats copies every model's current stats into the history under the UTC day of at
+// Taking another snapshot on the same day replaces that day's, so each day keeps its last one.
+func (db *DB) SnapshotModelStats(ctx context.Context, at time.Time) error {
+	query := `
+		INSERT INTO model_stats_history (
+			day, model_id, model_name, total_requests, total_wins,
+			total_tokens_in, total_tokens_out, total_cost,
+			avg_response_time_ms, error_count
+		)
+		SELECT ?, model_id, model_name, total_requests, total_wins,
+			   total_tokens_in, total_tokens_out, total_cost,
+			   avg_response_time_ms, error_count
+		FROM model_stats
+		WHERE true -- tells SQLite the ON CONFLICT below is an upsert, not a join constraint
+		ON CONFLICT(day, model_id) DO UPDATE SET
+			model_name = excluded.model_name,
+			total_requests = excluded.total_requests,
+			total_wins = excluded.total_wins,
+			total_tokens_in = excluded.total_tokens_in,
+			total_tokens_out = excluded.total_tokens_out,
+			total_cost = excluded.total_cost,
+			avg_response_time_ms = excluded.avg_response_time_ms,
+			error_count = excluded.error_count
+	`
+
+	if _, err := db.conn.ExecContext(ctx, query, at.UTC().Format(time.DateOnly)); err != nil {
+		return fmt.Errorf("failed to snapshot model stats: %w", err)
+	}
+	return nil
+}
+
+// GetModelStatsHistory retrieves the snapshots taken on or after the UTC day of since, oldest first
+func (db *DB) GetModelStatsHistory(ctx context.Context, since time.Time) ([]ModelStatsSnapshot, error) {
+	query := `
+		SELECT day, model_id, model_name, total_requests, total_wins,
+			   total_tokens_in, total_tokens_out, total_cost,
+			   avg_response_time_ms, error_count
+		FROM model_stats_history
+		WHERE day >= ?
+		ORDER BY day, model_id
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, since.UTC().Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query model stats history: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []ModelStatsSnapshot
+	for rows.Next() {
+		var s ModelStatsSnapshot
+		if err := rows.Scan(
+			&s.Day, &s.ModelID, &s.ModelName, &s.TotalRequests, &s.TotalWins,
+			&s.TotalTokensIn, &s.TotalTokensOut, &s.TotalCost,
+			&s.AvgResponseTimeMs, &s.ErrorCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan model stats snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, rows.Err()
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Tournament is a question run through a single-elimination bracket of model variants
+type Tournament struct {
+	ID         string            `json:"id"`
+	Question   string            `json:"question"`
+	Jury       []string          `json:"jury"`   // Variants judging every match
+	Winner     string            `json:"winner"` // Variant left at the end, empty while running or if nobody could play
+	QuestionTS int64             `json:"question_ts"`
+	TotalCost  float64           `json:"total_cost"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Entries    []TournamentEntry `json:"entries"` // By seed; filled by GetTournament
+	Matches    []TournamentMatch `json:"matches"` // By round, then slot; filled by GetTournament
+}
+
+// TournamentEntry is a variant's answer in a tournament
+type TournamentEntry struct {
+	TournamentID string  `json:"-"`
+	ModelName    string  `json:"model_name"`
+	ModelID      string  `json:"model_id"`
+	Seed         int     `json:"seed"`
+	Answer       string  `json:"answer"`
+	TokensIn     int64   `json:"tokens_in"`
+	TokensOut    int64   `json:"tokens_out"`
+	Cost         float64 `json:"cost"`
+	Error        string  `json:"error,omitempty"` // Why it couldn't answer; it forfeits its first match then
+}
+
+// TournamentMatch is one head-to-head of a tournament's bracket and how the jury voted
+type TournamentMatch struct {
+	TournamentID string      `json:"-"`
+	Round        int         `json:"round"`
+	Slot         int         `json:"slot"`
+	ModelA       string      `json:"model_a"`
+	ModelB       string      `json:"model_b"` // Empty for a bye
+	Winner       string      `json:"winner"`
+	Votes        []MatchVote `json:"votes"`
+	TokensIn     int64       `json:"tokens_in"`
+	TokensOut    int64       `json:"tokens_out"`
+	Cost         float64     `json:"cost"`
+}
+
+// MatchVote is a juror's pick in a match
+type MatchVote struct {
+	Juror  string `json:"juror"`
+	Vote   string `json:"vote,omitempty"` // Variant the juror picked, empty if it failed to
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SaveTournament saves a tournament, replacing its earlier record; entries and matches are saved on their own
+func (db *DB) SaveTournament(ctx context.Context, t Tournament) error {
+	jury, err := json.Marshal(t.Jury)
+	if err != nil {
+		return fmt.Errorf("failed to encode jury: %w", err)
+	}
+
+	query := `
+		INSERT INTO tournaments (id, question, jury, winner, question_ts, total_cost)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			winner = excluded.winner,
+			total_cost = excluded.total_cost
+	`
+	if _, err := db.conn.ExecContext(ctx, query, t.ID, t.Question, string(jury), t.Winner, t.QuestionTS, t.TotalCost); err != nil {
+		return fmt.Errorf("failed to save tournament: %w", err)
+	}
+	return nil
+}
+
+// SaveTournamentEntry saves a variant's answer in a tournament
+func (db *DB) SaveTournamentEntry(ctx context.Context, e TournamentEntry) error {
+	query := `
+		INSERT OR REPLACE INTO tournament_entries (tournament_id, model_name, model_id, seed, answer, tokens_in, tokens_out, cost, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if _, err := db.conn.ExecContext(ctx, query, e.TournamentID, e.ModelName, e.ModelID, e.Seed, e.Answer, e.TokensIn, e.TokensOut, e.Cost, e.Error); err != nil {
+		return fmt.Errorf("failed to save tournament entry: %w", err)
+	}
+	return nil
+}
+
+// SaveTournamentMatch saves a decided match of a tournament's bracket
+func (db *DB) SaveTournamentMatch(ctx context.Context, m TournamentMatch) error {
+	votes, err := json.Marshal(m.Votes)
+	if err != nil {
+		return fmt.Errorf("failed to encode votes: %w", err)
+	}
+	if m.Votes == nil {
+		votes = []byte("[]")
+	}
+
+	query := `
+		INSERT OR REPLACE INTO tournament_matches (tournament_id, round, slot, model_a, model_b, winner, votes, tokens_in, tokens_out, cost)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if _, err := db.conn.ExecContext(ctx, query, m.TournamentID, m.Round, m.Slot, m.ModelA, m.ModelB, m.Winner, string(votes), m.TokensIn, m.TokensOut, m.Cost); err != nil {
+		return fmt.Errorf("failed to save tournament match: %w", err)
+	}
+	return nil
+}
+
+// GetTournament retrieves a tournament with its entries and matches, or nil if it does not exist
+func (db *DB) GetTournament(ctx context.Context, id string) (*Tournament, error) {
+	var t Tournament
+	var jury string
+	err := db.conn.QueryRowContext(ctx,
+		"SELECT id, question, jury, winner, question_ts, total_cost, created_at FROM tournaments WHERE id = ?", id,
+	).Scan(&t.ID, &t.Question, &jury, &t.Winner, &t.QuestionTS, &t.TotalCost, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tournament: %w", err)
+	}
+	if err := json.Unmarshal([]byte(jury), &t.Jury); err != nil {
+		return nil, fmt.Errorf("failed to decode jury: %w", err)
+	}
+
+	entries, err := db.conn.QueryContext(ctx, `
+		SELECT tournament_id, model_name, model_id, seed, answer, tokens_in, tokens_out, cost, error
+		FROM tournament_entries
+		WHERE tournament_id = ?
+		ORDER BY seed
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tournament entries: %w", err)
+	}
+	defer entries.Close()
+	for entries.Next() {
+		var e TournamentEntry
+		if err := entries.Scan(&e.TournamentID, &e.ModelName, &e.ModelID, &e.Seed, &e.Answer, &e.TokensIn, &e.TokensOut, &e.Cost, &e.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan tournament entry: %w", err)
+		}
+		t.Entries = append(t.Entries, e)
+	}
+	if err := entries.Err(); err != nil {
+		return nil, err
+	}
+
+	matches, err := db.conn.QueryContext(ctx, `
+		SELECT tournament_id, round, slot, model_a, model_b, winner, votes, tokens_in, tokens_out, cost
+		FROM tournament_matches
+		WHERE tournament_id = ?
+		ORDER BY round, slot
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tournament matches: %w", err)
+	}
+	defer matches.Close()
+	for matches.Next() {
+		var m TournamentMatch
+		var votes string
+		if err := matches.Scan(&m.TournamentID, &m.Round, &m.Slot, &m.ModelA, &m.ModelB, &m.Winner, &votes, &m.TokensIn, &m.TokensOut, &m.Cost); err != nil {
+			return nil, fmt.Errorf("failed to scan tournament match: %w", err)
+		}
+		if err := json.Unmarshal([]byte(votes), &m.Votes); err != nil {
+			return nil, fmt.Errorf("failed to decode votes: %w", err)
+		}
+		t.Matches = append(t.Matches, m)
+	}
+
+	return &t, matches.Err()
+}
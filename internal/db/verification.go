@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// Verification is one participant's fact-check of a request's leading answer
+type Verification struct {
+	RequestID    string        `json:"-"`
+	Verifier     string        `json:"verifier"`      // Model ID of the participant that checked the answer
+	VerifierName string        `json:"verifier_name"` // Its variant
+	AnswerModel  string        `json:"answer_model"`  // Model ID of the answer that was checked
+	Claims       []types.Claim `json:"claims"`
+	TokensIn     int64         `json:"tokens_in"`
+	TokensOut    int64         `json:"tokens_out"`
+	Cost         float64       `json:"cost"`
+	Error        string        `json:"error,omitempty"` // Why the check failed, empty on success
+}
+
+// SaveVerification saves a participant's fact-check of a request's leading answer, replacing an earlier one
+func (db *DB) SaveVerification(ctx context.Context, v Verification) error {
+	claims, err := json.Marshal(v.Claims)
+	if err != nil {
+		return fmt.Errorf("failed to encode claims: %w", err)
+	}
+	if v.Claims == nil {
+		claims = []byte("[]")
+	}
+
+	query := `
+		INSERT INTO verifications (request_id, verifier, verifier_name, answer_model, claims, tokens_in, tokens_out, cost, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(request_id, verifier) DO UPDATE SET
+			verifier_name = excluded.verifier_name,
+			answer_model = excluded.answer_model,
+			claims = excluded.claims,
+			tokens_in = excluded.tokens_in,
+			tokens_out = excluded.tokens_out,
+			cost = excluded.cost,
+			error = excluded.error
+	`
+	if _, err := db.conn.ExecContext(ctx, query, v.RequestID, v.Verifier, v.VerifierName, v.AnswerModel, string(claims), v.TokensIn, v.TokensOut, v.Cost, v.Error); err != nil {
+		return fmt.Errorf("failed to save verification: %w", err)
+	}
+	return nil
+}
+
+// GetVerifications retrieves the fact-checks of a request's leading answer, ordered by verifier
+func (db *DB) GetVerifications(ctx context.Context, requestID string) ([]Verification, error) {
+	query := `
+		SELECT request_id, verifier, verifier_name, answer_model, claims, tokens_in, tokens_out, cost, error
+		FROM verifications
+		WHERE request_id = ?
+		ORDER BY verifier
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verifications: %w", err)
+	}
+	defer rows.Close()
+
+	var verifications []Verification
+	for rows.Next() {
+		var v Verification
+		var claims string
+		if err := rows.Scan(&v.RequestID, &v.Verifier, &v.VerifierName, &v.AnswerModel, &claims, &v.TokensIn, &v.TokensOut, &v.Cost, &v.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan verification: %w", err)
+		}
+		if err := json.Unmarshal([]byte(claims), &v.Claims); err != nil {
+			return nil, fmt.Errorf("failed to decode claims: %w", err)
+		}
+		verifications = append(verifications, v)
+	}
+
+	return verifications, rows.Err()
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrNotAwaitingHuman is returned when picking the winner of a request that isn't waiting for a person to
+var ErrNotAwaitingHuman = errors.New("request is not awaiting a human winner")
+
+// ErrNotParticipant is returned when the picked winner didn't answer in the request
+var ErrNotParticipant = errors.New("model did not answer in the request")
+
+// SetHumanWinner records the winner a person picked for a request whose strategy left it to them
+// The judges' provisional ranking is kept as it was stored. A winner can only be picked once.
+func (db *DB) SetHumanWinner(ctx context.Context, requestID, modelID string) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var awaiting bool
+	err = tx.QueryRowContext(ctx, "SELECT awaiting_human FROM requests WHERE id = ? AND deleted_at IS NULL", requestID).Scan(&awaiting)
+	if err == sql.ErrNoRows {
+		return ErrRequestNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get request: %w", err)
+	}
+	if !awaiting {
+		return ErrNotAwaitingHuman
+	}
+
+	var answers int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM model_rounds WHERE request_id = ? AND model_id = ? AND answer != ''", requestID, modelID).Scan(&answers)
+	if err != nil {
+		return fmt.Errorf("failed to check participant: %w", err)
+	}
+	if answers == 0 {
+		return ErrNotParticipant
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE requests SET winner_model = ?, awaiting_human = 0 WHERE id = ?", modelID, requestID); err != nil {
+		return fmt.Errorf("failed to save winner: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetVerdict retrieves the meta judge's verdict on a request, or nil if none was given
+func (db *DB) GetVerdict(ctx context.Context, requestID string) (*Ranking, error) {
+	query := `
+		SELECT id, request_id, ranker_model, ranked_models, justifications, meta, verdict,
+		       duration_ms, tokens_in, tokens_out, cost, created_at
+		FROM rankings
+		WHERE request_id = ? AND meta = 1
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var r Ranking
+	err := db.conn.QueryRowContext(ctx, query, requestID).Scan(
+		&r.ID, &r.RequestID, &r.RankerModel, &r.RankedModels, &r.Justifications, &r.Meta, &r.Verdict,
+		&r.DurationMs, &r.TokensIn, &r.TokensOut, &r.Cost, &r.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get verdict: %w", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,138 @@
+// Package diagnostics captures what went on in a failed run - the configuration, provider latencies, the last
+// prompt each model was sent, the errors and a goroutine dump - into a single file per request, so a bug report
+// can attach everything needed to reproduce it.
+package diagnostics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// ErrNotFound is returned when no diagnostics were captured for a request
+var ErrNotFound = errors.New("no diagnostics captured for request")
+
+// validID keeps request IDs that can't be used as file names out of the directory
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Bundle is everything captured about a failed run
+type Bundle struct {
+	RequestID  string    `json:"request_id"`
+	Question   string    `json:"question"`
+	Reason     string    `json:"reason"` // Why the run counts as failed
+	CapturedAt time.Time `json:"captured_at"`
+	GoVersion  string    `json:"go_version"`
+	Platform   string    `json:"platform"`
+	Config     any       `json:"config"` // With secrets redacted
+	Models     []Model   `json:"models"`
+	Errors     []Error   `json:"errors"`
+	Goroutines string    `json:"goroutines"`
+}
+
+// Model is one participant's calls in the run
+type Model struct {
+	ModelID    string  `json:"model_id"`
+	Variant    string  `json:"variant"`
+	Rounds     []Round `json:"rounds"`
+	LastPrompt string  `json:"last_prompt,omitempty"`
+}
+
+// Round is how one of a model's calls went
+type Round struct {
+	Round      int    `json:"round"`
+	DurationMs int64  `json:"duration_ms"` // Including retries
+	TokensIn   int64  `json:"tokens_in"`
+	TokensOut  int64  `json:"tokens_out"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Error is a failed model call
+type Error struct {
+	ModelID string `json:"model_id"`
+	Round   int    `json:"round"`
+	Error   string `json:"error"`
+}
+
+// Collector writes bundles into a directory, one file per request
+type Collector struct {
+	dir    string
+	config any
+}
+
+// New returns a collector writing into dir, snapshotting config (already redacted) into every bundle
+func New(dir string, config any) *Collector {
+	return &Collector{dir: dir, config: config}
+}
+
+// Capture completes a bundle with the environment and a goroutine dump and writes it, replacing an earlier one
+// A nil collector captures nothing.
+func (c *Collector) Capture(b Bundle) error {
+	if c == nil {
+		return nil
+	}
+	if !validID.MatchString(b.RequestID) {
+		return fmt.Errorf("invalid request ID %q", b.RequestID)
+	}
+
+	b.CapturedAt = time.Now().UTC()
+	b.GoVersion = runtime.Version()
+	b.Platform = runtime.GOOS + "/" + runtime.GOARCH
+	b.Config = c.config
+	b.Goroutines = Goroutines()
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create diagnostics directory: %w", err)
+	}
+	// Prompts can hold whatever users asked, so the file is readable by the server's user only
+	return os.WriteFile(c.path(b.RequestID), data, 0600)
+}
+
+// Read returns a request's bundle as written
+func (c *Collector) Read(requestID string) ([]byte, error) {
+	if c == nil || !validID.MatchString(requestID) {
+		return nil, ErrNotFound
+	}
+
+	data, err := os.ReadFile(c.path(requestID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Remove deletes a request's bundle, if one was captured
+func (c *Collector) Remove(requestID string) error {
+	if c == nil || !validID.MatchString(requestID) {
+		return nil
+	}
+
+	if err := os.Remove(c.path(requestID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path is where a request's bundle is written
+func (c *Collector) path(requestID string) string {
+	return filepath.Join(c.dir, requestID+".json")
+}
+
+// Goroutines is the stack of every goroutine in the process, as printed for an unrecovered panic
+func Goroutines() string {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		return fmt.Sprintf("failed to dump goroutines: %v", err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,66 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	c := New(t.TempDir(), map[string]string{"LogLevel": "info"})
+
+	if _, err := c.Read("req-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound before capturing, got %v", err)
+	}
+
+	err := c.Capture(Bundle{
+		RequestID: "req-1",
+		Reason:    "1 model call failed",
+		Models:    []Model{{ModelID: "grok", Variant: "grok-4", LastPrompt: "Why?"}},
+		Errors:    []Error{{ModelID: "grok", Round: 1, Error: "timeout"}},
+	})
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	data, err := c.Read("req-1")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		t.Fatalf("Failed to decode bundle: %v", err)
+	}
+	if b.Reason != "1 model call failed" || len(b.Errors) != 1 || b.Models[0].LastPrompt != "Why?" {
+		t.Errorf("Expected the captured run, got %+v", b)
+	}
+	if b.GoVersion == "" || b.CapturedAt.IsZero() || b.Config == nil {
+		t.Errorf("Expected the environment filled in, got %+v", b)
+	}
+	if !strings.Contains(b.Goroutines, "goroutine") {
+		t.Errorf("Expected a goroutine dump, got %q", b.Goroutines)
+	}
+
+	if err := c.Remove("req-1"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := c.Read("req-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound once removed, got %v", err)
+	}
+	if err := c.Remove("req-1"); err != nil {
+		t.Errorf("Expected removing a missing bundle to succeed, got %v", err)
+	}
+
+	if err := c.Capture(Bundle{RequestID: "../escape"}); err == nil {
+		t.Error("Expected an error for a request ID that isn't a file name, got nil")
+	}
+	if _, err := c.Read("../escape"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an invalid ID, got %v", err)
+	}
+
+	var none *Collector
+	if err := none.Capture(Bundle{RequestID: "req-2"}); err != nil {
+		t.Errorf("Expected a nil collector to capture nothing, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+// Package diff computes word-level differences between two versions of a text, such as a
+// model's answers in consecutive rounds
+package diff
+
+import (
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Op says whether a segment is kept, added or removed
+type Op string
+
+const (
+	Equal  Op = "="
+	Insert Op = "+"
+	Delete Op = "-"
+)
+
+// maxCells bounds the LCS table; larger changes are reported as one replacement
+const maxCells = 4_000_000
+
+// Segment is a run of text with the same Op; concatenating the Equal and Delete segments
+// gives the text before, the Equal and Insert segments the text after
+type Segment struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Words diffs before and after word by word, keeping whitespace and punctuation intact
+func Words(before, after string) []Segment {
+	a, b := tokenize(before), tokenize(after)
+
+	// Common prefix and suffix don't need the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var segments []Segment
+	segments = appendTokens(segments, Equal, a[:prefix])
+	segments = appendMiddle(segments, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	segments = appendTokens(segments, Equal, a[len(a)-suffix:])
+
+	return segments
+}
+
+// Changed reports whether segments contain any insertion or deletion
+func Changed(segments []Segment) bool {
+	for _, s := range segments {
+		if s.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+// appendMiddle diffs the differing middle of two token lists with a longest common subsequence
+func appendMiddle(segments []Segment, a, b []string) []Segment {
+	if len(a)*len(b) > maxCells {
+		segments = appendTokens(segments, Delete, a)
+		return appendTokens(segments, Insert, b)
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			segments = appendTokens(segments, Equal, a[i:i+1])
+			i++
+			j++
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			segments = appendTokens(segments, Delete, a[i:i+1])
+			i++
+		default:
+			segments = appendTokens(segments, Insert, b[j:j+1])
+			j++
+		}
+	}
+	segments = appendTokens(segments, Delete, a[i:])
+	return appendTokens(segments, Insert, b[j:])
+}
+
+// appendTokens adds tokens to segments, merging with the last segment if it has the same Op
+func appendTokens(segments []Segment, op Op, tokens []string) []Segment {
+	for _, t := range tokens {
+		if n := len(segments); n > 0 && segments[n-1].Op == op {
+			segments[n-1].Text += t
+		} else {
+			segments = append(segments, Segment{Op: op, Text: t})
+		}
+	}
+	return segments
+}
+
+// tokenize splits text into words, runs of whitespace and single other characters
+func tokenize(text string) []string {
+	var tokens []string
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		n := size
+		switch {
+		case isWord(r):
+			n = spanOf(text, isWord)
+		case unicode.IsSpace(r):
+			n = spanOf(text, unicode.IsSpace)
+		}
+		tokens = append(tokens, text[:n])
+		text = text[n:]
+	}
+	return tokens
+}
+
+// spanOf returns the byte length of text's leading runes matching fn
+func spanOf(text string, fn func(rune) bool) int {
+	for i, r := range text {
+		if !fn(r) {
+			return i
+		}
+	}
+	return len(text)
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_' || r == '\''
+}
+
+// Rounds diffs each round's answer (round -> answer) against the previous round with an answer
+// The first answered round has no diff; empty answers (e.g. failed rounds) are skipped.
+func Rounds(answers map[int]string) map[int][]Segment {
+	rounds := make([]int, 0, len(answers))
+	for r, answer := range answers {
+		if answer != "" {
+			rounds = append(rounds, r)
+		}
+	}
+	slices.Sort(rounds)
+
+	diffs := make(map[int][]Segment, len(rounds))
+	for i := 1; i < len(rounds); i++ {
+		diffs[rounds[i]] = Words(answers[rounds[i-1]], answers[rounds[i]])
+	}
+	return diffs
+}
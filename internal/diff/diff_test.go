@@ -0,0 +1,101 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// reconstruct rebuilds the text before and after from segments
+func reconstruct(segments []Segment) (string, string) {
+	var before, after strings.Builder
+	for _, s := range segments {
+		if s.Op != Insert {
+			before.WriteString(s.Text)
+		}
+		if s.Op != Delete {
+			after.WriteString(s.Text)
+		}
+	}
+	return before.String(), after.String()
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          []Segment
+	}{
+		{"identical", "Paris is the capital.", "Paris is the capital.", []Segment{{Equal, "Paris is the capital."}}},
+		{"both empty", "", "", nil},
+		{"replaced word", "The answer is 42.", "The answer is 43.", []Segment{
+			{Equal, "The answer is "}, {Delete, "42"}, {Insert, "43"}, {Equal, "."},
+		}},
+		{"inserted words", "Use a map.", "Use a sorted map.", []Segment{
+			{Equal, "Use a "}, {Insert, "sorted "}, {Equal, "map."},
+		}},
+		{"removed sentence", "Yes. Probably not. Done", "Yes. Done", []Segment{
+			{Equal, "Yes. "}, {Delete, "Probably not. "}, {Equal, "Done"},
+		}},
+		{"from empty", "", "New answer", []Segment{{Insert, "New answer"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Words(tt.before, tt.after)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Words(%q, %q) = %v, want %v", tt.before, tt.after, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Words(%q, %q) = %v, want %v", tt.before, tt.after, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestWordsReconstructs(t *testing.T) {
+	before := "Rust's borrow checker prevents data races at compile time.\n\n- ownership\n- lifetimes"
+	after := "The borrow checker prevents most data races at compile time, without a GC.\n\n- ownership\n- borrowing\n- lifetimes"
+
+	segments := Words(before, after)
+	gotBefore, gotAfter := reconstruct(segments)
+	if gotBefore != before || gotAfter != after {
+		t.Errorf("Segments don't reconstruct the inputs:\n%q\n%q", gotBefore, gotAfter)
+	}
+	if !Changed(segments) {
+		t.Error("Expected Changed to report the edits")
+	}
+	if Changed(Words(before, before)) {
+		t.Error("Expected no changes for identical texts")
+	}
+}
+
+func TestWordsLargeFallback(t *testing.T) {
+	before := strings.Repeat("alpha ", 3000)
+	after := strings.Repeat("beta ", 3000)
+
+	segments := Words(before, after)
+	gotBefore, gotAfter := reconstruct(segments)
+	if gotBefore != before || gotAfter != after {
+		t.Error("Expected the fallback to still reconstruct the inputs")
+	}
+}
+
+func TestRounds(t *testing.T) {
+	diffs := Rounds(map[int]string{1: "a b", 2: "", 3: "a c", 4: "a c"})
+
+	if _, ok := diffs[1]; ok {
+		t.Error("Expected no diff for the first round")
+	}
+	if _, ok := diffs[2]; ok {
+		t.Error("Expected no diff for a round without an answer")
+	}
+	if before, after := reconstruct(diffs[3]); before != "a b" || after != "a c" {
+		t.Errorf("Expected round 3 to be diffed against round 1, got %q -> %q", before, after)
+	}
+	if Changed(diffs[4]) {
+		t.Errorf("Expected no changes in round 4, got %v", diffs[4])
+	}
+}
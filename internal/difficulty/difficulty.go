@@ -0,0 +1,106 @@
+// Package difficulty estimates how hard a question was from how much the judges
+// disagreed and how far the final answers diverged.
+package difficulty
+
+import "github.com/meedamian/fat/internal/similarity"
+
+// Estimate is a question's difficulty and the signals it was derived from, all in [0, 1]
+type Estimate struct {
+	JudgeDisagreement float64 `json:"judge_disagreement"` // Mean pairwise Kendall tau distance between judge rankings
+	AnswerDivergence  float64 `json:"answer_divergence"`  // 1 - mean pairwise Jaccard similarity of answer vocabularies
+	Score             float64 `json:"score"`              // Mean of both signals
+}
+
+// Compute estimates difficulty from each judge's ranking (best first) and the final answers
+// A signal with too little data to measure contributes nothing; if neither can be measured the score is 0
+func Compute(rankings [][]string, answers []string) Estimate {
+	var e Estimate
+	signals := 0
+
+	if d, ok := JudgeDisagreement(rankings); ok {
+		e.JudgeDisagreement = d
+		e.Score += d
+		signals++
+	}
+	if d, ok := answerDivergence(answers); ok {
+		e.AnswerDivergence = d
+		e.Score += d
+		signals++
+	}
+	if signals > 0 {
+		e.Score /= float64(signals)
+	}
+
+	return e
+}
+
+// JudgeDisagreement averages the normalized Kendall tau distance over every pair of judges
+// ok is false with fewer than two rankings sharing at least two models
+func JudgeDisagreement(rankings [][]string) (float64, bool) {
+	var total float64
+	pairs := 0
+	for i := range rankings {
+		for j := i + 1; j < len(rankings); j++ {
+			if d, ok := KendallDistance(rankings[i], rankings[j]); ok {
+				total += d
+				pairs++
+			}
+		}
+	}
+	if pairs == 0 {
+		return 0, false
+	}
+	return total / float64(pairs), true
+}
+
+// KendallDistance is the fraction of discordant pairs among the models both rankings contain
+func KendallDistance(a, b []string) (float64, bool) {
+	posB := make(map[string]int, len(b))
+	for i, m := range b {
+		posB[m] = i
+	}
+
+	var common []int // positions in b, in a's order
+	for _, m := range a {
+		if p, ok := posB[m]; ok {
+			common = append(common, p)
+		}
+	}
+	if len(common) < 2 {
+		return 0, false
+	}
+
+	discordant := 0
+	for i := range common {
+		for j := i + 1; j < len(common); j++ {
+			if common[i] > common[j] {
+				discordant++
+			}
+		}
+	}
+	n := len(common)
+	return float64(discordant) / float64(n*(n-1)/2), true
+}
+
+// answerDivergence is one minus the mean pairwise Jaccard similarity of the answers' word sets
+func answerDivergence(answers []string) (float64, bool) {
+	var sets []map[string]bool
+	for _, a := range answers {
+		if words := similarity.WordSet(a); len(words) > 0 {
+			sets = append(sets, words)
+		}
+	}
+
+	var total float64
+	pairs := 0
+	for i := range sets {
+		for j := i + 1; j < len(sets); j++ {
+			total += 1 - similarity.Jaccard(sets[i], sets[j])
+			pairs++
+		}
+	}
+	if pairs == 0 {
+		return 0, false
+	}
+	return total / float64(pairs), true
+}
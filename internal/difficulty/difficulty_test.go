@@ -0,0 +1,57 @@
+package difficulty
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKendallDistance(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   []string
+		want   float64
+		wantOK bool
+	}{
+		{"identical", []string{"a", "b", "c"}, []string{"a", "b", "c"}, 0, true},
+		{"reversed", []string{"a", "b", "c"}, []string{"c", "b", "a"}, 1, true},
+		{"one swap", []string{"a", "b", "c"}, []string{"b", "a", "c"}, 1.0 / 3, true},
+		{"partial overlap", []string{"a", "b", "x"}, []string{"b", "a", "y"}, 1, true},
+		{"single common", []string{"a", "x"}, []string{"a", "y"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := KendallDistance(tt.a, tt.b)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("kendallDistance = (%f, %v), want (%f, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	easy := Compute(
+		[][]string{{"a", "b", "c"}, {"a", "b", "c"}},
+		[]string{"The answer is Paris.", "the answer is paris"},
+	)
+	if easy.Score != 0 {
+		t.Errorf("Expected unanimous judges and identical answers to score 0, got %+v", easy)
+	}
+
+	hard := Compute(
+		[][]string{{"a", "b", "c"}, {"c", "b", "a"}},
+		[]string{"Paris", "Lyon"},
+	)
+	if hard.Score != 1 {
+		t.Errorf("Expected opposed judges and disjoint answers to score 1, got %+v", hard)
+	}
+
+	judgesOnly := Compute([][]string{{"a", "b"}, {"b", "a"}}, []string{"only one answer"})
+	if judgesOnly.Score != 1 || judgesOnly.AnswerDivergence != 0 {
+		t.Errorf("Expected unmeasurable divergence to be ignored, got %+v", judgesOnly)
+	}
+
+	if empty := Compute(nil, nil); empty.Score != 0 {
+		t.Errorf("Expected no data to score 0, got %+v", empty)
+	}
+}
@@ -0,0 +1,158 @@
+// Package dpoexport turns ranked requests into preference pairs for preference tuning.
+// Each record pairs a better-ranked final answer (chosen) with a worse one (rejected) for
+// the same question, in the prompt/chosen/rejected JSONL layout DPO trainers expect.
+package dpoexport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+// Record is one preference pair
+type Record struct {
+	Prompt   string `json:"prompt"`
+	Chosen   string `json:"chosen"`
+	Rejected string `json:"rejected"`
+
+	// Provenance, ignored by trainers that only read the three fields above
+	RequestID     string  `json:"request_id"`
+	ChosenModel   string  `json:"chosen_model"`
+	RejectedModel string  `json:"rejected_model"`
+	Margin        float64 `json:"margin"` // Difference in mean normalized Borda score, in (0, 1]
+}
+
+// Build derives the preference pairs of one request from its stored judge rankings
+// Models are ordered by mean normalized Borda score across judges; every strictly
+// better/worse pair of non-empty final answers becomes a record, ties produce none.
+func Build(ctx context.Context, database *db.DB, req db.Request) ([]Record, error) {
+	rankings, err := database.GetRequestRankings(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := bordaScores(rankings)
+	if len(scores) < 2 {
+		return nil, nil
+	}
+
+	answers, err := finalAnswers(ctx, database, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		model  string
+		score  float64
+		answer string
+	}
+	var candidates []candidate
+	for model, score := range scores {
+		if answer := answers[model]; answer != "" {
+			candidates = append(candidates, candidate{model: model, score: score, answer: answer})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].model < candidates[j].model
+	})
+
+	var records []Record
+	for i, chosen := range candidates {
+		for _, rejected := range candidates[i+1:] {
+			margin := chosen.score - rejected.score
+			if margin <= 0 || chosen.answer == rejected.answer {
+				continue
+			}
+			records = append(records, Record{
+				Prompt:        req.Question,
+				Chosen:        chosen.answer,
+				Rejected:      rejected.answer,
+				RequestID:     req.ID,
+				ChosenModel:   chosen.model,
+				RejectedModel: rejected.model,
+				Margin:        margin,
+			})
+		}
+	}
+
+	return records, nil
+}
+
+// Write streams the preference pairs of every request (or only those with the given tag) as JSONL
+// Returns the number of records written
+func Write(ctx context.Context, database *db.DB, tag string, w io.Writer) (int, error) {
+	requests, err := database.GetRequests(ctx, tag)
+	if err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	written := 0
+	for _, req := range requests {
+		records, err := Build(ctx, database, req)
+		if err != nil {
+			return written, err
+		}
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return written, err
+			}
+			written++
+		}
+	}
+
+	return written, nil
+}
+
+// bordaScores averages each model's normalized Borda score over the judges that ranked it
+// A model ranked first scores 1 and last scores 0, regardless of how many models were ranked
+func bordaScores(rankings []db.Ranking) map[string]float64 {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, r := range rankings {
+		var ranked []string
+		if err := json.Unmarshal([]byte(r.RankedModels), &ranked); err != nil || len(ranked) < 2 {
+			continue
+		}
+		last := float64(len(ranked) - 1)
+		for pos, model := range ranked {
+			sums[model] += 1 - float64(pos)/last
+			counts[model]++
+		}
+	}
+
+	scores := make(map[string]float64, len(sums))
+	for model, sum := range sums {
+		scores[model] = sum / float64(counts[model])
+	}
+	return scores
+}
+
+// finalAnswers maps each model variant to the answer from the last round it answered in
+func finalAnswers(ctx context.Context, database *db.DB, requestID string) (map[string]string, error) {
+	rounds, err := database.GetRoundReplies(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	answers := make(map[string]string)
+	for _, byRound := range rounds {
+		latest := 0
+		for round, mr := range byRound {
+			if round > latest && strings.TrimSpace(mr.Answer) != "" {
+				latest = round
+				answers[mr.ModelName] = strings.TrimSpace(mr.Answer)
+			}
+		}
+	}
+	return answers, nil
+}
@@ -0,0 +1,103 @@
+package dpoexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+func TestBuildAndWrite(t *testing.T) {
+	dbPath := "test_dpo.db"
+	defer os.Remove(dbPath)
+
+	database, err := db.New(dbPath, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	req := db.Request{ID: "req-1", Question: "Q?", NumRounds: 2, NumModels: 3, WinnerModel: "grok", Tag: "math"}
+	if err := database.SaveRequest(ctx, req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	if err := database.SaveRequest(ctx, db.Request{ID: "req-2", Question: "Other?", NumRounds: 1, NumModels: 1}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	rounds := []db.ModelRound{
+		{RequestID: "req-1", ModelID: "grok", ModelName: "grok-4", Round: 1, Answer: "G1"},
+		{RequestID: "req-1", ModelID: "grok", ModelName: "grok-4", Round: 2, Answer: "G2"},
+		{RequestID: "req-1", ModelID: "gpt", ModelName: "gpt-5", Round: 1, Answer: "P1"},
+		{RequestID: "req-1", ModelID: "gpt", ModelName: "gpt-5", Round: 2, Error: "timeout"},
+		{RequestID: "req-1", ModelID: "claude", ModelName: "claude-opus-4-6", Round: 2, Answer: "C2"},
+	}
+	for _, mr := range rounds {
+		if err := database.SaveModelRound(ctx, mr); err != nil {
+			t.Fatalf("Failed to save model round: %v", err)
+		}
+	}
+
+	// grok is ranked first by both judges; gpt and claude split the remaining places evenly
+	rankings := []string{
+		`["grok-4","gpt-5","claude-opus-4-6"]`,
+		`["grok-4","claude-opus-4-6","gpt-5"]`,
+	}
+	for _, ranked := range rankings {
+		if err := database.SaveRanking(ctx, db.Ranking{RequestID: "req-1", RankerModel: "judge", RankedModels: ranked}); err != nil {
+			t.Fatalf("Failed to save ranking: %v", err)
+		}
+	}
+
+	records, err := Build(ctx, database, req)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 pairs (tie produces none), got %d: %+v", len(records), records)
+	}
+	for _, r := range records {
+		if r.Prompt != "Q?" || r.ChosenModel != "grok-4" || r.Chosen != "G2" {
+			t.Errorf("Expected grok's final answer chosen, got %+v", r)
+		}
+		if r.Margin != 0.75 {
+			t.Errorf("Expected margin 0.75, got %f", r.Margin)
+		}
+	}
+	if records[0].RejectedModel != "claude-opus-4-6" || records[1].Rejected != "P1" {
+		t.Errorf("Expected rejected answers ordered by model, using the last answered round: %+v", records)
+	}
+
+	var buf bytes.Buffer
+	written, err := Write(ctx, database, "math", &buf)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if written != 2 || len(lines) != 2 {
+		t.Fatalf("Expected 2 JSONL lines, got %d (written %d)", len(lines), written)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &decoded); err != nil {
+		t.Fatalf("Invalid JSONL line: %v", err)
+	}
+	for _, key := range []string{"prompt", "chosen", "rejected"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("Expected %q field in record", key)
+		}
+	}
+
+	buf.Reset()
+	if written, err := Write(ctx, database, "other", &buf); err != nil || written != 0 {
+		t.Errorf("Expected no pairs for an unknown tag, got %d (err %v)", written, err)
+	}
+}
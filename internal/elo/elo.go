@@ -0,0 +1,114 @@
+// Package elo rates model variants against each other across requests.
+// Each request is treated as a round-robin between its participants, with pairwise
+// outcomes taken from the aggregated Borda scores, so beating strong models counts for more.
+package elo
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+// InitialRating is assigned to a variant the first time it is rated
+const InitialRating = 1500.0
+
+// K is the most a variant's rating can move in one request
+const K = 32.0
+
+// Participant is a model variant's result in one request
+type Participant struct {
+	ModelID   string
+	ModelName string
+	Score     int // Aggregated Borda score; higher beats lower, equal is a draw
+}
+
+// Expected is the probability that a player rated a beats one rated b
+func Expected(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// Update applies one request's pairwise outcomes to ratings, keyed by variant name
+// Variants without a rating start at InitialRating. Every pair is scored against the ratings
+// from before the request, and K is split across a variant's opponents so a request
+// moves a rating by at most K regardless of how many models took part.
+func Update(ratings map[string]db.EloRating, participants []Participant, k float64) map[string]db.EloRating {
+	updated := make(map[string]db.EloRating, len(participants))
+	if len(participants) < 2 {
+		return updated
+	}
+
+	before := make(map[string]float64, len(participants))
+	for _, p := range participants {
+		r, ok := ratings[p.ModelName]
+		if !ok {
+			r = db.EloRating{ModelName: p.ModelName, Rating: InitialRating}
+		}
+		r.ModelID = p.ModelID
+		r.Games++
+		before[p.ModelName] = r.Rating
+		updated[p.ModelName] = r
+	}
+
+	perOpponent := k / float64(len(participants)-1)
+	for i, a := range participants {
+		for _, b := range participants[i+1:] {
+			outcome := 0.5
+			switch {
+			case a.Score > b.Score:
+				outcome = 1
+			case a.Score < b.Score:
+				outcome = 0
+			}
+
+			delta := perOpponent * (outcome - Expected(before[a.ModelName], before[b.ModelName]))
+
+			ra, rb := updated[a.ModelName], updated[b.ModelName]
+			ra.Rating += delta
+			rb.Rating -= delta
+			switch outcome {
+			case 1:
+				ra.Wins++
+				rb.Losses++
+			case 0:
+				ra.Losses++
+				rb.Wins++
+			default:
+				ra.Draws++
+				rb.Draws++
+			}
+			updated[a.ModelName], updated[b.ModelName] = ra, rb
+		}
+	}
+
+	return updated
+}
+
+// Record updates and stores the ratings of a finished request's participants
+func Record(ctx context.Context, database *db.DB, participants []Participant) error {
+	if len(participants) < 2 {
+		return nil
+	}
+
+	stored, err := database.GetEloRatings(ctx)
+	if err != nil {
+		return err
+	}
+
+	ratings := make(map[string]db.EloRating, len(stored))
+	for _, r := range stored {
+		ratings[r.ModelName] = r
+	}
+
+	updated := Update(ratings, participants, K)
+	changed := make([]db.EloRating, 0, len(updated))
+	for _, r := range updated {
+		changed = append(changed, r)
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].ModelName < changed[j].ModelName
+	})
+
+	return database.SaveEloRatings(ctx, changed)
+}
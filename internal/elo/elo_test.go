@@ -0,0 +1,114 @@
+package elo
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+func TestExpected(t *testing.T) {
+	if got := Expected(1500, 1500); got != 0.5 {
+		t.Errorf("Expected between equals = %f, want 0.5", got)
+	}
+	if got := Expected(1900, 1500); math.Abs(got-0.909) > 0.001 {
+		t.Errorf("Expected for +400 = %f, want ~0.909", got)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	participants := []Participant{
+		{ModelID: "grok", ModelName: "grok-4", Score: 9},
+		{ModelID: "gpt", ModelName: "gpt-5", Score: 6},
+		{ModelID: "claude", ModelName: "claude-opus-4-6", Score: 6},
+	}
+
+	updated := Update(map[string]db.EloRating{}, participants, K)
+
+	grok, gpt, claude := updated["grok-4"], updated["gpt-5"], updated["claude-opus-4-6"]
+
+	// From equal ratings the winner gains K/2 per opponent beaten, split over two opponents
+	if math.Abs(grok.Rating-(InitialRating+K/2)) > 1e-9 {
+		t.Errorf("winner rating = %f, want %f", grok.Rating, InitialRating+K/2)
+	}
+	if gpt.Rating != claude.Rating {
+		t.Errorf("drawn models should move equally, got %f and %f", gpt.Rating, claude.Rating)
+	}
+
+	total := grok.Rating + gpt.Rating + claude.Rating
+	if math.Abs(total-3*InitialRating) > 1e-9 {
+		t.Errorf("ratings should be zero-sum, total %f", total)
+	}
+
+	if grok.Wins != 2 || grok.Losses != 0 || gpt.Draws != 1 || gpt.Losses != 1 {
+		t.Errorf("unexpected records: grok %+v, gpt %+v", grok, gpt)
+	}
+	if grok.Games != 1 || grok.ModelID != "grok" {
+		t.Errorf("unexpected games/model ID: %+v", grok)
+	}
+}
+
+func TestUpdateRewardsUpsets(t *testing.T) {
+	ratings := map[string]db.EloRating{
+		"strong": {ModelName: "strong", Rating: 1800},
+		"weak":   {ModelName: "weak", Rating: 1400},
+	}
+
+	expected := Update(ratings, []Participant{{ModelName: "strong", Score: 2}, {ModelName: "weak", Score: 1}}, K)
+	upset := Update(ratings, []Participant{{ModelName: "strong", Score: 1}, {ModelName: "weak", Score: 2}}, K)
+
+	gainExpected := expected["strong"].Rating - 1800
+	gainUpset := upset["weak"].Rating - 1400
+	if gainUpset <= gainExpected {
+		t.Errorf("beating a stronger model should gain more: upset %f, expected win %f", gainUpset, gainExpected)
+	}
+}
+
+func TestUpdateNeedsTwoParticipants(t *testing.T) {
+	if got := Update(nil, []Participant{{ModelName: "solo", Score: 1}}, K); len(got) != 0 {
+		t.Errorf("a single participant should not be rated, got %v", got)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	dbPath := "test_elo.db"
+	defer os.Remove(dbPath)
+
+	database, err := db.New(dbPath, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	participants := []Participant{
+		{ModelID: "grok", ModelName: "grok-4", Score: 2},
+		{ModelID: "gpt", ModelName: "gpt-5", Score: 1},
+	}
+
+	for range 2 {
+		if err := Record(ctx, database, participants); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	ratings, err := database.GetEloRatings(ctx)
+	if err != nil {
+		t.Fatalf("GetEloRatings failed: %v", err)
+	}
+	if len(ratings) != 2 {
+		t.Fatalf("expected 2 ratings, got %d", len(ratings))
+	}
+
+	top := ratings[0]
+	if top.ModelName != "grok-4" || top.Games != 2 || top.Wins != 2 {
+		t.Errorf("unexpected top rating: %+v", top)
+	}
+	// The second win against a now weaker opponent is worth less than the first
+	if gain := top.Rating - InitialRating; gain <= K/2 || gain >= K {
+		t.Errorf("unexpected rating gain after two wins: %f", gain)
+	}
+}
@@ -0,0 +1,176 @@
+// Package embed renders minimal, self-contained views of arena results for other sites to put in an iframe,
+// and the oEmbed responses that let blogs and wikis embed them from a plain link.
+package embed
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/meedamian/fat/internal/jsonexport"
+	"github.com/meedamian/fat/internal/locale"
+	"github.com/meedamian/fat/internal/stats"
+)
+
+// Embed sizes in pixels, used unless the consumer asks for smaller
+const (
+	Width             = 600
+	RequestHeight     = 480
+	LeaderboardHeight = 420
+)
+
+// LeaderboardSize is how many models the leaderboard embed lists
+const LeaderboardSize = 10
+
+// ProviderName identifies fat in oEmbed responses
+const ProviderName = "fat"
+
+// ErrUnsupportedURL is returned for oEmbed URLs that don't point at anything embeddable
+var ErrUnsupportedURL = errors.New("URL is not an embeddable request or leaderboard")
+
+// Paths recognized by ParseURL: the embeds themselves, the answers site's request pages and the leaderboard API
+var (
+	requestPath     = regexp.MustCompile(`^/(?:embed/requests/([A-Za-z0-9_-]+)|h/q/([A-Za-z0-9_-]+)\.html)$`)
+	leaderboardPath = regexp.MustCompile(`^/(?:embed/)?leaderboard$`)
+)
+
+// RequestPath is where a request's embed is served
+func RequestPath(requestID string) string {
+	return "/embed/requests/" + requestID
+}
+
+// LeaderboardPath is where the leaderboard embed is served
+const LeaderboardPath = "/embed/leaderboard"
+
+// ranked is a model's place in the request embed
+type ranked struct {
+	Place     int
+	ModelName string
+	Score     int
+}
+
+// RenderRequest writes a request's embed: the question, the final ranking and the winning answer
+// pageURL links the request's full page, opened outside the iframe.
+func RenderRequest(w io.Writer, doc *jsonexport.Document, pageURL string, f locale.Format) error {
+	names := make(map[string]string, len(doc.Models))
+	answer := ""
+	for _, m := range doc.Models {
+		names[m.ModelID] = m.ModelName
+		if m.ModelID == doc.Request.WinnerModel && len(m.Rounds) > 0 {
+			answer = m.Rounds[len(m.Rounds)-1].Answer
+		}
+	}
+
+	ranking := make([]ranked, 0, len(doc.FinalRanking))
+	for _, p := range doc.FinalRanking {
+		name := names[p.Model]
+		if name == "" {
+			name = p.Model
+		}
+		ranking = append(ranking, ranked{Place: p.Place, ModelName: name, Score: p.Score})
+	}
+
+	winner := names[doc.Request.WinnerModel]
+	if winner == "" {
+		winner = "no winner"
+	}
+
+	return requestTemplate.Execute(w, map[string]any{
+		"Question": doc.Request.Question,
+		"Winner":   winner,
+		"Ranking":  ranking,
+		"Answer":   answer,
+		"Date":     f.In(doc.Request.CreatedAt).Format("2006-01-02"),
+		"Cost":     f.Cost(doc.Costs.Total),
+		"Page":     pageURL,
+	})
+}
+
+// RenderLeaderboard writes the leaderboard embed, best first, up to LeaderboardSize models
+func RenderLeaderboard(w io.Writer, entries []stats.LeaderboardEntry, pageURL string) error {
+	if len(entries) > LeaderboardSize {
+		entries = entries[:LeaderboardSize]
+	}
+	return leaderboardTemplate.Execute(w, map[string]any{
+		"Entries": entries,
+		"Page":    pageURL,
+	})
+}
+
+// Response is an oEmbed response of the "rich" type
+type Response struct {
+	Version      string `json:"version"`
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// Target is what an oEmbed URL points at
+type Target struct {
+	Base      string // Scheme and host the consumer reached fat at, which the iframe is loaded from too
+	RequestID string // Empty for the leaderboard
+}
+
+// ParseURL reads the target of an oEmbed URL
+func ParseURL(raw string) (Target, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Target{}, ErrUnsupportedURL
+	}
+
+	t := Target{Base: u.Scheme + "://" + u.Host}
+	if m := requestPath.FindStringSubmatch(u.Path); m != nil {
+		t.RequestID = m[1] + m[2]
+		return t, nil
+	}
+	if leaderboardPath.MatchString(u.Path) {
+		return t, nil
+	}
+	return Target{}, ErrUnsupportedURL
+}
+
+// Respond builds the oEmbed response for a target, fitting the iframe within maxWidth and maxHeight when given
+func Respond(t Target, title string, maxWidth, maxHeight int) Response {
+	src, height := t.Base+LeaderboardPath, LeaderboardHeight
+	if t.RequestID != "" {
+		src, height = t.Base+RequestPath(t.RequestID), RequestHeight
+	}
+	width := Width
+	if maxWidth > 0 {
+		width = min(width, maxWidth)
+	}
+	if maxHeight > 0 {
+		height = min(height, maxHeight)
+	}
+
+	html := fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" title="%s" style="border:0" loading="lazy"></iframe>`,
+		template.HTMLEscapeString(src), width, height, template.HTMLEscapeString(title))
+
+	return Response{
+		Version:      "1.0",
+		Type:         "rich",
+		Title:        title,
+		ProviderName: ProviderName,
+		ProviderURL:  t.Base + "/",
+		HTML:         html,
+		Width:        width,
+		Height:       height,
+	}
+}
+
+// Title is a request's oEmbed title: its question, shortened to one line
+func Title(question string) string {
+	title := strings.Join(strings.Fields(question), " ")
+	if r := []rune(title); len(r) > 100 {
+		title = string(r[:100]) + "…"
+	}
+	return title
+}
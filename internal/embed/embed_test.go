@@ -0,0 +1,88 @@
+package embed
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/jsonexport"
+	"github.com/meedamian/fat/internal/locale"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/stats"
+)
+
+func TestRenderRequest(t *testing.T) {
+	doc := &jsonexport.Document{
+		Request: jsonexport.Request{ID: "req-1", Question: "Why is the sky <blue>?", WinnerModel: "grok"},
+		Models: []jsonexport.Model{
+			{ModelID: "gpt", ModelName: "gpt-5", Rounds: []jsonexport.Round{{Round: 1, Answer: "Scattering."}}},
+			{ModelID: "grok", ModelName: "grok-4", Rounds: []jsonexport.Round{{Round: 1, Answer: "Rayleigh scattering."}}},
+		},
+		FinalRanking: []shared.Placement{{Model: "grok", Place: 1, Score: 4}, {Model: "gpt", Place: 2, Score: 2}},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderRequest(&buf, doc, "/h/q/req-1.html", locale.Format{}); err != nil {
+		t.Fatalf("RenderRequest failed: %v", err)
+	}
+	page := buf.String()
+	for _, want := range []string{"Why is the sky &lt;blue&gt;?", "🏆 grok-4 · 4", "#2 gpt-5 · 2", "Rayleigh scattering.", `href="/h/q/req-1.html"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected the embed to contain %q, got:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, ">Scattering.") {
+		t.Error("Expected only the winning answer")
+	}
+}
+
+func TestRenderLeaderboard(t *testing.T) {
+	entries := make([]stats.LeaderboardEntry, LeaderboardSize+2)
+	for i := range entries {
+		entries[i] = stats.LeaderboardEntry{ModelName: "model", Runs: 10}
+	}
+	entries[0] = stats.LeaderboardEntry{ModelName: "grok-4", Runs: 20, WeightedWinRate: 0.55, WeightedWinRateCI: stats.Interval{Low: 0.34, High: 0.74}}
+
+	var buf bytes.Buffer
+	if err := RenderLeaderboard(&buf, entries, "/"); err != nil {
+		t.Fatalf("RenderLeaderboard failed: %v", err)
+	}
+	page := buf.String()
+	if !strings.Contains(page, "grok-4") || !strings.Contains(page, "55%") || !strings.Contains(page, "34–74%") {
+		t.Errorf("Expected the leader's win rate and interval, got:\n%s", page)
+	}
+	if rows := strings.Count(page, "<tr>"); rows != LeaderboardSize+1 {
+		t.Errorf("Expected a header and %d rows, got %d", LeaderboardSize, rows-1)
+	}
+}
+
+func TestOEmbed(t *testing.T) {
+	tests := map[string]Target{
+		"https://fat.example.com/embed/requests/req-1": {Base: "https://fat.example.com", RequestID: "req-1"},
+		"http://localhost:4444/h/q/req-1.html":         {Base: "http://localhost:4444", RequestID: "req-1"},
+		"https://fat.example.com/leaderboard":          {Base: "https://fat.example.com"},
+		"https://fat.example.com/embed/leaderboard":    {Base: "https://fat.example.com"},
+	}
+	for raw, want := range tests {
+		got, err := ParseURL(raw)
+		if err != nil || got != want {
+			t.Errorf("ParseURL(%q) = %+v, %v, expected %+v", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"https://fat.example.com/", "https://fat.example.com/h/q/../x.html", "ftp://fat.example.com/leaderboard", "/leaderboard"} {
+		if _, err := ParseURL(raw); err != ErrUnsupportedURL {
+			t.Errorf("Expected ParseURL(%q) to be unsupported, got %v", raw, err)
+		}
+	}
+
+	r := Respond(Target{Base: "https://fat.example.com", RequestID: "req-1"}, Title("Why is\nthe sky \"blue\"?"), 400, 0)
+	if r.Version != "1.0" || r.Type != "rich" || r.Width != 400 || r.Height != RequestHeight {
+		t.Errorf("Expected a rich response fitted to the max width, got %+v", r)
+	}
+	if r.Title != `Why is the sky "blue"?` {
+		t.Errorf("Expected the question on one line, got %q", r.Title)
+	}
+	if want := `<iframe src="https://fat.example.com/embed/requests/req-1" width="400" height="480" title="Why is the sky &#34;blue&#34;?"`; !strings.HasPrefix(r.HTML, want) {
+		t.Errorf("Expected the iframe to start with %s, got %s", want, r.HTML)
+	}
+}
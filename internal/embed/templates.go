@@ -0,0 +1,95 @@
+package embed
+
+import "html/template"
+
+// style is shared by every embed; it follows the embedding page's light or dark preference
+const style = `
+        :root { --bg: #ffffff; --text: #18181b; --muted: #71717a; --accent: #6d4aff; --gold: #d97706; --line: rgba(0,0,0,0.08); }
+        @media (prefers-color-scheme: dark) {
+            :root { --bg: #0a0a0f; --text: #e4e4e7; --muted: #a1a1aa; --accent: #7c5cff; --gold: #fbbf24; --line: rgba(255,255,255,0.1); }
+        }
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        html, body { height: 100%; }
+        body { background: var(--bg); color: var(--text); font: 14px/1.5 system-ui, sans-serif; padding: 16px; display: flex; flex-direction: column; gap: 10px; overflow: hidden; }
+        a { color: var(--accent); }
+        .question { font-weight: 600; font-size: 1.1em; display: -webkit-box; -webkit-line-clamp: 3; -webkit-box-orient: vertical; overflow: hidden; }
+        .ranking { list-style: none; display: flex; flex-wrap: wrap; gap: 6px; }
+        .ranking li { border: 1px solid var(--line); border-radius: 999px; padding: 2px 10px; color: var(--muted); }
+        .ranking li.first { border-color: var(--gold); color: var(--text); }
+        .answer { flex: 1; min-height: 0; overflow-y: auto; white-space: pre-wrap; border-left: 3px solid var(--gold); padding-left: 12px; }
+        table { width: 100%; border-collapse: collapse; }
+        th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid var(--line); }
+        th { color: var(--muted); font-weight: 500; }
+        td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; }
+        .empty { color: var(--muted); font-style: italic; }
+        .footer { color: var(--muted); font-size: 0.85em; display: flex; justify-content: space-between; gap: 12px; }
+`
+
+// funcs format the leaderboard's positions and rates
+var funcs = template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+	"pct": func(rate float64) float64 { return rate * 100 },
+}
+
+var requestTemplate = template.Must(template.New("request").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Question}}</title>
+    <style>` + style + `    </style>
+</head>
+<body>
+    <div class="question">{{.Question}}</div>
+{{- if .Ranking}}
+    <ol class="ranking">
+    {{- range .Ranking}}
+        <li{{if eq .Place 1}} class="first"{{end}}>{{if eq .Place 1}}🏆 {{else}}#{{.Place}} {{end}}{{.ModelName}} · {{.Score}}</li>
+    {{- end}}
+    </ol>
+{{- end}}
+{{- if .Answer}}
+    <div class="answer">{{.Answer}}</div>
+{{- else}}
+    <p class="empty">🏆 {{.Winner}}</p>
+{{- end}}
+    <div class="footer">
+        <span>{{.Date}} · {{.Cost}}</span>
+        <a href="{{.Page}}" target="_blank" rel="noopener">Full session on fat ↗</a>
+    </div>
+</body>
+</html>
+`))
+
+var leaderboardTemplate = template.Must(template.New("leaderboard").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>fat leaderboard</title>
+    <style>` + style + `    </style>
+</head>
+<body>
+{{- if not .Entries}}
+    <p class="empty">No ranked runs yet.</p>
+{{- else}}
+    <table>
+        <tr><th class="num">#</th><th>Model</th><th class="num">Win rate</th><th class="num">95% CI</th><th class="num">Runs</th></tr>
+    {{- range $i, $e := .Entries}}
+        <tr>
+            <td class="num">{{inc $i}}</td>
+            <td>{{$e.ModelName}}</td>
+            <td class="num">{{printf "%.0f%%" (pct $e.WeightedWinRate)}}</td>
+            <td class="num">{{printf "%.0f–%.0f%%" (pct $e.WeightedWinRateCI.Low) (pct $e.WeightedWinRateCI.High)}}</td>
+            <td class="num">{{$e.Runs}}</td>
+        </tr>
+    {{- end}}
+    </table>
+{{- end}}
+    <div class="footer">
+        <span>Ranked by difficulty-weighted win rate</span>
+        <a href="{{.Page}}" target="_blank" rel="noopener">fat ↗</a>
+    </div>
+</body>
+</html>
+`))
@@ -0,0 +1,253 @@
+// Package embeddings compares final answers by meaning rather than wording.
+// Answers are embedded through an OpenAI-compatible API and scored pairwise by cosine similarity,
+// showing whether the agents converged on one answer or the winner stands apart from the rest.
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meedamian/fat/internal/shared"
+)
+
+// Supported embedding APIs
+const (
+	OpenAI = "openai"
+	Local  = "local" // Any OpenAI-compatible /embeddings endpoint, e.g. Ollama or llama.cpp
+)
+
+const (
+	defaultEndpoint = "https://api.openai.com/v1/embeddings"
+	defaultModel    = "text-embedding-3-small"
+)
+
+// Client embeds texts with one API
+type Client struct {
+	provider string
+	endpoint string
+	model    string
+	apiKey   string
+	http     *http.Client
+}
+
+// New creates a client for provider; an empty provider disables embeddings and returns nil
+// endpoint overrides the API URL (required for local, the full URL of its embeddings endpoint).
+func New(provider, endpoint, model, apiKey string) (*Client, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return nil, nil
+	}
+
+	switch provider {
+	case OpenAI:
+		if apiKey == "" {
+			return nil, fmt.Errorf("%s needs an API key", provider)
+		}
+		if endpoint == "" {
+			endpoint = defaultEndpoint
+		}
+		if model == "" {
+			model = defaultModel
+		}
+	case Local:
+		if endpoint == "" {
+			return nil, fmt.Errorf("%s needs the embeddings endpoint URL", provider)
+		}
+		if model == "" {
+			return nil, fmt.Errorf("%s needs a model name", provider)
+		}
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q (use %s or %s)", provider, OpenAI, Local)
+	}
+
+	return &Client{
+		provider: provider,
+		endpoint: endpoint,
+		model:    model,
+		apiKey:   apiKey,
+		http:     shared.NewHTTPClient(60 * time.Second),
+	}, nil
+}
+
+// Provider returns the name of the embeddings API in use
+func (c *Client) Provider() string {
+	return c.provider
+}
+
+// Model returns the embedding model in use
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Embed returns one vector per text, in order
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(map[string]any{"model": c.model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s embeddings failed: %w", c.provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s embeddings failed: status %d: %s", c.provider, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var decoded struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("%s embeddings failed: %w", c.provider, err)
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, d := range decoded.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("%s embeddings failed: unexpected index %d", c.provider, d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("%s embeddings failed: no embedding for input %d", c.provider, i)
+		}
+	}
+	return vectors, nil
+}
+
+// Pair is the similarity of two models' answers; A sorts before B
+type Pair struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Similarity is how close a request's final answers are to each other
+type Similarity struct {
+	Provider string             `json:"provider,omitempty"`
+	Model    string             `json:"model,omitempty"`
+	Pairs    []Pair             `json:"pairs"`
+	Mean     map[string]float64 `json:"mean"`              // Each model's mean similarity to the others
+	Overall  float64            `json:"overall"`           // Mean over every pair
+	Outlier  string             `json:"outlier,omitempty"` // Model least similar to the others, when it stands apart
+}
+
+// outlierGap is how far below the next lowest a model's mean similarity must be to call it an outlier
+const outlierGap = 0.05
+
+// Compare embeds every model's answer and scores each pair; answers are keyed by model ID
+// Empty answers are left out; fewer than two answers leave nothing to compare.
+func (c *Client) Compare(ctx context.Context, answers map[string]string) (*Similarity, error) {
+	ids := make([]string, 0, len(answers))
+	for id, answer := range answers {
+		if strings.TrimSpace(answer) != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < 2 {
+		return nil, errors.New("fewer than two answers to compare")
+	}
+	sort.Strings(ids)
+
+	texts := make([]string, len(ids))
+	for i, id := range ids {
+		texts[i] = answers[id]
+	}
+	vectors, err := c.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make([]Pair, 0, len(ids)*(len(ids)-1)/2)
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			pairs = append(pairs, Pair{A: ids[i], B: ids[j], Similarity: Cosine(vectors[i], vectors[j])})
+		}
+	}
+
+	s := Summarize(pairs)
+	s.Provider, s.Model = c.provider, c.model
+	return s, nil
+}
+
+// Summarize derives per-model means, the overall mean and the outlier from pairwise similarities
+func Summarize(pairs []Pair) *Similarity {
+	s := &Similarity{Pairs: pairs, Mean: make(map[string]float64)}
+	if len(pairs) == 0 {
+		return s
+	}
+
+	counts := make(map[string]int)
+	for _, p := range pairs {
+		s.Mean[p.A] += p.Similarity
+		s.Mean[p.B] += p.Similarity
+		counts[p.A]++
+		counts[p.B]++
+		s.Overall += p.Similarity
+	}
+	s.Overall /= float64(len(pairs))
+	for id := range s.Mean {
+		s.Mean[id] /= float64(counts[id])
+	}
+
+	// With two answers neither is further from the other, so an outlier needs at least three
+	if len(s.Mean) < 3 {
+		return s
+	}
+	ids := make([]string, 0, len(s.Mean))
+	for id := range s.Mean {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if s.Mean[ids[i]] != s.Mean[ids[j]] {
+			return s.Mean[ids[i]] < s.Mean[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if s.Mean[ids[1]]-s.Mean[ids[0]] >= outlierGap {
+		s.Outlier = ids[0]
+	}
+	return s
+}
+
+// Cosine is the cosine similarity of two vectors, 0 if either is zero or their lengths differ
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
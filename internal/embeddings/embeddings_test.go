@@ -0,0 +1,133 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	if c, err := New("", "", "", ""); c != nil || err != nil {
+		t.Errorf("Expected no client and no error without a provider, got %v, %v", c, err)
+	}
+	if _, err := New("openai", "", "", ""); err == nil {
+		t.Error("Expected error for OpenAI without an API key")
+	}
+	if _, err := New("local", "", "nomic-embed-text", ""); err == nil {
+		t.Error("Expected error for local without a URL")
+	}
+	if _, err := New("local", "http://localhost:11434/v1/embeddings", "", ""); err == nil {
+		t.Error("Expected error for local without a model")
+	}
+	if _, err := New("cohere", "", "", "key"); err == nil {
+		t.Error("Expected error for an unknown provider")
+	}
+
+	c, err := New(" OpenAI ", "", "", "key")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if c.Provider() != OpenAI || c.endpoint != defaultEndpoint || c.Model() != defaultModel {
+		t.Errorf("Expected openai client with default endpoint and model, got %+v", c)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		{"identical", []float64{1, 2, 3}, []float64{1, 2, 3}, 1},
+		{"scaled", []float64{1, 2}, []float64{2, 4}, 1},
+		{"orthogonal", []float64{1, 0}, []float64{0, 1}, 0},
+		{"opposite", []float64{1, 0}, []float64{-1, 0}, -1},
+		{"zero vector", []float64{0, 0}, []float64{1, 1}, 0},
+		{"length mismatch", []float64{1}, []float64{1, 1}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cosine(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cosine(%v, %v) = %f, want %f", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	vectors := map[string][]float64{
+		"yes":        {1, 0, 0},
+		"yes indeed": {0.9, 0.1, 0},
+		"of course":  {0.95, 0, 0.05},
+		"no":         {0, 0, 1},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("Expected the API key as bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Model != "test-embed" {
+			t.Errorf("Unexpected request body %+v (%v)", body, err)
+		}
+
+		// Answer out of order, as the API doesn't promise to keep it
+		data := make([]map[string]any, 0, len(body.Input))
+		for i := len(body.Input) - 1; i >= 0; i-- {
+			data = append(data, map[string]any{"index": i, "embedding": vectors[body.Input[i]]})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer srv.Close()
+
+	c, err := New(OpenAI, srv.URL, "test-embed", "key")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := c.Compare(context.Background(), map[string]string{"grok": "yes", "gpt": " "}); err == nil {
+		t.Error("Expected error with a single non-empty answer")
+	}
+
+	s, err := c.Compare(context.Background(), map[string]string{
+		"grok":   "yes",
+		"gpt":    "yes indeed",
+		"claude": "of course",
+		"gemini": "no",
+	})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+
+	if len(s.Pairs) != 6 || s.Pairs[0].A != "claude" || s.Pairs[0].B != "gemini" {
+		t.Errorf("Expected 6 pairs sorted by model, got %+v", s.Pairs)
+	}
+	if s.Outlier != "gemini" {
+		t.Errorf("Expected gemini as the outlier, got %q (means %v)", s.Outlier, s.Mean)
+	}
+	if s.Provider != OpenAI || s.Model != "test-embed" {
+		t.Errorf("Expected provider and model to be recorded, got %q, %q", s.Provider, s.Model)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]Pair{{A: "a", B: "b", Similarity: 0.2}})
+	if s.Outlier != "" || s.Overall != 0.2 || s.Mean["a"] != 0.2 {
+		t.Errorf("Expected no outlier between two answers, got %+v", s)
+	}
+
+	s = Summarize([]Pair{
+		{A: "a", B: "b", Similarity: 0.9},
+		{A: "a", B: "c", Similarity: 0.88},
+		{A: "b", B: "c", Similarity: 0.89},
+	})
+	if s.Outlier != "" {
+		t.Errorf("Expected no outlier when answers are equally close, got %q", s.Outlier)
+	}
+}
@@ -0,0 +1,170 @@
+// Package estimate predicts what a run will cost and how long it will take before it starts,
+// from the size of the prompts, the rate tables and what each variant produced in past runs.
+package estimate
+
+import (
+	"sort"
+	"time"
+
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// Assumed per call for a variant without history
+const (
+	DefaultTokensOut = 1000
+	DefaultDuration  = 30 * time.Second
+)
+
+// rankingTokensOut is about what a ranking reply takes: the order and a short reason
+const rankingTokensOut = 300
+
+// History is what a variant or family produced per call in past runs; zero fields are unknown
+type History struct {
+	Calls      int64
+	TokensOut  float64
+	DurationMs float64
+}
+
+// Call is the estimate of one model call
+type Call struct {
+	Round      int     `json:"round"` // 0 for ranking
+	TokensIn   int64   `json:"tokens_in"`
+	TokensOut  int64   `json:"tokens_out"`
+	Cost       float64 `json:"cost"`
+	DurationMs int64   `json:"duration_ms"`
+}
+
+// Model is the estimate for one participant or judge
+type Model struct {
+	Model   string  `json:"model"` // Family ID
+	Variant string  `json:"variant"`
+	Calls   []Call  `json:"calls"`
+	Cost    float64 `json:"cost"`
+	Samples int64   `json:"samples"` // Past calls the output size and duration are based on, 0 when defaults were assumed
+}
+
+// Estimate is the predicted cost and duration of a run
+// It assumes every round runs; early stopping and failed calls only make runs cheaper and shorter.
+type Estimate struct {
+	Rounds     int     `json:"rounds"`
+	Models     []Model `json:"models"`
+	TokensIn   int64   `json:"tokens_in"`
+	TokensOut  int64   `json:"tokens_out"`
+	Cost       float64 `json:"cost"`
+	DurationMs int64   `json:"duration_ms"` // Models answer in parallel, rounds and the ranking one after another
+}
+
+// Run estimates asking question over rounds, with judges ranking the answers (the participants if none)
+// history is looked up by variant, then by family ID.
+func Run(question string, rounds int, participants, judges []*types.ModelInfo, history map[string]History) Estimate {
+	est := Estimate{Rounds: rounds}
+	if len(judges) == 0 {
+		judges = participants
+	}
+
+	// Every reply of a round is part of each participant's prompt in the next
+	var repliesOut int64
+	for _, mi := range participants {
+		out, _, _ := expected(mi, history)
+		repliesOut += out
+	}
+
+	byName := make(map[string]*Model)
+	model := func(mi *types.ModelInfo) *Model {
+		if m, ok := byName[mi.Name]; ok {
+			return m
+		}
+		_, _, samples := expected(mi, history)
+		m := &Model{Model: mi.ID, Variant: mi.Name, Samples: samples}
+		byName[mi.Name] = m
+		return m
+	}
+
+	names := make([]string, 0, len(participants))
+	for _, mi := range participants {
+		names = append(names, mi.Name)
+	}
+
+	for round := 1; round <= rounds; round++ {
+		var longest int64
+		for _, mi := range participants {
+			others := make([]string, 0, len(names)-1)
+			for _, name := range names {
+				if name != mi.Name {
+					others = append(others, name)
+				}
+			}
+			prompt := shared.FormatPrompt(mi.ID, mi.Name, question, types.Meta{Round: round, TotalRounds: rounds, OtherAgents: others}, nil, nil, nil)
+			in := int64(shared.EstimateTokens(mi.Persona) + shared.EstimateTokens(prompt))
+			if round > 1 {
+				in += repliesOut
+			}
+
+			out, duration, _ := expected(mi, history)
+			call := price(mi, Call{Round: round, TokensIn: in, TokensOut: out, DurationMs: duration})
+			m := model(mi)
+			m.Calls = append(m.Calls, call)
+			longest = max(longest, duration)
+		}
+		est.DurationMs += longest
+	}
+
+	// Judges see every final answer
+	anonMap := shared.CreateAnonymizationMap(names)
+	var longest int64
+	for _, mi := range judges {
+		prompt := shared.FormatRankingPrompt(mi.Name, question, names, nil, anonMap, nil, nil, false)
+		in := int64(shared.EstimateTokens(prompt)) + repliesOut
+
+		_, duration, _ := expected(mi, history)
+		call := price(mi, Call{TokensIn: in, TokensOut: rankingTokensOut, DurationMs: duration})
+		m := model(mi)
+		m.Calls = append(m.Calls, call)
+		longest = max(longest, duration)
+	}
+	est.DurationMs += longest
+
+	for _, m := range byName {
+		for _, call := range m.Calls {
+			m.Cost += call.Cost
+			est.TokensIn += call.TokensIn
+			est.TokensOut += call.TokensOut
+		}
+		est.Cost += m.Cost
+		est.Models = append(est.Models, *m)
+	}
+	sort.Slice(est.Models, func(i, j int) bool { return est.Models[i].Variant < est.Models[j].Variant })
+
+	return est
+}
+
+// expected returns a model's output tokens and milliseconds per call, and how many past calls they're based on
+func expected(mi *types.ModelInfo, history map[string]History) (tokensOut, durationMs, samples int64) {
+	tokensOut, durationMs = DefaultTokensOut, DefaultDuration.Milliseconds()
+
+	h, ok := history[mi.Name]
+	if !ok {
+		h = history[mi.FamilyID()]
+	}
+	if h.TokensOut > 0 {
+		tokensOut = int64(h.TokensOut)
+		samples = h.Calls
+	}
+	if h.DurationMs > 0 {
+		durationMs = int64(h.DurationMs)
+	}
+
+	if mi.MaxOut > 0 {
+		tokensOut = min(tokensOut, mi.MaxOut)
+	}
+	return tokensOut, durationMs, samples
+}
+
+// price sets the cost of a call at the model's rate, including the run's pricing overrides
+func price(mi *types.ModelInfo, call Call) Call {
+	rate := mi.Pricing.Apply(mi.Name, models.ModelFamilies[mi.FamilyID()].Variants[mi.Name].Rate)
+	call.Cost = (float64(call.TokensIn)*rate.In + float64(call.TokensOut)*rate.Out) / 1_000_000
+	return call
+}
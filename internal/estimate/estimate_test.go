@@ -0,0 +1,89 @@
+package estimate
+
+import (
+	"math"
+	"testing"
+
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestRun(t *testing.T) {
+	gpt := &types.ModelInfo{ID: models.GPT, Name: models.GPT5Mini}
+	grok := &types.ModelInfo{ID: models.Grok, Name: models.Grok4, MaxOut: 500}
+	history := map[string]History{
+		models.GPT5Mini: {Calls: 12, TokensOut: 2000, DurationMs: 8000},
+		models.Grok:     {DurationMs: 12000}, // Family-level latency only
+	}
+
+	est := Run("Why is the sky blue?", 3, []*types.ModelInfo{gpt, grok}, nil, history)
+
+	if len(est.Models) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(est.Models))
+	}
+	byVariant := map[string]Model{}
+	for _, m := range est.Models {
+		byVariant[m.Variant] = m
+		if len(m.Calls) != 4 {
+			t.Errorf("Expected 3 rounds and a ranking for %s, got %d calls", m.Variant, len(m.Calls))
+		}
+	}
+
+	g := byVariant[models.GPT5Mini]
+	if g.Samples != 12 || g.Calls[0].TokensOut != 2000 {
+		t.Errorf("Expected output from history, got %d samples and %d tokens", g.Samples, g.Calls[0].TokensOut)
+	}
+	if x := byVariant[models.Grok4]; x.Samples != 0 || x.Calls[0].TokensOut != 500 {
+		t.Errorf("Expected the default output capped at MaxOut, got %d samples and %d tokens", x.Samples, x.Calls[0].TokensOut)
+	}
+
+	// Later rounds carry every previous reply
+	if grew := g.Calls[1].TokensIn - g.Calls[0].TokensIn; grew < 2500 {
+		t.Errorf("Expected round 2 prompt to include both replies, grew by %d tokens", grew)
+	}
+	if ranking := g.Calls[3]; ranking.Round != 0 || ranking.TokensOut != rankingTokensOut {
+		t.Errorf("Expected a ranking call last, got %+v", ranking)
+	}
+
+	call := g.Calls[0]
+	if want := (float64(call.TokensIn)*0.25 + float64(call.TokensOut)*2.0) / 1_000_000; math.Abs(call.Cost-want) > 1e-12 {
+		t.Errorf("Expected cost %v at list rates, got %v", want, call.Cost)
+	}
+
+	// The slowest model sets the pace of every round and the ranking
+	if est.DurationMs != 4*12000 {
+		t.Errorf("Expected 48s, got %dms", est.DurationMs)
+	}
+
+	var total float64
+	for _, m := range est.Models {
+		total += m.Cost
+	}
+	if math.Abs(est.Cost-total) > 1e-12 {
+		t.Errorf("Expected total cost %v, got %v", total, est.Cost)
+	}
+}
+
+func TestRunJudgesAndPricing(t *testing.T) {
+	pricing := &types.Pricing{Multiplier: 0.5}
+	gpt := &types.ModelInfo{ID: models.GPT, Name: models.GPT5Mini, Pricing: pricing}
+	judge := &types.ModelInfo{ID: models.GPT, Name: models.GPT5, Pricing: pricing}
+
+	est := Run("Q?", 3, []*types.ModelInfo{gpt}, []*types.ModelInfo{judge}, nil)
+
+	byVariant := map[string]Model{}
+	for _, m := range est.Models {
+		byVariant[m.Variant] = m
+	}
+	if len(byVariant[models.GPT5Mini].Calls) != 3 || len(byVariant[models.GPT5].Calls) != 1 {
+		t.Fatalf("Expected the jury to rank instead of the participant, got %+v", est.Models)
+	}
+
+	call := byVariant[models.GPT5Mini].Calls[0]
+	if call.TokensOut != DefaultTokensOut || call.DurationMs != DefaultDuration.Milliseconds() {
+		t.Errorf("Expected defaults without history, got %+v", call)
+	}
+	if want := (float64(call.TokensIn)*0.125 + float64(call.TokensOut)*1.0) / 1_000_000; math.Abs(call.Cost-want) > 1e-12 {
+		t.Errorf("Expected cost %v at half the list rates, got %v", want, call.Cost)
+	}
+}
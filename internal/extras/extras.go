@@ -0,0 +1,117 @@
+// Package extras loads provider-specific request options (e.g. Grok's reasoning_effort or Gemini's
+// safetySettings) that are passed into request bodies as-is, so new provider knobs need no code changes.
+package extras
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// reserved are request body fields fat builds itself; overriding them would break the call
+var reserved = map[string]bool{
+	"model":             true,
+	"messages":          true,
+	"contents":          true,
+	"system":            true,
+	"systemInstruction": true,
+	"stream":            true,
+	"input":             true, // OpenAI Responses API
+	"instructions":      true,
+}
+
+// Load reads extra options from a JSON file (family ID or variant name -> body field -> value)
+// and merges them over each model info's Extra. A missing file is not an error.
+func Load(path string, modelInfos []*types.ModelInfo) error {
+	options, err := read(path)
+	if err != nil {
+		return err
+	}
+
+	for _, mi := range modelInfos {
+		mi.Extra = Merge(mi.Extra, options[mi.ID], options[mi.Name])
+	}
+
+	return nil
+}
+
+// GetForVariant retrieves the options for a variant: the family's, then the variant's own on top
+func GetForVariant(path, familyID, variant string) map[string]any {
+	options, err := read(path)
+	if err != nil {
+		return nil
+	}
+	return Merge(options[familyID], options[variant])
+}
+
+// Merge deep-merges layers of options, later layers winning; nested objects are merged, anything else replaced
+// Returns nil if there are no options at all. The layers are not modified.
+func Merge(layers ...map[string]any) map[string]any {
+	var merged map[string]any
+	for _, layer := range layers {
+		if len(layer) == 0 {
+			continue
+		}
+		if merged == nil {
+			merged = map[string]any{}
+		}
+		mergeInto(merged, layer)
+	}
+	return merged
+}
+
+func mergeInto(dst, src map[string]any) {
+	for key, value := range src {
+		if srcMap, ok := value.(map[string]any); ok {
+			dstMap, ok := dst[key].(map[string]any)
+			if !ok {
+				dstMap = map[string]any{}
+			} else {
+				dstMap = maps.Clone(dstMap)
+			}
+			mergeInto(dstMap, srcMap)
+			dst[key] = dstMap
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// read parses the options file, expanding dotted keys like "reasoning.effort" into nested objects
+func read(path string) (map[string]map[string]any, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]map[string]any{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var raw map[string]map[string]any
+	if err := json.NewDecoder(file).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	options := make(map[string]map[string]any, len(raw))
+	for target, fields := range raw {
+		expanded := map[string]any{}
+		for key, value := range fields {
+			parts := strings.Split(key, ".")
+			if reserved[parts[0]] {
+				return nil, fmt.Errorf("%s: %q is set by fat and can't be overridden", target, parts[0])
+			}
+			for i := len(parts) - 1; i > 0; i-- {
+				value = map[string]any{parts[i]: value}
+			}
+			mergeInto(expanded, map[string]any{parts[0]: value})
+		}
+		options[target] = expanded
+	}
+
+	return options, nil
+}
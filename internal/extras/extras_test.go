@@ -0,0 +1,82 @@
+package extras
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "extras.json")
+	content := `{
+		"gemini": {"safetySettings": [{"category": "HARM_CATEGORY_HARASSMENT", "threshold": "BLOCK_NONE"}]},
+		"gemini-2.5-pro": {"generationConfig.thinkingConfig.thinkingBudget": 2048},
+		"gpt-5": {"reasoning_effort": "high", "verbosity": "low"}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gemini := &types.ModelInfo{ID: "gemini", Name: "gemini-2.5-pro", Extra: map[string]any{"generationConfig": map[string]any{"temperature": 0.2}}}
+	gpt := &types.ModelInfo{ID: "gpt", Name: "gpt-5"}
+	grok := &types.ModelInfo{ID: "grok", Name: "grok-4"}
+	if err := Load(path, []*types.ModelInfo{gemini, gpt, grok}); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	want := map[string]any{
+		"safetySettings": []any{map[string]any{"category": "HARM_CATEGORY_HARASSMENT", "threshold": "BLOCK_NONE"}},
+		"generationConfig": map[string]any{
+			"temperature":    0.2,
+			"thinkingConfig": map[string]any{"thinkingBudget": float64(2048)},
+		},
+	}
+	if !reflect.DeepEqual(gemini.Extra, want) {
+		t.Errorf("Expected family, variant and built-in options merged, got %v", gemini.Extra)
+	}
+	if gpt.Extra["reasoning_effort"] != "high" || gpt.Extra["verbosity"] != "low" {
+		t.Errorf("Expected gpt-5 options, got %v", gpt.Extra)
+	}
+	if grok.Extra != nil {
+		t.Errorf("Expected no options for grok, got %v", grok.Extra)
+	}
+
+	if got := GetForVariant(path, "gpt", "gpt-5-mini"); got != nil {
+		t.Errorf("Expected no options for another variant, got %v", got)
+	}
+}
+
+func TestLoadReserved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "extras.json")
+	if err := os.WriteFile(path, []byte(`{"gpt": {"messages": []}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(path, nil); err == nil {
+		t.Error("Expected error for overriding messages")
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	if err := Load(filepath.Join(t.TempDir(), "missing.json"), nil); err != nil {
+		t.Errorf("Expected missing file to be ignored, got %v", err)
+	}
+}
+
+func TestMergeDoesNotModifyLayers(t *testing.T) {
+	base := map[string]any{"reasoning": map[string]any{"effort": "low"}}
+	override := map[string]any{"reasoning": map[string]any{"summary": "auto"}}
+
+	merged := Merge(base, override)
+	if !reflect.DeepEqual(merged, map[string]any{"reasoning": map[string]any{"effort": "low", "summary": "auto"}}) {
+		t.Errorf("Expected nested objects merged, got %v", merged)
+	}
+	if len(base["reasoning"].(map[string]any)) != 1 {
+		t.Errorf("Expected base to be left alone, got %v", base)
+	}
+	if Merge(nil, map[string]any{}) != nil {
+		t.Error("Expected nil for no options")
+	}
+}
@@ -0,0 +1,253 @@
+// Package faults injects artificial latency, timeouts, errors and malformed replies into provider calls,
+// so retries, fallbacks and the UI's error paths can be exercised without waiting for a real outage.
+// Faults are off unless FAT_FAULTS or the admin API turns them on, and can be changed while running.
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/meedamian/fat/internal/retry"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// ErrInjected is the cause of every error a fault stands in for
+var ErrInjected = errors.New("injected fault")
+
+// Config says which faults provider calls get, each rate being the fraction of calls it hits
+// The rates are exclusive, so they add up to at most 1; latency comes on top of any of them.
+type Config struct {
+	Latency   time.Duration // Added before every call
+	Timeout   float64       // Calls that hang until their deadline
+	Error     float64       // Calls failing with a 503, which is retried
+	RateLimit float64       // Calls failing with a 429 asking to retry after a second
+	Malformed float64       // Calls whose reply comes back cut short, as if the response broke off
+	Models    []string      // Families or variants the faults apply to; all when empty
+}
+
+// Enabled reports whether c injects anything at all
+func (c Config) Enabled() bool {
+	return c.Latency > 0 || c.Timeout > 0 || c.Error > 0 || c.RateLimit > 0 || c.Malformed > 0
+}
+
+// Applies reports whether c's faults hit calls to mi
+func (c Config) Applies(mi *types.ModelInfo) bool {
+	return c.Enabled() && (len(c.Models) == 0 || slices.Contains(c.Models, mi.FamilyID()) || slices.Contains(c.Models, mi.Name))
+}
+
+// Validate checks that the rates are fractions that add up to at most 1 and latency isn't negative
+func (c Config) Validate() error {
+	if c.Latency < 0 {
+		return errors.New("latency must not be negative")
+	}
+	rates := map[string]float64{"timeout": c.Timeout, "error": c.Error, "ratelimit": c.RateLimit, "malformed": c.Malformed}
+	for _, name := range []string{"timeout", "error", "ratelimit", "malformed"} {
+		if rate := rates[name]; rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be a fraction from 0 to 1, got %g", name, rate)
+		}
+	}
+	if total := c.Timeout + c.Error + c.RateLimit + c.Malformed; total > 1 {
+		return fmt.Errorf("rates must add up to at most 1, got %g", total)
+	}
+	return nil
+}
+
+// String formats c the way Parse reads it, e.g. "latency=2s,error=0.2,models=grok+gpt"
+func (c Config) String() string {
+	var parts []string
+	if c.Latency > 0 {
+		parts = append(parts, "latency="+c.Latency.String())
+	}
+	for _, rate := range []struct {
+		name  string
+		value float64
+	}{{"timeout", c.Timeout}, {"error", c.Error}, {"ratelimit", c.RateLimit}, {"malformed", c.Malformed}} {
+		if rate.value > 0 {
+			parts = append(parts, rate.name+"="+strconv.FormatFloat(rate.value, 'g', -1, 64))
+		}
+	}
+	if len(c.Models) > 0 {
+		parts = append(parts, "models="+strings.Join(c.Models, "+"))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Parse reads a comma-separated list of key=value faults, e.g. "latency=2s,timeout=0.1,models=grok+gpt"
+// Keys are latency (a duration), timeout, error, ratelimit and malformed (fractions of calls), and
+// models (families or variants joined by +). An empty spec injects nothing.
+func Parse(spec string) (Config, error) {
+	var c Config
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return Config{}, fmt.Errorf("%q must be key=value", pair)
+		}
+
+		var rate *float64
+		switch key {
+		case "latency":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("latency must be a duration like 2s, got %q", value)
+			}
+			c.Latency = d
+			continue
+		case "models":
+			for _, model := range strings.Split(value, "+") {
+				if model = strings.TrimSpace(model); model != "" {
+					c.Models = append(c.Models, model)
+				}
+			}
+			continue
+		case "timeout":
+			rate = &c.Timeout
+		case "error":
+			rate = &c.Error
+		case "ratelimit":
+			rate = &c.RateLimit
+		case "malformed":
+			rate = &c.Malformed
+		default:
+			return Config{}, fmt.Errorf("unknown fault %q: must be latency, timeout, error, ratelimit, malformed or models", key)
+		}
+
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s must be a fraction from 0 to 1, got %q", key, value)
+		}
+		*rate = f
+	}
+	return c, c.Validate()
+}
+
+// configJSON is Config as the admin API shows and takes it, with latency as a duration string
+type configJSON struct {
+	Latency   string   `json:"latency,omitempty"`
+	Timeout   float64  `json:"timeout,omitempty"`
+	Error     float64  `json:"error,omitempty"`
+	RateLimit float64  `json:"ratelimit,omitempty"`
+	Malformed float64  `json:"malformed,omitempty"`
+	Models    []string `json:"models,omitempty"`
+}
+
+func (c Config) MarshalJSON() ([]byte, error) {
+	out := configJSON{Timeout: c.Timeout, Error: c.Error, RateLimit: c.RateLimit, Malformed: c.Malformed, Models: c.Models}
+	if c.Latency > 0 {
+		out.Latency = c.Latency.String()
+	}
+	return json.Marshal(out)
+}
+
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var in configJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*c = Config{Timeout: in.Timeout, Error: in.Error, RateLimit: in.RateLimit, Malformed: in.Malformed, Models: in.Models}
+	if in.Latency != "" {
+		d, err := time.ParseDuration(in.Latency)
+		if err != nil {
+			return fmt.Errorf("latency must be a duration like 2s, got %q", in.Latency)
+		}
+		c.Latency = d
+	}
+	return nil
+}
+
+// current holds the faults every wrapped model checks on each call
+var current atomic.Pointer[Config]
+
+// Set replaces the faults injected from now on; an empty Config turns them off
+func Set(c Config) {
+	current.Store(&c)
+}
+
+// Current returns the faults being injected
+func Current() Config {
+	if c := current.Load(); c != nil {
+		return *c
+	}
+	return Config{}
+}
+
+// roll picks where in [0, 1) a call lands among the rates; replaced in tests
+var roll = rand.Float64
+
+// Wrap returns m injecting the current faults into its calls to mi
+// Faults are looked up on every call, so changing them reaches models created before.
+func Wrap(m types.Model, mi *types.ModelInfo) types.Model {
+	return &faultyModel{model: m, info: mi}
+}
+
+type faultyModel struct {
+	model types.Model
+	info  *types.ModelInfo
+}
+
+func (m *faultyModel) Prompt(ctx context.Context, req types.PromptRequest) (types.PromptResponse, error) {
+	c := Current()
+	if !c.Applies(m.info) {
+		return m.model.Prompt(ctx, req)
+	}
+
+	if c.Latency > 0 {
+		select {
+		case <-time.After(c.Latency):
+		case <-ctx.Done():
+			return types.PromptResponse{}, fmt.Errorf("api request failed: %w", ctx.Err())
+		}
+	}
+
+	r := roll()
+	switch {
+	case r < c.Timeout:
+		m.log("timeout")
+		<-ctx.Done()
+		return types.PromptResponse{}, fmt.Errorf("api request failed: %w: %w", ErrInjected, ctx.Err())
+	case r < c.Timeout+c.Error:
+		m.log("error")
+		return types.PromptResponse{}, retry.Classify(503, nil, fmt.Errorf("api returned status 503: %w", ErrInjected))
+	case r < c.Timeout+c.Error+c.RateLimit:
+		m.log("ratelimit")
+		return types.PromptResponse{}, &retry.RateLimited{RetryAfter: time.Second, Err: fmt.Errorf("api returned status 429: %w", ErrInjected)}
+	case r < c.Timeout+c.Error+c.RateLimit+c.Malformed:
+		result, err := m.model.Prompt(ctx, req)
+		if err != nil {
+			return result, err
+		}
+		m.log("malformed")
+		return malformed(result, req.Meta.Structured), nil
+	default:
+		return m.model.Prompt(ctx, req)
+	}
+}
+
+// log records which fault a call got, so injected failures aren't mistaken for real ones
+func (m *faultyModel) log(fault string) {
+	if m.info.Logger != nil {
+		m.info.Logger.Warn("injecting fault", slog.String("fault", fault))
+	}
+}
+
+// malformed cuts result's reply off a third of the way in and parses what is left,
+// the way a response that broke off mid-stream would come back
+func malformed(result types.PromptResponse, structured bool) types.PromptResponse {
+	content := []rune(result.Reply.RawContent)
+	result.Reply = shared.ParseReply(string(content[:len(content)/3]), structured)
+	result.FinishReason = "injected_fault"
+	return result
+}
@@ -0,0 +1,115 @@
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/meedamian/fat/internal/retry"
+	"github.com/meedamian/fat/internal/types"
+)
+
+type stubModel struct{ calls int }
+
+func (m *stubModel) Prompt(ctx context.Context, req types.PromptRequest) (types.PromptResponse, error) {
+	m.calls++
+	return types.PromptResponse{Reply: types.Reply{Answer: "Paris", RawContent: "# ANSWER\n\nParis is the capital of France.\n\n# RATIONALE\n\nWell known."}}, nil
+}
+
+func TestParse(t *testing.T) {
+	c, err := Parse("latency=1.5s, timeout=0.1,error=0.2, ratelimit=0.05,malformed=0.15,models=grok+claude")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if c.Latency != 1500*time.Millisecond || c.Timeout != 0.1 || c.Error != 0.2 || c.RateLimit != 0.05 || c.Malformed != 0.15 || len(c.Models) != 2 {
+		t.Errorf("Unexpected faults %+v", c)
+	}
+	if again, err := Parse(c.String()); err != nil || again.String() != c.String() {
+		t.Errorf("Expected %q to parse back the same, got %q (%v)", c.String(), again.String(), err)
+	}
+
+	if c, err := Parse(""); err != nil || c.Enabled() {
+		t.Errorf("Expected an empty spec to inject nothing, got %+v (%v)", c, err)
+	}
+
+	for _, spec := range []string{"latency=soon", "error=half", "error=1.5", "timeout=0.6,error=0.6", "crash=0.1", "error"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Expected error for %q, got nil", spec)
+		}
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	var c Config
+	if err := json.Unmarshal([]byte(`{"latency":"250ms","error":0.5,"models":["gpt"]}`), &c); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if c.Latency != 250*time.Millisecond || c.Error != 0.5 || len(c.Models) != 1 {
+		t.Errorf("Unexpected faults %+v", c)
+	}
+	data, err := json.Marshal(c)
+	if err != nil || string(data) != `{"latency":"250ms","error":0.5,"models":["gpt"]}` {
+		t.Errorf("Unexpected JSON %s (%v)", data, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"latency":"a while"}`), &c); err == nil {
+		t.Error("Expected error for a malformed latency, got nil")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	defer Set(Config{})
+	defer func(r func() float64) { roll = r }(roll)
+
+	stub := &stubModel{}
+	grok := &types.ModelInfo{ID: "grok", Name: "grok-4"}
+	model := Wrap(stub, grok)
+	Set(Config{Timeout: 0.1, Error: 0.2, RateLimit: 0.1, Malformed: 0.2, Models: []string{"grok"}})
+
+	roll = func() float64 { return 0.2 }
+	_, err := model.Prompt(context.Background(), types.PromptRequest{})
+	var serverErr *retry.ServerError
+	if !errors.As(err, &serverErr) || serverErr.StatusCode != 503 || !errors.Is(err, ErrInjected) || !retry.IsRetryable(err) {
+		t.Errorf("Expected a retryable injected 503, got %v", err)
+	}
+
+	roll = func() float64 { return 0.35 }
+	_, err = model.Prompt(context.Background(), types.PromptRequest{})
+	var rateLimited *retry.RateLimited
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != time.Second {
+		t.Errorf("Expected an injected 429, got %v", err)
+	}
+
+	roll = func() float64 { return 0.05 }
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = model.Prompt(ctx, types.PromptRequest{}); !errors.Is(err, context.DeadlineExceeded) || retry.IsRetryable(err) {
+		t.Errorf("Expected the call to hang until its deadline, got %v", err)
+	}
+
+	roll = func() float64 { return 0.5 }
+	result, err := model.Prompt(context.Background(), types.PromptRequest{})
+	if err != nil || result.Reply.Answer == "Paris" || result.FinishReason != "injected_fault" {
+		t.Errorf("Expected a reply cut short, got %+v (%v)", result, err)
+	}
+
+	roll = func() float64 { return 0.9 }
+	if result, err := model.Prompt(context.Background(), types.PromptRequest{}); err != nil || result.Reply.Answer != "Paris" {
+		t.Errorf("Expected the call to go through, got %+v (%v)", result, err)
+	}
+	if stub.calls != 2 {
+		t.Errorf("Expected only the malformed and passing calls to reach the model, got %d", stub.calls)
+	}
+
+	// Other models, and models after faults are turned off, are left alone
+	roll = func() float64 { return 0.2 }
+	if _, err := Wrap(stub, &types.ModelInfo{ID: "gpt", Name: "gpt-5-mini"}).Prompt(context.Background(), types.PromptRequest{}); err != nil {
+		t.Errorf("Expected gpt calls untouched, got %v", err)
+	}
+	Set(Config{})
+	if _, err := model.Prompt(context.Background(), types.PromptRequest{}); err != nil {
+		t.Errorf("Expected no faults once turned off, got %v", err)
+	}
+}
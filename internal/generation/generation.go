@@ -0,0 +1,80 @@
+// Package generation loads sampling parameters (temperature, top_p, max_tokens and reasoning effort)
+// per model family or variant, which every provider translates into its own request fields.
+package generation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// efforts are the reasoning effort levels providers accept
+var efforts = map[string]bool{"minimal": true, "low": true, "medium": true, "high": true}
+
+// Load reads sampling parameters from a JSON file (family ID or variant name -> parameters)
+// and merges them over each model info's Generation. A missing file is not an error.
+func Load(path string, modelInfos []*types.ModelInfo) error {
+	params, err := read(path)
+	if err != nil {
+		return err
+	}
+
+	for _, mi := range modelInfos {
+		mi.Generation = mi.Generation.Merge(params[mi.ID]).Merge(params[mi.Name])
+	}
+
+	return nil
+}
+
+// GetForVariant retrieves the parameters for a variant: the family's, then the variant's own on top
+func GetForVariant(path, familyID, variant string) types.Generation {
+	params, err := read(path)
+	if err != nil {
+		return types.Generation{}
+	}
+	return params[familyID].Merge(params[variant])
+}
+
+// Validate reports the first parameter outside what providers accept
+func Validate(g types.Generation) error {
+	if g.Temperature != nil && (*g.Temperature < 0 || *g.Temperature > 2) {
+		return fmt.Errorf("temperature %v must be between 0 and 2", *g.Temperature)
+	}
+	if g.TopP != nil && (*g.TopP <= 0 || *g.TopP > 1) {
+		return fmt.Errorf("top_p %v must be above 0 and at most 1", *g.TopP)
+	}
+	if g.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens %d must not be negative", g.MaxTokens)
+	}
+	if g.ReasoningEffort != "" && !efforts[g.ReasoningEffort] {
+		return fmt.Errorf("reasoning_effort %q must be minimal, low, medium or high", g.ReasoningEffort)
+	}
+	return nil
+}
+
+// read parses and validates the parameters file
+func read(path string) (map[string]types.Generation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]types.Generation{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var params map[string]types.Generation
+	if err := json.NewDecoder(file).Decode(&params); err != nil {
+		return nil, err
+	}
+
+	for target, g := range params {
+		if err := Validate(g); err != nil {
+			return nil, fmt.Errorf("%s: %w", target, err)
+		}
+	}
+
+	return params, nil
+}
@@ -0,0 +1,63 @@
+package generation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "generation.json")
+	content := `{
+		"claude": {"temperature": 0.3, "max_tokens": 16000},
+		"claude-opus-4-6": {"temperature": 0.7},
+		"gpt": {"reasoning_effort": "low"}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	topP := 0.9
+	claude := &types.ModelInfo{ID: "claude", Name: "claude-opus-4-6", Generation: types.Generation{TopP: &topP}}
+	gpt := &types.ModelInfo{ID: "gpt", Name: "gpt-5"}
+	grok := &types.ModelInfo{ID: "grok", Name: "grok-4"}
+	if err := Load(path, []*types.ModelInfo{claude, gpt, grok}); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if claude.Generation.Temperature == nil || *claude.Generation.Temperature != 0.7 {
+		t.Errorf("Expected the variant's temperature over the family's, got %v", claude.Generation.Temperature)
+	}
+	if claude.Generation.MaxTokens != 16000 || claude.Generation.TopP != &topP {
+		t.Errorf("Expected family and built-in parameters kept, got %+v", claude.Generation)
+	}
+	if gpt.Generation.ReasoningEffort != "low" {
+		t.Errorf("Expected gpt reasoning effort, got %q", gpt.Generation.ReasoningEffort)
+	}
+	if grok.Generation != (types.Generation{}) {
+		t.Errorf("Expected no parameters for grok, got %+v", grok.Generation)
+	}
+
+	if got := GetForVariant(path, "claude", "claude-sonnet-4-6"); got.Temperature == nil || *got.Temperature != 0.3 {
+		t.Errorf("Expected the family's temperature for another variant, got %+v", got)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, content := range []string{
+		`{"gpt": {"temperature": 3}}`,
+		`{"gpt": {"top_p": 0}}`,
+		`{"gpt": {"max_tokens": -1}}`,
+		`{"gpt": {"reasoning_effort": "extreme"}}`,
+	} {
+		path := filepath.Join(t.TempDir(), "generation.json")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := Load(path, nil); err == nil {
+			t.Errorf("Expected error for %s", content)
+		}
+	}
+}
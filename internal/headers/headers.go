@@ -0,0 +1,100 @@
+package headers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// AllFamilies is the headers.json key whose headers are sent to every family
+const AllFamilies = "*"
+
+// Load reads extra request headers from a JSON file (family ID or "*" -> header -> value)
+// and assigns them to the provided model infos. A missing file is not an error.
+func Load(path string, modelInfos []*types.ModelInfo) error {
+	families, err := read(path)
+	if err != nil {
+		return err
+	}
+
+	for _, mi := range modelInfos {
+		mi.Headers = merge(families, mi.ID)
+	}
+
+	return nil
+}
+
+// GetForFamily retrieves the headers for a specific model family, including the "*" ones
+func GetForFamily(path, familyID string) http.Header {
+	families, err := read(path)
+	if err != nil {
+		return nil
+	}
+	return merge(families, familyID)
+}
+
+// merge combines the "*" headers with a family's own, which take precedence
+func merge(families map[string]http.Header, familyID string) http.Header {
+	h := http.Header{}
+	for _, key := range []string{AllFamilies, familyID} {
+		for name, values := range families[key] {
+			h[name] = values
+		}
+	}
+	if len(h) == 0 {
+		return nil
+	}
+	return h
+}
+
+// read parses the headers file, expanding $VAR and ${VAR} in values so tokens can stay in the environment
+func read(path string) (map[string]http.Header, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]http.Header{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var raw map[string]map[string]string
+	if err := json.NewDecoder(file).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	families := make(map[string]http.Header, len(raw))
+	for familyID, values := range raw {
+		h := http.Header{}
+		for name, value := range values {
+			if !validName(name) {
+				return nil, fmt.Errorf("invalid header name %q for %s", name, familyID)
+			}
+			value = strings.TrimSpace(os.ExpandEnv(value))
+			if strings.ContainsAny(value, "\r\n") {
+				return nil, fmt.Errorf("invalid value for header %s for %s", name, familyID)
+			}
+			h.Set(name, value)
+		}
+		families[familyID] = h
+	}
+
+	return families, nil
+}
+
+// validName reports whether name is a valid HTTP header field name (an RFC 7230 token)
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > 0x7e || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package headers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("GATEWAY_TOKEN", "secret")
+
+	path := filepath.Join(t.TempDir(), "headers.json")
+	content := `{
+		"*": {"X-Org-Id": "acme", "x-tenant": "default"},
+		"gpt": {"X-Tenant": "research", "Authorization": "Bearer ${GATEWAY_TOKEN}"}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gpt := &types.ModelInfo{ID: "gpt"}
+	claude := &types.ModelInfo{ID: "claude"}
+	if err := Load(path, []*types.ModelInfo{gpt, claude}); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if got := gpt.Headers.Get("X-Org-Id"); got != "acme" {
+		t.Errorf("Expected shared header for gpt, got %q", got)
+	}
+	if got := gpt.Headers.Get("X-Tenant"); got != "research" {
+		t.Errorf("Expected family header to override the shared one, got %q", got)
+	}
+	if got := gpt.Headers.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Expected environment variable to be expanded, got %q", got)
+	}
+	if got := claude.Headers.Get("X-Tenant"); got != "default" || claude.Headers.Get("Authorization") != "" {
+		t.Errorf("Expected only shared headers for claude, got %v", claude.Headers)
+	}
+
+	if h := GetForFamily(path, "gemini"); h.Get("X-Org-Id") != "acme" {
+		t.Errorf("Expected GetForFamily to include shared headers, got %v", h)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	mi := &types.ModelInfo{ID: "gpt"}
+	if err := Load(filepath.Join(t.TempDir(), "missing.json"), []*types.ModelInfo{mi}); err != nil {
+		t.Errorf("Expected missing file to be ignored, got %v", err)
+	}
+	if mi.Headers != nil {
+		t.Errorf("Expected no headers, got %v", mi.Headers)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []string{
+		`{"gpt": {"X Org": "acme"}}`,
+		`{"gpt": {"X-Org:": "acme"}}`,
+		`{"gpt": {"X-Org": "acme\r\nX-Injected: 1"}}`,
+		`{"gpt": "X-Org: acme"}`,
+	}
+
+	for _, content := range tests {
+		path := filepath.Join(t.TempDir(), "headers.json")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := Load(path, nil); err == nil {
+			t.Errorf("Expected error for %s", content)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package htmlexport
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// Card dimensions match the 1.91:1 ratio chats and social sites use for link previews
+const (
+	cardWidth    = 1200
+	cardHeight   = 630
+	cardLineLen  = 52 // Characters per question line at the question's font size
+	cardMaxLines = 3
+)
+
+var medals = []struct {
+	label string
+	color string
+}{
+	{"1st", "#f5c542"},
+	{"2nd", "#c0c7d1"},
+	{"3rd", "#cd7f32"},
+}
+
+// renderCard draws a summary card of a run (question, medal winners and what each model cost) as SVG
+func renderCard(data ExportData) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", cardWidth, cardHeight, cardWidth, cardHeight)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#0f1117"/>`+"\n", cardWidth, cardHeight)
+	buf.WriteString(`<g font-family="Inter, Helvetica, Arial, sans-serif">` + "\n")
+
+	// Question
+	for i, line := range wrapText(data.Question, cardLineLen, cardMaxLines) {
+		fmt.Fprintf(&buf, `<text x="60" y="%d" font-size="38" font-weight="700" fill="#f4f5f7">%s</text>`+"\n", 90+i*50, html.EscapeString(line))
+	}
+
+	// Medal winners
+	y := 300
+	for i, ids := range [][]string{data.GoldIDs, data.SilverIDs, data.BronzeIDs} {
+		if len(ids) == 0 {
+			continue
+		}
+		names := make([]string, len(ids))
+		for j, id := range ids {
+			names[j] = formatModelName(id)
+		}
+		fmt.Fprintf(&buf, `<circle cx="82" cy="%d" r="22" fill="%s"/>`+"\n", y-12, medals[i].color)
+		fmt.Fprintf(&buf, `<text x="82" y="%d" font-size="16" font-weight="700" fill="#0f1117" text-anchor="middle">%s</text>`+"\n", y-6, medals[i].label)
+		fmt.Fprintf(&buf, `<text x="124" y="%d" font-size="34" font-weight="600" fill="#f4f5f7">%s</text>`+"\n", y, html.EscapeString(strings.Join(names, ", ")))
+		y += 70
+	}
+
+	// Costs, in the same order as the models were listed
+	y = 300
+	var total float64
+	for _, model := range data.Models {
+		cost, ok := data.ModelCosts[model.ID]
+		if !ok {
+			continue
+		}
+		total += cost
+
+		fmt.Fprintf(&buf, `<text x="820" y="%d" font-size="24" fill="#9aa3b2">%s</text>`+"\n", y, html.EscapeString(formatModelName(model.ID)))
+		fmt.Fprintf(&buf, `<text x="1140" y="%d" font-size="24" fill="#f4f5f7" text-anchor="end" font-family="JetBrains Mono, monospace">%s</text>`+"\n", y, html.EscapeString(data.Locale.Cost(cost)))
+		y += 36
+	}
+	if total > 0 {
+		fmt.Fprintf(&buf, `<text x="60" y="%d" font-size="22" fill="#9aa3b2">Total cost %s</text>`+"\n", cardHeight-50, html.EscapeString(data.Locale.Cost(total)))
+	}
+	if data.Timestamp != "" {
+		fmt.Fprintf(&buf, `<text x="1140" y="%d" font-size="22" fill="#9aa3b2" text-anchor="end">%s</text>`+"\n", cardHeight-50, html.EscapeString(data.Timestamp))
+	}
+
+	buf.WriteString("</g>\n</svg>\n")
+	return buf.Bytes()
+}
+
+// wrapText breaks s into at most maxLines lines of up to width characters, ending with "…" if it had to cut
+func wrapText(s string, width, maxLines int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		if utf8.RuneCountInString(word) > width {
+			word = string([]rune(word)[:width-1]) + "…"
+		}
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := []rune(lines[maxLines-1])
+		if len(last) >= width {
+			last = last[:width-1]
+		}
+		lines[maxLines-1] = strings.TrimRight(string(last), " ") + "…"
+	}
+	return lines
+}
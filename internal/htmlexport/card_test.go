@@ -0,0 +1,62 @@
+package htmlexport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/locale"
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestRenderCard(t *testing.T) {
+	card := string(renderCard(ExportData{
+		Question:   "Is <b> & \"quoted\" text escaped?",
+		GoldIDs:    []string{"claude", "gpt"},
+		BronzeIDs:  []string{"grok"},
+		Models:     []*types.ModelInfo{{ID: "claude"}, {ID: "gpt"}, {ID: "grok"}},
+		ModelCosts: map[string]float64{"claude": 0.01, "gpt": 0.025},
+	}))
+
+	for _, want := range []string{
+		"Is &lt;b&gt; &amp; &#34;quoted&#34; text escaped?",
+		"Claude, GPT",
+		"Grok",
+		"$0.0250",
+		"Total cost $0.0350",
+	} {
+		if !strings.Contains(card, want) {
+			t.Errorf("Expected card to contain %q", want)
+		}
+	}
+	if strings.Contains(card, "2nd") {
+		t.Error("Expected no silver row when nobody won silver")
+	}
+}
+
+func TestRenderCardLocale(t *testing.T) {
+	f, err := locale.New("", "de")
+	if err != nil {
+		t.Fatalf("locale.New failed: %v", err)
+	}
+	card := string(renderCard(ExportData{
+		Question:   "Q?",
+		Models:     []*types.ModelInfo{{ID: "gpt"}},
+		ModelCosts: map[string]float64{"gpt": 1234.5},
+		Locale:     f,
+	}))
+
+	if !strings.Contains(card, "Total cost $1.234,5000") {
+		t.Error("Expected the total in German number format")
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	lines := wrapText("one two three four five six", 9, 2)
+	if len(lines) != 2 || lines[0] != "one two" || lines[1] != "three…" {
+		t.Errorf("Expected two lines cut with an ellipsis, got %q", lines)
+	}
+
+	if lines := wrapText("short", 9, 2); len(lines) != 1 || lines[0] != "short" {
+		t.Errorf("Expected a single untouched line, got %q", lines)
+	}
+}
@@ -11,42 +11,101 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/diff"
+	"github.com/meedamian/fat/internal/locale"
 	"github.com/meedamian/fat/internal/types"
 )
 
 type Exporter struct {
 	logger   *slog.Logger
 	staticFS fs.FS
+	dataDir  string // Exports are written to its h/
 }
 
-func New(logger *slog.Logger, staticFS fs.FS) *Exporter {
+func New(logger *slog.Logger, staticFS fs.FS, dataDir string) *Exporter {
 	return &Exporter{
 		logger:   logger,
 		staticFS: staticFS,
+		dataDir:  dataDir,
 	}
 }
 
 type ExportData struct {
-	Question        string
-	QuestionTS      int64    // Unix timestamp for directory
-	GoldIDs         []string // Models that won gold (can be multiple if tied)
-	SilverIDs       []string // Models that won silver
-	BronzeIDs       []string // Models that won bronze
-	Replies         map[string]types.Reply
-	AllRoundReplies map[string]map[int]db.ModelRound // Model ID -> Round -> ModelRound
-	Models          []*types.ModelInfo
-	Metrics         map[string]any
-	RoundCounts     map[string]int    // Model ID -> number of rounds completed
-	ModelCosts      map[string]string // Model ID -> formatted cost string
-	ModelScores     map[string]int    // Model ID -> ranking score
-	Discussions     []DiscussionPair
-	Timestamp       string
-	PageTitle       string // Formatted title for HTML <title> tag
+	Question          string
+	QuestionTS        int64    // Unix timestamp for directory
+	GoldIDs           []string // Models that won gold (can be multiple if tied)
+	SilverIDs         []string // Models that won silver
+	BronzeIDs         []string // Models that won bronze
+	Replies           map[string]types.Reply
+	AllRoundReplies   map[string]map[int]db.ModelRound // Model ID -> Round -> ModelRound
+	Models            []*types.ModelInfo
+	Metrics           map[string]any
+	RoundCounts       map[string]int     // Model ID -> number of rounds completed
+	ModelCosts        map[string]float64 // Model ID -> cost in dollars, only for models that cost anything
+	ModelScores       map[string]int     // Model ID -> ranking score
+	Discussions       []DiscussionPair
+	Timestamp         string             // When the export was made, rendered with Locale
+	Locale            locale.Format      // How timestamps and amounts are rendered
+	PageTitle         string             // Formatted title for HTML <title> tag
+	CardImage         string             // File name of the summary card next to the HTML, used as its og:image
+	Events            []db.Event         // Event log used by replay mode (optional)
+	Rankings          []db.Ranking       // Judges' rankings, whose justifications are shown under each answer (optional)
+	Verdict           *db.Ranking        // The meta judge's verdict, shown under the question, nil without one
+	AnswerMetrics     []db.AnswerMetric  // Operator-defined scorers' results, shown under each answer (optional)
+	SynthesizedAnswer string             // The top answers merged into one, shown above them; empty without a synthesizer
+	Synthesizer       string             // Model variant that wrote SynthesizedAnswer
+	Provenance        []types.Provenance // SynthesizedAnswer's paragraphs with the answers each draws on, nil if none were cited
+	Verifications     []db.Verification  // Participants' fact-checks of the winning answer, listed after the audit (optional)
+}
+
+// JudgeReason is a judge's placement of an answer with its one-line reason
+type JudgeReason struct {
+	Judge  string `json:"judge"`
+	Place  int    `json:"place"` // 1-based
+	Of     int    `json:"of"`    // Answers the judge ranked
+	Reason string `json:"reason"`
+}
+
+// AuditJudge is one judge's justified ranking, as listed in the audit panel
+type AuditJudge struct {
+	Judge      string             `json:"judge"`
+	Cost       float64            `json:"cost"`       // Of the judge's ranking call, in dollars
+	Placements []db.Justification `json:"placements"` // Best first
+}
+
+// ReplayEvent is an event log entry with its offset from the start of the run
+type ReplayEvent struct {
+	Type     string          `json:"type"`
+	OffsetMs int64           `json:"offsetMs"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// buildReplay converts the event log into replay events relative to the first event
+// Events keep their log order, so one timestamped earlier than the event before it is replayed right after it.
+func buildReplay(events []db.Event) []ReplayEvent {
+	replay := make([]ReplayEvent, 0, len(events))
+	if len(events) == 0 {
+		return replay
+	}
+
+	start := events[0].CreatedAt
+	var last int64
+	for _, e := range events {
+		last = max(last, e.CreatedAt.Sub(start).Milliseconds())
+		replay = append(replay, ReplayEvent{
+			Type:     e.Type,
+			OffsetMs: last,
+			Payload:  e.Payload,
+		})
+	}
+
+	return replay
 }
 
 type DiscussionPair struct {
@@ -82,6 +141,11 @@ func (e *Exporter) GenerateFilename(ctx context.Context, question string) (strin
 }
 
 func (e *Exporter) fallbackFilename(question string) string {
+	return Slug(question)
+}
+
+// Slug turns a question into a short filesystem-safe name
+func Slug(question string) string {
 	// Simple fallback: take first few words
 	words := strings.Fields(question)
 	if len(words) > 5 {
@@ -111,27 +175,27 @@ func (e *Exporter) Export(ctx context.Context, data ExportData) error {
 	// Set page title in data
 	data.PageTitle = pageTitle
 
+	outputPath := filepath.Join(e.dataDir, OutputPath(data.QuestionTS, slug, "html"))
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	// Write the summary card alongside, so the page can reference it
+	cardPath := filepath.Join(e.dataDir, OutputPath(data.QuestionTS, slug, "svg"))
+	if err := os.WriteFile(cardPath, renderCard(data), 0644); err != nil {
+		return fmt.Errorf("write card: %w", err)
+	}
+	data.CardImage = filepath.Base(cardPath)
+
 	// Generate HTML
 	html, err := e.renderHTML(data)
 	if err != nil {
 		return fmt.Errorf("generate HTML: %w", err)
 	}
 
-	// Format: ./h/YYYY-MM-DD/HHMM_slug.html
-	ts := time.Unix(data.QuestionTS, 0) // QuestionTS is in seconds
-	dateDir := ts.Format("2006-01-02")
-	timePrefix := ts.Format("1504")
-	filename := fmt.Sprintf("%s_%s.html", timePrefix, slug)
-
-	targetDir := filepath.Join("h", dateDir)
-
-	// Ensure directory exists
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		return fmt.Errorf("create directory: %w", err)
-	}
-
 	// Write file
-	outputPath := filepath.Join(targetDir, filename)
 	if err := os.WriteFile(outputPath, []byte(html), 0644); err != nil {
 		return fmt.Errorf("write file: %w", err)
 	}
@@ -140,6 +204,14 @@ func (e *Exporter) Export(ctx context.Context, data ExportData) error {
 	return nil
 }
 
+// OutputPath returns where an export of the given extension is written, relative to the data directory
+// It doubles as the export's remote storage key. Format: h/YYYY-MM-DD/HHMM_slug.ext
+func OutputPath(questionTS int64, slug, ext string) string {
+	ts := time.Unix(questionTS, 0) // QuestionTS is in seconds
+	filename := fmt.Sprintf("%s_%s.%s", ts.Format("1504"), slug, ext)
+	return filepath.Join("h", ts.Format("2006-01-02"), filename)
+}
+
 func (e *Exporter) renderHTML(data ExportData) (string, error) {
 	// Read CSS from embedded static directory
 	cssBytes, err := fs.ReadFile(e.staticFS, "static/style.css")
@@ -159,9 +231,7 @@ func (e *Exporter) renderHTML(data ExportData) (string, error) {
 		costValues := make(map[string]float64)
 		var minCost, maxCost float64
 		first := true
-		for modelID, costStr := range data.ModelCosts {
-			var cost float64
-			fmt.Sscanf(costStr, "$%f", &cost)
+		for modelID, cost := range data.ModelCosts {
 			costValues[modelID] = cost
 			if first {
 				minCost = cost
@@ -223,15 +293,33 @@ func (e *Exporter) renderHTML(data ExportData) (string, error) {
 		"bronzeIDs":       data.BronzeIDs,
 		"replies":         data.Replies,
 		"allRoundReplies": data.AllRoundReplies,
+		"roundDiffs":      roundDiffs(data.AllRoundReplies),
 		"models":          sortedModels,
 		"modelNames":      modelNames,
 		"metrics":         data.Metrics,
 		"roundCounts":     data.RoundCounts,
-		"modelCosts":      data.ModelCosts,
+		"modelCosts":      formatCosts(data.ModelCosts, data.Locale),
+		"totalCost":       data.Locale.Cost(totalCost(data.ModelCosts)),
+		"locale":          data.Locale.Lang(),
 		"costColors":      costColors,
 		"modelScores":     data.ModelScores,
 		"discussions":     data.Discussions,
 		"timestamp":       data.Timestamp,
+		"replay":          buildReplay(data.Events),
+		"judgeReasons":    judgeReasons(data.Rankings, data.Models),
+		"audit":           auditJudges(data.Rankings),
+		"customMetrics":   customMetrics(data.AnswerMetrics),
+	}
+	if data.Verdict != nil {
+		// The meta judge's reasons are listed with the judges'
+		exportData["judgeReasons"] = judgeReasons(append(slices.Clone(data.Rankings), *data.Verdict), data.Models)
+		exportData["verdict"] = map[string]string{"judge": data.Verdict.RankerModel, "justification": data.Verdict.Verdict}
+	}
+	if data.SynthesizedAnswer != "" {
+		exportData["synthesis"] = map[string]any{"synthesizer": data.Synthesizer, "answer": data.SynthesizedAnswer, "provenance": data.Provenance}
+	}
+	if len(data.Verifications) > 0 {
+		exportData["verifications"] = data.Verifications
 	}
 
 	dataJSON, err := json.Marshal(exportData)
@@ -247,8 +335,10 @@ func (e *Exporter) renderHTML(data ExportData) (string, error) {
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, map[string]any{
-		"CSS":  template.CSS(cssBytes),
-		"DATA": template.JS(dataJSON),
+		"CSS":   template.CSS(cssBytes),
+		"DATA":  template.JS(dataJSON),
+		"Title": data.PageTitle,
+		"Card":  data.CardImage,
 	}); err != nil {
 		return "", fmt.Errorf("execute template: %w", err)
 	}
@@ -256,6 +346,90 @@ func (e *Exporter) renderHTML(data ExportData) (string, error) {
 	return buf.String(), nil
 }
 
+// roundDiffs diffs each model's answers between rounds (model ID -> round -> changes since the previous answer)
+func roundDiffs(allRoundReplies map[string]map[int]db.ModelRound) map[string]map[int][]diff.Segment {
+	diffs := make(map[string]map[int][]diff.Segment, len(allRoundReplies))
+	for modelID, rounds := range allRoundReplies {
+		answers := make(map[int]string, len(rounds))
+		for r, mr := range rounds {
+			answers[r] = mr.Answer
+		}
+		diffs[modelID] = diff.Rounds(answers)
+	}
+	return diffs
+}
+
+// CustomMetric is an operator-defined scorer's result for an answer
+type CustomMetric struct {
+	Metric string  `json:"metric"`
+	Value  float64 `json:"value"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// customMetrics groups scorer results by model ID, in metric order
+func customMetrics(metrics []db.AnswerMetric) map[string][]CustomMetric {
+	byModel := make(map[string][]CustomMetric)
+	for _, m := range metrics {
+		byModel[m.ModelID] = append(byModel[m.ModelID], CustomMetric{Metric: m.Metric, Value: m.Value, Error: m.Error})
+	}
+	for _, ms := range byModel {
+		sort.Slice(ms, func(i, j int) bool { return ms[i].Metric < ms[j].Metric })
+	}
+	return byModel
+}
+
+// judgeReasons collects the judges' justified placements by model ID, in judge order
+// Rankings name models by variant; placements of models not in the run are dropped.
+func judgeReasons(rankings []db.Ranking, models []*types.ModelInfo) map[string][]JudgeReason {
+	idByName := make(map[string]string, len(models))
+	for _, mi := range models {
+		idByName[mi.Name] = mi.ID
+	}
+
+	reasons := make(map[string][]JudgeReason)
+	for _, r := range rankings {
+		for _, p := range r.JustifiedPlacements() {
+			if id, ok := idByName[p.Model]; ok {
+				reasons[id] = append(reasons[id], JudgeReason{Judge: p.Judge, Place: p.Place, Of: p.Of, Reason: p.Reason})
+			}
+		}
+	}
+	for _, rs := range reasons {
+		sort.SliceStable(rs, func(i, j int) bool { return rs[i].Judge < rs[j].Judge })
+	}
+	return reasons
+}
+
+// auditJudges lists the judges that justified their rankings, by name
+func auditJudges(rankings []db.Ranking) []AuditJudge {
+	var judges []AuditJudge
+	for _, r := range rankings {
+		if placements := r.JustifiedPlacements(); len(placements) > 0 {
+			judges = append(judges, AuditJudge{Judge: r.RankerModel, Cost: r.Cost, Placements: placements})
+		}
+	}
+	sort.SliceStable(judges, func(i, j int) bool { return judges[i].Judge < judges[j].Judge })
+	return judges
+}
+
+// formatCosts renders each model's cost for display
+func formatCosts(costs map[string]float64, f locale.Format) map[string]string {
+	formatted := make(map[string]string, len(costs))
+	for modelID, cost := range costs {
+		formatted[modelID] = f.Cost(cost)
+	}
+	return formatted
+}
+
+// totalCost sums the models' costs
+func totalCost(costs map[string]float64) float64 {
+	var total float64
+	for _, cost := range costs {
+		total += cost
+	}
+	return total
+}
+
 func formatModelName(id string) string {
 	switch id {
 	case "grok":
@@ -281,6 +455,15 @@ const htmlTemplate = `<!DOCTYPE html>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title id="pageTitle">Loading...</title>
+    <meta property="og:type" content="article">
+    <meta property="og:title" content="{{.Title}}">
+{{- if .Card}}
+    <meta property="og:image" content="{{.Card}}">
+    <meta property="og:image:width" content="1200">
+    <meta property="og:image:height" content="630">
+    <meta name="twitter:card" content="summary_large_image">
+    <meta name="twitter:image" content="{{.Card}}">
+{{- end}}
     <link rel="preconnect" href="https://fonts.googleapis.com">
     <link rel="preconnect" href="https://fonts.gstatic.com" crossorigin>
     <link href="https://fonts.googleapis.com/css2?family=Inter:wght@400;500;600;700;800&family=JetBrains+Mono:wght@400;500;600&display=swap" rel="stylesheet">
@@ -341,6 +524,103 @@ const htmlTemplate = `<!DOCTYPE html>
     gap: 6px;
 }
 
+/* Operator-defined metrics for each answer */
+.custom-metrics {
+    display: flex;
+    flex-wrap: wrap;
+    gap: 6px;
+    margin-top: 12px;
+}
+
+.custom-metric {
+    font-family: 'JetBrains Mono', monospace;
+    font-size: 0.8em;
+    padding: 2px 8px;
+    border-radius: 4px;
+    background: rgba(255, 255, 255, 0.06);
+    color: var(--text-muted);
+}
+
+.custom-metric strong {
+    color: var(--text-main);
+}
+
+.custom-metric.failed {
+    text-decoration: line-through;
+}
+
+/* The meta judge's final verdict */
+.verdict {
+    margin-top: 16px;
+    padding: 12px 16px;
+    border-left: 3px solid var(--text-muted);
+    background: rgba(255, 255, 255, 0.03);
+}
+
+.verdict h3 {
+    margin: 0 0 6px 0;
+    font-size: 0.9em;
+    color: var(--text-muted);
+    font-weight: 600;
+}
+
+.verdict p {
+    margin: 0;
+    white-space: pre-wrap;
+}
+
+/* The top answers merged into one */
+.synthesis {
+    margin-top: 16px;
+    padding: 12px 16px;
+    border-left: 3px solid var(--accent-primary);
+    background: rgba(255, 255, 255, 0.05);
+}
+
+.synthesis h3 {
+    margin: 0 0 6px 0;
+    font-size: 0.9em;
+    color: var(--text-muted);
+    font-weight: 600;
+}
+
+.synthesis-sources {
+    margin: -4px 0 10px 0;
+    font-size: 0.8em;
+    color: var(--text-muted);
+}
+
+/* Judges' reasons for each placement */
+.judge-reasons {
+    margin-top: 12px;
+    padding-top: 10px;
+    border-top: 1px dashed rgba(255, 255, 255, 0.1);
+}
+
+.judge-reasons summary {
+    cursor: pointer;
+    color: var(--text-muted);
+    font-size: 0.85em;
+    font-weight: 500;
+    user-select: none;
+    padding: 4px 0;
+}
+
+.judge-reasons ul {
+    margin: 8px 0 0 0;
+    padding-left: 18px;
+    font-size: 0.85em;
+}
+
+.judge-reasons li {
+    margin-bottom: 4px;
+}
+
+.judge-reasons .judge-place {
+    color: var(--text-muted);
+    font-family: 'JetBrains Mono', monospace;
+}
+
 /* Hide dropdown arrows - not interactive in static export */
 .model-chip::after,
 select.model-chip,
@@ -649,6 +929,34 @@ select.model-chip,
     font-weight: 600;
 }
 
+/* Replay mode */
+.replay-controls {
+    display: none;
+    align-items: center;
+    gap: 12px;
+    margin-left: auto;
+}
+
+.replay-button {
+    padding: 4px 12px;
+    background: rgba(124, 92, 255, 0.2);
+    border: 1px solid rgba(124, 92, 255, 0.5);
+    border-radius: 999px;
+    color: var(--text-main);
+    font-size: 12px;
+    font-weight: 600;
+    cursor: pointer;
+    font-family: inherit;
+}
+
+.replay-button:hover {
+    background: rgba(124, 92, 255, 0.35);
+}
+
+.model-card.replay-pending .answer-text {
+    opacity: 0.4;
+}
+
 /* Hero layout - move winners to top in narrow view */
 @media (max-width: 768px) {
     .gallery-stage {
@@ -686,6 +994,18 @@ select.model-chip,
                     <div class="question-meta">
                         <span>📅 <span id="questionDate"></span></span>
                         <span>💰 Total: <span id="totalCost"></span></span>
+                        <span id="replayControls" class="replay-controls">
+                            <span id="replayStatus"></span>
+                            <button id="replayButton" class="replay-button" type="button">▶ Replay</button>
+                        </span>
+                    </div>
+                    <div id="verdict" class="verdict" style="display: none;">
+                        <h3>⚖️ Final verdict by <span id="verdictJudge"></span></h3>
+                        <p id="verdictText"></p>
+                    </div>
+                    <div id="synthesis" class="synthesis" style="display: none;">
+                        <h3>🧩 The top answers merged by <span id="synthesizer"></span></h3>
+                        <div id="synthesisText" class="answer-text"></div>
                     </div>
                 </div>
             </section>
@@ -708,6 +1028,21 @@ select.model-chip,
                     <!-- Discussions will be rendered by JavaScript -->
                 </div>
             </section>
+
+            <section id="auditSection" class="audit-section hidden">
+                <h2>Audit</h2>
+                <div id="auditContainer">
+                    <!-- Every judge's reasons will be rendered by JavaScript -->
+                </div>
+            </section>
+
+            <section id="verificationSection" class="audit-section hidden">
+                <h2>Verification</h2>
+                <p id="verificationSummary" class="verification-summary"></p>
+                <div id="verificationContainer">
+                    <!-- Every verifier's checked claims will be rendered by JavaScript -->
+                </div>
+            </section>
         </main>
 
         <footer class="footer">
@@ -728,17 +1063,30 @@ select.model-chip,
         // Set question date
         document.getElementById('questionDate').textContent = DATA.timestamp;
         
-        // Calculate and display total cost
-        let totalCost = 0;
-        Object.values(DATA.modelCosts).forEach(costStr => {
-            const cost = parseFloat(costStr.replace('$', '')) || 0;
-            totalCost += cost;
-        });
-        document.getElementById('totalCost').textContent = '$' + totalCost.toFixed(4);
+        // Display total cost
+        document.getElementById('totalCost').textContent = DATA.totalCost;
         
         // Set footer timestamp
         document.getElementById('timestamp').textContent = DATA.timestamp;
         
+        // The meta judge's verdict, when it had the final say
+        if (DATA.verdict) {
+            document.getElementById('verdictJudge').textContent = DATA.verdict.judge;
+            document.getElementById('verdictText').textContent = DATA.verdict.justification || 'No justification given.';
+            document.getElementById('verdict').style.display = '';
+        }
+        
+        // The top answers merged into one, above the answers themselves
+        if (DATA.synthesis) {
+            document.getElementById('synthesizer').textContent = DATA.synthesis.synthesizer;
+            const provenance = DATA.synthesis.provenance || [];
+            document.getElementById('synthesisText').innerHTML = provenance.length === 0
+                ? marked.parse(DATA.synthesis.answer)
+                : provenance.map(p => marked.parse(p.paragraph) +
+                    ((p.sources || []).length > 0 ? '<p class="synthesis-sources">from ' + p.sources.map(escapeHTML).join(', ') + '</p>' : '')).join('');
+            document.getElementById('synthesis').style.display = '';
+        }
+        
         // Render model cards
         const galleryStage = document.getElementById('galleryStage');
         DATA.models.forEach(model => {
@@ -766,7 +1114,7 @@ select.model-chip,
                 'deepseek': 'DeepSeek',
                 'mistral': 'Mistral AI'
             };
-            const provider = providerMap[model.ID] || model.ID;
+            const provider = providerMap[model.Family || model.ID] || model.ID;
             
             // Medal
             let medalHTML = '';
@@ -797,6 +1145,7 @@ select.model-chip,
                 if (reply.Rationale) {
                     outputHTML += '<div class="rationale-text">' + marked.parse(reply.Rationale) + '</div>';
                 }
+                outputHTML += diffHTML(model.ID, roundCount);
             } else {
                 outputHTML = '<p class="placeholder">No response</p>';
             }
@@ -818,7 +1167,9 @@ select.model-chip,
                 '</div>' +
                 '<div class="model-output">' +
                     outputHTML +
-                '</div>';
+                '</div>' +
+                customMetricsHTML(model.ID) +
+                reasonsHTML(model.ID);
             
             galleryStage.appendChild(card);
         });
@@ -918,6 +1269,23 @@ select.model-chip,
             renderDiscussions();
         }
         
+        // Why each judge placed every answer where it did
+        if (DATA.audit && DATA.audit.length > 0) {
+            document.getElementById('auditContainer').innerHTML = auditHTML(DATA.audit);
+            document.getElementById('auditSection').classList.remove('hidden');
+        }
+        
+        // Every participant's fact-check of the winning answer
+        if (DATA.verifications && DATA.verifications.length > 0) {
+            const checked = DATA.verifications[0].answer_model;
+            const flagged = DATA.verifications.reduce((n, v) => n + (v.claims || []).filter(c => c.status !== 'supported').length, 0);
+            document.getElementById('verificationSummary').textContent =
+                'Every participant fact-checked the answer of ' + (DATA.modelNames[checked] || checked) + ': ' +
+                (flagged === 0 ? 'no claims flagged.' : flagged + ' claims flagged as unsupported or uncertain.');
+            document.getElementById('verificationContainer').innerHTML = verificationHTML(DATA.verifications);
+            document.getElementById('verificationSection').classList.remove('hidden');
+        }
+        
         // Add round dot interactivity
         const allRoundReplies = DATA.allRoundReplies;
         const currentRounds = {};
@@ -961,6 +1329,13 @@ select.model-chip,
                         rationaleDiv.innerHTML = marked.parse(roundReply.Rationale);
                         modelOutput.appendChild(rationaleDiv);
                     }
+
+                    // Swap in the changes leading up to this round
+                    const oldDiff = card.querySelector('.answer-diff-container');
+                    if (oldDiff) {
+                        oldDiff.remove();
+                    }
+                    card.querySelector('.model-output').insertAdjacentHTML('beforeend', diffHTML(modelId, roundNumber));
                     
                     // Highlight the selected dot
                     dots.forEach((d, i) => {
@@ -977,9 +1352,198 @@ select.model-chip,
                 });
             });
         });
+        // Replay mode: re-run the recorded event log with original (scaled) timing
+        const REPLAY_MAX_MS = 30000;
+        const replayEvents = DATA.replay || [];
+        if (replayEvents.some(e => e.type === 'response')) {
+            const replayControls = document.getElementById('replayControls');
+            const replayButton = document.getElementById('replayButton');
+            const replayStatus = document.getElementById('replayStatus');
+            replayControls.style.display = 'flex';
+
+            let replayTimers = [];
+            // Keep the original nodes (and their round dot handlers) to restore after replay
+            const finalCards = {};
+            document.querySelectorAll('.model-card').forEach(card => {
+                finalCards[card.dataset.model] = { className: card.className, nodes: Array.from(card.childNodes) };
+            });
+
+            function restoreFinal() {
+                document.querySelectorAll('.model-card').forEach(card => {
+                    const saved = finalCards[card.dataset.model];
+                    card.className = saved.className;
+                    card.replaceChildren(...saved.nodes);
+                });
+            }
+
+            function stopReplay() {
+                replayTimers.forEach(clearTimeout);
+                replayTimers = [];
+                restoreFinal();
+                replayStatus.textContent = '';
+                replayButton.textContent = '▶ Replay';
+            }
+
+            function applyEvent(ev) {
+                const p = ev.payload || {};
+                const card = p.model ? document.getElementById(p.model) : null;
+                switch (ev.type) {
+                    case 'round_start':
+                        replayStatus.textContent = 'Round ' + p.round + ' of ' + p.total;
+                        break;
+                    case 'response':
+                        if (!card) break;
+                        card.classList.remove('replay-pending');
+                        card.querySelector('.model-output').innerHTML =
+                            '<div class="answer-text">' + marked.parse(p.response || '') + '</div>' +
+                            (p.rationale ? '<div class="rationale-text">' + marked.parse(p.rationale) + '</div>' : '');
+                        card.querySelector('.round-progress').innerHTML =
+                            '<span class="round-dot filled"></span>'.repeat(p.round);
+                        break;
+                    case 'error':
+                        if (!card) break;
+                        card.querySelector('.model-output').innerHTML =
+                            '<p class="placeholder">' + escapeHTML(p.error) + '</p>';
+                        break;
+                    case 'ranking_start':
+                        replayStatus.textContent = 'Ranking…';
+                        break;
+                    case 'verification_start':
+                        replayStatus.textContent = 'Verifying…';
+                        break;
+                    case 'winner':
+                        restoreFinal();
+                        replayStatus.textContent = 'Done';
+                        replayButton.textContent = '▶ Replay';
+                        replayTimers = [];
+                        break;
+                }
+            }
+
+            function startReplay() {
+                document.querySelectorAll('.model-card').forEach(card => {
+                    card.replaceChildren(...finalCards[card.dataset.model].nodes.map(n => n.cloneNode(true)));
+                    card.className = 'model-card replay-pending';
+                    const medal = card.querySelector('.model-medal-center');
+                    if (medal) medal.remove();
+                    card.querySelector('.model-output').innerHTML = '<p class="placeholder">Waiting…</p>';
+                    card.querySelector('.round-progress').innerHTML = '';
+                });
+
+                // Keep original pacing, but compress long runs to fit REPLAY_MAX_MS
+                const totalMs = replayEvents[replayEvents.length - 1].offsetMs || 1;
+                const scale = Math.min(1, REPLAY_MAX_MS / totalMs);
+                replayEvents.forEach(ev => {
+                    replayTimers.push(setTimeout(() => applyEvent(ev), ev.offsetMs * scale));
+                });
+                replayButton.textContent = '■ Stop';
+            }
+
+            replayButton.addEventListener('click', () => {
+                if (replayTimers.length > 0) {
+                    stopReplay();
+                } else {
+                    startReplay();
+                }
+            });
+
+            if (window.location.hash === '#replay') {
+                startReplay();
+            }
+        }
     });
     
     // Helper function to escape HTML
+    // Collapsible word-level changes of a model's answer since its previous round
+    function diffHTML(modelId, round) {
+        const segments = (DATA.roundDiffs[modelId] || {})[round];
+        if (!segments || !segments.some(s => s.op !== '=')) {
+            return '';
+        }
+        let added = 0, removed = 0;
+        const body = segments.map(s => {
+            const words = (s.text.match(/[\p{L}\p{N}_']+/gu) || []).length;
+            if (s.op === '+') {
+                added += words;
+                return '<ins>' + escapeHTML(s.text) + '</ins>';
+            }
+            if (s.op === '-') {
+                removed += words;
+                return '<del>' + escapeHTML(s.text) + '</del>';
+            }
+            return escapeHTML(s.text);
+        }).join('');
+        return '<details class="answer-diff-container">' +
+            '<summary class="answer-diff-summary">Changes in round ' + round + ' (+' + added + ' / −' + removed + ' words)</summary>' +
+            '<div class="answer-diff-text">' + body + '</div>' +
+            '</details>';
+    }
+
+    // Operator-defined metrics for a model's final answer; failed ones are struck through with the error as a tooltip
+    function customMetricsHTML(modelId) {
+        const metrics = (DATA.customMetrics || {})[modelId];
+        if (!metrics || metrics.length === 0) {
+            return '';
+        }
+        const items = metrics.map(m => m.error
+            ? '<span class="custom-metric failed" title="' + escapeHTML(m.error) + '">' + escapeHTML(m.metric) + '</span>'
+            : '<span class="custom-metric">' + escapeHTML(m.metric) + ' <strong>' + Number(m.value.toFixed(4)) + '</strong></span>'
+        ).join('');
+        return '<div class="custom-metrics">' + items + '</div>';
+    }
+
+    // Collapsible list of why each judge placed a model's answer where it did
+    function reasonsHTML(modelId) {
+        const reasons = (DATA.judgeReasons || {})[modelId];
+        if (!reasons || reasons.length === 0) {
+            return '';
+        }
+        const items = reasons.map(r =>
+            '<li><span class="judge-place">' + escapeHTML(r.judge) + ' #' + r.place + '/' + r.of + '</span> ' + escapeHTML(r.reason) + '</li>'
+        ).join('');
+        return '<details class="judge-reasons">' +
+            '<summary>Why the judges placed it (' + reasons.length + ')</summary>' +
+            '<ul>' + items + '</ul>' +
+            '</details>';
+    }
+
+    // Every judge's ranking with its reason for each placement and what the ranking cost
+    function auditHTML(judges) {
+        return judges.map(j =>
+            '<div class="audit-judge">' +
+                '<h3>' + escapeHTML(j.judge) + '<span class="audit-cost">' + formatCost(j.cost) + '</span></h3>' +
+                '<ol>' + j.placements.map(p =>
+                    '<li value="' + p.place + '"><span class="audit-model">' + escapeHTML(p.model) + '</span> ' + escapeHTML(p.reason) + '</li>'
+                ).join('') + '</ol>' +
+            '</div>'
+        ).join('');
+    }
+
+    function verificationHTML(verifications) {
+        return verifications.map(v => {
+            let body;
+            if (v.error) {
+                body = '<p class="claim-note">Failed: ' + escapeHTML(v.error) + '</p>';
+            } else if (!v.claims || v.claims.length === 0) {
+                body = '<p class="claim-note">No checkable claims found.</p>';
+            } else {
+                body = '<ul class="claims">' + v.claims.map(c =>
+                    '<li class="claim claim-' + escapeHTML(c.status) + '"><span class="claim-status">' + escapeHTML(c.status) + '</span> ' +
+                        escapeHTML(c.text) + (c.note ? ' <span class="claim-note">— ' + escapeHTML(c.note) + '</span>' : '') + '</li>'
+                ).join('') + '</ul>';
+            }
+            return '<div class="audit-judge">' +
+                '<h3>' + escapeHTML(v.verifier_name) + '<span class="audit-cost">' + formatCost(v.cost) + '</span></h3>' +
+                body +
+            '</div>';
+        }).join('');
+    }
+
+    // Amounts in dollars, to the hundredth of a cent, in the export's locale
+    function formatCost(dollars) {
+        return '$' + dollars.toLocaleString(DATA.locale, {minimumFractionDigits: 4, maximumFractionDigits: 4});
+    }
+
     function escapeHTML(str) {
         if (!str) return '';
         const div = document.createElement('div');
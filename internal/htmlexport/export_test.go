@@ -0,0 +1,81 @@
+package htmlexport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestJudgeReasons(t *testing.T) {
+	models := []*types.ModelInfo{{ID: "grok", Name: "grok-4"}, {ID: "gpt", Name: "gpt-5"}}
+	rankings := []db.Ranking{
+		{RankerModel: "gpt-5", RankedModels: `["grok-4","gpt-5"]`, Justifications: `{"grok-4":"Cites sources","gpt-5":"Too vague"}`},
+		{RankerModel: "claude-opus-4-6", RankedModels: `["gpt-5","grok-4"]`, Justifications: `{"grok-4":"Misses the edge case"}`},
+		{RankerModel: "grok-4", RankedModels: `["grok-4","gpt-5"]`}, // Not asked for reasons
+	}
+
+	reasons := judgeReasons(rankings, models)
+
+	grok := reasons["grok"]
+	if len(grok) != 2 || grok[0].Judge != "claude-opus-4-6" || grok[0].Place != 2 || grok[1].Reason != "Cites sources" || grok[1].Of != 2 {
+		t.Errorf("Expected grok's 2 reasons ordered by judge, got %+v", grok)
+	}
+	if gpt := reasons["gpt"]; len(gpt) != 1 || gpt[0].Place != 2 || gpt[0].Reason != "Too vague" {
+		t.Errorf("Expected gpt's single reason, got %+v", gpt)
+	}
+}
+
+func TestAuditJudges(t *testing.T) {
+	rankings := []db.Ranking{
+		{RankerModel: "gpt-5", RankedModels: `["grok-4","gpt-5"]`, Justifications: `{"grok-4":"Cites sources","gpt-5":"Too vague"}`, Cost: 0.002},
+		{RankerModel: "grok-4", RankedModels: `["grok-4","gpt-5"]`}, // Not asked for reasons
+		{RankerModel: "claude-opus-4-6", RankedModels: `["gpt-5","grok-4"]`, Justifications: `{"grok-4":"Misses the edge case"}`},
+	}
+
+	judges := auditJudges(rankings)
+	if len(judges) != 2 || judges[0].Judge != "claude-opus-4-6" || judges[1].Judge != "gpt-5" {
+		t.Fatalf("Expected the 2 justifying judges by name, got %+v", judges)
+	}
+	if p := judges[1].Placements; len(p) != 2 || p[0].Model != "grok-4" || p[1].Reason != "Too vague" || judges[1].Cost != 0.002 {
+		t.Errorf("Unexpected placements of gpt-5 %+v", judges[1])
+	}
+	if p := judges[0].Placements; len(p) != 1 || p[0].Place != 2 {
+		t.Errorf("Expected claude-opus-4-6's single reason in 2nd place, got %+v", p)
+	}
+}
+
+func TestCustomMetrics(t *testing.T) {
+	metrics := customMetrics([]db.AnswerMetric{
+		{ModelID: "grok", Metric: "words", Value: 120},
+		{ModelID: "grok", Metric: "bleu", Value: 0.42},
+		{ModelID: "gpt", Metric: "bleu", Error: "command timed out"},
+	})
+
+	if grok := metrics["grok"]; len(grok) != 2 || grok[0].Metric != "bleu" || grok[1].Value != 120 {
+		t.Errorf("Expected grok's 2 metrics ordered by name, got %+v", grok)
+	}
+	if gpt := metrics["gpt"]; len(gpt) != 1 || gpt[0].Error == "" {
+		t.Errorf("Expected gpt's failed metric kept with its error, got %+v", gpt)
+	}
+}
+
+func TestBuildReplay(t *testing.T) {
+	start := time.Date(2025, 1, 3, 12, 0, 0, 0, time.UTC)
+	events := []db.Event{
+		{Type: "clear", CreatedAt: start},
+		{Type: "round_start", CreatedAt: start.Add(120 * time.Millisecond)},
+		{Type: "response", CreatedAt: start.Add(870 * time.Millisecond)},
+		{Type: "response", CreatedAt: start.Add(860 * time.Millisecond)}, // Logged after, stamped a little earlier
+	}
+
+	replay := buildReplay(events)
+	var offsets []int64
+	for _, e := range replay {
+		offsets = append(offsets, e.OffsetMs)
+	}
+	if len(offsets) != 4 || offsets[1] != 120 || offsets[2] != 870 || offsets[3] != 870 {
+		t.Errorf("Expected events within a second paced by milliseconds and never before the one logged ahead, got %v", offsets)
+	}
+}
@@ -0,0 +1,115 @@
+package htmlexport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+// TournamentPath returns where a tournament's export of the given extension is written, relative to the data directory
+// Tournaments are kept apart from runs of the same question asked in the same minute.
+func TournamentPath(questionTS int64, question, ext string) string {
+	return OutputPath(questionTS, Slug(question)+"-tournament", ext)
+}
+
+// ExportTournament saves t's bracket as a static HTML page
+func (e *Exporter) ExportTournament(ctx context.Context, t db.Tournament) error {
+	outputPath := filepath.Join(e.dataDir, TournamentPath(t.QuestionTS, t.Question, "html"))
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	page, err := RenderTournament(t)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outputPath, page, 0644); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	e.logger.Info("tournament exported", slog.String("path", outputPath))
+	return nil
+}
+
+// RenderTournament draws t's bracket, one column per round, followed by every entrant's answer
+func RenderTournament(t db.Tournament) ([]byte, error) {
+	var rounds [][]db.TournamentMatch
+	for _, m := range t.Matches {
+		for len(rounds) < m.Round {
+			rounds = append(rounds, nil)
+		}
+		rounds[m.Round-1] = append(rounds[m.Round-1], m)
+	}
+
+	seeds := make(map[string]int, len(t.Entries))
+	for _, e := range t.Entries {
+		seeds[e.ModelName] = e.Seed
+	}
+
+	var buf bytes.Buffer
+	if err := tournamentTemplate.Execute(&buf, map[string]any{
+		"T":      t,
+		"Rounds": rounds,
+		"Seeds":  seeds,
+		"Cost":   fmt.Sprintf("$%.4f", t.TotalCost),
+	}); err != nil {
+		return nil, fmt.Errorf("execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+var tournamentTemplate = template.Must(template.New("tournament").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.T.Question}} - tournament</title>
+<style>
+body { font-family: Inter, Helvetica, Arial, sans-serif; background: #0f1117; color: #f4f5f7; margin: 2rem; }
+.bracket { display: flex; gap: 2rem; overflow-x: auto; }
+.round { display: flex; flex-direction: column; justify-content: space-around; gap: 1rem; min-width: 14rem; }
+.match { border: 1px solid #2a2e3a; border-radius: 6px; padding: .5rem; }
+.side { display: flex; justify-content: space-between; padding: .2rem .4rem; color: #8a90a0; }
+.side.won { color: #f4f5f7; font-weight: 600; background: rgba(245, 197, 66, .15); }
+.votes { font-size: .8rem; color: #8a90a0; margin-top: .3rem; }
+.winner { color: #f5c542; }
+pre { white-space: pre-wrap; background: #171a23; padding: 1rem; border-radius: 6px; }
+</style>
+</head>
+<body>
+<h1>{{.T.Question}}</h1>
+<p>{{if .T.Winner}}Winner: <strong class="winner">{{.T.Winner}}</strong> · {{end}}Jury: {{range $i, $j := .T.Jury}}{{if $i}}, {{end}}{{$j}}{{end}} · Cost: {{.Cost}}</p>
+<div class="bracket">
+{{- range $r, $round := .Rounds}}
+<div class="round">
+<h3>Round {{inc $r}}</h3>
+{{- range $round}}
+<div class="match">
+<div class="side{{if and .ModelA (eq .Winner .ModelA)}} won{{end}}"><span>{{.ModelA}}</span><span>{{index $.Seeds .ModelA}}</span></div>
+<div class="side{{if and .ModelB (eq .Winner .ModelB)}} won{{end}}"><span>{{if .ModelB}}{{.ModelB}}{{else}}bye{{end}}</span><span>{{if .ModelB}}{{index $.Seeds .ModelB}}{{end}}</span></div>
+{{- if .Votes}}
+<div class="votes">{{range .Votes}}<div>{{.Juror}}: {{if .Error}}failed{{else}}{{.Vote}}{{if .Reason}} - {{.Reason}}{{end}}{{end}}</div>{{end}}</div>
+{{- end}}
+</div>
+{{- end}}
+</div>
+{{- end}}
+</div>
+<h2>Answers</h2>
+{{- range .T.Entries}}
+<h3>{{.Seed}}. {{.ModelName}}</h3>
+{{if .Error}}<p>Failed to answer: {{.Error}}</p>{{else}}<pre>{{.Answer}}</pre>{{end}}
+{{- end}}
+</body>
+</html>
+`))
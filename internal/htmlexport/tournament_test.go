@@ -0,0 +1,44 @@
+package htmlexport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+func TestRenderTournament(t *testing.T) {
+	page, err := RenderTournament(db.Tournament{
+		Question: "Is <Pluto> a planet?",
+		Jury:     []string{"gpt-5"},
+		Winner:   "gpt-5-mini",
+		Entries: []db.TournamentEntry{
+			{ModelName: "gpt-5", Seed: 1, Answer: "No"},
+			{ModelName: "gpt-5-mini", Seed: 2, Answer: "No, since 2006"},
+			{ModelName: "gpt-4.1", Seed: 3, Error: "timeout"},
+		},
+		Matches: []db.TournamentMatch{
+			{Round: 1, Slot: 0, ModelA: "gpt-5", Winner: "gpt-5"},
+			{Round: 1, Slot: 1, ModelA: "gpt-5-mini", ModelB: "gpt-4.1", Winner: "gpt-5-mini"},
+			{Round: 2, Slot: 0, ModelA: "gpt-5", ModelB: "gpt-5-mini", Winner: "gpt-5-mini", Votes: []db.MatchVote{{Juror: "gpt-5", Vote: "gpt-5-mini", Reason: "Dates the decision"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html := string(page)
+	for _, want := range []string{
+		"<h1>Is &lt;Pluto&gt; a planet?</h1>",
+		`<strong class="winner">gpt-5-mini</strong>`,
+		"<h3>Round 2</h3>",
+		"<span>bye</span>",
+		`<div class="side won"><span>gpt-5-mini</span><span>2</span></div>`,
+		"gpt-5: gpt-5-mini - Dates the decision",
+		"<p>Failed to answer: timeout</p>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected the bracket to contain %q\n%s", want, html)
+		}
+	}
+}
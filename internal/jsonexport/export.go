@@ -0,0 +1,327 @@
+// Package jsonexport reconstructs a completed request from the database as a stable,
+// versioned JSON document for machine consumption.
+package jsonexport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/diff"
+	"github.com/meedamian/fat/internal/embeddings"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// SchemaVersion is bumped whenever a field is removed or changes meaning
+// Adding fields does not change the version
+const SchemaVersion = 1
+
+// ErrNotFound is returned when no completed request exists with the given ID
+var ErrNotFound = errors.New("request not found")
+
+// Document is the full export of a completed request
+type Document struct {
+	SchemaVersion int       `json:"schema_version"`
+	Request       Request   `json:"request"`
+	Models        []Model   `json:"models"`
+	Discussion    []Message `json:"discussion"`
+	Rankings      []Ranking `json:"rankings"`
+	Costs         Costs     `json:"costs"`
+
+	FinalRanking []shared.Placement     `json:"final_ranking,omitempty"` // Every model's aggregated place by ID, best first, when stored
+	Similarity   *embeddings.Similarity `json:"similarity,omitempty"`    // Pairwise similarity of the final answers, when embeddings were on
+
+	Verifications []db.Verification `json:"verifications,omitempty"` // Every participant's fact-check of the winning answer, by verifier
+}
+
+// Request is the request-level summary
+type Request struct {
+	ID              string    `json:"id"`
+	Question        string    `json:"question"`
+	Tag             string    `json:"tag,omitempty"`
+	ParentRequestID string    `json:"parent_request_id,omitempty"` // Request this one followed up on
+	ClientRef       string    `json:"client_ref,omitempty"`        // Opaque reference the submitter attached
+	NumRounds       int       `json:"num_rounds"`
+	NumModels       int       `json:"num_models"`
+	WinnerModel     string    `json:"winner_model"`
+	Difficulty      *float64  `json:"difficulty"`
+	TotalDurationMs int64     `json:"total_duration_ms"`
+	TotalTokensIn   int64     `json:"total_tokens_in"`
+	TotalTokensOut  int64     `json:"total_tokens_out"`
+	ErrorCount      int       `json:"error_count"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	SynthesizedAnswer string `json:"synthesized_answer,omitempty"` // The top answers merged into one
+	Synthesizer       string `json:"synthesizer,omitempty"`        // Model variant that merged them
+
+	SynthesisProvenance []types.Provenance `json:"synthesis_provenance,omitempty"` // The synthesized answer's paragraphs with the answers each draws on
+}
+
+// Model is one participant and its answers in every round
+type Model struct {
+	ModelID   string             `json:"model_id"`
+	ModelName string             `json:"model_name"`
+	TokensIn  int64              `json:"tokens_in"`
+	TokensOut int64              `json:"tokens_out"`
+	Cost      float64            `json:"cost"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"` // Operator-defined scores of the final answer
+	Rounds    []Round            `json:"rounds"`
+}
+
+// Round is a model's reply in one round; private notes are deliberately omitted
+type Round struct {
+	Round      int            `json:"round"`
+	Answer     string         `json:"answer"`
+	Rationale  string         `json:"rationale,omitempty"`
+	Transforms []string       `json:"transforms,omitempty"` // Post-processing steps that changed the reply
+	Diff       []diff.Segment `json:"diff,omitempty"`       // Word-level changes since the model's previous answer
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	TokensIn   int64          `json:"tokens_in"`
+	TokensOut  int64          `json:"tokens_out"`
+	Cost       float64        `json:"cost"`
+}
+
+// Message is a discussion message one model addressed to another
+type Message struct {
+	Round   int    `json:"round"`
+	From    string `json:"from"` // Model ID
+	To      string `json:"to"`   // Agent name as written by the sender
+	Message string `json:"message"`
+}
+
+// Ranking is one judge's ordering of the final answers, best first
+type Ranking struct {
+	Judge          string            `json:"judge"`
+	Ranked         []string          `json:"ranked"`
+	Justifications map[string]string `json:"justifications,omitempty"` // Model name -> the judge's reason for its place
+	DurationMs     int64             `json:"duration_ms"`
+	TokensIn       int64             `json:"tokens_in"`
+	TokensOut      int64             `json:"tokens_out"`
+	Cost           float64           `json:"cost"`
+}
+
+// Costs splits total spend between answering rounds, ranking and verification
+type Costs struct {
+	Rounds       float64 `json:"rounds"`
+	Ranking      float64 `json:"ranking"`
+	Verification float64 `json:"verification,omitempty"`
+	Total        float64 `json:"total"`
+}
+
+// Build reconstructs the export document for a completed request
+func Build(ctx context.Context, database *db.DB, requestID string) (*Document, error) {
+	req, err := database.GetRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, ErrNotFound
+	}
+
+	rounds, err := database.GetRoundReplies(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	rankings, err := database.GetRequestRankings(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	answerMetrics, err := database.GetAnswerMetrics(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	metrics := make(map[string]map[string]float64)
+	for _, am := range answerMetrics {
+		if am.Error != "" {
+			continue
+		}
+		if metrics[am.ModelID] == nil {
+			metrics[am.ModelID] = make(map[string]float64)
+		}
+		metrics[am.ModelID][am.Metric] = am.Value
+	}
+
+	doc := &Document{
+		SchemaVersion: SchemaVersion,
+		Request: Request{
+			ID:              req.ID,
+			Question:        req.Question,
+			Tag:             req.Tag,
+			NumRounds:       req.NumRounds,
+			NumModels:       req.NumModels,
+			WinnerModel:     req.WinnerModel,
+			ParentRequestID: req.ParentRequestID,
+			ClientRef:       req.ClientRef,
+			Difficulty:      req.Difficulty,
+			TotalDurationMs: req.TotalDurationMs,
+			TotalTokensIn:   req.TotalTokensIn,
+			TotalTokensOut:  req.TotalTokensOut,
+			ErrorCount:      req.ErrorCount,
+			CreatedAt:       req.CreatedAt,
+
+			SynthesizedAnswer: req.SynthesizedAnswer,
+			Synthesizer:       req.Synthesizer,
+		},
+		Models:     []Model{},
+		Discussion: []Message{},
+		Rankings:   []Ranking{},
+	}
+	if req.FinalRanking != "" {
+		json.Unmarshal([]byte(req.FinalRanking), &doc.FinalRanking)
+	}
+	if req.Provenance != "" {
+		json.Unmarshal([]byte(req.Provenance), &doc.Request.SynthesisProvenance)
+	}
+
+	modelIDs := make([]string, 0, len(rounds))
+	for modelID := range rounds {
+		modelIDs = append(modelIDs, modelID)
+	}
+	sort.Strings(modelIDs)
+
+	for _, modelID := range modelIDs {
+		byRound := rounds[modelID]
+		roundNums := make([]int, 0, len(byRound))
+		for r := range byRound {
+			roundNums = append(roundNums, r)
+		}
+		sort.Ints(roundNums)
+
+		answers := make(map[int]string, len(byRound))
+		for r, mr := range byRound {
+			answers[r] = mr.Answer
+		}
+		diffs := diff.Rounds(answers)
+
+		m := Model{ModelID: modelID, Metrics: metrics[modelID], Rounds: make([]Round, 0, len(roundNums))}
+		for _, r := range roundNums {
+			mr := byRound[r]
+			m.ModelName = mr.ModelName
+			m.TokensIn += mr.TokensIn
+			m.TokensOut += mr.TokensOut
+			m.Cost += mr.Cost
+			m.Rounds = append(m.Rounds, Round{
+				Round:      mr.Round,
+				Answer:     mr.Answer,
+				Rationale:  mr.Rationale,
+				Transforms: decodeTransforms(mr.Transforms),
+				Diff:       diffs[r],
+				Error:      mr.Error,
+				DurationMs: mr.DurationMs,
+				TokensIn:   mr.TokensIn,
+				TokensOut:  mr.TokensOut,
+				Cost:       mr.Cost,
+			})
+
+			doc.Discussion = append(doc.Discussion, decodeDiscussion(modelID, mr)...)
+		}
+		doc.Costs.Rounds += m.Cost
+		doc.Models = append(doc.Models, m)
+	}
+
+	sort.SliceStable(doc.Discussion, func(i, j int) bool {
+		a, b := doc.Discussion[i], doc.Discussion[j]
+		if a.Round != b.Round {
+			return a.Round < b.Round
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+
+	for _, r := range rankings {
+		var ranked []string
+		if err := json.Unmarshal([]byte(r.RankedModels), &ranked); err != nil {
+			ranked = []string{}
+		}
+		var justifications map[string]string
+		if r.Justifications != "" {
+			json.Unmarshal([]byte(r.Justifications), &justifications)
+		}
+		doc.Rankings = append(doc.Rankings, Ranking{
+			Judge:          r.RankerModel,
+			Ranked:         ranked,
+			Justifications: justifications,
+			DurationMs:     r.DurationMs,
+			TokensIn:       r.TokensIn,
+			TokensOut:      r.TokensOut,
+			Cost:           r.Cost,
+		})
+		doc.Costs.Ranking += r.Cost
+	}
+	sort.SliceStable(doc.Rankings, func(i, j int) bool {
+		return doc.Rankings[i].Judge < doc.Rankings[j].Judge
+	})
+
+	verifications, err := database.GetVerifications(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range verifications {
+		doc.Costs.Verification += v.Cost
+	}
+	if len(verifications) > 0 {
+		doc.Verifications = verifications
+	}
+
+	doc.Costs.Total = doc.Costs.Rounds + doc.Costs.Ranking + doc.Costs.Verification
+
+	similarities, err := database.GetAnswerSimilarities(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if len(similarities) > 0 {
+		pairs := make([]embeddings.Pair, 0, len(similarities))
+		for _, s := range similarities {
+			pairs = append(pairs, embeddings.Pair{A: s.ModelA, B: s.ModelB, Similarity: s.Similarity})
+		}
+		doc.Similarity = embeddings.Summarize(pairs)
+		doc.Similarity.Model = similarities[0].EmbeddingModel
+	}
+
+	return doc, nil
+}
+
+// decodeDiscussion turns a round's stored discussion (JSON map of target agent -> message) into messages
+func decodeDiscussion(modelID string, mr db.ModelRound) []Message {
+	if mr.Discussion == "" {
+		return nil
+	}
+
+	var threads map[string]string
+	if err := json.Unmarshal([]byte(mr.Discussion), &threads); err != nil {
+		return nil
+	}
+
+	messages := make([]Message, 0, len(threads))
+	for to, text := range threads {
+		messages = append(messages, Message{
+			Round:   mr.Round,
+			From:    modelID,
+			To:      to,
+			Message: text,
+		})
+	}
+	return messages
+}
+
+// decodeTransforms turns a round's stored post-processing record (JSON array) into step names
+func decodeTransforms(stored string) []string {
+	if stored == "" {
+		return nil
+	}
+
+	var transforms []string
+	if err := json.Unmarshal([]byte(stored), &transforms); err != nil {
+		return nil
+	}
+	return transforms
+}
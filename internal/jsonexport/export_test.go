@@ -0,0 +1,109 @@
+package jsonexport
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestBuild(t *testing.T) {
+	dbPath := "test_export.db"
+	defer os.Remove(dbPath)
+
+	database, err := db.New(dbPath, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	if _, err := Build(ctx, database, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := database.SaveRequest(ctx, db.Request{ID: "req-1", Question: "Q?", NumRounds: 2, NumModels: 2, WinnerModel: "grok", ClientRef: "tab-1", FinalRanking: `[{"model":"grok","place":1,"score":4},{"model":"gpt","place":2,"score":2}]`}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	rounds := []db.ModelRound{
+		{RequestID: "req-1", ModelID: "grok", ModelName: "grok-4", Round: 1, Answer: "A1", Cost: 0.1, Discussion: `{}`},
+		{RequestID: "req-1", ModelID: "grok", ModelName: "grok-4", Round: 2, Answer: "A2", Cost: 0.2, Discussion: `{"gpt":"Cite sources"}`},
+		{RequestID: "req-1", ModelID: "gpt", ModelName: "gpt-5", Round: 1, Answer: "B1", Cost: 0.3, PrivateNotes: "secret"},
+	}
+	for _, mr := range rounds {
+		if err := database.SaveModelRound(ctx, mr); err != nil {
+			t.Fatalf("Failed to save model round: %v", err)
+		}
+	}
+	if err := database.SaveRanking(ctx, db.Ranking{RequestID: "req-1", RankerModel: "gpt-5", RankedModels: `["grok-4","gpt-5"]`, Cost: 0.05}); err != nil {
+		t.Fatalf("Failed to save ranking: %v", err)
+	}
+	if err := database.SaveAnswerMetrics(ctx, []db.AnswerMetric{
+		{RequestID: "req-1", ModelID: "grok", Metric: "bleu", Value: 0.42},
+		{RequestID: "req-1", ModelID: "gpt", Metric: "bleu", Error: "command timed out"},
+	}); err != nil {
+		t.Fatalf("Failed to save answer metrics: %v", err)
+	}
+	if err := database.SaveVerification(ctx, db.Verification{RequestID: "req-1", Verifier: "gpt", VerifierName: "gpt-5", AnswerModel: "grok", Claims: []types.Claim{{Text: "A2", Status: "supported"}}, Cost: 0.01}); err != nil {
+		t.Fatalf("Failed to save verification: %v", err)
+	}
+
+	doc, err := Build(ctx, database, "req-1")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if doc.Similarity != nil {
+		t.Errorf("Expected no similarity without embeddings, got %+v", doc.Similarity)
+	}
+
+	if err := database.SaveAnswerSimilarities(ctx, []db.AnswerSimilarity{{RequestID: "req-1", ModelA: "gpt", ModelB: "grok", Similarity: 0.75, EmbeddingModel: "text-embedding-3-small"}}); err != nil {
+		t.Fatalf("Failed to save answer similarity: %v", err)
+	}
+	doc, err = Build(ctx, database, "req-1")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if s := doc.Similarity; s == nil || len(s.Pairs) != 1 || s.Overall != 0.75 || s.Mean["grok"] != 0.75 || s.Model != "text-embedding-3-small" {
+		t.Errorf("Expected the stored similarity summarized, got %+v", doc.Similarity)
+	}
+
+	if len(doc.FinalRanking) != 2 || doc.FinalRanking[1].Model != "gpt" || doc.FinalRanking[1].Place != 2 {
+		t.Errorf("Expected the stored final ranking, got %+v", doc.FinalRanking)
+	}
+
+	if doc.Request.ClientRef != "tab-1" {
+		t.Errorf("Expected the client reference, got %q", doc.Request.ClientRef)
+	}
+	if doc.SchemaVersion != SchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", SchemaVersion, doc.SchemaVersion)
+	}
+	if len(doc.Models) != 2 || doc.Models[0].ModelID != "gpt" || doc.Models[1].ModelID != "grok" {
+		t.Fatalf("Expected models sorted by ID, got %+v", doc.Models)
+	}
+	if doc.Models[0].Metrics != nil || doc.Models[1].Metrics["bleu"] != 0.42 {
+		t.Errorf("Expected only grok's successful metric, got %+v and %+v", doc.Models[0].Metrics, doc.Models[1].Metrics)
+	}
+	if got := doc.Models[1].Rounds; len(got) != 2 || got[1].Answer != "A2" {
+		t.Errorf("Expected grok's rounds in order, got %+v", got)
+	}
+	if got := doc.Models[1].Rounds; got[0].Diff != nil || len(got[1].Diff) != 2 || got[1].Diff[1].Text != "A2" {
+		t.Errorf("Expected round 2 to diff against round 1, got %+v", got)
+	}
+	if len(doc.Discussion) != 1 || doc.Discussion[0].To != "gpt" || doc.Discussion[0].Round != 2 {
+		t.Errorf("Expected one round 2 discussion message to gpt, got %+v", doc.Discussion)
+	}
+	if len(doc.Rankings) != 1 || doc.Rankings[0].Ranked[0] != "grok-4" {
+		t.Errorf("Expected decoded ranking, got %+v", doc.Rankings)
+	}
+	if len(doc.Verifications) != 1 || doc.Verifications[0].AnswerModel != "grok" || len(doc.Verifications[0].Claims) != 1 {
+		t.Errorf("Expected gpt's verification of grok's answer, got %+v", doc.Verifications)
+	}
+	if diff := doc.Costs.Total - 0.66; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected total cost 0.66 including verification, got %f", doc.Costs.Total)
+	}
+}
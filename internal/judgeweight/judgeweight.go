@@ -0,0 +1,161 @@
+// Package judgeweight weighs each judge's ballot by how well its past rankings agreed with the other judges,
+// so a judge that ranks at random counts for less than a consistent one.
+package judgeweight
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/difficulty"
+)
+
+const (
+	// PriorBallots is how many fully agreeing ballots every judge starts with, so a few unlucky rankings
+	// don't sink a new judge's weight
+	PriorBallots = 5
+
+	// MinWeight is the least a judge's ballot counts, however much it disagrees
+	MinWeight = 0.1
+)
+
+// Compute derives every judge's weight from stored rankings
+// Each ranking is compared to the Borda consensus of the other judges of the same request, leaving the
+// judge's own answer out of both; requests with a single judge have no consensus and are skipped.
+func Compute(rankings []db.Ranking) []db.JudgeWeight {
+	byRequest := make(map[string]map[string][]string)
+	for _, r := range rankings {
+		var ranked []string
+		if err := json.Unmarshal([]byte(r.RankedModels), &ranked); err != nil || len(ranked) == 0 {
+			continue
+		}
+		if byRequest[r.RequestID] == nil {
+			byRequest[r.RequestID] = make(map[string][]string)
+		}
+		byRequest[r.RequestID][r.RankerModel] = ranked
+	}
+
+	sums := make(map[string]float64)
+	ballots := make(map[string]int)
+	for _, judges := range byRequest {
+		for judge, ranked := range judges {
+			var others [][]string
+			for other, r := range judges {
+				if other != judge {
+					others = append(others, without(r, judge))
+				}
+			}
+			if len(others) == 0 {
+				continue
+			}
+			if d, ok := difficulty.KendallDistance(without(ranked, judge), consensus(others)); ok {
+				sums[judge] += 1 - 2*d
+				ballots[judge]++
+			}
+		}
+	}
+
+	weights := make([]db.JudgeWeight, 0, len(ballots))
+	for judge, n := range ballots {
+		agreement := sums[judge] / float64(n)
+		shrunk := (sums[judge] + PriorBallots) / float64(n+PriorBallots)
+		weights = append(weights, db.JudgeWeight{
+			Judge:     judge,
+			Ballots:   n,
+			Agreement: agreement,
+			Weight:    min(1, max(MinWeight, shrunk)),
+		})
+	}
+	sort.Slice(weights, func(i, j int) bool {
+		if weights[i].Weight != weights[j].Weight {
+			return weights[i].Weight > weights[j].Weight
+		}
+		return weights[i].Judge < weights[j].Judge
+	})
+
+	return weights
+}
+
+// consensus orders every ranked model by its Borda score over rankings, best first, ties by name
+func consensus(rankings [][]string) []string {
+	scores := make(map[string]int)
+	for _, ranked := range rankings {
+		for i, model := range ranked {
+			scores[model] += len(ranked) - i
+		}
+	}
+
+	models := make([]string, 0, len(scores))
+	for model := range scores {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if scores[models[i]] != scores[models[j]] {
+			return scores[models[i]] > scores[models[j]]
+		}
+		return models[i] < models[j]
+	})
+	return models
+}
+
+// without returns ranked with model left out
+func without(ranked []string, model string) []string {
+	return slices.DeleteFunc(slices.Clone(ranked), func(m string) bool { return m == model })
+}
+
+// Refresh recomputes every judge's weight from the rankings table and stores it
+func Refresh(ctx context.Context, database *db.DB) ([]db.JudgeWeight, error) {
+	rankings, err := database.GetRankings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	weights := Compute(rankings)
+	if err := database.SaveJudgeWeights(ctx, weights); err != nil {
+		return nil, err
+	}
+	return weights, nil
+}
+
+// Load returns the stored weights by judge, for AggregateRankings
+func Load(ctx context.Context, database *db.DB) (map[string]float64, error) {
+	stored, err := database.GetJudgeWeights(ctx)
+	if err != nil {
+		return nil, err
+	}
+	weights := make(map[string]float64, len(stored))
+	for _, w := range stored {
+		weights[w.Judge] = w.Weight
+	}
+	return weights, nil
+}
+
+// Start refreshes the weights now and then every interval until ctx is done; 0 disables it
+func Start(ctx context.Context, logger *slog.Logger, database *db.DB, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			weights, err := Refresh(ctx, database)
+			if err != nil {
+				logger.Warn("failed to refresh judge weights", slog.Any("error", err))
+			} else {
+				logger.Debug("judge weights refreshed", slog.Int("judges", len(weights)))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,69 @@
+package judgeweight
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+func TestCompute(t *testing.T) {
+	var rankings []db.Ranking
+	for i := range 10 {
+		id := fmt.Sprintf("r%d", i)
+		for _, judge := range []string{"grok-4", "gpt-5", "claude-4"} {
+			rankings = append(rankings, db.Ranking{RequestID: id, RankerModel: judge, RankedModels: `["m1","m2","m3","m4"]`})
+		}
+		// Always the other way round
+		rankings = append(rankings, db.Ranking{RequestID: id, RankerModel: "gemini-3", RankedModels: `["m4","m3","m2","m1"]`})
+	}
+	// A lone judge has no one to agree with
+	rankings = append(rankings, db.Ranking{RequestID: "solo", RankerModel: "mistral", RankedModels: `["m1","m2"]`})
+
+	weights := Compute(rankings)
+	if len(weights) != 4 {
+		t.Fatalf("Expected 4 judges with a consensus to compare against, got %+v", weights)
+	}
+	byJudge := make(map[string]db.JudgeWeight, len(weights))
+	for _, w := range weights {
+		byJudge[w.Judge] = w
+	}
+
+	if w := byJudge["grok-4"]; w.Ballots != 10 || w.Agreement != 1 || w.Weight != 1 {
+		t.Errorf("Expected a consistent judge to count fully, got %+v", w)
+	}
+	if w := byJudge["gemini-3"]; w.Agreement != -1 || w.Weight != MinWeight {
+		t.Errorf("Expected a contrarian judge to count the minimum, got %+v", w)
+	}
+	if weights[len(weights)-1].Judge != "gemini-3" {
+		t.Errorf("Expected weights ordered highest first, got %+v", weights)
+	}
+}
+
+func TestComputeShrinksFewBallots(t *testing.T) {
+	rankings := []db.Ranking{
+		{RequestID: "r1", RankerModel: "a", RankedModels: `["m1","m2","m3"]`},
+		{RequestID: "r1", RankerModel: "b", RankedModels: `["m3","m2","m1"]`},
+	}
+
+	// One fully disagreeing ballot against five prior ones: (-1 + 5) / 6
+	for _, w := range Compute(rankings) {
+		if w.Ballots != 1 || w.Agreement != -1 || w.Weight < 0.66 || w.Weight > 0.67 {
+			t.Errorf("Expected a single ballot to move the weight only partly, got %+v", w)
+		}
+	}
+}
+
+func TestComputeIgnoresOwnAnswer(t *testing.T) {
+	// Both judges agree on everyone but themselves, each ranking itself first
+	rankings := []db.Ranking{
+		{RequestID: "r1", RankerModel: "a", RankedModels: `["a","b","m1","m2"]`},
+		{RequestID: "r1", RankerModel: "b", RankedModels: `["b","a","m1","m2"]`},
+	}
+
+	for _, w := range Compute(rankings) {
+		if w.Agreement != 1 {
+			t.Errorf("Expected self-placement not to count against %s, got %+v", w.Judge, w)
+		}
+	}
+}
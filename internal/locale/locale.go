@@ -0,0 +1,81 @@
+// Package locale renders timestamps and amounts for the people reading exports and the history listing: in a
+// configured time zone, with the number conventions of a configured language. Dates keep their ISO 8601 order,
+// which reads the same everywhere and sorts.
+package locale
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// TimestampLayout is how export timestamps are written, with the zone they're in
+const TimestampLayout = "2006-01-02 15:04:05 MST"
+
+// Format holds a time zone and a language to render with
+// The zero value uses the server's local time zone and English numbers.
+type Format struct {
+	location *time.Location
+	lang     language.Tag
+	printer  *message.Printer
+}
+
+// New returns the Format for an IANA time zone name (e.g. "Europe/Warsaw") and a BCP 47 language tag (e.g. "de")
+// An empty timezone keeps the server's local one, an empty lang English.
+func New(timezone, lang string) (Format, error) {
+	var f Format
+	if timezone = strings.TrimSpace(timezone); timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return Format{}, fmt.Errorf("unknown time zone %q", timezone)
+		}
+		f.location = loc
+	}
+	if lang = strings.TrimSpace(lang); lang != "" {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			return Format{}, fmt.Errorf("invalid language tag %q: %w", lang, err)
+		}
+		f.lang, f.printer = tag, message.NewPrinter(tag)
+	}
+	return f, nil
+}
+
+// In returns t in the time zone
+func (f Format) In(t time.Time) time.Time {
+	if f.location == nil {
+		return t.Local()
+	}
+	return t.In(f.location)
+}
+
+// Timestamp renders t in the time zone with TimestampLayout
+func (f Format) Timestamp(t time.Time) string {
+	return f.In(t).Format(TimestampLayout)
+}
+
+// Lang returns the language tag, e.g. "de-DE" for browsers' Intl formatting
+func (f Format) Lang() string {
+	if f.printer == nil {
+		return language.English.String()
+	}
+	return f.lang.String()
+}
+
+// Number renders v with the given number of decimals, grouped and separated the language's way
+func (f Format) Number(v float64, decimals int) string {
+	p := f.printer
+	if p == nil {
+		p = message.NewPrinter(language.English)
+	}
+	return p.Sprint(number.Decimal(v, number.Scale(decimals)))
+}
+
+// Cost renders an amount in dollars to the hundredth of a cent, e.g. "$1,234.5678" or, in German, "$1.234,5678"
+func (f Format) Cost(dollars float64) string {
+	return "$" + f.Number(dollars, 4)
+}
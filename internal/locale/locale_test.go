@@ -0,0 +1,51 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := New("Mars/Olympus_Mons", ""); err == nil {
+		t.Error("Expected an unknown time zone to be rejected")
+	}
+	if _, err := New("", "not a tag!"); err == nil {
+		t.Error("Expected a malformed language tag to be rejected")
+	}
+	if _, err := New(" UTC ", " pl "); err != nil {
+		t.Errorf("Expected padded values to be accepted, got %v", err)
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	at := time.Date(2025, 3, 1, 23, 30, 0, 0, time.UTC)
+
+	f, err := New("Asia/Tokyo", "")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if got := f.Timestamp(at); got != "2025-03-02 08:30:00 JST" {
+		t.Errorf("Expected the time in Tokyo, got %q", got)
+	}
+
+	if got := (Format{}).In(at); got.Location() != time.Local {
+		t.Errorf("Expected the zero value to use local time, got %v", got.Location())
+	}
+}
+
+func TestCost(t *testing.T) {
+	for lang, want := range map[string]string{
+		"":   "$1,234.5678",
+		"en": "$1,234.5678",
+		"de": "$1.234,5678",
+		"fr": "$1\u00a0234,5678", // Grouped with a no-break space
+	} {
+		f, err := New("", lang)
+		if err != nil {
+			t.Fatalf("New(%q) failed: %v", lang, err)
+		}
+		if got := f.Cost(1234.56781); got != want {
+			t.Errorf("Cost in %q = %q, want %q", lang, got, want)
+		}
+	}
+}
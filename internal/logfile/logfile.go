@@ -0,0 +1,129 @@
+// Package logfile writes logs to a file that is rotated once it grows too big or too old,
+// keeping a bounded number of rotated files next to it.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupLayout timestamps rotated files, sorting oldest first by name
+const backupLayout = "20060102T150405.000"
+
+// Writer appends to a file, moving it aside before a write would take it past maxSize bytes or once it's
+// older than maxAge. Its age counts from when the Writer opened or started it. Safe for concurrent use.
+type Writer struct {
+	path       string
+	maxSize    int64         // 0 never rotates for size
+	maxAge     time.Duration // 0 never rotates for age
+	maxBackups int           // Rotated files kept, oldest removed first; 0 keeps them all
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	started time.Time
+	now     func() time.Time
+}
+
+// Open opens path for appending, creating it and its directory if needed
+func Open(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*Writer, error) {
+	w := &Writer{path: path, maxSize: maxSize, maxAge: maxAge, maxBackups: maxBackups, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends p to the file, rotating it first if it's due
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	full := w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize
+	old := w.maxAge > 0 && w.now().Sub(w.started) >= w.maxAge
+	if full || old {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// open opens the file for appending, picking up its size
+func (w *Writer) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	w.file, w.size, w.started = file, info.Size(), w.now()
+	return nil
+}
+
+// rotate moves the file aside under a timestamped name, starts a new one and prunes old backups
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	ext := filepath.Ext(w.path)
+	base := strings.TrimSuffix(w.path, ext)
+	backup := base + "-" + w.now().UTC().Format(backupLayout) + ext
+	// Two rotations within a second mustn't overwrite the first one's file
+	for i := 1; fileExists(backup); i++ {
+		backup = fmt.Sprintf("%s-%s.%d%s", base, w.now().UTC().Format(backupLayout), i, ext)
+	}
+	if err := os.Rename(w.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	return w.prune(base, ext)
+}
+
+// prune removes the oldest backups beyond maxBackups
+func (w *Writer) prune(base, ext string) error {
+	if w.maxBackups <= 0 {
+		return nil
+	}
+	backups, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return err
+	}
+	slices.Sort(backups)
+	for len(backups) > w.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("failed to remove old log file: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,83 @@
+package logfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "fat.log")
+	w, err := Open(path, 10, 0, 2)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer w.Close()
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { at = at.Add(time.Second); return at }
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "fourth\n" {
+		t.Errorf("Expected only the latest line in the current file, got %q", data)
+	}
+	backups, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "fat-*.log"))
+	if len(backups) != 2 {
+		t.Fatalf("Expected the two newest backups kept, got %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "second\n" {
+		t.Errorf("Expected the oldest backup pruned, got %q in %s", data, backups[0])
+	}
+}
+
+func TestRotatesByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fat.log")
+	w, err := Open(path, 0, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer w.Close()
+
+	at := time.Now()
+	w.now = func() time.Time { return at }
+	w.started = at
+
+	w.Write([]byte("old\n"))
+	at = at.Add(30 * time.Minute)
+	w.Write([]byte("still today\n"))
+	at = at.Add(30 * time.Minute)
+	w.Write([]byte("new\n"))
+
+	if data, _ := os.ReadFile(path); string(data) != "new\n" {
+		t.Errorf("Expected a new file after an hour, got %q", data)
+	}
+	backups, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "fat-*.log"))
+	if len(backups) != 1 {
+		t.Fatalf("Expected one backup, got %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "old\nstill today\n" {
+		t.Errorf("Expected the first hour's lines in the backup, got %q", data)
+	}
+}
+
+func TestAppendsToExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fat.log")
+	os.WriteFile(path, []byte("before restart\n"), 0644)
+
+	w, err := Open(path, 20, 0, 0)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer w.Close()
+	w.Write([]byte("after restart\n"))
+
+	if data, _ := os.ReadFile(path); string(data) != "after restart\n" {
+		t.Errorf("Expected the existing file's size counted towards rotation, got %q", data)
+	}
+}
@@ -0,0 +1,270 @@
+// Package mdexport writes completed runs as Markdown transcripts next to the HTML exports,
+// for note-taking tools such as Obsidian that ingest plain Markdown.
+package mdexport
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/meedamian/fat/internal/htmlexport"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+)
+
+type Exporter struct {
+	logger  *slog.Logger
+	dataDir string // Exports are written to its h/
+}
+
+func New(logger *slog.Logger, dataDir string) *Exporter {
+	return &Exporter{logger: logger, dataDir: dataDir}
+}
+
+// Export renders data as Markdown and saves it alongside the HTML export
+func (e *Exporter) Export(ctx context.Context, data htmlexport.ExportData) error {
+	outputPath := filepath.Join(e.dataDir, htmlexport.OutputPath(data.QuestionTS, htmlexport.Slug(data.Question), "md"))
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	if err := os.WriteFile(outputPath, []byte(Render(data)), 0644); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	e.logger.Info("markdown exported", slog.String("path", outputPath))
+	return nil
+}
+
+// Render produces the Markdown transcript: question, final ranking, per-round answers and discussions
+func Render(data htmlexport.ExportData) string {
+	var b strings.Builder
+
+	// Front matter so note-taking tools can index runs
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "question: %q\n", data.Question)
+	if data.Timestamp != "" {
+		fmt.Fprintf(&b, "date: %q\n", data.Timestamp)
+	}
+	if len(data.GoldIDs) > 0 {
+		fmt.Fprintf(&b, "winner: %q\n", strings.Join(displayNames(data.GoldIDs), ", "))
+	}
+	b.WriteString("tags: [fat]\n")
+	b.WriteString("---\n\n")
+
+	fmt.Fprintf(&b, "# %s\n\n", oneLine(data.Question))
+	if strings.Contains(data.Question, "\n") {
+		b.WriteString(quote(data.Question))
+		b.WriteString("\n\n")
+	}
+
+	// The top answers merged into one, ahead of the answers it was made from
+	if data.SynthesizedAnswer != "" {
+		fmt.Fprintf(&b, "## Synthesized answer by `%s`\n\n", data.Synthesizer)
+		if len(data.Provenance) == 0 {
+			b.WriteString(strings.TrimSpace(data.SynthesizedAnswer))
+			b.WriteString("\n\n")
+		}
+		// Each paragraph is credited to the answers it draws on
+		for _, p := range data.Provenance {
+			b.WriteString(p.Paragraph)
+			b.WriteString("\n\n")
+			if len(p.Sources) > 0 {
+				fmt.Fprintf(&b, "*from `%s`*\n\n", strings.Join(p.Sources, "`, `"))
+			}
+		}
+	}
+
+	// Final ranking
+	models := sortedByScore(data)
+	b.WriteString("## Final ranking\n\n")
+	b.WriteString("| | Model | Variant | Score | Cost |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+	for _, m := range models {
+		cost := "-"
+		if dollars, ok := data.ModelCosts[m.ID]; ok {
+			cost = data.Locale.Cost(dollars)
+		}
+		fmt.Fprintf(&b, "| %s | %s | `%s` | %d | %s |\n",
+			medal(data, m.ID), formatModelName(m.ID), m.Name, data.ModelScores[m.ID], cost)
+	}
+	b.WriteString("\n")
+
+	// The meta judge's justification of the ranking above
+	if data.Verdict != nil && data.Verdict.Verdict != "" {
+		fmt.Fprintf(&b, "## Verdict of `%s`\n\n", data.Verdict.RankerModel)
+		b.WriteString(quote(data.Verdict.Verdict))
+		b.WriteString("\n\n")
+	}
+
+	// Final answers in ranking order
+	b.WriteString("## Final answers\n\n")
+	for _, m := range models {
+		reply, ok := data.Replies[m.ID]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "### %s %s\n\n", medal(data, m.ID), formatModelName(m.ID))
+		writeAnswer(&b, reply.Answer, reply.Rationale)
+	}
+
+	// Every round, model by model
+	rounds := 0
+	for _, byRound := range data.AllRoundReplies {
+		for round := range byRound {
+			rounds = max(rounds, round)
+		}
+	}
+	if rounds > 0 {
+		b.WriteString("## Rounds\n\n")
+		for round := 1; round <= rounds; round++ {
+			fmt.Fprintf(&b, "### Round %d\n\n", round)
+			for _, m := range data.Models {
+				mr, ok := data.AllRoundReplies[m.ID][round]
+				if !ok || (mr.Answer == "" && mr.Error == "") {
+					continue
+				}
+				fmt.Fprintf(&b, "#### %s\n\n", formatModelName(m.ID))
+				if mr.Error != "" {
+					fmt.Fprintf(&b, "> [!error] %s\n\n", oneLine(mr.Error))
+					continue
+				}
+				writeAnswer(&b, mr.Answer, mr.Rationale)
+			}
+		}
+	}
+
+	// Discussion threads
+	if len(data.Discussions) > 0 {
+		b.WriteString("## Discussions\n\n")
+		for _, pair := range data.Discussions {
+			fmt.Fprintf(&b, "### %s\n\n", pair.Header)
+			for _, msg := range pair.Messages {
+				fmt.Fprintf(&b, "**%s**\n\n", msg.Meta)
+				b.WriteString(quote(msg.Text))
+				b.WriteString("\n\n")
+			}
+		}
+	}
+
+	// Every participant's fact-check of the winning answer
+	if len(data.Verifications) > 0 {
+		fmt.Fprintf(&b, "## Verification of %s\n\n", formatModelName(data.Verifications[0].AnswerModel))
+		for _, v := range data.Verifications {
+			fmt.Fprintf(&b, "### `%s`\n\n", v.VerifierName)
+			switch {
+			case v.Error != "":
+				fmt.Fprintf(&b, "> [!error] %s\n\n", oneLine(v.Error))
+			case len(v.Claims) == 0:
+				b.WriteString("_(No checkable claims found)_\n\n")
+			default:
+				for _, c := range v.Claims {
+					fmt.Fprintf(&b, "- %s **%s** %s", claimMark(c.Status), strings.ToUpper(c.Status), oneLine(c.Text))
+					if c.Note != "" {
+						fmt.Fprintf(&b, " — %s", oneLine(c.Note))
+					}
+					b.WriteString("\n")
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// claimMark is the symbol shown for a verified claim's status
+func claimMark(status string) string {
+	switch status {
+	case shared.ClaimSupported:
+		return "✅"
+	case shared.ClaimUnsupported:
+		return "❌"
+	default:
+		return "❓"
+	}
+}
+
+// writeAnswer writes an answer with an optional collapsed rationale
+func writeAnswer(b *strings.Builder, answer, rationale string) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		answer = "_(No answer provided)_"
+	}
+	b.WriteString(answer)
+	b.WriteString("\n\n")
+
+	if rationale = strings.TrimSpace(rationale); rationale != "" {
+		b.WriteString("> [!note]- Rationale\n")
+		b.WriteString(quote(rationale))
+		b.WriteString("\n\n")
+	}
+}
+
+// sortedByScore orders models by ranking score, highest first
+func sortedByScore(data htmlexport.ExportData) []*types.ModelInfo {
+	models := slices.Clone(data.Models)
+	sort.SliceStable(models, func(i, j int) bool {
+		return data.ModelScores[models[i].ID] > data.ModelScores[models[j].ID]
+	})
+	return models
+}
+
+func medal(data htmlexport.ExportData, modelID string) string {
+	switch {
+	case slices.Contains(data.GoldIDs, modelID):
+		return "🏆"
+	case slices.Contains(data.SilverIDs, modelID):
+		return "🥈"
+	case slices.Contains(data.BronzeIDs, modelID):
+		return "🥉"
+	default:
+		return ""
+	}
+}
+
+func displayNames(ids []string) []string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = formatModelName(id)
+	}
+	return names
+}
+
+// quote prefixes every line with "> " so multi-line text stays inside a blockquote
+func quote(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func formatModelName(id string) string {
+	switch id {
+	case "grok":
+		return "Grok"
+	case "gpt":
+		return "GPT"
+	case "gemini":
+		return "Gemini"
+	case "claude":
+		return "Claude"
+	case "deepseek":
+		return "DeepSeek"
+	case "mistral":
+		return "Mistral"
+	default:
+		return id
+	}
+}
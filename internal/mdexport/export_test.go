@@ -0,0 +1,76 @@
+package mdexport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/htmlexport"
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestRender(t *testing.T) {
+	data := htmlexport.ExportData{
+		Question:  "What is the capital of France?",
+		GoldIDs:   []string{"claude"},
+		SilverIDs: []string{"grok"},
+		Replies: map[string]types.Reply{
+			"grok":   {Answer: "Paris, probably"},
+			"claude": {Answer: "Paris", Rationale: "It has been since 987"},
+		},
+		AllRoundReplies: map[string]map[int]db.ModelRound{
+			"grok":   {1: {Answer: "Lyon"}, 2: {Answer: "Paris, probably"}},
+			"claude": {1: {Answer: "Paris"}, 2: {Error: "timeout"}},
+		},
+		Models: []*types.ModelInfo{
+			{ID: "grok", Name: "grok-4-fast"},
+			{ID: "claude", Name: "claude-4.5-haiku"},
+		},
+		ModelScores: map[string]int{"grok": 3, "claude": 4},
+		Discussions: []htmlexport.DiscussionPair{{
+			Header:   "Grok ↔ Claude",
+			Messages: []htmlexport.DiscussionMessage{{Meta: "Claude • Round 1", Text: "Lyon is wrong.\nCheck again."}},
+		}},
+		Verdict:           &db.Ranking{RankerModel: "gpt-5", Meta: true, Verdict: "Grok hedged."},
+		SynthesizedAnswer: "Paris, the capital since 987",
+		Synthesizer:       "gpt-5",
+		Provenance:        []types.Provenance{{Paragraph: "Paris, the capital since 987", Sources: []string{"claude-4.5-haiku", "grok-4-fast"}}},
+		Verifications: []db.Verification{
+			{Verifier: "claude", VerifierName: "claude-4.5-haiku", AnswerModel: "claude", Claims: []types.Claim{{Text: "Paris since 987", Status: "unsupported", Note: "Since 508"}}},
+			{Verifier: "grok", VerifierName: "grok-4-fast", AnswerModel: "claude", Error: "timeout"},
+		},
+	}
+
+	md := Render(data)
+
+	for _, want := range []string{
+		`question: "What is the capital of France?"`,
+		`winner: "Claude"`,
+		"# What is the capital of France?",
+		"| 🏆 | Claude | `claude-4.5-haiku` | 4 | - |",
+		"| 🥈 | Grok | `grok-4-fast` | 3 | - |",
+		"> [!note]- Rationale\n> It has been since 987",
+		"### Round 2",
+		"> [!error] timeout",
+		"### Grok ↔ Claude",
+		"> Lyon is wrong.\n> Check again.",
+		"## Verdict of `gpt-5`\n\n> Grok hedged.",
+		"## Synthesized answer by `gpt-5`\n\nParis, the capital since 987\n\n*from `claude-4.5-haiku`, `grok-4-fast`*\n\n",
+		"## Verification of Claude",
+		"### `claude-4.5-haiku`\n\n- ❌ **UNSUPPORTED** Paris since 987 — Since 508",
+		"### `grok-4-fast`\n\n> [!error] timeout",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected markdown to contain %q\n%s", want, md)
+		}
+	}
+
+	if strings.Index(md, "## Synthesized answer") > strings.Index(md, "## Final ranking") {
+		t.Error("Expected the synthesized answer ahead of the ranking")
+	}
+
+	// Ranking order: gold before silver
+	if strings.Index(md, "### 🏆 Claude") > strings.Index(md, "### 🥈 Grok") {
+		t.Error("Expected final answers in ranking order")
+	}
+}
@@ -0,0 +1,108 @@
+package mdexport
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/htmlexport"
+)
+
+// ExportTournament renders t as Markdown and saves it alongside its HTML bracket
+func (e *Exporter) ExportTournament(ctx context.Context, t db.Tournament) error {
+	outputPath := filepath.Join(e.dataDir, htmlexport.TournamentPath(t.QuestionTS, t.Question, "md"))
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	if err := os.WriteFile(outputPath, []byte(RenderTournament(t)), 0644); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	e.logger.Info("tournament markdown exported", slog.String("path", outputPath))
+	return nil
+}
+
+// RenderTournament produces the bracket of t round by round, followed by every entrant's answer
+func RenderTournament(t db.Tournament) string {
+	var b strings.Builder
+
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "question: %q\n", t.Question)
+	if t.Winner != "" {
+		fmt.Fprintf(&b, "winner: %q\n", t.Winner)
+	}
+	b.WriteString("tags: [fat, tournament]\n")
+	b.WriteString("---\n\n")
+
+	fmt.Fprintf(&b, "# %s\n\n", oneLine(t.Question))
+	if t.Winner != "" {
+		fmt.Fprintf(&b, "🏆 `%s` won, judged by %s.\n\n", t.Winner, "`"+strings.Join(t.Jury, "`, `")+"`")
+	}
+
+	seeds := make(map[string]int, len(t.Entries))
+	for _, e := range t.Entries {
+		seeds[e.ModelName] = e.Seed
+	}
+	side := func(name, winner string) string {
+		if name == "" {
+			return "_bye_"
+		}
+		s := fmt.Sprintf("(%d) `%s`", seeds[name], name)
+		if name == winner {
+			s = "**" + s + "**"
+		}
+		return s
+	}
+
+	b.WriteString("## Bracket\n\n")
+	round := 0
+	for _, m := range t.Matches {
+		if m.Round != round {
+			if round != 0 {
+				b.WriteString("\n")
+			}
+			round = m.Round
+			fmt.Fprintf(&b, "### Round %d\n\n", round)
+		}
+		fmt.Fprintf(&b, "- %s vs %s", side(m.ModelA, m.Winner), side(m.ModelB, m.Winner))
+		if len(m.Votes) > 0 {
+			tally := make(map[string]int, 2)
+			for _, v := range m.Votes {
+				tally[v.Vote]++
+			}
+			fmt.Fprintf(&b, " — %d:%d", tally[m.ModelA], tally[m.ModelB])
+		}
+		b.WriteString("\n")
+		for _, v := range m.Votes {
+			switch {
+			case v.Error != "":
+				fmt.Fprintf(&b, "  - `%s` failed: %s\n", v.Juror, oneLine(v.Error))
+			case v.Reason != "":
+				fmt.Fprintf(&b, "  - `%s` → `%s`: %s\n", v.Juror, v.Vote, oneLine(v.Reason))
+			default:
+				fmt.Fprintf(&b, "  - `%s` → `%s`\n", v.Juror, v.Vote)
+			}
+		}
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Answers\n\n")
+	for _, e := range t.Entries {
+		fmt.Fprintf(&b, "### %d. `%s`\n\n", e.Seed, e.ModelName)
+		if e.Error != "" {
+			fmt.Fprintf(&b, "> [!error] %s\n\n", oneLine(e.Error))
+			continue
+		}
+		b.WriteString(strings.TrimSpace(e.Answer))
+		b.WriteString("\n\n")
+	}
+
+	fmt.Fprintf(&b, "_Total cost: $%.4f_\n", t.TotalCost)
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package mdexport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+func TestRenderTournament(t *testing.T) {
+	md := RenderTournament(db.Tournament{
+		Question:  "Is Pluto a planet?",
+		Jury:      []string{"gpt-5", "claude-4.5-haiku"},
+		Winner:    "gpt-5-mini",
+		TotalCost: 0.0123,
+		Entries: []db.TournamentEntry{
+			{ModelName: "gpt-5", Seed: 1, Answer: "No"},
+			{ModelName: "gpt-5-mini", Seed: 2, Answer: "No, since 2006"},
+			{ModelName: "gpt-4.1", Seed: 3, Error: "timeout"},
+		},
+		Matches: []db.TournamentMatch{
+			{Round: 1, Slot: 0, ModelA: "gpt-5", Winner: "gpt-5"},
+			{Round: 1, Slot: 1, ModelA: "gpt-5-mini", ModelB: "gpt-4.1", Winner: "gpt-5-mini"},
+			{Round: 2, Slot: 0, ModelA: "gpt-5", ModelB: "gpt-5-mini", Winner: "gpt-5-mini", Votes: []db.MatchVote{
+				{Juror: "gpt-5", Vote: "gpt-5-mini", Reason: "Dates the decision"},
+				{Juror: "claude-4.5-haiku", Error: "rate limited"},
+			}},
+		},
+	})
+
+	for _, want := range []string{
+		`winner: "gpt-5-mini"`,
+		"🏆 `gpt-5-mini` won, judged by `gpt-5`, `claude-4.5-haiku`.",
+		"### Round 1\n\n- **(1) `gpt-5`** vs _bye_\n- **(2) `gpt-5-mini`** vs (3) `gpt-4.1`\n\n### Round 2",
+		"- (1) `gpt-5` vs **(2) `gpt-5-mini`** — 0:1\n  - `gpt-5` → `gpt-5-mini`: Dates the decision\n  - `claude-4.5-haiku` failed: rate limited",
+		"### 3. `gpt-4.1`\n\n> [!error] timeout",
+		"_Total cost: $0.0123_",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected markdown to contain %q\n%s", want, md)
+		}
+	}
+}
@@ -1,21 +1,23 @@
 package metrics
 
 import (
+	"slices"
 	"sync"
 	"time"
 )
 
 // RequestMetrics tracks metrics for a single request
 type RequestMetrics struct {
-	RequestID    string
-	Question     string
-	StartTime    time.Time
-	EndTime      time.Time
-	NumRounds    int
-	NumModels    int
-	ModelMetrics map[string]*ModelMetrics
-	Winner       string
-	mu           sync.RWMutex
+	RequestID     string
+	Question      string
+	StartTime     time.Time
+	EndTime       time.Time
+	NumRounds     int
+	SkippedRounds int // Rounds not run because the answers converged early
+	NumModels     int
+	ModelMetrics  map[string]*ModelMetrics
+	Winner        string
+	mu            sync.RWMutex
 }
 
 // ModelMetrics tracks metrics for a single model
@@ -26,6 +28,12 @@ type ModelMetrics struct {
 	RankingTokens TokenCount
 	TotalTokens   TokenCount
 	Errors        []string
+	FallbackFrom  string // Variant that was replaced by a fallback because the provider didn't know it
+	Fallback      string // Variant used instead of FallbackFrom
+	Summarized    []int  // Rounds whose prompt had older rounds summarized to fit the context window
+	SummaryTokens TokenCount
+	VerifyTime    time.Duration // Fact-checking the leading answer after ranking
+	VerifyTokens  TokenCount
 	mu            sync.Mutex
 }
 
@@ -95,6 +103,29 @@ func (mm *ModelMetrics) RecordRound(round int, duration time.Duration, tokIn, to
 	mm.TotalTokens.Output += tokOut
 }
 
+// RecordFallback records that the model's variant was replaced by a fallback variant
+// Only the first substitution is kept as FallbackFrom, so chained fallbacks still show the requested variant.
+func (mm *ModelMetrics) RecordFallback(from, to string) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if mm.FallbackFrom == "" {
+		mm.FallbackFrom = from
+	}
+	mm.Fallback = to
+}
+
+// RecordSummary records that older rounds were summarized for the model's prompt in round
+// The summarizer's tokens are kept apart, as they're billed at its own rate.
+func (mm *ModelMetrics) RecordSummary(round int, tokIn, tokOut int64) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	mm.Summarized = append(mm.Summarized, round)
+	mm.SummaryTokens.Input += tokIn
+	mm.SummaryTokens.Output += tokOut
+}
+
 // RecordRanking records ranking metrics
 func (mm *ModelMetrics) RecordRanking(duration time.Duration, tokIn, tokOut int64) {
 	mm.mu.Lock()
@@ -109,6 +140,20 @@ func (mm *ModelMetrics) RecordRanking(duration time.Duration, tokIn, tokOut int6
 	mm.TotalTokens.Output += tokOut
 }
 
+// RecordVerification records the model's fact-check of the leading answer
+func (mm *ModelMetrics) RecordVerification(duration time.Duration, tokIn, tokOut int64) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	mm.VerifyTime = duration
+	mm.VerifyTokens = TokenCount{
+		Input:  tokIn,
+		Output: tokOut,
+	}
+	mm.TotalTokens.Input += tokIn
+	mm.TotalTokens.Output += tokOut
+}
+
 // Complete marks the request as complete
 func (rm *RequestMetrics) Complete(winner string) {
 	rm.mu.Lock()
@@ -118,6 +163,17 @@ func (rm *RequestMetrics) Complete(winner string) {
 	rm.Winner = winner
 }
 
+// SkipRounds records that the request stopped after completed rounds because the answers converged
+func (rm *RequestMetrics) SkipRounds(completed int) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if completed < rm.NumRounds {
+		rm.SkippedRounds = rm.NumRounds - completed
+		rm.NumRounds = completed
+	}
+}
+
 // Duration returns the total request duration
 func (rm *RequestMetrics) Duration() time.Duration {
 	rm.mu.RLock()
@@ -137,16 +193,24 @@ func (rm *RequestMetrics) Summary() map[string]any {
 	totalTokensIn := int64(0)
 	totalTokensOut := int64(0)
 	errorCount := 0
+	fallbacks := make(map[string]string)
+	summarized := make(map[string][]int)
 
 	for _, mm := range rm.ModelMetrics {
 		mm.mu.Lock()
 		totalTokensIn += mm.TotalTokens.Input
 		totalTokensOut += mm.TotalTokens.Output
 		errorCount += len(mm.Errors)
+		if mm.Fallback != "" {
+			fallbacks[mm.FallbackFrom] = mm.Fallback
+		}
+		if len(mm.Summarized) > 0 {
+			summarized[mm.ModelID] = slices.Clone(mm.Summarized)
+		}
 		mm.mu.Unlock()
 	}
 
-	return map[string]any{
+	summary := map[string]any{
 		"request_id":       rm.RequestID,
 		"duration_ms":      rm.Duration().Milliseconds(),
 		"num_rounds":       rm.NumRounds,
@@ -156,4 +220,14 @@ func (rm *RequestMetrics) Summary() map[string]any {
 		"error_count":      errorCount,
 		"winner":           rm.Winner,
 	}
+	if len(fallbacks) > 0 {
+		summary["fallbacks"] = fallbacks
+	}
+	if rm.SkippedRounds > 0 {
+		summary["skipped_rounds"] = rm.SkippedRounds
+	}
+	if len(summarized) > 0 {
+		summary["summarized"] = summarized
+	}
+	return summary
 }
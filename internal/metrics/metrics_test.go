@@ -138,6 +138,22 @@ func TestRecordRanking(t *testing.T) {
 	}
 }
 
+func TestRecordVerification(t *testing.T) {
+	mm := &ModelMetrics{
+		ModelID:     "grok",
+		TotalTokens: TokenCount{Input: 100, Output: 40},
+	}
+
+	mm.RecordVerification(2*time.Second, 30, 10)
+
+	if mm.VerifyTime != 2*time.Second || mm.VerifyTokens.Input != 30 || mm.VerifyTokens.Output != 10 {
+		t.Errorf("Expected the verification recorded, got %v and %+v", mm.VerifyTime, mm.VerifyTokens)
+	}
+	if mm.TotalTokens.Input != 130 || mm.TotalTokens.Output != 50 {
+		t.Errorf("Expected the verification tokens added to the totals, got %+v", mm.TotalTokens)
+	}
+}
+
 func TestComplete(t *testing.T) {
 	rm := NewRequestMetrics("test-123", "Test", 1, 1)
 
@@ -250,3 +266,59 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected 10 rounds for mm2, got %d", len(mm2.RoundMetrics))
 	}
 }
+
+func TestSummaryFallbacks(t *testing.T) {
+	rm := NewRequestMetrics("test-123", "What is AI?", 3, 2)
+
+	mm := rm.AddModelMetrics("gpt")
+	mm.RecordFallback("gpt-6", "gpt-5.4")
+	mm.RecordFallback("gpt-5.4", "gpt-5")
+	rm.AddModelMetrics("grok")
+
+	if mm.FallbackFrom != "gpt-6" || mm.Fallback != "gpt-5" {
+		t.Errorf("Expected fallback gpt-6 -> gpt-5, got %s -> %s", mm.FallbackFrom, mm.Fallback)
+	}
+
+	fallbacks, ok := rm.Summary()["fallbacks"].(map[string]string)
+	if !ok || len(fallbacks) != 1 || fallbacks["gpt-6"] != "gpt-5" {
+		t.Errorf("Expected fallbacks {gpt-6: gpt-5}, got %v", rm.Summary()["fallbacks"])
+	}
+
+	if _, ok := NewRequestMetrics("test-456", "Q", 1, 1).Summary()["fallbacks"]; ok {
+		t.Error("Expected no fallbacks entry without fallbacks")
+	}
+}
+
+func TestSkipRounds(t *testing.T) {
+	rm := NewRequestMetrics("test-123", "What is AI?", 5, 2)
+	rm.SkipRounds(2)
+
+	if rm.NumRounds != 2 || rm.SkippedRounds != 3 {
+		t.Errorf("Expected 2 rounds run and 3 skipped, got %d and %d", rm.NumRounds, rm.SkippedRounds)
+	}
+	if skipped := rm.Summary()["skipped_rounds"]; skipped != 3 {
+		t.Errorf("Expected skipped_rounds 3, got %v", skipped)
+	}
+}
+
+func TestSummarySummarized(t *testing.T) {
+	rm := NewRequestMetrics("test-123", "What is AI?", 4, 2)
+
+	mm := rm.AddModelMetrics("gemini")
+	mm.RecordSummary(3, 1000, 100)
+	mm.RecordSummary(4, 1200, 120)
+	rm.AddModelMetrics("grok")
+
+	if mm.SummaryTokens.Input != 2200 || mm.TotalTokens.Input != 0 {
+		t.Errorf("Expected summarizer tokens kept apart, got %+v and %+v", mm.SummaryTokens, mm.TotalTokens)
+	}
+
+	summarized, ok := rm.Summary()["summarized"].(map[string][]int)
+	if !ok || len(summarized) != 1 || len(summarized["gemini"]) != 2 {
+		t.Errorf("Expected summarized {gemini: [3 4]}, got %v", rm.Summary()["summarized"])
+	}
+
+	if _, ok := NewRequestMetrics("test-456", "Q", 1, 1).Summary()["summarized"]; ok {
+		t.Error("Expected no summarized entry without summaries")
+	}
+}
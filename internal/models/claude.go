@@ -2,7 +2,9 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	an "github.com/anthropics/anthropic-sdk-go/option"
@@ -31,21 +33,24 @@ var ClaudeFamily = types.ModelFamily{
 	Provider: "Anthropic",
 	BaseURL:  "https://api.anthropic.com/v1/messages",
 	Variants: map[string]types.ModelVariant{
-		Claude46Opus:   {MaxTok: 1_000_000, Rate: types.Rate{In: 5.0, Out: 25.0}},
-		Claude46Sonnet: {MaxTok: 1_000_000, Rate: types.Rate{In: 3.0, Out: 15.0}},
+		Claude46Opus:   {MaxTok: 1_000_000, MaxOut: 128_000, Rate: types.Rate{In: 5.0, Out: 25.0}, Thinking: true},
+		Claude46Sonnet: {MaxTok: 1_000_000, MaxOut: 64_000, Rate: types.Rate{In: 3.0, Out: 15.0}, Thinking: true},
 		// NOTE: Claude Sonnet 4.5 supports a 1M token context window when using the context-1m-2025-08-07 beta header. Long context pricing applies to requests exceeding 200K tokens.
 		// NOTE: Claude Sonnet 4 supports a 1M token context window when using the context-1m-2025-08-07 beta header. Long context pricing applies to requests exceeding 200K tokens.
-		Claude45Opus:   {MaxTok: 200_000, Rate: types.Rate{In: 5.0, Out: 25.0}},
-		Claude45Sonnet: {MaxTok: 200_000, Rate: types.Rate{In: 3.0, Out: 15.0}},
-		Claude45Haiku:  {MaxTok: 200_000, Rate: types.Rate{In: 1.0, Out: 5.0}},
-		Claude41Opus:   {MaxTok: 200_000, Rate: types.Rate{In: 15.0, Out: 75.0}},
-		Claude4Sonnet:  {MaxTok: 200_000, Rate: types.Rate{In: 3.0, Out: 15.0}},
-		Claude37Sonnet: {MaxTok: 200_000, Rate: types.Rate{In: 3.0, Out: 15.0}},
-		Claude4Opus:    {MaxTok: 200_000, Rate: types.Rate{In: 15.0, Out: 75.0}},
-		Claude35Haiku:  {MaxTok: 200_000, Rate: types.Rate{In: 0.8, Out: 4.0}},
+		Claude45Opus:   {MaxTok: 200_000, MaxOut: 64_000, Rate: types.Rate{In: 5.0, Out: 25.0}, Thinking: true},
+		Claude45Sonnet: {MaxTok: 200_000, MaxOut: 64_000, Rate: types.Rate{In: 3.0, Out: 15.0}, Thinking: true},
+		Claude45Haiku:  {MaxTok: 200_000, MaxOut: 64_000, Rate: types.Rate{In: 1.0, Out: 5.0}, Thinking: true},
+		Claude41Opus:   {MaxTok: 200_000, MaxOut: 32_000, Rate: types.Rate{In: 15.0, Out: 75.0}, Thinking: true},
+		Claude4Sonnet:  {MaxTok: 200_000, MaxOut: 64_000, Rate: types.Rate{In: 3.0, Out: 15.0}, Thinking: true},
+		Claude37Sonnet: {MaxTok: 200_000, MaxOut: 64_000, Rate: types.Rate{In: 3.0, Out: 15.0}, Thinking: true},
+		Claude4Opus:    {MaxTok: 200_000, MaxOut: 32_000, Rate: types.Rate{In: 15.0, Out: 75.0}, Thinking: true},
+		Claude35Haiku:  {MaxTok: 200_000, MaxOut: 8_192, Rate: types.Rate{In: 0.8, Out: 4.0}},
 	},
 }
 
+// claudeMinThinking is the smallest thinking budget the Messages API accepts
+const claudeMinThinking = 1024
+
 // ClaudeModel implements the Model interface for Anthropic Claude
 type ClaudeModel struct {
 	info   *types.ModelInfo
@@ -54,7 +59,16 @@ type ClaudeModel struct {
 
 // NewClaudeModel creates a new Claude model instance
 func NewClaudeModel(info *types.ModelInfo) *ClaudeModel {
-	client := anthropic.NewClient(an.WithAPIKey(info.APIKey), an.WithMaxRetries(3))
+	opts := []an.RequestOption{
+		an.WithAPIKey(info.APIKey),
+		an.WithMaxRetries(sdkMaxRetries),
+		an.WithHTTPClient(shared.NewHTTPClient(info.RequestTimeout)),
+	}
+	if info.RequestTimeout > 0 {
+		// Without an explicit timeout the SDK refuses large max_tokens on non-streaming calls
+		opts = append(opts, an.WithRequestTimeout(info.RequestTimeout))
+	}
+	client := anthropic.NewClient(append(opts, anthropicHeaders(info.Headers)...)...)
 	return &ClaudeModel{
 		info:   info,
 		client: client,
@@ -62,29 +76,76 @@ func NewClaudeModel(info *types.ModelInfo) *ClaudeModel {
 }
 
 // Prompt implements the Model interface
-func (m *ClaudeModel) Prompt(ctx context.Context, question string, meta types.Meta, replies map[string]types.Reply, discussion map[string]map[string][]types.DiscussionMessage, privateNotes map[int]string) (types.ModelResult, error) {
-	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, question, meta, replies, discussion, privateNotes)
+func (m *ClaudeModel) Prompt(ctx context.Context, req types.PromptRequest) (types.PromptResponse, error) {
+	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, req.Question, req.Meta, req.Replies, req.Discussion, req.Notes)
+	gen := m.info.Generation.Merge(req.Overrides)
+
+	// max_tokens is required by the Messages API
+	maxTokens := outputBudget(m.info, prompt, req.Overrides)
+	if maxTokens == 0 {
+		maxTokens = shared.ResponseReserve
+	}
 
 	params := anthropic.MessageNewParams{
 		Model:     anthropic.Model(m.info.Name),
-		MaxTokens: 1024,
+		MaxTokens: maxTokens,
 		Messages: []anthropic.MessageParam{
 			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
 		},
 	}
+	if m.info.Persona != "" {
+		params.System = []anthropic.TextBlockParam{{Text: m.info.Persona}}
+	}
+	budget := claudeThinkingBudget(m.info.ThinkingBudget, maxTokens)
+	if budget > 0 {
+		params.Thinking = anthropic.ThinkingConfigParamOfEnabled(budget)
+	} else if req.Meta.Structured {
+		// Forced tool use can't be combined with thinking, which then relies on the prompt alone
+		params.Tools, params.ToolChoice = claudeReplyTool(req.Meta.AnswerSchema)
+	}
+	claudeGeneration(&params, gen, budget > 0)
 
-	result, err := m.client.Messages.New(ctx, params)
+	result, err := m.client.Messages.New(ctx, params, anthropicExtras(m.info.Extra)...)
 	if err != nil {
-		return types.ModelResult{}, fmt.Errorf("claude api call failed: %w", err)
+		return types.PromptResponse{}, fmt.Errorf("claude api call failed: %w", classifyError(err))
 	}
 
-	content := result.Content[0].Text
-	reply := shared.ParseResponse(content)
+	// With extended thinking the answer follows one or more thinking blocks
+	var content, thinking strings.Builder
+	for _, block := range result.Content {
+		switch block.Type {
+		case "text":
+			content.WriteString(block.Text)
+		case "thinking":
+			thinking.WriteString(block.Thinking)
+		case "tool_use":
+			content.Write(block.Input)
+		}
+	}
+	reply := shared.ParseReply(content.String(), req.Meta.Structured)
+	reply.Thinking = strings.TrimSpace(thinking.String())
 
-	return types.ModelResult{
-		Reply:  reply,
-		TokIn:  result.Usage.InputTokens,
-		TokOut: result.Usage.OutputTokens,
-		Prompt: prompt,
+	return types.PromptResponse{
+		Reply:        reply,
+		TokIn:        result.Usage.InputTokens,
+		TokOut:       result.Usage.OutputTokens,
+		Prompt:       prompt,
+		FinishReason: string(result.StopReason),
+		ModelVersion: string(result.Model),
+		Raw:          json.RawMessage(result.RawJSON()),
 	}, nil
 }
+
+// claudeThinkingBudget fits the configured thinking budget into maxTokens, which covers both the
+// thinking and the answer, leaving ResponseReserve for the answer. Returns 0 (thinking off) when
+// there is no room for the API's minimum budget.
+func claudeThinkingBudget(budget, maxTokens int64) int64 {
+	if budget <= 0 {
+		return 0
+	}
+	budget = min(budget, maxTokens-shared.ResponseReserve)
+	if budget < claudeMinThinking {
+		return 0
+	}
+	return budget
+}
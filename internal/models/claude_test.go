@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/meedamian/fat/internal/shared"
+)
+
+func TestClaudeThinkingBudget(t *testing.T) {
+	tests := []struct {
+		budget, maxTokens, want int64
+	}{
+		{0, 64_000, 0},
+		{16_000, 64_000, 16_000},
+		{16_000, 20_000, 20_000 - shared.ResponseReserve},
+		{16_000, shared.ResponseReserve + 1000, 0}, // Below the API minimum
+	}
+
+	for _, tt := range tests {
+		if got := claudeThinkingBudget(tt.budget, tt.maxTokens); got != tt.want {
+			t.Errorf("claudeThinkingBudget(%d, %d) = %d, want %d", tt.budget, tt.maxTokens, got, tt.want)
+		}
+	}
+}
+
+func TestThinkingBudget(t *testing.T) {
+	if got := ThinkingBudget(Claude, Claude46Sonnet, 8000); got != 8000 {
+		t.Errorf("Expected budget for a thinking variant, got %d", got)
+	}
+	if got := ThinkingBudget(Claude, Claude35Haiku, 8000); got != 0 {
+		t.Errorf("Expected no budget for a variant without thinking, got %d", got)
+	}
+	if got := ThinkingBudget(Grok, Grok3Mini, 8000); got != 0 {
+		t.Errorf("Expected no budget for other families, got %d", got)
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/meedamian/fat/internal/shared"
@@ -23,8 +24,8 @@ var DeepSeekFamily = types.ModelFamily{
 	Provider: "DeepSeek",
 	BaseURL:  "https://api.deepseek.com/v1",
 	Variants: map[string]types.ModelVariant{
-		DeepSeekChat:  {MaxTok: 128_000, Rate: types.Rate{In: 0.28, Out: 0.42}},
-		DeepSeekCoder: {MaxTok: 128_000, Rate: types.Rate{In: 0.28, Out: 0.42}},
+		DeepSeekChat:  {MaxTok: 128_000, MaxOut: 8_192, Rate: types.Rate{In: 0.28, Out: 0.42}},
+		DeepSeekCoder: {MaxTok: 128_000, MaxOut: 8_192, Rate: types.Rate{In: 0.28, Out: 0.42}},
 	},
 }
 
@@ -36,11 +37,13 @@ type DeepSeekModel struct {
 
 // NewDeepSeekModel creates a new DeepSeek model instance
 func NewDeepSeekModel(info *types.ModelInfo) *DeepSeekModel {
-	client := openai.NewClient(
+	opts := []oa.RequestOption{
 		oa.WithAPIKey(info.APIKey),
 		oa.WithBaseURL(info.BaseURL),
-		oa.WithMaxRetries(3),
-	)
+		oa.WithMaxRetries(sdkMaxRetries),
+		oa.WithHTTPClient(shared.NewHTTPClient(info.RequestTimeout)),
+	}
+	client := openai.NewClient(append(opts, openaiHeaders(info.Headers)...)...)
 	return &DeepSeekModel{
 		info:   info,
 		client: client,
@@ -48,28 +51,42 @@ func NewDeepSeekModel(info *types.ModelInfo) *DeepSeekModel {
 }
 
 // Prompt implements the Model interface
-func (m *DeepSeekModel) Prompt(ctx context.Context, question string, meta types.Meta, replies map[string]types.Reply, discussion map[string]map[string][]types.DiscussionMessage, privateNotes map[int]string) (types.ModelResult, error) {
-	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, question, meta, replies, discussion, privateNotes)
+func (m *DeepSeekModel) Prompt(ctx context.Context, req types.PromptRequest) (types.PromptResponse, error) {
+	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, req.Question, req.Meta, req.Replies, req.Discussion, req.Notes)
+	gen := m.info.Generation.Merge(req.Overrides)
+
+	messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage(prompt)}
+	if m.info.Persona != "" {
+		messages = append([]openai.ChatCompletionMessageParamUnion{openai.SystemMessage(m.info.Persona)}, messages...)
+	}
 
 	params := openai.ChatCompletionNewParams{
-		Model: openai.ChatModel(m.info.Name),
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(prompt),
-		},
+		Model:    openai.ChatModel(m.info.Name),
+		Messages: messages,
+	}
+	if maxTokens := outputBudget(m.info, prompt, req.Overrides); maxTokens > 0 {
+		params.MaxTokens = openai.Int(maxTokens)
+	}
+	openaiGeneration(&params, gen)
+	if req.Meta.Structured {
+		params.ResponseFormat = openaiJSONObject()
 	}
 
-	result, err := m.client.Chat.Completions.New(ctx, params)
+	result, err := m.client.Chat.Completions.New(ctx, params, openaiExtras(m.info.Extra)...)
 	if err != nil {
-		return types.ModelResult{}, fmt.Errorf("deepseek api call failed: %w", err)
+		return types.PromptResponse{}, fmt.Errorf("deepseek api call failed: %w", classifyError(err))
 	}
 
 	content := result.Choices[0].Message.Content
-	reply := shared.ParseResponse(content)
+	reply := shared.ParseReply(content, req.Meta.Structured)
 
-	return types.ModelResult{
-		Reply:  reply,
-		TokIn:  result.Usage.PromptTokens,
-		TokOut: result.Usage.CompletionTokens,
-		Prompt: prompt,
+	return types.PromptResponse{
+		Reply:        reply,
+		TokIn:        result.Usage.PromptTokens,
+		TokOut:       result.Usage.CompletionTokens,
+		Prompt:       prompt,
+		FinishReason: result.Choices[0].FinishReason,
+		ModelVersion: result.Model,
+		Raw:          json.RawMessage(result.RawJSON()),
 	}, nil
 }
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LoadDefaults overrides DefaultModels from a JSON file (family ID -> variant) and rebuilds AllModels
+// A missing file is not an error. Must be called before AllModels is used.
+func LoadDefaults(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var defaults map[string]string
+	if err := json.Unmarshal(data, &defaults); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := ValidateDefaults(defaults); err != nil {
+		return err
+	}
+
+	for familyID, variant := range defaults {
+		DefaultModels[familyID] = variant
+	}
+	AllModels = buildDefaultModels()
+
+	return nil
+}
+
+// SaveDefaults writes the default variant of each family to a JSON file read by LoadDefaults
+func SaveDefaults(path string, defaults map[string]string) error {
+	if err := ValidateDefaults(defaults); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(defaults, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// ValidateDefaults checks that every family and variant exists
+func ValidateDefaults(defaults map[string]string) error {
+	for familyID, variant := range defaults {
+		family, ok := ModelFamilies[familyID]
+		if !ok {
+			return fmt.Errorf("unknown model family %q", familyID)
+		}
+		if _, ok := family.Variants[variant]; !ok {
+			return fmt.Errorf("unknown variant %q for family %q", variant, familyID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveAndLoadDefaults(t *testing.T) {
+	original := make(map[string]string, len(DefaultModels))
+	for familyID, variant := range DefaultModels {
+		original[familyID] = variant
+	}
+	defer func() {
+		DefaultModels = original
+		AllModels = buildDefaultModels()
+	}()
+
+	path := filepath.Join(t.TempDir(), "defaults.json")
+	if err := SaveDefaults(path, map[string]string{Mistral: MistralSmall}); err != nil {
+		t.Fatalf("SaveDefaults failed: %v", err)
+	}
+
+	if err := LoadDefaults(path); err != nil {
+		t.Fatalf("LoadDefaults failed: %v", err)
+	}
+	if DefaultModels[Mistral] != MistralSmall || AllModels[Mistral].Name != MistralSmall {
+		t.Errorf("Expected mistral default %s, got %s (AllModels %s)", MistralSmall, DefaultModels[Mistral], AllModels[Mistral].Name)
+	}
+	if DefaultModels[Grok] != original[Grok] {
+		t.Errorf("Expected families missing from the file to keep their default")
+	}
+}
+
+func TestLoadDefaultsMissingFile(t *testing.T) {
+	if err := LoadDefaults(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("Expected missing file to be ignored, got %v", err)
+	}
+}
+
+func TestDefaultsRejectUnknownVariants(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "defaults.json")
+
+	if err := SaveDefaults(path, map[string]string{Mistral: "no-such-model"}); err == nil {
+		t.Error("Expected error for unknown variant")
+	}
+	if err := SaveDefaults(path, map[string]string{"no-such-family": MistralSmall}); err == nil {
+		t.Error("Expected error for unknown family")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"gpt": "claude-opus-4-6"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDefaults(path); err == nil {
+		t.Error("Expected error for a variant from another family")
+	}
+}
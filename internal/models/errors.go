@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/meedamian/fat/internal/retry"
+	"github.com/openai/openai-go"
+	"google.golang.org/genai"
+)
+
+// sdkMaxRetries disables the SDKs' own retries; retry.Do in the orchestrator is the single
+// retry loop, so rate limit slots, Retry-After waits and attempt counts stay in one place.
+const sdkMaxRetries = 0
+
+// classifyError wraps an SDK error with the retry package's typed error for its HTTP status
+func classifyError(err error) error {
+	var openaiErr *openai.Error
+	if errors.As(err, &openaiErr) {
+		return retry.Classify(openaiErr.StatusCode, responseHeader(openaiErr.Response), err)
+	}
+
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) {
+		return retry.Classify(anthropicErr.StatusCode, responseHeader(anthropicErr.Response), err)
+	}
+
+	var geminiErr genai.APIError
+	if errors.As(err, &geminiErr) {
+		classified := retry.Classify(geminiErr.Code, nil, err)
+		if rateLimited, ok := classified.(*retry.RateLimited); ok {
+			rateLimited.RetryAfter = geminiRetryDelay(geminiErr.Details)
+		}
+		return classified
+	}
+
+	return err
+}
+
+// responseHeader returns res's headers, or nil for a missing response
+func responseHeader(res *http.Response) http.Header {
+	if res == nil {
+		return nil
+	}
+	return res.Header
+}
+
+// geminiRetryDelay extracts the delay from a google.rpc.RetryInfo error detail, as Gemini
+// reports it in the body rather than a Retry-After header
+func geminiRetryDelay(details []map[string]any) time.Duration {
+	for _, detail := range details {
+		if detail["@type"] != "type.googleapis.com/google.rpc.RetryInfo" {
+			continue
+		}
+		delay, _ := detail["retryDelay"].(string)
+		if d, err := time.ParseDuration(delay); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/meedamian/fat/internal/retry"
+	"github.com/openai/openai-go"
+	"google.golang.org/genai"
+)
+
+func TestClassifyError(t *testing.T) {
+	req := httptest.NewRequest("POST", "https://api.example.com/v1/chat/completions", nil)
+	response := func(status int, header http.Header) *http.Response {
+		return &http.Response{StatusCode: status, Header: header}
+	}
+
+	var rateLimited *retry.RateLimited
+	openaiErr := fmt.Errorf("wrapped: %w", &openai.Error{
+		StatusCode: http.StatusTooManyRequests,
+		Request:    req,
+		Response:   response(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"12"}}),
+	})
+	if err := classifyError(openaiErr); !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 12*time.Second {
+		t.Errorf("Expected RateLimited after 12s for OpenAI 429, got %v", err)
+	}
+
+	var authErr *retry.AuthError
+	anthropicErr := &anthropic.Error{StatusCode: http.StatusUnauthorized, Request: req, Response: response(http.StatusUnauthorized, nil)}
+	if err := classifyError(anthropicErr); !errors.As(err, &authErr) {
+		t.Errorf("Expected AuthError for Anthropic 401, got %v", err)
+	}
+
+	geminiErr := genai.APIError{
+		Code:    http.StatusTooManyRequests,
+		Details: []map[string]any{{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "33s"}},
+	}
+	if err := classifyError(geminiErr); !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 33*time.Second {
+		t.Errorf("Expected RateLimited after 33s for Gemini 429, got %v", err)
+	}
+
+	var serverErr *retry.ServerError
+	if err := classifyError(genai.APIError{Code: http.StatusServiceUnavailable}); !errors.As(err, &serverErr) {
+		t.Errorf("Expected ServerError for Gemini 503, got %v", err)
+	}
+
+	plain := errors.New("connection reset")
+	if err := classifyError(plain); err != plain {
+		t.Errorf("Expected unclassified errors to pass through, got %v", err)
+	}
+}
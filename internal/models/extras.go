@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	an "github.com/anthropics/anthropic-sdk-go/option"
+	oa "github.com/openai/openai-go/option"
+)
+
+// sjsonEscaper escapes characters with a special meaning in the SDKs' JSON paths
+var sjsonEscaper = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`)
+
+// extraField is one leaf of a model's Extra options, addressed by its JSON path
+type extraField struct {
+	path  string
+	value any
+}
+
+// extraFields flattens extra options into leaf fields, so nested objects are merged into the
+// request body rather than replacing what the SDK sends
+func extraFields(extra map[string]any) []extraField {
+	var fields []extraField
+	var walk func(prefix string, m map[string]any)
+	walk = func(prefix string, m map[string]any) {
+		for key, value := range m {
+			path := prefix + sjsonEscaper.Replace(key)
+			if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+				walk(path+".", nested)
+				continue
+			}
+			fields = append(fields, extraField{path: path, value: value})
+		}
+	}
+	walk("", extra)
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].path < fields[j].path })
+	return fields
+}
+
+// openaiExtras turns extra options into per-request OpenAI SDK options
+func openaiExtras(extra map[string]any) []oa.RequestOption {
+	fields := extraFields(extra)
+	opts := make([]oa.RequestOption, 0, len(fields))
+	for _, f := range fields {
+		opts = append(opts, oa.WithJSONSet(f.path, f.value))
+	}
+	return opts
+}
+
+// anthropicExtras turns extra options into per-request Anthropic SDK options
+func anthropicExtras(extra map[string]any) []an.RequestOption {
+	fields := extraFields(extra)
+	opts := make([]an.RequestOption, 0, len(fields))
+	for _, f := range fields {
+		opts = append(opts, an.WithJSONSet(f.path, f.value))
+	}
+	return opts
+}
+
+// cloneExtra deep-copies extra options for SDKs that merge them into (and may modify) the request body
+func cloneExtra(extra map[string]any) map[string]any {
+	if extra == nil {
+		return nil
+	}
+	data, err := json.Marshal(extra)
+	if err != nil {
+		return nil
+	}
+	var clone map[string]any
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil
+	}
+	return clone
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// captureBody starts a server that records the decoded request body and replies with reply
+func captureBody(t *testing.T, reply string) (*httptest.Server, *map[string]any) {
+	t.Helper()
+	body := map[string]any{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Invalid request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &body
+}
+
+func TestGrokSendsExtra(t *testing.T) {
+	srv, body := captureBody(t, `{"choices": [{"message": {"content": "# ANSWER\nyes"}}]}`)
+
+	info := &types.ModelInfo{
+		ID:      Grok,
+		Name:    Grok3Mini,
+		BaseURL: srv.URL,
+		Extra:   map[string]any{"reasoning_effort": "high", "search_parameters": map[string]any{"mode": "auto"}},
+	}
+	if _, err := NewGrokModel(info).Prompt(context.Background(), types.PromptRequest{Question: "question?"}); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if (*body)["reasoning_effort"] != "high" || (*body)["model"] != Grok3Mini {
+		t.Errorf("Expected extra options alongside the built-in fields, got %v", *body)
+	}
+	if params, _ := (*body)["search_parameters"].(map[string]any); params["mode"] != "auto" {
+		t.Errorf("Expected nested extra options, got %v", (*body)["search_parameters"])
+	}
+}
+
+func TestDeepSeekSendsExtra(t *testing.T) {
+	srv, body := captureBody(t, `{"choices": [{"index": 0, "message": {"role": "assistant", "content": "# ANSWER\nyes"}}]}`)
+
+	info := &types.ModelInfo{
+		ID:      DeepSeek,
+		Name:    DeepSeekChat,
+		BaseURL: srv.URL,
+		Extra:   map[string]any{"temperature": 0.3, "response_format": map[string]any{"type": "text"}},
+	}
+	if _, err := NewDeepSeekModel(info).Prompt(context.Background(), types.PromptRequest{Question: "question?"}); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if (*body)["temperature"] != 0.3 {
+		t.Errorf("Expected temperature 0.3, got %v", (*body)["temperature"])
+	}
+	if format, _ := (*body)["response_format"].(map[string]any); format["type"] != "text" {
+		t.Errorf("Expected nested extra options, got %v", (*body)["response_format"])
+	}
+	if messages, _ := (*body)["messages"].([]any); len(messages) != 1 {
+		t.Errorf("Expected the prompt to still be sent, got %v", (*body)["messages"])
+	}
+}
+
+func TestExtraFieldsEscapesKeys(t *testing.T) {
+	fields := extraFields(map[string]any{"a.b": 1, "c": map[string]any{"d": true}})
+	if len(fields) != 2 || fields[0].path != `a\.b` || fields[1].path != "c.d" {
+		t.Errorf("Unexpected fields %+v", fields)
+	}
+}
+
+func TestGrokRequestsStructuredReply(t *testing.T) {
+	srv, body := captureBody(t, `{"choices": [{"message": {"content": "{\"answer\": \"yes\", \"rationale\": \"\", \"discussion\": [], \"private_notes\": \"\", \"searches\": []}"}}]}`)
+
+	info := &types.ModelInfo{ID: Grok, Name: Grok3Mini, BaseURL: srv.URL}
+	result, err := NewGrokModel(info).Prompt(context.Background(), types.PromptRequest{Question: "question?", Meta: types.Meta{Structured: true}})
+	if err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if format, _ := (*body)["response_format"].(map[string]any); format["type"] != "json_schema" {
+		t.Errorf("Expected a json_schema response format, got %v", (*body)["response_format"])
+	}
+	if result.Reply.Answer != "yes" {
+		t.Errorf("Expected the JSON answer to be parsed, got %q", result.Reply.Answer)
+	}
+}
+
+func TestDeepSeekRequestsJSONObject(t *testing.T) {
+	srv, body := captureBody(t, `{"choices": [{"index": 0, "message": {"role": "assistant", "content": "# ANSWER\nyes"}}]}`)
+
+	info := &types.ModelInfo{ID: DeepSeek, Name: DeepSeekChat, BaseURL: srv.URL}
+	result, err := NewDeepSeekModel(info).Prompt(context.Background(), types.PromptRequest{Question: "question?", Meta: types.Meta{Structured: true}})
+	if err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if format, _ := (*body)["response_format"].(map[string]any); format["type"] != "json_object" {
+		t.Errorf("Expected a json_object response format, got %v", (*body)["response_format"])
+	}
+	if result.Reply.Answer != "yes" {
+		t.Errorf("Expected markdown fallback answer 'yes', got %q", result.Reply.Answer)
+	}
+}
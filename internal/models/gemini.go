@@ -2,7 +2,9 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/meedamian/fat/internal/shared"
 	"github.com/meedamian/fat/internal/types"
@@ -29,21 +31,38 @@ var GeminiFamily = types.ModelFamily{
 	Provider: "Google",
 	BaseURL:  "https://generativelanguage.googleapis.com/v1beta/models/{model}:generateContent", // Updated to placeholder for flexibility.
 	Variants: map[string]types.ModelVariant{
-		Gemini31Pro:       {MaxTok: 1_048_576, Rate: types.Rate{In: 2.0, Out: 12.0}},
-		Gemini31FlashLite: {MaxTok: 1_048_576, Rate: types.Rate{In: 0.25, Out: 1.5}},
+		Gemini31Pro:       {MaxTok: 1_048_576, MaxOut: 65_536, Rate: types.Rate{In: 2.0, Out: 12.0}},
+		Gemini31FlashLite: {MaxTok: 1_048_576, MaxOut: 65_536, Rate: types.Rate{In: 0.25, Out: 1.5}},
 
-		Gemini3Pro:   {MaxTok: 1_048_576, Rate: types.Rate{In: 2.0, Out: 12.0}},
-		Gemini3Flash: {MaxTok: 1_048_576, Rate: types.Rate{In: 0.5, Out: 3.0}},
+		Gemini3Pro:   {MaxTok: 1_048_576, MaxOut: 65_536, Rate: types.Rate{In: 2.0, Out: 12.0}},
+		Gemini3Flash: {MaxTok: 1_048_576, MaxOut: 65_536, Rate: types.Rate{In: 0.5, Out: 3.0}},
 
-		Gemini25Pro:       {MaxTok: 1_048_576, Rate: types.Rate{In: 1.25, Out: 10.0}},
-		Gemini25Flash:     {MaxTok: 1_048_576, Rate: types.Rate{In: 0.3, Out: 2.5}},
-		Gemini25FlashLite: {MaxTok: 1_048_576, Rate: types.Rate{In: 0.1, Out: 0.4}},
+		Gemini25Pro:       {MaxTok: 1_048_576, MaxOut: 65_536, Rate: types.Rate{In: 1.25, Out: 10.0}},
+		Gemini25Flash:     {MaxTok: 1_048_576, MaxOut: 65_536, Rate: types.Rate{In: 0.3, Out: 2.5}},
+		Gemini25FlashLite: {MaxTok: 1_048_576, MaxOut: 65_536, Rate: types.Rate{In: 0.1, Out: 0.4}},
 
-		Gemini20Flash:     {MaxTok: 1_048_576, Rate: types.Rate{In: 0.1, Out: 0.4}},
-		Gemini20FlashLite: {MaxTok: 1_048_576, Rate: types.Rate{In: 0.075, Out: 0.3}},
+		Gemini20Flash:     {MaxTok: 1_048_576, MaxOut: 8_192, Rate: types.Rate{In: 0.1, Out: 0.4}},
+		Gemini20FlashLite: {MaxTok: 1_048_576, MaxOut: 8_192, Rate: types.Rate{In: 0.075, Out: 0.3}},
 	},
 }
 
+// GeminiSafetyThresholds maps FAT_GEMINI_SAFETY values to the lowest harm probability Gemini blocks
+var GeminiSafetyThresholds = map[string]genai.HarmBlockThreshold{
+	"off":    genai.HarmBlockThresholdOff,
+	"none":   genai.HarmBlockThresholdBlockNone,
+	"high":   genai.HarmBlockThresholdBlockOnlyHigh,
+	"medium": genai.HarmBlockThresholdBlockMediumAndAbove,
+	"low":    genai.HarmBlockThresholdBlockLowAndAbove,
+}
+
+// geminiHarmCategories are the categories a safety threshold applies to
+var geminiHarmCategories = []genai.HarmCategory{
+	genai.HarmCategoryHarassment,
+	genai.HarmCategoryHateSpeech,
+	genai.HarmCategorySexuallyExplicit,
+	genai.HarmCategoryDangerousContent,
+}
+
 // GeminiModel implements the Model interface for Google Gemini
 type GeminiModel struct {
 	info   *types.ModelInfo
@@ -52,7 +71,11 @@ type GeminiModel struct {
 
 // NewGeminiModel creates a new Gemini model instance
 func NewGeminiModel(info *types.ModelInfo) *GeminiModel {
-	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{APIKey: info.APIKey})
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      info.APIKey,
+		HTTPClient:  shared.NewHTTPClient(info.RequestTimeout),
+		HTTPOptions: genai.HTTPOptions{Headers: info.Headers, ExtraBody: cloneExtra(info.Extra)},
+	})
 	if err != nil {
 		// Log error but return model anyway - error will surface on first Prompt call
 		if info.Logger != nil {
@@ -66,20 +89,37 @@ func NewGeminiModel(info *types.ModelInfo) *GeminiModel {
 }
 
 // Prompt implements the Model interface
-func (m *GeminiModel) Prompt(ctx context.Context, question string, meta types.Meta, replies map[string]types.Reply, discussion map[string]map[string][]types.DiscussionMessage, privateNotes map[int]string) (types.ModelResult, error) {
+func (m *GeminiModel) Prompt(ctx context.Context, req types.PromptRequest) (types.PromptResponse, error) {
 	if m.client == nil {
-		return types.ModelResult{}, fmt.Errorf("gemini client not initialized")
+		return types.PromptResponse{}, fmt.Errorf("gemini client not initialized")
 	}
 
-	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, question, meta, replies, discussion, privateNotes)
+	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, req.Question, req.Meta, req.Replies, req.Discussion, req.Notes)
+	gen := m.info.Generation.Merge(req.Overrides)
 
-	result, err := m.client.Models.GenerateContent(ctx, m.info.Name, genai.Text(prompt), nil)
+	config := &genai.GenerateContentConfig{
+		MaxOutputTokens: int32(outputBudget(m.info, prompt, req.Overrides)),
+	}
+	if m.info.Persona != "" {
+		config.SystemInstruction = genai.NewContentFromText(m.info.Persona, genai.RoleUser)
+	}
+	config.SafetySettings = geminiSafetySettings(m.info.Safety)
+	geminiGeneration(config, gen)
+	if req.Meta.Structured {
+		config.ResponseMIMEType = "application/json"
+		config.ResponseJsonSchema = shared.ReplySchema(req.Meta.AnswerSchema)
+	}
+
+	result, err := m.client.Models.GenerateContent(ctx, m.info.Name, genai.Text(prompt), config)
 	if err != nil {
-		return types.ModelResult{}, fmt.Errorf("gemini api call failed: %w", err)
+		return types.PromptResponse{}, fmt.Errorf("gemini api call failed: %w", classifyError(err))
+	}
+	if err := geminiBlocked(result); err != nil {
+		return types.PromptResponse{}, err
 	}
 
 	content := result.Text()
-	reply := shared.ParseResponse(content)
+	reply := shared.ParseReply(content, req.Meta.Structured)
 
 	// Extract token usage from UsageMetadata
 	var tokIn, tokOut int64
@@ -88,10 +128,64 @@ func (m *GeminiModel) Prompt(ctx context.Context, question string, meta types.Me
 		tokOut = int64(result.UsageMetadata.CandidatesTokenCount)
 	}
 
-	return types.ModelResult{
-		Reply:  reply,
-		TokIn:  tokIn,
-		TokOut: tokOut,
-		Prompt: prompt,
+	var finish string
+	if len(result.Candidates) > 0 {
+		finish = string(result.Candidates[0].FinishReason)
+	}
+	raw, _ := json.Marshal(result)
+
+	return types.PromptResponse{
+		Reply:        reply,
+		TokIn:        tokIn,
+		TokOut:       tokOut,
+		Prompt:       prompt,
+		FinishReason: finish,
+		ModelVersion: result.ModelVersion,
+		Raw:          raw,
 	}, nil
 }
+
+// geminiSafetySettings applies one threshold to every harm category; nil keeps Google's defaults
+func geminiSafetySettings(safety string) []*genai.SafetySetting {
+	threshold, ok := GeminiSafetyThresholds[safety]
+	if !ok {
+		return nil
+	}
+
+	settings := make([]*genai.SafetySetting, 0, len(geminiHarmCategories))
+	for _, category := range geminiHarmCategories {
+		settings = append(settings, &genai.SafetySetting{Category: category, Threshold: threshold})
+	}
+	return settings
+}
+
+// geminiBlocked reports a prompt or answer withheld by safety filters, which would otherwise
+// come back as an empty answer with no hint of why
+func geminiBlocked(result *genai.GenerateContentResponse) error {
+	if fb := result.PromptFeedback; fb != nil && fb.BlockReason != "" {
+		return fmt.Errorf("gemini blocked the prompt (%s)%s", fb.BlockReason, blockedCategories(fb.SafetyRatings))
+	}
+
+	if len(result.Candidates) == 0 || result.Text() != "" {
+		return nil
+	}
+	switch candidate := result.Candidates[0]; candidate.FinishReason {
+	case genai.FinishReasonSafety, genai.FinishReasonBlocklist, genai.FinishReasonProhibitedContent, genai.FinishReasonSPII:
+		return fmt.Errorf("gemini blocked the answer (%s)%s", candidate.FinishReason, blockedCategories(candidate.SafetyRatings))
+	}
+	return nil
+}
+
+// blockedCategories lists the harm categories that triggered a block, e.g. ": HARM_CATEGORY_HARASSMENT"
+func blockedCategories(ratings []*genai.SafetyRating) string {
+	var categories []string
+	for _, r := range ratings {
+		if r != nil && r.Blocked {
+			categories = append(categories, string(r.Category))
+		}
+	}
+	if len(categories) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(categories, ", ")
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestGeminiSafetySettings(t *testing.T) {
+	if settings := geminiSafetySettings(""); settings != nil {
+		t.Errorf("Expected no settings without a threshold, got %v", settings)
+	}
+
+	settings := geminiSafetySettings("none")
+	if len(settings) != len(geminiHarmCategories) {
+		t.Fatalf("Expected a setting per harm category, got %d", len(settings))
+	}
+	for _, s := range settings {
+		if s.Threshold != genai.HarmBlockThresholdBlockNone {
+			t.Errorf("Expected BLOCK_NONE for %s, got %s", s.Category, s.Threshold)
+		}
+	}
+}
+
+func TestGeminiBlocked(t *testing.T) {
+	answered := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+		Content:      genai.NewContentFromText("# ANSWER\nyes", genai.RoleModel),
+		FinishReason: genai.FinishReasonStop,
+	}}}
+	if err := geminiBlocked(answered); err != nil {
+		t.Errorf("Expected no error for an answer, got %v", err)
+	}
+
+	promptBlocked := &genai.GenerateContentResponse{PromptFeedback: &genai.GenerateContentResponsePromptFeedback{
+		BlockReason:   genai.BlockedReasonSafety,
+		SafetyRatings: []*genai.SafetyRating{{Category: genai.HarmCategoryDangerousContent, Blocked: true}},
+	}}
+	if err := geminiBlocked(promptBlocked); err == nil || !strings.Contains(err.Error(), "prompt (SAFETY): HARM_CATEGORY_DANGEROUS_CONTENT") {
+		t.Errorf("Expected blocked prompt error naming the category, got %v", err)
+	}
+
+	answerBlocked := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonSafety}}}
+	if err := geminiBlocked(answerBlocked); err == nil || !strings.Contains(err.Error(), "answer (SAFETY)") {
+		t.Errorf("Expected blocked answer error, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/responses"
+	oashared "github.com/openai/openai-go/shared"
+	"google.golang.org/genai"
+)
+
+// openaiGeneration sets the sampling parameters of an OpenAI-compatible Chat Completions request
+// Reasoning effort is left to the callers whose API takes it.
+func openaiGeneration(params *openai.ChatCompletionNewParams, g types.Generation) {
+	if g.Temperature != nil {
+		params.Temperature = openai.Float(*g.Temperature)
+	}
+	if g.TopP != nil {
+		params.TopP = openai.Float(*g.TopP)
+	}
+}
+
+// responsesGeneration is openaiGeneration for the Responses API, including reasoning effort
+func responsesGeneration(params *responses.ResponseNewParams, g types.Generation) {
+	if g.Temperature != nil {
+		params.Temperature = openai.Float(*g.Temperature)
+	}
+	if g.TopP != nil {
+		params.TopP = openai.Float(*g.TopP)
+	}
+	if g.ReasoningEffort != "" {
+		params.Reasoning = oashared.ReasoningParam{Effort: oashared.ReasoningEffort(g.ReasoningEffort)}
+	}
+}
+
+// claudeGeneration sets the sampling parameters of a Messages API request
+// Extended thinking only works with the default sampling, so they're left out when it's on.
+func claudeGeneration(params *anthropic.MessageNewParams, g types.Generation, thinking bool) {
+	if thinking {
+		return
+	}
+	if g.Temperature != nil {
+		params.Temperature = anthropic.Float(*g.Temperature)
+	}
+	if g.TopP != nil {
+		params.TopP = anthropic.Float(*g.TopP)
+	}
+}
+
+// geminiGeneration sets the sampling parameters of a Gemini request
+func geminiGeneration(config *genai.GenerateContentConfig, g types.Generation) {
+	if g.Temperature != nil {
+		config.Temperature = genai.Ptr(float32(*g.Temperature))
+	}
+	if g.TopP != nil {
+		config.TopP = genai.Ptr(float32(*g.TopP))
+	}
+}
+
+// grokGeneration sets the sampling parameters of a Grok request body
+func grokGeneration(body map[string]any, g types.Generation) {
+	if g.Temperature != nil {
+		body["temperature"] = *g.Temperature
+	}
+	if g.TopP != nil {
+		body["top_p"] = *g.TopP
+	}
+	if g.ReasoningEffort != "" {
+		body["reasoning_effort"] = g.ReasoningEffort
+	}
+}
+
+// outputBudget is shared.OutputBudget capped by a call's max_tokens override
+func outputBudget(info *types.ModelInfo, prompt string, over types.Generation) int64 {
+	budget := shared.OutputBudget(info, prompt)
+	if over.MaxTokens > 0 && (budget == 0 || over.MaxTokens < budget) {
+		return over.MaxTokens
+	}
+	return budget
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+	"github.com/openai/openai-go"
+	oa "github.com/openai/openai-go/option"
+)
+
+func TestGrokSendsGeneration(t *testing.T) {
+	srv, body := captureBody(t, `{"choices": [{"message": {"content": "# ANSWER\nyes"}}]}`)
+
+	temperature, topP := 0.2, 0.9
+	info := &types.ModelInfo{
+		ID:         Grok,
+		Name:       Grok3Mini,
+		BaseURL:    srv.URL,
+		Generation: types.Generation{Temperature: &temperature, TopP: &topP, ReasoningEffort: "low"},
+		Extra:      map[string]any{"top_p": 0.5},
+	}
+	if _, err := NewGrokModel(info).Prompt(context.Background(), types.PromptRequest{Question: "question?"}); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if (*body)["temperature"] != 0.2 || (*body)["reasoning_effort"] != "low" {
+		t.Errorf("Expected sampling parameters in the body, got %v", *body)
+	}
+	if (*body)["top_p"] != 0.5 {
+		t.Errorf("Expected extra options to win over sampling parameters, got top_p %v", (*body)["top_p"])
+	}
+}
+
+func TestOpenAISendsGeneration(t *testing.T) {
+	srv, body := captureBody(t, `{"choices": [{"index": 0, "message": {"role": "assistant", "content": "# ANSWER\nyes"}}]}`)
+
+	temperature := 1.0
+	info := &types.ModelInfo{ID: GPT, Name: GPT5Mini, Generation: types.Generation{Temperature: &temperature, ReasoningEffort: "minimal"}}
+	m := &OpenAIModel{info: info, client: openai.NewClient(oa.WithBaseURL(srv.URL), oa.WithAPIKey("test"), oa.WithMaxRetries(0))}
+	if _, err := m.Prompt(context.Background(), types.PromptRequest{Question: "question?"}); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if (*body)["temperature"] != 1.0 || (*body)["reasoning_effort"] != "minimal" {
+		t.Errorf("Expected sampling parameters in the body, got %v", *body)
+	}
+	if _, ok := (*body)["top_p"]; ok {
+		t.Errorf("Expected no top_p when unset, got %v", (*body)["top_p"])
+	}
+}
+
+func TestGrokAppliesOverrides(t *testing.T) {
+	srv, body := captureBody(t, `{"model": "grok-3-mini-0425", "choices": [{"message": {"content": "# ANSWER\nyes"}, "finish_reason": "length"}]}`)
+
+	temperature, override := 0.2, 0.7
+	info := &types.ModelInfo{ID: Grok, Name: Grok3Mini, BaseURL: srv.URL, Generation: types.Generation{Temperature: &temperature, ReasoningEffort: "low"}}
+	req := types.PromptRequest{Question: "question?", Overrides: types.Generation{Temperature: &override, MaxTokens: 100}}
+	result, err := NewGrokModel(info).Prompt(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if (*body)["temperature"] != 0.7 || (*body)["reasoning_effort"] != "low" || (*body)["max_tokens"] != 100.0 {
+		t.Errorf("Expected overrides laid over the model's parameters, got %v", *body)
+	}
+	if info.Generation.Temperature != &temperature {
+		t.Error("Expected overrides to leave the model's parameters alone")
+	}
+	if result.FinishReason != "length" || result.ModelVersion != "grok-3-mini-0425" || len(result.Raw) == 0 {
+		t.Errorf("Expected finish reason, model version and raw payload, got %q, %q, %s", result.FinishReason, result.ModelVersion, result.Raw)
+	}
+}
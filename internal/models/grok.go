@@ -5,8 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
+	"github.com/meedamian/fat/internal/extras"
+	"github.com/meedamian/fat/internal/retry"
 	"github.com/meedamian/fat/internal/shared"
 	"github.com/meedamian/fat/internal/types"
 )
@@ -33,6 +36,7 @@ var GrokFamily = types.ModelFamily{
 	Provider: "xAI",
 	BaseURL:  "https://api.x.ai/v1/chat/completions",
 	Variants: map[string]types.ModelVariant{
+		Grok420MultiAgent:      {MaxTok: 2_000_000, Rate: types.Rate{In: 2.0, Out: 6.0}},
 		Grok420:                {MaxTok: 2_000_000, Rate: types.Rate{In: 2.0, Out: 6.0}},
 		Grok41Fast:             {MaxTok: 2_000_000, Rate: types.Rate{In: 0.2, Out: 0.5}},
 		Grok41FastNonReasoning: {MaxTok: 2_000_000, Rate: types.Rate{In: 0.2, Out: 0.5}},
@@ -61,10 +65,12 @@ func NewGrokModel(info *types.ModelInfo) *GrokModel {
 
 // grokResponse represents the API response structure
 type grokResponse struct {
+	Model   string `json:"model"`
 	Choices []struct {
 		Message struct {
 			Content string `json:"content"`
 		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
 	Usage struct {
 		PromptTokens     int64 `json:"prompt_tokens"`
@@ -73,55 +79,78 @@ type grokResponse struct {
 }
 
 // Prompt implements the Model interface
-func (m *GrokModel) Prompt(ctx context.Context, question string, meta types.Meta, replies map[string]types.Reply, discussion map[string]map[string][]types.DiscussionMessage, privateNotes map[int]string) (types.ModelResult, error) {
-	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, question, meta, replies, discussion, privateNotes)
+func (m *GrokModel) Prompt(ctx context.Context, req types.PromptRequest) (types.PromptResponse, error) {
+	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, req.Question, req.Meta, req.Replies, req.Discussion, req.Notes)
+	gen := m.info.Generation.Merge(req.Overrides)
 
 	// Build messages array
 	messages := []map[string]string{{"role": "user", "content": prompt}}
+	if m.info.Persona != "" {
+		messages = append([]map[string]string{{"role": "system", "content": m.info.Persona}}, messages...)
+	}
 
 	// Call Grok API
 	body := map[string]any{
 		"model":    m.info.Name,
 		"messages": messages,
 	}
+	if maxTokens := outputBudget(m.info, prompt, req.Overrides); maxTokens > 0 {
+		body["max_tokens"] = maxTokens
+	}
+	grokGeneration(body, gen)
+	if req.Meta.Structured {
+		body["response_format"] = grokJSONSchema(req.Meta.AnswerSchema)
+	}
+	body = extras.Merge(body, m.info.Extra)
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
-		return types.ModelResult{}, fmt.Errorf("failed to marshal request: %w", err)
+		return types.PromptResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", m.info.BaseURL, bytes.NewBuffer(jsonBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", m.info.BaseURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return types.ModelResult{}, fmt.Errorf("failed to create request: %w", err)
+		return types.PromptResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+m.info.APIKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+	for name := range m.info.Headers {
+		httpReq.Header.Set(name, m.info.Headers.Get(name))
 	}
-	req.Header.Set("Authorization", "Bearer "+m.info.APIKey)
-	req.Header.Set("Content-Type", "application/json")
 
-	res, err := m.client.Do(req)
+	res, err := m.client.Do(httpReq)
 	if err != nil {
-		return types.ModelResult{}, fmt.Errorf("api request failed: %w", err)
+		return types.PromptResponse{}, fmt.Errorf("api request failed: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return types.ModelResult{}, fmt.Errorf("api returned status %d", res.StatusCode)
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return types.PromptResponse{}, retry.Classify(res.StatusCode, res.Header, fmt.Errorf("api returned status %d: %s", res.StatusCode, bytes.TrimSpace(msg)))
 	}
 
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return types.PromptResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
 	var result grokResponse
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
-		return types.ModelResult{}, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return types.PromptResponse{}, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	if len(result.Choices) == 0 {
-		return types.ModelResult{}, fmt.Errorf("no choices in response")
+		return types.PromptResponse{}, fmt.Errorf("no choices in response")
 	}
 
 	content := result.Choices[0].Message.Content
-	reply := shared.ParseResponse(content)
-
-	return types.ModelResult{
-		Reply:  reply,
-		TokIn:  result.Usage.PromptTokens,
-		TokOut: result.Usage.CompletionTokens,
-		Prompt: prompt,
+	reply := shared.ParseReply(content, req.Meta.Structured)
+
+	return types.PromptResponse{
+		Reply:        reply,
+		TokIn:        result.Usage.PromptTokens,
+		TokOut:       result.Usage.CompletionTokens,
+		Prompt:       prompt,
+		FinishReason: result.Choices[0].FinishReason,
+		ModelVersion: result.Model,
+		Raw:          raw,
 	}, nil
 }
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"net/http"
+
+	an "github.com/anthropics/anthropic-sdk-go/option"
+	oa "github.com/openai/openai-go/option"
+)
+
+// openaiHeaders turns extra headers into OpenAI SDK options
+// Pass them after the other options so they can override e.g. Authorization for a gateway.
+func openaiHeaders(h http.Header) []oa.RequestOption {
+	opts := make([]oa.RequestOption, 0, len(h))
+	for name := range h {
+		opts = append(opts, oa.WithHeader(name, h.Get(name)))
+	}
+	return opts
+}
+
+// anthropicHeaders turns extra headers into Anthropic SDK options
+// Pass them after the other options so they can override e.g. x-api-key for a gateway.
+func anthropicHeaders(h http.Header) []an.RequestOption {
+	opts := make([]an.RequestOption, 0, len(h))
+	for name := range h {
+		opts = append(opts, an.WithHeader(name, h.Get(name)))
+	}
+	return opts
+}
+
+// requestIDHeaders names the header each family's API takes a client-chosen request ID in
+// OpenAI documents its own; the others get the conventional X-Request-Id, which gateways and proxies log.
+var requestIDHeaders = map[string]string{
+	GPT:      "X-Client-Request-Id",
+	Claude:   "X-Request-Id",
+	Gemini:   "X-Request-Id",
+	Grok:     "X-Request-Id",
+	DeepSeek: "X-Request-Id",
+	Mistral:  "X-Request-Id",
+}
+
+// WithRequestID returns a copy of h that also sends requestID to familyID's API, so a provider call can be traced back
+// to the run that made it. A header of the same name in h is left alone, as the operator configured it deliberately.
+func WithRequestID(familyID string, h http.Header, requestID string) http.Header {
+	name, ok := requestIDHeaders[familyID]
+	if !ok || requestID == "" || h.Get(name) != "" {
+		return h
+	}
+	h = h.Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	h.Set(name, requestID)
+	return h
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestGrokSendsExtraHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"choices": [{"message": {"content": "# ANSWER\nyes"}}]}`))
+	}))
+	defer srv.Close()
+
+	info := &types.ModelInfo{
+		ID:      Grok,
+		Name:    Grok4,
+		BaseURL: srv.URL,
+		APIKey:  "xai-key",
+		Headers: http.Header{"X-Org-Id": {"acme"}, "Authorization": {"Bearer gateway"}},
+	}
+	if _, err := NewGrokModel(info).Prompt(context.Background(), types.PromptRequest{Question: "question?"}); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if got.Get("X-Org-Id") != "acme" {
+		t.Errorf("Expected X-Org-Id header, got %v", got)
+	}
+	if got.Get("Authorization") != "Bearer gateway" {
+		t.Errorf("Expected extra headers to override Authorization, got %q", got.Get("Authorization"))
+	}
+}
+
+func TestDeepSeekSendsExtraHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"index": 0, "message": {"role": "assistant", "content": "# ANSWER\nyes"}}]}`))
+	}))
+	defer srv.Close()
+
+	info := &types.ModelInfo{
+		ID:      DeepSeek,
+		Name:    DeepSeekChat,
+		BaseURL: srv.URL,
+		APIKey:  "ds-key",
+		Headers: http.Header{"X-Org-Id": {"acme"}},
+	}
+	if _, err := NewDeepSeekModel(info).Prompt(context.Background(), types.PromptRequest{Question: "question?"}); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if got.Get("X-Org-Id") != "acme" {
+		t.Errorf("Expected X-Org-Id header, got %v", got)
+	}
+	if got.Get("Authorization") != "Bearer ds-key" {
+		t.Errorf("Expected the API key to still be sent, got %q", got.Get("Authorization"))
+	}
+}
+
+func TestGrokSendsRequestID(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"choices": [{"message": {"content": "# ANSWER\nyes"}}]}`))
+	}))
+	defer srv.Close()
+
+	configured := http.Header{"X-Org-Id": {"acme"}}
+	info := &types.ModelInfo{ID: Grok, Name: Grok4, BaseURL: srv.URL, APIKey: "xai-key", Headers: WithRequestID(Grok, configured, "req-1")}
+	if _, err := NewGrokModel(info).Prompt(context.Background(), types.PromptRequest{Question: "question?"}); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if got.Get("X-Request-Id") != "req-1" || got.Get("X-Org-Id") != "acme" {
+		t.Errorf("Expected the request ID next to the configured headers, got %v", got)
+	}
+	if configured.Get("X-Request-Id") != "" {
+		t.Error("Expected the configured headers untouched")
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	if h := WithRequestID(GPT, nil, "req-1"); h.Get("X-Client-Request-Id") != "req-1" {
+		t.Errorf("Expected OpenAI's client request ID header, got %v", h)
+	}
+	if h := WithRequestID(Claude, http.Header{"X-Request-Id": {"gateway"}}, "req-1"); h.Get("X-Request-Id") != "gateway" {
+		t.Errorf("Expected the operator's header to win, got %v", h)
+	}
+	if h := WithRequestID("unknown", nil, "req-1"); h != nil {
+		t.Errorf("Expected no header for an unknown family, got %v", h)
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/meedamian/fat/internal/shared"
@@ -48,11 +49,13 @@ type MistralModel struct {
 // NewMistralModel creates a new Mistral model instance
 func NewMistralModel(info *types.ModelInfo) *MistralModel {
 	// Mistral uses OpenAI-compatible API
-	client := openai.NewClient(
+	opts := []oa.RequestOption{
 		oa.WithAPIKey(info.APIKey),
 		oa.WithBaseURL("https://api.mistral.ai/v1"),
-		oa.WithMaxRetries(3),
-	)
+		oa.WithMaxRetries(sdkMaxRetries),
+		oa.WithHTTPClient(shared.NewHTTPClient(info.RequestTimeout)),
+	}
+	client := openai.NewClient(append(opts, openaiHeaders(info.Headers)...)...)
 	return &MistralModel{
 		info:   info,
 		client: client,
@@ -60,28 +63,42 @@ func NewMistralModel(info *types.ModelInfo) *MistralModel {
 }
 
 // Prompt implements the Model interface
-func (m *MistralModel) Prompt(ctx context.Context, question string, meta types.Meta, replies map[string]types.Reply, discussion map[string]map[string][]types.DiscussionMessage, privateNotes map[int]string) (types.ModelResult, error) {
-	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, question, meta, replies, discussion, privateNotes)
+func (m *MistralModel) Prompt(ctx context.Context, req types.PromptRequest) (types.PromptResponse, error) {
+	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, req.Question, req.Meta, req.Replies, req.Discussion, req.Notes)
+	gen := m.info.Generation.Merge(req.Overrides)
+
+	messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage(prompt)}
+	if m.info.Persona != "" {
+		messages = append([]openai.ChatCompletionMessageParamUnion{openai.SystemMessage(m.info.Persona)}, messages...)
+	}
 
 	params := openai.ChatCompletionNewParams{
-		Model: openai.ChatModel(m.info.Name),
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(prompt),
-		},
+		Model:    openai.ChatModel(m.info.Name),
+		Messages: messages,
+	}
+	if maxTokens := outputBudget(m.info, prompt, req.Overrides); maxTokens > 0 {
+		params.MaxTokens = openai.Int(maxTokens)
+	}
+	openaiGeneration(&params, gen)
+	if req.Meta.Structured {
+		params.ResponseFormat = openaiJSONObject()
 	}
 
-	result, err := m.client.Chat.Completions.New(ctx, params)
+	result, err := m.client.Chat.Completions.New(ctx, params, openaiExtras(m.info.Extra)...)
 	if err != nil {
-		return types.ModelResult{}, fmt.Errorf("mistral api call failed: %w", err)
+		return types.PromptResponse{}, fmt.Errorf("mistral api call failed: %w", classifyError(err))
 	}
 
 	content := result.Choices[0].Message.Content
-	reply := shared.ParseResponse(content)
+	reply := shared.ParseReply(content, req.Meta.Structured)
 
-	return types.ModelResult{
-		Reply:  reply,
-		TokIn:  result.Usage.PromptTokens,
-		TokOut: result.Usage.CompletionTokens,
-		Prompt: prompt,
+	return types.PromptResponse{
+		Reply:        reply,
+		TokIn:        result.Usage.PromptTokens,
+		TokOut:       result.Usage.CompletionTokens,
+		Prompt:       prompt,
+		FinishReason: result.Choices[0].FinishReason,
+		ModelVersion: result.Model,
+		Raw:          json.RawMessage(result.RawJSON()),
 	}, nil
 }
@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 
+	"github.com/meedamian/fat/internal/faults"
 	"github.com/meedamian/fat/internal/types"
 )
 
@@ -57,32 +58,79 @@ func buildDefaultModels() map[string]*types.ModelInfo {
 		}
 
 		models[familyID] = &types.ModelInfo{
-			ID:      family.ID,
-			Name:    variantName,
-			MaxTok:  variant.MaxTok,
-			BaseURL: family.BaseURL,
+			ID:        family.ID,
+			Name:      variantName,
+			MaxTok:    variant.MaxTok,
+			MaxOut:    variant.MaxOut,
+			Extra:     variant.Extra,
+			Responses: variant.Responses,
+			BaseURL:   family.BaseURL,
 		}
 	}
 
 	return models
 }
 
+// ThinkingBudget returns budget for variants that support extended thinking and 0 for the rest
+func ThinkingBudget(familyID, variantName string, budget int64) int64 {
+	if !ModelFamilies[familyID].Variants[variantName].Thinking {
+		return 0
+	}
+	return budget
+}
+
+// FamilyForVariant returns the ID of the family offering the given variant, or "" if none does
+func FamilyForVariant(variantName string) string {
+	for familyID, family := range ModelFamilies {
+		if _, ok := family.Variants[variantName]; ok {
+			return familyID
+		}
+	}
+	return ""
+}
+
+// AgentIDs names the agents of a run taking part as the given variants, returning agent ID -> variant
+// An agent goes by its family ID, unless the run has several variants of its family; each of them then
+// goes by its variant name, so gpt-5 can answer next to gpt-5-mini. Unknown variants are left out.
+func AgentIDs(variants []string) map[string]string {
+	perFamily := make(map[string]int, len(variants))
+	for _, variant := range variants {
+		perFamily[FamilyForVariant(variant)]++
+	}
+
+	agents := make(map[string]string, len(variants))
+	for _, variant := range variants {
+		familyID := FamilyForVariant(variant)
+		switch {
+		case familyID == "":
+		case perFamily[familyID] == 1:
+			agents[familyID] = variant
+		default:
+			agents[variant] = variant
+		}
+	}
+	return agents
+}
+
 // NewModel creates a Model implementation for the given model info
+// Its calls get whatever faults are being injected, see the faults package.
 func NewModel(info *types.ModelInfo) types.Model {
-	switch info.ID {
+	var model types.Model
+	switch info.FamilyID() {
 	case Grok:
-		return NewGrokModel(info)
+		model = NewGrokModel(info)
 	case GPT:
-		return NewOpenAIModel(info)
+		model = NewOpenAIModel(info)
 	case Claude:
-		return NewClaudeModel(info)
+		model = NewClaudeModel(info)
 	case Gemini:
-		return NewGeminiModel(info)
+		model = NewGeminiModel(info)
 	case DeepSeek:
-		return NewDeepSeekModel(info)
+		model = NewDeepSeekModel(info)
 	case Mistral:
-		return NewMistralModel(info)
+		model = NewMistralModel(info)
 	default:
 		return nil
 	}
+	return faults.Wrap(model, info)
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestAgentIDs(t *testing.T) {
+	agents := AgentIDs([]string{GPT5, Grok420, GPT5Mini, "gpt-0"})
+	want := map[string]string{GPT5: GPT5, GPT5Mini: GPT5Mini, Grok: Grok420}
+	if !maps.Equal(agents, want) {
+		t.Errorf("Expected the gpt variants to go by their names and grok by its family, got %v", agents)
+	}
+
+	if agents := AgentIDs([]string{GPT5Mini, Claude45Sonnet}); !maps.Equal(agents, map[string]string{GPT: GPT5Mini, Claude: Claude45Sonnet}) {
+		t.Errorf("Expected one variant per family to go by the family, got %v", agents)
+	}
+}
+
+func TestNewModelUsesFamily(t *testing.T) {
+	mi := AllModels[GPT]
+	agent := *mi
+	agent.ID, agent.Family, agent.Name = GPT5Mini, GPT, GPT5Mini
+	if NewModel(&agent) == nil {
+		t.Error("Expected an agent named after its variant to get its family's model")
+	}
+}
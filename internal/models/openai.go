@@ -2,12 +2,15 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/meedamian/fat/internal/shared"
 	"github.com/meedamian/fat/internal/types"
 	"github.com/openai/openai-go"
 	oa "github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/responses"
+	oashared "github.com/openai/openai-go/shared"
 )
 
 const (
@@ -42,27 +45,27 @@ var GPTFamily = types.ModelFamily{
 	Provider: "OpenAI",
 	BaseURL:  "https://api.openai.com/v1/chat/completions",
 	Variants: map[string]types.ModelVariant{
-		GPT54Nano: {MaxTok: 400_000, Rate: types.Rate{In: 0.2, Out: 1.25}},
-		GPT54Mini: {MaxTok: 400_000, Rate: types.Rate{In: 0.75, Out: 4.5}},
-		GPT54:     {MaxTok: 400_000, Rate: types.Rate{In: 2.5, Out: 15.0}},
-		GPT54Pro:  {MaxTok: 400_000, Rate: types.Rate{In: 30.0, Out: 180.0}},
-
-		GPT52:    {MaxTok: 400_000, Rate: types.Rate{In: 1.75, Out: 14.0}},
-		GPT52Pro: {MaxTok: 400_000, Rate: types.Rate{In: 21.0, Out: 168.0}},
-
-		GPT51:         {MaxTok: 400_000, Rate: types.Rate{In: 1.25, Out: 10.0}},
-		GPT51Codex:    {MaxTok: 400_000, Rate: types.Rate{In: 1.25, Out: 10.0}},
-		GPT51CodexMax: {MaxTok: 400_000, Rate: types.Rate{In: 1.25, Out: 10.0}},
-
-		GPT5Pro:   {MaxTok: 400_000, Rate: types.Rate{In: 15.0, Out: 120.0}},
-		GPT5:      {MaxTok: 400_000, Rate: types.Rate{In: 1.25, Out: 10.0}},
-		GPT5Codex: {MaxTok: 400_000, Rate: types.Rate{In: 1.25, Out: 10.0}},
-		GPT5Mini:  {MaxTok: 400_000, Rate: types.Rate{In: 0.25, Out: 2.0}},
-		GPT5Nano:  {MaxTok: 400_000, Rate: types.Rate{In: 0.05, Out: 0.4}},
-
-		GPT41:     {MaxTok: 1_047_576, Rate: types.Rate{In: 2.0, Out: 8.0}},
-		GPT41Mini: {MaxTok: 1_047_576, Rate: types.Rate{In: 0.4, Out: 1.6}},
-		GPT41Nano: {MaxTok: 1_047_576, Rate: types.Rate{In: 0.1, Out: 0.4}},
+		GPT54Nano: {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 0.2, Out: 1.25}},
+		GPT54Mini: {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 0.75, Out: 4.5}},
+		GPT54:     {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 2.5, Out: 15.0}},
+		GPT54Pro:  {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 30.0, Out: 180.0}, Responses: true},
+
+		GPT52:    {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 1.75, Out: 14.0}},
+		GPT52Pro: {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 21.0, Out: 168.0}, Responses: true},
+
+		GPT51:         {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 1.25, Out: 10.0}},
+		GPT51Codex:    {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 1.25, Out: 10.0}, Responses: true},
+		GPT51CodexMax: {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 1.25, Out: 10.0}, Responses: true},
+
+		GPT5Pro:   {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 15.0, Out: 120.0}, Responses: true},
+		GPT5:      {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 1.25, Out: 10.0}},
+		GPT5Codex: {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 1.25, Out: 10.0}, Responses: true},
+		GPT5Mini:  {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 0.25, Out: 2.0}},
+		GPT5Nano:  {MaxTok: 400_000, MaxOut: 128_000, Rate: types.Rate{In: 0.05, Out: 0.4}},
+
+		GPT41:     {MaxTok: 1_047_576, MaxOut: 32_768, Rate: types.Rate{In: 2.0, Out: 8.0}},
+		GPT41Mini: {MaxTok: 1_047_576, MaxOut: 32_768, Rate: types.Rate{In: 0.4, Out: 1.6}},
+		GPT41Nano: {MaxTok: 1_047_576, MaxOut: 32_768, Rate: types.Rate{In: 0.1, Out: 0.4}},
 	},
 }
 
@@ -74,7 +77,12 @@ type OpenAIModel struct {
 
 // NewOpenAIModel creates a new OpenAI model instance
 func NewOpenAIModel(info *types.ModelInfo) *OpenAIModel {
-	client := openai.NewClient(oa.WithAPIKey(info.APIKey), oa.WithMaxRetries(3))
+	opts := []oa.RequestOption{
+		oa.WithAPIKey(info.APIKey),
+		oa.WithMaxRetries(sdkMaxRetries),
+		oa.WithHTTPClient(shared.NewHTTPClient(info.RequestTimeout)),
+	}
+	client := openai.NewClient(append(opts, openaiHeaders(info.Headers)...)...)
 	return &OpenAIModel{
 		info:   info,
 		client: client,
@@ -82,28 +90,93 @@ func NewOpenAIModel(info *types.ModelInfo) *OpenAIModel {
 }
 
 // Prompt implements the Model interface
-func (m *OpenAIModel) Prompt(ctx context.Context, question string, meta types.Meta, replies map[string]types.Reply, discussion map[string]map[string][]types.DiscussionMessage, privateNotes map[int]string) (types.ModelResult, error) {
-	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, question, meta, replies, discussion, privateNotes)
+func (m *OpenAIModel) Prompt(ctx context.Context, req types.PromptRequest) (types.PromptResponse, error) {
+	prompt := shared.FormatPrompt(m.info.ID, m.info.Name, req.Question, req.Meta, req.Replies, req.Discussion, req.Notes)
+	gen := m.info.Generation.Merge(req.Overrides)
+	if m.info.Responses {
+		return m.respond(ctx, prompt, req, gen)
+	}
+
+	messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage(prompt)}
+	if m.info.Persona != "" {
+		messages = append([]openai.ChatCompletionMessageParamUnion{openai.SystemMessage(m.info.Persona)}, messages...)
+	}
 
 	params := openai.ChatCompletionNewParams{
-		Model: openai.ChatModel(m.info.Name),
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(prompt),
-		},
+		Model:    openai.ChatModel(m.info.Name),
+		Messages: messages,
+	}
+	if maxTokens := outputBudget(m.info, prompt, req.Overrides); maxTokens > 0 {
+		params.MaxCompletionTokens = openai.Int(maxTokens)
+	}
+	openaiGeneration(&params, gen)
+	if effort := gen.ReasoningEffort; effort != "" {
+		params.ReasoningEffort = oashared.ReasoningEffort(effort)
+	}
+	if req.Meta.Structured {
+		params.ResponseFormat = openaiJSONSchema(req.Meta.AnswerSchema)
 	}
 
-	result, err := m.client.Chat.Completions.New(ctx, params)
+	result, err := m.client.Chat.Completions.New(ctx, params, openaiExtras(m.info.Extra)...)
 	if err != nil {
-		return types.ModelResult{}, fmt.Errorf("openai api call failed: %w", err)
+		return types.PromptResponse{}, fmt.Errorf("openai api call failed: %w", classifyError(err))
 	}
 
 	content := result.Choices[0].Message.Content
-	reply := shared.ParseResponse(content)
+	reply := shared.ParseReply(content, req.Meta.Structured)
+
+	return types.PromptResponse{
+		Reply:        reply,
+		TokIn:        result.Usage.PromptTokens,
+		TokOut:       result.Usage.CompletionTokens,
+		Prompt:       prompt,
+		FinishReason: result.Choices[0].FinishReason,
+		ModelVersion: result.Model,
+		Raw:          json.RawMessage(result.RawJSON()),
+	}, nil
+}
+
+// respond sends prompt through the Responses API, the only one serving the pro and codex variants
+func (m *OpenAIModel) respond(ctx context.Context, prompt string, req types.PromptRequest, gen types.Generation) (types.PromptResponse, error) {
+	params := responses.ResponseNewParams{
+		Model: m.info.Name,
+		Input: responses.ResponseNewParamsInputUnion{OfString: openai.String(prompt)},
+		Store: openai.Bool(false), // Every call is self-contained, nothing to continue from
+	}
+	if m.info.Persona != "" {
+		params.Instructions = openai.String(m.info.Persona)
+	}
+	if maxTokens := outputBudget(m.info, prompt, req.Overrides); maxTokens > 0 {
+		params.MaxOutputTokens = openai.Int(maxTokens)
+	}
+	responsesGeneration(&params, gen)
+	if req.Meta.Structured {
+		params.Text = responsesJSONSchema(req.Meta.AnswerSchema)
+	}
+
+	result, err := m.client.Responses.New(ctx, params, openaiExtras(m.info.Extra)...)
+	if err != nil {
+		return types.PromptResponse{}, fmt.Errorf("openai api call failed: %w", classifyError(err))
+	}
+
+	content := result.OutputText()
+	if content == "" && result.Status == responses.ResponseStatusIncomplete {
+		return types.PromptResponse{}, fmt.Errorf("openai response incomplete: %s", result.IncompleteDetails.Reason)
+	}
+	reply := shared.ParseReply(content, req.Meta.Structured)
+
+	finish := string(result.Status)
+	if result.IncompleteDetails.Reason != "" {
+		finish = result.IncompleteDetails.Reason
+	}
 
-	return types.ModelResult{
-		Reply:  reply,
-		TokIn:  result.Usage.PromptTokens,
-		TokOut: result.Usage.CompletionTokens,
-		Prompt: prompt,
+	return types.PromptResponse{
+		Reply:        reply,
+		TokIn:        result.Usage.InputTokens,
+		TokOut:       result.Usage.OutputTokens,
+		Prompt:       prompt,
+		FinishReason: finish,
+		ModelVersion: string(result.Model),
+		Raw:          json.RawMessage(result.RawJSON()),
 	}, nil
 }
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+	"github.com/openai/openai-go"
+	oa "github.com/openai/openai-go/option"
+)
+
+func TestOpenAIResponses(t *testing.T) {
+	srv, body := captureBody(t, `{
+		"id": "resp_1", "object": "response", "status": "completed", "model": "gpt-5-pro",
+		"output": [{"type": "message", "id": "msg_1", "role": "assistant", "status": "completed",
+			"content": [{"type": "output_text", "text": "# ANSWER\nyes", "annotations": []}]}],
+		"usage": {"input_tokens": 12, "output_tokens": 7, "total_tokens": 19}
+	}`)
+
+	info := &types.ModelInfo{ID: GPT, Name: GPT5Pro, Persona: "Be brief.", MaxOut: 2048, Responses: true}
+	m := &OpenAIModel{info: info, client: openai.NewClient(oa.WithBaseURL(srv.URL), oa.WithAPIKey("test"), oa.WithMaxRetries(0))}
+
+	result, err := m.Prompt(context.Background(), types.PromptRequest{Question: "question?"})
+	if err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if _, ok := (*body)["input"].(string); !ok || (*body)["messages"] != nil {
+		t.Errorf("Expected a Responses API request, got %v", *body)
+	}
+	if (*body)["instructions"] != "Be brief." || (*body)["store"] != false || (*body)["max_output_tokens"] != float64(2048) {
+		t.Errorf("Unexpected request body: %v", *body)
+	}
+	if result.Reply.Answer != "yes" || result.TokIn != 12 || result.TokOut != 7 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
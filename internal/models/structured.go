@@ -0,0 +1,73 @@
+package models
+
+import (
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/responses"
+	oashared "github.com/openai/openai-go/shared"
+)
+
+// openaiJSONSchema asks an OpenAI-compatible Chat Completions API for a reply matching the reply schema
+// Strict mode accepts only a subset of JSON schema that submitted answer schemas rarely keep to,
+// so it is only used for free-text answers.
+func openaiJSONSchema(answer map[string]any) openai.ChatCompletionNewParamsResponseFormatUnion {
+	return openai.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONSchema: &oashared.ResponseFormatJSONSchemaParam{
+			JSONSchema: oashared.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:   shared.ReplySchemaName,
+				Schema: shared.ReplySchema(answer),
+				Strict: openai.Bool(answer == nil),
+			},
+		},
+	}
+}
+
+// openaiJSONObject asks for any JSON object, for APIs with a JSON mode but no schema support
+func openaiJSONObject() openai.ChatCompletionNewParamsResponseFormatUnion {
+	return openai.ChatCompletionNewParamsResponseFormatUnion{OfJSONObject: &oashared.ResponseFormatJSONObjectParam{}}
+}
+
+// responsesJSONSchema is openaiJSONSchema for the Responses API
+func responsesJSONSchema(answer map[string]any) responses.ResponseTextConfigParam {
+	return responses.ResponseTextConfigParam{
+		Format: responses.ResponseFormatTextConfigUnionParam{
+			OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
+				Name:   shared.ReplySchemaName,
+				Schema: shared.ReplySchema(answer),
+				Strict: openai.Bool(answer == nil),
+			},
+		},
+	}
+}
+
+// grokJSONSchema is openaiJSONSchema as a raw request body field
+func grokJSONSchema(answer map[string]any) map[string]any {
+	return map[string]any{
+		"type": "json_schema",
+		"json_schema": map[string]any{
+			"name":   shared.ReplySchemaName,
+			"schema": shared.ReplySchema(answer),
+			"strict": answer == nil,
+		},
+	}
+}
+
+// claudeReplyTool makes Claude return the reply as the input of a forced tool call,
+// the Messages API's way of producing JSON that follows a schema
+func claudeReplyTool(answer map[string]any) ([]anthropic.ToolUnionParam, anthropic.ToolChoiceUnionParam) {
+	schema := shared.ReplySchema(answer)
+	required, _ := schema["required"].([]string)
+
+	tool := anthropic.ToolParam{
+		Name:        shared.ReplySchemaName,
+		Description: anthropic.String("Submit your reply for this round"),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties:  schema["properties"],
+			Required:    required,
+			ExtraFields: map[string]any{"additionalProperties": false},
+		},
+	}
+	return []anthropic.ToolUnionParam{{OfTool: &tool}},
+		anthropic.ToolChoiceUnionParam{OfTool: &anthropic.ToolChoiceToolParam{Name: shared.ReplySchemaName}}
+}
@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/ranking"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// CacheKey identifies a run for the answer cache: the question, the rounds, the participating and judging variants,
+// and the options that change what the models are asked or how the winner is picked. Pricing and tags only change how a run
+// is accounted, so they're left out.
+func CacheKey(question string, rounds int, participants, judges []*types.ModelInfo, opts Options) string {
+	variants := func(mis []*types.ModelInfo) []string {
+		names := make([]string, 0, len(mis))
+		for _, mi := range mis {
+			names = append(names, mi.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	strategy := opts.Strategy
+	if strategy == ranking.DefaultStrategy {
+		strategy = ""
+	}
+
+	key, _ := json.Marshal(struct {
+		Question     string                      `json:"question"`
+		Rounds       int                         `json:"rounds"`
+		Participants []string                    `json:"participants"`
+		Judges       []string                    `json:"judges"`
+		Generation   map[string]types.Generation `json:"generation"`
+		AnswerSchema map[string]any              `json:"answer_schema"`
+		Strategy     string                      `json:"strategy,omitempty"` // Left out for the default, so keys from before strategies still match
+		MetaJudge    string                      `json:"meta_judge,omitempty"`
+		Audit        bool                        `json:"audit,omitempty"`
+		Verify       bool                        `json:"verify,omitempty"`
+		Synthesizer  string                      `json:"synthesizer,omitempty"`
+		Mode         string                      `json:"mode,omitempty"`
+		Rotate       bool                        `json:"rotate_positions,omitempty"`
+	}{strings.TrimSpace(question), rounds, variants(participants), variants(judges), opts.Generation, opts.AnswerSchema, strategy, opts.MetaJudge, opts.Audit, opts.Verify, opts.Synthesizer, opts.Mode, opts.RotatePositions})
+
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
+
+// CachedRun returns the newest error-free run stored with key in the last ttl and its event log, or "" if there is none
+func (o *Orchestrator) CachedRun(ctx context.Context, key string, ttl time.Duration) (string, []db.Event, error) {
+	requestID, err := o.database.GetCachedRequestID(ctx, key, time.Now().Add(-ttl))
+	if err != nil || requestID == "" {
+		return "", nil, err
+	}
+
+	events, err := o.database.GetEvents(ctx, requestID, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return requestID, events, nil
+}
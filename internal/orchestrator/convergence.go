@@ -0,0 +1,48 @@
+package orchestrator
+
+import (
+	"github.com/meedamian/fat/internal/similarity"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// roundOutcome collects what a round produced, to decide whether the remaining rounds are worth running
+type roundOutcome struct {
+	failed        bool    // A model errored, so the round can't be compared
+	searches      bool    // A model asked for searches whose results only the next round would see
+	compared      int     // Models whose answer was compared to their previous one
+	minSimilarity float64 // Lowest similarity between a model's consecutive answers
+	messages      int     // Discussion messages sent this round
+}
+
+// add records one model's reply; previous is its answer from the round before ("" if it had none)
+func (ro *roundOutcome) add(previous string, reply types.Reply) {
+	if len(reply.Searches) > 0 {
+		ro.searches = true
+	}
+	ro.messages += len(reply.Discussion)
+
+	if previous == "" {
+		return
+	}
+	score := similarity.Text(previous, reply.Answer)
+	if ro.compared == 0 || score < ro.minSimilarity {
+		ro.minSimilarity = score
+	}
+	ro.compared++
+}
+
+// converged reports whether the round settled the discussion: every model answered, and either every
+// answer stayed at least threshold similar to the model's previous one, or nobody had anything to say
+// to the others. A threshold of 0 disables early stopping; round 1 never converges.
+func (ro *roundOutcome) converged(round, numModels int, threshold float64) (string, bool) {
+	if threshold <= 0 || round < 2 || ro.failed || ro.searches {
+		return "", false
+	}
+	if ro.compared == numModels && ro.minSimilarity >= threshold {
+		return "similar answers", true
+	}
+	if ro.messages == 0 {
+		return "no discussion", true
+	}
+	return "", false
+}
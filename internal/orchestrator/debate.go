@@ -0,0 +1,19 @@
+package orchestrator
+
+import (
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// debatePositions returns the side every participant argues in round (1-based) by model ID, nil outside debates
+// Sides alternate in the order the participants were selected, so they stay put when a run is resumed.
+func debatePositions(opts Options, activeModels []*types.ModelInfo, round int) map[string]string {
+	if opts.Mode != shared.ModeDebate {
+		return nil
+	}
+	ids := make([]string, 0, len(activeModels))
+	for _, mi := range activeModels {
+		ids = append(ids, mi.ID)
+	}
+	return shared.DebatePositions(ids, round, opts.RotatePositions)
+}
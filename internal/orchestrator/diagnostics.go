@@ -0,0 +1,64 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/meedamian/fat/internal/diagnostics"
+	"github.com/meedamian/fat/internal/metrics"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// captureDiagnostics writes a diagnostic bundle if the run failed: a model call errored or no winner was found
+// Cancelled runs didn't fail and are left alone.
+func (o *Orchestrator) captureDiagnostics(ctx context.Context, logger *slog.Logger, requestID, question, winnerID string, activeModels []*types.ModelInfo, reqMetrics *metrics.RequestMetrics) {
+	if o.diagnostics == nil || ctx.Err() != nil {
+		return
+	}
+
+	prompts := o.lastPrompts(requestID)
+	bundle := diagnostics.Bundle{RequestID: requestID, Question: question}
+	for _, mi := range activeModels {
+		m := diagnostics.Model{ModelID: mi.ID, Variant: mi.Name, LastPrompt: prompts[mi.ID]}
+		if mm := reqMetrics.ModelMetrics[mi.ID]; mm != nil {
+			for _, rm := range mm.RoundMetrics {
+				m.Rounds = append(m.Rounds, diagnostics.Round{
+					Round:      rm.Round,
+					DurationMs: rm.Duration.Milliseconds(),
+					TokensIn:   rm.Tokens.Input,
+					TokensOut:  rm.Tokens.Output,
+					Error:      rm.Error,
+				})
+				if rm.Error != "" {
+					bundle.Errors = append(bundle.Errors, diagnostics.Error{ModelID: mi.ID, Round: rm.Round, Error: rm.Error})
+				}
+			}
+		}
+		bundle.Models = append(bundle.Models, m)
+	}
+	sort.Slice(bundle.Errors, func(i, j int) bool {
+		if bundle.Errors[i].Round != bundle.Errors[j].Round {
+			return bundle.Errors[i].Round < bundle.Errors[j].Round
+		}
+		return bundle.Errors[i].ModelID < bundle.Errors[j].ModelID
+	})
+
+	switch {
+	case winnerID == "":
+		bundle.Reason = "no winner"
+	case len(bundle.Errors) == 1:
+		bundle.Reason = "1 model call failed"
+	case len(bundle.Errors) > 1:
+		bundle.Reason = fmt.Sprintf("%d model calls failed", len(bundle.Errors))
+	default:
+		return
+	}
+
+	if err := o.diagnostics.Capture(bundle); err != nil {
+		logger.Warn("failed to capture diagnostics", slog.Any("error", err))
+		return
+	}
+	logger.Info("diagnostics captured", slog.String("reason", bundle.Reason))
+}
@@ -0,0 +1,63 @@
+package orchestrator
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/meedamian/fat/internal/similarity"
+)
+
+// duplicateScanLimit caps how many past requests are compared against a new question
+const duplicateScanLimit = 1000
+
+// Duplicate is a previously answered question similar to a new one
+type Duplicate struct {
+	RequestID   string    `json:"request_id"`
+	Question    string    `json:"question"`
+	Similarity  float64   `json:"similarity"`
+	WinnerModel string    `json:"winner_model"`
+	Answer      string    `json:"answer"` // Winner's final answer, offered as the cached result
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// FindDuplicates returns completed requests whose question scores at least threshold against question
+// Matches are ordered most similar first; a threshold of 0 disables the check
+func (o *Orchestrator) FindDuplicates(ctx context.Context, question string, threshold float64) ([]Duplicate, error) {
+	if threshold <= 0 {
+		return nil, nil
+	}
+
+	previous, err := o.database.GetRecentRequests(ctx, duplicateScanLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []Duplicate
+	for _, req := range previous {
+		score := similarity.Text(question, req.Question)
+		if score < threshold {
+			continue
+		}
+
+		answer, err := o.database.GetFinalAnswer(ctx, req.ID, req.WinnerModel)
+		if err != nil {
+			return nil, err
+		}
+
+		duplicates = append(duplicates, Duplicate{
+			RequestID:   req.ID,
+			Question:    req.Question,
+			Similarity:  score,
+			WinnerModel: req.WinnerModel,
+			Answer:      answer,
+			CreatedAt:   req.CreatedAt,
+		})
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Similarity > duplicates[j].Similarity
+	})
+
+	return duplicates, nil
+}
@@ -0,0 +1,166 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/meedamian/fat/internal/htmlexport"
+	"github.com/meedamian/fat/internal/retry"
+	"github.com/meedamian/fat/internal/storage"
+)
+
+// A finished request's exports are written and published by workers of their own, so a slow disk or
+// export store never holds up the winner or the next queued question. Failed exports are retried with
+// backoff, and every step is sent to clients as an export message.
+const (
+	exportWorkers   = 2
+	exportQueueSize = 64 // Requests waiting for a worker before further exports are dropped
+)
+
+// exportRetry is how often and how patiently a failed export is attempted again
+var exportRetry = retry.Config{
+	MaxAttempts:  3,
+	InitialDelay: 2 * time.Second,
+	MaxDelay:     30 * time.Second,
+	Multiplier:   2.0,
+}
+
+// Statuses of a request's exports, as sent in export messages
+const (
+	ExportQueued   = "queued"
+	ExportRetrying = "retrying"
+	ExportDone     = "done"
+	ExportFailed   = "failed"
+)
+
+// errExportQueueFull is reported when a request's exports can't even be queued
+var errExportQueueFull = errors.New("export queue full")
+
+// exportJob is a finished request's exports waiting for a worker
+type exportJob struct {
+	requestID string
+	clientRef string // Echoed in the export messages, which outlive the run
+	data      htmlexport.ExportData
+}
+
+// startExportWorkers starts the goroutines writing queued exports
+func (o *Orchestrator) startExportWorkers() {
+	for range exportWorkers {
+		go func() {
+			for job := range o.exportQueue {
+				o.export(job)
+				o.exporting.Done()
+			}
+		}()
+	}
+}
+
+// queueExport hands a request's exports to the workers
+func (o *Orchestrator) queueExport(ctx context.Context, logger *slog.Logger, job exportJob) {
+	o.exporting.Add(1)
+	select {
+	case o.exportQueue <- job:
+		o.emitExport(ctx, job, ExportQueued, 0, nil)
+	default:
+		o.exporting.Done()
+		logger.Error("failed to queue exports", slog.Int("queued", exportQueueSize), slog.Any("error", errExportQueueFull))
+		o.emitExport(ctx, job, ExportFailed, 0, errExportQueueFull)
+	}
+}
+
+// export writes a request's exports and publishes them, attempting again after failures
+// Exports outlive the client that asked, and are only abandoned when running requests are aborted.
+func (o *Orchestrator) export(job exportJob) {
+	ctx := o.runsCtx
+	logger := o.logger.With("request_id", job.requestID)
+	start := time.Now()
+
+	attempt := 0
+	err := retry.Do(ctx, exportRetry, func() error {
+		attempt++
+		err := o.writeExports(ctx, job)
+		if err != nil && attempt < exportRetry.MaxAttempts && retry.IsRetryable(err) {
+			logger.Warn("export failed, retrying", slog.Int("attempt", attempt), slog.Any("error", err))
+			o.emitExport(ctx, job, ExportRetrying, attempt, err)
+		}
+		return err
+	})
+	if err != nil {
+		logger.Error("failed to export", slog.Int("attempts", attempt), slog.Any("error", err))
+		o.emitExport(ctx, job, ExportFailed, attempt, err)
+		return
+	}
+
+	logger.Info("exports written", slog.Int("attempts", attempt), slog.Duration("took", time.Since(start)))
+	o.emitExport(ctx, job, ExportDone, attempt, nil)
+}
+
+// writeExports renders the HTML, summary card and Markdown exports, then uploads them if a store is set
+func (o *Orchestrator) writeExports(ctx context.Context, job exportJob) error {
+	if err := o.exporter.Export(ctx, job.data); err != nil {
+		return err
+	}
+
+	// Markdown transcript alongside the HTML
+	if o.mdExporter != nil {
+		if err := o.mdExporter.Export(ctx, job.data); err != nil {
+			return fmt.Errorf("markdown export: %w", err)
+		}
+	}
+
+	if o.store != nil {
+		if err := o.publishExports(ctx, job.requestID, job.data.Question, job.data.QuestionTS); err != nil {
+			return fmt.Errorf("upload: %w", err)
+		}
+	}
+	return nil
+}
+
+// emitExport tells clients how a request's exports are coming along
+// Once done, url is where the HTML export is served: remote storage if set, otherwise /h/.
+func (o *Orchestrator) emitExport(ctx context.Context, job exportJob, status string, attempt int, err error) {
+	message := withClientRef(map[string]any{
+		"type":       "export",
+		"status":     status,
+		"request_id": job.requestID,
+	}, job.clientRef)
+	if status == ExportDone {
+		message["url"] = o.exportURL(job.data)
+	}
+	if attempt > 0 {
+		message["attempt"] = attempt
+	}
+	if err != nil {
+		message["error"] = err.Error()
+	}
+	o.emit(ctx, message)
+}
+
+// exportURL is where a request's HTML export is served once written
+func (o *Orchestrator) exportURL(data htmlexport.ExportData) string {
+	path := htmlexport.OutputPath(data.QuestionTS, htmlexport.Slug(data.Question), "html")
+	if o.store != nil {
+		return o.store.URL(storage.Key(path))
+	}
+	return "/" + filepath.ToSlash(path)
+}
+
+// WaitExports blocks until every queued export was written or given up on, or ctx is done
+func (o *Orchestrator) WaitExports(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		o.exporting.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// While a round waits on its models, clients are told every heartbeatInterval which ones are still
+// answering and for how long, so a slow provider shows up as such instead of the run looking hung.
+// A call taking stallFactor times its variant's historical p95 latency is reported once as stalled.
+const (
+	heartbeatInterval = 5 * time.Second
+	stallFactor       = 2
+	latencySamples    = 200 // Most recent successful calls per variant the p95 is taken over
+	minLatencySamples = 10  // Calls a variant needs before it can be reported as stalled
+)
+
+// pendingCall is a model a round is still waiting on, as reported in heartbeat messages
+type pendingCall struct {
+	Model     string `json:"model"`
+	Variant   string `json:"variant"`
+	ElapsedMs int64  `json:"elapsed_ms"`       // Since its call went out, or since the round started while queued
+	Queued    bool   `json:"queued,omitempty"` // Still waiting for the provider's rate limit
+	P95Ms     int64  `json:"p95_ms,omitempty"` // Historical p95 latency of the variant, 0 if too few calls are known
+	Stalled   bool   `json:"stalled,omitempty"`
+}
+
+// latencies returns each variant's historical p95 call latency, by variant
+func (o *Orchestrator) latencies(ctx context.Context, logger *slog.Logger) map[string]time.Duration {
+	p95, err := o.database.GetLatencyP95(ctx, latencySamples, minLatencySamples)
+	if err != nil {
+		logger.Warn("failed to load call latencies, stalled calls won't be reported", slog.Any("error", err))
+	}
+	return p95
+}
+
+// startHeartbeat reports a round's pending calls until the returned function is called
+func (o *Orchestrator) startHeartbeat(ctx context.Context, logger *slog.Logger, requestID string, round int, p95 map[string]time.Duration) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	roundStart := time.Now()
+	stalled := make(map[string]bool)
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				o.beat(ctx, logger, requestID, round, p95, roundStart, now, stalled)
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// beat broadcasts the calls a round is still waiting on and reports those newly stalled
+// Heartbeats only matter while the run is live, so they aren't kept in the event log.
+func (o *Orchestrator) beat(ctx context.Context, logger *slog.Logger, requestID string, round int, p95 map[string]time.Duration, roundStart, now time.Time, stalled map[string]bool) {
+	st, ok := o.Run(requestID)
+	if !ok {
+		return
+	}
+
+	pending := make([]pendingCall, 0, len(st.Models))
+	for _, ms := range st.Models {
+		if ms.Status != ModelWaiting && ms.Status != ModelStalled {
+			continue
+		}
+		call := pendingCall{Model: ms.ModelID, Variant: ms.Variant, Queued: ms.CallStarted == nil}
+		if call.Queued {
+			call.ElapsedMs = now.Sub(roundStart).Milliseconds()
+			pending = append(pending, call)
+			continue
+		}
+
+		elapsed := now.Sub(*ms.CallStarted)
+		call.ElapsedMs = elapsed.Milliseconds()
+		if latency, ok := p95[ms.Variant]; ok {
+			call.P95Ms = latency.Milliseconds()
+			call.Stalled = elapsed > stallFactor*latency
+		}
+		pending = append(pending, call)
+
+		if call.Stalled && !stalled[ms.ModelID] {
+			stalled[ms.ModelID] = true
+			logger.Warn("model call stalled",
+				slog.String("model", ms.ModelID),
+				slog.String("variant", ms.Variant),
+				slog.Int("round", round),
+				slog.Duration("elapsed", elapsed),
+				slog.Duration("p95", p95[ms.Variant]))
+			o.emit(ctx, map[string]any{
+				"type":       "stall",
+				"model":      ms.ModelID,
+				"variant":    ms.Variant,
+				"round":      round,
+				"elapsed_ms": call.ElapsedMs,
+				"p95_ms":     call.P95Ms,
+				"request_id": requestID,
+			})
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	o.broadcaster.Broadcast(withClientRef(map[string]any{
+		"type":       "heartbeat",
+		"request_id": requestID,
+		"round":      round,
+		"pending":    pending,
+	}, o.clientRef(requestID)))
+}
@@ -0,0 +1,246 @@
+package orchestrator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// Phases of a running request
+const (
+	PhaseRounds  = "rounds"
+	PhaseRanking = "ranking"
+	PhaseVerify  = "verification" // Participants fact-check the winning answer
+	PhaseDone    = "done"
+)
+
+// Statuses of a model in the current round
+const (
+	ModelWaiting  = "waiting"
+	ModelStalled  = "stalled" // Still waiting, well past how long the variant usually takes
+	ModelAnswered = "answered"
+	ModelFailed   = "failed"
+)
+
+// RunState is a snapshot of a running request, for clients that poll instead of following broadcasts
+type RunState struct {
+	RequestID string       `json:"request_id"`
+	Question  string       `json:"question"`
+	ClientRef string       `json:"client_ref,omitempty"` // Opaque reference the submitter attached
+	Phase     string       `json:"phase"`
+	Round     int          `json:"round"`      // Round in progress, or the last one once ranking started
+	NumRounds int          `json:"num_rounds"` // Lowered when the answers converge early
+	Winner    string       `json:"winner,omitempty"`
+	Models    []ModelState `json:"models,omitempty"`
+	StartedAt time.Time    `json:"started_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// ModelState is a model's progress in the current round and its latest answer
+type ModelState struct {
+	ModelID   string `json:"model_id"`
+	Variant   string `json:"variant"`
+	Status    string `json:"status"`
+	Round     int    `json:"round"` // Round of the latest answer, 0 if it hasn't answered yet
+	Answer    string `json:"answer,omitempty"`
+	Rationale string `json:"rationale,omitempty"`
+	Error     string `json:"error,omitempty"` // Why the current round failed
+
+	// When the current round's call left the rate limiter, nil while queued behind it or once answered
+	CallStarted *time.Time `json:"call_started,omitempty"`
+
+	prompt string // Last prompt sent, kept for diagnostics only
+}
+
+// track starts keeping the live state of a run; replies are the answers it resumes from
+func (o *Orchestrator) track(requestID, question, clientRef string, numRounds, completedRounds int, activeModels []*types.ModelInfo, replies map[string]types.Reply) {
+	now := time.Now()
+	st := &RunState{
+		RequestID: requestID,
+		Question:  question,
+		ClientRef: clientRef,
+		Phase:     PhaseRounds,
+		Round:     completedRounds,
+		NumRounds: numRounds,
+		Models:    make([]ModelState, 0, len(activeModels)),
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	for _, mi := range activeModels {
+		ms := ModelState{ModelID: mi.ID, Variant: mi.Name, Status: ModelWaiting}
+		if reply, ok := replies[mi.ID]; ok {
+			ms.Round = completedRounds
+			ms.Answer = reply.Answer
+			ms.Rationale = reply.Rationale
+		}
+		st.Models = append(st.Models, ms)
+	}
+
+	o.liveMu.Lock()
+	defer o.liveMu.Unlock()
+
+	o.live[requestID] = st
+}
+
+// clientRef returns the client reference of a running request, empty if it has none or isn't running
+func (o *Orchestrator) clientRef(requestID string) string {
+	o.liveMu.Lock()
+	defer o.liveMu.Unlock()
+
+	if st, ok := o.live[requestID]; ok {
+		return st.ClientRef
+	}
+	return ""
+}
+
+// recordPrompt keeps the prompt a model was last sent, for the diagnostics of a failed run
+func (o *Orchestrator) recordPrompt(requestID, modelID, prompt string) {
+	o.liveMu.Lock()
+	defer o.liveMu.Unlock()
+
+	if st, ok := o.live[requestID]; ok {
+		for i := range st.Models {
+			if st.Models[i].ModelID == modelID {
+				st.Models[i].prompt = prompt
+			}
+		}
+	}
+}
+
+// recordCallStart notes when a model's call in the current round went out
+func (o *Orchestrator) recordCallStart(requestID, modelID string, at time.Time) {
+	o.liveMu.Lock()
+	defer o.liveMu.Unlock()
+
+	if st, ok := o.live[requestID]; ok {
+		for i := range st.Models {
+			if st.Models[i].ModelID == modelID {
+				st.Models[i].CallStarted = &at
+			}
+		}
+	}
+}
+
+// lastPrompts returns the prompt each model of a run was last sent, by model ID
+func (o *Orchestrator) lastPrompts(requestID string) map[string]string {
+	o.liveMu.Lock()
+	defer o.liveMu.Unlock()
+
+	prompts := make(map[string]string)
+	if st, ok := o.live[requestID]; ok {
+		for _, ms := range st.Models {
+			if ms.prompt != "" {
+				prompts[ms.ModelID] = ms.prompt
+			}
+		}
+	}
+	return prompts
+}
+
+// untrack forgets a run's live state once it finished
+func (o *Orchestrator) untrack(requestID string) {
+	o.liveMu.Lock()
+	defer o.liveMu.Unlock()
+
+	delete(o.live, requestID)
+}
+
+// observe updates a run's live state from a message emitted for it
+func (o *Orchestrator) observe(message map[string]any) {
+	requestID, _ := message["request_id"].(string)
+	msgType, _ := message["type"].(string)
+
+	o.liveMu.Lock()
+	defer o.liveMu.Unlock()
+
+	st, ok := o.live[requestID]
+	if !ok {
+		return
+	}
+
+	model := func() *ModelState {
+		id, _ := message["model"].(string)
+		for i := range st.Models {
+			if st.Models[i].ModelID == id {
+				return &st.Models[i]
+			}
+		}
+		return nil
+	}
+
+	switch msgType {
+	case "round_start":
+		st.Round, _ = message["round"].(int)
+		for i := range st.Models {
+			st.Models[i].Status = ModelWaiting
+			st.Models[i].Error = ""
+			st.Models[i].CallStarted = nil
+		}
+	case "response":
+		if ms := model(); ms != nil {
+			ms.Status = ModelAnswered
+			ms.CallStarted = nil
+			ms.Round = st.Round
+			ms.Answer, _ = message["response"].(string)
+			ms.Rationale, _ = message["rationale"].(string)
+		}
+	case "error":
+		if ms := model(); ms != nil {
+			ms.Status = ModelFailed
+			ms.Error, _ = message["error"].(string)
+			ms.CallStarted = nil
+		}
+	case "stall":
+		if ms := model(); ms != nil && ms.Status == ModelWaiting {
+			ms.Status = ModelStalled
+		}
+	case "fallback":
+		if ms := model(); ms != nil {
+			ms.Variant, _ = message["to"].(string)
+		}
+	case "converged":
+		st.NumRounds, _ = message["round"].(int)
+	case "ranking_start":
+		st.Phase = PhaseRanking
+	case "verification_start":
+		st.Phase = PhaseVerify
+	case "winner":
+		st.Phase = PhaseDone
+		st.Winner, _ = message["model"].(string)
+	default:
+		return
+	}
+	st.UpdatedAt = time.Now()
+}
+
+// Runs returns the live state of every running request, oldest first, without the models' answers
+func (o *Orchestrator) Runs() []RunState {
+	o.liveMu.Lock()
+	defer o.liveMu.Unlock()
+
+	runs := make([]RunState, 0, len(o.live))
+	for _, st := range o.live {
+		run := *st
+		run.Models = nil
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.Before(runs[j].StartedAt)
+	})
+	return runs
+}
+
+// Run returns the live state of a running request, including each model's latest answer
+func (o *Orchestrator) Run(requestID string) (RunState, bool) {
+	o.liveMu.Lock()
+	defer o.liveMu.Unlock()
+
+	st, ok := o.live[requestID]
+	if !ok {
+		return RunState{}, false
+	}
+	run := *st
+	run.Models = append([]ModelState(nil), st.Models...)
+	return run, true
+}
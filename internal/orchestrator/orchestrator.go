@@ -3,21 +3,39 @@ package orchestrator
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
-	"sync/atomic"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/meedamian/fat/internal/answerschema"
 	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/diagnostics"
+	"github.com/meedamian/fat/internal/diff"
+	"github.com/meedamian/fat/internal/difficulty"
+	"github.com/meedamian/fat/internal/elo"
+	"github.com/meedamian/fat/internal/embeddings"
 	"github.com/meedamian/fat/internal/htmlexport"
+	"github.com/meedamian/fat/internal/jsonexport"
+	"github.com/meedamian/fat/internal/judgeweight"
+	"github.com/meedamian/fat/internal/locale"
+	"github.com/meedamian/fat/internal/mdexport"
 	"github.com/meedamian/fat/internal/metrics"
 	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/postprocess"
 	"github.com/meedamian/fat/internal/ranking"
+	"github.com/meedamian/fat/internal/ratelimit"
 	"github.com/meedamian/fat/internal/retry"
+	"github.com/meedamian/fat/internal/scorer"
+	"github.com/meedamian/fat/internal/search"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/storage"
 	"github.com/meedamian/fat/internal/types"
 	"github.com/meedamian/fat/internal/utils"
+	"golang.org/x/sync/errgroup"
 )
 
 // Broadcaster is an interface for broadcasting messages to connected clients
@@ -27,46 +45,352 @@ type Broadcaster interface {
 
 // Orchestrator coordinates the multi-round question processing
 type Orchestrator struct {
-	logger       *slog.Logger
-	database     *db.DB
-	broadcaster  Broadcaster
-	exporter     *htmlexport.Exporter
-	isProcessing atomic.Bool
+	logger         *slog.Logger
+	database       *db.DB
+	broadcaster    Broadcaster
+	exporter       *htmlexport.Exporter
+	mdExporter     *mdexport.Exporter
+	postprocess    *postprocess.Pipeline  // Applied to every parsed reply; nil leaves replies as parsed
+	limiter        *ratelimit.Registry    // Per-provider rate limits consulted before every model call; nil means unlimited
+	searcher       *search.Client         // Runs the web searches agents ask for; nil disables search
+	embedder       *embeddings.Client     // Compares the final answers by meaning; nil skips the comparison
+	scorers        *scorer.Set            // Operator-defined metrics computed for every final answer; nil scores nothing
+	diagnostics    *diagnostics.Collector // Captures a bundle for bug reports when a run fails; nil captures nothing
+	store          storage.Store          // Remote storage exports are uploaded to; nil keeps them local only
+	keepLocal      bool                   // Keep the local copies of uploaded exports
+	dataDir        string                 // Holds the h/ exports
+	fallback       FallbackFunc           // Replacement for variants the provider doesn't know; nil disables fallbacks
+	summarizer     *types.ModelInfo       // Cheap model condensing older rounds of prompts that overflow a context window; nil only trims them
+	convergence    float64                // Answer similarity (0-1) at which remaining rounds are skipped; 0 always runs every round
+	countSelfVotes bool                   // Count judges' rankings of their own answers towards the result
+	justify        bool                   // Ask judges for a one-line reason with every placement
+	weightJudges   bool                   // Weigh judges' ballots by their agreement with the other judges
+	attribution    string                 // Format of the line appended to winning answers, empty for none
+	locale         locale.Format          // Time zone and number format of exports
+
+	// Request queue - at most maxConcurrent requests run at once, up to maxQueued wait
+	queueMu       sync.Mutex
+	running       map[string]*queueEntry
+	queued        []*queueEntry
+	maxConcurrent int
+	maxQueued     int
+
+	// Live state of running requests, kept up to date from the messages they emit
+	liveMu sync.Mutex
+	live   map[string]*RunState
+
+	// Shutdown - once draining, no new requests start and running ones are tracked until they finish
+	draining   bool
+	stopping   chan struct{}   // Closed when draining starts, releasing queued requests
+	inflight   sync.WaitGroup  // Requests holding a processing slot
+	runsCtx    context.Context // Cancelled to abort running requests and their exports
+	cancelRuns context.CancelFunc
+
+	// Exports of finished requests, written by workers of their own
+	exportQueue chan exportJob
+	exporting   sync.WaitGroup // Exports queued or being written
+}
+
+// FallbackFunc returns the model to use instead of mi when its provider no longer knows mi's variant,
+// or nil if the family has no other variant to fall back to; it takes over mi's agent ID for the rest of the run
+type FallbackFunc func(mi *types.ModelInfo) *types.ModelInfo
+
+// Options holds optional per-request settings
+// It is persisted with the request state so resumed runs keep their settings
+type Options struct {
+	Tag    string   `json:"tag,omitempty"`    // Question set tag for benchmark tracking
+	Judges []string `json:"judges,omitempty"` // Model variants on the ranking jury; empty means participants rank each other
+
+	Pricing *types.Pricing `json:"pricing,omitempty"` // Rates charged for this run instead of list prices
+
+	ParentRequestID string `json:"parent_request_id,omitempty"` // Request this one follows up on, whose session it continues
+
+	Generation map[string]types.Generation `json:"generation,omitempty"` // Sampling overrides by family ID or variant name
+
+	AnswerSchema map[string]any `json:"answer_schema,omitempty"` // JSON schema every answer must follow; nil allows free text
+
+	SubQuestions []string `json:"sub_questions,omitempty"` // Questions answered in sections of one answer and ranked one by one; the question holds them all
+
+	CacheKey string `json:"cache_key,omitempty"` // Stored with the request so identical questions can replay it, empty when caching is off
+
+	Strategy  string `json:"strategy,omitempty"`   // Name of the winner-selection strategy; empty uses ranking.DefaultStrategy
+	MetaJudge string `json:"meta_judge,omitempty"` // Model variant giving the final verdict under the judge-of-judges strategy
+
+	Synthesizer string `json:"synthesizer,omitempty"` // Model variant merging the top answers into one after ranking, empty to skip it
+
+	Audit bool `json:"audit,omitempty"` // Judges must give a one-sentence reason for every placement, shown in the audit panel
+
+	Verify bool `json:"verify,omitempty"` // Every participant fact-checks the winning answer after ranking
+
+	Mode            string `json:"mode,omitempty"`             // shared.ModeDebate to have participants argue assigned sides, empty for a collaboration
+	RotatePositions bool   `json:"rotate_positions,omitempty"` // In a debate, participants switch sides every round
+
+	ClientRef string `json:"client_ref,omitempty"` // Opaque reference from the submitter, echoed in every message of the run
+}
+
+// Deps holds the services an Orchestrator works with; the optional ones may be nil
+type Deps struct {
+	Logger      *slog.Logger
+	Database    *db.DB
+	Broadcaster Broadcaster
+	Exporter    *htmlexport.Exporter
+	MDExporter  *mdexport.Exporter     // Writes the Markdown transcript next to the HTML export; nil skips it
+	Pipeline    *postprocess.Pipeline  // Applied to every parsed reply; nil leaves replies as parsed
+	Limiter     *ratelimit.Registry    // Per-provider rate limits consulted before every model call; nil means unlimited
+	Searcher    *search.Client         // Runs the web searches agents ask for; nil disables search
+	Embedder    *embeddings.Client     // Compares the final answers by meaning; nil skips the comparison
+	Scorers     *scorer.Set            // Operator-defined metrics computed for every final answer; nil scores nothing
+	Diagnostics *diagnostics.Collector // Captures a bundle for bug reports when a run fails; nil captures nothing
+	Store       storage.Store          // Remote storage exports are uploaded to; nil keeps them local only
+}
+
+// Config holds the settings every request of an Orchestrator runs with
+type Config struct {
+	KeepLocal      bool             // Keep the local copies of uploaded exports
+	DataDir        string           // Holds the h/ exports
+	Fallback       FallbackFunc     // Replacement for variants the provider doesn't know; nil disables fallbacks
+	Summarizer     *types.ModelInfo // Cheap model condensing older rounds of prompts that overflow a context window; nil only trims them
+	Convergence    float64          // Answer similarity (0-1) at which remaining rounds are skipped; 0 always runs every round
+	CountSelfVotes bool             // Count judges' rankings of their own answers towards the result
+	Justify        bool             // Ask judges for a one-line reason with every placement
+	WeightJudges   bool             // Weigh judges' ballots by their agreement with the other judges
+	Attribution    string           // Format of the line appended to winning answers, empty for none
+	Locale         locale.Format    // Time zone and number format of exports
+	MaxConcurrent  int              // Requests running at once, below 1 treated as 1
+	MaxQueued      int              // Requests waiting for a slot, 0 for an unbounded queue
 }
 
 // New creates a new Orchestrator
-func New(logger *slog.Logger, database *db.DB, broadcaster Broadcaster, exporter *htmlexport.Exporter) *Orchestrator {
-	return &Orchestrator{
-		logger:      logger,
-		database:    database,
-		broadcaster: broadcaster,
-		exporter:    exporter,
+func New(deps Deps, cfg Config) *Orchestrator {
+	maxConcurrent := cfg.MaxConcurrent
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	runsCtx, cancelRuns := context.WithCancel(context.Background())
+
+	o := &Orchestrator{
+		logger:         deps.Logger,
+		database:       deps.Database,
+		broadcaster:    deps.Broadcaster,
+		exporter:       deps.Exporter,
+		mdExporter:     deps.MDExporter,
+		postprocess:    deps.Pipeline,
+		limiter:        deps.Limiter,
+		searcher:       deps.Searcher,
+		embedder:       deps.Embedder,
+		scorers:        deps.Scorers,
+		diagnostics:    deps.Diagnostics,
+		store:          deps.Store,
+		keepLocal:      cfg.KeepLocal,
+		dataDir:        cfg.DataDir,
+		fallback:       cfg.Fallback,
+		summarizer:     cfg.Summarizer,
+		convergence:    cfg.Convergence,
+		countSelfVotes: cfg.CountSelfVotes,
+		justify:        cfg.Justify,
+		weightJudges:   cfg.WeightJudges,
+		attribution:    cfg.Attribution,
+		locale:         cfg.Locale,
+		running:        make(map[string]*queueEntry),
+		maxConcurrent:  maxConcurrent,
+		maxQueued:      cfg.MaxQueued,
+		live:           make(map[string]*RunState),
+		stopping:       make(chan struct{}),
+		runsCtx:        runsCtx,
+		cancelRuns:     cancelRuns,
+		exportQueue:    make(chan exportJob, exportQueueSize),
 	}
+	o.startExportWorkers()
+	return o
 }
 
-// IsProcessing returns true if a question is currently being processed
+// IsProcessing returns true if any question is currently being processed
 func (o *Orchestrator) IsProcessing() bool {
-	return o.isProcessing.Load()
+	o.queueMu.Lock()
+	defer o.queueMu.Unlock()
+
+	return len(o.running) > 0
+}
+
+// emit records a message in the request's event log and broadcasts it to clients
+// Messages of a running request carry its client reference, if the submitter gave one.
+func (o *Orchestrator) emit(ctx context.Context, message map[string]any) {
+	if requestID, ok := message["request_id"].(string); ok {
+		withClientRef(message, o.clientRef(requestID))
+		msgType, _ := message["type"].(string)
+		payload, err := json.Marshal(message)
+		if err == nil {
+			// Events must be recorded even after the request was cancelled
+			err = o.database.SaveEvent(context.WithoutCancel(ctx), db.Event{
+				RequestID: requestID,
+				Type:      msgType,
+				Payload:   payload,
+			})
+		}
+		if err != nil {
+			o.logger.Warn("failed to save event",
+				slog.String("request_id", requestID),
+				slog.String("type", msgType),
+				slog.Any("error", err))
+		}
+	}
+
+	o.observe(message)
+	o.broadcaster.Broadcast(message)
 }
 
-// ProcessQuestion orchestrates the entire question processing workflow
+// withClientRef adds the submitter's client reference to message, unless there is none or it already has one
+func withClientRef(message map[string]any, clientRef string) map[string]any {
+	if _, ok := message["client_ref"]; !ok && clientRef != "" {
+		message["client_ref"] = clientRef
+	}
+	return message
+}
+
+// ProcessQuestion orchestrates the entire question processing workflow under requestID
+// The ID is chosen by the caller, so it can hand it to the asker before the run is even queued.
+// metaJudge reviews the judges' rankings under the judge-of-judges strategy, and is nil otherwise
+// synthesizer merges the top answers into one after ranking, and is nil when the run has none
 func (o *Orchestrator) ProcessQuestion(
 	ctx context.Context,
+	requestID string,
 	question string,
 	numRounds int,
 	activeModels []*types.ModelInfo,
+	judges []*types.ModelInfo,
+	metaJudge *types.ModelInfo,
+	synthesizer *types.ModelInfo,
 	questionTS int64,
+	opts Options,
 ) {
-	if !o.isProcessing.CompareAndSwap(false, true) {
-		o.logger.Warn("attempted to start processing while already busy")
+	// Wait for a free processing slot
+	if err := o.acquire(ctx, requestID, question, opts.ClientRef); err != nil {
+		o.logger.Warn("request not started",
+			slog.String("request_id", requestID),
+			slog.Any("error", err))
+		o.broadcaster.Broadcast(withClientRef(map[string]any{
+			"type":       "error",
+			"error":      err.Error(),
+			"request_id": requestID,
+		}, opts.ClientRef))
 		return
 	}
-	defer o.isProcessing.Store(false)
+	defer o.release(requestID)
+
+	ctx, stop := o.runContext(ctx)
+	defer stop()
+
+	o.run(ctx, requestID, question, numRounds, activeModels, judges, metaJudge, synthesizer, questionTS, opts, 0,
+		make(map[string]types.Reply),
+		make(map[string]map[string][]types.DiscussionMessage),
+		make(map[string]map[int]string))
+}
+
+// Resume continues an interrupted request from its last fully completed round
+// activeModels must contain the same model IDs the request was started with, judges and metaJudge the same jury
+func (o *Orchestrator) Resume(ctx context.Context, requestID string, activeModels, judges []*types.ModelInfo, metaJudge, synthesizer *types.ModelInfo) error {
+	if o.isActive(requestID) {
+		return fmt.Errorf("request %s is already queued or running", requestID)
+	}
+
+	st, err := o.database.GetRequestState(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		return fmt.Errorf("no saved state for request %s", requestID)
+	}
+	if st.Status != db.StateRunning {
+		return fmt.Errorf("request %s is %s", requestID, st.Status)
+	}
+
+	replies := make(map[string]types.Reply)
+	discussion := make(map[string]map[string][]types.DiscussionMessage)
+	privateNotes := make(map[string]map[int]string)
+	if err := json.Unmarshal([]byte(st.Replies), &replies); err != nil {
+		return fmt.Errorf("failed to decode saved replies: %w", err)
+	}
+	if err := json.Unmarshal([]byte(st.Discussion), &discussion); err != nil {
+		return fmt.Errorf("failed to decode saved discussion: %w", err)
+	}
+	if err := json.Unmarshal([]byte(st.PrivateNotes), &privateNotes); err != nil {
+		return fmt.Errorf("failed to decode saved private notes: %w", err)
+	}
+	var opts Options
+	if st.Options != "" {
+		if err := json.Unmarshal([]byte(st.Options), &opts); err != nil {
+			return fmt.Errorf("failed to decode saved options: %w", err)
+		}
+	}
+
+	if err := o.acquire(ctx, requestID, st.Question, opts.ClientRef); err != nil {
+		return err
+	}
+	defer o.release(requestID)
+
+	ctx, stop := o.runContext(ctx)
+	defer stop()
+
+	o.logger.Info("resuming request",
+		slog.String("request_id", requestID),
+		slog.Int("completed_rounds", st.Round),
+		slog.Int("rounds", st.NumRounds))
+
+	o.run(ctx, requestID, st.Question, st.NumRounds, activeModels, judges, metaJudge, synthesizer, st.QuestionTS, opts, st.Round, replies, discussion, privateNotes)
+	return nil
+}
 
-	// Generate request ID
-	requestID := uuid.New().String()
+// run executes rounds startRound+1..numRounds followed by ranking and export
+// Conversation state is snapshotted after every round so the request can be resumed
+func (o *Orchestrator) run(
+	ctx context.Context,
+	requestID string,
+	question string,
+	numRounds int,
+	activeModels []*types.ModelInfo,
+	judges []*types.ModelInfo,
+	metaJudge *types.ModelInfo,
+	synthesizer *types.ModelInfo,
+	questionTS int64,
+	opts Options,
+	startRound int,
+	replies map[string]types.Reply,
+	discussion map[string]map[string][]types.DiscussionMessage,
+	privateNotes map[string]map[int]string, // modelID -> round -> notes
+) {
 	logger := o.logger.With("request_id", requestID)
 
+	// Models are priced and tagged for this run and may be swapped for fallback variants, which mustn't touch the caller's models
+	activeModels = forRequest(activeModels, requestID, opts.Pricing)
+	judges = forRequest(judges, requestID, opts.Pricing)
+	if metaJudge != nil {
+		metaJudge = forRequest([]*types.ModelInfo{metaJudge}, requestID, opts.Pricing)[0]
+	}
+	if synthesizer != nil {
+		synthesizer = forRequest([]*types.ModelInfo{synthesizer}, requestID, opts.Pricing)[0]
+	}
+
+	o.track(requestID, question, opts.ClientRef, numRounds, startRound, activeModels, replies)
+	defer o.untrack(requestID)
+
+	// A follow-up starts from the questions and answers earlier in its session
+	var session []types.Turn
+	if opts.ParentRequestID != "" {
+		var err error
+		if session, err = o.loadSession(ctx, opts.ParentRequestID); err != nil {
+			logger.Warn("failed to load session, answering without it",
+				slog.String("parent_request_id", opts.ParentRequestID),
+				slog.Any("error", err))
+		}
+	}
+
+	// The schema was checked when the question was submitted, so this only fails for tampered saved state
+	schema, err := answerschema.Parse(opts.AnswerSchema)
+	if err != nil {
+		logger.Warn("ignoring answer schema", slog.Any("error", err))
+	}
+
 	// Initialize metrics
 	reqMetrics := metrics.NewRequestMetrics(requestID, question, numRounds, len(activeModels))
 	for _, mi := range activeModels {
@@ -89,183 +413,529 @@ func (o *Orchestrator) ProcessQuestion(
 	}()
 
 	// Clear previous responses and send round start
-	o.broadcaster.Broadcast(map[string]any{
+	o.emit(ctx, map[string]any{
 		"type":       "clear",
 		"request_id": requestID,
 	})
 
-	// Initialize conversation state
-	replies := make(map[string]types.Reply)
-	discussion := make(map[string]map[string][]types.DiscussionMessage)
-	privateNotes := make(map[string]map[int]string) // modelID -> round -> notes
+	// Snapshot initial state so even a crash during round 1 is resumable
+	o.saveState(ctx, logger, requestID, question, numRounds, activeModels, questionTS, opts, startRound, replies, discussion, privateNotes)
+
+	// How long each variant usually takes, to tell stalled calls from slow ones
+	latencies := o.latencies(ctx, logger)
 
 	// Execute rounds
-	for round := range numRounds {
+	for round := startRound; round < numRounds; round++ {
 		logger.Info("starting round", slog.Int("round", round+1))
 
-		o.broadcaster.Broadcast(map[string]any{
+		roundStart := map[string]any{
 			"type":       "round_start",
 			"round":      round + 1,
 			"total":      numRounds,
 			"request_id": requestID,
-		})
+		}
+		positions := debatePositions(opts, activeModels, round+1)
+		if positions != nil {
+			roundStart["positions"] = positions
+		}
+		o.emit(ctx, roundStart)
+
+		stopHeartbeat := o.startHeartbeat(ctx, logger, requestID, round+1, latencies)
 
-		results := o.parallelCall(ctx, requestID, question, replies, discussion, privateNotes, activeModels, round, numRounds, questionTS, reqMetrics)
+		// Each reply is reported and saved as soon as it's in, but only joins the conversation once
+		// the whole round is over, since the calls still running read it
+		var outcome roundOutcome
+		results := o.parallelCall(ctx, requestID, question, session, replies, discussion, privateNotes, positions, activeModels, round, numRounds, questionTS, schema, reqMetrics, func(result callResult) {
+			// Find model name
+			modelName := result.modelID
+			for _, m := range activeModels {
+				if m.ID == result.modelID {
+					modelName = m.Name
+					break
+				}
+			}
+			if result.fallback != nil {
+				modelName = result.fallback.Name
+			}
 
-		// Wait for all models to complete this round
-		for range activeModels {
-			result := <-results
 			if result.err != nil {
+				outcome.failed = true
 				logger.Error("model error",
 					slog.String("model", result.modelID),
 					slog.Int("round", round+1),
 					slog.Any("error", result.err))
 
-				o.broadcaster.Broadcast(map[string]any{
+				// Failed calls may still have cost time, so they're kept like answered ones
+				failedRound := db.ModelRound{
+					RequestID:  requestID,
+					ModelID:    result.modelID,
+					ModelName:  modelName,
+					Round:      round + 1,
+					DurationMs: result.duration.Milliseconds(),
+					Error:      result.err.Error(),
+				}
+				if err := o.database.SaveModelRound(ctx, failedRound); err != nil {
+					logger.Warn("failed to save failed round to database", slog.Any("error", err))
+				}
+
+				o.emit(ctx, map[string]any{
 					"type":       "error",
 					"model":      result.modelID,
 					"round":      round + 1,
 					"error":      result.err.Error(),
 					"request_id": requestID,
 				})
-			} else {
-				// Update conversation state
-				replies[result.modelID] = result.reply
-
-				// Store private notes for this round
-				if result.reply.PrivateNotes != "" {
-					if privateNotes[result.modelID] == nil {
-						privateNotes[result.modelID] = make(map[int]string)
-					}
-					privateNotes[result.modelID][round+1] = result.reply.PrivateNotes
-				}
+				return
+			}
 
-				// Save round content to database (metrics will be added later)
-				discussionJSON, _ := json.Marshal(result.reply.Discussion)
+			// What the model changed since its previous answer
+			var answerDiff []diff.Segment
+			if previous, ok := replies[result.modelID]; ok && previous.Answer != "" {
+				answerDiff = diff.Words(previous.Answer, result.reply.Answer)
+			}
+			outcome.add(replies[result.modelID].Answer, result.reply)
 
-				// Find model name
-				modelName := result.modelID
-				for _, m := range activeModels {
-					if m.ID == result.modelID {
-						modelName = m.Name
-						break
-					}
-				}
+			// Save the round as soon as it's in, so a crash in a later round doesn't lose what was paid for
+			discussionJSON, _ := json.Marshal(result.reply.Discussion)
+			var transformsJSON []byte
+			if len(result.reply.Transforms) > 0 {
+				transformsJSON, _ = json.Marshal(result.reply.Transforms)
+			}
 
-				modelRound := db.ModelRound{
-					RequestID:    requestID,
-					ModelID:      result.modelID,
-					ModelName:    modelName,
-					Round:        round + 1,
-					Answer:       result.reply.Answer,
-					Rationale:    result.reply.Rationale,
-					Discussion:   string(discussionJSON),
-					PrivateNotes: result.reply.PrivateNotes,
-					// Performance metrics will be filled in later by saveMetrics
-					DurationMs: 0,
-					TokensIn:   0,
-					TokensOut:  0,
-					Cost:       0,
-				}
-				if err := o.database.SaveModelRound(ctx, modelRound); err != nil {
-					logger.Warn("failed to save round content to database", slog.Any("error", err))
+			modelRound := db.ModelRound{
+				RequestID:    requestID,
+				ModelID:      result.modelID,
+				ModelName:    modelName,
+				Round:        round + 1,
+				Answer:       result.reply.Answer,
+				Rationale:    result.reply.Rationale,
+				Discussion:   string(discussionJSON),
+				PrivateNotes: result.reply.PrivateNotes,
+				Transforms:   string(transformsJSON),
+				DurationMs:   result.duration.Milliseconds(),
+				TokensIn:     result.tokensIn,
+				TokensOut:    result.tokensOut,
+				Cost:         result.cost,
+			}
+			if err := o.database.SaveModelRound(ctx, modelRound); err != nil {
+				logger.Warn("failed to save round content to database", slog.Any("error", err))
+			}
+
+			o.emit(ctx, map[string]any{
+				"type":          "response",
+				"model":         result.modelID,
+				"round":         round + 1,
+				"response":      result.reply.Answer,
+				"rationale":     result.reply.Rationale,
+				"discussion":    result.reply.Discussion,
+				"private_notes": result.reply.PrivateNotes,
+				"thinking":      result.reply.Thinking,
+				"transforms":    result.reply.Transforms,
+				"searches":      result.reply.Searches,
+				"schema":        result.reply.Schema,
+				"diff":          answerDiff,
+				"tokens_in":     result.tokensIn,
+				"tokens_out":    result.tokensOut,
+				"cost":          result.cost,
+				"request_id":    requestID,
+			})
+		})
+
+		stopHeartbeat()
+
+		// Update conversation state, in the order the replies came in
+		var fallbacks []*types.ModelInfo
+		for _, result := range results {
+			if result.fallback != nil {
+				fallbacks = append(fallbacks, result.fallback)
+			}
+			if result.err != nil {
+				continue
+			}
+
+			replies[result.modelID] = result.reply
+
+			// Store private notes for this round
+			if result.reply.PrivateNotes != "" {
+				if privateNotes[result.modelID] == nil {
+					privateNotes[result.modelID] = make(map[int]string)
 				}
+				privateNotes[result.modelID][round+1] = result.reply.PrivateNotes
+			}
 
-				// Store discussion messages
-				for targetAgent, message := range result.reply.Discussion {
-					targetID := normalizeAgentName(targetAgent, activeModels)
-					if targetID == "" {
-						logger.Warn("could not normalize agent name",
-							slog.String("agent", targetAgent),
-							slog.String("from", result.modelID))
-						continue
-					}
+			// Store discussion messages
+			for targetAgent, message := range result.reply.Discussion {
+				targetID := normalizeAgentName(targetAgent, activeModels)
+				if targetID == "" {
+					logger.Warn("could not normalize agent name",
+						slog.String("agent", targetAgent),
+						slog.String("from", result.modelID))
+					continue
+				}
 
-					// Initialize discussion maps if needed
-					if _, exists := discussion[result.modelID]; !exists {
-						discussion[result.modelID] = make(map[string][]types.DiscussionMessage)
-					}
-					if _, exists := discussion[targetID]; !exists {
-						discussion[targetID] = make(map[string][]types.DiscussionMessage)
-					}
+				// Initialize discussion maps if needed
+				if _, exists := discussion[result.modelID]; !exists {
+					discussion[result.modelID] = make(map[string][]types.DiscussionMessage)
+				}
+				if _, exists := discussion[targetID]; !exists {
+					discussion[targetID] = make(map[string][]types.DiscussionMessage)
+				}
 
-					// Add message to both sender's and recipient's conversation threads
-					msg := types.DiscussionMessage{
-						From:    result.modelID,
-						Message: message,
-						Round:   round + 1,
-					}
-					discussion[result.modelID][targetID] = append(discussion[result.modelID][targetID], msg)
-					discussion[targetID][result.modelID] = append(discussion[targetID][result.modelID], msg)
+				// Add message to both sender's and recipient's conversation threads
+				msg := types.DiscussionMessage{
+					From:    result.modelID,
+					Message: message,
+					Round:   round + 1,
 				}
+				discussion[result.modelID][targetID] = append(discussion[result.modelID][targetID], msg)
+				discussion[targetID][result.modelID] = append(discussion[targetID][result.modelID], msg)
+			}
+		}
 
-				o.broadcaster.Broadcast(map[string]any{
-					"type":          "response",
-					"model":         result.modelID,
-					"round":         round + 1,
-					"response":      result.reply.Answer,
-					"rationale":     result.reply.Rationale,
-					"discussion":    result.reply.Discussion,
-					"private_notes": result.reply.PrivateNotes,
-					"tokens_in":     result.tokensIn,
-					"tokens_out":    result.tokensOut,
-					"cost":          result.cost,
-					"request_id":    requestID,
-				})
+		// Fallbacks replace their models for the rest of the run (and in the saved state) once the round is over
+		for _, fb := range fallbacks {
+			for i, m := range activeModels {
+				if m.ID == fb.ID {
+					o.emit(ctx, map[string]any{
+						"type":       "fallback",
+						"model":      fb.ID,
+						"round":      round + 1,
+						"from":       m.Name,
+						"to":         fb.Name,
+						"request_id": requestID,
+					})
+					activeModels[i] = fb
+				}
 			}
 		}
+
+		// Skip the remaining rounds once the answers stop changing; the state is saved as complete
+		// so a resumed run goes straight to ranking
+		if reason, ok := outcome.converged(round+1, len(activeModels), o.convergence); ok && round+1 < numRounds && ctx.Err() == nil {
+			logger.Info("answers converged, skipping remaining rounds",
+				slog.Int("round", round+1),
+				slog.Int("skipped", numRounds-round-1),
+				slog.String("reason", reason))
+			o.emit(ctx, map[string]any{
+				"type":       "converged",
+				"round":      round + 1,
+				"total":      numRounds,
+				"reason":     reason,
+				"similarity": outcome.minSimilarity,
+				"request_id": requestID,
+			})
+			reqMetrics.SkipRounds(round + 1)
+			numRounds = round + 1
+		}
+
+		if ctx.Err() == nil {
+			o.saveState(ctx, logger, requestID, question, numRounds, activeModels, questionTS, opts, round+1, replies, discussion, privateNotes)
+		}
 	}
 
 	// Ranking phase
-	logger.Info("starting ranking phase")
-	o.broadcaster.Broadcast(map[string]any{
+	strategy, err := ranking.StrategyFor(opts.Strategy)
+	if err != nil {
+		logger.Warn("falling back to the default winner strategy", slog.Any("error", err))
+		opts.Strategy = ranking.DefaultStrategy
+		strategy, _ = ranking.StrategyFor(opts.Strategy)
+	} else if opts.Strategy == "" {
+		opts.Strategy = ranking.DefaultStrategy
+	}
+	logger.Info("starting ranking phase", slog.String("strategy", opts.Strategy))
+	jury := judges
+	if len(jury) == 0 {
+		jury = activeModels
+	}
+	judgeNames := make([]string, 0, len(jury))
+	for _, mi := range jury {
+		judgeNames = append(judgeNames, mi.Name)
+	}
+	o.emit(ctx, map[string]any{
 		"type":       "ranking_start",
 		"request_id": requestID,
+		"judges":     judgeNames,
+		"strategy":   opts.Strategy,
+		"meta_judge": opts.MetaJudge,
+		"audit":      opts.Audit,
+		"mode":       opts.Mode,
 	})
 
-	goldIDs, silverIDs, bronzeIDs, scoresByID := ranking.RankModels(ctx, requestID, question, replies, activeModels, questionTS, reqMetrics, o.database, logger)
+	var weights map[string]float64
+	if o.weightJudges {
+		var err error
+		if weights, err = judgeweight.Load(ctx, o.database); err != nil {
+			logger.Warn("failed to load judge weights, counting every judge the same", slog.Any("error", err))
+		}
+	}
+
+	var goldIDs, silverIDs, bronzeIDs []string
+	var scoresByID map[string]int
+	var order []shared.Placement
+	var sections []ranking.Section
+	if len(opts.SubQuestions) > 0 {
+		goldIDs, silverIDs, bronzeIDs, scoresByID, order, sections = ranking.RankSections(ctx, requestID, question, opts.SubQuestions, replies, activeModels, judges, o.countSelfVotes, weights, strategy, questionTS, reqMetrics, o.database, logger)
+	} else {
+		// Debaters are judged on the side they argued in the final round
+		positions := debatePositions(opts, activeModels, numRounds)
+		goldIDs, silverIDs, bronzeIDs, scoresByID, order = ranking.RankModels(ctx, requestID, question, replies, positions, activeModels, judges, o.countSelfVotes, o.justify, opts.Audit, weights, strategy, questionTS, reqMetrics, o.database, logger)
+	}
+
+	// The meta judge has the final say; without its verdict the jury's result stands
+	var verdict *ranking.Verdict
+	if opts.Strategy == ranking.JudgeOfJudges {
+		if metaJudge == nil {
+			logger.Warn("no meta judge, the jury's ranking stands")
+		} else if verdict, err = ranking.MetaRank(ctx, requestID, question, replies, activeModels, metaJudge, questionTS, reqMetrics, o.database, logger); err != nil {
+			logger.Warn("meta judge failed, the jury's ranking stands", slog.Any("error", err))
+		} else {
+			goldIDs, silverIDs, bronzeIDs, scoresByID, order = verdict.Gold, verdict.Silver, verdict.Bronze, verdict.Scores, verdict.Order
+		}
+	}
+
+	// An audited run shows every judge's reasons with the result
+	var justifications []db.Justification
+	if opts.Audit {
+		if justifications, err = o.database.GetJustifications(ctx, requestID); err != nil {
+			logger.Warn("failed to load justifications", slog.Any("error", err))
+		}
+	}
 
 	// Use first gold winner for metrics completion and broadcast
 	winnerID := ""
 	if len(goldIDs) > 0 {
 		winnerID = goldIDs[0]
 	}
+
+	// A final verification round: every participant fact-checks the winning answer claim by claim
+	var verifications []db.Verification
+	if opts.Verify && winnerID != "" && ctx.Err() == nil {
+		o.emit(ctx, map[string]any{
+			"type":       "verification_start",
+			"model":      winnerID,
+			"request_id": requestID,
+		})
+		verifications = o.verify(ctx, logger, requestID, question, questionTS, replies, activeModels, winnerID, reqMetrics)
+	}
 	reqMetrics.Complete(winnerID)
 
+	// The top answers merged into one, shown above them; without it the winner's answer stands alone
+	var synth *Synthesis
+	if synthesizer != nil {
+		topIDs := append(append(slices.Clone(goldIDs), silverIDs...), bronzeIDs...)
+		if synth, err = o.synthesize(ctx, logger, synthesizer, question, questionTS, replies, activeModels, topIDs); errors.Is(err, errTooFewAnswers) {
+			logger.Info("nothing to synthesize", slog.Any("error", err))
+		} else if err != nil {
+			logger.Warn("synthesizer failed, the winner's answer stands alone", slog.Any("error", err))
+		}
+	}
+
 	logger.Info("question processing complete", slog.Any("metrics", reqMetrics.Summary()))
 
+	// Estimate how hard the question was from judge disagreement and answer divergence
+	estimate := o.estimateDifficulty(ctx, requestID, replies)
+	logger.Info("estimated question difficulty", slog.Float64("difficulty", estimate.Score))
+
 	// Save to database
-	if err := o.saveToDatabase(ctx, reqMetrics, question, winnerID); err != nil {
+	totalCost := requestCost(reqMetrics, activeModels)
+	if synth != nil {
+		totalCost += synth.Cost
+	}
+	if err := o.saveToDatabase(ctx, reqMetrics, activeModels, question, winnerID, order, opts, estimate.Score, synth); err != nil {
 		logger.Error("failed to save to database", slog.Any("error", err))
 	}
 
+	// Where the money went, from the rounds and rankings just stored
+	costBreakdown, err := o.database.StoreCostBreakdown(ctx, requestID)
+	if err != nil {
+		logger.Warn("failed to store cost breakdown", slog.Any("error", err))
+	}
+
+	// Rate participants against each other from the aggregated ranking
+	if err := elo.Record(ctx, o.database, eloParticipants(activeModels, replies, scoresByID)); err != nil {
+		logger.Warn("failed to update elo ratings", slog.Any("error", err))
+	}
+
+	// Check whether the agents converged on one answer or the winner stands apart
+	similarity := o.compareAnswers(ctx, logger, requestID, replies)
+
+	// Run the operator's own metrics over the final answers, shown next to the judges' rankings
+	customMetrics := o.scoreAnswers(ctx, logger, requestID, question, replies, activeModels)
+
 	// For backwards compatibility, broadcast first gold and first silver
 	runnerUpID := ""
 	if len(silverIDs) > 0 {
 		runnerUpID = silverIDs[0]
 	}
-	o.broadcaster.Broadcast(map[string]any{
-		"type":       "winner",
-		"model":      winnerID,
-		"runner_up":  runnerUpID,
-		"answer":     replies[winnerID],
-		"gold":       goldIDs,
-		"silver":     silverIDs,
-		"bronze":     bronzeIDs,
-		"request_id": requestID,
-		"metrics":    reqMetrics.Summary(),
+	// Disclose how the answer was made, if the deployment asks for it
+	answer := replies[winnerID]
+	attribution := ""
+	if o.attribution != "" && winnerID != "" {
+		winnerName := winnerID
+		for _, mi := range activeModels {
+			if mi.ID == winnerID {
+				winnerName = mi.Name
+			}
+		}
+		attribution = shared.Attribution(o.attribution, len(activeModels), winnerName, totalCost)
+		answer.Answer = shared.WithAttribution(answer.Answer, attribution)
+	}
+
+	// Where the export will be published, known before it's written
+	exportURL := ""
+	if o.store != nil && o.exporter != nil {
+		exportURL = o.store.URL(storage.Key(htmlexport.OutputPath(questionTS, htmlexport.Slug(question), "html")))
+	}
+
+	o.emit(ctx, map[string]any{
+		"type":           "winner",
+		"model":          winnerID,
+		"runner_up":      runnerUpID,
+		"answer":         answer,
+		"attribution":    attribution,
+		"gold":           goldIDs,
+		"silver":         silverIDs,
+		"bronze":         bronzeIDs,
+		"order":          order,
+		"request_id":     requestID,
+		"metrics":        reqMetrics.Summary(),
+		"difficulty":     estimate,
+		"sections":       sections,
+		"similarity":     similarity,
+		"custom_metrics": customMetrics,
+		"export_url":     exportURL,
+		"cost_breakdown": costBreakdown,
+		"strategy":       opts.Strategy,
+		"awaiting_human": awaitingHuman(opts.Strategy, winnerID),
+		"verdict":        verdict,
+		"audit":          justifications,
+		"synthesis":      synth,
+		"verification":   verifications,
 	})
 
-	// Export static HTML
+	if ctx.Err() == nil {
+		if err := o.database.SetRequestStateStatus(ctx, requestID, db.StateComplete); err != nil {
+			logger.Warn("failed to mark request state complete", slog.Any("error", err))
+		}
+	}
+
+	// Exports are written and published in the background
 	if o.exporter != nil {
-		if err := o.exportStaticHTML(ctx, requestID, question, questionTS, replies, discussion, goldIDs, silverIDs, bronzeIDs, scoresByID, activeModels, reqMetrics); err != nil {
-			logger.Error("failed to export static HTML", slog.Any("error", err))
+		if data, err := o.exportData(ctx, requestID, question, questionTS, replies, discussion, goldIDs, silverIDs, bronzeIDs, scoresByID, activeModels, reqMetrics); err != nil {
+			logger.Error("failed to gather export data", slog.Any("error", err))
+		} else {
+			o.queueExport(ctx, logger, exportJob{requestID: requestID, clientRef: opts.ClientRef, data: data})
 		}
 	}
+
+	// Keep what's needed to report a failed run as a bug
+	o.captureDiagnostics(ctx, logger, requestID, question, winnerID, activeModels, reqMetrics)
+}
+
+// publishExports uploads a request's exports to remote storage, with its JSON export alongside
+func (o *Orchestrator) publishExports(ctx context.Context, requestID, question string, questionTS int64) error {
+	slug := htmlexport.Slug(question)
+	paths := []string{
+		htmlexport.OutputPath(questionTS, slug, "svg"),
+		htmlexport.OutputPath(questionTS, slug, "html"),
+	}
+	if o.mdExporter != nil {
+		paths = append(paths, htmlexport.OutputPath(questionTS, slug, "md"))
+	}
+	keys, err := storage.UploadFiles(ctx, o.store, o.dataDir, paths, !o.keepLocal)
+	if err != nil {
+		return err
+	}
+
+	doc, err := jsonexport.Build(ctx, o.database, requestID)
+	if err != nil {
+		return fmt.Errorf("json export: %w", err)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	key := storage.Key(htmlexport.OutputPath(questionTS, slug, "json"))
+	if err := o.store.Put(ctx, key, data, storage.ContentType(key)); err != nil {
+		return fmt.Errorf("upload %s: %w", key, err)
+	}
+
+	o.logger.Info("exports uploaded",
+		slog.String("request_id", requestID),
+		slog.Int("files", len(keys)+1),
+		slog.String("url", o.store.URL(storage.Key(paths[1]))))
+	return nil
 }
 
-// exportStaticHTML generates and saves a static HTML snapshot
-func (o *Orchestrator) exportStaticHTML(
+// forRequest returns copies of modelInfos charged at pricing and tagged with requestID, leaving the caller's models untouched
+func forRequest(modelInfos []*types.ModelInfo, requestID string, pricing *types.Pricing) []*types.ModelInfo {
+	tagged := make([]*types.ModelInfo, len(modelInfos))
+	for i, mi := range modelInfos {
+		clone := *mi
+		if pricing != nil {
+			clone.Pricing = pricing
+		}
+		tagged[i] = withRequestID(&clone, requestID)
+	}
+	return tagged
+}
+
+// withRequestID tags mi's log lines and provider calls with requestID, so either can be traced back to its run
+// mi is changed in place, and must be a copy the run owns.
+func withRequestID(mi *types.ModelInfo, requestID string) *types.ModelInfo {
+	if mi.Logger != nil {
+		mi.Logger = mi.Logger.With("request_id", requestID)
+	}
+	mi.Headers = models.WithRequestID(mi.FamilyID(), mi.Headers, requestID)
+	return mi
+}
+
+// saveState snapshots the conversation state after completedRounds rounds
+func (o *Orchestrator) saveState(
+	ctx context.Context,
+	logger *slog.Logger,
+	requestID string,
+	question string,
+	numRounds int,
+	activeModels []*types.ModelInfo,
+	questionTS int64,
+	opts Options,
+	completedRounds int,
+	replies map[string]types.Reply,
+	discussion map[string]map[string][]types.DiscussionMessage,
+	privateNotes map[string]map[int]string,
+) {
+	modelNames := make(map[string]string, len(activeModels))
+	for _, mi := range activeModels {
+		modelNames[mi.ID] = mi.Name
+	}
+
+	modelsJSON, _ := json.Marshal(modelNames)
+	repliesJSON, _ := json.Marshal(replies)
+	discussionJSON, _ := json.Marshal(discussion)
+	notesJSON, _ := json.Marshal(privateNotes)
+	optsJSON, _ := json.Marshal(opts)
+
+	st := db.RequestState{
+		RequestID:    requestID,
+		Question:     question,
+		NumRounds:    numRounds,
+		QuestionTS:   questionTS,
+		Models:       string(modelsJSON),
+		Round:        completedRounds,
+		Replies:      string(repliesJSON),
+		Discussion:   string(discussionJSON),
+		PrivateNotes: string(notesJSON),
+		Options:      string(optsJSON),
+		Status:       db.StateRunning,
+	}
+	if err := o.database.SaveRequestState(ctx, st); err != nil {
+		logger.Warn("failed to save request state", slog.Any("error", err))
+	}
+}
+
+// exportData gathers what the exports of a finished request show
+func (o *Orchestrator) exportData(
 	ctx context.Context,
 	requestID string,
 	question string,
@@ -276,7 +946,7 @@ func (o *Orchestrator) exportStaticHTML(
 	scoresByID map[string]int,
 	activeModels []*types.ModelInfo,
 	reqMetrics *metrics.RequestMetrics,
-) error {
+) (htmlexport.ExportData, error) {
 	// Convert discussions to export format
 	var discussions []htmlexport.DiscussionPair
 	processed := make(map[string]bool)
@@ -337,7 +1007,7 @@ func (o *Orchestrator) exportStaticHTML(
 	}
 
 	// Calculate costs for each model
-	modelCosts := make(map[string]string)
+	modelCosts := make(map[string]float64)
 	for _, model := range activeModels {
 		if mm, ok := reqMetrics.ModelMetrics[model.ID]; ok {
 			rate := getRateForModel(model)
@@ -345,7 +1015,7 @@ func (o *Orchestrator) exportStaticHTML(
 			tokensOut := mm.TotalTokens.Output
 			cost := (float64(tokensIn) * rate.In / 1_000_000) + (float64(tokensOut) * rate.Out / 1_000_000)
 			if cost > 0 {
-				modelCosts[model.ID] = fmt.Sprintf("$%.4f", cost)
+				modelCosts[model.ID] = cost
 			}
 		}
 	}
@@ -353,28 +1023,78 @@ func (o *Orchestrator) exportStaticHTML(
 	// Load all round replies from database
 	allRoundReplies, err := o.database.GetRoundReplies(ctx, requestID)
 	if err != nil {
-		return fmt.Errorf("failed to load round replies: %w", err)
+		return htmlexport.ExportData{}, fmt.Errorf("failed to load round replies: %w", err)
 	}
 
-	// Prepare export data
-	exportData := htmlexport.ExportData{
-		Question:        question,
-		QuestionTS:      questionTS,
-		GoldIDs:         goldIDs,
-		SilverIDs:       silverIDs,
-		BronzeIDs:       bronzeIDs,
-		Replies:         replies,
-		AllRoundReplies: allRoundReplies,
-		Models:          activeModels,
-		Metrics:         reqMetrics.Summary(),
-		RoundCounts:     roundCounts,
-		ModelCosts:      modelCosts,
-		ModelScores:     scoresByID,
-		Discussions:     discussions,
-		Timestamp:       time.Now().Format("2006-01-02 15:04:05 MST"),
-	}
-
-	return o.exporter.Export(ctx, exportData)
+	// Load event log for replay mode
+	events, err := o.database.GetEvents(ctx, requestID, 0)
+	if err != nil {
+		o.logger.Warn("failed to load events for replay", slog.Any("error", err))
+	}
+
+	// Load the judges' rankings for their justifications
+	rankings, err := o.database.GetRequestRankings(ctx, requestID)
+	if err != nil {
+		o.logger.Warn("failed to load rankings for export", slog.Any("error", err))
+	}
+
+	// Load the meta judge's verdict, if it had the final say
+	verdict, err := o.database.GetVerdict(ctx, requestID)
+	if err != nil {
+		o.logger.Warn("failed to load verdict for export", slog.Any("error", err))
+	}
+
+	// Load the operator-defined metrics shown alongside them
+	answerMetrics, err := o.database.GetAnswerMetrics(ctx, requestID)
+	if err != nil {
+		o.logger.Warn("failed to load custom metrics for export", slog.Any("error", err))
+	}
+
+	// Load the synthesized answer, if the top answers were merged
+	var synthesizedAnswer, synthesizer string
+	var provenance []types.Provenance
+	if req, err := o.database.GetRequest(ctx, requestID); err != nil {
+		o.logger.Warn("failed to load synthesized answer for export", slog.Any("error", err))
+	} else if req != nil {
+		synthesizedAnswer, synthesizer = req.SynthesizedAnswer, req.Synthesizer
+		if req.Provenance != "" {
+			if err := json.Unmarshal([]byte(req.Provenance), &provenance); err != nil {
+				o.logger.Warn("failed to parse synthesis provenance for export", slog.Any("error", err))
+			}
+		}
+	}
+
+	// Load the participants' fact-checks of the winning answer, if it was verified
+	verifications, err := o.database.GetVerifications(ctx, requestID)
+	if err != nil {
+		o.logger.Warn("failed to load verifications for export", slog.Any("error", err))
+	}
+
+	return htmlexport.ExportData{
+		Question:          question,
+		QuestionTS:        questionTS,
+		GoldIDs:           goldIDs,
+		SilverIDs:         silverIDs,
+		BronzeIDs:         bronzeIDs,
+		Replies:           replies,
+		AllRoundReplies:   allRoundReplies,
+		Models:            activeModels,
+		Metrics:           reqMetrics.Summary(),
+		RoundCounts:       roundCounts,
+		ModelCosts:        modelCosts,
+		ModelScores:       scoresByID,
+		Discussions:       discussions,
+		Timestamp:         o.locale.Timestamp(time.Now()),
+		Locale:            o.locale,
+		Events:            events,
+		Rankings:          rankings,
+		Verdict:           verdict,
+		AnswerMetrics:     answerMetrics,
+		SynthesizedAnswer: synthesizedAnswer,
+		Synthesizer:       synthesizer,
+		Provenance:        provenance,
+		Verifications:     verifications,
+	}, nil
 }
 
 type callResult struct {
@@ -383,130 +1103,426 @@ type callResult struct {
 	tokensIn  int64
 	tokensOut int64
 	cost      float64
+	duration  time.Duration // Of the call, including retries and any fallback
 	err       error
+	fallback  *types.ModelInfo // Set when the model's variant was replaced by its family's fallback
 }
 
+// parallelCall asks every model for its reply to the round at once, one errgroup goroutine each, and returns
+// the results in the order they came in. onResult is called with each result as soon as it's in, one at a time.
+// The calls read the conversation state until parallelCall returns, so it must not be changed before then.
 func (o *Orchestrator) parallelCall(
 	ctx context.Context,
 	requestID string,
 	question string,
+	session []types.Turn,
 	replies map[string]types.Reply,
 	discussion map[string]map[string][]types.DiscussionMessage,
 	privateNotes map[string]map[int]string,
+	positions map[string]string, // Model ID -> side it argues this round in a debate, nil outside debates
 	activeModels []*types.ModelInfo,
 	round int,
 	numRounds int,
 	questionTS int64,
+	schema *answerschema.Schema, // nil when answers are free text
 	reqMetrics *metrics.RequestMetrics,
-) <-chan callResult {
-	results := make(chan callResult, len(activeModels))
+	onResult func(callResult),
+) []callResult {
+	var (
+		g       errgroup.Group
+		mu      sync.Mutex
+		results = make([]callResult, 0, len(activeModels))
+	)
 
 	for _, mi := range activeModels {
-		go func(mi *types.ModelInfo) {
-			defer func() {
-				if r := recover(); r != nil {
-					results <- callResult{modelID: mi.ID, err: fmt.Errorf("panic: %v", r)}
-				}
-			}()
+		g.Go(func() error {
+			result := o.callModel(ctx, requestID, question, session, replies, discussion, privateNotes, positions, activeModels, mi, round, numRounds, questionTS, schema, reqMetrics)
 
-			startTime := time.Now()
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, result)
+			onResult(result)
 
-			// Calculate other agents
-			otherAgents := make([]string, 0, len(activeModels)-1)
-			for _, m := range activeModels {
-				if m.ID != mi.ID {
-					otherAgents = append(otherAgents, m.Name)
-				}
-			}
+			// A failed model is part of the round's outcome, not a reason to stop the others
+			return nil
+		})
+	}
 
-			meta := types.Meta{
-				Round:       round + 1,
-				TotalRounds: numRounds,
-				OtherAgents: otherAgents,
-			}
+	g.Wait()
+	return results
+}
 
-			// Create timeout context
-			timeout := mi.RequestTimeout
-			if timeout == 0 {
-				timeout = 60 * time.Second
-			}
-			callCtx, cancel := context.WithTimeout(ctx, timeout)
-			defer cancel()
+// callModel asks one model for its reply to the round, retrying and falling back to its family's
+// fallback variant as needed; a panic is reported as the model's error
+func (o *Orchestrator) callModel(
+	ctx context.Context,
+	requestID string,
+	question string,
+	session []types.Turn,
+	replies map[string]types.Reply,
+	discussion map[string]map[string][]types.DiscussionMessage,
+	privateNotes map[string]map[int]string,
+	positions map[string]string, // Model ID -> side it argues this round in a debate, nil outside debates
+	activeModels []*types.ModelInfo,
+	mi *types.ModelInfo,
+	round int,
+	numRounds int,
+	questionTS int64,
+	schema *answerschema.Schema, // nil when answers are free text
+	reqMetrics *metrics.RequestMetrics,
+) (res callResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = callResult{modelID: mi.ID, err: fmt.Errorf("panic: %v", r)}
+		}
+	}()
+
+	// Calculate other agents, and in a debate who argues which side, by the names the prompt knows them by
+	otherAgents := make([]string, 0, len(activeModels)-1)
+	var positionsByName map[string]string
+	if positions != nil {
+		positionsByName = make(map[string]string, len(positions))
+	}
+	for _, m := range activeModels {
+		if m.ID != mi.ID {
+			otherAgents = append(otherAgents, m.Name)
+		}
+		if side, ok := positions[m.ID]; ok {
+			positionsByName[m.Name] = side
+		}
+	}
+
+	meta := types.Meta{
+		Round:       round + 1,
+		TotalRounds: numRounds,
+		OtherAgents: otherAgents,
+		MaxTok:      mi.MaxTok,
+		Search:      o.searcher != nil,
+		Structured:  mi.Structured || schema != nil,
+		Session:     session,
+		Positions:   positionsByName,
+	}
+	if schema != nil {
+		meta.AnswerSchema = schema.Map()
+	}
+
+	// Get this model's private notes from previous rounds
+	modelNotes := privateNotes[mi.ID] // may be nil - that's OK
+
+	// Older rounds that would overflow the context window are condensed rather than cut
+	discussion, modelNotes = o.summarizeOlderRounds(ctx, mi, question, &meta, replies, discussion, modelNotes, questionTS, reqMetrics)
+
+	// Queue for a free call slot and behind the provider's rate limit before the timeout clock starts
+	release, err := o.limiter.Acquire(ctx, mi.FamilyID())
+	if err != nil {
+		return callResult{modelID: mi.ID, err: fmt.Errorf("model %s: waiting for a call slot: %w", mi.Name, err)}
+	}
+	defer release()
+
+	prompt := shared.FormatPrompt(mi.ID, mi.Name, question, meta, replies, discussion, modelNotes)
+	o.recordPrompt(requestID, mi.ID, prompt)
+	estimate := shared.EstimateTokens(prompt)
+	reservation, err := o.limiter.Wait(ctx, mi.FamilyID(), estimate)
+	if err != nil {
+		return callResult{modelID: mi.ID, err: fmt.Errorf("model %s: waiting for rate limit: %w", mi.Name, err)}
+	}
+	if waited := reservation.Waited(); waited > 0 {
+		mi.Logger.Info("rate limited, call was queued",
+			slog.Int("round", round+1),
+			slog.Duration("waited", waited))
+	}
+
+	startTime := time.Now()
+	o.recordCallStart(requestID, mi.ID, startTime)
 
-			model := models.NewModel(mi)
+	// Retry configuration
+	retryCfg := retry.DefaultConfig()
+	var result types.PromptResponse
 
-			// Get this model's private notes from previous rounds
-			modelNotes := privateNotes[mi.ID] // may be nil - that's OK
+	// Execute with retry - every attempt is a request and needs its own rate limit slot
+	attempt := 0
+	call := func(mi *types.ModelInfo) error {
+		// Create timeout context
+		timeout := mi.RequestTimeout
+		if timeout == 0 {
+			timeout = 60 * time.Second
+		}
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
 
-			// Retry configuration
-			retryCfg := retry.DefaultConfig()
-			var result types.ModelResult
-			var err error
+		model := models.NewModel(mi)
+		meta.MaxTok = mi.MaxTok
 
-			// Execute with retry
-			retryErr := retry.Do(callCtx, retryCfg, func() error {
-				result, err = model.Prompt(callCtx, question, meta, replies, discussion, modelNotes)
-				if err != nil && retry.IsRetryable(err) {
-					mi.Logger.Warn("retrying after error", slog.Any("error", err))
+		return retry.Do(callCtx, retryCfg, func() error {
+			if attempt++; attempt > 1 {
+				if reservation, err = o.limiter.Wait(callCtx, mi.FamilyID(), estimate); err != nil {
 					return err
 				}
+			}
+			result, err = model.Prompt(callCtx, types.PromptRequest{Question: question, Meta: meta, Replies: replies, Discussion: discussion, Notes: modelNotes})
+			if err == nil {
+				reservation.Settle(int(result.TokIn + result.TokOut))
+			}
+			if err != nil && retry.IsRetryable(err) {
+				mi.Logger.Warn("retrying after error", slog.Any("error", err))
 				return err
-			})
+			}
+			return err
+		})
+	}
+	retryErr := call(mi)
+
+	// A variant the provider doesn't know (e.g. decommissioned) is swapped for the family's fallback
+	var fallback *types.ModelInfo
+	if retryErr != nil && retry.IsModelNotFound(retryErr) && o.fallback != nil {
+		// Skipped if another agent of the run already answers as the fallback variant
+		if fallback = o.fallback(mi); fallback != nil && slices.ContainsFunc(activeModels, func(m *types.ModelInfo) bool { return m.Name == fallback.Name }) {
+			fallback = nil
+		}
+		if fallback != nil {
+			mi.Logger.Warn("model not found, switching to fallback variant",
+				slog.Int("round", round+1),
+				slog.String("fallback", fallback.Name),
+				slog.Any("error", retryErr))
+			if mm := reqMetrics.ModelMetrics[mi.ID]; mm != nil {
+				mm.RecordFallback(mi.Name, fallback.Name)
+			}
+			fallback.ID, fallback.Family = mi.ID, mi.FamilyID()
+			fallback.Pricing = mi.Pricing
+			mi = withRequestID(fallback, requestID)
+			retryErr = call(mi)
+		}
+	}
 
-			duration := time.Since(startTime)
+	duration := time.Since(startTime)
 
-			if retryErr != nil {
-				mi.Logger.Error("model prompt failed after retries",
-					slog.Int("round", round+1),
-					slog.Any("error", retryErr))
+	if retryErr != nil {
+		mi.Logger.Error("model prompt failed after retries",
+			slog.Int("round", round+1),
+			slog.Any("error", retryErr))
 
-				// Record metrics
-				mm := reqMetrics.ModelMetrics[mi.ID]
-				if mm != nil {
-					mm.RecordRound(round+1, duration, 0, 0, retryErr)
-				}
+		// Record metrics
+		mm := reqMetrics.ModelMetrics[mi.ID]
+		if mm != nil {
+			mm.RecordRound(round+1, duration, 0, 0, retryErr)
+		}
 
-				results <- callResult{modelID: mi.ID, err: fmt.Errorf("model %s: %w", mi.Name, retryErr)}
-				return
-			}
+		// A fallback that failed for other reasons still replaces the unknown variant
+		if retry.IsModelNotFound(retryErr) {
+			fallback = nil
+		}
+		return callResult{modelID: mi.ID, duration: duration, err: fmt.Errorf("model %s: %w", mi.Name, retryErr), fallback: fallback}
+	}
 
-			// Record metrics
-			mm := reqMetrics.ModelMetrics[mi.ID]
-			if mm != nil {
-				mm.RecordRound(round+1, duration, result.TokIn, result.TokOut, nil)
-			}
+	mi.Logger.Debug("model answered",
+		slog.Int("round", round+1),
+		slog.String("finish_reason", result.FinishReason),
+		slog.String("model_version", result.ModelVersion))
 
-			// Log the conversation
-			if err := utils.Log(questionTS, fmt.Sprintf("R%d", round+1), mi.Name, result.Prompt, result.Reply.RawContent); err != nil {
-				mi.Logger.Warn("failed to log conversation", slog.Any("error", err))
-			}
+	// Record metrics
+	mm := reqMetrics.ModelMetrics[mi.ID]
+	if mm != nil {
+		mm.RecordRound(round+1, duration, result.TokIn, result.TokOut, nil)
+	}
 
-			// Calculate cost
-			rate := getRateForModel(mi)
-			cost := (float64(result.TokIn)*rate.In + float64(result.TokOut)*rate.Out) / 1_000_000
+	// Log the conversation
+	if err := utils.Log(questionTS, fmt.Sprintf("R%d", round+1), mi.Name, result.Prompt, result.Reply.RawContent); err != nil {
+		mi.Logger.Warn("failed to log conversation", slog.Any("error", err))
+	}
 
-			results <- callResult{
-				modelID:   mi.ID,
-				reply:     result.Reply,
-				tokensIn:  result.TokIn,
-				tokensOut: result.TokOut,
-				cost:      cost,
-			}
-		}(mi)
+	// Clean up the parsed reply before it is stored, shared with other agents and ranked
+	o.postprocess.Apply(&result.Reply)
+	if len(result.Reply.Transforms) > 0 {
+		mi.Logger.Debug("post-processed reply", slog.Any("transforms", result.Reply.Transforms))
 	}
 
-	return results
+	// Answers breaking the schema are kept, but marked so judges rank them below valid ones
+	if schema != nil {
+		check := schema.Check(result.Reply.Answer)
+		result.Reply.Schema = &check
+		if !check.Valid {
+			mi.Logger.Info("answer does not match schema", slog.Int("round", round+1), slog.String("error", check.Error))
+		}
+	}
+
+	// Searches are run now so their results are ready for the next round
+	if round+1 < numRounds {
+		o.runSearches(ctx, mi, round+1, &result.Reply)
+	} else {
+		result.Reply.Searches = nil
+	}
+
+	// Calculate cost
+	rate := getRateForModel(mi)
+	cost := (float64(result.TokIn)*rate.In + float64(result.TokOut)*rate.Out) / 1_000_000
+
+	return callResult{
+		modelID:   mi.ID,
+		reply:     result.Reply,
+		tokensIn:  result.TokIn,
+		tokensOut: result.TokOut,
+		cost:      cost,
+		duration:  duration,
+		fallback:  fallback,
+	}
 }
 
-// saveToDatabase persists request metrics to SQLite
-func (o *Orchestrator) saveToDatabase(ctx context.Context, reqMetrics *metrics.RequestMetrics, question, winner string) error {
-	summary := reqMetrics.Summary()
+// runSearches executes the web searches a reply asks for and attaches the results to it
+// Failed searches are reported to the model as such, so it doesn't wait for results that won't come.
+func (o *Orchestrator) runSearches(ctx context.Context, mi *types.ModelInfo, round int, reply *types.Reply) {
+	if o.searcher == nil || len(reply.Searches) == 0 {
+		reply.Searches = nil
+		return
+	}
+
+	var b strings.Builder
+	for _, query := range reply.Searches {
+		results, err := o.searcher.Search(ctx, query)
+		if err != nil {
+			mi.Logger.Warn("web search failed",
+				slog.Int("round", round),
+				slog.String("query", query),
+				slog.Any("error", err))
+		} else {
+			mi.Logger.Debug("web search",
+				slog.Int("round", round),
+				slog.String("query", query),
+				slog.Int("results", len(results)))
+		}
+		b.WriteString(search.Format(query, results, err))
+	}
+	reply.SearchResult = b.String()
+}
+
+// eloParticipants pairs each model that produced a final answer with its Borda score
+// Empty when ranking fell back to a default winner, so no ratings are moved without a real ranking
+func eloParticipants(activeModels []*types.ModelInfo, replies map[string]types.Reply, scoresByID map[string]int) []elo.Participant {
+	if len(scoresByID) == 0 {
+		return nil
+	}
+
+	var participants []elo.Participant
+	for _, mi := range activeModels {
+		if _, answered := replies[mi.ID]; !answered {
+			continue
+		}
+		participants = append(participants, elo.Participant{
+			ModelID:   mi.ID,
+			ModelName: mi.Name,
+			Score:     scoresByID[mi.ID],
+		})
+	}
+	return participants
+}
+
+// estimateDifficulty scores the question from the stored judge rankings and the final answers
+func (o *Orchestrator) estimateDifficulty(ctx context.Context, requestID string, replies map[string]types.Reply) difficulty.Estimate {
+	var rankings [][]string
+	stored, err := o.database.GetRequestRankings(ctx, requestID)
+	if err != nil {
+		o.logger.Warn("failed to load rankings for difficulty estimate",
+			slog.String("request_id", requestID),
+			slog.Any("error", err))
+	}
+	for _, r := range stored {
+		var ranked []string
+		if err := json.Unmarshal([]byte(r.RankedModels), &ranked); err == nil {
+			rankings = append(rankings, ranked)
+		}
+	}
+
+	answers := make([]string, 0, len(replies))
+	for _, reply := range replies {
+		answers = append(answers, reply.Answer)
+	}
 
-	// Calculate total cost
+	return difficulty.Compute(rankings, answers)
+}
+
+// compareAnswers scores the final answers against each other by embedding and stores the scores
+// Returns nil when embeddings are off or the comparison failed, which is logged and doesn't fail the run.
+func (o *Orchestrator) compareAnswers(ctx context.Context, logger *slog.Logger, requestID string, replies map[string]types.Reply) *embeddings.Similarity {
+	if o.embedder == nil {
+		return nil
+	}
+
+	answers := make(map[string]string, len(replies))
+	for modelID, reply := range replies {
+		answers[modelID] = reply.Answer
+	}
+	similarity, err := o.embedder.Compare(ctx, answers)
+	if err != nil {
+		logger.Warn("failed to compare final answers", slog.Any("error", err))
+		return nil
+	}
+	logger.Info("compared final answers",
+		slog.Float64("similarity", similarity.Overall),
+		slog.String("outlier", similarity.Outlier))
+
+	pairs := make([]db.AnswerSimilarity, 0, len(similarity.Pairs))
+	for _, p := range similarity.Pairs {
+		pairs = append(pairs, db.AnswerSimilarity{
+			RequestID:      requestID,
+			ModelA:         p.A,
+			ModelB:         p.B,
+			Similarity:     p.Similarity,
+			EmbeddingModel: similarity.Model,
+		})
+	}
+	if err := o.database.SaveAnswerSimilarities(ctx, pairs); err != nil {
+		logger.Warn("failed to save answer similarity", slog.Any("error", err))
+	}
+
+	return similarity
+}
+
+// scoreAnswers runs every configured scorer over the final answers and stores the results
+// Returns each model's successful values by metric, nil without scorers; failures are logged and stored.
+func (o *Orchestrator) scoreAnswers(ctx context.Context, logger *slog.Logger, requestID, question string, replies map[string]types.Reply, activeModels []*types.ModelInfo) map[string]map[string]float64 {
+	if o.scorers == nil {
+		return nil
+	}
+
+	inputs := make([]scorer.Input, 0, len(replies))
+	for _, mi := range activeModels {
+		if reply, ok := replies[mi.ID]; ok && strings.TrimSpace(reply.Answer) != "" {
+			inputs = append(inputs, scorer.Input{
+				RequestID: requestID,
+				Question:  question,
+				ModelID:   mi.ID,
+				ModelName: mi.Name,
+				Answer:    reply.Answer,
+			})
+		}
+	}
+
+	results := o.scorers.Score(ctx, inputs)
+	values := make(map[string]map[string]float64, len(inputs))
+	stored := make([]db.AnswerMetric, 0, len(results))
+	for _, r := range results {
+		stored = append(stored, db.AnswerMetric{RequestID: requestID, ModelID: r.ModelID, Metric: r.Metric, Value: r.Value, Error: r.Error})
+		if r.Error != "" {
+			logger.Warn("scorer failed", slog.String("metric", r.Metric), slog.String("model", r.ModelID), slog.String("error", r.Error))
+			continue
+		}
+		if values[r.ModelID] == nil {
+			values[r.ModelID] = make(map[string]float64)
+		}
+		values[r.ModelID][r.Metric] = r.Value
+	}
+	if err := o.database.SaveAnswerMetrics(ctx, stored); err != nil {
+		logger.Warn("failed to save answer metrics", slog.Any("error", err))
+	}
+
+	return values
+}
+
+// requestCost is what a request's model calls cost, at the rates of the models that ran
+func requestCost(reqMetrics *metrics.RequestMetrics, activeModels []*types.ModelInfo) float64 {
 	totalCost := 0.0
 	for modelID, mm := range reqMetrics.ModelMetrics {
 		var modelInfo *types.ModelInfo
-		for _, mi := range models.AllModels {
+		for _, mi := range activeModels {
 			if mi.ID == modelID {
 				modelInfo = mi
 				break
@@ -519,6 +1535,19 @@ func (o *Orchestrator) saveToDatabase(ctx context.Context, reqMetrics *metrics.R
 			totalCost += cost
 		}
 	}
+	return totalCost
+}
+
+// awaitingHuman reports whether a run's winner is left for a person to pick, which needs a provisional one to replace
+func awaitingHuman(strategy, winner string) bool {
+	return strategy == ranking.Human && winner != ""
+}
+
+// saveToDatabase persists request metrics to SQLite, costed at the rates of the models that ran
+// synth is stored with the request and its call added to the cost, when the run had one.
+func (o *Orchestrator) saveToDatabase(ctx context.Context, reqMetrics *metrics.RequestMetrics, activeModels []*types.ModelInfo, question, winner string, order []shared.Placement, opts Options, questionDifficulty float64, synth *Synthesis) error {
+	summary := reqMetrics.Summary()
+	totalCost := requestCost(reqMetrics, activeModels)
 
 	// Save main request record
 	req := db.Request{
@@ -532,16 +1561,35 @@ func (o *Orchestrator) saveToDatabase(ctx context.Context, reqMetrics *metrics.R
 		TotalTokensOut:  summary["total_tokens_out"].(int64),
 		TotalCost:       totalCost,
 		ErrorCount:      summary["error_count"].(int),
+		Tag:             opts.Tag,
+		Difficulty:      &questionDifficulty,
+		ParentRequestID: opts.ParentRequestID,
+		CacheKey:        opts.CacheKey,
+		Strategy:        opts.Strategy,
+		AwaitingHuman:   awaitingHuman(opts.Strategy, winner),
+		ClientRef:       opts.ClientRef,
+	}
+	if len(order) > 0 {
+		finalRanking, _ := json.Marshal(order)
+		req.FinalRanking = string(finalRanking)
+	}
+	if synth != nil {
+		req.SynthesizedAnswer, req.Synthesizer = synth.Answer, synth.Synthesizer
+		if len(synth.Provenance) > 0 {
+			provenance, _ := json.Marshal(synth.Provenance)
+			req.Provenance = string(provenance)
+		}
+		req.TotalCost += synth.Cost
 	}
 
 	if err := o.database.SaveRequest(ctx, req); err != nil {
 		return fmt.Errorf("failed to save request: %w", err)
 	}
 
-	// Save individual model rounds
+	// Rounds were saved as they came in, only the models' stats are left
 	for modelID, mm := range reqMetrics.ModelMetrics {
 		var modelInfo *types.ModelInfo
-		for _, mi := range models.AllModels {
+		for _, mi := range activeModels {
 			if mi.ID == modelID {
 				modelInfo = mi
 				break
@@ -553,28 +1601,6 @@ func (o *Orchestrator) saveToDatabase(ctx context.Context, reqMetrics *metrics.R
 		}
 
 		rate := getRateForModel(modelInfo)
-		for _, roundMetric := range mm.RoundMetrics {
-			cost := (float64(roundMetric.Tokens.Input)*rate.In + float64(roundMetric.Tokens.Output)*rate.Out) / 1_000_000
-
-			mr := db.ModelRound{
-				RequestID:  reqMetrics.RequestID,
-				ModelID:    modelID,
-				ModelName:  modelInfo.Name,
-				Round:      roundMetric.Round,
-				DurationMs: roundMetric.Duration.Milliseconds(),
-				TokensIn:   roundMetric.Tokens.Input,
-				TokensOut:  roundMetric.Tokens.Output,
-				Cost:       cost,
-				Error:      roundMetric.Error,
-			}
-
-			if err := o.database.SaveModelRound(ctx, mr); err != nil {
-				o.logger.Warn("failed to save model round",
-					slog.String("model", modelID),
-					slog.Int("round", roundMetric.Round),
-					slog.Any("error", err))
-			}
-		}
 
 		// Update model stats
 		won := (modelID == winner)
@@ -589,6 +1615,7 @@ func (o *Orchestrator) saveToDatabase(ctx context.Context, reqMetrics *metrics.R
 
 		modelCost := (float64(mm.TotalTokens.Input)*rate.In + float64(mm.TotalTokens.Output)*rate.Out) / 1_000_000
 
+		// Stats are kept per agent like Elo ratings, so variants of one family in a run don't count the family twice
 		if err := o.database.UpdateModelStats(ctx, modelID, modelInfo.Name, won,
 			mm.TotalTokens.Input, mm.TotalTokens.Output, modelCost, avgResponseTime); err != nil {
 			o.logger.Warn("failed to update model stats",
@@ -600,7 +1627,6 @@ func (o *Orchestrator) saveToDatabase(ctx context.Context, reqMetrics *metrics.R
 	return nil
 }
 
-// normalizeAgentName converts any agent name variant to model ID
 // formatModelName formats model IDs to match live site display names
 func formatModelName(id string) string {
 	switch id {
@@ -621,39 +1647,42 @@ func formatModelName(id string) string {
 	}
 }
 
+// normalizeAgentName converts any name a model addresses another agent by to that agent's ID, or "" if it names none
+// An agent's exact ID or variant wins; a family or part of a name only counts when it fits a single agent,
+// as "gpt" could be either of gpt-5 and gpt-5-mini taking part side by side.
 func normalizeAgentName(agentName string, activeModels []*types.ModelInfo) string {
 	agentName = strings.TrimSpace(agentName)
 	agentName = strings.ToLower(agentName)
+	if agentName == "" {
+		return ""
+	}
 
 	for _, mi := range activeModels {
-		if strings.ToLower(mi.ID) == agentName {
+		if strings.ToLower(mi.ID) == agentName || strings.ToLower(mi.Name) == agentName {
 			return mi.ID
 		}
-		if strings.ToLower(mi.Name) == agentName {
-			return mi.ID
-		}
-		if strings.Contains(strings.ToLower(mi.Name), agentName) {
-			return mi.ID
-		}
-		if strings.Contains(strings.ToLower(mi.ID), agentName) {
-			return mi.ID
+	}
+
+	var match string
+	for _, mi := range activeModels {
+		if mi.FamilyID() == agentName || strings.Contains(strings.ToLower(mi.Name), agentName) || strings.Contains(strings.ToLower(mi.ID), agentName) {
+			if match != "" {
+				return ""
+			}
+			match = mi.ID
 		}
 	}
 
-	return ""
+	return match
 }
 
 // getRateForModel retrieves the pricing rate for a model by looking up its variant
+// The run's pricing overrides, if any, are applied to the variant's list rate.
 func getRateForModel(modelInfo *types.ModelInfo) types.Rate {
-	family, ok := models.ModelFamilies[modelInfo.ID]
-	if !ok {
-		return types.Rate{}
-	}
-
-	variant, ok := family.Variants[modelInfo.Name]
+	family, ok := models.ModelFamilies[modelInfo.FamilyID()]
 	if !ok {
-		return types.Rate{}
+		return modelInfo.Pricing.Apply(modelInfo.Name, types.Rate{})
 	}
 
-	return variant.Rate
+	return modelInfo.Pricing.Apply(modelInfo.Name, family.Variants[modelInfo.Name].Rate)
 }
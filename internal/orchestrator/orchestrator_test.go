@@ -0,0 +1,41 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestNormalizeAgentName(t *testing.T) {
+	activeModels := []*types.ModelInfo{
+		{ID: "gpt-5", Family: "gpt", Name: "gpt-5"},
+		{ID: "gpt-5-mini", Family: "gpt", Name: "gpt-5-mini"},
+		{ID: "grok", Name: "grok-4.20-multi-agent"},
+	}
+
+	tests := map[string]string{
+		"GPT-5":       "gpt-5", // An exact ID wins over it being part of gpt-5-mini
+		" gpt-5-mini": "gpt-5-mini",
+		"mini":        "gpt-5-mini", // Part of a single agent's name
+		"grok":        "grok",
+		"Grok-4.20":   "grok",
+		"gpt":         "", // The family of both gpt agents
+		"GPT":         "",
+		"claude":      "",
+		"":            "",
+	}
+	for name, want := range tests {
+		if got := normalizeAgentName(name, activeModels); got != want {
+			t.Errorf("normalizeAgentName(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	// With a single variant of the family taking part, the family names it
+	single := []*types.ModelInfo{{ID: "gpt", Name: "gpt-5-mini"}, activeModels[2]}
+	if got := normalizeAgentName("GPT", single); got != "gpt" {
+		t.Errorf("Expected the family to name its only agent, got %q", got)
+	}
+	if got := normalizeAgentName("gpt-5-mini", single); got != "gpt" {
+		t.Errorf("Expected the variant to name the agent running it, got %q", got)
+	}
+}
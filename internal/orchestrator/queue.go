@@ -0,0 +1,228 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrQueueFull is returned when the request queue has no free slots
+var ErrQueueFull = errors.New("request queue is full")
+
+// ErrShuttingDown is returned for requests that arrive or are still queued while the server shuts down
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// queueEntry is a request that is waiting for, or holding, a processing slot
+type queueEntry struct {
+	RequestID  string    `json:"request_id"`
+	Question   string    `json:"question"`
+	ClientRef  string    `json:"client_ref,omitempty"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	ready      chan struct{}
+}
+
+// QueueStatus is a snapshot of the request queue
+type QueueStatus struct {
+	MaxConcurrent int          `json:"max_concurrent"`
+	MaxQueued     int          `json:"max_queued"`
+	Running       []queueEntry `json:"running"`
+	Queued        []queueEntry `json:"queued"`
+}
+
+// acquire waits until a processing slot is free for the request
+// Queue positions are broadcast whenever they change
+func (o *Orchestrator) acquire(ctx context.Context, requestID, question, clientRef string) error {
+	o.queueMu.Lock()
+	if o.draining {
+		o.queueMu.Unlock()
+		return ErrShuttingDown
+	}
+	if o.maxQueued > 0 && len(o.queued) >= o.maxQueued {
+		o.queueMu.Unlock()
+		return ErrQueueFull
+	}
+
+	entry := &queueEntry{
+		RequestID:  requestID,
+		Question:   question,
+		ClientRef:  clientRef,
+		EnqueuedAt: time.Now(),
+		ready:      make(chan struct{}),
+	}
+	o.queued = append(o.queued, entry)
+	o.dispatchLocked()
+	o.queueMu.Unlock()
+
+	var err error
+	select {
+	case <-entry.ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-o.stopping:
+		err = ErrShuttingDown
+	}
+
+	o.queueMu.Lock()
+	defer o.queueMu.Unlock()
+
+	// The slot may have been granted while we were cancelled
+	select {
+	case <-entry.ready:
+		o.releaseLocked(requestID)
+	default:
+		o.removeQueuedLocked(requestID)
+	}
+	return err
+}
+
+// release frees the request's processing slot and starts the next queued request
+func (o *Orchestrator) release(requestID string) {
+	o.queueMu.Lock()
+	defer o.queueMu.Unlock()
+
+	o.releaseLocked(requestID)
+}
+
+func (o *Orchestrator) releaseLocked(requestID string) {
+	if _, ok := o.running[requestID]; ok {
+		delete(o.running, requestID)
+		o.inflight.Done()
+	}
+	o.dispatchLocked()
+}
+
+func (o *Orchestrator) removeQueuedLocked(requestID string) {
+	for i, e := range o.queued {
+		if e.RequestID == requestID {
+			o.queued = append(o.queued[:i], o.queued[i+1:]...)
+			break
+		}
+	}
+	o.broadcastPositionsLocked()
+}
+
+// dispatchLocked moves queued requests into free slots and broadcasts new positions
+func (o *Orchestrator) dispatchLocked() {
+	for len(o.running) < o.maxConcurrent && len(o.queued) > 0 {
+		entry := o.queued[0]
+		o.queued = o.queued[1:]
+		entry.StartedAt = time.Now()
+		o.running[entry.RequestID] = entry
+		o.inflight.Add(1)
+		close(entry.ready)
+	}
+	o.broadcastPositionsLocked()
+}
+
+// broadcastPositionsLocked tells every waiting client where its request is in line
+func (o *Orchestrator) broadcastPositionsLocked() {
+	for i, e := range o.queued {
+		o.broadcaster.Broadcast(withClientRef(map[string]any{
+			"type":       "queue",
+			"request_id": e.RequestID,
+			"question":   e.Question,
+			"position":   i + 1,
+			"queued":     len(o.queued),
+			"running":    len(o.running),
+		}, e.ClientRef))
+	}
+}
+
+// isActive reports whether a request is currently queued or running
+func (o *Orchestrator) isActive(requestID string) bool {
+	o.queueMu.Lock()
+	defer o.queueMu.Unlock()
+
+	if _, ok := o.running[requestID]; ok {
+		return true
+	}
+	for _, e := range o.queued {
+		if e.RequestID == requestID {
+			return true
+		}
+	}
+	return false
+}
+
+// QueueFull returns true if no more requests can be queued
+func (o *Orchestrator) QueueFull() bool {
+	o.queueMu.Lock()
+	defer o.queueMu.Unlock()
+
+	return o.maxQueued > 0 && len(o.queued) >= o.maxQueued
+}
+
+// QueueStatus returns a snapshot of running and queued requests
+func (o *Orchestrator) QueueStatus() QueueStatus {
+	o.queueMu.Lock()
+	defer o.queueMu.Unlock()
+
+	status := QueueStatus{
+		MaxConcurrent: o.maxConcurrent,
+		MaxQueued:     o.maxQueued,
+		Running:       make([]queueEntry, 0, len(o.running)),
+		Queued:        make([]queueEntry, 0, len(o.queued)),
+	}
+	for _, e := range o.running {
+		status.Running = append(status.Running, *e)
+	}
+	sort.Slice(status.Running, func(i, j int) bool {
+		return status.Running[i].StartedAt.Before(status.Running[j].StartedAt)
+	})
+	for _, e := range o.queued {
+		status.Queued = append(status.Queued, *e)
+	}
+
+	return status
+}
+
+// runContext derives a request's context that is also cancelled when shutdown gives up waiting
+func (o *Orchestrator) runContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stopAfter := context.AfterFunc(o.runsCtx, cancel)
+	return ctx, func() {
+		stopAfter()
+		cancel()
+	}
+}
+
+// ShuttingDown returns true once Shutdown was called
+func (o *Orchestrator) ShuttingDown() bool {
+	o.queueMu.Lock()
+	defer o.queueMu.Unlock()
+
+	return o.draining
+}
+
+// Shutdown stops accepting requests, rejects queued ones and waits for running ones and their exports to finish
+// If ctx ends first, running requests are cancelled - their saved state keeps them resumable -
+// and Shutdown returns once they have unwound.
+func (o *Orchestrator) Shutdown(ctx context.Context) error {
+	o.queueMu.Lock()
+	if !o.draining {
+		o.draining = true
+		o.queued = nil
+		close(o.stopping)
+	}
+	o.queueMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		o.inflight.Wait()
+		o.exporting.Wait() // Exports queued by the last requests
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		o.cancelRuns()
+		<-done
+		return fmt.Errorf("cancelled running requests: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,41 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/meedamian/fat/internal/shared"
+)
+
+// ResolveAnswerReferences replaces every {{answer:<request-id>}} in a question with that request's winning answer
+// Fails if a referenced request doesn't exist or has no winning answer, so nothing is asked with a dangling reference.
+func (o *Orchestrator) ResolveAnswerReferences(ctx context.Context, question string) (string, error) {
+	ids := shared.AnswerReferences(question)
+	if len(ids) == 0 {
+		return question, nil
+	}
+
+	answers := make(map[string]string, len(ids))
+	for _, id := range ids {
+		req, err := o.database.GetRequest(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if req == nil {
+			return "", fmt.Errorf("unknown request %q in {{answer:%s}}", id, id)
+		}
+
+		answer := ""
+		if req.WinnerModel != "" {
+			if answer, err = o.database.GetFinalAnswer(ctx, id, req.WinnerModel); err != nil {
+				return "", err
+			}
+		}
+		if answer == "" {
+			return "", fmt.Errorf("request %q in {{answer:%s}} has no winning answer", id, id)
+		}
+		answers[id] = answer
+	}
+
+	return shared.ExpandAnswerReferences(question, answers), nil
+}
@@ -0,0 +1,39 @@
+package orchestrator
+
+import (
+	"context"
+	"slices"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// maxSessionTurns caps how many earlier questions a follow-up carries, keeping prompts bounded
+const maxSessionTurns = 5
+
+// loadSession follows a follow-up's parents back towards the start of its session
+// Returns the most recent maxSessionTurns questions with their winning answers, oldest first.
+func (o *Orchestrator) loadSession(ctx context.Context, parentRequestID string) ([]types.Turn, error) {
+	var turns []types.Turn
+	seen := make(map[string]bool)
+	for id := parentRequestID; id != "" && !seen[id] && len(turns) < maxSessionTurns; {
+		seen[id] = true
+
+		req, err := o.database.GetRequest(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if req == nil {
+			break
+		}
+
+		answer, err := o.database.GetFinalAnswer(ctx, req.ID, req.WinnerModel)
+		if err != nil {
+			return nil, err
+		}
+		turns = append(turns, types.Turn{Question: req.Question, Answer: answer})
+		id = req.ParentRequestID
+	}
+
+	slices.Reverse(turns)
+	return turns, nil
+}
@@ -0,0 +1,98 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/meedamian/fat/internal/metrics"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+	"github.com/meedamian/fat/internal/utils"
+)
+
+// summarizeOlderRounds condenses mi's discussion and notes from before the previous round into meta.Summary
+// when its prompt would overflow mi's context window, returning the context left to show in full.
+// Without a summarizer, or when it fails, everything is returned as is and the prompt is trimmed instead.
+func (o *Orchestrator) summarizeOlderRounds(
+	ctx context.Context,
+	mi *types.ModelInfo,
+	question string,
+	meta *types.Meta,
+	replies map[string]types.Reply,
+	discussion map[string]map[string][]types.DiscussionMessage,
+	notes map[int]string,
+	questionTS int64,
+	reqMetrics *metrics.RequestMetrics,
+) (map[string]map[string][]types.DiscussionMessage, map[int]string) {
+	if o.summarizer == nil || !shared.Overflows(mi.ID, mi.Name, question, *meta, replies, discussion, notes) {
+		return discussion, notes
+	}
+	older, recent, recentNotes := shared.SplitOlderRounds(mi.ID, meta.Round, discussion, notes)
+	if older == "" {
+		return discussion, notes
+	}
+
+	summary, result, err := o.summarize(ctx, reqMetrics.RequestID, mi.Name, question, older)
+	if err != nil {
+		mi.Logger.Warn("failed to summarize older rounds, trimming them instead", slog.Int("round", meta.Round), slog.Any("error", err))
+		return discussion, notes
+	}
+	if err := utils.Log(questionTS, fmt.Sprintf("R%d-summary", meta.Round), mi.Name, result.Prompt, result.Reply.RawContent); err != nil {
+		mi.Logger.Warn("failed to log summary", slog.Any("error", err))
+	}
+	if mm := reqMetrics.ModelMetrics[mi.ID]; mm != nil {
+		mm.RecordSummary(meta.Round, result.TokIn, result.TokOut)
+	}
+	mi.Logger.Info("summarized older rounds to fit the context window",
+		slog.Int("round", meta.Round),
+		slog.String("summarizer", o.summarizer.Name),
+		slog.Int("older_tokens", shared.EstimateTokens(older)),
+		slog.Int("summary_tokens", shared.EstimateTokens(summary)))
+
+	meta.Summary = summary
+	return recent, recentNotes
+}
+
+// summarize has the summarizer condense older for requestID, queueing behind its provider's rate limits like any other call
+func (o *Orchestrator) summarize(ctx context.Context, requestID, modelName, question, older string) (string, types.PromptResponse, error) {
+	summarizer := *o.summarizer
+	withRequestID(&summarizer, requestID)
+	release, err := o.limiter.Acquire(ctx, summarizer.FamilyID())
+	if err != nil {
+		return "", types.PromptResponse{}, err
+	}
+	defer release()
+
+	prompt := shared.FormatSummaryPrompt(modelName, question, older)
+	reservation, err := o.limiter.Wait(ctx, summarizer.FamilyID(), shared.EstimateTokens(prompt))
+	if err != nil {
+		return "", types.PromptResponse{}, err
+	}
+
+	timeout := summarizer.RequestTimeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := models.NewModel(&summarizer).Prompt(callCtx, types.PromptRequest{Question: prompt, Meta: types.Meta{Round: 1, TotalRounds: 1}})
+	if err != nil {
+		return "", result, err
+	}
+	reservation.Settle(int(result.TokIn + result.TokOut))
+
+	// The summary is the answer, though a reply ignoring the answer format is taken whole
+	summary := strings.TrimSpace(result.Reply.Answer)
+	if summary == "" {
+		summary = strings.TrimSpace(result.Reply.RawContent)
+	}
+	if summary == "" {
+		return "", result, fmt.Errorf("summarizer %s returned nothing", summarizer.Name)
+	}
+	return summary, result, nil
+}
@@ -0,0 +1,117 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+	"github.com/meedamian/fat/internal/utils"
+)
+
+// errTooFewAnswers is returned when the top of the ranking holds fewer than two answers, leaving nothing to merge
+var errTooFewAnswers = errors.New("fewer than two top answers to synthesize")
+
+// Synthesis is the top answers of a run merged into one by its synthesizer
+type Synthesis struct {
+	Synthesizer string  `json:"synthesizer"` // Model variant that wrote the answer
+	Answer      string  `json:"answer"`
+	Cost        float64 `json:"cost"` // Of the synthesizer's call, in dollars
+
+	Provenance []types.Provenance `json:"provenance,omitempty"` // Answer's paragraphs and the answers each draws on; nil if the synthesizer cited none
+}
+
+// synthesize has synthesizer merge the answers of topIDs, best first, into one consolidated answer,
+// crediting every paragraph to the answers it draws on. It queues behind the synthesizer's rate limits like any other call.
+func (o *Orchestrator) synthesize(
+	ctx context.Context,
+	logger *slog.Logger,
+	synthesizer *types.ModelInfo,
+	question string,
+	questionTS int64,
+	replies map[string]types.Reply,
+	activeModels []*types.ModelInfo,
+	topIDs []string,
+) (*Synthesis, error) {
+	var answers, sources []string
+	for _, id := range topIDs {
+		if answer := strings.TrimSpace(replies[id].Answer); answer != "" {
+			answers = append(answers, answer)
+			sources = append(sources, modelName(id, activeModels))
+		}
+	}
+	if len(answers) < 2 {
+		return nil, errTooFewAnswers
+	}
+
+	release, err := o.limiter.Acquire(ctx, synthesizer.FamilyID())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	prompt := shared.FormatSynthesisPrompt(question, answers)
+	reservation, err := o.limiter.Wait(ctx, synthesizer.FamilyID(), shared.EstimateTokens(prompt))
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := synthesizer.RequestTimeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	logger.Info("asking synthesizer to merge the top answers", slog.String("synthesizer", synthesizer.Name), slog.Int("answers", len(answers)))
+
+	// Like the judges, the synthesizer writes without its persona
+	writer := *synthesizer
+	writer.Persona = ""
+	result, err := models.NewModel(&writer).Prompt(callCtx, types.PromptRequest{Question: prompt, Meta: types.Meta{Round: 1, TotalRounds: 1}})
+	if err != nil {
+		return nil, err
+	}
+	reservation.Settle(int(result.TokIn + result.TokOut))
+
+	if err := utils.Log(questionTS, "synthesis", synthesizer.Name, prompt, result.Reply.RawContent); err != nil {
+		logger.Warn("failed to log synthesis", slog.Any("error", err))
+	}
+
+	// The merged answer is the answer, though a reply ignoring the answer format is taken whole
+	answer := strings.TrimSpace(result.Reply.Answer)
+	if answer == "" {
+		answer = strings.TrimSpace(result.Reply.RawContent)
+	}
+	if answer == "" {
+		return nil, fmt.Errorf("synthesizer %s returned nothing", synthesizer.Name)
+	}
+
+	answer, provenance := shared.ParseProvenance(answer, sources)
+	if provenance == nil {
+		logger.Info("synthesizer credited no answers", slog.String("synthesizer", synthesizer.Name))
+	}
+
+	rate := getRateForModel(synthesizer)
+	return &Synthesis{
+		Synthesizer: synthesizer.Name,
+		Answer:      answer,
+		Cost:        (float64(result.TokIn)*rate.In + float64(result.TokOut)*rate.Out) / 1_000_000,
+		Provenance:  provenance,
+	}, nil
+}
+
+// modelName returns the variant of the agent with id, or id if it isn't taking part
+func modelName(id string, activeModels []*types.ModelInfo) string {
+	for _, mi := range activeModels {
+		if mi.ID == id {
+			return mi.Name
+		}
+	}
+	return id
+}
@@ -0,0 +1,310 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/tournament"
+	"github.com/meedamian/fat/internal/types"
+	"github.com/meedamian/fat/internal/utils"
+)
+
+// RunTournament runs question through a single-elimination bracket of entrants, seeded in the order given,
+// with every match decided by the whole jury, and returns the tournament as saved
+// pricing overrides the configured rates of every model taking part, and is nil to keep them.
+// Entrants answer once, then only their answers compete; one that fails to answer forfeits its first match.
+// Messages carry tournament_id rather than request_id, as a tournament is no request of its own.
+func (o *Orchestrator) RunTournament(
+	ctx context.Context,
+	tournamentID string,
+	question string,
+	entrants []*types.ModelInfo,
+	jury []*types.ModelInfo,
+	pricing *types.Pricing,
+	questionTS int64,
+) (*db.Tournament, error) {
+	if len(entrants) < 2 {
+		return nil, errors.New("a tournament needs at least 2 entrants")
+	}
+	if len(jury) == 0 {
+		return nil, errors.New("a tournament needs a jury")
+	}
+
+	logger := o.logger.With("tournament_id", tournamentID)
+	entrants = forRequest(entrants, tournamentID, pricing)
+	jury = forRequest(jury, tournamentID, pricing)
+
+	names := make([]string, len(entrants))
+	for i, mi := range entrants {
+		names[i] = mi.Name
+	}
+	juryNames := make([]string, len(jury))
+	for i, mi := range jury {
+		juryNames[i] = mi.Name
+	}
+
+	// Stored even after the tournament was cancelled, so what was played can still be looked at
+	saveCtx := context.WithoutCancel(ctx)
+	t := db.Tournament{ID: tournamentID, Question: question, Jury: juryNames, QuestionTS: questionTS}
+	if err := o.database.SaveTournament(saveCtx, t); err != nil {
+		return nil, err
+	}
+
+	logger.Info("tournament started", slog.Int("entrants", len(entrants)), slog.Int("jury", len(jury)))
+	o.broadcaster.Broadcast(map[string]any{
+		"type":          "tournament_start",
+		"tournament_id": tournamentID,
+		"question":      question,
+		"entrants":      names,
+		"jury":          juryNames,
+		"rounds":        tournament.Rounds(len(entrants)),
+	})
+
+	// Every entrant answers once, at the same time
+	entries := make(map[string]db.TournamentEntry, len(entrants))
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for i, mi := range entrants {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			e := db.TournamentEntry{TournamentID: tournamentID, ModelName: mi.Name, ModelID: mi.ID, Seed: i + 1}
+			result, err := o.promptOnce(ctx, mi, question)
+			if err != nil {
+				mi.Logger.Warn("tournament answer failed", slog.Any("error", err))
+				e.Error = err.Error()
+			} else {
+				if err := utils.Log(questionTS, "tournament", mi.Name, question, result.Reply.RawContent); err != nil {
+					mi.Logger.Warn("failed to log tournament answer", slog.Any("error", err))
+				}
+				rate := getRateForModel(mi)
+				e.Answer = result.Reply.Answer
+				e.TokensIn, e.TokensOut = result.TokIn, result.TokOut
+				e.Cost = (float64(result.TokIn)*rate.In + float64(result.TokOut)*rate.Out) / 1_000_000
+			}
+			if err := o.database.SaveTournamentEntry(saveCtx, e); err != nil {
+				mi.Logger.Warn("failed to save tournament entry", slog.Any("error", err))
+			}
+
+			mu.Lock()
+			entries[mi.Name] = e
+			mu.Unlock()
+
+			o.broadcaster.Broadcast(map[string]any{
+				"type":          "tournament_answer",
+				"tournament_id": tournamentID,
+				"model":         mi.Name,
+				"seed":          e.Seed,
+				"answer":        e.Answer,
+				"error":         e.Error,
+			})
+		}()
+	}
+	wg.Wait()
+
+	for _, e := range entries {
+		t.TotalCost += e.Cost
+	}
+
+	// Round by round, each match of a round played at once
+	round := tournament.Seed(names)
+	for {
+		if err := ctx.Err(); err != nil {
+			o.saveTournamentResult(saveCtx, logger, t)
+			return nil, err
+		}
+
+		played := make([]db.TournamentMatch, len(round))
+		for i := range round {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				played[i] = o.playMatch(ctx, question, jury, entries, &round[i])
+			}()
+		}
+		wg.Wait()
+
+		for _, m := range played {
+			m.TournamentID = tournamentID
+			t.TotalCost += m.Cost
+			if err := o.database.SaveTournamentMatch(saveCtx, m); err != nil {
+				logger.Warn("failed to save tournament match", slog.Any("error", err))
+			}
+			o.broadcaster.Broadcast(map[string]any{
+				"type":          "tournament_match",
+				"tournament_id": tournamentID,
+				"round":         m.Round,
+				"slot":          m.Slot,
+				"model_a":       m.ModelA,
+				"model_b":       m.ModelB,
+				"winner":        m.Winner,
+				"votes":         m.Votes,
+			})
+		}
+
+		next := tournament.Next(round)
+		if next == nil {
+			break
+		}
+		round = next
+	}
+
+	t.Winner = round[0].Winner
+	o.saveTournamentResult(saveCtx, logger, t)
+	logger.Info("tournament finished", slog.String("winner", t.Winner), slog.Float64("cost", t.TotalCost))
+
+	saved, err := o.database.GetTournament(saveCtx, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	o.broadcaster.Broadcast(map[string]any{
+		"type":          "tournament_winner",
+		"tournament_id": tournamentID,
+		"winner":        t.Winner,
+		"total_cost":    t.TotalCost,
+	})
+
+	o.exportTournament(ctx, logger, *saved)
+	return saved, nil
+}
+
+// playMatch has the jury decide m, recording its winner in m
+// A side that failed to answer forfeits, so its opponent advances as if on a bye.
+func (o *Orchestrator) playMatch(ctx context.Context, question string, jury []*types.ModelInfo, entries map[string]db.TournamentEntry, m *tournament.Match) db.TournamentMatch {
+	played := db.TournamentMatch{Round: m.Round, Slot: m.Slot, ModelA: m.A, ModelB: m.B}
+
+	contender := func(name string) string {
+		if name != "" && entries[name].Error != "" {
+			return ""
+		}
+		return name
+	}
+	if decided := (tournament.Match{A: contender(m.A), B: contender(m.B)}); decided.Bye() {
+		m.Winner = tournament.Decide(decided, 0, 0)
+		played.Winner = m.Winner
+		return played
+	}
+
+	var (
+		wg             sync.WaitGroup
+		mu             sync.Mutex
+		votesA, votesB int
+	)
+	played.Votes = make([]db.MatchVote, len(jury))
+	for i, mi := range jury {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Every other juror sees the answers swapped, so a lean towards answer A doesn't decide matches
+			first, second := m.A, m.B
+			if i%2 == 1 {
+				first, second = second, first
+			}
+			prompt := shared.FormatMatchPrompt(question, entries[first].Answer, entries[second].Answer)
+
+			vote := db.MatchVote{Juror: mi.Name}
+			result, err := o.promptOnce(ctx, judgeOf(mi), prompt)
+			if err == nil {
+				rate := getRateForModel(mi)
+				mu.Lock()
+				played.TokensIn += result.TokIn
+				played.TokensOut += result.TokOut
+				played.Cost += (float64(result.TokIn)*rate.In + float64(result.TokOut)*rate.Out) / 1_000_000
+				mu.Unlock()
+
+				var side string
+				if side, vote.Reason, err = shared.ParseMatchVote(result.Reply.RawContent); side == shared.MatchA {
+					vote.Vote = first
+				} else if side == shared.MatchB {
+					vote.Vote = second
+				}
+			}
+			if err != nil {
+				mi.Logger.Warn("tournament vote failed", slog.Int("round", m.Round), slog.Int("slot", m.Slot), slog.Any("error", err))
+				vote.Error = err.Error()
+			}
+
+			mu.Lock()
+			switch vote.Vote {
+			case m.A:
+				votesA++
+			case m.B:
+				votesB++
+			}
+			played.Votes[i] = vote
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	m.Winner = tournament.Decide(*m, votesA, votesB)
+	played.Winner = m.Winner
+	return played
+}
+
+// judgeOf returns mi stripped of its persona, as jurors judge like the ranking judges do
+func judgeOf(mi *types.ModelInfo) *types.ModelInfo {
+	juror := *mi
+	juror.Persona = ""
+	return &juror
+}
+
+// promptOnce asks mi a single question, queueing behind its rate limits like any other call
+func (o *Orchestrator) promptOnce(ctx context.Context, mi *types.ModelInfo, prompt string) (types.PromptResponse, error) {
+	release, err := o.limiter.Acquire(ctx, mi.FamilyID())
+	if err != nil {
+		return types.PromptResponse{}, err
+	}
+	defer release()
+
+	reservation, err := o.limiter.Wait(ctx, mi.FamilyID(), shared.EstimateTokens(prompt))
+	if err != nil {
+		return types.PromptResponse{}, err
+	}
+
+	timeout := mi.RequestTimeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := models.NewModel(mi).Prompt(callCtx, types.PromptRequest{Question: prompt, Meta: types.Meta{Round: 1, TotalRounds: 1}})
+	if err != nil {
+		return result, err
+	}
+	reservation.Settle(int(result.TokIn + result.TokOut))
+	return result, nil
+}
+
+// saveTournamentResult records the winner and what the tournament has cost so far
+func (o *Orchestrator) saveTournamentResult(ctx context.Context, logger *slog.Logger, t db.Tournament) {
+	if err := o.database.SaveTournament(ctx, t); err != nil {
+		logger.Warn("failed to save tournament result", slog.Any("error", err))
+	}
+}
+
+// exportTournament writes the bracket views of a finished tournament next to the run exports
+func (o *Orchestrator) exportTournament(ctx context.Context, logger *slog.Logger, t db.Tournament) {
+	if o.exporter != nil {
+		if err := o.exporter.ExportTournament(ctx, t); err != nil {
+			logger.Error("failed to export tournament", slog.Any("error", err))
+		}
+	}
+	if o.mdExporter != nil {
+		if err := o.mdExporter.ExportTournament(ctx, t); err != nil {
+			logger.Error("failed to export tournament as markdown", slog.Any("error", err))
+		}
+	}
+}
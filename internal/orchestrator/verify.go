@@ -0,0 +1,87 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/metrics"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+	"github.com/meedamian/fat/internal/utils"
+)
+
+// verify has every participant fact-check the leading answer at once, claim by claim, and returns their checks by verifier
+// A failed check is kept with its error, so the export shows who didn't verify; nil is returned if there's no answer to check.
+func (o *Orchestrator) verify(
+	ctx context.Context,
+	logger *slog.Logger,
+	requestID string,
+	question string,
+	questionTS int64,
+	replies map[string]types.Reply,
+	activeModels []*types.ModelInfo,
+	leaderID string,
+	reqMetrics *metrics.RequestMetrics,
+) []db.Verification {
+	answer := strings.TrimSpace(replies[leaderID].Answer)
+	if answer == "" {
+		return nil
+	}
+	prompt := shared.FormatVerificationPrompt(question, answer)
+
+	logger.Info("verifying the leading answer", slog.String("model", leaderID), slog.Int("verifiers", len(activeModels)))
+
+	var (
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		verifications []db.Verification
+	)
+	for _, mi := range activeModels {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v := db.Verification{RequestID: requestID, Verifier: mi.ID, VerifierName: mi.Name, AnswerModel: leaderID}
+			startTime := time.Now()
+			result, err := o.checkClaims(ctx, mi, prompt)
+			if err != nil {
+				mi.Logger.Warn("verification failed", slog.Any("error", err))
+				v.Error = err.Error()
+			} else {
+				if err := utils.Log(questionTS, "verify", mi.Name, prompt, result.Reply.RawContent); err != nil {
+					mi.Logger.Warn("failed to log verification", slog.Any("error", err))
+				}
+				rate := getRateForModel(mi)
+				v.Claims = shared.ParseVerification(result.Reply.RawContent)
+				v.TokensIn, v.TokensOut = result.TokIn, result.TokOut
+				v.Cost = (float64(result.TokIn)*rate.In + float64(result.TokOut)*rate.Out) / 1_000_000
+				if mm := reqMetrics.ModelMetrics[mi.ID]; mm != nil {
+					mm.RecordVerification(time.Since(startTime), result.TokIn, result.TokOut)
+				}
+			}
+
+			// Stored even after the request was cancelled, like the ranking it follows
+			if err := o.database.SaveVerification(context.WithoutCancel(ctx), v); err != nil {
+				mi.Logger.Warn("failed to save verification", slog.Any("error", err))
+			}
+
+			mu.Lock()
+			verifications = append(verifications, v)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(verifications, func(i, j int) bool { return verifications[i].Verifier < verifications[j].Verifier })
+	return verifications
+}
+
+// checkClaims asks mi for its fact-check; like the judges, verifiers check without their persona
+func (o *Orchestrator) checkClaims(ctx context.Context, mi *types.ModelInfo, prompt string) (types.PromptResponse, error) {
+	return o.promptOnce(ctx, judgeOf(mi), prompt)
+}
@@ -0,0 +1,109 @@
+package personas
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// Presets maps short role names to full persona instructions
+// personas.json values matching a preset name are expanded, anything else is used verbatim
+var Presets = map[string]string{
+	"skeptic": "You are the skeptic of the group. Question claims that lack evidence, " +
+		"probe for hidden assumptions, and do not accept a point just because other agents agree on it.",
+	"domain-expert": "You are a domain expert. Prioritise technical precision, cite established " +
+		"facts and standards, and correct oversimplifications made by other agents.",
+	"devils-advocate": "You are the devil's advocate. Deliberately argue the strongest opposing " +
+		"position to the emerging consensus so weak reasoning is exposed before the final answer.",
+	"pragmatist": "You are the pragmatist. Favour actionable, concrete answers and push back on " +
+		"ideas that are impractical, overly theoretical, or too costly to act on.",
+}
+
+// Load reads personas from a JSON file (family ID -> persona or preset name)
+// and assigns them to the provided model infos. A missing file is not an error.
+func Load(path string, modelInfos []*types.ModelInfo) error {
+	personas, err := read(path)
+	if err != nil {
+		return err
+	}
+
+	for _, mi := range modelInfos {
+		mi.Persona = personas[mi.ID]
+	}
+
+	return nil
+}
+
+// GetForFamily retrieves the persona for a specific model family
+// The file is only read again once it changed, as this runs for every model built.
+func GetForFamily(path, familyID string) string {
+	personas, err := cached(path)
+	if err != nil {
+		return ""
+	}
+	return personas[familyID]
+}
+
+// cache holds the personas last read by cached, with what the file looked like then
+var cache struct {
+	sync.Mutex
+	path     string
+	modTime  time.Time
+	size     int64
+	personas map[string]string
+}
+
+// cached returns the personas in path, reading the file only when its modification time or size changed
+func cached(path string) (map[string]string, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Lock()
+	defer cache.Unlock()
+	if cache.personas != nil && cache.path == path && cache.modTime.Equal(info.ModTime()) && cache.size == info.Size() {
+		return cache.personas, nil
+	}
+
+	personas, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	cache.path, cache.modTime, cache.size, cache.personas = path, info.ModTime(), info.Size(), personas
+	return personas, nil
+}
+
+// read parses the personas file and expands preset names
+func read(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var personas map[string]string
+	if err := json.NewDecoder(file).Decode(&personas); err != nil {
+		return nil, err
+	}
+
+	for familyID, persona := range personas {
+		persona = strings.TrimSpace(persona)
+		if preset, ok := Presets[strings.ToLower(persona)]; ok {
+			persona = preset
+		}
+		personas[familyID] = persona
+	}
+
+	return personas, nil
+}
@@ -0,0 +1,73 @@
+package personas
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "personas.json")
+	grok := &types.ModelInfo{ID: "grok", Persona: "stale"}
+	if err := Load(path, []*types.ModelInfo{grok}); err != nil {
+		t.Fatalf("Expected a missing file to be no error, got %v", err)
+	}
+	if grok.Persona != "" {
+		t.Errorf("Expected no persona without a file, got %q", grok.Persona)
+	}
+	if persona := GetForFamily(path, "grok"); persona != "" {
+		t.Errorf("Expected no persona without a file, got %q", persona)
+	}
+}
+
+func TestLoadMalformedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "personas.json")
+	if err := os.WriteFile(path, []byte(`{"grok": "skeptic",`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(path, nil); err == nil {
+		t.Error("Expected error for malformed JSON, got nil")
+	}
+	if persona := GetForFamily(path, "grok"); persona != "" {
+		t.Errorf("Expected no persona from a malformed file, got %q", persona)
+	}
+}
+
+func TestLoadPerFamily(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "personas.json")
+	if err := os.WriteFile(path, []byte(`{"grok": " Skeptic ", "gpt": "Answer like a pirate."}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	grok, gpt, claude := &types.ModelInfo{ID: "grok"}, &types.ModelInfo{ID: "gpt"}, &types.ModelInfo{ID: "claude"}
+	if err := Load(path, []*types.ModelInfo{grok, gpt, claude}); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if grok.Persona != Presets["skeptic"] {
+		t.Errorf("Expected the skeptic preset for grok, got %q", grok.Persona)
+	}
+	if gpt.Persona != "Answer like a pirate." {
+		t.Errorf("Expected gpt's persona verbatim, got %q", gpt.Persona)
+	}
+	if claude.Persona != "" {
+		t.Errorf("Expected no persona for a family left out, got %q", claude.Persona)
+	}
+	if persona := GetForFamily(path, "gpt"); persona != gpt.Persona {
+		t.Errorf("Expected GetForFamily to agree with Load, got %q", persona)
+	}
+
+	// A changed file is read again
+	if err := os.WriteFile(path, []byte(`{"gpt": "pragmatist"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if persona := GetForFamily(path, "gpt"); persona != Presets["pragmatist"] {
+		t.Errorf("Expected the changed persona, got %q", persona)
+	}
+}
@@ -0,0 +1,188 @@
+// Package pipeline chains runs into stages defined in YAML, e.g. brainstorm → critique → final draft.
+// Each stage asks its own panel of models a question built from the pipeline's input and the winning
+// answers of the stages before it.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/meedamian/fat/internal/models"
+)
+
+// DefaultRounds is how many rounds a stage runs when it doesn't say
+const DefaultRounds = 3
+
+// Pipeline is a named sequence of stages, run in order
+type Pipeline struct {
+	Name   string  `yaml:"name" json:"name"`
+	Stages []Stage `yaml:"stages" json:"stages"`
+}
+
+// Stage is one run of a pipeline
+// Question is a Go template over Data: {{.Input}} is the pipeline's input, {{.Previous}} the winning answer
+// of the stage before and {{stage "name"}} that of any earlier stage. The first stage asks the input if empty.
+type Stage struct {
+	Name     string   `yaml:"name" json:"name"`
+	Question string   `yaml:"question" json:"question,omitempty"`
+	Models   []string `yaml:"models" json:"models,omitempty"` // Families or variants on the panel; empty for every family
+	Rounds   int      `yaml:"rounds" json:"rounds"`           // 3-10, 0 for DefaultRounds
+
+	tmpl *template.Template
+}
+
+// Data is what a stage's question template is rendered with
+type Data struct {
+	Input    string
+	Previous string
+	answers  map[string]string // Winning answer by stage name, for the stage function
+}
+
+// Result is what a stage produced
+type Result struct {
+	Stage     string `json:"stage"`
+	Question  string `json:"question"` // As rendered and asked
+	RequestID string `json:"request_id"`
+	Winner    string `json:"winner"` // Family ID of the winning model
+	Answer    string `json:"answer"` // Winning answer, fed to the stages after
+}
+
+// Asker runs one stage's question to completion and returns its outcome; Stage and Question are filled in by Run
+type Asker func(ctx context.Context, stage Stage, question string) (Result, error)
+
+// Load reads and validates a pipeline definition
+func Load(path string) (*Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse validates a pipeline definition: stage names are unique, models are known, rounds are in range and
+// every question template only refers to the input and earlier stages.
+func Parse(data []byte) (*Pipeline, error) {
+	var p Pipeline
+	if err := yaml.UnmarshalWithOptions(data, &p, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("invalid pipeline: %w", err)
+	}
+	if len(p.Stages) == 0 {
+		return nil, errors.New("invalid pipeline: no stages")
+	}
+
+	earlier := make(map[string]string, len(p.Stages))
+	for i := range p.Stages {
+		s := &p.Stages[i]
+		if s.Name = strings.TrimSpace(s.Name); s.Name == "" {
+			s.Name = fmt.Sprintf("stage-%d", i+1)
+		}
+		if _, dup := earlier[s.Name]; dup {
+			return nil, fmt.Errorf("invalid pipeline: stage name %q is used twice", s.Name)
+		}
+		if err := s.check(i == 0); err != nil {
+			return nil, fmt.Errorf("invalid stage %q: %w", s.Name, err)
+		}
+
+		// Rendering with every earlier stage known catches references to later or unknown ones
+		data := Data{Input: "input", answers: earlier}
+		if i > 0 {
+			data.Previous = "previous"
+		}
+		if _, err := s.Render(data); err != nil {
+			return nil, fmt.Errorf("invalid stage %q: %w", s.Name, err)
+		}
+		earlier[s.Name] = "answer"
+	}
+
+	return &p, nil
+}
+
+// check validates a stage's settings and parses its question template
+func (s *Stage) check(first bool) error {
+	if s.Rounds == 0 {
+		s.Rounds = DefaultRounds
+	}
+	if s.Rounds < 3 || s.Rounds > 10 {
+		return fmt.Errorf("rounds %d must be between 3 and 10", s.Rounds)
+	}
+	for _, pick := range s.Models {
+		if _, ok := models.ModelFamilies[pick]; !ok && models.FamilyForVariant(pick) == "" {
+			return fmt.Errorf("unknown model %q", pick)
+		}
+	}
+
+	question := s.Question
+	if strings.TrimSpace(question) == "" {
+		if !first {
+			return errors.New("question is required")
+		}
+		question = "{{.Input}}"
+	}
+
+	var err error
+	s.tmpl, err = template.New(s.Name).Option("missingkey=error").Funcs(template.FuncMap{
+		"stage": func(string) (string, error) { return "", nil }, // Replaced with the run's answers in Render
+	}).Parse(question)
+	return err
+}
+
+// Render builds the stage's question
+func (s Stage) Render(data Data) (string, error) {
+	var b strings.Builder
+	tmpl, err := s.tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl.Funcs(template.FuncMap{
+		"stage": func(name string) (string, error) {
+			answer, ok := data.answers[name]
+			if !ok {
+				return "", fmt.Errorf("no earlier stage %q", name)
+			}
+			return answer, nil
+		},
+	})
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Run asks every stage in order, feeding each the winning answers of the stages before it
+// progress, if set, is called before each stage. Results of the stages that finished are returned
+// with the error of the one that failed.
+func (p *Pipeline) Run(ctx context.Context, input string, ask Asker, progress func(i int, stage Stage, question string)) ([]Result, error) {
+	data := Data{Input: strings.TrimSpace(input), answers: make(map[string]string, len(p.Stages))}
+
+	results := make([]Result, 0, len(p.Stages))
+	for i, stage := range p.Stages {
+		question, err := stage.Render(data)
+		if err != nil {
+			return results, fmt.Errorf("stage %q: %w", stage.Name, err)
+		}
+		if question == "" {
+			return results, fmt.Errorf("stage %q: question is empty", stage.Name)
+		}
+		if progress != nil {
+			progress(i, stage, question)
+		}
+
+		result, err := ask(ctx, stage, question)
+		if err != nil {
+			return results, fmt.Errorf("stage %q: %w", stage.Name, err)
+		}
+		result.Stage, result.Question = stage.Name, question
+		results = append(results, result)
+
+		data.Previous = result.Answer
+		data.answers[stage.Name] = result.Answer
+	}
+
+	return results, nil
+}
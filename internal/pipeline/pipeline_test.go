@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const essay = `
+name: essay
+stages:
+  - name: brainstorm
+    models: [grok, gemini]
+  - name: critique
+    question: "Critique these ideas:\n{{.Previous}}"
+    models: [claude]
+    rounds: 4
+  - name: draft
+    question: "Write an essay on {{.Input}} using {{stage \"brainstorm\"}} and {{.Previous}}"
+`
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(essay))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if p.Name != "essay" || len(p.Stages) != 3 {
+		t.Fatalf("Expected 3 stages of essay, got %+v", p)
+	}
+	if p.Stages[0].Rounds != DefaultRounds || p.Stages[1].Rounds != 4 {
+		t.Errorf("Expected default and explicit rounds, got %d and %d", p.Stages[0].Rounds, p.Stages[1].Rounds)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"no stages", "name: empty\n", "no stages"},
+		{"unknown field", "stages:\n  - name: a\n    judges: [gpt]\n", "judges"},
+		{"duplicate name", "stages:\n  - name: a\n  - name: a\n    question: x\n", "used twice"},
+		{"unknown model", "stages:\n  - models: [hal-9000]\n", "unknown model"},
+		{"rounds", "stages:\n  - rounds: 2\n", "between 3 and 10"},
+		{"missing question", "stages:\n  - name: a\n  - name: b\n", "question is required"},
+		{"later stage", "stages:\n  - question: '{{stage \"b\"}}'\n  - name: b\n    question: x\n", `no earlier stage "b"`},
+		{"bad template", "stages:\n  - question: '{{.Input'\n", "unclosed action"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	p, err := Parse([]byte(essay))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var asked []string
+	ask := func(_ context.Context, stage Stage, question string) (Result, error) {
+		asked = append(asked, question)
+		return Result{RequestID: "req-" + stage.Name, Answer: strings.ToUpper(stage.Name)}, nil
+	}
+
+	results, err := p.Run(context.Background(), " tides ", ask, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []string{"tides", "Critique these ideas:\nBRAINSTORM", "Write an essay on tides using BRAINSTORM and CRITIQUE"}
+	for i, q := range want {
+		if asked[i] != q {
+			t.Errorf("Stage %d asked %q, want %q", i+1, asked[i], q)
+		}
+	}
+	if len(results) != 3 || results[2].Stage != "draft" || results[2].Answer != "DRAFT" || results[1].Question != want[1] {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
+
+func TestRunStopsAtFailure(t *testing.T) {
+	p, err := Parse([]byte(essay))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ask := func(_ context.Context, stage Stage, _ string) (Result, error) {
+		if stage.Name == "critique" {
+			return Result{}, errors.New("no winner")
+		}
+		return Result{Answer: "ideas"}, nil
+	}
+
+	results, err := p.Run(context.Background(), "tides", ask, nil)
+	if err == nil || !strings.Contains(err.Error(), `stage "critique"`) {
+		t.Errorf("Expected the failing stage in the error, got %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected the finished stage's result, got %d", len(results))
+	}
+}
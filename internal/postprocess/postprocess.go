@@ -0,0 +1,208 @@
+// Package postprocess cleans up parsed model replies before they are stored and ranked.
+// A pipeline is an ordered chain of built-in transforms and custom regex rules.
+package postprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// Built-in transform names, usable in the config file's "steps" list
+const (
+	StripFences         = "strip-fences"
+	NormalizeWhitespace = "normalize-whitespace"
+	Profanity           = "profanity"
+)
+
+// DefaultSteps are the built-in transforms run when the config doesn't list any
+var DefaultSteps = []string{StripFences, NormalizeWhitespace}
+
+// profanityWords are masked by the profanity transform, in addition to any configured words
+var profanityWords = []string{
+	"fuck", "fucking", "fucked", "shit", "shitty", "bullshit", "asshole",
+	"bitch", "bastard", "damn", "dick", "cunt", "motherfucker",
+}
+
+// Rule is a custom regex replacement; Replace may reference capture groups as $1, ${name}
+type Rule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"`
+}
+
+// Config describes a pipeline as read from the post-processing file
+type Config struct {
+	Steps     []string `json:"steps"`     // Built-in transforms in order; nil means DefaultSteps, empty means none
+	Profanity []string `json:"profanity"` // Extra words masked by the profanity transform
+	Rules     []Rule   `json:"rules"`     // Custom rules, run after the built-in steps
+}
+
+// Transform is one named step of the pipeline
+type Transform struct {
+	Name  string
+	Apply func(string) string
+}
+
+// Pipeline applies its transforms in order to every text field of a reply
+type Pipeline struct {
+	transforms []Transform
+}
+
+// New builds a pipeline from cfg, rejecting unknown steps and invalid patterns
+func New(cfg Config) (*Pipeline, error) {
+	steps := cfg.Steps
+	if steps == nil {
+		steps = DefaultSteps
+	}
+
+	p := &Pipeline{}
+	for _, step := range steps {
+		switch step {
+		case StripFences:
+			p.transforms = append(p.transforms, Transform{Name: step, Apply: stripFences})
+		case NormalizeWhitespace:
+			p.transforms = append(p.transforms, Transform{Name: step, Apply: normalizeWhitespace})
+		case Profanity:
+			p.transforms = append(p.transforms, Transform{Name: step, Apply: profanityFilter(cfg.Profanity)})
+		default:
+			return nil, fmt.Errorf("unknown post-processing step %q", step)
+		}
+	}
+
+	for i, rule := range cfg.Rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for rule %q: %w", rule.Name, err)
+		}
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule-%d", i+1)
+		}
+		replace := rule.Replace
+		p.transforms = append(p.transforms, Transform{
+			Name:  name,
+			Apply: func(s string) string { return re.ReplaceAllString(s, replace) },
+		})
+	}
+
+	return p, nil
+}
+
+// Default returns the pipeline used when no config file is present
+func Default() *Pipeline {
+	p, _ := New(Config{})
+	return p
+}
+
+// Load reads a pipeline from a JSON config file. A missing file yields the default pipeline.
+func Load(path string) (*Pipeline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Default(), nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var cfg Config
+	if err := json.NewDecoder(file).Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	return New(cfg)
+}
+
+// Apply runs the pipeline over the reply's answer, rationale, discussion and private notes
+// The names of transforms that changed anything are recorded in reply.Transforms; RawContent is left untouched.
+// A nil pipeline does nothing.
+func (p *Pipeline) Apply(reply *types.Reply) {
+	if p == nil {
+		return
+	}
+
+	for _, t := range p.transforms {
+		changed := false
+		apply := func(s string) string {
+			out := t.Apply(s)
+			if out != s {
+				changed = true
+			}
+			return out
+		}
+
+		reply.Answer = apply(reply.Answer)
+		reply.Rationale = apply(reply.Rationale)
+		reply.PrivateNotes = apply(reply.PrivateNotes)
+		for agent, message := range reply.Discussion {
+			reply.Discussion[agent] = apply(message)
+		}
+
+		if changed {
+			reply.Transforms = append(reply.Transforms, t.Name)
+		}
+	}
+}
+
+// stripFences unwraps text that is entirely enclosed in a single markdown code fence
+// Models sometimes wrap a whole answer in ```markdown ... ```; fences inside an answer are kept.
+func stripFences(s string) string {
+	trimmed := strings.TrimSpace(s)
+	lines := strings.Split(trimmed, "\n")
+	if len(lines) < 2 {
+		return s
+	}
+
+	first, last := strings.TrimSpace(lines[0]), strings.TrimSpace(lines[len(lines)-1])
+	if !strings.HasPrefix(first, "```") || last != "```" {
+		return s
+	}
+
+	inner := lines[1 : len(lines)-1]
+	for _, line := range inner {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			return s
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(inner, "\n"))
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// normalizeWhitespace converts line endings, trims trailing spaces and collapses runs of blank lines
+func normalizeWhitespace(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	s = strings.Join(lines, "\n")
+
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n\n"))
+}
+
+// profanityFilter masks listed words, keeping their first letter (e.g. "s***")
+func profanityFilter(extra []string) func(string) string {
+	words := make([]string, 0, len(profanityWords)+len(extra))
+	for _, w := range slices.Concat(profanityWords, extra) {
+		if w = strings.TrimSpace(w); w != "" {
+			words = append(words, regexp.QuoteMeta(w))
+		}
+	}
+	re := regexp.MustCompile(`(?i)\b(` + strings.Join(words, "|") + `)\b`)
+
+	return func(s string) string {
+		return re.ReplaceAllStringFunc(s, func(word string) string {
+			runes := []rune(word)
+			return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package postprocess
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestStripFences(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"wrapped", "```markdown\n# Title\n\nBody\n```", "# Title\n\nBody"},
+		{"wrapped without language", "\n```\ncode\n```\n", "code"},
+		{"inner block kept", "Intro\n\n```go\nx := 1\n```\n\nOutro", "Intro\n\n```go\nx := 1\n```\n\nOutro"},
+		{"two blocks kept", "```\na\n```\n\n```\nb\n```", "```\na\n```\n\n```\nb\n```"},
+		{"plain", "just text", "just text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripFences(tt.in); got != tt.want {
+				t.Errorf("stripFences(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeWhitespace(t *testing.T) {
+	in := "  \r\nFirst line   \r\n\r\n\r\n\r\nSecond\t\n\n"
+	want := "First line\n\nSecond"
+
+	if got := normalizeWhitespace(in); got != want {
+		t.Errorf("normalizeWhitespace(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestProfanityFilter(t *testing.T) {
+	filter := profanityFilter([]string{"heck"})
+
+	if got := filter("Well SHIT, what the heck; shitake is fine"); got != "Well S***, what the h***; shitake is fine" {
+		t.Errorf("unexpected filtered text: %q", got)
+	}
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	if _, err := New(Config{Steps: []string{"shout"}}); err == nil {
+		t.Error("expected error for unknown step")
+	}
+	if _, err := New(Config{Rules: []Rule{{Name: "bad", Pattern: "("}}}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestApplyRecordsTransforms(t *testing.T) {
+	p, err := New(Config{
+		Steps: []string{StripFences, NormalizeWhitespace, Profanity},
+		Rules: []Rule{
+			{Name: "unhedge", Pattern: `(?i)as an ai language model,\s*`, Replace: ""},
+			{Pattern: `never matches \d{10}`},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	reply := types.Reply{
+		Answer:     "```\nAs an AI language model, the answer is 42.\n```",
+		Rationale:  "Because   \n\n\n\nreasons",
+		Discussion: map[string]string{"GPT": "That was damn good"},
+		RawContent: "```\nAs an AI language model, the answer is 42.\n```",
+	}
+	p.Apply(&reply)
+
+	if reply.Answer != "the answer is 42." {
+		t.Errorf("unexpected answer: %q", reply.Answer)
+	}
+	if reply.Rationale != "Because\n\nreasons" {
+		t.Errorf("unexpected rationale: %q", reply.Rationale)
+	}
+	if reply.Discussion["GPT"] != "That was d*** good" {
+		t.Errorf("unexpected discussion: %q", reply.Discussion["GPT"])
+	}
+	if reply.RawContent == reply.Answer {
+		t.Error("raw content should be left untouched")
+	}
+
+	want := []string{StripFences, NormalizeWhitespace, Profanity, "unhedge"}
+	if !slices.Equal(reply.Transforms, want) {
+		t.Errorf("Transforms = %v, want %v", reply.Transforms, want)
+	}
+}
+
+func TestApplyNilPipeline(t *testing.T) {
+	var p *Pipeline
+	reply := types.Reply{Answer: "```\nx\n```"}
+	p.Apply(&reply)
+
+	if reply.Answer != "```\nx\n```" || reply.Transforms != nil {
+		t.Error("nil pipeline should leave the reply unchanged")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	// Missing file falls back to the defaults
+	p, err := Load(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("Load of missing file failed: %v", err)
+	}
+	if len(p.transforms) != len(DefaultSteps) {
+		t.Errorf("expected %d default transforms, got %d", len(DefaultSteps), len(p.transforms))
+	}
+
+	path := filepath.Join(dir, "postprocess.json")
+	if err := os.WriteFile(path, []byte(`{"steps": [], "rules": [{"name": "x", "pattern": "a", "replace": "b"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(p.transforms) != 1 || p.transforms[0].Name != "x" {
+		t.Errorf("expected only the custom rule, got %v", p.transforms)
+	}
+}
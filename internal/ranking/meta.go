@@ -0,0 +1,179 @@
+package ranking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/metrics"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+	"github.com/meedamian/fat/internal/utils"
+)
+
+var (
+	errNoAnswers = errors.New("no answers to judge")        // Returned when no participant answered
+	errNoVerdict = errors.New("meta judge gave no ranking") // Returned when the meta judge's reply holds no ranking
+)
+
+// Verdict is a meta judge's final say over the jury's rankings
+type Verdict struct {
+	Judge         string             `json:"judge"`             // Variant of the meta judge
+	Justification string             `json:"justification"`     // Why it ranked the answers as it did, in its own words
+	Reasons       map[string]string  `json:"reasons,omitempty"` // Its reason for each model's place, by model ID
+	Gold          []string           `json:"gold"`
+	Silver        []string           `json:"silver"`
+	Bronze        []string           `json:"bronze"`
+	Scores        map[string]int     `json:"scores"` // By model ID
+	Order         []shared.Placement `json:"order"`  // Every model's place by ID, best first
+}
+
+// MetaRank has metaJudge review the jury's stored rankings of a request along with the answers and decide the result
+// The meta judge ranks every answer, and its ranking is scored like a single Borda ballot; answers it leaves out
+// share the last place. The verdict is stored with the request's rankings, marked as the meta judge's.
+func MetaRank(
+	ctx context.Context,
+	requestID string,
+	question string,
+	replies map[string]types.Reply,
+	activeModels []*types.ModelInfo,
+	metaJudge *types.ModelInfo,
+	questionTS int64,
+	reqMetrics *metrics.RequestMetrics,
+	database *db.DB,
+	logger *slog.Logger,
+) (*Verdict, error) {
+	logger = logger.With("meta_judge", metaJudge.Name)
+	startTime := time.Now()
+
+	stored, err := database.GetRequestRankings(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the jury's rankings: %w", err)
+	}
+	sort.SliceStable(stored, func(i, j int) bool { return stored[i].RankerModel < stored[j].RankerModel })
+	jury := make([]shared.JuryRanking, 0, len(stored))
+	for _, r := range stored {
+		var judge shared.JuryRanking
+		if err := json.Unmarshal([]byte(r.RankedModels), &judge.Ranking); err != nil {
+			logger.Warn("skipping unreadable ranking", slog.String("judge", r.RankerModel), slog.Any("error", err))
+			continue
+		}
+		if r.Justifications != "" {
+			json.Unmarshal([]byte(r.Justifications), &judge.Reasons)
+		}
+		jury = append(jury, judge)
+	}
+
+	repliesByName := make(map[string]types.Reply)
+	costsByName := make(map[string]float64)
+	allAgentNames := make([]string, 0, len(activeModels))
+	participants := make(map[string]bool, len(activeModels))
+	for _, mi := range activeModels {
+		allAgentNames = append(allAgentNames, mi.Name)
+		participants[mi.Name] = true
+		if reply, ok := replies[mi.ID]; ok {
+			repliesByName[mi.Name] = reply
+		}
+		if mm := reqMetrics.ModelMetrics[mi.ID]; mm != nil {
+			rate := getRateForModel(mi)
+			costsByName[mi.Name] = (float64(mm.TotalTokens.Input)*rate.In + float64(mm.TotalTokens.Output)*rate.Out) / 1_000_000
+		}
+	}
+	if len(repliesByName) == 0 {
+		return nil, errNoAnswers
+	}
+	anonMap := shared.CreateAnonymizationMap(allAgentNames)
+
+	logger.Info("asking meta judge for a verdict", slog.Int("jury_rankings", len(jury)))
+	prompt := shared.FormatMetaRankingPrompt(question, repliesByName, jury, anonMap, costsByName)
+
+	timeout := metaJudge.RequestTimeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Like the jury, the meta judge ranks without its persona
+	judge := *metaJudge
+	judge.Persona = ""
+	req := types.PromptRequest{Question: prompt, Meta: types.Meta{Round: 1, TotalRounds: 1}}
+	result, err := models.NewModel(&judge).Prompt(callCtx, req)
+	duration := time.Since(startTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.Log(questionTS, "meta", metaJudge.Name, prompt, result.Reply.RawContent); err != nil {
+		logger.Warn("failed to log verdict", slog.Any("error", err))
+	}
+	if participants[metaJudge.Name] {
+		if mm := reqMetrics.ModelMetrics[metaJudge.ID]; mm != nil {
+			mm.RecordRanking(duration, result.TokIn, result.TokOut)
+		}
+	}
+
+	ranking, reasons, justification := shared.ParseVerdict(result.Reply.RawContent, prompt)
+	if len(ranking) == 0 {
+		return nil, errNoVerdict
+	}
+
+	rankedJSON, _ := json.Marshal(ranking)
+	rate := getRateForModel(metaJudge)
+	record := db.Ranking{
+		RequestID:    requestID,
+		RankerModel:  metaJudge.Name,
+		RankedModels: string(rankedJSON),
+		Meta:         true,
+		Verdict:      justification,
+		DurationMs:   duration.Milliseconds(),
+		TokensIn:     int64(result.TokIn),
+		TokensOut:    int64(result.TokOut),
+		Cost:         (float64(result.TokIn)*rate.In + float64(result.TokOut)*rate.Out) / 1_000_000,
+	}
+	if len(reasons) > 0 {
+		reasonsJSON, _ := json.Marshal(reasons)
+		record.Justifications = string(reasonsJSON)
+	}
+	if err := database.SaveRanking(ctx, record); err != nil {
+		logger.Warn("failed to save verdict to database", slog.Any("error", err))
+	}
+
+	// The meta judge's ranking is a single Borda ballot; answers it left out tie for last
+	scoresByName := make(map[string]int, len(allAgentNames))
+	for _, name := range allAgentNames {
+		scoresByName[name] = 0
+	}
+	points := len(allAgentNames)
+	for _, name := range ranking {
+		if score, ok := scoresByName[name]; ok && score == 0 {
+			scoresByName[name] = points
+			points--
+		}
+	}
+	goldNames, silverNames, bronzeNames, order := shared.Medals(scoresByName)
+
+	v := &Verdict{
+		Judge:         metaJudge.Name,
+		Justification: justification,
+		Order:         placementsByID(activeModels, order),
+	}
+	v.Gold, v.Silver, v.Bronze, v.Scores = byID(activeModels, goldNames, silverNames, bronzeNames, scoresByName)
+	if len(reasons) > 0 {
+		v.Reasons = make(map[string]string, len(reasons))
+		for _, mi := range activeModels {
+			if reason, ok := reasons[mi.Name]; ok {
+				v.Reasons[mi.ID] = reason
+			}
+		}
+	}
+
+	logger.Info("meta judge gave its verdict", slog.Any("gold", goldNames), slog.Any("ranking", ranking))
+	return v, nil
+}
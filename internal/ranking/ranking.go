@@ -3,7 +3,9 @@ package ranking
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,27 +17,131 @@ import (
 	"github.com/meedamian/fat/internal/utils"
 )
 
-// RankModels executes the ranking phase where all models rank each other's responses
-// Returns gold, silver, and bronze winner IDs (can have multiple winners for ties) and scores by model ID
+// RankModels executes the ranking phase where the judges rank the participants' responses
+// judges may be models that did not take part; when empty, all participants rank each other
+// Participants judging also rank their own answer, which is recorded as their self-preference;
+// those self-votes only count towards the result when countSelfVotes is set. With justify, judges give a
+// one-line reason for every placement, stored with their ranking. With audit, every placement must have a
+// one-sentence reason: a judge leaving any answer unplaced or unjustified is asked once more, and its ranking is
+// discarded if it still does. weights scales each judge's Borda points
+// by variant name; nil counts every judge the same. strategy turns the judges' rankings into the result,
+// nil for a Borda count. positions holds the side each participant argued by model ID when the replies come from
+// a debate, which has the judges score argument quality; nil for a collaboration.
+// Returns gold, silver, and bronze winner IDs (can have multiple winners for ties), scores by model ID and
+// every model's place by ID, best first; the order is empty when no judge's ranking could be used.
 func RankModels(
 	ctx context.Context,
 	requestID string,
 	question string,
 	replies map[string]types.Reply,
+	positions map[string]string,
 	activeModels []*types.ModelInfo,
+	judges []*types.ModelInfo,
+	countSelfVotes bool,
+	justify bool,
+	audit bool,
+	weights map[string]float64,
+	strategy Strategy,
 	questionTS int64,
 	reqMetrics *metrics.RequestMetrics,
 	database *db.DB,
 	logger *slog.Logger,
-) ([]string, []string, []string, map[string]int) {
+) ([]string, []string, []string, map[string]int, []shared.Placement) {
+	gold, silver, bronze, scores, order, _ := rank(ctx, requestID, question, nil, replies, positions, activeModels, judges, countSelfVotes, justify || audit, audit, weights, strategy, questionTS, reqMetrics, database, logger)
+	return gold, silver, bronze, scores, order
+}
+
+// Section is the ranking of the answers to one sub-question of a composite question
+type Section struct {
+	Question string             `json:"question"`
+	Answers  map[string]string  `json:"answers"` // Each model's section of its final answer, by model ID
+	Gold     []string           `json:"gold"`
+	Silver   []string           `json:"silver"`
+	Bronze   []string           `json:"bronze"`
+	Scores   map[string]int     `json:"scores"` // By model ID
+	Order    []shared.Placement `json:"order"`  // Every model's place by ID, best first
+}
+
+// RankSections is RankModels for a composite question, whose answers hold a section per sub-question
+// Every judge ranks each sub-question's sections separately in a single call. The overall result counts
+// every judge's ranking of every section, so every sub-question weighs the same; the ranking stored per judge
+// and its self-preference follow the sum of its sections' Borda scores. Judges aren't asked to justify
+// section placements.
+func RankSections(
+	ctx context.Context,
+	requestID string,
+	question string,
+	subQuestions []string,
+	replies map[string]types.Reply,
+	activeModels []*types.ModelInfo,
+	judges []*types.ModelInfo,
+	countSelfVotes bool,
+	weights map[string]float64,
+	strategy Strategy,
+	questionTS int64,
+	reqMetrics *metrics.RequestMetrics,
+	database *db.DB,
+	logger *slog.Logger,
+) ([]string, []string, []string, map[string]int, []shared.Placement, []Section) {
+	return rank(ctx, requestID, question, subQuestions, replies, nil, activeModels, judges, countSelfVotes, false, false, weights, strategy, questionTS, reqMetrics, database, logger)
+}
+
+// rank runs the ranking phase of RankModels, or of RankSections when subQuestions is not empty
+func rank(
+	ctx context.Context,
+	requestID string,
+	question string,
+	subQuestions []string,
+	replies map[string]types.Reply,
+	positions map[string]string,
+	activeModels []*types.ModelInfo,
+	judges []*types.ModelInfo,
+	countSelfVotes bool,
+	justify bool,
+	audit bool,
+	weights map[string]float64,
+	strategy Strategy,
+	questionTS int64,
+	reqMetrics *metrics.RequestMetrics,
+	database *db.DB,
+	logger *slog.Logger,
+) ([]string, []string, []string, map[string]int, []shared.Placement, []Section) {
 	logger = logger.With("request_id", requestID)
-	logger.Info("starting ranking phase", slog.Int("num_models", len(activeModels)))
+	if strategy == nil {
+		strategy = strategies[Borda]
+	}
+
+	if len(judges) == 0 {
+		judges = activeModels
+	}
+	logger.Info("starting ranking phase",
+		slog.Int("num_models", len(activeModels)),
+		slog.Int("num_judges", len(judges)),
+		slog.Int("sections", len(subQuestions)))
 
 	// Remap replies to use full model names as keys (needed for ranking prompt)
 	repliesByName := make(map[string]types.Reply)
+	var answered []string // Shown to every judge, so every audited ranking must place and justify them all
+	var positionsByName map[string]string
+	if positions != nil {
+		positionsByName = make(map[string]string, len(positions))
+	}
 	for _, mi := range activeModels {
 		if reply, ok := replies[mi.ID]; ok {
 			repliesByName[mi.Name] = reply
+			answered = append(answered, mi.Name)
+		}
+		if side, ok := positions[mi.ID]; ok {
+			positionsByName[mi.Name] = side
+		}
+	}
+
+	// A composite answer is judged section by section
+	var sectionAnswers map[string][]string
+	if len(subQuestions) > 0 {
+		sectionAnswers = make(map[string][]string, len(repliesByName))
+		for name, reply := range repliesByName {
+			sectionAnswers[name] = shared.SplitSections(reply.Answer, len(subQuestions))
 		}
 	}
 
@@ -52,33 +158,42 @@ func RankModels(
 
 	// Create shared anonymization map for all models
 	allAgentNames := make([]string, 0, len(activeModels))
+	participants := make(map[string]bool, len(activeModels))
 	for _, mi := range activeModels {
 		allAgentNames = append(allAgentNames, mi.Name)
+		participants[mi.Name] = true
 	}
 	anonMap := shared.CreateAnonymizationMap(allAgentNames)
 
-	// Collect rankings from all models
+	// Collect rankings from all judges (keyed by variant, as a jury may hold several of one family)
+	// Every judge has one ranking per section, or a single one for a plain question, and an overall ranking
+	sectionRankings := make(map[string][][]string)
 	rankings := make(map[string][]string)
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	for _, mi := range activeModels {
+	for _, mi := range judges {
 		wg.Add(1)
 		go func(mi *types.ModelInfo) {
 			defer wg.Done()
 
 			startTime := time.Now()
 
-			// Calculate other agents
-			otherAgents := make([]string, 0, len(activeModels)-1)
+			// Calculate other agents - every participant except the judge itself
+			otherAgents := make([]string, 0, len(activeModels))
 			for _, m := range activeModels {
-				if m.ID != mi.ID {
+				if m.Name != mi.Name {
 					otherAgents = append(otherAgents, m.Name)
 				}
 			}
 
 			// Create ranking prompt with shared anonymization map and costs
-			prompt := shared.FormatRankingPrompt(mi.Name, question, otherAgents, repliesByName, anonMap, costsByName)
+			var prompt string
+			if len(subQuestions) > 0 {
+				prompt = shared.FormatSectionRankingPrompt(mi.Name, question, subQuestions, otherAgents, sectionAnswers, anonMap, costsByName)
+			} else {
+				prompt = shared.FormatRankingPrompt(mi.Name, question, otherAgents, repliesByName, anonMap, costsByName, positionsByName, justify)
+			}
 
 			// Create timeout context
 			timeout := mi.RequestTimeout
@@ -88,51 +203,98 @@ func RankModels(
 			callCtx, cancel := context.WithTimeout(ctx, timeout)
 			defer cancel()
 
-			// Call model for ranking
-			model := models.NewModel(mi)
+			// Call model for ranking - judges rank without their persona to stay impartial
+			judge := *mi
+			judge.Persona = ""
+			model := models.NewModel(&judge)
 			meta := types.Meta{
 				Round:       1,
 				TotalRounds: 1,
 				OtherAgents: otherAgents,
 			}
 
-			result, err := model.Prompt(callCtx, prompt, meta, make(map[string]types.Reply), make(map[string]map[string][]types.DiscussionMessage), nil)
-
-			duration := time.Since(startTime)
-
+			result, err := model.Prompt(callCtx, types.PromptRequest{Question: prompt, Meta: meta})
 			if err != nil {
 				mi.Logger.Error("ranking failed", slog.Any("error", err))
 				return
 			}
-
-			// Parse ranking from response
-			ranking := shared.ParseRanking(result.Reply.RawContent, prompt)
+			tokIn, tokOut := result.TokIn, result.TokOut
 
 			// Log ranking
 			if err := utils.Log(questionTS, "rank", mi.Name, prompt, result.Reply.RawContent); err != nil {
 				mi.Logger.Warn("failed to log ranking", slog.Any("error", err))
 			}
 
-			// Record metrics
-			mm := reqMetrics.ModelMetrics[mi.ID]
-			if mm != nil {
-				mm.RecordRanking(duration, result.TokIn, result.TokOut)
+			// Parse ranking from response
+			var sections [][]string
+			var reasons map[string]string
+			if len(subQuestions) > 0 {
+				sections = shared.ParseSectionRankings(result.Reply.RawContent, prompt, len(subQuestions))
+			} else {
+				ranking, justifications := shared.ParseJustifiedRanking(result.Reply.RawContent, prompt)
+
+				// An audited ranking must place and justify every answer, and gets one more chance to
+				var missing []string
+				if audit {
+					missing = shared.MissingReasons(ranking, justifications, answered)
+				}
+				if len(missing) > 0 {
+					mi.Logger.Warn("ranking left answers unjustified, asking again", slog.Any("missing", missing))
+					reminder := shared.FormatJustificationReminder(prompt, missing, anonMap)
+					if retried, err := model.Prompt(callCtx, types.PromptRequest{Question: reminder, Meta: meta}); err != nil {
+						mi.Logger.Error("ranking retry failed", slog.Any("error", err))
+						ranking = nil
+					} else {
+						tokIn, tokOut = tokIn+retried.TokIn, tokOut+retried.TokOut
+						if err := utils.Log(questionTS, "rank", mi.Name, reminder, retried.Reply.RawContent); err != nil {
+							mi.Logger.Warn("failed to log ranking", slog.Any("error", err))
+						}
+						ranking, justifications = shared.ParseJustifiedRanking(retried.Reply.RawContent, prompt)
+						if missing := shared.MissingReasons(ranking, justifications, answered); len(missing) > 0 {
+							mi.Logger.Warn("discarding ranking with unjustified answers", slog.Any("missing", missing))
+							ranking = nil
+						}
+					}
+				}
+				if audit {
+					for agent, reason := range justifications {
+						justifications[agent] = shared.FirstSentence(reason)
+					}
+				}
+
+				sections = [][]string{ranking}
+				if justify {
+					reasons = justifications
+				}
+			}
+			ranking := overallRanking(sections)
+			duration := time.Since(startTime)
+
+			// Record metrics - outside judges have no participant metrics; their cost is kept with the ranking
+			if participants[mi.Name] {
+				if mm := reqMetrics.ModelMetrics[mi.ID]; mm != nil {
+					mm.RecordRanking(duration, tokIn, tokOut)
+				}
 			}
 
 			// Save ranking to database
 			if len(ranking) > 0 {
 				rankedModelsJSON, _ := json.Marshal(ranking)
 				rate := getRateForModel(mi)
-				rankingCost := (float64(result.TokIn)*rate.In + float64(result.TokOut)*rate.Out) / 1_000_000
+				rankingCost := (float64(tokIn)*rate.In + float64(tokOut)*rate.Out) / 1_000_000
 				rankingRecord := db.Ranking{
 					RequestID:    requestID,
 					RankerModel:  mi.Name,
 					RankedModels: string(rankedModelsJSON),
 					DurationMs:   duration.Milliseconds(),
-					TokensIn:     int64(result.TokIn),
-					TokensOut:    int64(result.TokOut),
+					TokensIn:     int64(tokIn),
+					TokensOut:    int64(tokOut),
 					Cost:         rankingCost,
 				}
+				if len(reasons) > 0 {
+					justificationsJSON, _ := json.Marshal(reasons)
+					rankingRecord.Justifications = string(justificationsJSON)
+				}
 				if err := database.SaveRanking(ctx, rankingRecord); err != nil {
 					mi.Logger.Warn("failed to save ranking to database", slog.Any("error", err))
 				}
@@ -142,7 +304,8 @@ func RankModels(
 			if len(ranking) == 0 {
 				mi.Logger.Warn("model failed to provide ranking - likely provided answer instead")
 			} else {
-				rankings[mi.ID] = ranking
+				rankings[mi.Name] = ranking
+				sectionRankings[mi.Name] = sections
 			}
 			mu.Unlock()
 
@@ -155,11 +318,103 @@ func RankModels(
 	// Log how many valid rankings we got
 	logger.Info("aggregating rankings",
 		slog.Int("valid_rankings", len(rankings)),
-		slog.Int("total_models", len(activeModels)))
+		slog.Int("total_judges", len(judges)))
+
+	recordSelfPreference(ctx, requestID, rankings, participants, database, logger)
+
+	// Every section is aggregated on its own; the overall result counts every judge's ranking of every section
+	var sections []Section
+	combined := make(map[string][]string)
+	combinedWeights := make(map[string]float64, len(weights))
+	for i := range max(len(subQuestions), 1) {
+		byJudge := make(map[string][]string, len(sectionRankings))
+		for judge, rs := range sectionRankings {
+			if len(rs[i]) > 0 {
+				byJudge[judge] = rs[i]
+			}
+		}
+		if !countSelfVotes {
+			byJudge = shared.WithoutSelfVotes(byJudge)
+		}
+		for judge, ranking := range byJudge {
+			key := judge
+			if len(subQuestions) > 0 {
+				key = fmt.Sprintf("%s/Q%d", judge, i+1)
+			}
+			combined[key] = ranking
+			if w, ok := weights[judge]; ok {
+				combinedWeights[key] = w
+			}
+		}
+
+		if len(subQuestions) > 0 {
+			gold, silver, bronze, scores, order := strategy.Aggregate(byJudge, allAgentNames, weights)
+			gold, silver, bronze, scores = byID(activeModels, gold, silver, bronze, scores)
+			answers := make(map[string]string, len(sectionAnswers))
+			for _, mi := range activeModels {
+				if sa, ok := sectionAnswers[mi.Name]; ok {
+					answers[mi.ID] = sa[i]
+				}
+			}
+			sections = append(sections, Section{
+				Question: subQuestions[i],
+				Answers:  answers,
+				Gold:     gold,
+				Silver:   silver,
+				Bronze:   bronze,
+				Scores:   scores,
+				Order:    placementsByID(activeModels, order),
+			})
+		}
+	}
 
-	goldNames, silverNames, bronzeNames, scoresByName := shared.AggregateRankings(rankings, allAgentNames)
+	goldNames, silverNames, bronzeNames, scoresByName, orderByName := strategy.Aggregate(combined, allAgentNames, combinedWeights)
+	goldIDs, silverIDs, bronzeIDs, scoresByID := byID(activeModels, goldNames, silverNames, bronzeNames, scoresByName)
 
-	// Convert names back to IDs
+	if len(goldIDs) > 0 {
+		logger.Info("ranking complete",
+			slog.Any("gold", goldNames),
+			slog.Any("silver", silverNames),
+			slog.Any("bronze", bronzeNames))
+		return goldIDs, silverIDs, bronzeIDs, scoresByID, placementsByID(activeModels, orderByName), sections
+	}
+
+	// Fallback to first model with response
+	for _, mi := range activeModels {
+		if _, ok := replies[mi.ID]; ok {
+			logger.Warn("ranking fallback to first responder", slog.String("model", mi.ID))
+			return []string{mi.ID}, []string{}, []string{}, map[string]int{}, nil, sections
+		}
+	}
+
+	// Final fallback
+	logger.Warn("no ranking winner, returning first active model")
+	return []string{activeModels[0].ID}, []string{}, []string{}, map[string]int{}, nil, sections
+}
+
+// overallRanking orders the agents of a judge's section rankings by their summed Borda score, best first
+// A single ranking is returned as is; ties keep the order in which agents first appear.
+func overallRanking(sections [][]string) []string {
+	if len(sections) == 1 {
+		return sections[0]
+	}
+
+	scores := make(map[string]int)
+	var agents []string
+	for _, ranking := range sections {
+		for i, agent := range ranking {
+			if _, seen := scores[agent]; !seen {
+				agents = append(agents, agent)
+			}
+			scores[agent] += len(ranking) - i
+		}
+	}
+	sort.SliceStable(agents, func(i, j int) bool { return scores[agents[i]] > scores[agents[j]] })
+	return agents
+}
+
+// byID converts medal holders and scores from model names to model IDs
+func byID(activeModels []*types.ModelInfo, goldNames, silverNames, bronzeNames []string, scoresByName map[string]int) ([]string, []string, []string, map[string]int) {
 	goldIDs := make([]string, 0, len(goldNames))
 	silverIDs := make([]string, 0, len(silverNames))
 	bronzeIDs := make([]string, 0, len(bronzeNames))
@@ -188,38 +443,70 @@ func RankModels(
 		}
 	}
 
-	if len(goldIDs) > 0 {
-		logger.Info("ranking complete",
-			slog.Any("gold", goldNames),
-			slog.Any("silver", silverNames),
-			slog.Any("bronze", bronzeNames))
-		return goldIDs, silverIDs, bronzeIDs, scoresByID
-	}
+	return goldIDs, silverIDs, bronzeIDs, scoresByID
+}
 
-	// Fallback to first model with response
-	for _, mi := range activeModels {
-		if _, ok := replies[mi.ID]; ok {
-			logger.Warn("ranking fallback to first responder", slog.String("model", mi.ID))
-			return []string{mi.ID}, []string{}, []string{}, map[string]int{}
+// recordSelfPreference stores how each participating judge ranked its own answer compared to its peers
+func recordSelfPreference(ctx context.Context, requestID string, rankings map[string][]string, participants map[string]bool, database *db.DB, logger *slog.Logger) {
+	for judge, ranking := range rankings {
+		if !participants[judge] {
+			continue
+		}
+		position, self, peers, ok := shared.SelfPreference(judge, rankings)
+		if !ok {
+			continue
+		}
+		if position == 0 {
+			logger.Info("judge ranked its own answer first",
+				slog.String("judge", judge),
+				slog.Float64("peer_score", peers))
 		}
-	}
 
-	// Final fallback
-	logger.Warn("no ranking winner, returning first active model")
-	return []string{activeModels[0].ID}, []string{}, []string{}, map[string]int{}
+		sp := db.SelfPreference{
+			RequestID:    requestID,
+			JudgeModel:   judge,
+			SelfPosition: position,
+			Ranked:       len(ranking),
+			SelfScore:    self,
+			PeerScore:    peers,
+			Bias:         self - peers,
+		}
+		if err := database.SaveSelfPreference(ctx, sp); err != nil {
+			logger.Warn("failed to save self-preference", slog.String("judge", judge), slog.Any("error", err))
+		}
+	}
 }
 
 // getRateForModel retrieves the pricing rate for a model by looking up its variant
+// The run's pricing overrides, if any, are applied to the variant's list rate.
 func getRateForModel(modelInfo *types.ModelInfo) types.Rate {
-	family, ok := models.ModelFamilies[modelInfo.ID]
+	family, ok := models.ModelFamilies[modelInfo.FamilyID()]
 	if !ok {
-		return types.Rate{}
+		return modelInfo.Pricing.Apply(modelInfo.Name, types.Rate{})
 	}
 
-	variant, ok := family.Variants[modelInfo.Name]
-	if !ok {
-		return types.Rate{}
+	return modelInfo.Pricing.Apply(modelInfo.Name, family.Variants[modelInfo.Name].Rate)
+}
+
+// placementsByID maps an aggregated ordering from model names to IDs, keeping tied models sorted by ID
+func placementsByID(activeModels []*types.ModelInfo, order []shared.Placement) []shared.Placement {
+	ids := make(map[string]string, len(activeModels))
+	for _, mi := range activeModels {
+		ids[mi.Name] = mi.ID
 	}
 
-	return variant.Rate
+	placements := make([]shared.Placement, 0, len(order))
+	for _, p := range order {
+		if id, ok := ids[p.Model]; ok {
+			p.Model = id
+			placements = append(placements, p)
+		}
+	}
+	sort.SliceStable(placements, func(i, j int) bool {
+		if placements[i].Place != placements[j].Place {
+			return placements[i].Place < placements[j].Place
+		}
+		return placements[i].Model < placements[j].Model
+	})
+	return placements
 }
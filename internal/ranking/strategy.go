@@ -0,0 +1,177 @@
+package ranking
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/elo"
+	"github.com/meedamian/fat/internal/shared"
+)
+
+// Names of the built-in winner-selection strategies
+const (
+	Borda         = "borda"           // Borda count over the judges' rankings
+	Elo           = "elo"             // Every ranking is a round-robin of Elo games, from equal ratings
+	Consensus     = "consensus"       // Borda count, each judge weighed by how much the rest of the jury agrees with it
+	JudgeOfJudges = "judge-of-judges" // A meta judge reviews the jury's rankings and has the final say, see MetaRank
+	Human         = "human"           // Borda count as a provisional result, the winner is left for a person to pick
+)
+
+// DefaultStrategy selects the winner when a run doesn't name a strategy
+const DefaultStrategy = Borda
+
+// Strategy turns the judges' rankings into the final result
+// rankings are keyed by judge, best first; weights scales judges by the same key, and judges missing from it,
+// or all of them when it is nil, count fully. Returns gold, silver and bronze (tied agents share a medal),
+// scores and every agent's place, best first, all by agent name.
+type Strategy interface {
+	Aggregate(rankings map[string][]string, agents []string, weights map[string]float64) ([]string, []string, []string, map[string]int, []shared.Placement)
+}
+
+// StrategyFunc adapts a function to a Strategy
+type StrategyFunc func(rankings map[string][]string, agents []string, weights map[string]float64) ([]string, []string, []string, map[string]int, []shared.Placement)
+
+// Aggregate calls f
+func (f StrategyFunc) Aggregate(rankings map[string][]string, agents []string, weights map[string]float64) ([]string, []string, []string, map[string]int, []shared.Placement) {
+	return f(rankings, agents, weights)
+}
+
+// strategies are the strategies a run can select, by name
+var strategies = map[string]Strategy{
+	Borda:         StrategyFunc(shared.AggregateRankings),
+	Elo:           StrategyFunc(eloAggregate),
+	Consensus:     StrategyFunc(consensusAggregate),
+	JudgeOfJudges: StrategyFunc(shared.AggregateRankings), // The jury's Borda count stands if the meta judge fails
+	Human:         StrategyFunc(shared.AggregateRankings),
+}
+
+// Register makes a strategy selectable by name, replacing any registered under it before
+// Meant to be called from init, before any run starts.
+func Register(name string, strategy Strategy) {
+	strategies[name] = strategy
+}
+
+// StrategyFor returns the strategy registered under name; empty selects DefaultStrategy
+func StrategyFor(name string) (Strategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	strategy, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown winner strategy %q: must be one of %s", name, strings.Join(StrategyNames(), ", "))
+	}
+	return strategy, nil
+}
+
+// StrategyNames lists the registered strategies, sorted
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// eloAggregate rates the agents like players: every judge's ranking is a round-robin in which each agent beats
+// those it is placed above. Judges are played in name order from equal ratings, a judge's weight scaling how far
+// its games move them, and the final ratings, rounded, are the scores.
+func eloAggregate(rankings map[string][]string, agents []string, weights map[string]float64) ([]string, []string, []string, map[string]int, []shared.Placement) {
+	ratings := make(map[string]db.EloRating, len(agents))
+	for _, agent := range agents {
+		ratings[agent] = db.EloRating{ModelName: agent, Rating: elo.InitialRating}
+	}
+
+	for _, judge := range slices.Sorted(maps.Keys(rankings)) {
+		weight, ok := weights[judge]
+		if !ok {
+			weight = 1
+		}
+		var players []elo.Participant
+		for i, agent := range rankings[judge] {
+			if _, ok := ratings[agent]; ok {
+				players = append(players, elo.Participant{ModelName: agent, Score: len(rankings[judge]) - i})
+			}
+		}
+		for agent, r := range elo.Update(ratings, players, elo.K*weight) {
+			ratings[agent] = r
+		}
+	}
+
+	scores := make(map[string]int, len(ratings))
+	for agent, r := range ratings {
+		scores[agent] = int(math.Round(r.Rating))
+	}
+	gold, silver, bronze, order := shared.Medals(scores)
+	return gold, silver, bronze, scores, order
+}
+
+// consensusAggregate is a Borda count in which the jury judges its judges: each judge's weight is scaled by
+// the share of agent pairs it orders the same way as the Borda count of the other judges, so an outlier counts
+// for less. A lone judge has nobody to agree with and keeps its weight.
+func consensusAggregate(rankings map[string][]string, agents []string, weights map[string]float64) ([]string, []string, []string, map[string]int, []shared.Placement) {
+	if len(rankings) < 2 {
+		return shared.AggregateRankings(rankings, agents, weights)
+	}
+
+	adjusted := make(map[string]float64, len(rankings))
+	for judge, ranking := range rankings {
+		others := make(map[string][]string, len(rankings)-1)
+		for other, r := range rankings {
+			if other != judge {
+				others[other] = r
+			}
+		}
+		weight, ok := weights[judge]
+		if !ok {
+			weight = 1
+		}
+		adjusted[judge] = weight * pairAgreement(ranking, bordaScores(others, agents))
+	}
+	return shared.AggregateRankings(rankings, agents, adjusted)
+}
+
+// bordaScores is the unweighted Borda count of rankings, by agent
+func bordaScores(rankings map[string][]string, agents []string) map[string]int {
+	scores := make(map[string]int, len(agents))
+	for _, agent := range agents {
+		scores[agent] = 0
+	}
+	for _, ranking := range rankings {
+		points := len(agents)
+		for _, agent := range ranking {
+			if _, ok := scores[agent]; ok {
+				scores[agent] += points
+				points--
+			}
+		}
+	}
+	return scores
+}
+
+// pairAgreement is the share of pairs in ranking that scores orders the same way, a tie counting half
+// A ranking of fewer than two agents agrees fully.
+func pairAgreement(ranking []string, scores map[string]int) float64 {
+	var agreed float64
+	pairs := 0
+	for i, better := range ranking {
+		for _, worse := range ranking[i+1:] {
+			pairs++
+			switch {
+			case scores[better] > scores[worse]:
+				agreed++
+			case scores[better] == scores[worse]:
+				agreed += 0.5
+			}
+		}
+	}
+	if pairs == 0 {
+		return 1
+	}
+	return agreed / float64(pairs)
+}
@@ -0,0 +1,77 @@
+package ranking
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStrategyFor(t *testing.T) {
+	for _, name := range []string{"", Borda, Elo, Consensus, JudgeOfJudges, Human} {
+		if _, err := StrategyFor(name); err != nil {
+			t.Errorf("Expected %q to be a strategy, got %v", name, err)
+		}
+	}
+	if _, err := StrategyFor("coin-flip"); err == nil {
+		t.Error("Expected error for an unknown strategy")
+	}
+}
+
+func TestEloAggregate(t *testing.T) {
+	agents := []string{"a", "b", "c"}
+	rankings := map[string][]string{
+		"j1": {"a", "b", "c"},
+		"j2": {"a", "c", "b"},
+		"j3": {"b", "a", "c"},
+	}
+
+	gold, _, _, scores, order := eloAggregate(rankings, agents, nil)
+	if !slices.Equal(gold, []string{"a"}) {
+		t.Errorf("Expected a to win most games, got %v (scores %v)", gold, scores)
+	}
+	if len(order) != 3 || order[2].Model != "c" {
+		t.Errorf("Expected c to finish last, got %v", order)
+	}
+	if scores["a"] <= 1500 || scores["c"] >= 1500 {
+		t.Errorf("Expected ratings to move away from 1500, got %v", scores)
+	}
+
+	// Without any rankings nobody moves and everybody ties
+	gold, _, _, _, _ = eloAggregate(nil, agents, nil)
+	if len(gold) != 3 {
+		t.Errorf("Expected a three-way tie without rankings, got %v", gold)
+	}
+}
+
+func TestConsensusAggregate(t *testing.T) {
+	agents := []string{"a", "b", "c"}
+	// Two judges agree, the third ranks exactly the other way round
+	rankings := map[string][]string{
+		"j1": {"b", "a", "c"},
+		"j2": {"b", "a", "c"},
+		"j3": {"c", "a", "b"},
+	}
+
+	gold, _, _, scores, _ := consensusAggregate(rankings, agents, nil)
+	if !slices.Equal(gold, []string{"b"}) {
+		t.Errorf("Expected the agreeing judges to decide, got %v (scores %v)", gold, scores)
+	}
+	if scores["c"] >= scores["a"] {
+		t.Errorf("Expected the outlier's favourite to count for little, got %v", scores)
+	}
+
+	// A lone judge has nobody to be checked against
+	gold, _, _, _, _ = consensusAggregate(map[string][]string{"j1": {"c", "a", "b"}}, agents, nil)
+	if !slices.Equal(gold, []string{"c"}) {
+		t.Errorf("Expected a lone judge's favourite to win, got %v", gold)
+	}
+}
+
+func TestPairAgreement(t *testing.T) {
+	scores := map[string]int{"a": 3, "b": 2, "c": 2}
+	if got := pairAgreement([]string{"a", "b", "c"}, scores); got != 2.5/3 {
+		t.Errorf("Expected two agreeing pairs and a tie, got %v", got)
+	}
+	if got := pairAgreement([]string{"a"}, scores); got != 1 {
+		t.Errorf("Expected a single agent to agree fully, got %v", got)
+	}
+}
@@ -0,0 +1,212 @@
+// Package ratelimit keeps calls to each provider under its requests-per-minute and
+// tokens-per-minute limits, and caps how many of them are in flight at once. Callers over
+// a limit wait for room instead of failing, so a busy request queues up rather than
+// tripping provider 429s.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// Window is the period the limits apply to
+const Window = time.Minute
+
+// Limits are a provider's allowances; zero means unlimited
+type Limits struct {
+	RPM         int `json:"rpm"`         // Requests per minute
+	TPM         int `json:"tpm"`         // Tokens (in + out) per minute
+	Concurrency int `json:"concurrency"` // Calls in flight at once, across all running requests
+}
+
+// Limiter enforces one provider's limits over a sliding window
+type Limiter struct {
+	limits Limits
+	now    func() time.Time
+	slots  *semaphore.Weighted // nil without a concurrency limit
+
+	mu      sync.Mutex
+	entries []*Reservation // Calls started within the last Window, oldest first
+}
+
+// Reservation is a call's slot in its provider's window
+type Reservation struct {
+	limiter *Limiter
+	at      time.Time
+	tokens  int
+	waited  time.Duration
+}
+
+// Registry holds a Limiter per provider (model family ID)
+type Registry struct {
+	limiters map[string]*Limiter
+}
+
+// NewLimiter creates a limiter enforcing limits
+func NewLimiter(limits Limits) *Limiter {
+	l := &Limiter{limits: limits, now: time.Now}
+	if limits.Concurrency > 0 {
+		l.slots = semaphore.NewWeighted(int64(limits.Concurrency))
+	}
+	return l
+}
+
+// New creates a registry from provider limits; providers without limits are never throttled
+func New(limits map[string]Limits) *Registry {
+	r := &Registry{limiters: make(map[string]*Limiter, len(limits))}
+	for provider, l := range limits {
+		if l.RPM > 0 || l.TPM > 0 || l.Concurrency > 0 {
+			r.limiters[provider] = NewLimiter(l)
+		}
+	}
+	return r
+}
+
+// Load reads provider limits from a JSON file (family ID -> {"rpm": n, "tpm": n, "concurrency": n})
+// A missing file gives a registry without limits.
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var limits map[string]Limits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for provider, l := range limits {
+		if l.RPM < 0 || l.TPM < 0 || l.Concurrency < 0 {
+			return nil, fmt.Errorf("negative rate limit for %s", provider)
+		}
+	}
+
+	return New(limits), nil
+}
+
+// Wait blocks until the provider has room for a call of about tokens tokens, then reserves it
+// Returns a no-op reservation for unlimited providers or a nil registry.
+func (r *Registry) Wait(ctx context.Context, provider string, tokens int) (*Reservation, error) {
+	if r == nil || r.limiters[provider] == nil {
+		return &Reservation{}, nil
+	}
+	return r.limiters[provider].Wait(ctx, tokens)
+}
+
+// Acquire blocks until the provider has a free call slot and takes it; release gives it back
+// A call holds its slot across retries, so backing off doesn't let another call in ahead of it.
+func (r *Registry) Acquire(ctx context.Context, provider string) (release func(), err error) {
+	if r == nil || r.limiters[provider] == nil {
+		return func() {}, nil
+	}
+	return r.limiters[provider].Acquire(ctx)
+}
+
+// Acquire blocks until fewer than the allowed number of calls are in flight and takes a slot
+func (l *Limiter) Acquire(ctx context.Context) (release func(), err error) {
+	if l.slots == nil {
+		return func() {}, nil
+	}
+	if err := l.slots.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	return func() { l.slots.Release(1) }, nil
+}
+
+// Wait blocks until a call of about tokens tokens fits in the window, then reserves it
+// A call larger than the whole token allowance is let through once the window is empty.
+func (l *Limiter) Wait(ctx context.Context, tokens int) (*Reservation, error) {
+	start := l.now()
+
+	for {
+		l.mu.Lock()
+		now := l.now()
+		l.pruneLocked(now)
+
+		delay := l.delayLocked(now, tokens)
+		if delay <= 0 {
+			res := &Reservation{limiter: l, at: now, tokens: tokens, waited: now.Sub(start)}
+			l.entries = append(l.entries, res)
+			l.mu.Unlock()
+			return res, nil
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// pruneLocked drops reservations that have left the window
+func (l *Limiter) pruneLocked(now time.Time) {
+	i := 0
+	for i < len(l.entries) && now.Sub(l.entries[i].at) >= Window {
+		i++
+	}
+	l.entries = l.entries[i:]
+}
+
+// delayLocked returns how long until a call of tokens tokens fits, or 0 if it fits now
+func (l *Limiter) delayLocked(now time.Time, tokens int) time.Duration {
+	if len(l.entries) == 0 {
+		return 0
+	}
+
+	// Requests: wait for enough of the oldest calls to expire
+	var delay time.Duration
+	if l.limits.RPM > 0 && len(l.entries) >= l.limits.RPM {
+		oldest := l.entries[len(l.entries)-l.limits.RPM]
+		delay = max(delay, oldest.at.Add(Window).Sub(now))
+	}
+
+	// Tokens: wait until calls holding enough tokens expire
+	if l.limits.TPM > 0 {
+		used := 0
+		for _, e := range l.entries {
+			used += e.tokens
+		}
+		excess := used + tokens - l.limits.TPM
+		for _, e := range l.entries {
+			if excess <= 0 {
+				break
+			}
+			excess -= e.tokens
+			delay = max(delay, e.at.Add(Window).Sub(now))
+		}
+	}
+
+	return delay
+}
+
+// Settle replaces the reserved token estimate with the tokens the call actually used
+func (res *Reservation) Settle(tokens int) {
+	if res == nil || res.limiter == nil {
+		return
+	}
+
+	res.limiter.mu.Lock()
+	defer res.limiter.mu.Unlock()
+
+	res.tokens = tokens
+}
+
+// Waited is how long the call was held back before its reservation was granted
+func (res *Reservation) Waited() time.Duration {
+	if res == nil {
+		return 0
+	}
+	return res.waited
+}
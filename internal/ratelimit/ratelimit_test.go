@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeClock lets tests place reservations in the past instead of sleeping
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestLimiter(limits Limits) (*Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	l := NewLimiter(limits)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestRequestsPerMinute(t *testing.T) {
+	l, clock := newTestLimiter(Limits{RPM: 2})
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := l.Wait(ctx, 0); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+		clock.t = clock.t.Add(10 * time.Second)
+	}
+
+	l.mu.Lock()
+	delay := l.delayLocked(clock.t, 0)
+	l.mu.Unlock()
+	if delay != 40*time.Second {
+		t.Errorf("Expected third call to wait for the first to leave the window (40s), got %v", delay)
+	}
+
+	clock.t = clock.t.Add(delay)
+	if _, err := l.Wait(ctx, 0); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+}
+
+func TestTokensPerMinute(t *testing.T) {
+	l, clock := newTestLimiter(Limits{TPM: 1000})
+	ctx := context.Background()
+
+	first, err := l.Wait(ctx, 600)
+	if err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	clock.t = clock.t.Add(20 * time.Second)
+
+	l.mu.Lock()
+	delay := l.delayLocked(clock.t, 600)
+	l.mu.Unlock()
+	if delay != 40*time.Second {
+		t.Errorf("Expected a call over the token budget to wait 40s, got %v", delay)
+	}
+
+	// The first call used fewer tokens than estimated, freeing room
+	first.Settle(300)
+	l.mu.Lock()
+	delay = l.delayLocked(clock.t, 600)
+	l.mu.Unlock()
+	if delay != 0 {
+		t.Errorf("Expected settled tokens to free the budget, got delay %v", delay)
+	}
+}
+
+func TestOversizedCallRunsAlone(t *testing.T) {
+	l, clock := newTestLimiter(Limits{TPM: 100})
+
+	l.mu.Lock()
+	delay := l.delayLocked(clock.t, 5000)
+	l.mu.Unlock()
+	if delay != 0 {
+		t.Errorf("Expected a call larger than the budget to run on an empty window, got %v", delay)
+	}
+}
+
+func TestWaitHonoursContext(t *testing.T) {
+	l := NewLimiter(Limits{RPM: 1})
+	if _, err := l.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Wait(ctx, 0); err != context.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded while queued, got %v", err)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	l := NewLimiter(Limits{Concurrency: 2})
+	ctx := context.Background()
+
+	first, err := l.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if _, err := l.Acquire(ctx); err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(short); err != context.DeadlineExceeded {
+		t.Errorf("Expected a third call to wait for a free slot, got %v", err)
+	}
+
+	first()
+	if _, err := l.Acquire(ctx); err != nil {
+		t.Errorf("Expected a released slot to be free again, got %v", err)
+	}
+
+	unlimited := NewLimiter(Limits{RPM: 1})
+	for range 10 {
+		if _, err := unlimited.Acquire(ctx); err != nil {
+			t.Fatalf("Expected no concurrency limit without one set, got %v", err)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	var nilRegistry *Registry
+	if res, err := nilRegistry.Wait(context.Background(), "gpt", 100); err != nil || res.Waited() != 0 {
+		t.Errorf("Expected a nil registry not to throttle, got %v", err)
+	}
+	if release, err := nilRegistry.Acquire(context.Background(), "gpt"); err != nil {
+		t.Errorf("Expected a nil registry not to limit concurrency, got %v", err)
+	} else {
+		release()
+	}
+
+	path := filepath.Join(t.TempDir(), "ratelimits.json")
+	if err := os.WriteFile(path, []byte(`{"gpt": {"rpm": 1}, "claude": {"concurrency": 2}, "grok": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if r.limiters["gpt"] == nil || r.limiters["claude"] == nil || r.limiters["grok"] != nil {
+		t.Errorf("Expected only providers with limits to get a limiter, got %v", r.limiters)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"gpt": {"rpm": -1}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Expected error for negative limit")
+	}
+
+	if r, err := Load(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(r.limiters) != 0 {
+		t.Errorf("Expected missing file to mean no limits, got %v (err %v)", r, err)
+	}
+}
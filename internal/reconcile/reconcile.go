@@ -0,0 +1,279 @@
+// Package reconcile compares the spend fat computed from token counts with what providers bill,
+// as reported by their usage APIs, so token-accounting bugs (e.g. uncounted reasoning tokens) show up as drift.
+// Provider totals cover the whole organization behind the admin key, not only fat's calls.
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/shared"
+)
+
+// Source reports a provider's billed spend over a period
+type Source interface {
+	Family() string
+	Spend(ctx context.Context, start, end time.Time) (float64, error)
+}
+
+// Drift is one provider's computed and billed spend over a period
+type Drift struct {
+	Provider string  `json:"provider"`
+	Family   string  `json:"family"`
+	Computed float64 `json:"computed"`        // USD, from fat's stored costs
+	Billed   float64 `json:"billed"`          // USD, from the provider's usage API
+	Drift    float64 `json:"drift"`           // Billed - Computed
+	Percent  float64 `json:"percent"`         // Drift relative to Billed, 0 when nothing was billed
+	Error    string  `json:"error,omitempty"` // Why the billed spend couldn't be fetched
+}
+
+// Sources returns the usage APIs that have an admin key configured
+func Sources(openAIAdminKey, anthropicAdminKey string) []Source {
+	client := shared.NewHTTPClient(30 * time.Second)
+
+	var sources []Source
+	if openAIAdminKey != "" {
+		sources = append(sources, &OpenAI{BaseURL: "https://api.openai.com", AdminKey: openAIAdminKey, HTTP: client})
+	}
+	if anthropicAdminKey != "" {
+		sources = append(sources, &Anthropic{BaseURL: "https://api.anthropic.com", AdminKey: anthropicAdminKey, HTTP: client})
+	}
+	return sources
+}
+
+// Run compares every source's billed spend in [start, end) with the costs fat stored for the same period
+// A source that fails is reported with its error instead of failing the whole run.
+func Run(ctx context.Context, database *db.DB, sources []Source, start, end time.Time) ([]Drift, error) {
+	costs, err := database.GetCostsByVariant(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	computed := make(map[string]float64)
+	for variant, cost := range costs {
+		computed[models.FamilyForVariant(variant)] += cost
+	}
+
+	drifts := make([]Drift, 0, len(sources))
+	for _, source := range sources {
+		family := source.Family()
+		d := Drift{
+			Provider: models.ModelFamilies[family].Provider,
+			Family:   family,
+			Computed: computed[family],
+		}
+
+		billed, err := source.Spend(ctx, start, end)
+		if err != nil {
+			d.Error = err.Error()
+		} else {
+			d.Billed = billed
+			d.Drift = billed - d.Computed
+			if billed > 0 {
+				d.Percent = 100 * d.Drift / billed
+			}
+		}
+		drifts = append(drifts, d)
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		return math.Abs(drifts[i].Drift) > math.Abs(drifts[j].Drift)
+	})
+
+	return drifts, nil
+}
+
+// Start reconciles the previous UTC day every interval until ctx is done, warning about drift above
+// threshold percent. Billing data lags, so a full day that has ended is compared rather than the last hours.
+func Start(ctx context.Context, logger *slog.Logger, database *db.DB, sources []Source, interval time.Duration, threshold float64) {
+	if interval <= 0 || len(sources) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			end := time.Now().UTC().Truncate(24 * time.Hour)
+			start := end.AddDate(0, 0, -1)
+			drifts, err := Run(ctx, database, sources, start, end)
+			if err != nil {
+				logger.Warn("spend reconciliation failed", slog.Any("error", err))
+				continue
+			}
+
+			for _, d := range drifts {
+				switch {
+				case d.Error != "":
+					logger.Warn("failed to fetch billed spend",
+						slog.String("provider", d.Provider),
+						slog.String("error", d.Error))
+				case math.Abs(d.Percent) > threshold:
+					logger.Warn("spend drift",
+						slog.String("provider", d.Provider),
+						slog.String("day", start.Format(time.DateOnly)),
+						slog.Float64("computed", d.Computed),
+						slog.Float64("billed", d.Billed),
+						slog.Float64("percent", d.Percent))
+				default:
+					logger.Info("spend reconciled",
+						slog.String("provider", d.Provider),
+						slog.String("day", start.Format(time.DateOnly)),
+						slog.Float64("computed", d.Computed),
+						slog.Float64("billed", d.Billed))
+				}
+			}
+		}
+	}()
+}
+
+// OpenAI reads spend from the organization costs API, which needs an admin key
+type OpenAI struct {
+	BaseURL  string
+	AdminKey string
+	HTTP     *http.Client
+}
+
+// Family returns the model family billed by OpenAI
+func (o *OpenAI) Family() string {
+	return models.GPT
+}
+
+// Spend sums the daily cost buckets in [start, end)
+func (o *OpenAI) Spend(ctx context.Context, start, end time.Time) (float64, error) {
+	params := url.Values{
+		"start_time":   {strconv.FormatInt(start.Unix(), 10)},
+		"end_time":     {strconv.FormatInt(end.Unix(), 10)},
+		"bucket_width": {"1d"},
+		"limit":        {"180"},
+	}
+	header := http.Header{"Authorization": {"Bearer " + o.AdminKey}}
+
+	var total float64
+	for {
+		var page struct {
+			Data []struct {
+				Results []struct {
+					Amount struct {
+						Value float64 `json:"value"`
+					} `json:"amount"`
+				} `json:"results"`
+			} `json:"data"`
+			HasMore  bool   `json:"has_more"`
+			NextPage string `json:"next_page"`
+		}
+		if err := get(ctx, o.HTTP, o.BaseURL+"/v1/organization/costs?"+params.Encode(), header, &page); err != nil {
+			return 0, err
+		}
+
+		for _, bucket := range page.Data {
+			for _, r := range bucket.Results {
+				total += r.Amount.Value
+			}
+		}
+
+		if !page.HasMore || page.NextPage == "" {
+			return total, nil
+		}
+		params.Set("page", page.NextPage)
+	}
+}
+
+// Anthropic reads spend from the Admin API cost report, which needs an admin key
+type Anthropic struct {
+	BaseURL  string
+	AdminKey string
+	HTTP     *http.Client
+}
+
+// Family returns the model family billed by Anthropic
+func (a *Anthropic) Family() string {
+	return models.Claude
+}
+
+// Spend sums the cost report's daily buckets in [start, end); amounts are reported in cents
+func (a *Anthropic) Spend(ctx context.Context, start, end time.Time) (float64, error) {
+	params := url.Values{
+		"starting_at": {start.UTC().Format(time.RFC3339)},
+		"ending_at":   {end.UTC().Format(time.RFC3339)},
+	}
+	header := http.Header{
+		"X-Api-Key":         {a.AdminKey},
+		"Anthropic-Version": {"2023-06-01"},
+	}
+
+	var cents float64
+	for {
+		var page struct {
+			Data []struct {
+				Results []struct {
+					Amount string `json:"amount"`
+				} `json:"results"`
+			} `json:"data"`
+			HasMore  bool   `json:"has_more"`
+			NextPage string `json:"next_page"`
+		}
+		if err := get(ctx, a.HTTP, a.BaseURL+"/v1/organizations/cost_report?"+params.Encode(), header, &page); err != nil {
+			return 0, err
+		}
+
+		for _, bucket := range page.Data {
+			for _, r := range bucket.Results {
+				amount, err := strconv.ParseFloat(r.Amount, 64)
+				if err != nil {
+					return 0, fmt.Errorf("invalid amount %q: %w", r.Amount, err)
+				}
+				cents += amount
+			}
+		}
+
+		if !page.HasMore || page.NextPage == "" {
+			return cents / 100, nil
+		}
+		params.Set("page", page.NextPage)
+	}
+}
+
+// get fetches a usage API page and decodes it into v
+func get(ctx context.Context, client *http.Client, endpoint string, header http.Header, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("usage api returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
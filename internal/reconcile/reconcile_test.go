@@ -0,0 +1,105 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/models"
+)
+
+func TestOpenAISpend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/organization/costs" || r.Header.Get("Authorization") != "Bearer admin" {
+			t.Errorf("Unexpected request %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("page") == "" {
+			w.Write([]byte(`{"data":[{"results":[{"amount":{"value":1.25}},{"amount":{"value":0.5}}]}],"has_more":true,"next_page":"p2"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"results":[{"amount":{"value":2}}]}],"has_more":false}`))
+	}))
+	defer srv.Close()
+
+	source := &OpenAI{BaseURL: srv.URL, AdminKey: "admin", HTTP: srv.Client()}
+	spend, err := source.Spend(context.Background(), time.Now().Add(-24*time.Hour), time.Now())
+	if err != nil {
+		t.Fatalf("Spend failed: %v", err)
+	}
+	if spend != 3.75 {
+		t.Errorf("Expected 3.75 across both pages, got %v", spend)
+	}
+}
+
+func TestAnthropicSpend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "admin" || r.Header.Get("Anthropic-Version") == "" {
+			t.Errorf("Expected admin key and version headers, got %v", r.Header)
+		}
+		w.Write([]byte(`{"data":[{"results":[{"amount":"150.5","currency":"USD"}]}],"has_more":false}`))
+	}))
+	defer srv.Close()
+
+	source := &Anthropic{BaseURL: srv.URL, AdminKey: "admin", HTTP: srv.Client()}
+	spend, err := source.Spend(context.Background(), time.Now().Add(-24*time.Hour), time.Now())
+	if err != nil {
+		t.Fatalf("Spend failed: %v", err)
+	}
+	if spend != 1.505 {
+		t.Errorf("Expected 150.5 cents as $1.505, got %v", spend)
+	}
+}
+
+type fakeSource struct {
+	family string
+	spend  float64
+	err    error
+}
+
+func (f fakeSource) Family() string { return f.family }
+
+func (f fakeSource) Spend(context.Context, time.Time, time.Time) (float64, error) {
+	return f.spend, f.err
+}
+
+func TestRun(t *testing.T) {
+	dbPath := "test_reconcile.db"
+	defer os.Remove(dbPath)
+
+	database, err := db.New(dbPath, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.SaveModelRound(ctx, db.ModelRound{RequestID: "r1", ModelID: models.GPT, ModelName: models.GPT5, Round: 1, Cost: 8}); err != nil {
+		t.Fatalf("Failed to save model round: %v", err)
+	}
+
+	sources := []Source{
+		fakeSource{family: models.GPT, spend: 10},
+		fakeSource{family: models.Claude, err: errors.New("forbidden")},
+	}
+	drifts, err := Run(ctx, database, sources, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(drifts) != 2 {
+		t.Fatalf("Expected 2 providers, got %d", len(drifts))
+	}
+	gpt := drifts[0]
+	if gpt.Family != models.GPT || gpt.Computed != 8 || gpt.Billed != 10 || gpt.Drift != 2 || gpt.Percent != 20 {
+		t.Errorf("Expected GPT computed 8 vs billed 10 (20%% drift), got %+v", gpt)
+	}
+	if drifts[1].Error != "forbidden" {
+		t.Errorf("Expected the failing source to report its error, got %+v", drifts[1])
+	}
+}
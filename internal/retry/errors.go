@@ -0,0 +1,116 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimited is a 429 from a provider; RetryAfter is the wait it asked for, or 0 if it didn't say
+type RateLimited struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+// ServerError is a 5xx from a provider, worth retrying with backoff
+type ServerError struct {
+	StatusCode int
+	Err        error
+}
+
+// BadRequest is a 4xx caused by the request itself (malformed, unknown model, too long), so retrying won't help
+type BadRequest struct {
+	StatusCode int
+	Err        error
+}
+
+// AuthError is a 401 or 403 - a missing, invalid or unauthorized API key
+type AuthError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *RateLimited) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited (retry after %s): %v", e.RetryAfter, e.Err)
+	}
+	return fmt.Sprintf("rate limited: %v", e.Err)
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("server error %d: %v", e.StatusCode, e.Err)
+}
+
+func (e *BadRequest) Error() string {
+	return fmt.Sprintf("bad request %d: %v", e.StatusCode, e.Err)
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication failed %d: %v", e.StatusCode, e.Err)
+}
+
+func (e *RateLimited) Unwrap() error { return e.Err }
+func (e *ServerError) Unwrap() error { return e.Err }
+func (e *BadRequest) Unwrap() error  { return e.Err }
+func (e *AuthError) Unwrap() error   { return e.Err }
+
+// Classify wraps err from a provider HTTP response with the typed error for its status code
+// header may be nil; statuses that say nothing about retrying (e.g. 408) return err unchanged.
+func Classify(statusCode int, header http.Header, err error) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return &RateLimited{RetryAfter: retryAfter(header), Err: err}
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return &AuthError{StatusCode: statusCode, Err: err}
+	case statusCode >= 500:
+		return &ServerError{StatusCode: statusCode, Err: err}
+	case statusCode >= 400 && statusCode != http.StatusRequestTimeout && statusCode != http.StatusConflict:
+		return &BadRequest{StatusCode: statusCode, Err: err}
+	default:
+		return err
+	}
+}
+
+// modelNotFound matches provider messages about unknown, retired or inaccessible models
+var modelNotFound = regexp.MustCompile(`(?i)model_not_found|model[^.]*(not[ _]found|does not exist|decommissioned|deprecated|no longer)|(unknown|invalid) model`)
+
+// IsModelNotFound reports whether err is a provider rejecting the requested model itself,
+// e.g. a 404 or a 400 saying the model was decommissioned
+func IsModelNotFound(err error) bool {
+	var badRequest *BadRequest
+	if !errors.As(err, &badRequest) {
+		return false
+	}
+	return badRequest.StatusCode == http.StatusNotFound || modelNotFound.MatchString(err.Error())
+}
+
+// ParseRetryAfter reads a Retry-After header, given either in seconds or as an HTTP date
+// Returns 0 if the value is missing, malformed or already in the past.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
+
+// retryAfter prefers the millisecond-precision retry-after-ms header some providers send
+func retryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.Atoi(header.Get("Retry-After-Ms")); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return ParseRetryAfter(header.Get("Retry-After"), time.Now())
+}
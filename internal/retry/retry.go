@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"time"
@@ -26,6 +27,8 @@ func DefaultConfig() Config {
 }
 
 // Do executes fn with exponential backoff retry
+// Errors that IsRetryable rejects end it immediately, and a RateLimited error with a
+// RetryAfter waits that long instead of the backoff delay.
 func Do(ctx context.Context, cfg Config, fn func() error) error {
 	var lastErr error
 	
@@ -43,13 +46,25 @@ func Do(ctx context.Context, cfg Config, fn func() error) error {
 
 		lastErr = err
 
+		// Retrying won't fix a bad request or key
+		if !IsRetryable(err) {
+			return fmt.Errorf("attempt %d failed, not retrying: %w", attempt+1, err)
+		}
+
 		// Don't retry on last attempt
 		if attempt == cfg.MaxAttempts-1 {
 			break
 		}
 
-		// Calculate backoff delay
+		// Calculate backoff delay, or wait as long as the provider asked
 		delay := calculateBackoff(attempt, cfg)
+		var rateLimited *RateLimited
+		if errors.As(err, &rateLimited) && rateLimited.RetryAfter > 0 {
+			delay = rateLimited.RetryAfter
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return fmt.Errorf("retry after %s exceeds deadline: %w", delay, err)
+			}
+		}
 
 		// Wait with context awareness
 		select {
@@ -80,11 +95,17 @@ func IsRetryable(err error) bool {
 		return false
 	}
 
-	// Add specific error type checks here
-	// For now, retry all errors except context cancellation
-	if err == context.Canceled || err == context.DeadlineExceeded {
+	// Cancellation, and provider errors that will fail the same way again
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var badRequest *BadRequest
+	var authErr *AuthError
+	if errors.As(err, &badRequest) || errors.As(err, &authErr) {
 		return false
 	}
 
+	// Rate limits, server errors and unclassified (e.g. network) errors
 	return true
 }
@@ -3,6 +3,8 @@ package retry
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -203,6 +205,11 @@ func TestIsRetryable(t *testing.T) {
 		{errors.New("normal error"), true},
 		{context.Canceled, false},
 		{context.DeadlineExceeded, false},
+		{fmt.Errorf("call failed: %w", context.DeadlineExceeded), false},
+		{&RateLimited{Err: errors.New("429")}, true},
+		{&ServerError{StatusCode: 503, Err: errors.New("503")}, true},
+		{&BadRequest{StatusCode: 400, Err: errors.New("400")}, false},
+		{fmt.Errorf("wrapped: %w", &AuthError{StatusCode: 401, Err: errors.New("401")}), false},
 	}
 
 	for _, tt := range tests {
@@ -232,10 +239,214 @@ func TestDoWithNonRetryableError(t *testing.T) {
 		t.Error("Expected error, got nil")
 	}
 
-	// Should still retry even with non-retryable error
-	// because IsRetryable is only used as a hint in the actual implementation
-	if attempts < 1 {
-		t.Errorf("Expected at least 1 attempt, got %d", attempts)
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error to wrap context.Canceled, got %v", err)
+	}
+}
+
+func TestDoFailsFastOnClientErrors(t *testing.T) {
+	ctx := context.Background()
+	cfg := Config{
+		MaxAttempts:  3,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     100 * time.Millisecond,
+		Multiplier:   2.0,
+	}
+
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+		attempts := 0
+		err := Do(ctx, cfg, func() error {
+			attempts++
+			return Classify(status, nil, errors.New("rejected"))
+		})
+
+		if err == nil {
+			t.Errorf("Status %d: expected error, got nil", status)
+		}
+
+		if attempts != 1 {
+			t.Errorf("Status %d: expected 1 attempt, got %d", status, attempts)
+		}
+	}
+}
+
+func TestDoRetriesServerErrors(t *testing.T) {
+	ctx := context.Background()
+	cfg := Config{
+		MaxAttempts:  3,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     100 * time.Millisecond,
+		Multiplier:   2.0,
+	}
+
+	attempts := 0
+	err := Do(ctx, cfg, func() error {
+		attempts++
+		return Classify(http.StatusBadGateway, nil, errors.New("upstream down"))
+	})
+
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) || serverErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected ServerError 502, got %v", err)
+	}
+
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDoHonorsRetryAfter(t *testing.T) {
+	ctx := context.Background()
+	cfg := Config{
+		MaxAttempts:  2,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     100 * time.Millisecond,
+		Multiplier:   2.0,
+	}
+
+	start := time.Now()
+	attempts := 0
+	err := Do(ctx, cfg, func() error {
+		attempts++
+		if attempts == 1 {
+			return &RateLimited{RetryAfter: 200 * time.Millisecond, Err: errors.New("slow down")}
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	// Retry-After overrides the 10ms backoff, even beyond MaxDelay
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected to wait at least 200ms, got %v", elapsed)
+	}
+}
+
+func TestDoRetryAfterBeyondDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	cfg := Config{
+		MaxAttempts:  3,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     100 * time.Millisecond,
+		Multiplier:   2.0,
+	}
+
+	start := time.Now()
+	attempts := 0
+	err := Do(ctx, cfg, func() error {
+		attempts++
+		return &RateLimited{RetryAfter: time.Minute, Err: errors.New("slow down")}
+	})
+
+	var rateLimited *RateLimited
+	if !errors.As(err, &rateLimited) {
+		t.Errorf("Expected RateLimited error, got %v", err)
+	}
+
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+
+	// Gives up right away rather than sleeping until the deadline
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected to give up immediately, took %v", elapsed)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	base := errors.New("api error")
+	header := http.Header{"Retry-After": []string{"7"}}
+
+	tests := []struct {
+		status    int
+		retryable bool
+		check     func(error) bool
+	}{
+		{http.StatusTooManyRequests, true, func(err error) bool {
+			var e *RateLimited
+			return errors.As(err, &e) && e.RetryAfter == 7*time.Second
+		}},
+		{http.StatusInternalServerError, true, func(err error) bool { var e *ServerError; return errors.As(err, &e) }},
+		{529, true, func(err error) bool { var e *ServerError; return errors.As(err, &e) }},
+		{http.StatusBadRequest, false, func(err error) bool { var e *BadRequest; return errors.As(err, &e) }},
+		{http.StatusNotFound, false, func(err error) bool { var e *BadRequest; return errors.As(err, &e) }},
+		{http.StatusUnauthorized, false, func(err error) bool { var e *AuthError; return errors.As(err, &e) }},
+		{http.StatusForbidden, false, func(err error) bool { var e *AuthError; return errors.As(err, &e) }},
+		{http.StatusRequestTimeout, true, func(err error) bool { return err == base }},
+	}
+
+	for _, tt := range tests {
+		err := Classify(tt.status, header, base)
+		if !tt.check(err) {
+			t.Errorf("Classify(%d): unexpected error %#v", tt.status, err)
+		}
+		if !errors.Is(err, base) {
+			t.Errorf("Classify(%d): expected error to wrap the original", tt.status)
+		}
+		if IsRetryable(err) != tt.retryable {
+			t.Errorf("Classify(%d): expected retryable %v", tt.status, tt.retryable)
+		}
+	}
+
+	var rateLimited *RateLimited
+	err := Classify(http.StatusTooManyRequests, http.Header{"Retry-After-Ms": []string{"1500"}, "Retry-After": []string{"2"}}, base)
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 1500*time.Millisecond {
+		t.Errorf("Expected retry-after-ms to take precedence, got %v", err)
+	}
+
+	if err := Classify(http.StatusTooManyRequests, nil, base); !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 0 {
+		t.Errorf("Expected RateLimited without a wait for a nil header, got %v", err)
+	}
+}
+
+func TestIsModelNotFound(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{Classify(http.StatusNotFound, nil, errors.New("not found")), true},
+		{Classify(http.StatusBadRequest, nil, errors.New("The model `llama3-70b` has been decommissioned")), true},
+		{Classify(http.StatusBadRequest, nil, errors.New(`{"error":{"code":"model_not_found"}}`)), true},
+		{Classify(http.StatusBadRequest, nil, errors.New("max_tokens is too large")), false},
+		{Classify(http.StatusInternalServerError, nil, errors.New("model not found")), false},
+		{errors.New("model not found"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsModelNotFound(tt.err); got != tt.want {
+			t.Errorf("IsModelNotFound(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{" 5 ", 5 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		if result := ParseRetryAfter(tt.value, now); result != tt.expected {
+			t.Errorf("ParseRetryAfter(%q): expected %v, got %v", tt.value, tt.expected, result)
+		}
 	}
 }
 
@@ -0,0 +1,302 @@
+// Package scorer runs operator-defined metrics over final answers: an external command or an HTTP
+// endpoint receives each answer and returns a number, e.g. a BLEU score against a reference, the share of
+// unit tests a generated function passes or a custom rubric. Scores are shown next to the judges' rankings.
+package scorer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meedamian/fat/internal/shared"
+)
+
+// DefaultTimeout bounds a scorer that doesn't set its own timeout
+const DefaultTimeout = 30 * time.Second
+
+// maxOutput caps how much of a scorer's output is read
+const maxOutput = 64 << 10
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Scorer is one metric, computed by either a shell command or an HTTP endpoint
+// Both receive an Input as JSON - on stdin, or as a POST body - and answer with a bare number
+// or a JSON object with a numeric "score".
+type Scorer struct {
+	Name    string `json:"name"`
+	Command string `json:"command,omitempty"` // Run through the platform's shell
+	URL     string `json:"url,omitempty"`
+	Timeout string `json:"timeout,omitempty"` // Go duration, DefaultTimeout if empty
+
+	timeout time.Duration
+}
+
+// Input is what a scorer is given for each final answer
+type Input struct {
+	RequestID string `json:"request_id"`
+	Question  string `json:"question"`
+	ModelID   string `json:"model_id"`
+	ModelName string `json:"model_name"`
+	Answer    string `json:"answer"`
+}
+
+// Result is one scorer's verdict on one answer; Error is set instead of Value when it failed
+type Result struct {
+	ModelID string  `json:"model_id"`
+	Metric  string  `json:"metric"`
+	Value   float64 `json:"value"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Set is every configured scorer
+type Set struct {
+	scorers []Scorer
+	http    *http.Client
+}
+
+// Load reads scorers from a JSON file holding an array of them
+// A missing file gives nil, which scores nothing.
+func Load(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var scorers []Scorer
+	if err := json.Unmarshal(data, &scorers); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return New(scorers)
+}
+
+// New validates scorers; names must be unique and each needs exactly one of command and url
+func New(scorers []Scorer) (*Set, error) {
+	seen := make(map[string]bool, len(scorers))
+	longest := DefaultTimeout
+	for i := range scorers {
+		sc := &scorers[i]
+		if !validName.MatchString(sc.Name) {
+			return nil, fmt.Errorf("scorer %d: name %q must be letters, digits, '.', '_' or '-'", i+1, sc.Name)
+		}
+		if seen[sc.Name] {
+			return nil, fmt.Errorf("scorer %q is defined twice", sc.Name)
+		}
+		seen[sc.Name] = true
+
+		if (sc.Command == "") == (sc.URL == "") {
+			return nil, fmt.Errorf("scorer %q needs either a command or a url", sc.Name)
+		}
+
+		sc.timeout = DefaultTimeout
+		if sc.Timeout != "" {
+			d, err := time.ParseDuration(sc.Timeout)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("scorer %q: invalid timeout %q", sc.Name, sc.Timeout)
+			}
+			sc.timeout = d
+		}
+		longest = max(longest, sc.timeout)
+	}
+
+	// Each call is bounded by its scorer's own timeout; the client's only has to let the longest through
+	return &Set{scorers: scorers, http: shared.NewHTTPClient(longest)}, nil
+}
+
+// Score runs every scorer over every answer concurrently
+// Results are ordered by model ID and then metric name; a scorer failing only fails its own results.
+func (s *Set) Score(ctx context.Context, inputs []Input) []Result {
+	if s == nil || len(s.scorers) == 0 {
+		return nil
+	}
+
+	results := make([]Result, 0, len(inputs)*len(s.scorers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, in := range inputs {
+		for _, sc := range s.scorers {
+			wg.Add(1)
+			go func(sc Scorer, in Input) {
+				defer wg.Done()
+				r := Result{ModelID: in.ModelID, Metric: sc.Name}
+				value, err := s.run(ctx, sc, in)
+				if err != nil {
+					r.Error = err.Error()
+				} else {
+					r.Value = value
+				}
+				mu.Lock()
+				results = append(results, r)
+				mu.Unlock()
+			}(sc, in)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].ModelID != results[j].ModelID {
+			return results[i].ModelID < results[j].ModelID
+		}
+		return results[i].Metric < results[j].Metric
+	})
+	return results
+}
+
+// run computes one scorer's value for one answer
+func (s *Set) run(ctx context.Context, sc Scorer, in Input) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, sc.timeout)
+	defer cancel()
+
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return 0, err
+	}
+
+	var out []byte
+	if sc.Command != "" {
+		out, err = runCommand(ctx, sc.Command, payload)
+	} else {
+		out, err = s.post(ctx, sc.URL, payload)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return ParseScore(out)
+}
+
+// errOutputTooLong is returned for a command whose stdout outgrew maxOutput, which got it killed
+var errOutputTooLong = fmt.Errorf("command output over %d bytes", maxOutput)
+
+// runCommand runs command through the platform's shell with payload on stdin and returns its stdout
+// A command writing more than maxOutput is killed rather than buffered.
+func runCommand(ctx context.Context, command string, payload []byte) ([]byte, error) {
+	ctx, kill := context.WithCancel(ctx)
+	defer kill()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	// Children the shell started may hold stdout open after it was killed
+	cmd.WaitDelay = time.Second
+
+	stdout := &limitedBuffer{limit: maxOutput, onExceed: kill}
+	stderr := &limitedBuffer{limit: maxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if stdout.exceeded {
+			return nil, errOutputTooLong
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("command timed out: %w", ctx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("command failed: %w: %s", err, truncate(msg, 200))
+		}
+		return nil, fmt.Errorf("command failed: %w", err)
+	}
+	if stdout.exceeded {
+		return nil, errOutputTooLong
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest, calling onExceed once they overflow
+// It must not embed bytes.Buffer, whose ReadFrom would let io.Copy bypass the limit.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	exceeded bool
+	onExceed func()
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		if !b.exceeded && b.onExceed != nil {
+			b.onExceed()
+		}
+		b.exceeded = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte  { return b.buf.Bytes() }
+func (b *limitedBuffer) String() string { return b.buf.String() }
+
+// post sends payload to url and returns the response body
+func (s *Set) post(ctx context.Context, url string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOutput))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, truncate(strings.TrimSpace(string(body)), 200))
+	}
+	return body, nil
+}
+
+// ParseScore reads a scorer's output: a bare number, or a JSON object with a numeric "score"
+func ParseScore(out []byte) (float64, error) {
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		return 0, errors.New("no score returned")
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		var decoded struct {
+			Score *float64 `json:"score"`
+		}
+		if json.Unmarshal([]byte(text), &decoded) != nil || decoded.Score == nil {
+			return 0, fmt.Errorf("expected a number or {\"score\": n}, got %q", truncate(text, 80))
+		}
+		value = *decoded.Score
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("score %q is not a finite number", text)
+	}
+	return value, nil
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
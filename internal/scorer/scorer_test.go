@@ -0,0 +1,147 @@
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	if s, err := Load(filepath.Join(t.TempDir(), "missing.json")); s != nil || err != nil {
+		t.Errorf("Expected no scorers and no error for a missing file, got %v, %v", s, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "scorers.json")
+	os.WriteFile(path, []byte(`[{"name": "bleu", "command": "python3 bleu.py", "timeout": "2m"}]`), 0644)
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load scorers: %v", err)
+	}
+	if len(s.scorers) != 1 || s.scorers[0].timeout.Minutes() != 2 {
+		t.Errorf("Expected one scorer with a 2m timeout, got %+v", s.scorers)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		scorers []Scorer
+	}{
+		{"bad name", []Scorer{{Name: "bl eu", Command: "true"}}},
+		{"duplicate", []Scorer{{Name: "bleu", Command: "true"}, {Name: "bleu", URL: "http://localhost"}}},
+		{"neither", []Scorer{{Name: "bleu"}}},
+		{"both", []Scorer{{Name: "bleu", Command: "true", URL: "http://localhost"}}},
+		{"bad timeout", []Scorer{{Name: "bleu", Command: "true", Timeout: "soon"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.scorers); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseScore(t *testing.T) {
+	tests := []struct {
+		out     string
+		want    float64
+		wantErr bool
+	}{
+		{"0.42\n", 0.42, false},
+		{`{"score": 7, "detail": "7/10 tests"}`, 7, false},
+		{"", 0, true},
+		{"passed", 0, true},
+		{`{"value": 1}`, 0, true},
+		{"NaN", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseScore([]byte(tt.out))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseScore(%q) = %v, %v; want %v, error %v", tt.out, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scorer commands are shell snippets")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in Input
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.RequestID != "req-1" {
+			t.Errorf("Unexpected input %+v (%v)", in, err)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"score": len(in.Answer)})
+	}))
+	defer srv.Close()
+
+	s, err := New([]Scorer{
+		{Name: "length", URL: srv.URL},
+		{Name: "mentions-paris", Command: `grep -q Paris && echo 1 || echo 0`},
+		{Name: "broken", Command: "echo oops >&2; exit 3"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create scorers: %v", err)
+	}
+
+	results := s.Score(context.Background(), []Input{
+		{RequestID: "req-1", ModelID: "grok", Answer: "Paris"},
+		{RequestID: "req-1", ModelID: "gpt", Answer: "Lyon, France"},
+	})
+	if len(results) != 6 {
+		t.Fatalf("Expected 6 results, got %+v", results)
+	}
+
+	got := make(map[string]Result, len(results))
+	for _, r := range results {
+		got[r.ModelID+"/"+r.Metric] = r
+	}
+	if r := got["grok/length"]; r.Value != 5 || r.Error != "" {
+		t.Errorf("Expected grok's length 5 from the endpoint, got %+v", r)
+	}
+	if got["grok/mentions-paris"].Value != 1 || got["gpt/mentions-paris"].Value != 0 {
+		t.Errorf("Expected the command to read the answer on stdin, got %+v and %+v", got["grok/mentions-paris"], got["gpt/mentions-paris"])
+	}
+	if r := got["gpt/broken"]; !strings.Contains(r.Error, "oops") {
+		t.Errorf("Expected the failing command's stderr in its error, got %+v", r)
+	}
+	if results[0].ModelID != "gpt" || results[0].Metric != "broken" {
+		t.Errorf("Expected results sorted by model and metric, got %+v", results[0])
+	}
+
+	var none *Set
+	if r := none.Score(context.Background(), []Input{{ModelID: "grok"}}); r != nil {
+		t.Errorf("Expected no results without scorers, got %+v", r)
+	}
+}
+
+func TestRunCommandOutputLimit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scorer commands are shell snippets")
+	}
+
+	start := time.Now()
+	if _, err := runCommand(context.Background(), "yes", nil); !errors.Is(err, errOutputTooLong) {
+		t.Errorf("Expected an endless command to be cut off, got %v", err)
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("Expected the command killed once over the limit, took %s", took)
+	}
+
+	out, err := runCommand(context.Background(), "head -c 1000 /dev/zero", nil)
+	if err != nil || len(out) != 1000 {
+		t.Errorf("Expected output under the limit kept whole, got %d bytes (%v)", len(out), err)
+	}
+}
@@ -0,0 +1,270 @@
+// Package search runs the web searches agents ask for in a "# SEARCH" section of their reply.
+// Results are fed back to the agent in the next round, so it can check facts it is unsure of.
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/meedamian/fat/internal/shared"
+)
+
+// Supported search APIs
+const (
+	SearxNG = "searxng"
+	Brave   = "brave"
+	Tavily  = "tavily"
+)
+
+// maxSnippet bounds each result's snippet so a handful of results can't flood the prompt
+const maxSnippet = 500
+
+// Default endpoints of the hosted APIs; SearxNG is self-hosted and needs a URL
+var defaultEndpoints = map[string]string{
+	Brave:  "https://api.search.brave.com/res/v1/web/search",
+	Tavily: "https://api.tavily.com/search",
+}
+
+// Result is a single search hit
+type Result struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// Client queries one search API
+type Client struct {
+	provider string
+	endpoint string
+	apiKey   string
+	limit    int
+	http     *http.Client
+}
+
+// New creates a client for provider; an empty provider disables search and returns nil
+// endpoint overrides the API URL (required for SearxNG, the instance's base URL)
+func New(provider, endpoint, apiKey string, limit int) (*Client, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return nil, nil
+	}
+
+	switch provider {
+	case SearxNG:
+		if endpoint == "" {
+			return nil, fmt.Errorf("%s needs the instance URL", provider)
+		}
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/search"
+	case Brave, Tavily:
+		if apiKey == "" {
+			return nil, fmt.Errorf("%s needs an API key", provider)
+		}
+		if endpoint == "" {
+			endpoint = defaultEndpoints[provider]
+		}
+	default:
+		return nil, fmt.Errorf("unknown search provider %q (use %s, %s or %s)", provider, SearxNG, Brave, Tavily)
+	}
+
+	if limit < 1 {
+		limit = 5
+	}
+
+	return &Client{
+		provider: provider,
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		limit:    limit,
+		http:     shared.NewHTTPClient(20 * time.Second),
+	}, nil
+}
+
+// Provider returns the name of the search API in use
+func (c *Client) Provider() string {
+	return c.provider
+}
+
+// Search runs query and returns at most the configured number of results
+func (c *Client) Search(ctx context.Context, query string) ([]Result, error) {
+	var results []Result
+	var err error
+	switch c.provider {
+	case SearxNG:
+		results, err = c.searxng(ctx, query)
+	case Brave:
+		results, err = c.brave(ctx, query)
+	case Tavily:
+		results, err = c.tavily(ctx, query)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s search failed: %w", c.provider, err)
+	}
+
+	if len(results) > c.limit {
+		results = results[:c.limit]
+	}
+	for i := range results {
+		results[i].Snippet = truncate(strings.TrimSpace(results[i].Snippet), maxSnippet)
+	}
+	return results, nil
+}
+
+func (c *Client) searxng(ctx context.Context, query string) ([]Result, error) {
+	params := url.Values{"q": {query}, "format": {"json"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	var resp struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
+
+func (c *Client) brave(ctx context.Context, query string) ([]Result, error) {
+	params := url.Values{"q": {query}, "count": {fmt.Sprint(c.limit)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Subscription-Token", c.apiKey)
+
+	var resp struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(resp.Web.Results))
+	for _, r := range resp.Web.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: stripTags(r.Description)})
+	}
+	return results, nil
+}
+
+func (c *Client) tavily(ctx context.Context, query string) ([]Result, error) {
+	body, err := json.Marshal(map[string]any{"query": query, "max_results": c.limit})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	var resp struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
+
+// do sends req and decodes a successful JSON response into v
+func (c *Client) do(req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Format renders the results of one query for a prompt
+func Format(query string, results []Result, err error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", query)
+
+	switch {
+	case err != nil:
+		b.WriteString("(Search failed)\n\n")
+	case len(results) == 0:
+		b.WriteString("(No results)\n\n")
+	}
+
+	for i, r := range results {
+		fmt.Fprintf(&b, "%d. %s\n   %s\n", i+1, r.Title, r.URL)
+		if r.Snippet != "" {
+			fmt.Fprintf(&b, "   %s\n", strings.Join(strings.Fields(r.Snippet), " "))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// stripTags removes the <strong> highlighting some APIs put in snippets
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
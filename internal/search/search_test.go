@@ -0,0 +1,130 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	if c, err := New("", "", "", 5); c != nil || err != nil {
+		t.Errorf("Expected no client and no error without a provider, got %v, %v", c, err)
+	}
+	if _, err := New("searxng", "", "", 5); err == nil {
+		t.Error("Expected error for SearxNG without a URL")
+	}
+	if _, err := New("brave", "", "", 5); err == nil {
+		t.Error("Expected error for Brave without an API key")
+	}
+	if _, err := New("bing", "", "key", 5); err == nil {
+		t.Error("Expected error for an unknown provider")
+	}
+
+	c, err := New(" Tavily ", "", "key", 0)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if c.Provider() != Tavily || c.endpoint != defaultEndpoints[Tavily] || c.limit != 5 {
+		t.Errorf("Expected tavily client with default endpoint and limit, got %+v", c)
+	}
+}
+
+func TestSearchSearxNG(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("q") != "go generics" || r.URL.Query().Get("format") != "json" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"results": []map[string]string{
+			{"title": "One", "url": "https://one.example", "content": "first"},
+			{"title": "Two", "url": "https://two.example", "content": "second"},
+			{"title": "Three", "url": "https://three.example", "content": "third"},
+		}})
+	}))
+	defer srv.Close()
+
+	c, err := New(SearxNG, srv.URL+"/", "", 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	results, err := c.Search(context.Background(), "go generics")
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 || results[0] != (Result{Title: "One", URL: "https://one.example", Snippet: "first"}) {
+		t.Errorf("Expected the first 2 results, got %+v", results)
+	}
+}
+
+func TestSearchBrave(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Subscription-Token") != "key" {
+			t.Errorf("Expected subscription token, got %q", r.Header.Get("X-Subscription-Token"))
+		}
+		json.NewEncoder(w).Encode(map[string]any{"web": map[string]any{"results": []map[string]string{
+			{"title": "Go", "url": "https://go.dev", "description": "The <strong>Go</strong> language"},
+		}}})
+	}))
+	defer srv.Close()
+
+	c, _ := New(Brave, srv.URL, "key", 5)
+	results, err := c.Search(context.Background(), "golang")
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Snippet != "The Go language" {
+		t.Errorf("Expected snippet without tags, got %+v", results)
+	}
+}
+
+func TestSearchTavily(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != http.MethodPost || body["query"] != "golang" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("Unexpected request %s %v", r.Method, body)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"results": []map[string]string{
+			{"title": "Go", "url": "https://go.dev", "content": strings.Repeat("x", maxSnippet+10)},
+		}})
+	}))
+	defer srv.Close()
+
+	c, _ := New(Tavily, srv.URL, "key", 5)
+	results, err := c.Search(context.Background(), "golang")
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || len([]rune(results[0].Snippet)) != maxSnippet+1 {
+		t.Errorf("Expected one truncated result, got %+v", results)
+	}
+}
+
+func TestSearchError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c, _ := New(Brave, srv.URL, "key", 5)
+	if _, err := c.Search(context.Background(), "golang"); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	out := Format("go", []Result{{Title: "Go", URL: "https://go.dev", Snippet: "The Go\nlanguage"}}, nil)
+	if !strings.Contains(out, "## go") || !strings.Contains(out, "1. Go\n   https://go.dev\n   The Go language\n") {
+		t.Errorf("Unexpected format:\n%s", out)
+	}
+
+	if out := Format("go", nil, errors.New("boom")); !strings.Contains(out, "(Search failed)") {
+		t.Errorf("Expected failure notice, got:\n%s", out)
+	}
+	if out := Format("go", nil, nil); !strings.Contains(out, "(No results)") {
+		t.Errorf("Expected no results notice, got:\n%s", out)
+	}
+}
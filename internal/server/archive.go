@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/meedamian/fat/internal/storage"
+	"github.com/meedamian/fat/internal/tarball"
+)
+
+// archiveDir holds tarballs of exports moved out of h/, served under /h/archive/; relative to the data directory
+const archiveDir = "h/archive"
+
+// archiveLink is a tarball or a file in one, as listed under /h/archive/
+type archiveLink struct {
+	Name string
+	Href string
+	Size int64 // 0 for tarballs
+}
+
+var archiveListing = template.Must(template.New("archive").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}</title>
+    <style>
+        body { background: #0a0a0f; color: #e4e4e7; font-family: system-ui, sans-serif; padding: 40px 20px; max-width: 900px; margin: 0 auto; }
+        a { color: #7c5cff; }
+        li { margin-bottom: 6px; }
+        .meta, .empty { color: #71717a; }
+    </style>
+</head>
+<body>
+    <h1>🗄️ {{.Title}}</h1>
+    <p><a href="{{.Up}}">↑ Up</a></p>
+{{- if not .Links}}
+    <p class="empty">Nothing archived yet.</p>
+{{- else}}
+    <ul>
+    {{- range .Links}}
+        <li><a href="{{.Href}}">{{.Name}}</a>{{if .Size}} <span class="meta">{{.Size}} bytes</span>{{end}}</li>
+    {{- end}}
+    </ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// serveArchive lists the tarballs in h/archive, the files in one, or serves a file out of one
+// rest is the path after /h/archive, e.g. "/2025-01.tar.gz/2025-01-02/1504_slug.html".
+func (s *Server) serveArchive(c *gin.Context, rest string) {
+	name, entry, _ := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+
+	if name == "" {
+		tarballs, err := s.archives.Tarballs()
+		if err != nil {
+			c.String(500, "Error reading archives: %v", err)
+			return
+		}
+		links := make([]archiveLink, 0, len(tarballs))
+		for _, t := range tarballs {
+			links = append(links, archiveLink{Name: t, Href: "/h/archive/" + t + "/"})
+		}
+		s.renderArchiveListing(c, "Archived Sessions", "/h/", links)
+		return
+	}
+
+	if entry == "" {
+		entries, err := s.archives.List(name)
+		if errors.Is(err, tarball.ErrNotFound) {
+			c.String(404, err.Error())
+			return
+		}
+		if err != nil {
+			c.String(500, "Error reading archive: %v", err)
+			return
+		}
+		links := make([]archiveLink, 0, len(entries))
+		for _, e := range entries {
+			links = append(links, archiveLink{Name: e.Name, Href: "/h/archive/" + name + "/" + e.Name, Size: e.Size})
+		}
+		s.renderArchiveListing(c, name, "/h/archive/", links)
+		return
+	}
+
+	data, err := s.archives.Read(name, entry)
+	if errors.Is(err, tarball.ErrNotFound) {
+		c.String(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.String(500, "Error reading archive: %v", err)
+		return
+	}
+	c.Data(200, storage.ContentType(entry), data)
+}
+
+func (s *Server) renderArchiveListing(c *gin.Context, title, up string, links []archiveLink) {
+	var b bytes.Buffer
+	if err := archiveListing.Execute(&b, map[string]any{"Title": title, "Up": up, "Links": links}); err != nil {
+		c.String(500, "Error rendering archive: %v", err)
+		return
+	}
+	c.Data(200, "text/html; charset=utf-8", b.Bytes())
+}
+
+// exportFile is where an export is on disk, given its path relative to h/
+func (s *Server) exportFile(file string) string {
+	return filepath.Join(s.config.DataDir, "h", filepath.FromSlash(file))
+}
+
+// archivedURL returns where an export missing from h/ is served out of an archive, or "" if no tarball holds it
+// file is the export's path relative to h/.
+func (s *Server) archivedURL(file string) string {
+	file = path.Clean(file)
+	if _, err := os.Stat(s.exportFile(file)); err == nil {
+		return ""
+	}
+	t, err := s.archives.Find(file)
+	if err != nil {
+		return ""
+	}
+	return "/h/archive/" + t + "/" + file
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/orchestrator"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// AskResult identifies a run finished by Ask
+type AskResult struct {
+	RequestID  string // Chosen before the run starts, and passed to progress first as an accepted message
+	QuestionTS int64  // Names the run's exports in h/
+}
+
+// Ask runs one question to completion without serving HTTP, passing every message the run emits to progress
+// picks selects the participants by family ID (its default variant) or variant name; none means every family.
+// tag files the run under a question set for benchmark tracking, empty for none. {{answer:<request-id>}}
+// references in the question are resolved first.
+func (s *Server) Ask(ctx context.Context, question string, rounds int, picks []string, tag string, progress func(map[string]any)) (AskResult, error) {
+	run, err := s.prepareRun(ctx, question, picks, tag)
+	if err != nil {
+		return AskResult{}, err
+	}
+	question = run.question
+
+	result := AskResult{RequestID: uuid.New().String(), QuestionTS: time.Now().Unix()}
+	progress(map[string]any{
+		"type":       "accepted",
+		"request_id": result.RequestID,
+		"question":   question,
+	})
+
+	var runErr string
+	finished := false
+	s.clientsMutex.Lock()
+	s.listener = func(message map[string]any) {
+		switch message["type"] {
+		case "winner":
+			finished = true
+		case "error":
+			// Errors without a model are about the request itself, e.g. a full queue
+			if _, ok := message["model"]; !ok {
+				runErr, _ = message["error"].(string)
+			}
+		}
+		progress(message)
+	}
+	s.clientsMutex.Unlock()
+
+	s.orchestrator.ProcessQuestion(ctx, result.RequestID, question, rounds, run.activeModels, run.judges, run.metaJudge, run.synthesizer, result.QuestionTS, run.opts)
+
+	// The exports are written in the background; callers read them once Ask returns
+	exportErr := s.orchestrator.WaitExports(ctx)
+
+	s.clientsMutex.Lock()
+	s.listener = nil
+	s.clientsMutex.Unlock()
+
+	if runErr != "" {
+		return result, errors.New(runErr)
+	}
+	if !finished {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		return result, errors.New("run ended without a winner")
+	}
+	return result, exportErr
+}
+
+// preparedRun is a question with everything the orchestrator needs to run it
+type preparedRun struct {
+	question     string // With its answer references resolved
+	activeModels []*types.ModelInfo
+	judges       []*types.ModelInfo
+	metaJudge    *types.ModelInfo
+	synthesizer  *types.ModelInfo
+	opts         orchestrator.Options
+}
+
+// prepareRun picks the participants and judges of a question run outside the web UI, under the configured defaults
+// picks are families or variants; several variants of a family pit them against each other.
+func (s *Server) prepareRun(ctx context.Context, question string, picks []string, tag string) (preparedRun, error) {
+	var variants []string
+	if len(picks) == 0 {
+		for familyID := range models.ModelFamilies {
+			variants = append(variants, s.defaultVariant(familyID))
+		}
+	}
+	seen := make(map[string]bool, len(picks))
+	for _, pick := range picks {
+		variant := pick
+		if _, ok := models.ModelFamilies[pick]; ok {
+			variant = s.defaultVariant(pick)
+		} else if models.FamilyForVariant(pick) == "" {
+			return preparedRun{}, fmt.Errorf("unknown model %q", pick)
+		}
+		if !seen[variant] {
+			seen[variant] = true
+			variants = append(variants, variant)
+		}
+	}
+	activeModels := s.buildActiveModels(models.AgentIDs(variants))
+	if len(activeModels) == 0 {
+		return preparedRun{}, errors.New("no models to ask")
+	}
+
+	question, err := s.orchestrator.ResolveAnswerReferences(ctx, question)
+	if err != nil {
+		return preparedRun{}, err
+	}
+
+	opts := orchestrator.Options{Tag: tag}
+	pricing, err := s.pricing(nil)
+	if err != nil {
+		return preparedRun{}, err
+	}
+	opts.Pricing = pricing
+
+	judges := s.buildJudges(s.config.Judges)
+	for _, mi := range judges {
+		opts.Judges = append(opts.Judges, mi.Name)
+	}
+	if opts.Strategy, err = s.selectedStrategy(nil); err != nil {
+		return preparedRun{}, err
+	}
+	opts.Audit = s.config.AuditRankings
+	opts.Verify = s.config.Verification
+	metaJudge, err := s.selectedMetaJudge(nil, opts.Strategy)
+	if err != nil {
+		return preparedRun{}, err
+	}
+	if metaJudge != nil {
+		opts.MetaJudge = metaJudge.Name
+	}
+	synthesizer, err := s.selectedSynthesizer(nil)
+	if err != nil {
+		return preparedRun{}, err
+	}
+	if synthesizer != nil {
+		opts.Synthesizer = synthesizer.Name
+	}
+
+	return preparedRun{question: question, activeModels: activeModels, judges: judges, metaJudge: metaJudge, synthesizer: synthesizer, opts: opts}, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/meedamian/fat/internal/db"
+)
+
+// auditJudge is what one judge's ranking of a request cost
+type auditJudge struct {
+	Judge     string  `json:"judge"`
+	TokensOut int64   `json:"tokens_out"`
+	Cost      float64 `json:"cost"`
+}
+
+// handleAudit lists why each judge of a request placed every answer where it did, and what its ranking cost
+func (s *Server) handleAudit(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.Param("id")
+
+	req, err := s.database.GetRequest(ctx, requestID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if req == nil {
+		c.JSON(404, gin.H{"error": db.ErrRequestNotFound.Error()})
+		return
+	}
+
+	rankings, err := s.database.GetRequestRankings(ctx, requestID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	judges := make([]auditJudge, 0, len(rankings))
+	for _, r := range rankings {
+		judges = append(judges, auditJudge{Judge: r.RankerModel, TokensOut: r.TokensOut, Cost: r.Cost})
+	}
+
+	justifications, err := s.database.GetJustifications(ctx, requestID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if justifications == nil {
+		justifications = []db.Justification{}
+	}
+
+	c.JSON(200, gin.H{
+		"request_id":     requestID,
+		"judges":         judges,
+		"justifications": justifications,
+	})
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/meedamian/fat/internal/auth"
+)
+
+// userKey is where requireAuth leaves the authenticated user on the gin context
+const userKey = "user"
+
+// requireAuth refuses requests without the bearer token or a valid access key, while either is configured
+func (s *Server) requireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := s.auth.Check(c.Request.Context(), auth.Credential(c.Request))
+		if errors.Is(err, auth.ErrUnauthorized) {
+			c.Header("WWW-Authenticate", `Bearer realm="fat"`)
+			c.AbortWithStatusJSON(401, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		if user != "" {
+			c.Set(userKey, user)
+		}
+		c.Next()
+	}
+}
+
+// authorizeWS checks the credential a WebSocket connection was opened with before it may ask anything,
+// telling the client why not otherwise
+func (s *Server) authorizeWS(conn *websocket.Conn, ctx context.Context) bool {
+	s.clientsMutex.Lock()
+	client, ok := s.clients[conn]
+	s.clientsMutex.Unlock()
+	if !ok {
+		return false
+	}
+
+	_, err := s.auth.Check(ctx, client.credential)
+	if err == nil {
+		return true
+	}
+	s.send(conn, map[string]any{
+		"type":         "error",
+		"error":        err.Error(),
+		"unauthorized": errors.Is(err, auth.ErrUnauthorized),
+	})
+	return false
+}
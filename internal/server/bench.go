@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/meedamian/fat/internal/benchmark"
+	"github.com/meedamian/fat/internal/constants"
+)
+
+// suiteRequest is the body of POST /benchmarks/:tag/run
+type suiteRequest struct {
+	Questions []string `json:"questions"` // Empty runs every sample question
+	Rounds    int      `json:"rounds"`    // 3 if not set
+	Models    []string `json:"models"`    // Families or variants; empty means every family
+}
+
+// handleRunSuite starts running a question set one question at a time under the tag, answering right away
+// The runs go through the queue like any other; GET /benchmarks/:tag/report follows the results as they come in.
+func (s *Server) handleRunSuite(c *gin.Context) {
+	var req suiteRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+	if len(req.Questions) == 0 {
+		req.Questions = constants.SampleQuestions
+	}
+	if req.Rounds == 0 {
+		req.Rounds = 3
+	}
+	if req.Rounds < 3 || req.Rounds > 10 {
+		c.JSON(400, gin.H{"error": "rounds must be between 3 and 10"})
+		return
+	}
+
+	// Bad picks fail every question alike, so they are rejected before any runs
+	tag := c.Param("tag")
+	if _, err := s.prepareRun(c.Request.Context(), req.Questions[0], req.Models, tag); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Detach from the HTTP request - the suite outlives it
+	go s.runSuite(context.Background(), req.Questions, req.Rounds, req.Models, tag)
+
+	c.JSON(202, gin.H{
+		"tag":       tag,
+		"questions": len(req.Questions),
+		"rounds":    req.Rounds,
+	})
+}
+
+// runSuite runs questions one after another, tagged with tag, skipping any that can't be prepared
+func (s *Server) runSuite(ctx context.Context, questions []string, rounds int, picks []string, tag string) {
+	logger := s.logger.With("tag", tag)
+	logger.Info("benchmark suite started", slog.Int("questions", len(questions)))
+
+	for i, question := range questions {
+		run, err := s.prepareRun(ctx, question, picks, tag)
+		if err != nil {
+			logger.Warn("skipping benchmark question", slog.Int("question", i+1), slog.Any("error", err))
+			continue
+		}
+		requestID := uuid.New().String()
+		logger.Info("running benchmark question", slog.Int("question", i+1), slog.String("request_id", requestID))
+		s.orchestrator.ProcessQuestion(ctx, requestID, run.question, rounds, run.activeModels, run.judges, run.metaJudge, run.synthesizer, time.Now().Unix(), run.opts)
+	}
+
+	logger.Info("benchmark suite finished", slog.Int("questions", len(questions)))
+}
+
+// handleSuiteReport reports per-model win rates, cost and latency over the runs tagged tag, as JSON or ?format=csv
+func (s *Server) handleSuiteReport(c *gin.Context) {
+	report, err := benchmark.Suite(c.Request.Context(), s.database, c.Param("tag"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", "attachment; filename=\""+report.Tag+".csv\"")
+		if err := report.WriteCSV(c.Writer); err != nil {
+			s.logger.Warn("failed to write suite report", slog.Any("error", err))
+		}
+		return
+	}
+	c.JSON(200, report)
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/meedamian/fat/internal/costs"
+)
+
+// handleCosts reports spend and tokens per provider and model in day, week or month buckets
+// ?from= and ?to= limit it to a range of UTC dates, both included.
+func (s *Server) handleCosts(c *gin.Context) {
+	granularity := c.DefaultQuery("granularity", costs.Day)
+	switch granularity {
+	case costs.Day, costs.Week, costs.Month:
+	default:
+		c.JSON(400, gin.H{"error": "granularity must be day, week or month"})
+		return
+	}
+
+	var start, end time.Time
+	for _, param := range []string{"from", "to"} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		date, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": param + " must be a date like 2025-01-31"})
+			return
+		}
+		if param == "from" {
+			start = date
+		} else {
+			end = date.AddDate(0, 0, 1)
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		c.JSON(400, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	report, err := costs.Build(c.Request.Context(), s.database, granularity, start, end)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, report)
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/htmlexport"
+	"github.com/meedamian/fat/internal/storage"
+	"github.com/meedamian/fat/internal/utils"
+)
+
+// exportExtensions are the files a completed request can leave in h/, and in remote storage alongside its JSON export
+var exportExtensions = []string{"html", "svg", "md", "json"}
+
+// handleDeleteRequest hides a request from the history and exports and removes its exported files
+// With ?redact=true its text is scrubbed from the database too, along with its conversation logs.
+func (s *Server) handleDeleteRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.Param("id")
+
+	redact := false
+	if r := c.Query("redact"); r != "" {
+		parsed, err := strconv.ParseBool(r)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "redact must be true or false"})
+			return
+		}
+		redact = parsed
+	}
+
+	deleted, err := s.database.DeleteRequest(ctx, requestID, redact)
+	if errors.Is(err, db.ErrRequestNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The database is already updated, so every file is attempted even if one fails
+	var failed []error
+	if deleted.QuestionTS != 0 && deleted.Question != db.RedactedText {
+		slug := htmlexport.Slug(deleted.Question)
+		for _, ext := range exportExtensions {
+			path := htmlexport.OutputPath(deleted.QuestionTS, slug, ext)
+			if err := os.Remove(filepath.Join(s.config.DataDir, path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				failed = append(failed, err)
+			}
+			if s.store != nil {
+				if err := s.store.Delete(ctx, storage.Key(path)); err != nil {
+					failed = append(failed, fmt.Errorf("remote %s: %w", storage.Key(path), err))
+				}
+			}
+		}
+	}
+	if redact && deleted.QuestionTS != 0 {
+		if err := utils.RemoveLogs(deleted.QuestionTS); err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if err := s.diagnostics.Remove(requestID); err != nil {
+		failed = append(failed, err)
+	}
+
+	if err := errors.Join(failed...); err != nil {
+		s.logger.Warn("request deleted, but not all of its files were removed",
+			slog.String("request_id", requestID), slog.Any("error", err))
+		c.JSON(500, gin.H{"error": fmt.Sprintf("request deleted, but not all of its files were removed: %v", err)})
+		return
+	}
+
+	s.logger.Info("request deleted", slog.String("request_id", requestID), slog.Bool("redacted", redact))
+	c.JSON(200, gin.H{
+		"request_id": requestID,
+		"deleted":    true,
+		"redacted":   redact,
+	})
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/meedamian/fat/internal/embed"
+	"github.com/meedamian/fat/internal/jsonexport"
+	"github.com/meedamian/fat/internal/site"
+	"github.com/meedamian/fat/internal/stats"
+)
+
+// serveRequestEmbed renders a completed request's minimal view for other sites to iframe
+func (s *Server) serveRequestEmbed(c *gin.Context) {
+	requestID := c.Param("id")
+	doc, err := jsonexport.Build(c.Request.Context(), s.database, requestID)
+	if errors.Is(err, jsonexport.ErrNotFound) {
+		c.String(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.String(500, "Error reading request: %v", err)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := embed.RenderRequest(&b, doc, "/h/"+site.PagePath(requestID), s.locale); err != nil {
+		c.String(500, "Error rendering request: %v", err)
+		return
+	}
+	c.Data(200, "text/html; charset=utf-8", b.Bytes())
+}
+
+// serveLeaderboardEmbed renders the top of the leaderboard for other sites to iframe
+func (s *Server) serveLeaderboardEmbed(c *gin.Context) {
+	entries, err := stats.Leaderboard(c.Request.Context(), s.database)
+	if err != nil {
+		c.String(500, "Error reading leaderboard: %v", err)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := embed.RenderLeaderboard(&b, entries, "/"); err != nil {
+		c.String(500, "Error rendering leaderboard: %v", err)
+		return
+	}
+	c.Data(200, "text/html; charset=utf-8", b.Bytes())
+}
+
+// handleOEmbed answers oEmbed requests for request pages and the leaderboard with an iframe of their embed
+func (s *Server) handleOEmbed(c *gin.Context) {
+	if format := c.Query("format"); format != "" && format != "json" {
+		c.JSON(501, gin.H{"error": "only the json format is supported"})
+		return
+	}
+
+	var maxSize [2]int
+	for i, param := range []string{"maxwidth", "maxheight"} {
+		if v := c.Query(param); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 {
+				c.JSON(400, gin.H{"error": param + " must be a positive integer"})
+				return
+			}
+			maxSize[i] = parsed
+		}
+	}
+
+	target, err := embed.ParseURL(c.Query("url"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	title := "fat leaderboard"
+	if target.RequestID != "" {
+		req, err := s.database.GetRequest(c.Request.Context(), target.RequestID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		if req == nil {
+			c.JSON(404, gin.H{"error": "request not found"})
+			return
+		}
+		title = embed.Title(req.Question)
+	}
+
+	c.JSON(200, embed.Respond(target, title, maxSize[0], maxSize[1]))
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/meedamian/fat/internal/estimate"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// handleEstimate predicts the cost and duration of the run a question message would start
+// The body takes the fields of a WebSocket question message: question, rounds, models, judges, pricing and generation.
+func (s *Server) handleEstimate(c *gin.Context) {
+	var msg map[string]any
+	if err := c.ShouldBindJSON(&msg); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body: " + err.Error()})
+		return
+	}
+
+	est, err := s.estimate(c.Request.Context(), msg)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, est)
+}
+
+// handleEstimateWS answers an "estimate" message with the estimate of the question it carries
+func (s *Server) handleEstimateWS(conn *websocket.Conn, ctx context.Context, msg map[string]any) {
+	est, err := s.estimate(ctx, msg)
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+
+	s.send(conn, map[string]any{
+		"type":     "estimate",
+		"estimate": est,
+	})
+}
+
+// estimate builds the participants and jury a question message selects, then estimates their run
+func (s *Server) estimate(ctx context.Context, msg map[string]any) (estimate.Estimate, error) {
+	question, subQuestions, err := messageQuestion(msg, s.config.MaxQuestionLength)
+	if err != nil {
+		return estimate.Estimate{}, err
+	}
+	if len(subQuestions) > 0 {
+		question = shared.FormatSubQuestions(question, subQuestions)
+	}
+	question, err = s.resolveQuestion(ctx, question)
+	if err != nil {
+		return estimate.Estimate{}, err
+	}
+
+	rounds, err := messageRounds(msg)
+	if err != nil {
+		return estimate.Estimate{}, err
+	}
+	variants, err := s.selectedVariants(msg)
+	if err != nil {
+		return estimate.Estimate{}, err
+	}
+	judgeNames, err := s.selectedJudges(msg)
+	if err != nil {
+		return estimate.Estimate{}, err
+	}
+	activeModels := s.buildActiveModels(variants)
+	judges := s.buildJudges(judgeNames)
+
+	pricing, err := s.pricing(msg["pricing"])
+	if err != nil {
+		return estimate.Estimate{}, err
+	}
+	overrides, err := generationOverrides(msg["generation"])
+	if err != nil {
+		return estimate.Estimate{}, err
+	}
+	for _, mis := range [][]*types.ModelInfo{activeModels, judges} {
+		applyGeneration(mis, overrides)
+		for _, mi := range mis {
+			mi.Pricing = pricing
+		}
+	}
+
+	history, err := s.callHistory(ctx)
+	if err != nil {
+		return estimate.Estimate{}, err
+	}
+
+	return estimate.Run(question, rounds, activeModels, judges, history), nil
+}
+
+// callHistory returns per-call averages by variant from past rounds, and each family's response time from its stats
+func (s *Server) callHistory(ctx context.Context) (map[string]estimate.History, error) {
+	averages, err := s.database.GetCallAverages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.database.GetAllModelStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make(map[string]estimate.History, len(averages)+len(stats))
+	for variant, a := range averages {
+		history[variant] = estimate.History{Calls: a.Calls, TokensOut: a.AvgTokensOut, DurationMs: a.AvgDurationMs}
+	}
+	for _, st := range stats {
+		history[st.ModelID] = estimate.History{DurationMs: float64(st.AvgResponseTimeMs)}
+	}
+	return history, nil
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/meedamian/fat/internal/faults"
+	"github.com/meedamian/fat/internal/models"
+)
+
+// handleGetFaults shows the faults being injected into provider calls
+func (s *Server) handleGetFaults(c *gin.Context) {
+	current := faults.Current()
+	c.JSON(200, gin.H{"faults": current, "enabled": current.Enabled()})
+}
+
+// handleSetFaults replaces the faults injected into provider calls, starting with the next call
+// Fields left out are off, so a body of {} turns every fault off.
+func (s *Server) handleSetFaults(c *gin.Context) {
+	var cfg faults.Config
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": "invalid faults: " + err.Error()})
+		return
+	}
+	for _, model := range cfg.Models {
+		if _, ok := models.ModelFamilies[model]; !ok && models.FamilyForVariant(model) == "" {
+			c.JSON(400, gin.H{"error": "invalid faults: unknown family or variant " + model})
+			return
+		}
+	}
+
+	faults.Set(cfg)
+	if cfg.Enabled() {
+		s.logger.Warn("injecting faults into provider calls", slog.String("faults", cfg.String()))
+	} else {
+		s.logger.Info("fault injection turned off")
+	}
+	c.JSON(200, gin.H{"faults": cfg, "enabled": cfg.Enabled()})
+}
+
+// handleClearFaults stops injecting faults into provider calls
+func (s *Server) handleClearFaults(c *gin.Context) {
+	faults.Set(faults.Config{})
+	s.logger.Info("fault injection turned off")
+	c.JSON(200, gin.H{"faults": faults.Config{}, "enabled": false})
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/htmlexport"
+	"github.com/meedamian/fat/internal/jsonexport"
+	"github.com/meedamian/fat/internal/site"
+)
+
+// History page sizes
+const (
+	historyPageSize    = 50
+	historyMaxPageSize = 200
+)
+
+// historyItem is a request as listed by the history API
+type historyItem struct {
+	ID          string    `json:"id"`
+	Question    string    `json:"question"`
+	Tag         string    `json:"tag,omitempty"`
+	FollowsUp   string    `json:"parent_request_id,omitempty"`
+	WinnerModel string    `json:"winner_model"`
+	NumRounds   int       `json:"num_rounds"`
+	NumModels   int       `json:"num_models"`
+	TotalCost   float64   `json:"total_cost"`
+	ErrorCount  int       `json:"error_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	Export      string    `json:"export,omitempty"` // URL of the static HTML export, if it's still on disk
+}
+
+// historyQuery reads the page and filters of the history API
+// Dates are YYYY-MM-DD in UTC, and both ends of the range are inclusive.
+func historyQuery(c *gin.Context) (db.HistoryFilter, int, error) {
+	f := db.HistoryFilter{
+		Model:  c.Query("model"),
+		Winner: c.Query("winner"),
+		Limit:  historyPageSize,
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return f, 0, errors.New("from must be a YYYY-MM-DD date")
+		}
+		f.From = t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return f, 0, errors.New("to must be a YYYY-MM-DD date")
+		}
+		f.To = t.AddDate(0, 0, 1)
+	}
+
+	if pp := c.Query("per_page"); pp != "" {
+		parsed, err := strconv.Atoi(pp)
+		if err != nil || parsed < 1 || parsed > historyMaxPageSize {
+			return f, 0, fmt.Errorf("per_page must be between 1 and %d", historyMaxPageSize)
+		}
+		f.Limit = parsed
+	}
+
+	page := 1
+	if p := c.Query("page"); p != "" {
+		parsed, err := strconv.Atoi(p)
+		if err != nil || parsed < 1 {
+			return f, 0, errors.New("page must be a positive integer")
+		}
+		page = parsed
+	}
+	f.Offset = (page - 1) * f.Limit
+
+	return f, page, nil
+}
+
+// exportURL returns where a request's static HTML export is served, or "" if it's neither on disk nor archived
+func (s *Server) exportURL(e db.HistoryEntry) string {
+	if e.QuestionTS == 0 {
+		return ""
+	}
+
+	path := htmlexport.OutputPath(e.QuestionTS, htmlexport.Slug(e.Question), "html")
+	if _, err := os.Stat(filepath.Join(s.config.DataDir, path)); err != nil {
+		return s.archivedURL(strings.TrimPrefix(filepath.ToSlash(path), "h/"))
+	}
+	return "/" + filepath.ToSlash(path)
+}
+
+// handleHistory lists past requests, newest first, one page at a time
+func (s *Server) handleHistory(c *gin.Context) {
+	f, page, err := historyQuery(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, total, err := s.database.GetHistory(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]historyItem, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, historyItem{
+			ID:          e.ID,
+			Question:    e.Question,
+			Tag:         e.Tag,
+			FollowsUp:   e.ParentRequestID,
+			WinnerModel: e.WinnerModel,
+			NumRounds:   e.NumRounds,
+			NumModels:   e.NumModels,
+			TotalCost:   e.TotalCost,
+			ErrorCount:  e.ErrorCount,
+			CreatedAt:   e.CreatedAt,
+			Export:      s.exportURL(e),
+		})
+	}
+
+	c.JSON(200, gin.H{
+		"requests": items,
+		"total":    total,
+		"page":     page,
+		"per_page": f.Limit,
+	})
+}
+
+// handleHistorySession returns a past request reconstructed from the database
+func (s *Server) handleHistorySession(c *gin.Context) {
+	doc, err := jsonexport.Build(c.Request.Context(), s.database, c.Param("id"))
+	if errors.Is(err, jsonexport.ErrNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, doc)
+}
+
+// serveHistoryListing renders every past request as the answers site's index, the same page `fat export-site` writes
+func (s *Server) serveHistoryListing(c *gin.Context) {
+	entries, _, err := s.database.GetHistory(c.Request.Context(), db.HistoryFilter{})
+	if err != nil {
+		c.String(500, "Error reading history: %v", err)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := site.RenderIndex(&b, entries, s.locale); err != nil {
+		c.String(500, "Error rendering history: %v", err)
+		return
+	}
+	c.Data(200, "text/html; charset=utf-8", b.Bytes())
+}
+
+// serveHistoryPage renders a past request's page of the answers site, linking its HTML export if that's still on disk
+func (s *Server) serveHistoryPage(c *gin.Context, requestID string) {
+	doc, err := jsonexport.Build(c.Request.Context(), s.database, requestID)
+	if errors.Is(err, jsonexport.ErrNotFound) {
+		c.String(404, err.Error())
+		return
+	}
+	if err != nil {
+		c.String(500, "Error reading request: %v", err)
+		return
+	}
+
+	export := ""
+	if state, err := s.database.GetRequestState(c.Request.Context(), requestID); err == nil && state != nil {
+		export = s.exportURL(db.HistoryEntry{Request: db.Request{Question: doc.Request.Question}, QuestionTS: state.QuestionTS})
+	}
+
+	var b bytes.Buffer
+	if err := site.RenderPage(&b, doc, export, s.locale); err != nil {
+		c.String(500, "Error rendering request: %v", err)
+		return
+	}
+	c.Data(200, "text/html; charset=utf-8", b.Bytes())
+}
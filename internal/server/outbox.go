@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Messages to a WebSocket client are queued and written by a goroutine of its own, so a client on a slow
+// network never holds up a run or the other clients. Once its queue is full, progress messages that only
+// report the latest state replace their pending predecessor instead of piling up behind it.
+const (
+	sendQueueSize = 64               // Pending messages before superseded progress messages are dropped
+	maxSendQueue  = 1024             // Pending messages before the client is considered gone and disconnected
+	writeTimeout  = 10 * time.Second // How long one write may take
+)
+
+// supersedable are the message types a newer message of the same request and model makes obsolete;
+// queue messages carry the current position, heartbeat the calls a round still waits on
+var supersedable = map[string]bool{
+	"queue":     true,
+	"heartbeat": true,
+}
+
+// outgoing is a queued message
+type outgoing struct {
+	data  []byte
+	key   string // What the message supersedes, empty if it must be delivered
+	close bool   // data is a close frame, sent once everything before it was, before disconnecting
+}
+
+// supersedeKey identifies the pending messages a message makes obsolete, "" for those that must all arrive
+func supersedeKey(message map[string]any) string {
+	msgType, _ := message["type"].(string)
+	if !supersedable[msgType] {
+		return ""
+	}
+	requestID, _ := message["request_id"].(string)
+	model, _ := message["model"].(string)
+	return msgType + "\x00" + requestID + "\x00" + model
+}
+
+// enqueue queues a message for the writer, reporting false once the client fell too far behind to keep
+func (c *wsClient) enqueue(msg outgoing) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) >= sendQueueSize && msg.key != "" {
+		for i, p := range c.pending {
+			if p.key == msg.key {
+				c.pending = append(c.pending[:i], c.pending[i+1:]...)
+				c.dropped++
+				break
+			}
+		}
+	}
+	if len(c.pending) >= maxSendQueue && !msg.close {
+		return false
+	}
+	c.pending = append(c.pending, msg)
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// send queues a message for the client
+func (c *wsClient) send(message map[string]any) bool {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return true
+	}
+	return c.enqueue(outgoing{data: data, key: supersedeKey(message)})
+}
+
+// take empties the queue
+func (c *wsClient) take() ([]outgoing, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, dropped := c.pending, c.dropped
+	c.pending, c.dropped = nil, 0
+	return pending, dropped
+}
+
+// send queues a message for conn, disconnecting it if it fell too far behind
+func (s *Server) send(conn *websocket.Conn, message map[string]any) {
+	s.clientsMutex.Lock()
+	defer s.clientsMutex.Unlock()
+
+	if client, ok := s.clients[conn]; ok && !client.send(message) {
+		s.dropClientLocked(conn)
+	}
+}
+
+// dropClientLocked disconnects a client whose queue overflowed; the caller holds clientsMutex
+func (s *Server) dropClientLocked(conn *websocket.Conn) {
+	s.logger.Warn("websocket client too far behind, disconnecting", slog.Int("queued", maxSendQueue))
+	conn.Close()
+	delete(s.clients, conn)
+}
+
+// writeLoop writes a client's queued messages until the connection fails or the client is done
+func (s *Server) writeLoop(conn *websocket.Conn, client *wsClient) {
+	for {
+		select {
+		case <-client.done:
+			return
+		case <-client.wake:
+		}
+
+		pending, dropped := client.take()
+		if dropped > 0 {
+			s.logger.Debug("dropped superseded websocket messages", slog.Int("dropped", dropped))
+		}
+		for _, msg := range pending {
+			if msg.close {
+				if err := conn.WriteControl(websocket.CloseMessage, msg.data, time.Now().Add(time.Second)); err != nil {
+					s.logger.Debug("websocket close frame failed", slog.Any("error", err))
+				}
+				conn.Close()
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, msg.data); err != nil {
+				s.logger.Warn("websocket write failed", slog.Any("error", err))
+				conn.Close() // Ends the read loop, which removes the client
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnqueueSupersedesStaleProgress(t *testing.T) {
+	client := newWSClient("")
+	client.send(map[string]any{"type": "queue", "request_id": "req", "position": 3})
+	for i := range sendQueueSize {
+		client.send(map[string]any{"type": "response", "request_id": "req", "round": i})
+	}
+	client.send(map[string]any{"type": "queue", "request_id": "req", "position": 1})
+
+	pending, dropped := client.take()
+	if len(pending) != sendQueueSize+1 || dropped != 1 {
+		t.Fatalf("Expected the stale queue message dropped and every response kept, got %d pending and %d dropped", len(pending), dropped)
+	}
+
+	var positions []float64
+	for _, msg := range pending {
+		var message map[string]any
+		if err := json.Unmarshal(msg.data, &message); err != nil {
+			t.Fatal(err)
+		}
+		if message["type"] == "queue" {
+			positions = append(positions, message["position"].(float64))
+		}
+	}
+	if len(positions) != 1 || positions[0] != 1 {
+		t.Errorf("Expected only the latest queue position, got %v", positions)
+	}
+
+	// Below the threshold every message is delivered, superseded or not
+	client.send(map[string]any{"type": "queue", "request_id": "req", "position": 2})
+	client.send(map[string]any{"type": "queue", "request_id": "req", "position": 1})
+	if pending, _ := client.take(); len(pending) != 2 {
+		t.Errorf("Expected both queue messages with room in the queue, got %d", len(pending))
+	}
+}
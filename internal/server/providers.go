@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/meedamian/fat/internal/apikeys"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/shared"
+)
+
+// Outcomes of checking a provider's key
+const (
+	authOK      = "ok"
+	authInvalid = "invalid" // The provider rejected the key
+	authMissing = "missing" // No key configured
+	authError   = "error"   // The provider couldn't be reached or answered unexpectedly
+)
+
+// providerHealth is the outcome of checking one provider
+type providerHealth struct {
+	Family      string   `json:"family"`
+	Provider    string   `json:"provider"`
+	Auth        string   `json:"auth"`
+	LatencyMS   int64    `json:"latency_ms,omitempty"` // Of the model list call
+	Error       string   `json:"error,omitempty"`
+	Default     string   `json:"default"`
+	Variants    []string `json:"variants,omitempty"`    // Catalog variants the provider lists
+	Unavailable []string `json:"unavailable,omitempty"` // Catalog variants it doesn't
+}
+
+// handleProvidersHealth checks every provider live, answering 503 if a configured key doesn't work
+func (s *Server) handleProvidersHealth(c *gin.Context) {
+	providers := s.checkProviders(c.Request.Context())
+
+	healthy := true
+	for _, p := range providers {
+		if p.Auth == authInvalid || p.Auth == authError {
+			healthy = false
+		}
+	}
+
+	status := 200
+	if !healthy {
+		status = 503
+	}
+	c.JSON(status, gin.H{
+		"healthy":   healthy,
+		"providers": providers,
+	})
+}
+
+// checkProviders lists every family's models with its key in parallel, sorted by family
+func (s *Server) checkProviders(ctx context.Context) []providerHealth {
+	ctx, cancel := context.WithTimeout(ctx, keyValidationTimeout)
+	defer cancel()
+	client := shared.NewHTTPClient(keyValidationTimeout)
+
+	familyIDs := make([]string, 0, len(models.ModelFamilies))
+	for familyID := range models.ModelFamilies {
+		familyIDs = append(familyIDs, familyID)
+	}
+	sort.Strings(familyIDs)
+
+	var wg sync.WaitGroup
+	results := make([]providerHealth, len(familyIDs))
+	for i, familyID := range familyIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.checkProvider(ctx, client, familyID)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// checkProvider lists a family's models to check its key and which catalog variants are offered
+func (s *Server) checkProvider(ctx context.Context, client *http.Client, familyID string) providerHealth {
+	family := models.ModelFamilies[familyID]
+	health := providerHealth{
+		Family:   familyID,
+		Provider: family.Provider,
+		Default:  s.defaultVariant(familyID),
+	}
+
+	key := apikeys.GetForFamily(familyID)
+	if key == "" {
+		health.Auth = authMissing
+		return health
+	}
+
+	start := time.Now()
+	listed, err := apikeys.ListModels(ctx, client, familyID, key)
+	health.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		health.Auth = authError
+		if errors.Is(err, apikeys.ErrInvalidKey) {
+			health.Auth = authInvalid
+		}
+		health.Error = err.Error()
+		return health
+	}
+
+	health.Auth = authOK
+	for variant := range family.Variants {
+		if apikeys.Serves(listed, variant) {
+			health.Variants = append(health.Variants, variant)
+		} else {
+			health.Unavailable = append(health.Unavailable, variant)
+		}
+	}
+	sort.Strings(health.Variants)
+	sort.Strings(health.Unavailable)
+
+	return health
+}
+
+// preflight checks every provider before serving and logs what would make runs fail
+func (s *Server) preflight(ctx context.Context) {
+	s.logger.Info("checking providers")
+	for _, p := range s.checkProviders(ctx) {
+		logger := s.logger.With(slog.String("family", p.Family))
+		switch p.Auth {
+		case authOK:
+			if slices.Contains(p.Unavailable, p.Default) {
+				logger.Warn("default variant not listed by provider", slog.String("variant", p.Default))
+				continue
+			}
+			logger.Info("provider ready", slog.Int64("latency_ms", p.LatencyMS))
+		case authMissing:
+			// Already reported when the keys were loaded
+		default:
+			logger.Warn("provider check failed", slog.String("auth", p.Auth), slog.String("error", p.Error))
+		}
+	}
+}
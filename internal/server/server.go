@@ -1,29 +1,57 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
-	"math/rand"
 	"net/http"
-	"os"
 	"path/filepath"
-	"sort"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/meedamian/fat/internal/answerschema"
 	"github.com/meedamian/fat/internal/apikeys"
+	"github.com/meedamian/fat/internal/auth"
+	"github.com/meedamian/fat/internal/benchmark"
 	"github.com/meedamian/fat/internal/config"
-	"github.com/meedamian/fat/internal/constants"
 	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/diagnostics"
+	"github.com/meedamian/fat/internal/dpoexport"
+	"github.com/meedamian/fat/internal/embeddings"
+	"github.com/meedamian/fat/internal/extras"
+	"github.com/meedamian/fat/internal/generation"
+	"github.com/meedamian/fat/internal/headers"
 	"github.com/meedamian/fat/internal/htmlexport"
+	"github.com/meedamian/fat/internal/jsonexport"
+	"github.com/meedamian/fat/internal/judgeweight"
+	"github.com/meedamian/fat/internal/locale"
+	"github.com/meedamian/fat/internal/mdexport"
 	"github.com/meedamian/fat/internal/models"
 	"github.com/meedamian/fat/internal/orchestrator"
+	"github.com/meedamian/fat/internal/personas"
+	"github.com/meedamian/fat/internal/postprocess"
+	"github.com/meedamian/fat/internal/ranking"
+	"github.com/meedamian/fat/internal/ratelimit"
+	"github.com/meedamian/fat/internal/reconcile"
+	"github.com/meedamian/fat/internal/scorer"
+	"github.com/meedamian/fat/internal/search"
+	"github.com/meedamian/fat/internal/shared"
+	"github.com/meedamian/fat/internal/site"
+	"github.com/meedamian/fat/internal/stats"
+	"github.com/meedamian/fat/internal/statshistory"
+	"github.com/meedamian/fat/internal/storage"
+	"github.com/meedamian/fat/internal/tarball"
 	"github.com/meedamian/fat/internal/types"
 )
 
@@ -41,42 +69,188 @@ type Server struct {
 	config       config.Config
 	database     *db.DB
 	orchestrator *orchestrator.Orchestrator
-	clients      map[*websocket.Conn]bool
+	diagnostics  *diagnostics.Collector
+	auth         *auth.Authenticator
+	archives     *tarball.Archives // Exports moved out of h/ into tarballs
+	store        storage.Store     // Remote copies of the exports, nil when they're only kept locally
+	clients      map[*websocket.Conn]*wsClient
 	clientsMutex sync.Mutex
+	listener     func(map[string]any) // Also receives every broadcast, while Ask runs a question
 	staticFS     fs.FS
 	startTime    time.Time
+	locale       locale.Format // Time zone and number format of exports and the history listing
+
+	httpServer *http.Server
+	shutdownCh chan shutdownRequest
+	exitCode   int
+
+	// Default variant per family, changed at runtime by the setup flow
+	defaults   map[string]string
+	defaultsMu sync.RWMutex
+}
+
+// shutdownRequest says how the server should stop
+type shutdownRequest struct {
+	exitCode    int
+	waitForRuns bool // Let running requests finish (up to ShutdownTimeout) instead of cancelling them
 }
 
 // New creates a new Server instance
 func New(logger *slog.Logger, cfg config.Config, database *db.DB, staticFS fs.FS) *Server {
 	s := &Server{
-		logger:    logger,
-		config:    cfg,
-		database:  database,
-		clients:   make(map[*websocket.Conn]bool),
-		staticFS:  staticFS,
-		startTime: time.Now(),
+		logger:     logger,
+		config:     cfg,
+		database:   database,
+		clients:    make(map[*websocket.Conn]*wsClient),
+		staticFS:   staticFS,
+		startTime:  time.Now(),
+		shutdownCh: make(chan shutdownRequest, 1),
+		defaults:   make(map[string]string, len(models.DefaultModels)),
+		archives:   tarball.New(filepath.Join(cfg.DataDir, archiveDir)),
+		auth:       auth.New(cfg.AuthToken, database),
+	}
+	for familyID, variant := range models.DefaultModels {
+		s.defaults[familyID] = variant
+	}
+
+	// Create HTML exporter with embedded static files, and a Markdown exporter writing next to it
+	exporter := htmlexport.New(logger, staticFS, cfg.DataDir)
+	mdExporter := mdexport.New(logger, cfg.DataDir)
+
+	// Load the reply post-processing chain, keeping the defaults if the file is unusable
+	pipeline, err := postprocess.Load(cfg.PostProcessFile)
+	if err != nil {
+		logger.Warn("failed to load post-processing rules", slog.String("file", cfg.PostProcessFile), slog.Any("error", err))
+		pipeline = postprocess.Default()
+	}
+
+	// Load per-provider rate limits, running unthrottled if the file is unusable
+	limiter, err := ratelimit.Load(cfg.RateLimitsFile)
+	if err != nil {
+		logger.Warn("failed to load rate limits", slog.String("file", cfg.RateLimitsFile), slog.Any("error", err))
+		limiter = ratelimit.New(nil)
+	}
+
+	// Set up web search for agents, leaving it off if misconfigured
+	searcher, err := search.New(cfg.SearchProvider, cfg.SearchURL, cfg.SearchAPIKey, cfg.SearchResults)
+	if err != nil {
+		logger.Warn("web search disabled", slog.Any("error", err))
+	} else if searcher != nil {
+		logger.Info("web search enabled", slog.String("provider", searcher.Provider()))
+	}
+
+	// Set up embeddings comparing the final answers, leaving them off if misconfigured
+	embedder, err := embeddings.New(cfg.EmbeddingsProvider, cfg.EmbeddingsURL, cfg.EmbeddingsModel, embeddingsKey(cfg))
+	if err != nil {
+		logger.Warn("answer embeddings disabled", slog.Any("error", err))
+	} else if embedder != nil {
+		logger.Info("answer embeddings enabled", slog.String("provider", embedder.Provider()), slog.String("model", embedder.Model()))
+	}
+
+	// Load the operator's custom metrics, scoring nothing if the file is unusable
+	scorers, err := scorer.Load(cfg.ScorersFile)
+	if err != nil {
+		logger.Warn("failed to load custom metrics", slog.String("file", cfg.ScorersFile), slog.Any("error", err))
+		scorers = nil
+	} else if scorers != nil {
+		logger.Info("custom metrics enabled", slog.String("file", cfg.ScorersFile))
+	}
+
+	// Upload exports to an S3-compatible bucket, keeping them local only if it's misconfigured
+	s3, err := storage.NewS3(storage.S3Config{
+		Bucket:    cfg.S3Bucket,
+		Endpoint:  cfg.S3Endpoint,
+		Region:    cfg.S3Region,
+		Prefix:    cfg.S3Prefix,
+		AccessKey: cfg.S3AccessKey,
+		SecretKey: cfg.S3SecretKey,
+		PublicURL: cfg.S3PublicURL,
+	})
+	if err != nil {
+		logger.Warn("export uploads disabled", slog.Any("error", err))
+	} else if s3 != nil {
+		s.store = s3
+		logger.Info("export uploads enabled", slog.String("bucket", cfg.S3Bucket), slog.Bool("keep_local", cfg.S3KeepLocal))
+	}
+
+	// Failed runs leave a diagnostic bundle for bug reports, with the config's secrets masked
+	s.diagnostics = diagnostics.New(cfg.DiagnosticsDir, cfg.Redacted())
+
+	// Older rounds overflowing a model's context window are summarized by a cheap model, or only trimmed without one
+	var summarizer *types.ModelInfo
+	if cfg.Summarizer != "" {
+		if familyID := models.FamilyForVariant(cfg.Summarizer); familyID != "" {
+			summarizer = s.newModelInfo(familyID, cfg.Summarizer)
+		}
+		if summarizer != nil {
+			logger.Info("context summarization enabled", slog.String("summarizer", summarizer.Name))
+		} else {
+			logger.Warn("context summarization disabled, unknown variant", slog.String("summarizer", cfg.Summarizer))
+		}
 	}
 
-	// Create HTML exporter with embedded static files
-	exporter := htmlexport.New(logger, staticFS)
+	// Exports and the history listing follow the team's time zone and number format rather than the server's
+	if s.locale, err = locale.New(cfg.Timezone, cfg.Locale); err != nil {
+		logger.Warn("falling back to local time and English numbers", slog.Any("error", err))
+	}
 
-	s.orchestrator = orchestrator.New(logger, database, s, exporter)
+	s.orchestrator = orchestrator.New(orchestrator.Deps{
+		Logger:      logger.With(config.SubsystemKey, config.SubsystemOrchestrator),
+		Database:    database,
+		Broadcaster: s,
+		Exporter:    exporter,
+		MDExporter:  mdExporter,
+		Pipeline:    pipeline,
+		Limiter:     limiter,
+		Searcher:    searcher,
+		Embedder:    embedder,
+		Scorers:     scorers,
+		Diagnostics: s.diagnostics,
+		Store:       s.store,
+	}, orchestrator.Config{
+		KeepLocal:      cfg.S3KeepLocal,
+		DataDir:        cfg.DataDir,
+		Fallback:       s.fallbackFor,
+		Summarizer:     summarizer,
+		Convergence:    cfg.ConvergenceThreshold,
+		CountSelfVotes: cfg.CountSelfVotes,
+		Justify:        cfg.JudgeJustifications,
+		WeightJudges:   cfg.WeightJudges,
+		Attribution:    cfg.Attribution,
+		Locale:         s.locale,
+		MaxConcurrent:  cfg.MaxConcurrentRequests,
+		MaxQueued:      cfg.MaxQueuedRequests,
+	})
 	return s
 }
 
-// Broadcast sends a message to all connected WebSocket clients
+// embeddingsKey returns the key for the embeddings API; OpenAI's defaults to the gpt family's key
+func embeddingsKey(cfg config.Config) string {
+	if cfg.EmbeddingsAPIKey == "" && strings.EqualFold(strings.TrimSpace(cfg.EmbeddingsProvider), embeddings.OpenAI) {
+		return apikeys.GetForFamily("gpt")
+	}
+	return cfg.EmbeddingsAPIKey
+}
+
+// Broadcast sends a message to the WebSocket clients following its request, or to all of them if it has none
 func (s *Server) Broadcast(message map[string]any) {
 	s.clientsMutex.Lock()
 	defer s.clientsMutex.Unlock()
 
-	messageBytes, _ := json.Marshal(message)
+	if s.listener != nil {
+		s.listener(message)
+	}
+
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	requestID, _ := message["request_id"].(string)
+	msg := outgoing{data: messageBytes, key: supersedeKey(message)}
 
-	for client := range s.clients {
-		if err := client.WriteMessage(websocket.TextMessage, messageBytes); err != nil {
-			s.logger.Warn("websocket write failed", slog.Any("error", err))
-			client.Close()
-			delete(s.clients, client)
+	for conn, client := range s.clients {
+		if client.follows(requestID) && !client.enqueue(msg) {
+			s.dropClientLocked(conn)
 		}
 	}
 }
@@ -103,23 +277,43 @@ func (s *Server) slogMiddleware() gin.HandlerFunc {
 			logFunc = s.logger.Warn
 		}
 
-		logFunc("http request",
+		attrs := []any{
 			slog.String("method", method),
 			slog.String("path", path),
 			slog.Int("status", status),
 			slog.Duration("duration", duration),
 			slog.String("ip", c.ClientIP()),
-		)
+		}
+		if user := c.GetString(userKey); user != "" {
+			attrs = append(attrs, slog.String("user", user))
+		}
+		logFunc("http request", attrs...)
 	}
 }
 
-// Run starts the HTTP server
-func (s *Server) Run() error {
+// Run serves HTTP until ctx is done or a shutdown endpoint is called, then shuts down gracefully
+func (s *Server) Run(ctx context.Context) error {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
 	r.Use(gin.Recovery())
 	r.Use(s.slogMiddleware())
 
+	// Spending, shutting down, stats and admin endpoints need the token or an access key, once either exists
+	authorized := s.requireAuth()
+
+	// Compare computed spend with provider billing in the background, if configured
+	spendSources := reconcile.Sources(s.config.OpenAIAdminKey, s.config.AnthropicAdminKey)
+	reconcile.Start(ctx, s.logger, s.database, spendSources, s.config.ReconcileInterval, s.config.ReconcileThreshold)
+	judgeweight.Start(ctx, s.logger, s.database, s.config.JudgeWeightsInterval)
+	statshistory.Start(ctx, s.logger, s.database, s.config.StatsSnapshotInterval)
+	s.startPruningRequestStates(ctx)
+	site.Start(ctx, s.logger, s.database, s.config.SiteDir, s.config.SiteInterval, s.locale)
+
+	// Catch bad keys and retired default variants before the first run does
+	if s.config.Preflight {
+		s.preflight(ctx)
+	}
+
 	// Serve embedded static files
 	staticSubFS, err := fs.Sub(s.staticFS, "static")
 	if err != nil {
@@ -137,16 +331,28 @@ func (s *Server) Run() error {
 		c.Data(200, "text/html; charset=utf-8", data)
 	})
 
-	// Serve /h/ directory with directory listing
+	// Serve /h/ exports, with the answers site rendered from the database as the index
 	r.GET("/h/*filepath", func(c *gin.Context) {
 		filepath := c.Param("filepath")
-		if filepath == "" || filepath == "/" {
-			// Generate directory listing
-			s.serveDirectoryListing(c, "h")
+		if filepath == "" || filepath == "/" || filepath == "/index.html" {
+			s.serveHistoryListing(c)
+			return
+		}
+		if id, ok := strings.CutPrefix(filepath, "/"+site.PagesDir+"/"); ok && strings.HasSuffix(id, ".html") {
+			s.serveHistoryPage(c, strings.TrimSuffix(id, ".html"))
+			return
+		}
+		if rest, ok := strings.CutPrefix(filepath, "/archive"); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+			s.serveArchive(c, rest)
+			return
+		}
+		// Exports moved into an archive are still found at their old address
+		if url := s.archivedURL(strings.TrimPrefix(filepath, "/")); url != "" {
+			c.Redirect(302, url)
 			return
 		}
 		// Serve static file
-		c.File("h" + filepath)
+		c.File(s.exportFile(filepath))
 	})
 
 	r.GET("/ws", s.handleWebSocket)
@@ -160,7 +366,7 @@ func (s *Server) Run() error {
 	})
 
 	// Stats endpoint
-	r.GET("/stats", func(c *gin.Context) {
+	r.GET("/stats", authorized, func(c *gin.Context) {
 		ctx := c.Request.Context()
 
 		modelStats, err := s.database.GetAllModelStats(ctx)
@@ -175,314 +381,1604 @@ func (s *Server) Run() error {
 			return
 		}
 
+		eloRatings, err := s.database.GetEloRatings(ctx)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		selfPreference, err := s.database.GetSelfPreferenceStats(ctx)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		judgeWeights, err := s.database.GetJudgeWeights(ctx)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		placements, err := s.database.GetPlacementCounts(ctx)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
 		c.JSON(200, gin.H{
 			"model_stats":     modelStats,
 			"recent_requests": recentRequests,
+			"elo":             eloRatings,
+			"self_preference": selfPreference,
+			"judge_weights":   judgeWeights,
+			"placements":      placements,
 		})
 	})
 
-	// Models endpoint
-	r.GET("/models", func(c *gin.Context) {
-		familiesData := make(map[string]gin.H)
-
-		for familyID, family := range models.ModelFamilies {
-			variants := make([]gin.H, 0, len(family.Variants))
-			for variantKey, variant := range family.Variants {
-				variants = append(variants, gin.H{
-					"key":      variantKey,
-					"name":     variantKey,
-					"rate_in":  variant.Rate.In,
-					"rate_out": variant.Rate.Out,
-				})
-			}
+	// How much each judge's ranking counts, from its agreement with the other judges
+	r.GET("/stats/judge-weights", authorized, func(c *gin.Context) {
+		weights, err := s.database.GetJudgeWeights(c.Request.Context())
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
 
-			activeVariant := models.DefaultModels[familyID]
+		c.JSON(200, gin.H{"judges": weights, "applied": s.config.WeightJudges})
+	})
 
-			familiesData[familyID] = gin.H{
-				"id":       family.ID,
-				"provider": family.Provider,
-				"variants": variants,
-				"active":   activeVariant,
-			}
+	// How much each judge favors its own answer over what the other judges think of it
+	r.GET("/stats/self-preference", authorized, func(c *gin.Context) {
+		judges, err := s.database.GetSelfPreferenceStats(c.Request.Context())
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
 		}
 
-		c.JSON(200, familiesData)
+		c.JSON(200, gin.H{"judges": judges})
 	})
 
-	// Random question endpoint
-	r.GET("/question/random", func(c *gin.Context) {
-		if len(constants.SampleQuestions) == 0 {
-			c.JSON(200, gin.H{"question": ""})
+	// Elo ratings accounting for opponent strength, highest first
+	r.GET("/stats/elo", authorized, func(c *gin.Context) {
+		ratings, err := s.database.GetEloRatings(c.Request.Context())
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
 			return
 		}
-		randomIndex := rand.Intn(len(constants.SampleQuestions))
-		c.JSON(200, gin.H{"question": constants.SampleQuestions[randomIndex]})
+
+		c.JSON(200, gin.H{"ratings": ratings})
 	})
 
-	// Shutdown endpoints
-	r.GET("/die/now", func(c *gin.Context) {
-		s.logger.Warn("received die/now request, exiting immediately")
-		os.Exit(1)
+	// How every model's win rate and average cost evolved, day by day, as JSON or a chart
+	r.GET("/stats/history", authorized, s.handleStatsHistory)
+	r.GET("/stats/history.svg", authorized, s.handleStatsHistoryChart)
+
+	// Provider connections per host since startup, and how many were reused from the pool
+	r.GET("/stats/connections", authorized, func(c *gin.Context) {
+		c.JSON(200, gin.H{"hosts": shared.ConnStats()})
 	})
 
-	r.GET("/die", func(c *gin.Context) {
-		if s.orchestrator.IsProcessing() {
-			c.JSON(423, gin.H{"error": "processing in progress"})
+	// Leaderboard with confidence intervals on win rates and bootstrapped rank intervals
+	r.GET("/leaderboard", func(c *gin.Context) {
+		entries, err := stats.Leaderboard(c.Request.Context(), s.database)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
 			return
 		}
-		s.logger.Info("received die request, exiting")
-		os.Exit(1)
-	})
 
-	r.GET("/perish", func(c *gin.Context) {
-		s.logger.Warn("received perish request, exiting immediately")
-		os.Exit(0)
+		c.JSON(200, gin.H{"models": entries})
 	})
 
-	s.logger.Info("starting server", slog.String("addr", s.config.ServerAddress))
-	return r.Run(s.config.ServerAddress)
-}
-
-func (s *Server) handleWebSocket(c *gin.Context) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		s.logger.Error("websocket upgrade failed", slog.Any("error", err))
-		return
-	}
+	// Minimal views for other sites to iframe, and oEmbed so they can be embedded from a link
+	r.GET("/embed/requests/:id", s.serveRequestEmbed)
+	r.GET("/embed/leaderboard", s.serveLeaderboardEmbed)
+	r.GET("/oembed", s.handleOEmbed)
+
+	// Event log endpoint - pass ?after=<event id> to fetch only newer events
+	r.GET("/requests/:id/events", func(c *gin.Context) {
+		var afterID int64
+		if after := c.Query("after"); after != "" {
+			parsed, err := strconv.ParseInt(after, 10, 64)
+			if err != nil {
+				c.JSON(400, gin.H{"error": "invalid after parameter"})
+				return
+			}
+			afterID = parsed
+		}
 
-	s.clientsMutex.Lock()
-	s.clients[conn] = true
-	s.clientsMutex.Unlock()
+		events, err := s.database.GetEvents(c.Request.Context(), c.Param("id"), afterID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+		c.JSON(200, gin.H{
+			"request_id": c.Param("id"),
+			"events":     events,
+		})
+	})
 
-	defer func() {
-		s.clientsMutex.Lock()
-		delete(s.clients, conn)
-		s.clientsMutex.Unlock()
-		conn.Close()
-	}()
+	// Past requests, paginated and filterable by date range, model and winner
+	r.GET("/api/history", s.handleHistory)
+	r.GET("/api/history/:id", s.handleHistorySession)
 
-	for {
-		var msg map[string]any
-		err := conn.ReadJSON(&msg)
+	// Machine-readable export of a completed request
+	r.GET("/api/request/:id/export.json", func(c *gin.Context) {
+		doc, err := jsonexport.Build(c.Request.Context(), s.database, c.Param("id"))
+		if errors.Is(err, jsonexport.ErrNotFound) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
-			s.logger.Debug("websocket read error", slog.Any("error", err))
-			break
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
 		}
 
-		msgType, ok := msg["type"].(string)
-		if !ok {
-			continue
-		}
+		c.JSON(200, doc)
+	})
 
-		switch msgType {
-		case "question":
-			s.handleQuestionWS(conn, ctx, msg)
+	// Every judge's reason for every placement, to audit how a request was ranked
+	r.GET("/api/requests/:id/audit", s.handleAudit)
+
+	// A tournament's answers and bracket, as played so far
+	r.GET("/api/tournaments/:id", s.handleTournament)
+
+	// Hide a request from the history and exports; ?redact=true also scrubs its text, keeping the metrics
+	r.DELETE("/api/requests/:id", authorized, s.handleDeleteRequest)
+
+	// Diagnostic bundle of a failed run, to attach to a bug report
+	r.GET("/api/requests/:id/diagnostics", authorized, func(c *gin.Context) {
+		data, err := s.diagnostics.Read(c.Param("id"))
+		if errors.Is(err, diagnostics.ErrNotFound) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
 		}
-	}
-}
 
-func (s *Server) handleQuestionWS(conn *websocket.Conn, ctx context.Context, msg map[string]any) {
-	question, ok := msg["question"].(string)
-	if !ok || question == "" {
-		conn.WriteJSON(map[string]any{
-			"type":  "error",
-			"error": "Question is required",
-		})
-		return
-	}
+		c.Data(200, "application/json; charset=utf-8", data)
+	})
 
-	roundsFloat, ok := msg["rounds"].(float64)
-	rounds := int(roundsFloat)
-	if !ok || rounds < 3 || rounds > 10 {
-		rounds = 3
-	}
+	// Preference pairs (prompt, chosen, rejected) as DPO-compatible JSONL
+	r.GET("/api/request/:id/pairs.jsonl", func(c *gin.Context) {
+		ctx := c.Request.Context()
 
-	// Build activeModels from selected models
-	selectedModels, _ := msg["models"].(map[string]any)
-	activeModels := []*types.ModelInfo{}
+		req, err := s.database.GetRequest(ctx, c.Param("id"))
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		if req == nil {
+			c.JSON(404, gin.H{"error": "request not found"})
+			return
+		}
 
-	for familyID, family := range models.ModelFamilies {
-		var variantKey string
+		records, err := dpoexport.Build(ctx, s.database, *req)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
 
-		if selectedModels != nil {
-			if selected, ok := selectedModels[familyID].(string); ok && selected != "" {
-				variantKey = selected
+		c.Header("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(c.Writer)
+		enc.SetEscapeHTML(false)
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				s.logger.Warn("failed to write preference pair", slog.Any("error", err))
+				return
 			}
 		}
-		if variantKey == "" {
-			variantKey = models.DefaultModels[familyID]
+	})
+
+	// Preference pairs from every request, optionally limited to a question set with ?tag=
+	r.GET("/api/export/pairs.jsonl", func(c *gin.Context) {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="pairs.jsonl"`)
+
+		written, err := dpoexport.Write(c.Request.Context(), s.database, c.Query("tag"), c.Writer)
+		if err != nil {
+			// Headers are already sent once a record is written, so only log
+			s.logger.Error("preference pair export failed", slog.Int("written", written), slog.Any("error", err))
+			if written == 0 {
+				c.JSON(500, gin.H{"error": err.Error()})
+			}
 		}
+	})
+
+	// Request queue - running and waiting questions
+	r.GET("/api/queue", func(c *gin.Context) {
+		c.JSON(200, s.orchestrator.QueueStatus())
+	})
 
-		variant, ok := family.Variants[variantKey]
+	// Live state of running requests, for clients that poll instead of following /ws
+	r.GET("/api/runs", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"running": s.orchestrator.Runs(),
+			"queued":  s.orchestrator.QueueStatus().Queued,
+		})
+	})
+	r.GET("/api/runs/:id", func(c *gin.Context) {
+		run, ok := s.orchestrator.Run(c.Param("id"))
 		if !ok {
-			s.logger.Warn("unknown variant for family",
-				slog.String("family", familyID),
-				slog.String("variant", variantKey))
-			continue
+			c.JSON(404, gin.H{"error": "request is not running"})
+			return
 		}
 
-		mi := &types.ModelInfo{
-			ID:             family.ID,
-			Name:           variantKey,
-			MaxTok:         variant.MaxTok,
-			BaseURL:        family.BaseURL,
-			Logger:         s.logger.With("model", variantKey),
-			RequestTimeout: s.config.ModelRequestTimeout,
+		c.JSON(200, run)
+	})
+	r.GET("/api/runs/:id/models/:model", func(c *gin.Context) {
+		run, ok := s.orchestrator.Run(c.Param("id"))
+		if !ok {
+			c.JSON(404, gin.H{"error": "request is not running"})
+			return
 		}
 
-		if apiKey := apikeys.GetForFamily(familyID); apiKey != "" {
-			mi.APIKey = apiKey
-		} else {
-			s.logger.Warn("api key missing for model",
-				slog.String("family", familyID),
-				slog.String("model", variantKey))
+		for _, ms := range run.Models {
+			if ms.ModelID == c.Param("model") {
+				c.JSON(200, ms)
+				return
+			}
 		}
+		c.JSON(404, gin.H{"error": "model is not taking part in the request"})
+	})
 
-		activeModels = append(activeModels, mi)
-	}
-
-	questionTS := time.Now().Unix()
+	// Resumable requests - runs interrupted by a crash, restart or disconnect
+	r.GET("/requests/resumable", authorized, func(c *gin.Context) {
+		states, err := s.database.GetResumableRequests(c.Request.Context())
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
 
-	// Send loading messages
-	for _, mi := range activeModels {
-		s.Broadcast(map[string]any{
-			"type":  "loading",
-			"model": mi.ID,
-		})
-	}
+		resumable := make([]gin.H, 0, len(states))
+		for _, st := range states {
+			resumable = append(resumable, gin.H{
+				"request_id":       st.RequestID,
+				"question":         st.Question,
+				"completed_rounds": st.Round,
+				"rounds":           st.NumRounds,
+				"updated_at":       st.UpdatedAt,
+			})
+		}
 
-	// Process question in background
-	go func() {
-		s.orchestrator.ProcessQuestion(ctx, question, rounds, activeModels, questionTS)
-	}()
-}
+		c.JSON(200, gin.H{"requests": resumable})
+	})
 
-// serveDirectoryListing generates an HTML page listing all files in the h/ directory
-func (s *Server) serveDirectoryListing(c *gin.Context, baseDir string) {
-	type FileEntry struct {
-		Path    string
-		Name    string
-		ModTime time.Time
-		Size    int64
-	}
+	r.POST("/requests/:id/resume", authorized, s.handleResume)
+	r.POST("/requests/:id/winner", authorized, s.handleHumanWinner)
 
-	type DateGroup struct {
-		Date  string
-		Files []FileEntry
-	}
+	// Benchmark regression tracking for tagged question sets
+	r.POST("/benchmarks/:tag/baseline", authorized, func(c *gin.Context) {
+		baselines, err := benchmark.SnapshotBaseline(c.Request.Context(), s.database, c.Param("tag"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
 
-	// Read directory structure
-	groups := make(map[string][]FileEntry)
+		c.JSON(201, gin.H{
+			"tag":       c.Param("tag"),
+			"baselines": baselines,
+		})
+	})
 
-	err := filepath.WalkDir(baseDir, func(path string, d os.DirEntry, err error) error {
+	r.GET("/benchmarks/:tag/regressions", func(c *gin.Context) {
+		report, err := benchmark.DetectRegressions(c.Request.Context(), s.database, c.Param("tag"))
 		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
 		}
-		if !strings.HasSuffix(path, ".html") {
-			return nil
+
+		c.JSON(200, report)
+	})
+
+	// Run a question set as a benchmark suite, and report how each model did across it
+	r.POST("/benchmarks/:tag/run", authorized, s.handleRunSuite)
+	r.GET("/benchmarks/:tag/report", s.handleSuiteReport)
+
+	// A/B comparison of two tagged question sets, e.g. two prompt versions
+	r.GET("/api/reports/compare-tags", func(c *gin.Context) {
+		tagA, tagB := c.Query("a"), c.Query("b")
+		if tagA == "" || tagB == "" {
+			c.JSON(400, gin.H{"error": "both tags are required as a and b"})
+			return
 		}
 
-		info, err := d.Info()
+		report, err := benchmark.CompareTags(c.Request.Context(), s.database, tagA, tagB)
 		if err != nil {
-			return nil
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
 		}
 
-		// Extract date from path (h/YYYY-MM-DD/file.html)
-		parts := strings.Split(path, string(os.PathSeparator))
-		date := "unknown"
-		if len(parts) >= 2 {
-			date = parts[1]
-		}
+		c.JSON(200, report)
+	})
 
-		groups[date] = append(groups[date], FileEntry{
-			Path:    "/" + path,
-			Name:    filepath.Base(path),
-			ModTime: info.ModTime(),
-			Size:    info.Size(),
-		})
+	// Spend per provider and model by day, week or month, to check against provider invoices
+	r.GET("/api/costs", authorized, s.handleCosts)
 
-		return nil
+	// List price history, and recomputing stored costs at the rates in force when each request ran
+	r.GET("/api/pricing/history", func(c *gin.Context) {
+		history, err := s.database.GetPriceHistory(c.Request.Context())
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, history)
 	})
 
-	if err != nil && !os.IsNotExist(err) {
-		c.String(500, "Error reading directory: %v", err)
-		return
-	}
-
-	// Sort dates descending (newest first)
-	dates := make([]string, 0, len(groups))
-	for date := range groups {
-		dates = append(dates, date)
-	}
-	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	r.POST("/api/pricing/recompute", authorized, func(c *gin.Context) {
+		recomputed, err := s.database.RecomputeCosts(c.Request.Context())
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"requests": recomputed})
+	})
 
-	// Sort files within each group by name (descending = newest first)
-	for date := range groups {
-		sort.Slice(groups[date], func(i, j int) bool {
-			return groups[date][i].Name > groups[date][j].Name
-		})
-	}
+	// Computed vs billed spend per provider over the last ?days= days (default 1), including today
+	r.GET("/api/reconcile", authorized, func(c *gin.Context) {
+		if len(spendSources) == 0 {
+			c.JSON(404, gin.H{"error": "no provider admin keys configured"})
+			return
+		}
 
-	// Build HTML
-	var html strings.Builder
-	html.WriteString(`<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Nexus - Exported Sessions</title>
-    <style>
-        :root { --bg: #0a0a0f; --text: #e4e4e7; --muted: #71717a; --accent: #7c5cff; }
-        * { margin: 0; padding: 0; box-sizing: border-box; }
-        body { background: var(--bg); color: var(--text); font-family: system-ui, sans-serif; padding: 40px 20px; max-width: 900px; margin: 0 auto; }
-        h1 { font-size: 2em; margin-bottom: 8px; }
-        .tagline { color: var(--muted); margin-bottom: 40px; }
-        .date-group { margin-bottom: 32px; }
-        .date-header { color: var(--accent); font-size: 1.1em; font-weight: 600; margin-bottom: 12px; padding-bottom: 8px; border-bottom: 1px solid rgba(255,255,255,0.1); }
-        .file-list { list-style: none; }
-        .file-list li { margin-bottom: 8px; }
-        .file-list a { color: var(--text); text-decoration: none; display: block; padding: 12px 16px; background: rgba(255,255,255,0.03); border-radius: 8px; transition: all 0.2s; }
-        .file-list a:hover { background: rgba(124, 92, 255, 0.15); transform: translateX(4px); }
-        .file-name { font-weight: 500; }
-        .file-meta { color: var(--muted); font-size: 0.85em; margin-top: 4px; }
-        .empty { color: var(--muted); font-style: italic; }
-    </style>
-</head>
-<body>
-    <h1>📄 Exported Sessions</h1>
-    <p class="tagline">Static HTML snapshots of Nexus conversations</p>
-`)
-
-	if len(dates) == 0 {
-		html.WriteString(`    <p class="empty">No exports yet. Run some questions and they'll appear here!</p>`)
-	} else {
-		for _, date := range dates {
-			html.WriteString(fmt.Sprintf(`    <div class="date-group">
-        <div class="date-header">📅 %s</div>
-        <ul class="file-list">
-`, date))
-			for _, f := range groups[date] {
-				sizeKB := float64(f.Size) / 1024
-				html.WriteString(fmt.Sprintf(`            <li><a href="%s">
-                <div class="file-name">%s</div>
-                <div class="file-meta">%.1f KB</div>
-            </a></li>
-`, f.Path, f.Name, sizeKB))
+		days := 1
+		if d := c.Query("days"); d != "" {
+			parsed, err := strconv.Atoi(d)
+			if err != nil || parsed < 1 {
+				c.JSON(400, gin.H{"error": "days must be a positive integer"})
+				return
 			}
-			html.WriteString(`        </ul>
-    </div>
-`)
+			days = parsed
 		}
-	}
 
-	html.WriteString(`</body>
-</html>`)
+		end := time.Now().UTC()
+		start := end.Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+		drifts, err := reconcile.Run(c.Request.Context(), s.database, spendSources, start, end)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{
+			"start":     start,
+			"end":       end,
+			"providers": drifts,
+		})
+	})
+
+	// Models endpoint
+	r.GET("/models", func(c *gin.Context) {
+		familiesData := make(map[string]gin.H)
+
+		for familyID, family := range models.ModelFamilies {
+			variants := make([]gin.H, 0, len(family.Variants))
+			for variantKey, variant := range family.Variants {
+				variants = append(variants, gin.H{
+					"key":      variantKey,
+					"name":     variantKey,
+					"rate_in":  variant.Rate.In,
+					"rate_out": variant.Rate.Out,
+				})
+			}
+
+			activeVariant := s.defaultVariant(familyID)
+
+			familiesData[familyID] = gin.H{
+				"id":       family.ID,
+				"provider": family.Provider,
+				"variants": variants,
+				"active":   activeVariant,
+			}
+		}
+
+		c.JSON(200, familiesData)
+	})
+
+	// Random question endpoint - pass ?category= to pick from one category only
+	r.GET("/question/random", func(c *gin.Context) {
+		question, err := s.database.RandomSampleQuestion(c.Request.Context(), c.Query("category"))
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"question": question})
+	})
+
+	// Sample question management, with how often each was asked and who won it
+	r.GET("/admin/questions", authorized, func(c *gin.Context) {
+		questions, err := s.database.GetSampleQuestions(c.Request.Context(),
+			c.Query("category"), c.Query("include_disabled") == "true")
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{"questions": questions})
+	})
+
+	r.POST("/admin/questions", authorized, s.handleAddSampleQuestion)
+	r.PATCH("/admin/questions/:id", authorized, s.handleUpdateSampleQuestion)
+
+	// Fault injection into provider calls, for exercising retries and error paths on purpose
+	r.GET("/admin/faults", authorized, s.handleGetFaults)
+	r.PUT("/admin/faults", authorized, s.handleSetFaults)
+	r.DELETE("/admin/faults", authorized, s.handleClearFaults)
+
+	// First-run setup: API keys and default models
+	r.GET("/setup", s.serveSetupPage)
+	r.GET("/api/setup", s.handleSetupStatus)
+	r.POST("/api/setup/validate", authorized, s.handleSetupValidate)
+	r.POST("/api/setup/keys", authorized, s.handleSetupKeys)
+	r.POST("/api/setup/defaults", authorized, s.handleSetupDefaults)
+
+	// What a run would cost and take, without starting it
+	r.POST("/api/estimate", s.handleEstimate)
+
+	// Live check of every provider's key, latency and offered variants
+	r.GET("/api/providers/health", authorized, s.handleProvidersHealth)
+
+	// Shutdown endpoints - the process exits with 1 after /die and /die/now, 0 after /perish
+	r.GET("/die/now", authorized, func(c *gin.Context) {
+		s.logger.Warn("received die/now request, cancelling running requests")
+		s.requestShutdown(1, false)
+		c.JSON(202, gin.H{"status": "shutting down"})
+	})
+
+	r.GET("/die", authorized, func(c *gin.Context) {
+		s.logger.Info("received die request, waiting for running requests")
+		s.requestShutdown(1, true)
+		c.JSON(202, gin.H{
+			"status":  "shutting down",
+			"running": len(s.orchestrator.QueueStatus().Running),
+		})
+	})
+
+	r.GET("/perish", authorized, func(c *gin.Context) {
+		s.logger.Warn("received perish request, cancelling running requests")
+		s.requestShutdown(0, false)
+		c.JSON(202, gin.H{"status": "shutting down"})
+	})
+
+	s.httpServer = &http.Server{
+		Addr:    s.config.ServerAddress,
+		Handler: r,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info("starting server", slog.String("addr", s.config.ServerAddress))
+		serveErr <- s.httpServer.ListenAndServe()
+	}()
+
+	req := shutdownRequest{waitForRuns: true}
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	case <-ctx.Done():
+		s.logger.Info("received shutdown signal")
+		s.requestShutdown(0, true)
+		req = <-s.shutdownCh
+	case req = <-s.shutdownCh:
+	}
+
+	s.exitCode = req.exitCode
+	s.shutdown(req.waitForRuns)
+	return nil
+}
+
+// requestShutdown asks Run to shut the server down; only the first request counts
+func (s *Server) requestShutdown(exitCode int, waitForRuns bool) {
+	select {
+	case s.shutdownCh <- shutdownRequest{exitCode: exitCode, waitForRuns: waitForRuns}:
+	default:
+	}
+}
+
+// shutdown stops accepting work, lets running requests finish (or cancels them), flushes the
+// database and disconnects WebSocket clients. Running requests that don't finish within
+// ShutdownTimeout are cancelled and stay resumable.
+func (s *Server) shutdown(waitForRuns bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting HTTP requests and new WebSocket connections; open sockets stay up for progress updates
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.logger.Warn("http server shutdown incomplete", slog.Any("error", err))
+	}
+
+	runsCtx := ctx
+	if !waitForRuns {
+		cancelled, cancelNow := context.WithCancel(ctx)
+		cancelNow()
+		runsCtx = cancelled
+	}
+	if err := s.orchestrator.Shutdown(runsCtx); err != nil {
+		s.logger.Warn("running requests did not finish, resume them after restart", slog.Any("error", err))
+	}
+
+	if err := s.database.Checkpoint(context.Background()); err != nil {
+		s.logger.Warn("failed to flush database", slog.Any("error", err))
+	}
+
+	s.closeClients()
+	s.logger.Info("server stopped")
+}
+
+// closeClients lets every WebSocket client receive what's queued for it, then sends a close frame and
+// disconnects it; clients still behind after a second are cut off
+func (s *Server) closeClients() {
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+
+	s.clientsMutex.Lock()
+	clients := make(map[*websocket.Conn]*wsClient, len(s.clients))
+	for conn, client := range s.clients {
+		client.enqueue(outgoing{data: closeMsg, close: true})
+		clients[conn] = client
+	}
+	s.clientsMutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for conn, client := range clients {
+		select {
+		case <-client.done:
+		case <-ctx.Done():
+			conn.Close()
+		}
+	}
+}
+
+// ExitCode is the process exit code requested by the shutdown endpoint that stopped the server
+func (s *Server) ExitCode() int {
+	return s.exitCode
+}
+
+func (s *Server) handleWebSocket(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		s.logger.Error("websocket upgrade failed", slog.Any("error", err))
+		return
+	}
+
+	client := newWSClient(auth.Credential(c.Request))
+	s.clientsMutex.Lock()
+	s.clients[conn] = client
+	s.clientsMutex.Unlock()
+	go s.writeLoop(conn, client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer func() {
+		s.clientsMutex.Lock()
+		delete(s.clients, conn)
+		s.clientsMutex.Unlock()
+		close(client.done)
+		conn.Close()
+	}()
+
+	// A message over the limit closes the connection with 1009 (message too big)
+	conn.SetReadLimit(maxWSMessageBytes)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if errors.Is(err, websocket.ErrReadLimit) {
+			s.logger.Warn("websocket message too big", slog.Int("limit", maxWSMessageBytes))
+			break
+		}
+		if err != nil {
+			s.logger.Debug("websocket read error", slog.Any("error", err))
+			break
+		}
+
+		// A malformed message is answered, not a reason to drop the connection
+		var msg map[string]any
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.send(conn, map[string]any{
+				"type":  "error",
+				"error": "invalid message: must be a JSON object",
+			})
+			continue
+		}
+
+		s.dispatchWS(conn, ctx, msg)
+	}
+}
+
+// maxWSMessageBytes caps a message read from a WebSocket client, well above the longest valid question
+const maxWSMessageBytes = 1 << 20
+
+// dispatchWS handles one message from a WebSocket client
+// A panic while handling it is reported to the client and logged, leaving the connection and the server up.
+func (s *Server) dispatchWS(conn *websocket.Conn, ctx context.Context, msg map[string]any) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("websocket message handler panicked",
+				slog.Any("type", msg["type"]),
+				slog.Any("panic", r),
+				slog.String("stack", string(debug.Stack())))
+			s.send(conn, map[string]any{
+				"type":  "error",
+				"error": "internal error handling the message",
+			})
+		}
+	}()
+
+	msgType, ok := msg["type"].(string)
+	if !ok {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": "invalid message: type is required",
+		})
+		return
+	}
+
+	switch msgType {
+	case "question", "follow_up":
+		if s.authorizeWS(conn, ctx) {
+			s.handleQuestionWS(conn, ctx, msg)
+		}
+	case "estimate":
+		s.handleEstimateWS(conn, ctx, msg)
+	case "subscribe", "unsubscribe":
+		s.handleSubscribeWS(conn, msg)
+	}
+}
+
+func (s *Server) handleQuestionWS(conn *websocket.Conn, ctx context.Context, msg map[string]any) {
+	// A composite question is asked as one, with every sub-question answered and ranked in its own section
+	question, subQuestions, err := messageQuestion(msg, s.config.MaxQuestionLength)
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	if len(subQuestions) > 0 {
+		question = shared.FormatSubQuestions(question, subQuestions)
+	}
+
+	// References to earlier winning answers are filled in before anything else sees the question
+	question, err = s.resolveQuestion(ctx, question)
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if s.orchestrator.ShuttingDown() {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": orchestrator.ErrShuttingDown.Error(),
+		})
+		return
+	}
+
+	if s.orchestrator.QueueFull() {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": orchestrator.ErrQueueFull.Error(),
+		})
+		return
+	}
+
+	// A follow-up continues the session of an earlier request instead of starting cold
+	var parentRequestID string
+	if msg["type"] == "follow_up" {
+		parentRequestID, _ = msg["parent_request_id"].(string)
+		parent, err := s.database.GetRequest(ctx, parentRequestID)
+		if err != nil || parent == nil {
+			s.send(conn, map[string]any{
+				"type":  "error",
+				"error": fmt.Sprintf("unknown parent request %q", parentRequestID),
+			})
+			return
+		}
+	}
+
+	rounds, err := messageRounds(msg)
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	variants, err := s.selectedVariants(msg)
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	activeModels := s.buildActiveModels(variants)
+
+	opts := orchestrator.Options{ParentRequestID: parentRequestID, SubQuestions: subQuestions}
+	if tag, ok := msg["tag"].(string); ok {
+		opts.Tag = strings.TrimSpace(tag)
+	}
+	if opts.ClientRef, err = messageClientRef(msg); err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+
+	pricing, err := s.pricing(msg["pricing"])
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	opts.Pricing = pricing
+
+	overrides, err := generationOverrides(msg["generation"])
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	opts.Generation = overrides
+	applyGeneration(activeModels, overrides)
+
+	schema, err := answerschema.Parse(msg["answer_schema"])
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	if schema != nil {
+		opts.AnswerSchema = schema.Map()
+	}
+
+	judgeNames, err := s.selectedJudges(msg)
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	judges := s.buildJudges(judgeNames)
+	for _, mi := range judges {
+		opts.Judges = append(opts.Judges, mi.Name)
+	}
+	applyGeneration(judges, overrides)
+
+	opts.Strategy, err = s.selectedStrategy(msg)
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	opts.Audit = s.config.AuditRankings
+	if audit, ok := msg["audit"].(bool); ok {
+		opts.Audit = audit
+	}
+	opts.Verify = s.config.Verification
+	if verify, ok := msg["verify"].(bool); ok {
+		opts.Verify = verify
+	}
+	opts.Mode, opts.RotatePositions, err = questionMode(msg)
+	if err == nil && opts.Mode == shared.ModeDebate {
+		// A debate needs an opposing side, and its answers argue one question rather than answer several
+		if len(activeModels) < 2 {
+			err = errors.New("a debate needs at least two models")
+		} else if len(subQuestions) > 0 {
+			err = errors.New("a debate can't have sub-questions")
+		}
+	}
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	metaJudge, err := s.selectedMetaJudge(msg, opts.Strategy)
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	if metaJudge != nil {
+		opts.MetaJudge = metaJudge.Name
+		applyGeneration([]*types.ModelInfo{metaJudge}, overrides)
+	}
+	synthesizer, err := s.selectedSynthesizer(msg)
+	if err != nil {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		return
+	}
+	if synthesizer != nil {
+		opts.Synthesizer = synthesizer.Name
+		applyGeneration([]*types.ModelInfo{synthesizer}, overrides)
+	}
+
+	// Replay an identical earlier run, or offer a similar one, unless the client insists on a fresh run
+	// Follow-ups depend on their session, so an earlier run of the same words is neither cached nor a duplicate
+	force, _ := msg["force"].(bool)
+	if s.config.AnswerCacheTTL > 0 && parentRequestID == "" {
+		opts.CacheKey = orchestrator.CacheKey(question, rounds, activeModels, judges, opts)
+		if !force && s.replayCachedRun(conn, ctx, question, opts.CacheKey, opts.ClientRef) {
+			return
+		}
+	}
+
+	if !force && parentRequestID == "" {
+		duplicates, err := s.orchestrator.FindDuplicates(ctx, question, s.config.DuplicateThreshold)
+		if err != nil {
+			s.logger.Warn("duplicate question check failed", slog.Any("error", err))
+		} else if len(duplicates) > 0 {
+			s.send(conn, map[string]any{
+				"type":       "duplicate",
+				"question":   question,
+				"duplicates": duplicates,
+			})
+			return
+		}
+	}
+
+	questionTS := time.Now().Unix()
+
+	// The asker follows its own run, and nobody else's unless it subscribes
+	requestID := uuid.New().String()
+	s.subscribe(conn, requestID)
+
+	// Tell the asker which run is theirs before it's queued, so it can follow, cancel or look it up from the start
+	s.send(conn, withClientRef(map[string]any{
+		"type":       "accepted",
+		"request_id": requestID,
+		"question":   question,
+	}, opts.ClientRef))
+
+	// Send loading messages
+	for _, mi := range activeModels {
+		s.Broadcast(withClientRef(map[string]any{
+			"type":       "loading",
+			"model":      mi.ID,
+			"request_id": requestID,
+		}, opts.ClientRef))
+	}
+
+	// Process question in background
+	go func() {
+		s.orchestrator.ProcessQuestion(ctx, requestID, question, rounds, activeModels, judges, metaJudge, synthesizer, questionTS, opts)
+	}()
+}
+
+// replayCachedRun sends the event log of the newest identical run within the cache TTL to conn
+// The replayed messages carry the asker's clientRef instead of the earlier run's.
+// Returns false if there is no such run, and the question should be answered afresh.
+func (s *Server) replayCachedRun(conn *websocket.Conn, ctx context.Context, question, cacheKey, clientRef string) bool {
+	requestID, events, err := s.orchestrator.CachedRun(ctx, cacheKey, s.config.AnswerCacheTTL)
+	if err != nil {
+		s.logger.Warn("answer cache lookup failed", slog.Any("error", err))
+		return false
+	}
+	if requestID == "" || len(events) == 0 {
+		return false
+	}
+
+	s.logger.Info("replaying cached run", slog.String("request_id", requestID))
+
+	// Held so broadcasts of other runs don't interleave with the replay
+	s.clientsMutex.Lock()
+	defer s.clientsMutex.Unlock()
+
+	client, ok := s.clients[conn]
+	if !ok {
+		return true
+	}
+	ok = client.send(withClientRef(map[string]any{
+		"type":       "cached",
+		"question":   question,
+		"request_id": requestID,
+		"created_at": events[0].CreatedAt,
+	}, clientRef))
+	for _, e := range events {
+		ok = ok && client.enqueue(outgoing{data: replaceClientRef(e.Payload, clientRef)})
+	}
+	if !ok {
+		s.dropClientLocked(conn)
+	}
+	return true
+}
+
+// withClientRef adds a submitter's client reference to message, unless there is none
+func withClientRef(message map[string]any, clientRef string) map[string]any {
+	if clientRef != "" {
+		message["client_ref"] = clientRef
+	}
+	return message
+}
+
+// replaceClientRef swaps the client reference of a stored event for clientRef, removing it if that's empty
+// Events without either are passed on untouched.
+func replaceClientRef(payload []byte, clientRef string) []byte {
+	if clientRef == "" && !bytes.Contains(payload, []byte(`"client_ref"`)) {
+		return payload
+	}
+	var message map[string]any
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return payload
+	}
+	delete(message, "client_ref")
+	replaced, err := json.Marshal(withClientRef(message, clientRef))
+	if err != nil {
+		return payload
+	}
+	return replaced
+}
+
+// messageRounds returns the round count a question message asks for, 3 if it names none
+func messageRounds(msg map[string]any) (int, error) {
+	raw, ok := msg["rounds"]
+	if !ok || raw == nil {
+		return 3, nil
+	}
+	rounds, ok := raw.(float64)
+	if !ok || rounds != float64(int(rounds)) || rounds < 3 || rounds > 10 {
+		return 0, errors.New("invalid rounds: must be a whole number from 3 to 10")
+	}
+	return int(rounds), nil
+}
+
+// messageQuestion returns the question of a question message and its non-empty sub-questions, nil for a plain question
+// Together they may take at most maxLength characters, so a submission can't grow into a huge prompt.
+func messageQuestion(msg map[string]any, maxLength int) (string, []string, error) {
+	question, ok := msg["question"].(string)
+	if _, present := msg["question"]; present && !ok {
+		return "", nil, errors.New("invalid question: must be a string")
+	}
+	if strings.TrimSpace(question) == "" {
+		return "", nil, errors.New("Question is required")
+	}
+
+	subQuestions, err := messageSubQuestions(msg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	length := utf8.RuneCountInString(question)
+	for _, sub := range subQuestions {
+		length += utf8.RuneCountInString(sub)
+	}
+	if err := checkQuestionLength(length, maxLength); err != nil {
+		return "", nil, err
+	}
+	return question, subQuestions, nil
+}
+
+// checkQuestionLength rejects a question of length characters over maxLength, with 0 allowing any length
+func checkQuestionLength(length, maxLength int) error {
+	if maxLength > 0 && length > maxLength {
+		return fmt.Errorf("question too long: %d characters, at most %d are allowed", length, maxLength)
+	}
+	return nil
+}
+
+// resolveQuestion fills in the answer references of a question message's question and checks its length again,
+// as every reference expands to a whole answer that would otherwise get past FAT_MAX_QUESTION_LENGTH
+func (s *Server) resolveQuestion(ctx context.Context, question string) (string, error) {
+	resolved, err := s.orchestrator.ResolveAnswerReferences(ctx, question)
+	if err != nil {
+		return "", err
+	}
+	if resolved != question {
+		if err := checkQuestionLength(utf8.RuneCountInString(resolved), s.config.MaxQuestionLength); err != nil {
+			return "", err
+		}
+	}
+	return resolved, nil
+}
+
+// maxClientRefLen caps a client reference, which is echoed in every message of its run
+const maxClientRefLen = 256
+
+// messageClientRef returns the opaque client reference a question message carries, empty for none
+func messageClientRef(msg map[string]any) (string, error) {
+	raw, ok := msg["client_ref"]
+	if !ok || raw == nil {
+		return "", nil
+	}
+	clientRef, ok := raw.(string)
+	if !ok {
+		return "", errors.New("invalid client_ref: must be a string")
+	}
+	if len(clientRef) > maxClientRefLen {
+		return "", fmt.Errorf("invalid client_ref: at most %d bytes are allowed", maxClientRefLen)
+	}
+	return clientRef, nil
+}
+
+// maxSubQuestions caps the sub-questions of a composite question, which all have to fit one answer
+const maxSubQuestions = 10
+
+// messageSubQuestions returns the non-empty sub-questions of a question message, nil for a plain question
+func messageSubQuestions(msg map[string]any) ([]string, error) {
+	raw, ok := msg["sub_questions"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("invalid sub_questions: must be a list of strings")
+	}
+
+	var subQuestions []string
+	for _, item := range list {
+		sub, ok := item.(string)
+		if !ok {
+			return nil, errors.New("invalid sub_questions: must be a list of strings")
+		}
+		if sub = strings.TrimSpace(sub); sub != "" {
+			subQuestions = append(subQuestions, sub)
+		}
+	}
+	if len(subQuestions) > maxSubQuestions {
+		return nil, fmt.Errorf("invalid sub_questions: at most %d are allowed", maxSubQuestions)
+	}
+	return subQuestions, nil
+}
+
+// selectedVariants returns the agents a question message selects (agent ID -> variant), falling back to family defaults
+// Every selection must name a family and one of its variants, a list of its variants to pit them against each other,
+// or null to leave the family out of the run; at least 2 agents must be left to take part.
+func (s *Server) selectedVariants(msg map[string]any) (map[string]string, error) {
+	var selectedModels map[string]any
+	if raw, ok := msg["models"]; ok && raw != nil {
+		if selectedModels, ok = raw.(map[string]any); !ok {
+			return nil, errors.New("invalid models: must map model families to variants")
+		}
+	}
+
+	var variants []string
+	for familyID, family := range models.ModelFamilies {
+		raw, listed := selectedModels[familyID]
+		switch selected := raw.(type) {
+		case nil:
+			if !listed {
+				variants = append(variants, s.defaultVariant(familyID))
+			}
+		case string:
+			if selected == "" {
+				selected = s.defaultVariant(familyID)
+			} else if _, ok := family.Variants[selected]; !ok {
+				return nil, fmt.Errorf("invalid models: unknown %s variant %q", familyID, selected)
+			}
+			variants = append(variants, selected)
+		case []any:
+			if len(selected) == 0 {
+				return nil, fmt.Errorf("invalid models: the %s variants must not be an empty list, use null to skip the family", familyID)
+			}
+			seen := make(map[string]bool, len(selected))
+			for _, v := range selected {
+				variant, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid models: the %s variants must be strings", familyID)
+				}
+				if _, ok := family.Variants[variant]; !ok {
+					return nil, fmt.Errorf("invalid models: unknown %s variant %q", familyID, variant)
+				}
+				if seen[variant] {
+					return nil, fmt.Errorf("invalid models: %s is listed twice, a variant can take part once", variant)
+				}
+				seen[variant] = true
+				variants = append(variants, variant)
+			}
+		default:
+			return nil, fmt.Errorf("invalid models: the %s variant must be a string, a list of variants, or null to skip it", familyID)
+		}
+	}
+	for familyID := range selectedModels {
+		if _, ok := models.ModelFamilies[familyID]; !ok {
+			return nil, fmt.Errorf("invalid models: unknown model family %q", familyID)
+		}
+	}
+
+	agents := models.AgentIDs(variants)
+	if len(agents) < 2 {
+		return nil, fmt.Errorf("invalid models: at least 2 models must take part, %d left after skipping", len(agents))
+	}
+	return agents, nil
+}
+
+// selectedJudges returns the jury of a question message; a jury named in the message overrides the configured one
+func (s *Server) selectedJudges(msg map[string]any) ([]string, error) {
+	raw, ok := msg["judges"]
+	if !ok || raw == nil {
+		return s.config.Judges, nil
+	}
+	selected, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("invalid judges: must be a list of variants")
+	}
+
+	var judgeNames []string
+	for _, j := range selected {
+		name, ok := j.(string)
+		if !ok {
+			return nil, errors.New("invalid judges: must be a list of variants")
+		}
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if models.FamilyForVariant(name) == "" {
+			return nil, fmt.Errorf("invalid judges: unknown variant %q", name)
+		}
+		judgeNames = append(judgeNames, name)
+	}
+	return judgeNames, nil
+}
+
+// selectedStrategy returns the winner strategy of a question message; a strategy named in the message overrides the configured one
+func (s *Server) selectedStrategy(msg map[string]any) (string, error) {
+	name := s.config.WinnerStrategy
+	if selected, ok := msg["strategy"].(string); ok && strings.TrimSpace(selected) != "" {
+		name = strings.TrimSpace(selected)
+	}
+	if _, err := ranking.StrategyFor(name); err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = ranking.DefaultStrategy
+	}
+	return name, nil
+}
+
+// questionMode returns the mode of a question message and, for a debate, whether participants switch sides every round
+// A debate's positions are "fixed" (the default) or "rotating".
+func questionMode(msg map[string]any) (string, bool, error) {
+	mode, _ := msg["mode"].(string)
+	mode = strings.TrimSpace(mode)
+	positions, _ := msg["positions"].(string)
+	positions = strings.TrimSpace(positions)
+
+	switch mode {
+	case "":
+		if positions != "" {
+			return "", false, fmt.Errorf("positions only apply to mode %q", shared.ModeDebate)
+		}
+		return "", false, nil
+	case shared.ModeDebate:
+		switch positions {
+		case "", "fixed":
+			return mode, false, nil
+		case "rotating":
+			return mode, true, nil
+		}
+		return "", false, fmt.Errorf("unknown positions %q: must be fixed or rotating", positions)
+	}
+	return "", false, fmt.Errorf("unknown mode %q: must be %s or empty", mode, shared.ModeDebate)
+}
+
+// selectedMetaJudge returns the meta judge of a question message under the judge-of-judges strategy, nil under any other
+// A meta judge named in the message overrides the configured one.
+func (s *Server) selectedMetaJudge(msg map[string]any, strategy string) (*types.ModelInfo, error) {
+	if strategy != ranking.JudgeOfJudges {
+		return nil, nil
+	}
+	name := s.config.MetaJudge
+	if selected, ok := msg["meta_judge"].(string); ok && strings.TrimSpace(selected) != "" {
+		name = strings.TrimSpace(selected)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("the %s strategy needs a meta judge: set FAT_META_JUDGE or send meta_judge", ranking.JudgeOfJudges)
+	}
+	judges := s.buildJudges([]string{name})
+	if len(judges) == 0 {
+		return nil, fmt.Errorf("unknown meta judge variant %q", name)
+	}
+	return judges[0], nil
+}
+
+// selectedSynthesizer returns the model merging a question's top answers into one, nil when there's none
+// A synthesizer named in the message overrides the configured one.
+func (s *Server) selectedSynthesizer(msg map[string]any) (*types.ModelInfo, error) {
+	name := s.config.Synthesizer
+	if selected, ok := msg["synthesizer"].(string); ok && strings.TrimSpace(selected) != "" {
+		name = strings.TrimSpace(selected)
+	}
+	if name == "" {
+		return nil, nil
+	}
+	built := s.buildJudges([]string{name})
+	if len(built) == 0 {
+		return nil, fmt.Errorf("unknown synthesizer variant %q", name)
+	}
+	return built[0], nil
+}
+
+// pricing decodes a run's rate overrides, falling back to the configured price multiplier
+// Returns nil when list prices apply.
+func (s *Server) pricing(raw any) (*types.Pricing, error) {
+	var pricing *types.Pricing
+	if raw != nil {
+		data, err := json.Marshal(raw)
+		if err == nil {
+			err = json.Unmarshal(data, &pricing)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid pricing: %w", err)
+		}
+	}
+
+	if pricing == nil {
+		if s.config.PriceMultiplier == 0 {
+			return nil, nil
+		}
+		pricing = &types.Pricing{}
+	}
+	if pricing.Multiplier == 0 {
+		pricing.Multiplier = s.config.PriceMultiplier
+	}
+
+	if pricing.Multiplier < 0 {
+		return nil, fmt.Errorf("invalid pricing: multiplier must not be negative")
+	}
+	for variant, rate := range pricing.Rates {
+		if rate.In < 0 || rate.Out < 0 {
+			return nil, fmt.Errorf("invalid pricing: rates for %s must not be negative", variant)
+		}
+	}
+
+	return pricing, nil
+}
+
+// buildActiveModels creates runtime model infos for the given agent ID -> variant map
+// States saved before agents could go by their variant names map family IDs to variants, which reads the same.
+func (s *Server) buildActiveModels(agents map[string]string) []*types.ModelInfo {
+	activeModels := []*types.ModelInfo{}
+
+	for agentID, variantKey := range agents {
+		familyID := models.FamilyForVariant(variantKey)
+		if familyID == "" {
+			familyID = agentID // Logged as an unknown variant of the family it was picked for
+		}
+		if mi := s.newModelInfo(familyID, variantKey); mi != nil {
+			mi.ID = agentID
+			activeModels = append(activeModels, mi)
+		}
+	}
+
+	return activeModels
+}
+
+// buildJudges creates runtime model infos for a ranking jury given by variant names
+// Unknown variants are skipped; an empty result means participants rank each other
+func (s *Server) buildJudges(variantKeys []string) []*types.ModelInfo {
+	judges := []*types.ModelInfo{}
+	seen := make(map[string]bool, len(variantKeys))
+
+	for _, variantKey := range variantKeys {
+		if seen[variantKey] {
+			continue
+		}
+		seen[variantKey] = true
+
+		familyID := models.FamilyForVariant(variantKey)
+		if familyID == "" {
+			s.logger.Warn("unknown judge variant", slog.String("variant", variantKey))
+			continue
+		}
+
+		if mi := s.newModelInfo(familyID, variantKey); mi != nil {
+			judges = append(judges, mi)
+		}
+	}
+
+	return judges
+}
+
+// newModelInfo creates a runtime model info for one variant, or nil if it doesn't exist
+func (s *Server) newModelInfo(familyID, variantKey string) *types.ModelInfo {
+	family, ok := models.ModelFamilies[familyID]
+	if !ok {
+		s.logger.Warn("unknown model family", slog.String("family", familyID))
+		return nil
+	}
+
+	variant, ok := family.Variants[variantKey]
+	if !ok {
+		s.logger.Warn("unknown variant for family",
+			slog.String("family", familyID),
+			slog.String("variant", variantKey))
+		return nil
+	}
+
+	// A configured max_tokens takes over from FAT_MAX_OUTPUT_TOKENS, neither going past the variant's own cap
+	gen := variant.Generation.Merge(generation.GetForVariant(s.config.GenerationFile, familyID, variantKey))
+	limit := s.config.MaxOutputTokens
+	if gen.MaxTokens > 0 {
+		limit = gen.MaxTokens
+	}
+
+	mi := &types.ModelInfo{
+		ID:             family.ID,
+		Family:         family.ID,
+		Name:           variantKey,
+		MaxTok:         variant.MaxTok,
+		MaxOut:         outputCap(variant.MaxOut, limit),
+		BaseURL:        family.BaseURL,
+		Logger:         s.logger.With(config.SubsystemKey, config.SubsystemProviders, "model", variantKey),
+		RequestTimeout: s.config.ModelRequestTimeout,
+		Persona:        personas.GetForFamily(s.config.PersonasFile, familyID),
+		Headers:        headers.GetForFamily(s.config.HeadersFile, familyID),
+		Extra:          extras.Merge(variant.Extra, extras.GetForVariant(s.config.ExtrasFile, familyID, variantKey)),
+		Safety:         s.config.GeminiSafety,
+		ThinkingBudget: models.ThinkingBudget(familyID, variantKey, s.config.ClaudeThinkingBudget),
+		Responses:      variant.Responses,
+		Structured:     s.config.Structured(familyID, variantKey),
+		Generation:     gen,
+	}
+
+	if apiKey := apikeys.GetForFamily(familyID); apiKey != "" {
+		mi.APIKey = apiKey
+	} else {
+		s.logger.Warn("api key missing for model",
+			slog.String("family", familyID),
+			slog.String("model", variantKey))
+	}
+
+	return mi
+}
+
+// outputCap returns the output token cap of a variant whose own cap is variantMax under limit (0 for none)
+func outputCap(variantMax, limit int64) int64 {
+	if limit > 0 && (variantMax == 0 || limit < variantMax) {
+		return limit
+	}
+	return variantMax
+}
+
+// applyGeneration lays a run's sampling overrides (family ID or variant name -> parameters) over the models'
+func applyGeneration(modelInfos []*types.ModelInfo, overrides map[string]types.Generation) {
+	for _, mi := range modelInfos {
+		g := overrides[mi.FamilyID()].Merge(overrides[mi.Name])
+		mi.Generation = mi.Generation.Merge(g)
+		if g.MaxTokens > 0 {
+			mi.MaxOut = outputCap(models.ModelFamilies[mi.FamilyID()].Variants[mi.Name].MaxOut, g.MaxTokens)
+		}
+	}
+}
+
+// generationOverrides decodes a run's sampling overrides, keyed by family ID or variant name
+func generationOverrides(raw any) (map[string]types.Generation, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	var overrides map[string]types.Generation
+	data, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(data, &overrides)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid generation: %w", err)
+	}
+
+	for target, g := range overrides {
+		if err := generation.Validate(g); err != nil {
+			return nil, fmt.Errorf("invalid generation for %s: %w", target, err)
+		}
+	}
+
+	return overrides, nil
+}
+
+// fallbackFor returns the family's fallback variant to use when the provider doesn't know mi's variant
+// FAT_FALLBACK_MODELS picks it per family, otherwise it's the family's default variant
+func (s *Server) fallbackFor(mi *types.ModelInfo) *types.ModelInfo {
+	variant := s.config.Fallbacks[mi.FamilyID()]
+	if variant == "" {
+		variant = s.defaultVariant(mi.FamilyID())
+	}
+	if variant == "" || variant == mi.Name {
+		return nil
+	}
+	return s.newModelInfo(mi.FamilyID(), variant)
+}
+
+// startPruningRequestStates deletes the snapshots of requests left unfinished for longer than the resume TTL,
+// at startup and every hour until ctx is done. They hold the question and replies, and would otherwise pile up.
+func (s *Server) startPruningRequestStates(ctx context.Context) {
+	if s.config.ResumeTTL <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			if pruned, err := s.database.PruneRequestStates(ctx, time.Now().Add(-s.config.ResumeTTL)); err != nil {
+				s.logger.Warn("failed to prune abandoned requests", slog.Any("error", err))
+			} else if pruned > 0 {
+				s.logger.Info("pruned abandoned requests", slog.Int64("count", pruned))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// handleResume continues an interrupted request in the background
+func (s *Server) handleResume(c *gin.Context) {
+	requestID := c.Param("id")
+
+	if s.orchestrator.ShuttingDown() {
+		c.JSON(503, gin.H{"error": orchestrator.ErrShuttingDown.Error()})
+		return
+	}
+	if s.orchestrator.QueueFull() {
+		c.JSON(429, gin.H{"error": orchestrator.ErrQueueFull.Error()})
+		return
+	}
+
+	st, err := s.database.GetRequestState(c.Request.Context(), requestID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if st == nil {
+		c.JSON(404, gin.H{"error": "no saved state for request"})
+		return
+	}
+	if st.Status != db.StateRunning {
+		c.JSON(409, gin.H{"error": "request already " + st.Status})
+		return
+	}
+
+	var variants map[string]string
+	if err := json.Unmarshal([]byte(st.Models), &variants); err != nil {
+		c.JSON(500, gin.H{"error": "corrupt saved models: " + err.Error()})
+		return
+	}
+	activeModels := s.buildActiveModels(variants)
+
+	var opts orchestrator.Options
+	if st.Options != "" {
+		if err := json.Unmarshal([]byte(st.Options), &opts); err != nil {
+			c.JSON(500, gin.H{"error": "corrupt saved options: " + err.Error()})
+			return
+		}
+	}
+	judges := s.buildJudges(opts.Judges)
+	applyGeneration(activeModels, opts.Generation)
+	applyGeneration(judges, opts.Generation)
+	var metaJudge *types.ModelInfo
+	if opts.MetaJudge != "" {
+		if built := s.buildJudges([]string{opts.MetaJudge}); len(built) > 0 {
+			metaJudge = built[0]
+			applyGeneration(built, opts.Generation)
+		}
+	}
+	var synthesizer *types.ModelInfo
+	if opts.Synthesizer != "" {
+		if built := s.buildJudges([]string{opts.Synthesizer}); len(built) > 0 {
+			synthesizer = built[0]
+			applyGeneration(built, opts.Generation)
+		}
+	}
+
+	for _, mi := range activeModels {
+		s.Broadcast(withClientRef(map[string]any{
+			"type":       "loading",
+			"model":      mi.ID,
+			"request_id": requestID,
+		}, opts.ClientRef))
+	}
+
+	// Detach from the HTTP request - resumed runs outlive it
+	go func() {
+		if err := s.orchestrator.Resume(context.Background(), requestID, activeModels, judges, metaJudge, synthesizer); err != nil {
+			s.logger.Error("failed to resume request",
+				slog.String("request_id", requestID),
+				slog.Any("error", err))
+		}
+	}()
+
+	c.JSON(202, gin.H{
+		"status":     "resuming",
+		"request_id": requestID,
+		"from_round": st.Round + 1,
+		"rounds":     st.NumRounds,
+	})
+}
+
+// handleAddSampleQuestion adds a question to the random question pool
+func (s *Server) handleAddSampleQuestion(c *gin.Context) {
+	var body struct {
+		Question string `json:"question"`
+		Category string `json:"category"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(body.Question) == "" {
+		c.JSON(400, gin.H{"error": "question is required"})
+		return
+	}
+
+	question, err := s.database.AddSampleQuestion(c.Request.Context(), body.Question, body.Category)
+	if errors.Is(err, db.ErrDuplicateQuestion) {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(201, question)
+}
+
+// handleUpdateSampleQuestion edits, recategorizes, or enables/disables a sample question
+func (s *Server) handleUpdateSampleQuestion(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid question id"})
+		return
+	}
+
+	var body struct {
+		Question *string `json:"question"`
+		Category *string `json:"category"`
+		Enabled  *bool   `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body: " + err.Error()})
+		return
+	}
+	if body.Question != nil && strings.TrimSpace(*body.Question) == "" {
+		c.JSON(400, gin.H{"error": "question cannot be empty"})
+		return
+	}
+
+	question, err := s.database.UpdateSampleQuestion(c.Request.Context(), id, db.SampleQuestionUpdate{
+		Question: body.Question,
+		Category: body.Category,
+		Enabled:  body.Enabled,
+	})
+	if errors.Is(err, db.ErrDuplicateQuestion) {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if question == nil {
+		c.JSON(404, gin.H{"error": "question not found"})
+		return
+	}
 
-	c.Data(200, "text/html; charset=utf-8", []byte(html.String()))
+	c.JSON(200, question)
 }
@@ -0,0 +1,270 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/meedamian/fat/internal/apikeys"
+	"github.com/meedamian/fat/internal/models"
+)
+
+// keyValidationTimeout bounds the live check of all keys in one setup request
+const keyValidationTimeout = 15 * time.Second
+
+// keyCheck is the outcome of validating one family's key
+type keyCheck struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// defaultVariant returns the variant used for a family when the client doesn't pick one
+func (s *Server) defaultVariant(familyID string) string {
+	s.defaultsMu.RLock()
+	defer s.defaultsMu.RUnlock()
+
+	return s.defaults[familyID]
+}
+
+// serveSetupPage serves the first-run setup wizard
+func (s *Server) serveSetupPage(c *gin.Context) {
+	data, err := fs.ReadFile(s.staticFS, "static/setup.html")
+	if err != nil {
+		c.String(500, "Failed to load setup.html")
+		return
+	}
+	c.Data(200, "text/html; charset=utf-8", data)
+}
+
+// handleSetupStatus reports which families are missing a key and the current default variants
+func (s *Server) handleSetupStatus(c *gin.Context) {
+	familyIDs := make([]string, 0, len(models.ModelFamilies))
+	for familyID := range models.ModelFamilies {
+		familyIDs = append(familyIDs, familyID)
+	}
+	sort.Strings(familyIDs)
+
+	complete := true
+	families := make([]gin.H, 0, len(familyIDs))
+	for _, familyID := range familyIDs {
+		family := models.ModelFamilies[familyID]
+
+		variants := make([]string, 0, len(family.Variants))
+		for variantKey := range family.Variants {
+			variants = append(variants, variantKey)
+		}
+		sort.Strings(variants)
+
+		source := apikeys.Source(familyID)
+		if source == "" {
+			complete = false
+		}
+
+		families = append(families, gin.H{
+			"id":       familyID,
+			"provider": family.Provider,
+			"env_var":  apikeys.EnvVar(familyID),
+			"has_key":  source != "",
+			"source":   source,
+			"default":  s.defaultVariant(familyID),
+			"variants": variants,
+		})
+	}
+
+	c.JSON(200, gin.H{
+		"complete": complete,
+		"families": families,
+	})
+}
+
+// handleSetupValidate checks the configured keys (all, or the listed families) with their providers
+func (s *Server) handleSetupValidate(c *gin.Context) {
+	var body struct {
+		Families []string `json:"families"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(400, gin.H{"error": "invalid body: " + err.Error()})
+			return
+		}
+	}
+
+	familyIDs := body.Families
+	if len(familyIDs) == 0 {
+		for familyID := range models.ModelFamilies {
+			familyIDs = append(familyIDs, familyID)
+		}
+	}
+
+	keys := make(map[string]string, len(familyIDs))
+	for _, familyID := range familyIDs {
+		if _, ok := models.ModelFamilies[familyID]; !ok {
+			c.JSON(400, gin.H{"error": "unknown model family " + familyID})
+			return
+		}
+		keys[familyID] = apikeys.GetForFamily(familyID)
+	}
+
+	c.JSON(200, gin.H{"results": s.validateKeys(c.Request.Context(), keys)})
+}
+
+// handleSetupKeys validates pasted keys live and stores the valid ones in keys.json or an env file
+func (s *Server) handleSetupKeys(c *gin.Context) {
+	var body struct {
+		Keys           map[string]string `json:"keys"`
+		Store          string            `json:"store"`    // "json" (default, encrypted if a passphrase is set) or "env"
+		EnvFile        string            `json:"env_file"` // Env file to write with store "env", default .env
+		SkipValidation bool              `json:"skip_validation"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body: " + err.Error()})
+		return
+	}
+
+	keys := make(map[string]string, len(body.Keys))
+	for familyID, key := range body.Keys {
+		if _, ok := models.ModelFamilies[familyID]; !ok {
+			c.JSON(400, gin.H{"error": "unknown model family " + familyID})
+			return
+		}
+		if key = strings.TrimSpace(key); key != "" {
+			keys[familyID] = key
+		}
+	}
+	if len(keys) == 0 {
+		c.JSON(400, gin.H{"error": "no keys given"})
+		return
+	}
+
+	results := make(map[string]keyCheck, len(keys))
+	if body.SkipValidation {
+		for familyID := range keys {
+			results[familyID] = keyCheck{Valid: true}
+		}
+	} else {
+		results = s.validateKeys(c.Request.Context(), keys)
+	}
+
+	valid := make(map[string]string, len(keys))
+	saved := make([]string, 0, len(keys))
+	for familyID, key := range keys {
+		if results[familyID].Valid {
+			valid[familyID] = key
+			saved = append(saved, familyID)
+		}
+	}
+	sort.Strings(saved)
+	if len(valid) == 0 {
+		c.JSON(422, gin.H{"error": "no valid keys", "results": results})
+		return
+	}
+
+	var path string
+	var err error
+	switch body.Store {
+	case "", "json":
+		if apikeys.Encrypted() {
+			path = apikeys.EncryptedKeysFile
+			err = apikeys.SaveEncrypted(path, os.Getenv(apikeys.PassphraseEnvVar), valid)
+		} else {
+			path = apikeys.KeysFile
+			err = apikeys.SaveJSON(path, valid)
+		}
+	case "env":
+		path = body.EnvFile
+		if path == "" {
+			path = ".env"
+		}
+		err = apikeys.SaveEnv(path, valid)
+	default:
+		c.JSON(400, gin.H{"error": "store must be json or env"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.logger.Info("api keys saved via setup", slog.String("file", path), slog.Any("families", saved))
+
+	c.JSON(200, gin.H{
+		"saved":   saved,
+		"file":    path,
+		"results": results,
+	})
+}
+
+// handleSetupDefaults changes the default variant of one or more families and persists the selection
+func (s *Server) handleSetupDefaults(c *gin.Context) {
+	var body struct {
+		Defaults map[string]string `json:"defaults"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body: " + err.Error()})
+		return
+	}
+	if err := models.ValidateDefaults(body.Defaults); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.defaultsMu.Lock()
+	defer s.defaultsMu.Unlock()
+
+	merged := make(map[string]string, len(s.defaults))
+	for familyID, variant := range s.defaults {
+		merged[familyID] = variant
+	}
+	for familyID, variant := range body.Defaults {
+		merged[familyID] = variant
+	}
+
+	if err := models.SaveDefaults(s.config.DefaultsFile, merged); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	s.defaults = merged
+
+	c.JSON(200, gin.H{"defaults": merged})
+}
+
+// validateKeys checks keys (family ID -> key) with their providers in parallel
+func (s *Server) validateKeys(ctx context.Context, keys map[string]string) map[string]keyCheck {
+	ctx, cancel := context.WithTimeout(ctx, keyValidationTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]keyCheck, len(keys))
+	for familyID, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			check := keyCheck{Valid: true}
+			if key == "" {
+				check = keyCheck{Error: "no key configured"}
+			} else if err := apikeys.Validate(ctx, http.DefaultClient, familyID, key); err != nil {
+				check = keyCheck{Error: err.Error()}
+				if !errors.Is(err, apikeys.ErrInvalidKey) {
+					s.logger.Warn("api key validation failed", slog.String("family", familyID), slog.Any("error", err))
+				}
+			}
+
+			mu.Lock()
+			results[familyID] = check
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/meedamian/fat/internal/statshistory"
+)
+
+// defaultHistoryDays is how far back the stats history goes unless ?days= says otherwise
+const defaultHistoryDays = 90
+
+// handleStatsHistory lists every model's daily snapshots of the last ?days= days (default 90)
+func (s *Server) handleStatsHistory(c *gin.Context) {
+	days, err := historyDays(c.Query("days"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	series, err := statshistory.Load(c.Request.Context(), s.database, days)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"days": days, "models": series})
+}
+
+// handleStatsHistoryChart draws the same as an SVG chart of ?metric=, win_rate (default) or avg_cost
+func (s *Server) handleStatsHistoryChart(c *gin.Context) {
+	days, err := historyDays(c.Query("days"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	series, err := statshistory.Load(c.Request.Context(), s.database, days)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	svg, err := statshistory.Chart(series, c.DefaultQuery("metric", statshistory.WinRate))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(200, "image/svg+xml", svg)
+}
+
+// historyDays parses ?days=, which defaults to defaultHistoryDays
+func historyDays(v string) (int, error) {
+	if v == "" {
+		return defaultHistoryDays, nil
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("days must be a positive number, got %q", v)
+	}
+	return days, nil
+}
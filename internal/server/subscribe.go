@@ -0,0 +1,101 @@
+package server
+
+import (
+	"crypto/subtle"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsClient is what a WebSocket connection receives besides messages about no request in particular
+type wsClient struct {
+	requests   map[string]bool // Requests it asked or subscribed to
+	all        bool            // Every request's events, for admins holding the firehose token
+	credential string          // Token or access key the connection was opened with, checked before each question
+
+	// Messages waiting for the connection's writer
+	mu      sync.Mutex
+	pending []outgoing
+	dropped int           // Superseded messages dropped since the writer last ran
+	wake    chan struct{} // Signals the writer that messages are pending
+	done    chan struct{} // Closed once the connection is gone
+}
+
+func newWSClient(credential string) *wsClient {
+	return &wsClient{
+		requests:   make(map[string]bool),
+		credential: credential,
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// follows reports whether the client receives a message broadcast for requestID, "" being no request
+func (c *wsClient) follows(requestID string) bool {
+	return requestID == "" || c.all || c.requests[requestID]
+}
+
+// subscribe makes conn receive the events of a request; a no-op for connections that already left
+func (s *Server) subscribe(conn *websocket.Conn, requestID string) {
+	s.clientsMutex.Lock()
+	defer s.clientsMutex.Unlock()
+
+	if client, ok := s.clients[conn]; ok {
+		client.requests[requestID] = true
+	}
+}
+
+// handleSubscribeWS changes which requests' events a connection receives
+// {"request_id": "..."} follows one request, {"all": true, "token": "..."} every request.
+func (s *Server) handleSubscribeWS(conn *websocket.Conn, msg map[string]any) {
+	subscribe := msg["type"] == "subscribe"
+	requestID, _ := msg["request_id"].(string)
+	all, _ := msg["all"].(bool)
+
+	if requestID == "" && !all {
+		s.send(conn, map[string]any{
+			"type":  "error",
+			"error": "request_id or all is required",
+		})
+		return
+	}
+
+	if all && subscribe {
+		token, _ := msg["token"].(string)
+		if s.config.FirehoseToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.FirehoseToken)) != 1 {
+			s.send(conn, map[string]any{
+				"type":  "error",
+				"error": "subscribing to every request needs the firehose token",
+			})
+			return
+		}
+	}
+
+	s.clientsMutex.Lock()
+	defer s.clientsMutex.Unlock()
+
+	client, ok := s.clients[conn]
+	if !ok {
+		return
+	}
+	if all {
+		client.all = subscribe
+	}
+	if requestID != "" {
+		if subscribe {
+			client.requests[requestID] = true
+		} else {
+			delete(client.requests, requestID)
+		}
+	}
+
+	reply := "subscribed"
+	if !subscribe {
+		reply = "unsubscribed"
+	}
+	client.send(map[string]any{
+		"type":       reply,
+		"request_id": requestID,
+		"all":        client.all,
+	})
+}
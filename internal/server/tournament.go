@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/models"
+	"github.com/meedamian/fat/internal/types"
+)
+
+// Tournament runs question through a single-elimination bracket without serving HTTP, passing every message
+// of the tournament to progress
+// entrants are seeded in the order given, by variant name or family ID (its default variant); jury judges
+// every match, and none means the configured judges.
+func (s *Server) Tournament(ctx context.Context, question string, entrants, jury []string, progress func(map[string]any)) (*db.Tournament, error) {
+	players, err := s.tournamentModels(entrants)
+	if err != nil {
+		return nil, err
+	}
+	if len(players) < 2 {
+		return nil, errors.New("a tournament needs at least 2 different models")
+	}
+
+	if len(jury) == 0 {
+		jury = s.config.Judges
+	}
+	jurors, err := s.tournamentModels(jury)
+	if err != nil {
+		return nil, err
+	}
+	if len(jurors) == 0 {
+		return nil, errors.New("a tournament needs a jury")
+	}
+
+	pricing, err := s.pricing(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	question, err = s.orchestrator.ResolveAnswerReferences(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+
+	tournamentID := uuid.New().String()
+	s.clientsMutex.Lock()
+	s.listener = func(message map[string]any) {
+		if message["tournament_id"] == tournamentID {
+			progress(message)
+		}
+	}
+	s.clientsMutex.Unlock()
+
+	defer func() {
+		s.clientsMutex.Lock()
+		s.listener = nil
+		s.clientsMutex.Unlock()
+	}()
+
+	return s.orchestrator.RunTournament(ctx, tournamentID, question, players, jurors, pricing, time.Now().Unix())
+}
+
+// tournamentModels resolves picks to one model each, skipping repeats so no variant plays itself
+func (s *Server) tournamentModels(picks []string) ([]*types.ModelInfo, error) {
+	variants := make([]string, 0, len(picks))
+	seen := make(map[string]bool, len(picks))
+	for _, pick := range picks {
+		variant := pick
+		if _, ok := models.ModelFamilies[pick]; ok {
+			variant = s.defaultVariant(pick)
+		} else if models.FamilyForVariant(pick) == "" {
+			return nil, fmt.Errorf("unknown model %q", pick)
+		}
+		if !seen[variant] {
+			seen[variant] = true
+			variants = append(variants, variant)
+		}
+	}
+	return s.buildJudges(variants), nil
+}
+
+// handleTournament returns a tournament with its answers and bracket
+func (s *Server) handleTournament(c *gin.Context) {
+	t, err := s.database.GetTournament(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if t == nil {
+		c.JSON(404, gin.H{"error": "tournament not found"})
+		return
+	}
+	c.JSON(200, t)
+}
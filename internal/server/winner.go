@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/meedamian/fat/internal/db"
+)
+
+// handleHumanWinner records the winner a person picked for a run whose strategy left it to them
+// The pick is broadcast as a human_winner message; the run's exports keep the judges' provisional winner.
+func (s *Server) handleHumanWinner(c *gin.Context) {
+	requestID := c.Param("id")
+
+	var body struct {
+		Model string `json:"model"` // Model ID of the winning participant
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body: " + err.Error()})
+		return
+	}
+	model := strings.TrimSpace(body.Model)
+	if model == "" {
+		c.JSON(400, gin.H{"error": "model is required"})
+		return
+	}
+
+	err := s.database.SetHumanWinner(c.Request.Context(), requestID, model)
+	switch {
+	case errors.Is(err, db.ErrRequestNotFound):
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, db.ErrNotAwaitingHuman):
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, db.ErrNotParticipant):
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The pick is part of the run, so it echoes the submitter's client reference like the run's own messages
+	message := map[string]any{
+		"type":       "human_winner",
+		"model":      model,
+		"request_id": requestID,
+	}
+	if req, err := s.database.GetRequest(c.Request.Context(), requestID); err == nil && req != nil {
+		withClientRef(message, req.ClientRef)
+	}
+	s.Broadcast(message)
+	c.JSON(200, gin.H{"request_id": requestID, "winner": model})
+}
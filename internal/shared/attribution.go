@@ -0,0 +1,35 @@
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Attribution fills in an attribution line disclosing how a winning answer was made
+// format may use {models} for the number of participants, {winner} for the winning model's name
+// and {cost} for the run's total cost in dollars.
+func Attribution(format string, models int, winner string, cost float64) string {
+	return strings.NewReplacer(
+		"{models}", strconv.Itoa(models),
+		"{winner}", winner,
+		"{cost}", fmt.Sprintf("%.4f", cost),
+	).Replace(format)
+}
+
+// WithAttribution appends an attribution line to an answer, separated by a blank line
+// An empty attribution leaves the answer as it was.
+func WithAttribution(answer, attribution string) string {
+	if attribution == "" {
+		return answer
+	}
+	return strings.TrimRight(answer, "\n") + "\n\n" + attribution
+}
+
+// WithoutAttribution strips an attribution line added by WithAttribution, e.g. before passing the answer on to another run
+func WithoutAttribution(answer, attribution string) string {
+	if attribution == "" {
+		return answer
+	}
+	return strings.TrimSuffix(answer, "\n\n"+attribution)
+}
@@ -0,0 +1,22 @@
+package shared
+
+import "testing"
+
+func TestAttribution(t *testing.T) {
+	line := Attribution("Generated by {models} models via fat, winner: {winner}, cost: ${cost}", 4, "grok-4", 0.01234)
+	if want := "Generated by 4 models via fat, winner: grok-4, cost: $0.0123"; line != want {
+		t.Errorf("Expected %q, got %q", want, line)
+	}
+
+	answer := WithAttribution("The answer is 42.\n", line)
+	if want := "The answer is 42.\n\n" + line; answer != want {
+		t.Errorf("Expected %q, got %q", want, answer)
+	}
+	if got := WithoutAttribution(answer, line); got != "The answer is 42." {
+		t.Errorf("Expected the attribution stripped, got %q", got)
+	}
+
+	if got := WithAttribution("The answer is 42.", ""); got != "The answer is 42." {
+		t.Errorf("Expected no attribution to leave the answer alone, got %q", got)
+	}
+}
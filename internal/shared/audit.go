@@ -0,0 +1,51 @@
+package shared
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// sentenceEnd matches the end of a reason's first sentence
+var sentenceEnd = regexp.MustCompile(`[.!?](\s|$)`)
+
+// MissingReasons lists the agents an audited ranking leaves without a placement and a reason, sorted
+// agents are those the judge was shown.
+func MissingReasons(ranking []string, reasons map[string]string, agents []string) []string {
+	var missing []string
+	for _, agent := range agents {
+		if !slices.Contains(ranking, agent) || strings.TrimSpace(reasons[agent]) == "" {
+			missing = append(missing, agent)
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// FirstSentence cuts a reason down to its first sentence
+func FirstSentence(reason string) string {
+	reason = strings.TrimSpace(reason)
+	if loc := sentenceEnd.FindStringIndex(reason); loc != nil {
+		return reason[:loc[0]+1]
+	}
+	return reason
+}
+
+// FormatJustificationReminder is sent after an audited ranking prompt whose reply left answers unjustified
+// missing names the agents as anonMap letters, and the prompt is repeated so the reply can be parsed with it.
+func FormatJustificationReminder(prompt string, missing []string, anonMap map[string]string) string {
+	letters := make([]string, 0, len(missing))
+	for _, agent := range missing {
+		if letter, ok := anonMap[agent]; ok {
+			letters = append(letters, letter)
+		}
+	}
+	slices.Sort(letters)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("⚠️  YOUR PREVIOUS RANKING WAS REJECTED: agent(s) %s had no placement or no reason.\n", strings.Join(letters, ", ")))
+	b.WriteString("Every answer must be placed, and every placement needs a one-sentence reason.\n\n")
+	b.WriteString(prompt)
+	return b.String()
+}
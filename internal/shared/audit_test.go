@@ -0,0 +1,47 @@
+package shared
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMissingReasons(t *testing.T) {
+	agents := []string{"Grok", "GPT", "Claude"}
+	reasons := map[string]string{"GPT": "Cites the spec", "Grok": " "}
+
+	missing := MissingReasons([]string{"GPT", "Grok"}, reasons, agents)
+	if !slices.Equal(missing, []string{"Claude", "Grok"}) {
+		t.Errorf("Expected Claude unplaced and Grok unjustified, got %v", missing)
+	}
+
+	reasons["Grok"], reasons["Claude"] = "Wrong", "Vague"
+	if missing := MissingReasons([]string{"GPT", "Grok", "Claude"}, reasons, agents); len(missing) != 0 {
+		t.Errorf("Expected a complete ranking, got %v missing", missing)
+	}
+}
+
+func TestFirstSentence(t *testing.T) {
+	for in, want := range map[string]string{
+		"Most accurate. Also the shortest.": "Most accurate.",
+		"Uses v1.2 of the API! Good":        "Uses v1.2 of the API!",
+		"  no full stop  ":                  "no full stop",
+		"Is it right?":                      "Is it right?",
+	} {
+		if got := FirstSentence(in); got != want {
+			t.Errorf("FirstSentence(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatJustificationReminder(t *testing.T) {
+	prompt := "rank these <!-- ANONYMIZATION_MAP: A=Grok B=GPT -->"
+	reminder := FormatJustificationReminder(prompt, []string{"GPT", "Grok"}, map[string]string{"Grok": "A", "GPT": "B"})
+
+	if !strings.Contains(reminder, "agent(s) A, B had no placement") {
+		t.Errorf("Expected the missing letters, got %q", reminder)
+	}
+	if !strings.HasSuffix(reminder, prompt) {
+		t.Error("Expected the prompt repeated, so the reply can be decoded")
+	}
+}
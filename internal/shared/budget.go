@@ -0,0 +1,204 @@
+package shared
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// ResponseReserve is how many tokens of a model's context window are kept free for its reply
+const ResponseReserve = 8192
+
+// minOutputTokens is the least output ever requested, so a nearly full context still gets an answer
+const minOutputTokens = 1024
+
+// maxTokensPerSecond is a generation speed few models exceed; a reply longer than the request
+// timeout at this speed would be cut off by the timeout anyway, after being paid for
+const maxTokensPerSecond = 200
+
+// minPartTokens is the smallest useful remainder of a trimmed part; anything shorter is dropped
+const minPartTokens = 32
+
+// truncationMarker is appended to parts that were cut short
+const truncationMarker = " [truncated]\n\n"
+
+// trimmedNotice tells the model that some of its context is missing
+const trimmedNotice = "(Some earlier context was shortened or omitted to fit your context window.)\n\n"
+
+// Trim priorities for prompt context within a round, lowest trimmed first
+const (
+	priorityOtherReplies = iota
+	prioritySearchResults
+	priorityPrivateNotes
+	priorityDiscussion
+	priorityOwnAnswer
+)
+
+// EstimateTokens approximates how many tokens text occupies for a BPE tokenizer
+// ASCII words cost one token per four characters, punctuation and non-ASCII runes one each.
+// It deliberately errs on the high side so trimmed prompts still fit.
+func EstimateTokens(text string) int {
+	tokens := 0
+	word := 0
+	for _, r := range text {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			word++
+			continue
+		}
+		tokens += (word + 3) / 4
+		word = 0
+		if !unicode.IsSpace(r) {
+			tokens++
+		}
+	}
+	return tokens + (word+3)/4
+}
+
+// promptBudget returns how many tokens a prompt may use for a model with the given context window
+// A window of 0 means unknown, in which case prompts are never trimmed
+func promptBudget(maxTok int64) int {
+	if maxTok <= 0 {
+		return 0
+	}
+	if maxTok <= 2*ResponseReserve {
+		return int(maxTok / 2)
+	}
+	return int(maxTok - ResponseReserve)
+}
+
+// OutputBudget returns the max output tokens to request for prompt (sent along with the model's persona)
+// It's the smallest of the context window left after the prompt, the variant's output cap and what
+// could be generated before the request timeout, but never below minOutputTokens.
+// Returns 0 when nothing limits the output.
+func OutputBudget(info *types.ModelInfo, prompt string) int64 {
+	var budget int64
+	limit := func(n int64) {
+		if n > 0 && (budget == 0 || n < budget) {
+			budget = n
+		}
+	}
+
+	if info.MaxTok > 0 {
+		limit(max(info.MaxTok-int64(EstimateTokens(info.Persona)+EstimateTokens(prompt)), 1))
+	}
+	limit(info.MaxOut)
+	limit(int64(info.RequestTimeout.Seconds() * maxTokensPerSecond))
+
+	if budget == 0 {
+		return 0
+	}
+	floor := int64(minOutputTokens)
+	if info.MaxOut > 0 {
+		floor = min(floor, info.MaxOut)
+	}
+	return max(budget, floor)
+}
+
+// promptPart is a trimmable piece of prompt context
+type promptPart struct {
+	text     string
+	round    int // Older rounds are trimmed first
+	priority int // Within a round, lower priorities are trimmed first
+}
+
+// fitParts trims parts in place until they fit in budget tokens, returning whether anything was trimmed
+// Parts are trimmed a group at a time (same round and priority), sharing the cut evenly within a group
+// so no single agent's reply loses everything while another stays whole.
+func fitParts(parts []*promptPart, budget int) bool {
+	total := 0
+	for _, p := range parts {
+		total += EstimateTokens(p.text)
+	}
+	if total <= budget {
+		return false
+	}
+
+	order := slices.Clone(parts)
+	slices.SortStableFunc(order, func(a, b *promptPart) int {
+		if a.round != b.round {
+			return a.round - b.round
+		}
+		return a.priority - b.priority
+	})
+
+	for start := 0; start < len(order) && total > budget; {
+		end := start + 1
+		for end < len(order) && order[end].priority == order[start].priority && order[end].round == order[start].round {
+			end++
+		}
+		group := order[start:end]
+		start = end
+
+		sizes := make([]int, len(group))
+		groupTotal := 0
+		for i, p := range group {
+			sizes[i] = EstimateTokens(p.text)
+			groupTotal += sizes[i]
+		}
+
+		keep := groupTotal - (total - budget)
+		if keep < minPartTokens*len(group) {
+			for _, p := range group {
+				p.text = ""
+			}
+			total -= groupTotal
+			continue
+		}
+
+		limit := waterLevel(sizes, keep)
+		for i, p := range group {
+			if sizes[i] > limit {
+				p.text = truncateTokens(p.text, limit)
+				total += EstimateTokens(p.text) - sizes[i]
+			}
+		}
+	}
+
+	return true
+}
+
+// waterLevel finds the per-part cap at which the capped sizes sum to at most keep
+func waterLevel(sizes []int, keep int) int {
+	sorted := slices.Clone(sizes)
+	slices.Sort(sorted)
+	for i, s := range sorted {
+		share := keep / (len(sorted) - i)
+		if s > share {
+			return share
+		}
+		keep -= s
+	}
+	return sorted[len(sorted)-1]
+}
+
+// truncateTokens cuts text at a word boundary so that it plus the truncation marker fits in limit tokens
+func truncateTokens(text string, limit int) string {
+	limit -= EstimateTokens(truncationMarker)
+	if limit <= 0 {
+		return ""
+	}
+
+	// Largest prefix within the limit; the estimate grows monotonically with the prefix
+	bounds := make([]int, 0, len(text)+1)
+	for i := range text {
+		bounds = append(bounds, i)
+	}
+	bounds = append(bounds, len(text))
+	n := sort.Search(len(bounds), func(i int) bool {
+		return EstimateTokens(text[:bounds[i]]) > limit
+	})
+
+	prefix := text[:bounds[max(0, n-1)]]
+	if cut := strings.LastIndexFunc(prefix, unicode.IsSpace); cut > 0 {
+		prefix = prefix[:cut]
+	}
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return ""
+	}
+	return prefix + truncationMarker
+}
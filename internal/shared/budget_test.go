@@ -0,0 +1,158 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 2},
+		{"hello world", 4},
+		{"a, b.", 4},
+		{"日本", 2},
+	}
+
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateTokens(t *testing.T) {
+	text := strings.Repeat("word ", 200)
+
+	got := truncateTokens(text, 50)
+	if !strings.HasSuffix(got, truncationMarker) {
+		t.Errorf("expected truncation marker, got %q", got)
+	}
+	if n := EstimateTokens(got); n > 50 {
+		t.Errorf("truncated text has %d tokens, want at most 50", n)
+	}
+	if strings.Contains(got, "wor ") {
+		t.Error("text should be cut at a word boundary")
+	}
+
+	if got := truncateTokens(text, 1); got != "" {
+		t.Errorf("limit below marker size should drop text, got %q", got)
+	}
+}
+
+func TestFitParts(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 100) // 400 tokens
+
+	own := &promptPart{text: long, round: 2, priority: priorityOwnAnswer}
+	gpt := &promptPart{text: long, round: 2, priority: priorityOtherReplies}
+	claude := &promptPart{text: long, round: 2, priority: priorityOtherReplies}
+	oldMsg := &promptPart{text: long, round: 1, priority: priorityDiscussion}
+	newMsg := &promptPart{text: long, round: 2, priority: priorityDiscussion}
+	parts := []*promptPart{own, gpt, claude, oldMsg, newMsg}
+
+	if fitParts(parts, 5*400) {
+		t.Error("parts within budget should not be trimmed")
+	}
+
+	if !fitParts(parts, 1000) {
+		t.Fatal("expected parts to be trimmed")
+	}
+
+	total := 0
+	for _, p := range parts {
+		total += EstimateTokens(p.text)
+	}
+	if total > 1000 {
+		t.Errorf("trimmed parts use %d tokens, want at most 1000", total)
+	}
+
+	if oldMsg.text != "" {
+		t.Error("discussion from an older round should be dropped first")
+	}
+	if own.text != long || newMsg.text != long {
+		t.Error("own answer and latest discussion should be kept whole")
+	}
+	if gpt.text == "" || claude.text == "" {
+		t.Error("other replies should be shortened, not dropped")
+	}
+	if d := EstimateTokens(gpt.text) - EstimateTokens(claude.text); d < -1 || d > 1 {
+		t.Errorf("other replies should be trimmed evenly, got %q and %q", gpt.text, claude.text)
+	}
+}
+
+func TestFormatPromptTrimsToContextWindow(t *testing.T) {
+	long := strings.Repeat("lorem ipsum dolor sit amet ", 1000) // 8k tokens each
+
+	meta := types.Meta{
+		Round:       3,
+		TotalRounds: 3,
+		OtherAgents: []string{"gpt-5", "claude-sonnet-4-5"},
+		MaxTok:      32_000,
+	}
+	replies := map[string]types.Reply{
+		"mistral": {Answer: "OWN ANSWER " + long},
+		"gpt":     {Answer: "GPT ANSWER " + long},
+		"claude":  {Answer: "CLAUDE ANSWER " + long},
+	}
+	discussion := map[string]map[string][]types.DiscussionMessage{
+		"mistral": {
+			"gpt":    {{From: "gpt", Message: "OLD MESSAGE " + long, Round: 1}},
+			"claude": {{From: "claude", Message: "LATEST MESSAGE", Round: 2}},
+		},
+	}
+	notes := map[int]string{1: "NOTE ONE " + long, 2: "NOTE TWO"}
+
+	prompt := FormatPrompt("mistral", "mistral-small-latest", "Question?", meta, replies, discussion, notes)
+
+	if n := EstimateTokens(prompt); n > promptBudget(meta.MaxTok) {
+		t.Errorf("prompt uses %d tokens, want at most %d", n, promptBudget(meta.MaxTok))
+	}
+
+	for _, want := range []string{"OWN ANSWER", "LATEST MESSAGE", "NOTE TWO", "--- RESPONSE FORMAT ---", "shortened or omitted"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("trimmed prompt should keep %q", want)
+		}
+	}
+	if strings.Contains(prompt, "OLD MESSAGE") {
+		t.Error("older discussion should be dropped before anything else")
+	}
+
+	// Without a context window nothing is trimmed
+	meta.MaxTok = 0
+	prompt = FormatPrompt("mistral", "mistral-small-latest", "Question?", meta, replies, discussion, notes)
+	if strings.Contains(prompt, "[truncated]") || !strings.Contains(prompt, "OLD MESSAGE") {
+		t.Error("prompt should not be trimmed when MaxTok is unset")
+	}
+}
+
+func TestOutputBudget(t *testing.T) {
+	prompt := strings.Repeat("word ", 1000) // 1000 tokens
+
+	tests := []struct {
+		name string
+		info types.ModelInfo
+		want int64
+	}{
+		{"nothing known", types.ModelInfo{}, 0},
+		{"context left after prompt", types.ModelInfo{MaxTok: 5000}, 4000},
+		{"variant cap", types.ModelInfo{MaxTok: 200_000, MaxOut: 8192}, 8192},
+		{"timeout cap", types.ModelInfo{MaxTok: 200_000, MaxOut: 128_000, RequestTimeout: 60 * time.Second}, 12_000},
+		{"persona counts", types.ModelInfo{MaxTok: 5000, Persona: strings.Repeat("role ", 500)}, 3500},
+		{"floor when context is full", types.ModelInfo{MaxTok: 1000}, 1024},
+		{"floor never exceeds variant cap", types.ModelInfo{MaxTok: 1000, MaxOut: 512}, 512},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OutputBudget(&tt.info, prompt); got != tt.want {
+				t.Errorf("OutputBudget = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package shared
 
 import (
 	"net/http"
+	"net/http/httptrace"
+	"sync"
 	"time"
 )
 
@@ -12,6 +14,7 @@ type HTTPClient interface {
 
 // defaultTransport is a shared transport with optimized connection pooling settings
 var defaultTransport = &http.Transport{
+	Proxy:               http.ProxyFromEnvironment,
 	MaxIdleConns:        100,
 	MaxIdleConnsPerHost: 20,
 	IdleConnTimeout:     90 * time.Second,
@@ -25,6 +28,56 @@ func NewHTTPClient(timeout time.Duration) *http.Client {
 
 	return &http.Client{
 		Timeout:   timeout,
-		Transport: defaultTransport,
+		Transport: countingTransport{base: defaultTransport},
 	}
 }
+
+// ConnStat counts the connections requests to one host got from the shared transport
+type ConnStat struct {
+	New    int64 `json:"new"`
+	Reused int64 `json:"reused"` // Taken from the idle pool instead of dialing
+}
+
+var (
+	connStatsMu sync.Mutex
+	connStats   = make(map[string]*ConnStat)
+)
+
+// ConnStats returns the connection counts per host since startup
+func ConnStats() map[string]ConnStat {
+	connStatsMu.Lock()
+	defer connStatsMu.Unlock()
+
+	stats := make(map[string]ConnStat, len(connStats))
+	for host, stat := range connStats {
+		stats[host] = *stat
+	}
+	return stats
+}
+
+// countingTransport records whether each request reused a pooled connection
+type countingTransport struct {
+	base http.RoundTripper
+}
+
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			connStatsMu.Lock()
+			defer connStatsMu.Unlock()
+
+			stat := connStats[host]
+			if stat == nil {
+				stat = &ConnStat{}
+				connStats[host] = stat
+			}
+			if info.Reused {
+				stat.Reused++
+			} else {
+				stat.New++
+			}
+		},
+	}
+	return t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
@@ -0,0 +1,43 @@
+package shared
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientCountsReuse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(5 * time.Second)
+	if client.Timeout != 5*time.Second {
+		t.Errorf("Expected the given timeout, got %v", client.Timeout)
+	}
+
+	for range 3 {
+		res, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	u, _ := url.Parse(srv.URL)
+	stat := ConnStats()[u.Host]
+	if stat.New != 1 || stat.Reused != 2 {
+		t.Errorf("Expected 1 new and 2 reused connections, got %+v", stat)
+	}
+}
+
+func TestNewHTTPClientDefaultTimeout(t *testing.T) {
+	if got := NewHTTPClient(0).Timeout; got != 60*time.Second {
+		t.Errorf("Expected the default timeout, got %v", got)
+	}
+}
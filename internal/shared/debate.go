@@ -0,0 +1,77 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModeDebate is the question mode where agents argue assigned sides instead of collaborating on one answer
+const ModeDebate = "debate"
+
+// Sides an agent argues in a debate
+const (
+	PositionPro = "pro" // For the proposition in the question
+	PositionCon = "con" // Against it
+)
+
+// DebatePositions assigns the agents alternating sides in round (1-based), pro first, keyed by agent
+// With rotate, everyone switches sides every round, so each agent argues both cases over an even number of rounds.
+func DebatePositions(agents []string, round int, rotate bool) map[string]string {
+	positions := make(map[string]string, len(agents))
+	for i, agent := range agents {
+		pro := i%2 == 0
+		if rotate && round%2 == 0 {
+			pro = !pro
+		}
+		if pro {
+			positions[agent] = PositionPro
+		} else {
+			positions[agent] = PositionCon
+		}
+	}
+	return positions
+}
+
+// writeDebateBrief tells an agent which side it argues and who argues which
+func writeDebateBrief(b *strings.Builder, modelName, position string, others []string, positions map[string]string) {
+	b.WriteString("# YOUR POSITION\n\n")
+	b.WriteString(fmt.Sprintf("You argue %s: ", strings.ToUpper(position)))
+	if position == PositionPro {
+		b.WriteString("make the strongest possible case FOR the proposition in the question.\n")
+	} else {
+		b.WriteString("make the strongest possible case AGAINST the proposition in the question.\n")
+	}
+	b.WriteString("Argue your assigned side whatever your own view is - the judges score the quality of the argument, not which side is right.\n\n")
+
+	for _, agent := range others {
+		if side, ok := positions[agent]; ok {
+			b.WriteString(fmt.Sprintf("- %s argues %s\n", agent, strings.ToUpper(side)))
+		}
+	}
+	if len(others) > 0 {
+		b.WriteString("\n")
+	}
+}
+
+// writeDebateTask writes the round's instructions for a debating agent, in place of the collaborative ones
+func writeDebateTask(b *strings.Builder, round, totalRounds int) {
+	if round == 1 {
+		b.WriteString("This is round 1 - give your opening argument for your side.\n\n")
+		b.WriteString("Focus on:\n")
+		b.WriteString("- Stating your side's case clearly\n")
+		b.WriteString("- Backing every claim with evidence, data or concrete examples\n")
+		b.WriteString("- Anticipating the opposing side's strongest objections\n\n")
+		return
+	}
+
+	b.WriteString(fmt.Sprintf("This is round %d of %d - cross-examination. Strengthen your ANSWER by:\n", round, totalRounds))
+	b.WriteString("- Rebutting the strongest points the opposing side made\n")
+	b.WriteString("- Answering the cross-examination questions put to you directly, without dodging\n")
+	b.WriteString("- Conceding minor points where you must, while defending the core of your case\n")
+	b.WriteString("- Arguing the side you are assigned THIS round, even if you argued the other one before\n\n")
+	b.WriteString("In DISCUSSION messages, cross-examine the other agents:\n")
+	b.WriteString("- Ask pointed questions that expose weak evidence, gaps or contradictions in their case\n")
+	b.WriteString("- Challenge unsupported claims and logical fallacies by name\n")
+	b.WriteString("- Direct your questions mainly at agents arguing the opposing side\n")
+	b.WriteString("- Provide 1-2 specific questions or challenges\n\n")
+}
@@ -0,0 +1,67 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestDebatePositions(t *testing.T) {
+	agents := []string{"grok", "gpt", "claude"}
+
+	fixed := DebatePositions(agents, 2, false)
+	if fixed["grok"] != PositionPro || fixed["gpt"] != PositionCon || fixed["claude"] != PositionPro {
+		t.Errorf("Expected alternating sides starting with pro, got %v", fixed)
+	}
+
+	if first := DebatePositions(agents, 1, true); first["grok"] != PositionPro || first["gpt"] != PositionCon {
+		t.Errorf("Expected round 1 to keep the assigned sides, got %v", first)
+	}
+	if second := DebatePositions(agents, 2, true); second["grok"] != PositionCon || second["gpt"] != PositionPro || second["claude"] != PositionCon {
+		t.Errorf("Expected everyone to switch sides in round 2, got %v", second)
+	}
+}
+
+func TestFormatPromptDebate(t *testing.T) {
+	meta := types.Meta{
+		Round:       2,
+		TotalRounds: 3,
+		OtherAgents: []string{"gpt-5"},
+		Positions:   map[string]string{"grok-4": PositionCon, "gpt-5": PositionPro},
+	}
+	prompt := FormatPrompt("grok", "grok-4", "Should cities ban cars?", meta, nil, nil, nil)
+
+	for _, want := range []string{"2-agent debate", "You argue CON", "- gpt-5 argues PRO", "cross-examination", "[One pointed cross-examination question or challenge]"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected the debate prompt to contain %q", want)
+		}
+	}
+	if strings.Contains(prompt, "Incorporating valid points from other agents") {
+		t.Error("Expected no collaborative instructions in a debate")
+	}
+
+	meta.Positions = nil
+	if prompt := FormatPrompt("grok", "grok-4", "Should cities ban cars?", meta, nil, nil, nil); strings.Contains(prompt, "# YOUR POSITION") || !strings.Contains(prompt, "2-agent collaboration") {
+		t.Error("Expected a collaboration without positions")
+	}
+}
+
+func TestFormatRankingPromptDebate(t *testing.T) {
+	finalAnswers := map[string]types.Reply{
+		"Grok": {Answer: "Cars must go"},
+		"GPT":  {Answer: "Cars must stay"},
+	}
+	anonMap := CreateAnonymizationMap([]string{"Grok", "GPT"})
+	positions := map[string]string{"Grok": PositionPro, "GPT": PositionCon}
+
+	prompt := FormatRankingPrompt("Grok", "Should cities ban cars?", []string{"GPT"}, finalAnswers, anonMap, nil, positions, false)
+	for _, want := range []string{"## Agent " + anonMap["Grok"] + " (Argues: PRO", "## Agent " + anonMap["GPT"] + " (Argues: CON", "**Rebuttal**", "quality of the ARGUMENT"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected the debate ranking prompt to contain %q", want)
+		}
+	}
+	if strings.Contains(prompt, "**Accuracy**") {
+		t.Error("Expected argument criteria instead of the collaborative ones")
+	}
+}
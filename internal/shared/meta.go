@@ -0,0 +1,111 @@
+package shared
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// JuryRanking is one judge's ranking as shown to a meta judge
+type JuryRanking struct {
+	Ranking []string          // Agent names, best first
+	Reasons map[string]string // The judge's reason for each agent's place, by agent name; empty if it gave none
+}
+
+// FormatMetaRankingPrompt asks a meta judge for the final verdict on the answers, given how the jury ranked them
+// Agents are anonymized with anonMap like in FormatRankingPrompt and judges are only numbered, so neither model
+// nor judge names sway the verdict. The meta judge ranks every answer with a reason in a # RANKING section, then
+// justifies its verdict in a # VERDICT section; ParseVerdict reads both.
+func FormatMetaRankingPrompt(question string, finalAnswers map[string]types.Reply, jury []JuryRanking, anonMap map[string]string, costs map[string]float64) string {
+	var b strings.Builder
+
+	agents := make([]string, 0, len(finalAnswers))
+	for agent := range finalAnswers {
+		if _, ok := anonMap[agent]; ok {
+			agents = append(agents, agent)
+		}
+	}
+	slices.SortFunc(agents, func(a, b string) int { return strings.Compare(anonMap[a], anonMap[b]) })
+
+	b.WriteString("You are the CHIEF JUDGE. A jury of judges ranked the answers below, and they may disagree.\n")
+	b.WriteString("Review the answers yourself, weigh the jury's rankings and reasons, and issue the FINAL verdict.\n")
+	b.WriteString("Do NOT write a new answer to the question.\n\n")
+
+	b.WriteString("# ORIGINAL QUESTION (for context only - DO NOT answer this)\n\n")
+	b.WriteString(question)
+	b.WriteString("\n\n")
+
+	b.WriteString("# ANSWERS\n\n")
+	for _, agent := range agents {
+		reply := finalAnswers[agent]
+		b.WriteString(fmt.Sprintf("## Agent %s (Cost: %.4f¢)\n\n%s\n\n", anonMap[agent], costs[agent]*100, reply.Answer))
+		if reply.Schema != nil && !reply.Schema.Valid {
+			b.WriteString(fmt.Sprintf("Schema validation: FAILED - %s\n\n", reply.Schema.Error))
+		}
+	}
+
+	b.WriteString("# JURY RANKINGS (best to worst)\n\n")
+	for i, judge := range jury {
+		letters := make([]string, 0, len(judge.Ranking))
+		for _, agent := range judge.Ranking {
+			if letter, ok := anonMap[agent]; ok {
+				letters = append(letters, letter)
+			}
+		}
+		b.WriteString(fmt.Sprintf("## Judge %d: %s\n\n", i+1, strings.Join(letters, " > ")))
+		for _, agent := range judge.Ranking {
+			if reason := judge.Reasons[agent]; reason != "" {
+				b.WriteString(fmt.Sprintf("- %s: %s\n", anonMap[agent], reason))
+			}
+		}
+		if len(judge.Reasons) > 0 {
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("# YOUR TASK\n\n")
+	b.WriteString("Where the judges agree, confirm their ranking unless it is clearly wrong. Where they disagree,\n")
+	b.WriteString("decide who is right by checking the answers: accuracy first, then completeness, clarity,\n")
+	b.WriteString("cost-efficiency and insight. Answers violating the question's format requirements rank lower.\n\n")
+
+	b.WriteString("# YOUR RESPONSE FORMAT\n\n")
+	b.WriteString("Respond with exactly these two sections, in this order:\n\n")
+	b.WriteString("# RANKING\n")
+	for _, agent := range agents {
+		b.WriteString(fmt.Sprintf("%s: <why it is placed here>\n", anonMap[agent]))
+	}
+	b.WriteString("\n(Every agent letter, one per line, reordered from best to worst)\n\n")
+	b.WriteString("# VERDICT\n")
+	b.WriteString("<One short paragraph justifying the winner and where you overruled the jury, and why>\n\n")
+
+	b.WriteString("<!-- ANONYMIZATION_MAP:")
+	for agent, letter := range anonMap {
+		b.WriteString(fmt.Sprintf(" %s=%s", letter, agent))
+	}
+	b.WriteString(" -->")
+
+	return b.String()
+}
+
+// ParseVerdict reads a meta judge's reply to a FormatMetaRankingPrompt prompt
+// Returns its ranking by agent name, its reason for each agent's place and its written justification.
+func ParseVerdict(content string, prompt string) ([]string, map[string]string, string) {
+	ranking, reasons := ParseJustifiedRanking(content, prompt)
+
+	var verdict []string
+	inVerdict := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			inVerdict = strings.HasPrefix(strings.TrimSpace(strings.TrimLeft(trimmed, "#")), "VERDICT")
+			continue
+		}
+		if inVerdict {
+			verdict = append(verdict, line)
+		}
+	}
+
+	return ranking, reasons, strings.TrimSpace(strings.Join(verdict, "\n"))
+}
@@ -0,0 +1,62 @@
+package shared
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestFormatMetaRankingPrompt(t *testing.T) {
+	anonMap := map[string]string{"Grok": "A", "GPT": "B"}
+	answers := map[string]types.Reply{
+		"Grok": {Answer: "Grok's answer"},
+		"GPT":  {Answer: "GPT's answer"},
+	}
+	jury := []JuryRanking{
+		{Ranking: []string{"GPT", "Grok"}, Reasons: map[string]string{"GPT": "cites the spec"}},
+		{Ranking: []string{"Grok", "GPT"}},
+	}
+
+	prompt := FormatMetaRankingPrompt("Why?", answers, jury, anonMap, nil)
+
+	for _, want := range []string{"Grok's answer", "## Judge 1: B > A", "- B: cites the spec", "## Judge 2: A > B", "# RANKING", "# VERDICT"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected prompt to contain %q", want)
+		}
+	}
+	if strings.Contains(strings.Split(prompt, "<!--")[0], "Grok\n") {
+		t.Error("Expected model names to stay anonymized")
+	}
+}
+
+func TestParseVerdict(t *testing.T) {
+	prompt := `<!-- ANONYMIZATION_MAP: A=Grok B=GPT C=Claude -->`
+	content := `# RANKING
+B: Only one with a working proof
+A: Right answer, weak argument
+C: Wrong
+
+# VERDICT
+Judge 2 missed the flaw in A's second step.
+B wins.
+`
+
+	ranking, reasons, verdict := ParseVerdict(content, prompt)
+	if !slices.Equal(ranking, []string{"GPT", "Grok", "Claude"}) {
+		t.Errorf("Expected GPT, Grok, Claude, got %v", ranking)
+	}
+	if reasons["GPT"] != "Only one with a working proof" {
+		t.Errorf("Unexpected reasons %v", reasons)
+	}
+	if verdict != "Judge 2 missed the flaw in A's second step.\nB wins." {
+		t.Errorf("Unexpected verdict %q", verdict)
+	}
+
+	// A reply without a verdict still ranks
+	ranking, _, verdict = ParseVerdict("B\nA\nC", prompt)
+	if len(ranking) != 3 || verdict != "" {
+		t.Errorf("Expected a ranking without verdict, got %v, %q", ranking, verdict)
+	}
+}
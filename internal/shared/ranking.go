@@ -2,7 +2,9 @@ package shared
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
+	"regexp"
 	"slices"
 	"sort"
 	"strings"
@@ -31,7 +33,10 @@ func CreateAnonymizationMap(allAgents []string) map[string]string {
 }
 
 // FormatRankingPrompt creates a standardized ranking prompt with anonymized agents
-func FormatRankingPrompt(agentName, question string, otherAgents []string, finalAnswers map[string]types.Reply, anonMap map[string]string, costs map[string]float64) string {
+// With justify, the judge is asked for a one-line reason after each letter, which ParseJustifications extracts.
+// positions holds the side each agent argued by name when the answers come from a debate, which has the judge
+// score the quality of the arguments instead; nil for a collaboration.
+func FormatRankingPrompt(agentName, question string, otherAgents []string, finalAnswers map[string]types.Reply, anonMap map[string]string, costs map[string]float64, positions map[string]string, justify bool) string {
 	var b strings.Builder
 
 	// Build list of all agents
@@ -42,19 +47,36 @@ func FormatRankingPrompt(agentName, question string, otherAgents []string, final
 	b.WriteString("║               🚨 RANKING MODE - NOT WRITING MODE 🚨          ║\n")
 	b.WriteString("║                                                              ║\n")
 	b.WriteString("║  YOUR TASK: Judge and rank the answers shown below          ║\n")
-	b.WriteString("║  YOUR OUTPUT: A list of agent letters, best to worst        ║\n")
+	if justify {
+		b.WriteString("║  YOUR OUTPUT: Agent letters, best to worst, with reasons    ║\n")
+	} else {
+		b.WriteString("║  YOUR OUTPUT: A list of agent letters, best to worst        ║\n")
+	}
 	b.WriteString("║                                                              ║\n")
 	b.WriteString("║  ❌ DO NOT write a new answer to the question                ║\n")
 	b.WriteString("║  ❌ DO NOT use # ANSWER or # RATIONALE sections              ║\n")
-	b.WriteString("║  ❌ DO NOT explain your ranking                              ║\n")
+	if justify {
+		b.WriteString("║  ❌ DO NOT explain beyond ONE short line per agent           ║\n")
+	} else {
+		b.WriteString("║  ❌ DO NOT explain your ranking                              ║\n")
+	}
 	b.WriteString("║  ❌ DO NOT write prose or paragraphs                         ║\n")
 	b.WriteString("║                                                              ║\n")
-	b.WriteString("║  ✅ ONLY output agent letters, one per line                  ║\n")
-	b.WriteString("║  ✅ Example: A\\nB\\nC\\nD (nothing else)                       ║\n")
+	if justify {
+		b.WriteString("║  ✅ ONLY output one line per agent: letter, colon, reason    ║\n")
+		b.WriteString("║  ✅ Example: A: most accurate\\nB: misses the edge case       ║\n")
+	} else {
+		b.WriteString("║  ✅ ONLY output agent letters, one per line                  ║\n")
+		b.WriteString("║  ✅ Example: A\\nB\\nC\\nD (nothing else)                       ║\n")
+	}
 	b.WriteString("╚══════════════════════════════════════════════════════════════╝\n\n")
 	b.WriteString("You are acting as a JUDGE, not as a writer.\n")
 	b.WriteString("This is NOT a creative writing task.\n")
-	b.WriteString("Your ENTIRE response must be ONLY the ranked letters.\n\n")
+	if justify {
+		b.WriteString("Your ENTIRE response must be ONLY the ranked letters, each with its one-line reason.\n\n")
+	} else {
+		b.WriteString("Your ENTIRE response must be ONLY the ranked letters.\n\n")
+	}
 
 	b.WriteString("# ORIGINAL QUESTION (for context only - DO NOT answer this)\n\n")
 	b.WriteString(question)
@@ -63,6 +85,7 @@ func FormatRankingPrompt(agentName, question string, otherAgents []string, final
 	b.WriteString("# ANSWERS TO RANK\n\n")
 
 	// Show answers with anonymous letters and costs
+	var schemaChecked bool
 	for _, agent := range allAgents {
 		if reply, ok := finalAnswers[agent]; ok {
 			letter := anonMap[agent]
@@ -75,7 +98,19 @@ func FormatRankingPrompt(agentName, question string, otherAgents []string, final
 			if !strings.Contains(costStr, ".") {
 				costStr = strings.TrimSuffix(costStr, "¢") + "¢"
 			}
-			b.WriteString(fmt.Sprintf("## Agent %s (Cost: %s)\n\n%s\n\n", letter, costStr, reply.Answer))
+			if side, ok := positions[agent]; ok {
+				b.WriteString(fmt.Sprintf("## Agent %s (Argues: %s, Cost: %s)\n\n%s\n\n", letter, strings.ToUpper(side), costStr, reply.Answer))
+			} else {
+				b.WriteString(fmt.Sprintf("## Agent %s (Cost: %s)\n\n%s\n\n", letter, costStr, reply.Answer))
+			}
+			if reply.Schema != nil {
+				schemaChecked = true
+				if reply.Schema.Valid {
+					b.WriteString("Schema validation: passed\n\n")
+				} else {
+					b.WriteString(fmt.Sprintf("Schema validation: FAILED - %s\n\n", reply.Schema.Error))
+				}
+			}
 		}
 	}
 
@@ -93,13 +128,29 @@ func FormatRankingPrompt(agentName, question string, otherAgents []string, final
 	b.WriteString("- Question asks for \"one sentence\" → Answer provides multiple sentences\n")
 	b.WriteString("- Question asks for \"bullet points\" → Answer provides prose\n\n")
 	b.WriteString("Prompt adherence violations should result in severe ranking penalties.\n\n")
+	if schemaChecked {
+		b.WriteString("Answers were required to be JSON matching a schema. Every answer that FAILED\n")
+		b.WriteString("schema validation MUST be ranked below every answer that passed.\n\n")
+	}
 	b.WriteString("═══════════════════════════════════════════════════════════════\n\n")
-	b.WriteString("Ranking criteria (for answers that follow the prompt):\n")
-	b.WriteString("- **Accuracy** (35%): Correctness and precision\n")
-	b.WriteString("- **Completeness** (25%): Addresses all aspects of the question\n")
-	b.WriteString("- **Clarity** (20%): Well-structured and understandable\n")
-	b.WriteString("- **Cost-Efficiency** (10%): Quality relative to cost\n")
-	b.WriteString("- **Insight** (10%): Depth and originality\n\n")
+	if positions != nil {
+		b.WriteString("These answers come from a DEBATE: each agent was assigned a side (PRO or CON) to argue.\n")
+		b.WriteString("Score the quality of the ARGUMENT, not which side you agree with. A well-argued\n")
+		b.WriteString("case for a side you reject must outrank a weak case for a side you accept.\n\n")
+		b.WriteString("Ranking criteria (for answers that follow the prompt):\n")
+		b.WriteString("- **Evidence** (30%): Claims backed by facts, data or concrete examples\n")
+		b.WriteString("- **Logic** (25%): Sound reasoning, free of fallacies and contradictions\n")
+		b.WriteString("- **Rebuttal** (25%): Engages and answers the opposing side's strongest points\n")
+		b.WriteString("- **Clarity** (10%): Well-structured and persuasive\n")
+		b.WriteString("- **Cost-Efficiency** (10%): Quality relative to cost\n\n")
+	} else {
+		b.WriteString("Ranking criteria (for answers that follow the prompt):\n")
+		b.WriteString("- **Accuracy** (35%): Correctness and precision\n")
+		b.WriteString("- **Completeness** (25%): Addresses all aspects of the question\n")
+		b.WriteString("- **Clarity** (20%): Well-structured and understandable\n")
+		b.WriteString("- **Cost-Efficiency** (10%): Quality relative to cost\n")
+		b.WriteString("- **Insight** (10%): Depth and originality\n\n")
+	}
 	b.WriteString("Note: Lower cost is better when quality is similar. Consider value for money.\n\n")
 	b.WriteString("Be objective. Judge on merit, not identity.\n\n")
 
@@ -110,14 +161,24 @@ func FormatRankingPrompt(agentName, question string, otherAgents []string, final
 	b.WriteString("⚠️  DO NOT write \"# ANSWER\" or any other heading.\n")
 	b.WriteString("⚠️  DO NOT write explanatory text.\n")
 	b.WriteString("⚠️  The FIRST character of your response must be a letter (A-H).\n\n")
-	b.WriteString("Output ONLY agent letters, one per line, ordered from best to worst.\n")
-	b.WriteString("NO sections like # ANSWER or # RATIONALE.\n")
-	b.WriteString("NO explanations or commentary.\n")
-	b.WriteString("JUST the list:\n\n")
+	if justify {
+		b.WriteString("Output ONLY one line per agent, ordered from best to worst: the agent letter,\n")
+		b.WriteString("a colon, and ONE short sentence on why the answer is placed there.\n")
+		b.WriteString("NO sections like # ANSWER or # RATIONALE.\n")
+		b.WriteString("NO other commentary.\n")
+		b.WriteString("JUST the list:\n\n")
+	} else {
+		b.WriteString("Output ONLY agent letters, one per line, ordered from best to worst.\n")
+		b.WriteString("NO sections like # ANSWER or # RATIONALE.\n")
+		b.WriteString("NO explanations or commentary.\n")
+		b.WriteString("JUST the list:\n\n")
+	}
 
-	// Show example with the anonymous letters
+	// Show example with the anonymous letters (a judge from outside the collaboration has none)
 	for _, agent := range allAgents {
-		b.WriteString(fmt.Sprintf("%s\n", anonMap[agent]))
+		if letter, ok := anonMap[agent]; ok {
+			b.WriteString(fmt.Sprintf("%s%s\n", letter, exampleReason(justify)))
+		}
 	}
 	b.WriteString("\n(Reorder the above letters from best to worst)\n\n")
 	b.WriteString("══════════════════════════════════════════════════════════════\n")
@@ -132,15 +193,24 @@ func FormatRankingPrompt(agentName, question string, otherAgents []string, final
 		}
 	}
 	for _, letter := range exampleLetters {
-		b.WriteString(fmt.Sprintf("%s\n", letter))
+		b.WriteString(fmt.Sprintf("%s%s\n", letter, exampleReason(justify)))
 	}
 
 	b.WriteString("\n═══════════════════════════════════════════════════════════════\n")
-	b.WriteString("NO OTHER TEXT, NO SECTIONS, NO EXPLANATIONS - JUST THE LIST!\n")
+	if justify {
+		b.WriteString("NO OTHER TEXT, NO SECTIONS - JUST THE LIST WITH ONE-LINE REASONS!\n")
+	} else {
+		b.WriteString("NO OTHER TEXT, NO SECTIONS, NO EXPLANATIONS - JUST THE LIST!\n")
+	}
 	b.WriteString("═══════════════════════════════════════════════════════════════\n\n")
 	b.WriteString("REMINDER: Start your response with a letter (A-H), not with \"#\" or text.\n")
-	b.WriteString("If you write \"# ANSWER\" or any explanation, your response is INVALID.\n")
-	b.WriteString("The correct format is ONLY letters, one per line. Nothing else.\n\n")
+	if justify {
+		b.WriteString("If you write \"# ANSWER\" or anything but the list, your response is INVALID.\n")
+		b.WriteString("The correct format is ONLY \"letter: reason\", one per line. Nothing else.\n\n")
+	} else {
+		b.WriteString("If you write \"# ANSWER\" or any explanation, your response is INVALID.\n")
+		b.WriteString("The correct format is ONLY letters, one per line. Nothing else.\n\n")
+	}
 
 	// Add mapping at the end for the system to decode (hidden from model's perspective in practice)
 	b.WriteString("<!-- ANONYMIZATION_MAP:")
@@ -154,7 +224,15 @@ func FormatRankingPrompt(agentName, question string, otherAgents []string, final
 
 // ParseRanking extracts agent letters from ranking response and decodes them using the prompt's mapping
 func ParseRanking(content string, prompt string) []string {
+	ranking, _ := ParseJustifiedRanking(content, prompt)
+	return ranking
+}
+
+// ParseJustifiedRanking is ParseRanking for a prompt that asked for reasons, see FormatRankingPrompt
+// Returns the ranking and each ranked agent's one-line reason, for the agents the judge gave one.
+func ParseJustifiedRanking(content string, prompt string) ([]string, map[string]string) {
 	var ranking []string
+	reasons := make(map[string]string)
 
 	// Extract anonymization mapping from prompt
 	letterToAgent := extractAnonymizationMap(prompt)
@@ -163,7 +241,7 @@ func ParseRanking(content string, prompt string) []string {
 	hasAnswerSection := strings.Contains(content, "# ANSWER")
 	if hasAnswerSection {
 		fmt.Printf("DEBUG: Model provided # ANSWER section instead of ranking\n")
-		return ranking
+		return ranking, reasons
 	}
 
 	hasRankingSection := strings.Contains(content, "# RANKING")
@@ -185,6 +263,20 @@ func ParseRanking(content string, prompt string) []string {
 		}
 
 		if inRankingSection && line != "" {
+			// A placement with a reason, e.g. "A: most accurate"
+			entry := line
+			entry, _ = strings.CutPrefix(entry, "Agent ")
+			entry, _ = strings.CutPrefix(entry, "- ")
+			entry, _ = strings.CutPrefix(entry, "* ")
+			if m := justifiedPlacement.FindStringSubmatch(entry); m != nil {
+				// An unknown letter is dropped, leaving the ranking short like any other miss
+				if realName, ok := letterToAgent[m[1]]; ok {
+					ranking = append(ranking, realName)
+					reasons[realName] = strings.TrimSpace(m[2])
+				}
+				continue
+			}
+
 			// Skip instruction lines, separators, code blocks
 			if strings.Contains(line, "IMPORTANT:") ||
 				strings.Contains(line, "Do NOT") ||
@@ -231,7 +323,18 @@ func ParseRanking(content string, prompt string) []string {
 		}
 	}
 
-	return ranking
+	return ranking, reasons
+}
+
+// justifiedPlacement matches a ranked agent letter followed by the judge's reason for its place
+var justifiedPlacement = regexp.MustCompile(`^\*{0,2}([A-H])\*{0,2}\s*[:\-–—]\s*(.+)$`)
+
+// exampleReason is what follows each letter in the example response of a ranking prompt
+func exampleReason(justify bool) string {
+	if justify {
+		return ": <why it is placed here>"
+	}
+	return ""
 }
 
 // extractAnonymizationMap extracts the letter-to-agent mapping from the prompt
@@ -264,24 +367,39 @@ func extractAnonymizationMap(prompt string) map[string]string {
 	return mapping
 }
 
+// Placement is an agent's place in the aggregated ranking
+type Placement struct {
+	Model string `json:"model"`
+	Place int    `json:"place"` // 1 for gold; tied agents share a place and the next score takes the next one
+	Score int    `json:"score"`
+	Tied  bool   `json:"tied,omitempty"` // Another agent has the same score
+}
+
 // AggregateRankings combines rankings from multiple agents using Borda count
-// Returns gold/silver/bronze winners (with ties handled - multiple models can share a place) and scores
-func AggregateRankings(rankings map[string][]string, allAgents []string) ([]string, []string, []string, map[string]int) {
-	scores := make(map[string]int)
+// weights scales each judge's points by judge key; judges missing from it, or all judges when it is nil,
+// count fully. Weighted scores are rounded to whole points, so judges' ballots that nearly cancel out tie.
+// Returns gold/silver/bronze winners (with ties handled - multiple models can share a place), scores and the
+// full ordering of every agent, best first and tied agents by name
+func AggregateRankings(rankings map[string][]string, allAgents []string, weights map[string]float64) ([]string, []string, []string, map[string]int, []Placement) {
+	weighted := make(map[string]float64)
 
 	// Initialize scores
 	for _, agent := range allAgents {
-		scores[agent] = 0
+		weighted[agent] = 0
 	}
 
 	// Borda count: first place gets n points, second gets n-1, etc.
 	for rankerID, ranking := range rankings {
+		weight, ok := weights[rankerID]
+		if !ok {
+			weight = 1
+		}
 		points := len(allAgents)
-		fmt.Printf("DEBUG: Processing ranking from %s: %v\n", rankerID, ranking)
+		fmt.Printf("DEBUG: Processing ranking from %s (weight %.2f): %v\n", rankerID, weight, ranking)
 		for _, agent := range ranking {
-			if _, exists := scores[agent]; exists {
+			if _, exists := weighted[agent]; exists {
 				fmt.Printf("DEBUG: Awarding %d points to %s\n", points, agent)
-				scores[agent] += points
+				weighted[agent] += float64(points) * weight
 				points--
 			} else {
 				fmt.Printf("DEBUG: Agent %s not in allAgents list!\n", agent)
@@ -289,12 +407,24 @@ func AggregateRankings(rankings map[string][]string, allAgents []string) ([]stri
 		}
 	}
 
+	scores := make(map[string]int, len(weighted))
+	for agent, score := range weighted {
+		scores[agent] = int(math.Round(score))
+	}
+
 	// Log all scores before finding winners
 	fmt.Printf("DEBUG: Final scores:\n")
 	for agent, score := range scores {
 		fmt.Printf("DEBUG:   %s: %d points\n", agent, score)
 	}
 
+	gold, silver, bronze, order := Medals(scores)
+	return gold, silver, bronze, scores, order
+}
+
+// Medals hands out gold, silver and bronze by score, with tied agents sharing a medal, and orders every
+// agent best first, tied agents by name
+func Medals(scores map[string]int) ([]string, []string, []string, []Placement) {
 	// Group models by score
 	scoreGroups := make(map[int][]string)
 	for agent, score := range scores {
@@ -323,5 +453,62 @@ func AggregateRankings(rankings map[string][]string, allAgents []string) ([]stri
 		fmt.Printf("DEBUG: Bronze (%d pts): %v\n", uniqueScores[2], bronze)
 	}
 
-	return gold, silver, bronze, scores
+	order := make([]Placement, 0, len(scores))
+	for i, score := range uniqueScores {
+		group := slices.Sorted(slices.Values(scoreGroups[score]))
+		for _, agent := range group {
+			order = append(order, Placement{Model: agent, Place: i + 1, Score: score, Tied: len(group) > 1})
+		}
+	}
+
+	return gold, silver, bronze, order
+}
+
+// WithoutSelfVotes returns the rankings with every judge's own answer removed from its ranking
+// Judges that aren't participants are unaffected. The input is not modified.
+func WithoutSelfVotes(rankings map[string][]string) map[string][]string {
+	filtered := make(map[string][]string, len(rankings))
+	for judge, ranking := range rankings {
+		filtered[judge] = slices.DeleteFunc(slices.Clone(ranking), func(agent string) bool {
+			return agent == judge
+		})
+	}
+	return filtered
+}
+
+// SelfPreference measures how a judge placed its own answer against how the other judges placed it
+// Placements are scored from 1 (first) to 0 (last). Returns the judge's position (0-based) in its own
+// ranking, its self score and the mean score the other judges gave it; ok is false if the judge didn't
+// rank itself or no other judge ranked it.
+func SelfPreference(judge string, rankings map[string][]string) (position int, self, peers float64, ok bool) {
+	position = slices.Index(rankings[judge], judge)
+	if position < 0 {
+		return 0, 0, 0, false
+	}
+	self = placementScore(position, len(rankings[judge]))
+
+	var total float64
+	var count int
+	for other, ranking := range rankings {
+		if other == judge {
+			continue
+		}
+		if i := slices.Index(ranking, judge); i >= 0 {
+			total += placementScore(i, len(ranking))
+			count++
+		}
+	}
+	if count == 0 {
+		return position, self, 0, false
+	}
+
+	return position, self, total / float64(count), true
+}
+
+// placementScore maps a 0-based position in a ranking of n to [0, 1], first place scoring 1
+func placementScore(position, n int) float64 {
+	if n < 2 {
+		return 1
+	}
+	return 1 - float64(position)/float64(n-1)
 }
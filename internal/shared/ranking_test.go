@@ -1,6 +1,7 @@
 package shared
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/meedamian/fat/internal/types"
@@ -44,6 +45,29 @@ Gemini
 	}
 }
 
+func TestParseJustifiedRanking(t *testing.T) {
+	prompt := `<!-- ANONYMIZATION_MAP: A=Grok B=GPT C=Claude -->`
+	content := `B: Most accurate, cites the spec
+**A** - Correct but misses the edge case
+Agent C: Answers a different question
+`
+
+	ranking, reasons := ParseJustifiedRanking(content, prompt)
+
+	if len(ranking) != 3 || ranking[0] != "GPT" || ranking[1] != "Grok" || ranking[2] != "Claude" {
+		t.Fatalf("Expected GPT, Grok, Claude, got %v", ranking)
+	}
+	if reasons["GPT"] != "Most accurate, cites the spec" || reasons["Grok"] != "Correct but misses the edge case" || reasons["Claude"] != "Answers a different question" {
+		t.Errorf("Unexpected reasons %v", reasons)
+	}
+
+	// Plain letters still parse, without reasons
+	ranking, reasons = ParseJustifiedRanking("A\nB\nC", prompt)
+	if len(ranking) != 3 || len(reasons) != 0 {
+		t.Errorf("Expected 3 placements without reasons, got %v, %v", ranking, reasons)
+	}
+}
+
 func TestAggregateRankings(t *testing.T) {
 	rankings := map[string][]string{
 		"grok":   {"Grok", "GPT", "Claude"},
@@ -53,7 +77,7 @@ func TestAggregateRankings(t *testing.T) {
 
 	allAgents := []string{"Grok", "GPT", "Claude"}
 
-	gold, silver, bronze, _ := AggregateRankings(rankings, allAgents)
+	gold, silver, bronze, _, _ := AggregateRankings(rankings, allAgents, nil)
 
 	// Grok should win: 3+2+3=8 points
 	// GPT: 2+3+1=6 points
@@ -80,7 +104,7 @@ func TestAggregateRankingsWithTies(t *testing.T) {
 
 	allAgents := []string{"Grok", "GPT", "Claude", "Gemini"}
 
-	gold, silver, bronze, _ := AggregateRankings(rankings, allAgents)
+	gold, silver, bronze, _, _ := AggregateRankings(rankings, allAgents, nil)
 
 	// Grok: 4+3+4+3=14 points
 	// GPT: 3+4+3+4=14 points (tied for gold!)
@@ -118,6 +142,52 @@ func TestAggregateRankingsWithTies(t *testing.T) {
 	}
 }
 
+func TestAggregateRankingsOrder(t *testing.T) {
+	rankings := map[string][]string{
+		"a": {"Grok", "GPT", "Claude", "Gemini", "Mistral"},
+		"b": {"GPT", "Grok", "Claude", "Mistral", "Gemini"},
+	}
+	allAgents := []string{"Grok", "GPT", "Claude", "Gemini", "Mistral"}
+
+	_, _, _, _, order := AggregateRankings(rankings, allAgents, nil)
+
+	// Grok and GPT tie on 9, Claude 6, Gemini and Mistral tie on 3
+	want := []Placement{
+		{Model: "GPT", Place: 1, Score: 9, Tied: true},
+		{Model: "Grok", Place: 1, Score: 9, Tied: true},
+		{Model: "Claude", Place: 2, Score: 6},
+		{Model: "Gemini", Place: 3, Score: 3, Tied: true},
+		{Model: "Mistral", Place: 3, Score: 3, Tied: true},
+	}
+	if !slices.Equal(order, want) {
+		t.Errorf("Expected full ordering %+v, got %+v", want, order)
+	}
+}
+
+func TestAggregateRankingsWeighted(t *testing.T) {
+	// Unweighted, gpt and claude outvote grok's judge; weighted, grok's consistent judge carries the day
+	rankings := map[string][]string{
+		"grok":   {"Grok", "GPT", "Claude"},
+		"gpt":    {"GPT", "Grok", "Claude"},
+		"claude": {"GPT", "Claude", "Grok"},
+	}
+	allAgents := []string{"Grok", "GPT", "Claude"}
+
+	gold, _, _, _, _ := AggregateRankings(rankings, allAgents, nil)
+	if len(gold) != 1 || gold[0] != "GPT" {
+		t.Fatalf("Expected GPT to win unweighted, got %v", gold)
+	}
+
+	gold, _, _, scores, _ := AggregateRankings(rankings, allAgents, map[string]float64{"gpt": 0.2, "claude": 0.2})
+	// Grok: 3 + 0.4 + 0.2 = 3.6, GPT: 2 + 0.6 + 0.6 = 3.2, Claude: 1 + 0.2 + 0.4 = 1.6
+	if len(gold) != 1 || gold[0] != "Grok" {
+		t.Errorf("Expected Grok to win weighted, got %v (scores %v)", gold, scores)
+	}
+	if scores["Grok"] != 4 || scores["Claude"] != 2 {
+		t.Errorf("Expected weighted scores rounded to whole points, got %v", scores)
+	}
+}
+
 func TestFormatRankingPrompt(t *testing.T) {
 	finalAnswers := map[string]types.Reply{
 		"Grok":   {Answer: "Answer from Grok"},
@@ -134,7 +204,7 @@ func TestFormatRankingPrompt(t *testing.T) {
 	allAgents := []string{"Grok", "GPT", "Claude"}
 	anonMap := CreateAnonymizationMap(allAgents)
 
-	prompt := FormatRankingPrompt("Grok", "What is AI?", []string{"GPT", "Claude"}, finalAnswers, anonMap, costs)
+	prompt := FormatRankingPrompt("Grok", "What is AI?", []string{"GPT", "Claude"}, finalAnswers, anonMap, costs, nil, false)
 
 	if prompt == "" {
 		t.Error("Ranking prompt should not be empty")
@@ -161,6 +231,50 @@ func TestFormatRankingPrompt(t *testing.T) {
 	}
 }
 
+func TestFormatRankingPromptJustify(t *testing.T) {
+	finalAnswers := map[string]types.Reply{
+		"Grok": {Answer: "Answer from Grok"},
+		"GPT":  {Answer: "Answer from GPT"},
+	}
+	anonMap := CreateAnonymizationMap([]string{"Grok", "GPT"})
+
+	prompt := FormatRankingPrompt("Grok", "What is AI?", []string{"GPT"}, finalAnswers, anonMap, nil, nil, true)
+	for _, want := range []string{"ONE short sentence", anonMap["Grok"] + ": <why it is placed here>"} {
+		if !contains(prompt, want) {
+			t.Errorf("Justified ranking prompt missing: %s", want)
+		}
+	}
+	if contains(prompt, "DO NOT explain your ranking") {
+		t.Error("Justified ranking prompt still forbids explanations")
+	}
+
+	if prompt := FormatRankingPrompt("Grok", "What is AI?", []string{"GPT"}, finalAnswers, anonMap, nil, nil, false); contains(prompt, "<why it is placed here>") {
+		t.Error("Expected no reasons asked for without justify")
+	}
+}
+
+func TestFormatRankingPromptSchema(t *testing.T) {
+	finalAnswers := map[string]types.Reply{
+		"Grok": {Answer: `{"city":"Paris"}`, Schema: &types.SchemaCheck{Valid: true}},
+		"GPT":  {Answer: "Paris", Schema: &types.SchemaCheck{Error: "answer is not valid JSON"}},
+	}
+	anonMap := CreateAnonymizationMap([]string{"Grok", "GPT"})
+
+	prompt := FormatRankingPrompt("Grok", "Capital of France?", []string{"GPT"}, finalAnswers, anonMap, nil, nil, false)
+
+	for _, want := range []string{"Schema validation: passed", "Schema validation: FAILED - answer is not valid JSON", "MUST be ranked below"} {
+		if !contains(prompt, want) {
+			t.Errorf("Ranking prompt missing: %s", want)
+		}
+	}
+
+	delete(finalAnswers, "GPT")
+	finalAnswers["Grok"] = types.Reply{Answer: "Paris"}
+	if prompt := FormatRankingPrompt("Grok", "Q?", nil, finalAnswers, anonMap, nil, nil, false); contains(prompt, "Schema validation") {
+		t.Error("Expected no schema lines without an answer schema")
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && findSubstring(s, substr))
 }
@@ -173,3 +287,39 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestWithoutSelfVotes(t *testing.T) {
+	rankings := map[string][]string{
+		"grok-4": {"grok-4", "gpt-5", "claude"},
+		"judge":  {"gpt-5", "grok-4"},
+	}
+
+	filtered := WithoutSelfVotes(rankings)
+
+	if got := filtered["grok-4"]; len(got) != 2 || got[0] != "gpt-5" {
+		t.Errorf("Expected grok-4's own answer removed, got %v", got)
+	}
+	if got := filtered["judge"]; len(got) != 2 {
+		t.Errorf("Expected an outside judge's ranking untouched, got %v", got)
+	}
+	if len(rankings["grok-4"]) != 3 {
+		t.Error("Expected the input rankings to be left unmodified")
+	}
+}
+
+func TestSelfPreference(t *testing.T) {
+	rankings := map[string][]string{
+		"grok-4": {"grok-4", "gpt-5", "claude"},
+		"gpt-5":  {"gpt-5", "claude", "grok-4"},
+		"claude": {"gpt-5", "grok-4", "claude"},
+	}
+
+	position, self, peers, ok := SelfPreference("grok-4", rankings)
+	if !ok || position != 0 || self != 1 || peers != 0.25 {
+		t.Errorf("Expected grok-4 first in its own ranking (1) and 0.25 from peers, got %d %v %v %v", position, self, peers, ok)
+	}
+
+	if _, _, _, ok := SelfPreference("judge", rankings); ok {
+		t.Error("Expected no self-preference for a judge that didn't rank itself")
+	}
+}
@@ -0,0 +1,31 @@
+package shared
+
+import "regexp"
+
+// answerReference matches a reference to an earlier request's winning answer, e.g. "{{answer:3f2a9c1e-...}}"
+var answerReference = regexp.MustCompile(`\{\{\s*answer:\s*([A-Za-z0-9_-]+)\s*\}\}`)
+
+// AnswerReferences returns the distinct request IDs a question refers to with {{answer:<id>}}, in order of first use
+func AnswerReferences(question string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, m := range answerReference.FindAllStringSubmatch(question, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			ids = append(ids, m[1])
+		}
+	}
+	return ids
+}
+
+// ExpandAnswerReferences replaces every {{answer:<id>}} in a question with answers[id]
+// References to IDs missing from answers are left as written.
+func ExpandAnswerReferences(question string, answers map[string]string) string {
+	return answerReference.ReplaceAllStringFunc(question, func(ref string) string {
+		id := answerReference.FindStringSubmatch(ref)[1]
+		if answer, ok := answers[id]; ok {
+			return answer
+		}
+		return ref
+	})
+}
@@ -0,0 +1,27 @@
+package shared
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAnswerReferences(t *testing.T) {
+	question := "Compare {{answer:req-1}} with {{ answer: req-2 }}, then improve {{answer:req-1}}. {{answer:}} {{answers:req-3}}"
+
+	if got := AnswerReferences(question); !slices.Equal(got, []string{"req-1", "req-2"}) {
+		t.Errorf("AnswerReferences = %v, want [req-1 req-2]", got)
+	}
+	if got := AnswerReferences("No references here"); got != nil {
+		t.Errorf("Expected no references, got %v", got)
+	}
+}
+
+func TestExpandAnswerReferences(t *testing.T) {
+	question := "Compare {{answer:req-1}} with {{ answer: req-2 }}, then improve {{answer:req-1}}. Keep {{answer:req-3}}."
+	answers := map[string]string{"req-1": "Use a map", "req-2": "Use $1 a slice"}
+
+	want := "Compare Use a map with Use $1 a slice, then improve Use a map. Keep {{answer:req-3}}."
+	if got := ExpandAnswerReferences(question, answers); got != want {
+		t.Errorf("ExpandAnswerReferences = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package shared
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sectionHeading matches the heading of a sub-question's section in an answer: a markdown heading
+// such as "## Q2: Title" (the whole line), or a "**Q2.**" or "Q2." prefix followed by the answer itself
+var sectionHeading = regexp.MustCompile(`(?im)^[ \t]*(?:#{1,6}[ \t]*Q(\d+)\b.*$|\*\*Q(\d+)\b[^*\n]*\*\*|Q(\d+)[.:)])`)
+
+// rankingHeading matches the heading of a sub-question's ranking in a judge's reply, e.g. "# Q2"
+var rankingHeading = regexp.MustCompile(`(?im)^\s*#*\s*Q(\d+)\b.*$`)
+
+// FormatSubQuestions turns a question and its sub-questions into the composite question agents answer
+// Each sub-question is to be answered in a section headed "## Q<n>", which SplitSections takes apart again.
+func FormatSubQuestions(question string, subQuestions []string) string {
+	var b strings.Builder
+	if question = strings.TrimSpace(question); question != "" {
+		b.WriteString(question)
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString("Answer each of the following questions in its own section of your answer, in order, ")
+	b.WriteString("headed \"## Q1\", \"## Q2\" and so on. Every section is judged on its own.\n\n")
+	for i, sub := range subQuestions {
+		b.WriteString(fmt.Sprintf("Q%d. %s\n", i+1, strings.TrimSpace(sub)))
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// SplitSections returns the sections of an answer to n sub-questions, "" for every section it lacks
+// Text before the first heading is dropped; a section repeated later replaces the earlier one.
+func SplitSections(answer string, n int) []string {
+	sections := make([]string, n)
+
+	matches := sectionHeading.FindAllStringSubmatchIndex(answer, -1)
+	for i, m := range matches {
+		var number int
+		for g := 2; g < len(m); g += 2 {
+			if m[g] >= 0 {
+				number, _ = strconv.Atoi(answer[m[g]:m[g+1]])
+			}
+		}
+		if number < 1 || number > n {
+			continue
+		}
+
+		end := len(answer)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		sections[number-1] = strings.TrimSpace(answer[m[1]:end])
+	}
+
+	return sections
+}
+
+// FormatSectionRankingPrompt creates the ranking prompt of a composite question, whose sub-questions are ranked separately
+// sectionAnswers holds each agent's answer split by SplitSections. The anonymization map is appended as in FormatRankingPrompt.
+func FormatSectionRankingPrompt(agentName, question string, subQuestions []string, otherAgents []string, sectionAnswers map[string][]string, anonMap map[string]string, costs map[string]float64) string {
+	var b strings.Builder
+
+	allAgents := append([]string{agentName}, otherAgents...)
+	slices.Sort(allAgents)
+
+	b.WriteString("You are acting as a JUDGE, not as a writer. Do NOT answer the questions yourself.\n")
+	b.WriteString("Agents answered several questions at once. Rank their answers to EACH question separately.\n\n")
+
+	b.WriteString("# ORIGINAL QUESTION (for context only - DO NOT answer this)\n\n")
+	b.WriteString(question)
+	b.WriteString("\n\n")
+
+	for i, sub := range subQuestions {
+		b.WriteString(fmt.Sprintf("# Q%d ANSWERS TO RANK\n\n", i+1))
+		b.WriteString(fmt.Sprintf("Question: %s\n\n", strings.TrimSpace(sub)))
+		for _, agent := range allAgents {
+			sections, ok := sectionAnswers[agent]
+			if !ok {
+				continue
+			}
+			answer := "(no answer to this question)"
+			if i < len(sections) && sections[i] != "" {
+				answer = sections[i]
+			}
+			b.WriteString(fmt.Sprintf("## Agent %s\n\n%s\n\n", anonMap[agent], answer))
+		}
+	}
+
+	b.WriteString("# YOUR TASK\n\n")
+	b.WriteString("For every question, rank the answers above from best to worst on accuracy, completeness,\n")
+	b.WriteString("clarity and insight. A missing answer ranks last. Format requirements in a question are mandatory.\n")
+	if len(costs) > 0 {
+		b.WriteString("Total cost per agent, for value for money when answers are of similar quality:")
+		for _, agent := range allAgents {
+			if cost, ok := costs[agent]; ok {
+				b.WriteString(fmt.Sprintf(" %s %.4f¢", anonMap[agent], cost*100))
+			}
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("Be objective. Judge on merit, not identity.\n\n")
+
+	b.WriteString("# YOUR RESPONSE FORMAT\n\n")
+	b.WriteString("For every question, a heading followed by agent letters, one per line, best to worst.\n")
+	b.WriteString("NO explanations, NO other text. Exactly this shape:\n\n")
+	for i := range subQuestions {
+		b.WriteString(fmt.Sprintf("# Q%d\n", i+1))
+		for _, agent := range allAgents {
+			if letter, ok := anonMap[agent]; ok {
+				b.WriteString(letter + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("<!-- ANONYMIZATION_MAP:")
+	for agent, letter := range anonMap {
+		b.WriteString(fmt.Sprintf(" %s=%s", letter, agent))
+	}
+	b.WriteString(" -->")
+
+	return b.String()
+}
+
+// ParseSectionRankings extracts a judge's ranking of each of n sub-questions, nil for every one it didn't rank
+func ParseSectionRankings(content, prompt string, n int) [][]string {
+	rankings := make([][]string, n)
+
+	matches := rankingHeading.FindAllStringSubmatchIndex(content, -1)
+	for i, m := range matches {
+		number, _ := strconv.Atoi(content[m[2]:m[3]])
+		if number < 1 || number > n {
+			continue
+		}
+
+		end := len(content)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		rankings[number-1] = ParseRanking(content[m[1]:end], prompt)
+	}
+
+	return rankings
+}
@@ -0,0 +1,43 @@
+package shared
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatSubQuestions(t *testing.T) {
+	question := FormatSubQuestions("Interview questions:", []string{"What is a mutex?", " Explain CAP. "})
+
+	for _, want := range []string{"Interview questions:\n\n", `"## Q1"`, "Q1. What is a mutex?\n", "Q2. Explain CAP."} {
+		if !strings.Contains(question, want) {
+			t.Errorf("Composite question missing %q:\n%s", want, question)
+		}
+	}
+}
+
+func TestSplitSections(t *testing.T) {
+	answer := "Sure, here goes.\n\n## Q1: Mutexes\nA lock.\n\n**Q3.** Nothing else\n\n## Q2\nPick two.\n## Q9\nOut of range"
+
+	got := SplitSections(answer, 4)
+	want := []string{"A lock.", "Pick two.", "Nothing else", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitSections() = %q, want %q", got, want)
+	}
+}
+
+func TestParseSectionRankings(t *testing.T) {
+	anonMap := map[string]string{"grok-4": "A", "gpt-5": "B"}
+	prompt := FormatSectionRankingPrompt("grok-4", "Q?", []string{"One?", "Two?", "Three?"}, []string{"gpt-5"},
+		map[string][]string{"grok-4": {"1", "", "3"}, "gpt-5": {"1", "2", "3"}}, anonMap, nil)
+
+	if !strings.Contains(prompt, "# Q2 ANSWERS TO RANK") || !strings.Contains(prompt, "(no answer to this question)") {
+		t.Errorf("Expected every section and missing answers in the prompt:\n%s", prompt)
+	}
+
+	got := ParseSectionRankings("# Q1\nA\nB\n\n# Q2\nB\nA\n", prompt, 3)
+	want := [][]string{{"grok-4", "gpt-5"}, {"gpt-5", "grok-4"}, nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSectionRankings() = %v, want %v", got, want)
+	}
+}
@@ -3,195 +3,351 @@ package shared
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"slices"
 	"strings"
 
 	"github.com/meedamian/fat/internal/types"
 )
 
+// MaxSearches is how many web search queries a single reply may request
+const MaxSearches = 3
+
+// listMarker matches bullet or number markers models put in front of search queries
+var listMarker = regexp.MustCompile(`^([-*•]|\d+[.)])\s+`)
+
 // FormatPrompt creates a standardized prompt for all models
 // modelID is the short ID (e.g., "grok", "claude") used for discussion lookup
 // modelName is the full name (e.g., "grok-4-fast") used for display
 // privateNotes contains this model's own notes from previous rounds (keyed by round number)
+// When meta.MaxTok is set, previous-round context is trimmed to fit the model's context window
 func FormatPrompt(modelID, modelName, question string, meta types.Meta, replies map[string]types.Reply, discussion map[string]map[string][]types.DiscussionMessage, privateNotes map[int]string) string {
-	var b strings.Builder
+	pc := collectContext(modelID, modelName, meta, replies, discussion, privateNotes)
+	prompt := renderPrompt(modelName, question, meta, pc, false)
 
-	otherAgentsStr := "none"
-	if len(meta.OtherAgents) > 0 {
-		otherAgentsStr = strings.Join(meta.OtherAgents, ", ")
+	budget := promptBudget(meta.MaxTok)
+	if budget == 0 {
+		return prompt
 	}
 
-	agentCount := len(meta.OtherAgents) + 1
-	b.WriteString(fmt.Sprintf("You are %s in a %d-agent collaboration. Other agents: %s. Round %d of %d.\n\n", modelName, agentCount, otherAgentsStr, meta.Round, meta.TotalRounds))
+	total := EstimateTokens(prompt)
+	if total <= budget {
+		return prompt
+	}
 
-	b.WriteString("# QUESTION\n\n")
-	b.WriteString(question)
-	b.WriteString("\n\n")
+	parts := pc.parts()
+	contextTokens := 0
+	for _, p := range parts {
+		contextTokens += EstimateTokens(p.text)
+	}
+	fixed := total - contextTokens + EstimateTokens(trimmedNotice)
+	if !fitParts(parts, max(0, budget-fixed)) {
+		return prompt
+	}
+	return renderPrompt(modelName, question, meta, pc, true)
+}
+
+// promptContext holds the previous-round context of a prompt, split into trimmable parts
+type promptContext struct {
+	hasReplies bool
+	own        *promptPart // nil when the model has no previous answer
+	others     []*promptPart
+	threads    []promptThread
+	hasNotes   bool
+	notes      []*promptPart
+	search     *promptPart // nil when the model has no search results
+}
+
+// promptThread is the visible part of a discussion with one agent
+type promptThread struct {
+	agent    string
+	messages []*promptPart
+}
+
+// parts returns every trimmable part in display order
+func (pc *promptContext) parts() []*promptPart {
+	var parts []*promptPart
+	if pc.own != nil {
+		parts = append(parts, pc.own)
+	}
+	parts = append(parts, pc.others...)
+	for _, t := range pc.threads {
+		parts = append(parts, t.messages...)
+	}
+	parts = append(parts, pc.notes...)
+	if pc.search != nil {
+		parts = append(parts, pc.search)
+	}
+	return parts
+}
+
+// collectContext gathers replies, discussion and private notes from previous rounds
+func collectContext(modelID, modelName string, meta types.Meta, replies map[string]types.Reply, discussion map[string]map[string][]types.DiscussionMessage, privateNotes map[int]string) *promptContext {
+	pc := &promptContext{}
 
 	// Only show context from previous rounds if not round 1
 	if meta.Round > 1 {
-		b.WriteString("# REPLIES from previous round:\n\n")
-		if len(replies) == 0 {
-			b.WriteString("(No replies available)\n\n")
+		pc.hasReplies = len(replies) > 0
+
+		// Show own previous answer first (replies map uses modelID as key)
+		if ownReply, hasOwn := replies[modelID]; hasOwn {
+			answer := strings.TrimSpace(ownReply.Answer)
+			if answer == "" {
+				answer = "(No answer provided)"
+			}
+			text := fmt.Sprintf("## Your previous answer (%s)\n\n%s\n\n", modelName, answer)
+
+			// Include rationale if provided
+			if strings.TrimSpace(ownReply.Rationale) != "" {
+				text += fmt.Sprintf("### Rationale\n\n%s\n\n", strings.TrimSpace(ownReply.Rationale))
+			}
+			pc.own = &promptPart{text: text, round: meta.Round - 1, priority: priorityOwnAnswer}
+
+			// Results of the web searches this model asked for (never shown to other agents)
+			if results := strings.TrimSpace(ownReply.SearchResult); results != "" {
+				pc.search = &promptPart{text: results + "\n\n", round: meta.Round - 1, priority: prioritySearchResults}
+			}
 		}
 
-		if len(replies) > 0 {
-			// Show own previous answer first (replies map uses modelID as key)
-			if ownReply, hasOwn := replies[modelID]; hasOwn {
-				answer := strings.TrimSpace(ownReply.Answer)
-				if answer == "" {
-					answer = "(No answer provided)"
-				}
-				b.WriteString(fmt.Sprintf("## Your previous answer (%s)\n\n", modelName))
-				b.WriteString(answer)
-				b.WriteString("\n\n")
+		// Show other agents' answers
+		agentIDs := make([]string, 0, len(replies))
+		for agentID := range replies {
+			if agentID != modelID {
+				agentIDs = append(agentIDs, agentID)
+			}
+		}
+		slices.Sort(agentIDs)
+
+		// Map short IDs to display names
+		idToDisplayName := map[string]string{
+			"grok":     "Grok",
+			"gpt":      "GPT",
+			"claude":   "Claude",
+			"gemini":   "Gemini",
+			"deepseek": "DeepSeek",
+			"mistral":  "Mistral",
+		}
 
-				// Include rationale if provided
-				if strings.TrimSpace(ownReply.Rationale) != "" {
-					b.WriteString(fmt.Sprintf("### Rationale\n\n%s\n\n", strings.TrimSpace(ownReply.Rationale)))
+		// Build a map of agentID -> full model name from OtherAgents
+		agentIDToFullName := make(map[string]string)
+		for _, fullName := range meta.OtherAgents {
+			lowerFullName := strings.ToLower(fullName)
+			for id := range idToDisplayName {
+				if strings.Contains(lowerFullName, id) {
+					agentIDToFullName[id] = fullName
+					break
 				}
 			}
+		}
 
-			// Show other agents' answers
-			agentIDs := make([]string, 0, len(replies))
-			for agentID := range replies {
-				if agentID != modelID {
-					agentIDs = append(agentIDs, agentID)
-				}
+		for _, agentID := range agentIDs {
+			reply := replies[agentID]
+			answer := strings.TrimSpace(reply.Answer)
+			if answer == "" {
+				answer = "(No answer provided)"
 			}
-			slices.Sort(agentIDs)
-
-			// Map short IDs to display names
-			idToDisplayName := map[string]string{
-				"grok":     "Grok",
-				"gpt":      "GPT",
-				"claude":   "Claude",
-				"gemini":   "Gemini",
-				"deepseek": "DeepSeek",
-				"mistral":  "Mistral",
+
+			// Get display name for this agent
+			displayName := idToDisplayName[agentID]
+			if displayName == "" {
+				displayName = agentID
 			}
 
-			// Build a map of agentID -> full model name from OtherAgents
-			agentIDToFullName := make(map[string]string)
-			for _, fullName := range meta.OtherAgents {
-				lowerFullName := strings.ToLower(fullName)
-				for id := range idToDisplayName {
-					if strings.Contains(lowerFullName, id) {
-						agentIDToFullName[id] = fullName
-						break
-					}
-				}
+			// Get full model name; an agent going by its variant name already shows it
+			fullModelName := agentIDToFullName[agentID]
+			if fullModelName == "" {
+				fullModelName = agentID
 			}
 
-			for _, agentID := range agentIDs {
-				reply := replies[agentID]
-				answer := strings.TrimSpace(reply.Answer)
-				if answer == "" {
-					answer = "(No answer provided)"
-				}
+			text := fmt.Sprintf("## %s (%s)\n\n%s\n\n", displayName, fullModelName, answer)
+			if fullModelName == displayName {
+				text = fmt.Sprintf("## %s\n\n%s\n\n", displayName, answer)
+			}
 
-				// Get display name for this agent
-				displayName := idToDisplayName[agentID]
-				if displayName == "" {
-					displayName = agentID
-				}
+			// Include rationale if provided
+			if strings.TrimSpace(reply.Rationale) != "" {
+				text += fmt.Sprintf("### Rationale\n\n%s\n\n", strings.TrimSpace(reply.Rationale))
+			}
+			pc.others = append(pc.others, &promptPart{text: text, round: meta.Round - 1, priority: priorityOtherReplies})
+		}
 
-				// Get full model name
-				fullModelName := agentIDToFullName[agentID]
-				if fullModelName == "" {
-					fullModelName = agentID
-				}
+		// Show conversation threads with other agents
+		threads := discussion[modelID]
 
-				b.WriteString(fmt.Sprintf("## %s (%s)\n\n%s\n\n", displayName, fullModelName, answer))
+		// Sort agent names for consistent ordering
+		agents := make([]string, 0, len(threads))
+		for agent := range threads {
+			if len(threads[agent]) > 0 {
+				agents = append(agents, agent)
+			}
+		}
+		slices.Sort(agents)
+
+		for _, agent := range agents {
+			messages := threads[agent]
+
+			// Find the latest message from each party
+			var lastFromMe, lastToMe *types.DiscussionMessage
+			for i := len(messages) - 1; i >= 0; i-- {
+				msg := &messages[i]
+				if msg.From == modelID && lastFromMe == nil {
+					lastFromMe = msg
+				} else if msg.From == agent && lastToMe == nil {
+					lastToMe = msg
+				}
+				// Stop once we have both (or confirmed we don't have one)
+				if lastFromMe != nil && lastToMe != nil {
+					break
+				}
+			}
 
-				// Include rationale if provided
-				if strings.TrimSpace(reply.Rationale) != "" {
-					b.WriteString(fmt.Sprintf("### Rationale\n\n%s\n\n", strings.TrimSpace(reply.Rationale)))
+			// Show context: my last message to them (if any), then the latest message from them to me
+			thread := promptThread{agent: agent}
+			for _, msg := range []*types.DiscussionMessage{lastFromMe, lastToMe} {
+				if msg == nil {
+					continue
+				}
+				trimmed := strings.TrimSpace(msg.Message)
+				if trimmed == "" {
+					continue
 				}
+				thread.messages = append(thread.messages, &promptPart{
+					text:     fmt.Sprintf("%s: %s\n\n", msg.From, trimmed),
+					round:    msg.Round,
+					priority: priorityDiscussion,
+				})
 			}
+			pc.threads = append(pc.threads, thread)
 		}
+	}
 
-		// Show conversation threads with other agents
-		if threads, hasThreads := discussion[modelID]; hasThreads && len(threads) > 0 {
-			// Check if there are any threads with messages
-			hasContent := false
-			for _, messages := range threads {
-				if len(messages) > 0 {
+	// Show this model's private notes from previous rounds (if any)
+	pc.hasNotes = len(privateNotes) > 0
+	for round := 1; round < meta.Round; round++ {
+		if note, exists := privateNotes[round]; exists && strings.TrimSpace(note) != "" {
+			pc.notes = append(pc.notes, &promptPart{
+				text:     fmt.Sprintf("## ROUND %d\n\n%s\n\n", round, strings.TrimSpace(note)),
+				round:    round,
+				priority: priorityPrivateNotes,
+			})
+		}
+	}
+
+	return pc
+}
+
+// renderPrompt assembles the prompt around the collected context
+// trimmed adds a notice that some context was shortened to fit the context window
+func renderPrompt(modelName, question string, meta types.Meta, pc *promptContext, trimmed bool) string {
+	var b strings.Builder
+
+	otherAgentsStr := "none"
+	if len(meta.OtherAgents) > 0 {
+		otherAgentsStr = strings.Join(meta.OtherAgents, ", ")
+	}
+
+	// In a debate the agents argue assigned sides instead of working towards one answer
+	position := meta.Positions[modelName]
+	kind := "collaboration"
+	if position != "" {
+		kind = "debate"
+	}
+
+	agentCount := len(meta.OtherAgents) + 1
+	b.WriteString(fmt.Sprintf("You are %s in a %d-agent %s. Other agents: %s. Round %d of %d.\n\n", modelName, agentCount, kind, otherAgentsStr, meta.Round, meta.TotalRounds))
+
+	// A follow-up carries the questions it continues, so models don't start cold
+	if len(meta.Session) > 0 {
+		b.WriteString("# EARLIER IN THIS SESSION\n\n")
+		b.WriteString("(This question follows up on these; the answers are what the agents settled on)\n\n")
+		for i, turn := range meta.Session {
+			b.WriteString(fmt.Sprintf("## Question %d\n\n%s\n\n", i+1, turn.Question))
+			b.WriteString(fmt.Sprintf("## Answer %d\n\n%s\n\n", i+1, turn.Answer))
+		}
+	}
+
+	b.WriteString("# QUESTION\n\n")
+	b.WriteString(question)
+	b.WriteString("\n\n")
+
+	if position != "" {
+		writeDebateBrief(&b, modelName, position, meta.OtherAgents, meta.Positions)
+	}
+
+	// Older rounds that didn't fit the context window arrive condensed
+	if meta.Summary != "" {
+		b.WriteString("# SUMMARY OF EARLIER ROUNDS\n\n")
+		b.WriteString("(Discussion and your private notes from before the previous round, condensed to fit your context window)\n\n")
+		b.WriteString(strings.TrimSpace(meta.Summary))
+		b.WriteString("\n\n")
+	}
+
+	// Only show context from previous rounds if not round 1
+	if meta.Round > 1 {
+		b.WriteString("# REPLIES from previous round:\n\n")
+		if !pc.hasReplies {
+			b.WriteString("(No replies available)\n\n")
+		}
+
+		if pc.own != nil {
+			b.WriteString(pc.own.text)
+		}
+		for _, p := range pc.others {
+			b.WriteString(p.text)
+		}
+
+		// Only threads with something left to show get a heading
+		hasContent := false
+		for _, t := range pc.threads {
+			for _, m := range t.messages {
+				if m.text != "" {
 					hasContent = true
-					break
 				}
 			}
+		}
 
-			if hasContent {
-				b.WriteString("# DISCUSSION\n\n")
+		if hasContent {
+			b.WriteString("# DISCUSSION\n\n")
 
-				// Sort agent names for consistent ordering
-				agents := make([]string, 0, len(threads))
-				for agent := range threads {
-					if len(threads[agent]) > 0 {
-						agents = append(agents, agent)
-					}
+			for _, t := range pc.threads {
+				var thread strings.Builder
+				for _, m := range t.messages {
+					thread.WriteString(m.text)
 				}
-				slices.Sort(agents)
-
-				for _, agent := range agents {
-					messages := threads[agent]
-					if len(messages) == 0 {
-						continue
-					}
-
-					b.WriteString(fmt.Sprintf("## With %s\n\n", agent))
-
-					// Find the latest message from each party
-					var lastFromMe, lastToMe *types.DiscussionMessage
-					for i := len(messages) - 1; i >= 0; i-- {
-						msg := &messages[i]
-						if msg.From == modelID && lastFromMe == nil {
-							lastFromMe = msg
-						} else if msg.From == agent && lastToMe == nil {
-							lastToMe = msg
-						}
-						// Stop once we have both (or confirmed we don't have one)
-						if lastFromMe != nil && lastToMe != nil {
-							break
-						}
-					}
-
-					// Show context: my last message to them (if any)
-					if lastFromMe != nil {
-						trimmed := strings.TrimSpace(lastFromMe.Message)
-						if trimmed != "" {
-							b.WriteString(fmt.Sprintf("%s: %s\n\n", lastFromMe.From, trimmed))
-						}
-					}
-
-					// Show the latest message from them to me
-					if lastToMe != nil {
-						trimmed := strings.TrimSpace(lastToMe.Message)
-						if trimmed != "" {
-							b.WriteString(fmt.Sprintf("%s: %s\n\n", lastToMe.From, trimmed))
-						}
-					}
+				if thread.Len() == 0 {
+					continue
 				}
+				b.WriteString(fmt.Sprintf("## With %s\n\n", t.agent))
+				b.WriteString(thread.String())
 			}
 		}
 	}
 
 	// Show this model's private notes from previous rounds (if any)
 	// These are ONLY visible to this model - never shared with other agents
-	if len(privateNotes) > 0 {
+	if pc.hasNotes {
 		b.WriteString("# YOUR PRIVATE NOTES from previous rounds\n\n")
 		b.WriteString("(Only you can see these - no other agent or human has access)\n\n")
-		for round := 1; round < meta.Round; round++ {
-			if note, exists := privateNotes[round]; exists && strings.TrimSpace(note) != "" {
-				b.WriteString(fmt.Sprintf("## ROUND %d\n\n%s\n\n", round, strings.TrimSpace(note)))
-			}
+		for _, p := range pc.notes {
+			b.WriteString(p.text)
 		}
 	}
 
+	if pc.search != nil && pc.search.text != "" {
+		b.WriteString("# YOUR SEARCH RESULTS\n\n")
+		b.WriteString("(Web search results for the queries you requested last round - verify before relying on them)\n\n")
+		b.WriteString(pc.search.text)
+	}
+
+	if trimmed {
+		b.WriteString(trimmedNotice)
+	}
+
 	// Round-specific instructions
 	b.WriteString("--- YOUR TASK ---\n\n")
-	if meta.Round == 1 {
+	if position != "" {
+		writeDebateTask(&b, meta.Round, meta.TotalRounds)
+	} else if meta.Round == 1 {
 		b.WriteString("This is round 1 - provide your initial answer to the question.\n\n")
 		b.WriteString("Focus on:\n")
 		b.WriteString("- Answering the question directly and completely\n")
@@ -199,7 +355,7 @@ func FormatPrompt(modelID, modelName, question string, meta types.Meta, replies
 		b.WriteString("- Being concise but thorough\n\n")
 	}
 
-	if meta.Round > 1 {
+	if position == "" && meta.Round > 1 {
 		b.WriteString(fmt.Sprintf("This is round %d of %d - refine your answer based on:\n", meta.Round, meta.TotalRounds))
 		b.WriteString("1. Gaps or weaknesses in other agents' answers\n")
 		b.WriteString("2. Discussion points directed at you\n")
@@ -218,13 +374,20 @@ func FormatPrompt(modelID, modelName, question string, meta types.Meta, replies
 	}
 
 	b.WriteString("--- RESPONSE FORMAT ---\n\n")
+	if meta.Structured {
+		writeStructuredFormat(&b, meta)
+		return b.String()
+	}
+
 	b.WriteString("Respond in this EXACT format:\n\n")
 	b.WriteString("# ANSWER\n\n")
 	if meta.Round == 1 {
 		b.WriteString("Your answer to the question\n")
 	}
 
-	if meta.Round > 1 {
+	if meta.Round > 1 && position != "" {
+		b.WriteString("Your strengthened argument for your side (rebut the other side, answer their questions)\n")
+	} else if meta.Round > 1 {
 		b.WriteString("Your refined answer (incorporate feedback, address gaps)\n")
 	}
 
@@ -246,7 +409,11 @@ func FormatPrompt(modelID, modelName, question string, meta types.Meta, replies
 		b.WriteString("# DISCUSSION\n\n")
 		b.WriteString("(Optional - only if you have substantive feedback)\n\n")
 		b.WriteString("## With [AgentName]\n\n")
-		b.WriteString("[One specific, actionable suggestion]\n\n")
+		if position != "" {
+			b.WriteString("[One pointed cross-examination question or challenge]\n\n")
+		} else {
+			b.WriteString("[One specific, actionable suggestion]\n\n")
+		}
 		b.WriteString("IMPORTANT RULES:\n")
 		b.WriteString("- Omit DISCUSSION section entirely if no substantive feedback\n")
 		b.WriteString("- Each message must suggest a specific improvement or ask a clarifying question\n")
@@ -264,6 +431,14 @@ func FormatPrompt(modelID, modelName, question string, meta types.Meta, replies
 	b.WriteString("- They will be passed back to you in future rounds\n")
 	b.WriteString("Use this for tracking your reasoning, things to investigate, or ideas to develop.\n")
 
+	// Searches only make sense when there is a next round to use the results in
+	if meta.Search && meta.Round < meta.TotalRounds {
+		b.WriteString("\n# SEARCH\n\n")
+		b.WriteString(fmt.Sprintf("(Optional) Up to %d web search queries, one per line.\n", MaxSearches))
+		b.WriteString("The results will be shown only to you in the next round.\n")
+		b.WriteString("Use this to verify facts, figures or recent events you are unsure of - omit it otherwise.\n")
+	}
+
 	return b.String()
 }
 
@@ -361,6 +536,8 @@ func ParseResponse(content string) types.Reply {
 			case "PRIVATE NOTES":
 				currentSection = "private_notes"
 				foundAnySection = true
+			case "SEARCH":
+				currentSection = "search"
 			default:
 				currentSection = ""
 			}
@@ -447,5 +624,12 @@ func saveSection(reply *types.Reply, section, content, agent string) {
 		}
 	case "private_notes":
 		reply.PrivateNotes = content
+	case "search":
+		for _, line := range strings.Split(content, "\n") {
+			query := strings.Trim(listMarker.ReplaceAllString(strings.TrimSpace(line), ""), "\"'`")
+			if query != "" && len(reply.Searches) < MaxSearches {
+				reply.Searches = append(reply.Searches, query)
+			}
+		}
 	}
 }
@@ -616,3 +616,95 @@ Test`,
 		})
 	}
 }
+
+func TestParseResponse_Search(t *testing.T) {
+	content := `# ANSWER
+
+Paris
+
+# SEARCH
+
+- "population of Paris 2024"
+2. Eiffel Tower height
+2024 Olympics host city
+one query too many`
+
+	reply := ParseResponse(content)
+	if reply.Answer != "Paris" {
+		t.Errorf("Expected answer 'Paris', got %q", reply.Answer)
+	}
+
+	expected := []string{"population of Paris 2024", "Eiffel Tower height", "2024 Olympics host city"}
+	if len(reply.Searches) != len(expected) {
+		t.Fatalf("Expected %d searches, got %v", len(expected), reply.Searches)
+	}
+	for i, query := range expected {
+		if reply.Searches[i] != query {
+			t.Errorf("Search %d: expected %q, got %q", i, query, reply.Searches[i])
+		}
+	}
+}
+
+func TestFormatPromptSearch(t *testing.T) {
+	meta := types.Meta{Round: 2, TotalRounds: 3, OtherAgents: []string{"gpt-5"}, Search: true}
+	replies := map[string]types.Reply{
+		"grok": {Answer: "Mine", SearchResult: "## Paris population\n\n1. Paris\n   https://example.com\n"},
+		"gpt":  {Answer: "Theirs", SearchResult: "## secret query\n"},
+	}
+
+	prompt := FormatPrompt("grok", "grok-4", "Question?", meta, replies, nil, nil)
+	if !strings.Contains(prompt, "# YOUR SEARCH RESULTS") || !strings.Contains(prompt, "https://example.com") {
+		t.Error("Expected the model's own search results in the prompt")
+	}
+	if strings.Contains(prompt, "secret query") {
+		t.Error("Other agents' search results must not be shown")
+	}
+	if !strings.Contains(prompt, "\n# SEARCH\n") {
+		t.Error("Expected SEARCH instructions when search is enabled and rounds remain")
+	}
+
+	meta.Round = 3
+	if prompt := FormatPrompt("grok", "grok-4", "Question?", meta, replies, nil, nil); strings.Contains(prompt, "\n# SEARCH\n") {
+		t.Error("SEARCH instructions should be omitted in the last round")
+	}
+
+	meta.Round, meta.Search = 2, false
+	if prompt := FormatPrompt("grok", "grok-4", "Question?", meta, replies, nil, nil); strings.Contains(prompt, "\n# SEARCH\n") {
+		t.Error("SEARCH instructions should be omitted when search is disabled")
+	}
+}
+
+func TestFormatPromptSession(t *testing.T) {
+	meta := types.Meta{Round: 1, TotalRounds: 3, OtherAgents: []string{"gpt-5"}}
+	if prompt := FormatPrompt("grok", "grok-4", "And in winter?", meta, nil, nil, nil); strings.Contains(prompt, "# EARLIER IN THIS SESSION") {
+		t.Error("Fresh questions should not have a session section")
+	}
+
+	meta.Session = []types.Turn{{Question: "How cold is Oslo?", Answer: "About 5°C on average."}}
+	prompt := FormatPrompt("grok", "grok-4", "And in winter?", meta, nil, nil, nil)
+	session := strings.Index(prompt, "# EARLIER IN THIS SESSION")
+	question := strings.Index(prompt, "# QUESTION")
+	if session < 0 || session > question {
+		t.Fatal("Expected the session before the question")
+	}
+	if !strings.Contains(prompt, "How cold is Oslo?") || !strings.Contains(prompt, "About 5°C on average.") {
+		t.Error("Expected the earlier question and its answer in the prompt")
+	}
+}
+
+// TestFormatPromptVariantAgents verifies that agents of the same family going by their variant names are told apart
+func TestFormatPromptVariantAgents(t *testing.T) {
+	meta := types.Meta{Round: 2, TotalRounds: 3, OtherAgents: []string{"gpt-5", "gpt-5-mini"}}
+	replies := map[string]types.Reply{
+		"grok":       {Answer: "Answer from Grok"},
+		"gpt-5":      {Answer: "Answer from gpt-5"},
+		"gpt-5-mini": {Answer: "Answer from gpt-5-mini"},
+	}
+
+	prompt := FormatPrompt("grok", "grok-4", "What is AI?", meta, replies, nil, nil)
+	for _, want := range []string{"## gpt-5\n\nAnswer from gpt-5\n\n", "## gpt-5-mini\n\nAnswer from gpt-5-mini\n\n"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected the prompt to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// ReplySchemaName names the reply schema in provider requests (e.g. OpenAI's json_schema or Claude's tool)
+const ReplySchemaName = "reply"
+
+// structuredReply is the JSON form of a reply requested in structured output mode
+type structuredReply struct {
+	Answer       json.RawMessage `json:"answer"` // A string, or any JSON value under an answer schema
+	Rationale    string          `json:"rationale"`
+	Discussion   json.RawMessage `json:"discussion"`
+	PrivateNotes string          `json:"private_notes"`
+	Searches     []string        `json:"searches"`
+}
+
+// discussionEntry is one message of a structured reply's discussion
+type discussionEntry struct {
+	Agent   string `json:"agent"`
+	Message string `json:"message"`
+}
+
+// ReplySchema returns the JSON schema of a structured reply, with answer as the schema of its answer (nil for text)
+// Every field is required and no others are allowed, as OpenAI's strict mode demands;
+// optional sections are sent as empty strings or arrays. A new map is returned on every call.
+func ReplySchema(answer map[string]any) map[string]any {
+	str := func() map[string]any { return map[string]any{"type": "string"} }
+	if answer == nil {
+		answer = str()
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"answer":    answer,
+			"rationale": str(),
+			"discussion": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"agent":   str(),
+						"message": str(),
+					},
+					"required":             []string{"agent", "message"},
+					"additionalProperties": false,
+				},
+			},
+			"private_notes": str(),
+			"searches": map[string]any{
+				"type":  "array",
+				"items": str(),
+			},
+		},
+		"required":             []string{"answer", "rationale", "discussion", "private_notes", "searches"},
+		"additionalProperties": false,
+	}
+}
+
+// writeStructuredFormat describes the JSON reply, carrying the same rules as the markdown format
+func writeStructuredFormat(b *strings.Builder, meta types.Meta) {
+	b.WriteString("Respond with a single JSON object with these fields:\n\n")
+
+	if meta.Round == 1 {
+		b.WriteString("- \"answer\": Your answer to the question.\n")
+	} else {
+		b.WriteString("- \"answer\": Your refined answer (incorporate feedback, address gaps).\n")
+	}
+	if meta.AnswerSchema != nil {
+		schema, _ := json.MarshalIndent(meta.AnswerSchema, "  ", "  ")
+		b.WriteString("  The answer must be a JSON value (not a string containing JSON) that validates against this schema:\n  ")
+		b.Write(schema)
+		b.WriteString("\n  Answers that don't validate are ranked below every answer that does.\n")
+	} else {
+		b.WriteString("  Include ONLY the raw answer here - no scaffolding, disclaimers, or meta-commentary.\n")
+	}
+
+	if meta.Round == 1 {
+		b.WriteString("- \"rationale\": Brief explanation of your approach or reasoning, or \"\".\n")
+	} else {
+		b.WriteString("- \"rationale\": Brief explanation of changes made (e.g., \"Added economic data from GPT's suggestion\"), or \"\".\n")
+	}
+
+	if meta.Round > 1 {
+		b.WriteString("- \"discussion\": Messages to other agents as [{\"agent\": \"AgentName\", \"message\": \"...\"}], or [] if you have no substantive feedback.\n")
+		b.WriteString("  Each message must suggest a specific improvement or ask a clarifying question - no prefixes like \"To AgentName:\", no bare praise.\n")
+	} else {
+		b.WriteString("- \"discussion\": [] (discussion starts in round 2).\n")
+	}
+
+	b.WriteString("- \"private_notes\": Your private scratchpad for the next round, or \"\". No other agent or human will ever see it.\n")
+
+	if meta.Search && meta.Round < meta.TotalRounds {
+		b.WriteString(fmt.Sprintf("- \"searches\": Up to %d web search queries whose results will be shown only to you in the next round, or [].\n", MaxSearches))
+		b.WriteString("  Use them to verify facts, figures or recent events you are unsure of.\n")
+	} else {
+		b.WriteString("- \"searches\": [].\n")
+	}
+
+	b.WriteString("\nReturn only the JSON object, without code fences or any text around it.\n")
+}
+
+// ParseReply parses a model's reply, trying JSON first when structured output was requested
+// Falls back to markdown parsing when the model didn't return a usable JSON object.
+func ParseReply(content string, structured bool) types.Reply {
+	if structured {
+		if reply, ok := ParseStructuredResponse(content); ok {
+			return reply
+		}
+	}
+	return ParseResponse(content)
+}
+
+// ParseStructuredResponse parses a JSON reply, tolerating code fences and text around the object
+// Discussion may be an array of {agent, message} or an object keyed by agent.
+// Returns false if there is no JSON object with an answer or rationale.
+func ParseStructuredResponse(content string) (types.Reply, bool) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return types.Reply{}, false
+	}
+
+	var sr structuredReply
+	if err := json.Unmarshal([]byte(content[start:end+1]), &sr); err != nil {
+		return types.Reply{}, false
+	}
+
+	reply := types.Reply{
+		Answer:       structuredAnswer(sr.Answer),
+		Rationale:    strings.TrimSpace(sr.Rationale),
+		Discussion:   make(map[string]string),
+		PrivateNotes: strings.TrimSpace(sr.PrivateNotes),
+		RawContent:   content,
+	}
+	if reply.Answer == "" && reply.Rationale == "" {
+		return types.Reply{}, false
+	}
+
+	var entries []discussionEntry
+	var byAgent map[string]string
+	if err := json.Unmarshal(sr.Discussion, &entries); err == nil {
+		for _, e := range entries {
+			saveSection(&reply, "discussion", e.Message, strings.TrimSpace(e.Agent))
+		}
+	} else if err := json.Unmarshal(sr.Discussion, &byAgent); err == nil {
+		for agent, message := range byAgent {
+			saveSection(&reply, "discussion", message, strings.TrimSpace(agent))
+		}
+	}
+
+	for _, query := range sr.Searches {
+		if query = strings.TrimSpace(query); query != "" && len(reply.Searches) < MaxSearches {
+			reply.Searches = append(reply.Searches, query)
+		}
+	}
+
+	return reply, true
+}
+
+// structuredAnswer returns a string answer as is, and any other JSON value as compact JSON text
+func structuredAnswer(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return strings.TrimSpace(text)
+	}
+
+	var compact bytes.Buffer
+	if len(raw) == 0 || string(raw) == "null" || json.Compact(&compact, raw) != nil {
+		return ""
+	}
+	return compact.String()
+}
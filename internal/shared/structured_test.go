@@ -0,0 +1,95 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestParseStructuredResponse(t *testing.T) {
+	content := "```json\n" + `{
+  "answer": "Paris",
+  "rationale": "Capital of France",
+  "discussion": [{"agent": "GPT", "message": "Cite a source."}, {"agent": "", "message": "dropped"}],
+  "private_notes": "check population",
+  "searches": ["paris population", " "]
+}` + "\n```"
+
+	reply, ok := ParseStructuredResponse(content)
+	if !ok {
+		t.Fatal("Expected the JSON reply to parse")
+	}
+	if reply.Answer != "Paris" || reply.Rationale != "Capital of France" || reply.PrivateNotes != "check population" {
+		t.Errorf("Unexpected reply: %+v", reply)
+	}
+	if len(reply.Discussion) != 1 || reply.Discussion["GPT"] != "Cite a source." {
+		t.Errorf("Expected one discussion message to GPT, got %v", reply.Discussion)
+	}
+	if len(reply.Searches) != 1 || reply.Searches[0] != "paris population" {
+		t.Errorf("Expected one search, got %v", reply.Searches)
+	}
+	if reply.RawContent != content {
+		t.Error("Expected raw content to be preserved")
+	}
+}
+
+func TestParseStructuredResponseDiscussionObject(t *testing.T) {
+	reply, ok := ParseStructuredResponse(`{"answer": "42", "discussion": {"Claude": "Show the steps."}}`)
+	if !ok || reply.Discussion["Claude"] != "Show the steps." {
+		t.Errorf("Expected discussion keyed by agent, got %+v (ok=%v)", reply, ok)
+	}
+}
+
+func TestParseReplyFallsBackToMarkdown(t *testing.T) {
+	content := "# ANSWER\n\nParis\n\n# RATIONALE\n\nIt's {obviously} the capital"
+
+	reply := ParseReply(content, true)
+	if reply.Answer != "Paris" {
+		t.Errorf("Expected markdown fallback answer 'Paris', got %q", reply.Answer)
+	}
+
+	if _, ok := ParseStructuredResponse(`{"answer": ""}`); ok {
+		t.Error("Expected a JSON object without answer or rationale to be rejected")
+	}
+}
+
+func TestReplySchemaRequiresEveryField(t *testing.T) {
+	schema := ReplySchema(nil)
+	properties := schema["properties"].(map[string]any)
+	required := schema["required"].([]string)
+	if len(required) != len(properties) {
+		t.Errorf("Strict mode needs every property required, got %v for %d properties", required, len(properties))
+	}
+}
+
+func TestFormatPromptStructured(t *testing.T) {
+	meta := types.Meta{Round: 2, TotalRounds: 3, OtherAgents: []string{"gpt-5"}, Structured: true}
+	prompt := FormatPrompt("grok", "grok-4", "Question?", meta, nil, nil, nil)
+
+	if !strings.Contains(prompt, "single JSON object") || !strings.Contains(prompt, `"discussion"`) {
+		t.Error("Expected JSON response format instructions")
+	}
+	if strings.Contains(prompt, "# ANSWER") {
+		t.Error("Markdown response format should be omitted in structured mode")
+	}
+}
+
+func TestStructuredAnswerSchema(t *testing.T) {
+	answer := map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}}
+	schema := ReplySchema(answer)
+	if got := schema["properties"].(map[string]any)["answer"]; got.(map[string]any)["type"] != "object" {
+		t.Errorf("Expected the answer schema in place of a string, got %v", got)
+	}
+
+	reply, ok := ParseStructuredResponse(`{"answer": {"city": "Paris"}, "rationale": ""}`)
+	if !ok || reply.Answer != `{"city":"Paris"}` {
+		t.Errorf("Expected a JSON answer as compact text, got %q (ok=%v)", reply.Answer, ok)
+	}
+
+	meta := types.Meta{Round: 1, TotalRounds: 3, Structured: true, AnswerSchema: answer}
+	prompt := FormatPrompt("grok", "grok-4", "Question?", meta, nil, nil, nil)
+	if !strings.Contains(prompt, "validates against this schema") || !strings.Contains(prompt, `"city"`) {
+		t.Error("Expected the answer schema in the response format instructions")
+	}
+}
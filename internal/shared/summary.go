@@ -0,0 +1,85 @@
+package shared
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// Overflows reports whether the untrimmed prompt would exceed the context window in meta.MaxTok
+// A window of 0 means unknown, which never overflows.
+func Overflows(modelID, modelName, question string, meta types.Meta, replies map[string]types.Reply, discussion map[string]map[string][]types.DiscussionMessage, privateNotes map[int]string) bool {
+	budget := promptBudget(meta.MaxTok)
+	if budget == 0 {
+		return false
+	}
+	pc := collectContext(modelID, modelName, meta, replies, discussion, privateNotes)
+	return EstimateTokens(renderPrompt(modelName, question, meta, pc, false)) > budget
+}
+
+// SplitOlderRounds separates modelID's discussion and private notes written before the previous round
+// It returns them rendered for a summarizer, along with copies of discussion and privateNotes without them.
+// older is empty when there is nothing to summarize, in which case the originals are returned.
+func SplitOlderRounds(modelID string, round int, discussion map[string]map[string][]types.DiscussionMessage, privateNotes map[int]string) (older string, recent map[string]map[string][]types.DiscussionMessage, recentNotes map[int]string) {
+	var b strings.Builder
+	threads := make(map[string][]types.DiscussionMessage, len(discussion[modelID]))
+
+	agents := make([]string, 0, len(discussion[modelID]))
+	for agent := range discussion[modelID] {
+		agents = append(agents, agent)
+	}
+	slices.Sort(agents)
+	for _, agent := range agents {
+		var kept []types.DiscussionMessage
+		var thread strings.Builder
+		for _, msg := range discussion[modelID][agent] {
+			if msg.Round >= round-1 {
+				kept = append(kept, msg)
+			} else if text := strings.TrimSpace(msg.Message); text != "" {
+				thread.WriteString(fmt.Sprintf("Round %d, %s: %s\n\n", msg.Round, msg.From, text))
+			}
+		}
+		threads[agent] = kept
+		if thread.Len() > 0 {
+			b.WriteString(fmt.Sprintf("## Discussion with %s\n\n", agent))
+			b.WriteString(thread.String())
+		}
+	}
+
+	recentNotes = make(map[int]string, len(privateNotes))
+	for r := 1; r < round; r++ {
+		note, ok := privateNotes[r]
+		if !ok {
+			continue
+		}
+		if r >= round-1 {
+			recentNotes[r] = note
+		} else if text := strings.TrimSpace(note); text != "" {
+			b.WriteString(fmt.Sprintf("## Your private notes from round %d\n\n%s\n\n", r, text))
+		}
+	}
+
+	if b.Len() == 0 {
+		return "", discussion, privateNotes
+	}
+
+	// Other models' threads are never shown to modelID, so they're left out of the copy
+	recent = map[string]map[string][]types.DiscussionMessage{modelID: threads}
+	return b.String(), recent, recentNotes
+}
+
+// FormatSummaryPrompt asks a summarizer to condense older rounds of a collaboration for modelName
+func FormatSummaryPrompt(modelName, question, older string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("The context below is from earlier rounds of a multi-agent collaboration, as seen by %s. ", modelName))
+	b.WriteString("It no longer fits that agent's context window, so summarize it for them.\n\n")
+	b.WriteString("Keep every claim, correction, open disagreement and commitment made, attributed to whoever made it. ")
+	b.WriteString("Drop pleasantries and repetition. Reply with the summary only, in plain prose or bullet points, in at most a few hundred words.\n\n")
+	b.WriteString("# QUESTION THEY ARE ANSWERING\n\n")
+	b.WriteString(question)
+	b.WriteString("\n\n# EARLIER ROUNDS\n\n")
+	b.WriteString(older)
+	return b.String()
+}
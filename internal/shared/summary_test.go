@@ -0,0 +1,74 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestOverflows(t *testing.T) {
+	replies := map[string]types.Reply{"gpt": {Answer: strings.Repeat("word ", 20_000)}}
+	meta := types.Meta{Round: 2, TotalRounds: 3, OtherAgents: []string{"gpt-5"}}
+
+	if Overflows("grok", "grok-4", "Q?", meta, replies, nil, nil) {
+		t.Error("Expected an unknown context window never to overflow")
+	}
+	meta.MaxTok = 20_000
+	if !Overflows("grok", "grok-4", "Q?", meta, replies, nil, nil) {
+		t.Error("Expected a prompt past the window less the response reserve to overflow")
+	}
+	meta.MaxTok = 200_000
+	if Overflows("grok", "grok-4", "Q?", meta, replies, nil, nil) {
+		t.Error("Expected a prompt within the window to fit")
+	}
+}
+
+func TestSplitOlderRounds(t *testing.T) {
+	discussion := map[string]map[string][]types.DiscussionMessage{
+		"grok": {"gpt": {
+			{From: "grok", Message: "You missed X", Round: 1},
+			{From: "gpt", Message: "X is covered", Round: 2},
+			{From: "gpt", Message: "Agreed on Y", Round: 3},
+		}},
+		"gpt": {"grok": {{From: "gpt", Message: "Not grok's", Round: 1}}},
+	}
+	notes := map[int]string{1: "check X", 2: "X settled", 3: "polish"}
+
+	older, recent, recentNotes := SplitOlderRounds("grok", 4, discussion, notes)
+
+	for _, want := range []string{"## Discussion with gpt", "Round 1, grok: You missed X", "Round 2, gpt: X is covered", "round 1\n\ncheck X", "round 2\n\nX settled"} {
+		if !strings.Contains(older, want) {
+			t.Errorf("Expected older rounds to contain %q, got %q", want, older)
+		}
+	}
+	if strings.Contains(older, "Agreed on Y") || strings.Contains(older, "polish") || strings.Contains(older, "Not grok's") {
+		t.Errorf("Expected only grok's context from before round 3, got %q", older)
+	}
+	if thread := recent["grok"]["gpt"]; len(thread) != 1 || thread[0].Message != "Agreed on Y" {
+		t.Errorf("Expected only the previous round's message kept, got %v", thread)
+	}
+	if len(recentNotes) != 1 || recentNotes[3] != "polish" {
+		t.Errorf("Expected only the previous round's note kept, got %v", recentNotes)
+	}
+	if len(discussion["grok"]["gpt"]) != 3 || len(notes) != 3 {
+		t.Error("Expected the originals left alone")
+	}
+
+	// Round 2 only has the previous round, leaving nothing to summarize
+	if older, _, _ := SplitOlderRounds("grok", 2, discussion, notes); older != "" {
+		t.Errorf("Expected nothing older than round 1, got %q", older)
+	}
+}
+
+func TestFormatPromptShowsSummary(t *testing.T) {
+	meta := types.Meta{Round: 4, TotalRounds: 4, Summary: "GPT conceded X."}
+	prompt := FormatPrompt("grok", "grok-4", "Q?", meta, nil, nil, nil)
+
+	if !strings.Contains(prompt, "# SUMMARY OF EARLIER ROUNDS") || !strings.Contains(prompt, "GPT conceded X.") {
+		t.Errorf("Expected the summary in the prompt, got %q", prompt)
+	}
+	if strings.Index(prompt, "# SUMMARY OF EARLIER ROUNDS") > strings.Index(prompt, "# REPLIES") {
+		t.Error("Expected the summary before the previous round's replies")
+	}
+}
@@ -0,0 +1,92 @@
+package shared
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// FormatSynthesisPrompt asks a synthesizer to merge the top answers to question, best first, into one
+// The answers are numbered rather than named, so the merge can't favor a model by reputation.
+func FormatSynthesisPrompt(question string, answers []string) string {
+	var b strings.Builder
+	b.WriteString("Several agents answered the question below, then ranked each other's answers. ")
+	b.WriteString("The best ones are listed in ranking order, best first.\n\n")
+	b.WriteString("Write one consolidated answer that keeps everything correct and useful from all of them: ")
+	b.WriteString("start from the best answer and add the points it misses that the others raised. ")
+	b.WriteString("Where they contradict each other, go with the better-supported claim and drop the other. ")
+	b.WriteString("Don't mention the agents, the ranking or that the answer was merged; answer the question directly, ")
+	b.WriteString("except for one thing: end every paragraph with the numbers of the answers it draws on, ")
+	b.WriteString("e.g. [sources: 1, 3], so each contribution can be credited.\n\n")
+	b.WriteString("# QUESTION\n\n")
+	b.WriteString(question)
+	b.WriteString("\n\n# TOP ANSWERS\n\n")
+	for i, answer := range answers {
+		b.WriteString(fmt.Sprintf("## Answer %d\n\n%s\n\n", i+1, strings.TrimSpace(answer)))
+	}
+	return b.String()
+}
+
+// sourcesTag matches the [sources: 1, 3] a synthesizer ends a paragraph with
+var sourcesTag = regexp.MustCompile(`(?i)\s*\[sources?:\s*([\d,\s]*)\]\s*$`)
+
+// ParseProvenance splits a synthesized answer into paragraphs and the answers each cites with [sources: ...],
+// numbered as in FormatSynthesisPrompt; sources holds the model variant of every answer in that order
+// It returns the answer without the tags, or unchanged with nil provenance if the synthesizer cited nothing.
+// Numbers outside the answers given are ignored.
+func ParseProvenance(answer string, sources []string) (string, []types.Provenance) {
+	var (
+		provenance []types.Provenance
+		paragraph  []string
+		inFence    bool
+		cited      bool
+	)
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(paragraph, "\n"))
+		paragraph = nil
+		var from []string
+		if m := sourcesTag.FindStringSubmatchIndex(text); m != nil {
+			cited = true
+			for _, n := range strings.Split(text[m[2]:m[3]], ",") {
+				i, err := strconv.Atoi(strings.TrimSpace(n))
+				if err == nil && i >= 1 && i <= len(sources) && !slices.Contains(from, sources[i-1]) {
+					from = append(from, sources[i-1])
+				}
+			}
+			text = strings.TrimSpace(text[:m[0]])
+		}
+		// A tag on a paragraph of its own cites the one before it
+		if text == "" {
+			if len(provenance) > 0 && len(provenance[len(provenance)-1].Sources) == 0 {
+				provenance[len(provenance)-1].Sources = from
+			}
+			return
+		}
+		provenance = append(provenance, types.Provenance{Paragraph: text, Sources: from})
+	}
+
+	for _, line := range strings.Split(answer, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if strings.TrimSpace(line) == "" && !inFence {
+			flush()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+
+	if !cited {
+		return answer, nil
+	}
+	paragraphs := make([]string, len(provenance))
+	for i, p := range provenance {
+		paragraphs[i] = p.Paragraph
+	}
+	return strings.Join(paragraphs, "\n\n"), provenance
+}
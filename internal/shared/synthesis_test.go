@@ -0,0 +1,52 @@
+package shared
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFormatSynthesisPrompt(t *testing.T) {
+	prompt := FormatSynthesisPrompt("Capital of France?", []string{"Paris", "  Paris, since 987\n"})
+
+	for _, want := range []string{"# QUESTION\n\nCapital of France?", "## Answer 1\n\nParis\n\n", "## Answer 2\n\nParis, since 987\n\n"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected the prompt to contain %q, got %q", want, prompt)
+		}
+	}
+	if strings.Index(prompt, "## Answer 1") > strings.Index(prompt, "## Answer 2") {
+		t.Error("Expected the answers in ranking order")
+	}
+}
+
+func TestParseProvenance(t *testing.T) {
+	sources := []string{"grok-4", "gpt-5", "claude-opus-4-6"}
+	answer := "Paris is the capital. [sources: 1, 2]\n\n" +
+		"```\ncode\n\nmore code\n```\n[Sources: 3, 3, 7]\n\n" +
+		"A closing thought.\n\n[sources: 2]\n\n" +
+		"Nobody said this."
+
+	clean, provenance := ParseProvenance(answer, sources)
+	if strings.Contains(clean, "[sources") || strings.Contains(clean, "[Sources") {
+		t.Errorf("Expected the tags removed, got %q", clean)
+	}
+	if len(provenance) != 4 {
+		t.Fatalf("Expected 4 paragraphs, got %+v", provenance)
+	}
+	if provenance[0].Paragraph != "Paris is the capital." || !slices.Equal(provenance[0].Sources, []string{"grok-4", "gpt-5"}) {
+		t.Errorf("Unexpected first paragraph %+v", provenance[0])
+	}
+	if provenance[1].Paragraph != "```\ncode\n\nmore code\n```" || !slices.Equal(provenance[1].Sources, []string{"claude-opus-4-6"}) {
+		t.Errorf("Expected the code block kept whole with unknown and repeated numbers dropped, got %+v", provenance[1])
+	}
+	if !slices.Equal(provenance[2].Sources, []string{"gpt-5"}) {
+		t.Errorf("Expected a tag on its own line to cite the paragraph before, got %+v", provenance[2])
+	}
+	if len(provenance[3].Sources) != 0 {
+		t.Errorf("Expected an uncited paragraph without sources, got %+v", provenance[3])
+	}
+
+	if clean, provenance := ParseProvenance("Paris.\n\nSince 987.", sources); clean != "Paris.\n\nSince 987." || provenance != nil {
+		t.Errorf("Expected an answer citing nothing left alone, got %q and %+v", clean, provenance)
+	}
+}
@@ -0,0 +1,59 @@
+package shared
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Sides of a head-to-head match, as a juror sees them
+const (
+	MatchA = "A"
+	MatchB = "B"
+)
+
+// ErrNoMatchVote is returned for a juror's reply that doesn't name answer A or B
+var ErrNoMatchVote = errors.New("no vote for answer A or B")
+
+// matchVote matches a juror's pick, e.g. "A", "Answer B" or "**B** - it cites sources"
+var matchVote = regexp.MustCompile(`(?i)^[*_\s]*(?:answer\s+)?([AB])\b[*_]*(?:\s*[:.–—-]\s*(.*))?$`)
+
+// FormatMatchPrompt asks a juror which of two answers to question is better
+// The answers are only lettered, so the juror can't favor a variant by name; callers swap a and b between
+// jurors to cancel out a preference for whichever answer comes first.
+func FormatMatchPrompt(question, a, b string) string {
+	var sb strings.Builder
+	sb.WriteString("You are JUDGING a head-to-head match between two answers to the same question. Do NOT write a new answer.\n\n")
+	sb.WriteString("# ORIGINAL QUESTION (for context only - DO NOT answer this)\n\n")
+	sb.WriteString(question)
+	sb.WriteString("\n\n# ANSWER A\n\n")
+	sb.WriteString(strings.TrimSpace(a))
+	sb.WriteString("\n\n# ANSWER B\n\n")
+	sb.WriteString(strings.TrimSpace(b))
+	sb.WriteString("\n\n# YOUR TASK\n\n")
+	sb.WriteString("Pick the better answer: accuracy first, then completeness, clarity and insight. ")
+	sb.WriteString("Answers violating the question's format requirements lose. There are no ties - pick one even if they are close.\n\n")
+	sb.WriteString("Respond with ONLY this section:\n\n")
+	sb.WriteString("# VERDICT\n\n")
+	sb.WriteString("A or B - one sentence on why\n")
+	return sb.String()
+}
+
+// ParseMatchVote reads a juror's reply to a FormatMatchPrompt prompt, returning MatchA or MatchB and the juror's reason
+// A reply without the # VERDICT heading is searched whole.
+func ParseMatchVote(content string) (string, string, error) {
+	lines := strings.Split(extractContentFromJSON(content), "\n")
+	for i, line := range lines {
+		if heading := strings.TrimLeft(strings.TrimSpace(line), "#"); heading != strings.TrimSpace(line) && strings.EqualFold(strings.TrimSpace(heading), "VERDICT") {
+			lines = lines[i+1:]
+			break
+		}
+	}
+
+	for _, line := range lines {
+		if m := matchVote.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			return strings.ToUpper(m[1]), strings.TrimSpace(m[2]), nil
+		}
+	}
+	return "", "", ErrNoMatchVote
+}
@@ -0,0 +1,36 @@
+package shared
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFormatMatchPrompt(t *testing.T) {
+	prompt := FormatMatchPrompt("Is Pluto a planet?", " No.\n", "Yes.")
+	for _, want := range []string{"Is Pluto a planet?", "# ANSWER A\n\nNo.\n\n", "# ANSWER B\n\nYes.\n\n", "# VERDICT"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected the prompt to contain %q", want)
+		}
+	}
+}
+
+func TestParseMatchVote(t *testing.T) {
+	for _, tc := range []struct {
+		content, vote, reason string
+	}{
+		{"# VERDICT\n\nB - it cites the 2006 IAU decision", MatchB, "it cites the 2006 IAU decision"},
+		{"## Verdict\n\n**Answer A**: more complete", MatchA, "more complete"},
+		{"# VERDICT\n\na", MatchA, ""},
+		{"Both are fine.\n\nB", MatchB, ""},
+	} {
+		vote, reason, err := ParseMatchVote(tc.content)
+		if err != nil || vote != tc.vote || reason != tc.reason {
+			t.Errorf("ParseMatchVote(%q) = %q, %q, %v; expected %q, %q", tc.content, vote, reason, err, tc.vote, tc.reason)
+		}
+	}
+
+	if _, _, err := ParseMatchVote("# VERDICT\n\nAbsolutely the first one"); !errors.Is(err, ErrNoMatchVote) {
+		t.Errorf("Expected ErrNoMatchVote, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package shared
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+// Verdicts a verifier gives a claim
+const (
+	ClaimSupported   = "supported"   // Confidently correct
+	ClaimUnsupported = "unsupported" // Wrong, or nothing backs it up
+	ClaimUncertain   = "uncertain"   // Can't be told either way
+)
+
+// claimLine matches one verified claim, e.g. "- [UNSUPPORTED] Completed in 1899 | It was 1889"
+var claimLine = regexp.MustCompile(`(?i)^\[?\s*(supported|unsupported|uncertain)\s*\]?\s*[:–—-]?\s*(.*)$`)
+
+// FormatVerificationPrompt asks an agent to fact-check the leading answer to question claim by claim
+// The answer isn't attributed, so verifiers check what it says rather than who said it.
+func FormatVerificationPrompt(question, answer string) string {
+	var b strings.Builder
+	b.WriteString("You are FACT-CHECKING the answer that leads a multi-agent collaboration. Do NOT write a new answer.\n\n")
+	b.WriteString("# QUESTION\n\n")
+	b.WriteString(question)
+	b.WriteString("\n\n# ANSWER TO VERIFY\n\n")
+	b.WriteString(strings.TrimSpace(answer))
+	b.WriteString("\n\n# YOUR TASK\n\n")
+	b.WriteString("List every checkable factual claim the answer makes - figures, dates, names, quotations, cause and effect - and judge each one:\n")
+	b.WriteString("- SUPPORTED: you are confident it is correct\n")
+	b.WriteString("- UNSUPPORTED: it is wrong, or nothing backs it up\n")
+	b.WriteString("- UNCERTAIN: you can't tell either way\n\n")
+	b.WriteString("Restate each claim briefly in your own words instead of copying whole paragraphs, and after \" | \" add a short note on\n")
+	b.WriteString("what is wrong or doubtful about every claim that isn't supported. Leave the section empty if there is nothing to check.\n\n")
+	b.WriteString("Respond with ONLY this section, one claim per line:\n\n")
+	b.WriteString("# VERIFICATION\n\n")
+	b.WriteString("- [SUPPORTED] The Eiffel Tower stands in Paris\n")
+	b.WriteString("- [UNSUPPORTED] It was completed in 1899 | It was completed in 1889\n")
+	b.WriteString("- [UNCERTAIN] It draws 7 million visitors a year | Figures vary by year and source\n")
+	return b.String()
+}
+
+// ParseVerification extracts the claims of a verifier's # VERIFICATION section
+// A reply without the heading is searched whole, as models sometimes file the list under # ANSWER instead.
+func ParseVerification(content string) []types.Claim {
+	lines := strings.Split(extractContentFromJSON(content), "\n")
+	for i, line := range lines {
+		if heading := strings.TrimLeft(strings.TrimSpace(line), "#"); heading != strings.TrimSpace(line) && strings.EqualFold(strings.TrimSpace(heading), "VERIFICATION") {
+			lines = lines[i+1:]
+			for j, line := range lines {
+				if strings.HasPrefix(strings.TrimSpace(line), "# ") {
+					lines = lines[:j]
+					break
+				}
+			}
+			break
+		}
+	}
+
+	var claims []types.Claim
+	for _, line := range lines {
+		m := claimLine.FindStringSubmatch(listMarker.ReplaceAllString(strings.TrimSpace(line), ""))
+		if m == nil {
+			continue
+		}
+		text, note, _ := strings.Cut(m[2], " | ")
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		claims = append(claims, types.Claim{Text: text, Status: strings.ToLower(m[1]), Note: strings.TrimSpace(note)})
+	}
+	return claims
+}
@@ -0,0 +1,58 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/types"
+)
+
+func TestFormatVerificationPrompt(t *testing.T) {
+	prompt := FormatVerificationPrompt("When was the Eiffel Tower built?", "  In 1889.\n")
+	for _, want := range []string{"# QUESTION\n\nWhen was the Eiffel Tower built?", "# ANSWER TO VERIFY\n\nIn 1889.\n\n", "# VERIFICATION"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected the prompt to contain %q", want)
+		}
+	}
+}
+
+func TestParseVerification(t *testing.T) {
+	content := `# ANSWER
+
+Checked below.
+
+# VERIFICATION
+
+- [SUPPORTED] The Eiffel Tower stands in Paris
+* [unsupported] It was completed in 1899 | It was completed in 1889
+1. UNCERTAIN: It draws 7 million visitors a year | Figures vary
+- Not a claim line
+- [SUPPORTED]
+
+# PRIVATE NOTES
+
+- [SUPPORTED] Outside the section`
+
+	want := []types.Claim{
+		{Text: "The Eiffel Tower stands in Paris", Status: ClaimSupported},
+		{Text: "It was completed in 1899", Status: ClaimUnsupported, Note: "It was completed in 1889"},
+		{Text: "It draws 7 million visitors a year", Status: ClaimUncertain, Note: "Figures vary"},
+	}
+	claims := ParseVerification(content)
+	if len(claims) != len(want) {
+		t.Fatalf("Expected %d claims, got %+v", len(want), claims)
+	}
+	for i := range want {
+		if claims[i] != want[i] {
+			t.Errorf("Claim %d: expected %+v, got %+v", i, want[i], claims[i])
+		}
+	}
+
+	// Without the heading the whole reply is searched
+	if claims := ParseVerification("# ANSWER\n\n- [UNSUPPORTED] Paris is in Spain | It's in France"); len(claims) != 1 || claims[0].Status != ClaimUnsupported {
+		t.Errorf("Expected one unsupported claim, got %+v", claims)
+	}
+	if claims := ParseVerification("### Verification\n\nNothing to check."); len(claims) != 0 {
+		t.Errorf("Expected no claims, got %+v", claims)
+	}
+}
@@ -0,0 +1,53 @@
+// Package similarity compares free-form text such as questions and answers
+package similarity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalize lowercases text and collapses everything except letters and digits into single spaces
+func Normalize(text string) string {
+	return strings.Join(Words(text), " ")
+}
+
+// Words splits text into lowercase words, dropping punctuation
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// WordSet returns the distinct words of text
+func WordSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range Words(text) {
+		set[w] = true
+	}
+	return set
+}
+
+// Jaccard is the size of the intersection over the size of the union of two word sets
+// Two empty sets are identical
+func Jaccard(a, b map[string]bool) float64 {
+	intersection := 0
+	for w := range a {
+		if b[w] {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	if union == 0 {
+		return 1
+	}
+	return float64(intersection) / float64(union)
+}
+
+// Text scores how similar two texts are in [0, 1]
+// Texts that normalize to the same string score 1, otherwise their word sets are compared
+func Text(a, b string) float64 {
+	if Normalize(a) == Normalize(b) {
+		return 1
+	}
+	return Jaccard(WordSet(a), WordSet(b))
+}
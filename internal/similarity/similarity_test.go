@@ -0,0 +1,31 @@
+package similarity
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	got := Normalize("  What's the  capital of FRANCE?! ")
+	if got != "what s the capital of france" {
+		t.Errorf("Normalize = %q", got)
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{"same after normalization", "What is AI?", "what is ai", 1},
+		{"disjoint", "apples and pears", "rust compiler", 0},
+		{"half overlap", "red green", "green blue red yellow", 0.5},
+		{"both empty", "", "?!", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Text(tt.a, tt.b); got != tt.want {
+				t.Errorf("Text(%q, %q) = %f, want %f", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
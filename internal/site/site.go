@@ -0,0 +1,258 @@
+// Package site renders the answers archive as static HTML: an index of every past question, searchable and
+// grouped by day, plus a page per question, ready to publish to GitHub Pages, S3 or any other static host.
+// The server's /h/ listing is the same pages rendered on request.
+package site
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/jsonexport"
+	"github.com/meedamian/fat/internal/locale"
+)
+
+// PagesDir is the directory of per-question pages, relative to the index
+const PagesDir = "q"
+
+// validID keeps request IDs that can't be used as file names out of the site
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// PagePath is where a request's page lives, relative to the index
+func PagePath(requestID string) string {
+	return PagesDir + "/" + requestID + ".html"
+}
+
+// indexEntry is a request as listed in the index
+type indexEntry struct {
+	Link     string
+	Question string
+	Tag      string
+	Winner   string
+	Time     string
+	Cost     string
+	Search   string // Lowercased text the search box matches against
+}
+
+// indexDay is the requests asked on one day
+type indexDay struct {
+	Date    string
+	Entries []indexEntry
+}
+
+// RenderIndex writes the index of past requests, newest first and grouped by day in f's time zone
+func RenderIndex(w io.Writer, entries []db.HistoryEntry, f locale.Format) error {
+	var days []indexDay
+	tags := make(map[string]bool)
+	for _, e := range entries {
+		if !validID.MatchString(e.ID) {
+			continue
+		}
+		day := f.In(e.CreatedAt).Format(time.DateOnly)
+		if len(days) == 0 || days[len(days)-1].Date != day {
+			days = append(days, indexDay{Date: day})
+		}
+
+		winner := e.WinnerModel
+		if winner == "" {
+			winner = "no winner"
+		}
+		if e.Tag != "" {
+			tags[e.Tag] = true
+		}
+		d := &days[len(days)-1]
+		d.Entries = append(d.Entries, indexEntry{
+			Link:     PagePath(e.ID),
+			Question: e.Question,
+			Tag:      e.Tag,
+			Winner:   winner,
+			Time:     f.In(e.CreatedAt).Format("15:04"),
+			Cost:     f.Cost(e.TotalCost),
+			Search:   strings.ToLower(strings.Join([]string{e.Question, e.Tag, winner}, " ")),
+		})
+	}
+
+	tagList := make([]string, 0, len(tags))
+	for tag := range tags {
+		tagList = append(tagList, tag)
+	}
+	sort.Strings(tagList)
+
+	return indexTemplate.Execute(w, map[string]any{
+		"Total": len(entries),
+		"Days":  days,
+		"Tags":  tagList,
+	})
+}
+
+// pageAnswer is a model's final answer with its aggregated place
+type pageAnswer struct {
+	ModelName string
+	Place     int // 0 if unranked
+	Score     int
+	Answer    string
+	Error     string
+	Cost      string
+}
+
+// RenderPage writes a request's page: the question, the final ranking and every model's final answer
+// exportURL links the full interactive transcript, empty if there is none to link.
+func RenderPage(w io.Writer, doc *jsonexport.Document, exportURL string, f locale.Format) error {
+	places := make(map[string]int, len(doc.FinalRanking))
+	scores := make(map[string]int, len(doc.FinalRanking))
+	for _, p := range doc.FinalRanking {
+		places[p.Model] = p.Place
+		scores[p.Model] = p.Score
+	}
+
+	answers := make([]pageAnswer, 0, len(doc.Models))
+	winner := ""
+	for _, m := range doc.Models {
+		a := pageAnswer{ModelName: m.ModelName, Place: places[m.ModelID], Score: scores[m.ModelID], Cost: f.Cost(m.Cost)}
+		if len(m.Rounds) > 0 {
+			last := m.Rounds[len(m.Rounds)-1]
+			a.Answer, a.Error = last.Answer, last.Error
+		}
+		if a.Place == 0 && m.ModelID == doc.Request.WinnerModel {
+			a.Place = 1
+		}
+		if m.ModelID == doc.Request.WinnerModel {
+			winner = m.ModelName
+		}
+		answers = append(answers, a)
+	}
+	// Best first; unranked answers after every ranked one
+	sort.SliceStable(answers, func(i, j int) bool {
+		pi, pj := answers[i].Place, answers[j].Place
+		if (pi == 0) != (pj == 0) {
+			return pj == 0
+		}
+		return pi < pj
+	})
+
+	parent := ""
+	if validID.MatchString(doc.Request.ParentRequestID) {
+		parent = "../" + PagePath(doc.Request.ParentRequestID)
+	}
+
+	return pageTemplate.Execute(w, map[string]any{
+		"Request": doc.Request,
+		"Date":    f.In(doc.Request.CreatedAt).Format("2006-01-02 15:04 MST"),
+		"Winner":  winner,
+		"Answers": answers,
+		"Cost":    f.Cost(doc.Costs.Total),
+		"Parent":  parent,
+		"Export":  exportURL,
+	})
+}
+
+// Generate writes the site for every past request into dir: index.html and one page per request under PagesDir
+// Returns how many request pages were written.
+func Generate(ctx context.Context, database *db.DB, dir string, f locale.Format) (int, error) {
+	entries, _, err := database.GetHistory(ctx, db.HistoryFilter{})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, PagesDir), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create site directory: %w", err)
+	}
+
+	pages := 0
+	written := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return pages, err
+		}
+		if !validID.MatchString(e.ID) {
+			continue
+		}
+
+		doc, err := jsonexport.Build(ctx, database, e.ID)
+		if err != nil {
+			return pages, fmt.Errorf("request %s: %w", e.ID, err)
+		}
+		var buf bytes.Buffer
+		if err := RenderPage(&buf, doc, "", f); err != nil {
+			return pages, fmt.Errorf("request %s: %w", e.ID, err)
+		}
+		if err := writeFile(filepath.Join(dir, filepath.FromSlash(PagePath(e.ID))), buf.Bytes()); err != nil {
+			return pages, err
+		}
+		written[filepath.Base(PagePath(e.ID))] = true
+		pages++
+	}
+
+	// The index goes last, so it never links to a page that isn't there yet
+	var buf bytes.Buffer
+	if err := RenderIndex(&buf, entries, f); err != nil {
+		return pages, err
+	}
+	if err := writeFile(filepath.Join(dir, "index.html"), buf.Bytes()); err != nil {
+		return pages, err
+	}
+
+	// Drop the pages of requests deleted since the last run
+	stale, err := filepath.Glob(filepath.Join(dir, PagesDir, "*.html"))
+	if err != nil {
+		return pages, err
+	}
+	for _, path := range stale {
+		if !written[filepath.Base(path)] {
+			if err := os.Remove(path); err != nil {
+				return pages, fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+	}
+	return pages, nil
+}
+
+// writeFile replaces path with data, going through a temporary file so readers never see half a page
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Start regenerates the site into dir in the background, once right away and then every interval
+// An empty dir or a non-positive interval disables it.
+func Start(ctx context.Context, logger *slog.Logger, database *db.DB, dir string, interval time.Duration, f locale.Format) {
+	if dir == "" || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			pages, err := Generate(ctx, database, dir, f)
+			if err != nil {
+				logger.Warn("failed to generate the answers site", slog.String("dir", dir), slog.Any("error", err))
+			} else {
+				logger.Debug("answers site generated", slog.String("dir", dir), slog.Int("pages", pages))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
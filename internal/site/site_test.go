@@ -0,0 +1,115 @@
+package site
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+	"github.com/meedamian/fat/internal/locale"
+)
+
+func TestGenerate(t *testing.T) {
+	dbPath := "test_site.db"
+	defer os.Remove(dbPath)
+
+	database, err := db.New(dbPath, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	requests := []db.Request{
+		{ID: "req-1", Question: "Why is the sky <blue>?", NumRounds: 1, NumModels: 2, WinnerModel: "grok", Tag: "physics", FinalRanking: `[{"model":"grok","place":1,"score":4},{"model":"gpt","place":2,"score":2}]`},
+		{ID: "req-2", Question: "And at sunset?", NumRounds: 1, NumModels: 2, ParentRequestID: "req-1"},
+	}
+	for _, req := range requests {
+		if err := database.SaveRequest(ctx, req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+	rounds := []db.ModelRound{
+		{RequestID: "req-1", ModelID: "gpt", ModelName: "gpt-5", Round: 1, Answer: "Scattering."},
+		{RequestID: "req-1", ModelID: "grok", ModelName: "grok-4", Round: 1, Answer: "Rayleigh scattering."},
+	}
+	for _, mr := range rounds {
+		if err := database.SaveModelRound(ctx, mr); err != nil {
+			t.Fatalf("Failed to save model round: %v", err)
+		}
+	}
+
+	dir := t.TempDir()
+	pages, err := Generate(ctx, database, dir, locale.Format{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if pages != 2 {
+		t.Errorf("Expected 2 pages, got %d", pages)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	for _, want := range []string{`href="q/req-1.html"`, `href="q/req-2.html"`, "Why is the sky &lt;blue&gt;?", `data-tag="physics"`, "2 past sessions"} {
+		if !strings.Contains(string(index), want) {
+			t.Errorf("Expected the index to contain %q", want)
+		}
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, PagesDir, "req-1.html"))
+	if err != nil {
+		t.Fatalf("Failed to read page: %v", err)
+	}
+	winner := strings.Index(string(page), "Rayleigh scattering.")
+	runnerUp := strings.Index(string(page), ">Scattering.")
+	if winner < 0 || runnerUp < 0 || winner > runnerUp {
+		t.Errorf("Expected both answers, the winner's first, got:\n%s", page)
+	}
+
+	followUp, err := os.ReadFile(filepath.Join(dir, PagesDir, "req-2.html"))
+	if err != nil {
+		t.Fatalf("Failed to read page: %v", err)
+	}
+	if !strings.Contains(string(followUp), `href="../q/req-1.html"`) {
+		t.Errorf("Expected the follow-up to link its parent, got:\n%s", followUp)
+	}
+
+	if _, err := database.DeleteRequest(ctx, "req-2", false); err != nil {
+		t.Fatalf("Failed to delete request: %v", err)
+	}
+	if pages, err := Generate(ctx, database, dir, locale.Format{}); err != nil || pages != 1 {
+		t.Fatalf("Expected 1 page after deleting a request, got %d (%v)", pages, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, PagesDir, "req-2.html")); !os.IsNotExist(err) {
+		t.Error("Expected a deleted request's page removed")
+	}
+}
+
+func TestRenderIndexLocale(t *testing.T) {
+	f, err := locale.New("America/New_York", "de")
+	if err != nil {
+		t.Fatalf("locale.New failed: %v", err)
+	}
+	entries := []db.HistoryEntry{{Request: db.Request{
+		ID:        "req-1",
+		Question:  "Late question",
+		CreatedAt: time.Date(2025, 3, 2, 2, 30, 0, 0, time.UTC),
+		TotalCost: 1234.5,
+	}}}
+
+	var b strings.Builder
+	if err := RenderIndex(&b, entries, f); err != nil {
+		t.Fatalf("RenderIndex failed: %v", err)
+	}
+	for _, want := range []string{"2025-03-01", "21:30", "$1.234,5000"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected the index to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package site
+
+import "html/template"
+
+// style is shared by the index and the request pages
+const style = `
+        :root { --bg: #0a0a0f; --text: #e4e4e7; --muted: #71717a; --accent: #7c5cff; --gold: #fbbf24; }
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        body { background: var(--bg); color: var(--text); font-family: system-ui, sans-serif; padding: 40px 20px; max-width: 900px; margin: 0 auto; }
+        a { color: var(--accent); }
+        h1 { font-size: 2em; margin-bottom: 8px; }
+        .tagline { color: var(--muted); margin-bottom: 24px; }
+        .search { width: 100%; padding: 12px 16px; margin-bottom: 12px; background: rgba(255,255,255,0.05); border: 1px solid rgba(255,255,255,0.1); border-radius: 8px; color: var(--text); font-size: 1em; }
+        .tags { display: flex; flex-wrap: wrap; gap: 6px; margin-bottom: 32px; }
+        .tags button { background: rgba(255,255,255,0.05); border: 1px solid rgba(255,255,255,0.1); border-radius: 999px; color: var(--muted); padding: 4px 12px; cursor: pointer; }
+        .tags button.active { border-color: var(--accent); color: var(--text); }
+        .date-group { margin-bottom: 32px; }
+        .date-header { color: var(--accent); font-size: 1.1em; font-weight: 600; margin-bottom: 12px; padding-bottom: 8px; border-bottom: 1px solid rgba(255,255,255,0.1); }
+        .file-list { list-style: none; }
+        .file-list li { margin-bottom: 8px; }
+        .file-list a { color: var(--text); text-decoration: none; display: block; padding: 12px 16px; background: rgba(255,255,255,0.03); border-radius: 8px; transition: all 0.2s; }
+        .file-list a:hover { background: rgba(124, 92, 255, 0.15); transform: translateX(4px); }
+        .file-name { font-weight: 500; }
+        .file-meta { color: var(--muted); font-size: 0.85em; margin-top: 4px; }
+        .tag { color: var(--accent); }
+        .empty { color: var(--muted); font-style: italic; }
+        .question { white-space: pre-wrap; font-size: 1.2em; margin: 16px 0 8px; }
+        .answer-card { padding: 16px; margin-bottom: 16px; background: rgba(255,255,255,0.03); border-radius: 8px; border-left: 3px solid rgba(255,255,255,0.1); }
+        .answer-card.winner { border-left-color: var(--gold); }
+        .answer-card h2 { font-size: 1.05em; margin-bottom: 4px; }
+        .answer { white-space: pre-wrap; line-height: 1.5; margin-top: 12px; }
+        .error { color: #f87171; }
+`
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Nexus - Exported Sessions</title>
+    <style>` + style + `    </style>
+</head>
+<body>
+    <h1>📄 Exported Sessions</h1>
+    <p class="tagline">{{.Total}} past sessions of Nexus conversations</p>
+{{- if not .Days}}
+    <p class="empty">No sessions yet. Run some questions and they'll appear here!</p>
+{{- else}}
+    <input class="search" type="search" placeholder="Search questions, tags and winners…" autofocus>
+    {{- if .Tags}}
+    <div class="tags">
+        <button class="active" data-tag="">All</button>
+        {{- range .Tags}}
+        <button data-tag="{{.}}">{{.}}</button>
+        {{- end}}
+    </div>
+    {{- end}}
+{{- end}}
+{{- range .Days}}
+    <div class="date-group">
+        <div class="date-header">📅 {{.Date}}</div>
+        <ul class="file-list">
+        {{- range .Entries}}
+            <li data-search="{{.Search}}" data-tag="{{.Tag}}"><a href="{{.Link}}">
+                <div class="file-name">{{.Question}}</div>
+                <div class="file-meta">{{.Time}}{{if .Tag}} · <span class="tag">#{{.Tag}}</span>{{end}} · 🏆 {{.Winner}} · {{.Cost}}</div>
+            </a></li>
+        {{- end}}
+        </ul>
+    </div>
+{{- end}}
+    <p class="empty" id="no-matches" hidden>No sessions match.</p>
+    <script>
+    (function () {
+        const search = document.querySelector('.search');
+        if (!search) return;
+        let tag = '';
+
+        function filter() {
+            const terms = search.value.toLowerCase().split(/\s+/).filter(Boolean);
+            let shown = 0;
+            document.querySelectorAll('.date-group').forEach(group => {
+                let visible = 0;
+                group.querySelectorAll('li').forEach(li => {
+                    const match = (!tag || li.dataset.tag === tag) && terms.every(t => li.dataset.search.includes(t));
+                    li.hidden = !match;
+                    if (match) visible++;
+                });
+                group.hidden = visible === 0;
+                shown += visible;
+            });
+            document.getElementById('no-matches').hidden = shown > 0;
+        }
+
+        search.addEventListener('input', filter);
+        document.querySelectorAll('.tags button').forEach(button => {
+            button.addEventListener('click', () => {
+                document.querySelectorAll('.tags button').forEach(b => b.classList.remove('active'));
+                button.classList.add('active');
+                tag = button.dataset.tag;
+                filter();
+            });
+        });
+    })();
+    </script>
+</body>
+</html>
+`))
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Request.Question}} - Nexus</title>
+    <style>` + style + `    </style>
+</head>
+<body>
+    <p><a href="../index.html">← All sessions</a></p>
+    <p class="question">{{.Request.Question}}</p>
+    <p class="file-meta">
+        {{.Date}}{{if .Request.Tag}} · <span class="tag">#{{.Request.Tag}}</span>{{end}}
+        · {{.Request.NumModels}} models · {{.Request.NumRounds}} rounds · {{.Cost}}
+        {{- if .Winner}} · 🏆 {{.Winner}}{{end}}
+        {{- if .Parent}} · <a href="{{.Parent}}">follows up on an earlier question</a>{{end}}
+        {{- if .Export}} · <a href="{{.Export}}">full transcript</a>{{end}}
+    </p>
+    <br>
+{{- range .Answers}}
+    <div class="answer-card{{if eq .Place 1}} winner{{end}}">
+        <h2>{{if eq .Place 1}}🥇{{else if eq .Place 2}}🥈{{else if eq .Place 3}}🥉{{end}} {{.ModelName}}</h2>
+        <div class="file-meta">{{if .Place}}#{{.Place}} · {{.Score}} points · {{end}}{{.Cost}}</div>
+        {{- if .Answer}}
+        <div class="answer">{{.Answer}}</div>
+        {{- else if .Error}}
+        <p class="answer error">{{.Error}}</p>
+        {{- end}}
+    </div>
+{{- end}}
+</body>
+</html>
+`))
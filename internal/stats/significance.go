@@ -0,0 +1,144 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// PermutationIterations is how many label shuffles are drawn when testing a difference in means
+const PermutationIterations = 10000
+
+// ChiSquare tests whether the rows of a contingency table come from the same distribution
+// Columns empty in every row are dropped. Returns the statistic, its degrees of freedom and the p-value;
+// a table with fewer than two non-empty rows or columns has nothing to test and returns p = 1.
+// The chi-square approximation is rough when expected counts fall below 5, so treat small samples with care.
+func ChiSquare(table [][]int64) (statistic float64, df int, p float64) {
+	rowTotals := make([]int64, 0, len(table))
+	var rows [][]int64
+	for _, row := range table {
+		var total int64
+		for _, n := range row {
+			total += n
+		}
+		if total > 0 {
+			rows = append(rows, row)
+			rowTotals = append(rowTotals, total)
+		}
+	}
+	if len(rows) < 2 {
+		return 0, 0, 1
+	}
+
+	var colTotals []int64
+	var cols []int
+	for c := range rows[0] {
+		var total int64
+		for _, row := range rows {
+			total += row[c]
+		}
+		if total > 0 {
+			colTotals = append(colTotals, total)
+			cols = append(cols, c)
+		}
+	}
+	if len(cols) < 2 {
+		return 0, 0, 1
+	}
+
+	var grand int64
+	for _, t := range rowTotals {
+		grand += t
+	}
+	for r, row := range rows {
+		for i, c := range cols {
+			expected := float64(rowTotals[r]) * float64(colTotals[i]) / float64(grand)
+			d := float64(row[c]) - expected
+			statistic += d * d / expected
+		}
+	}
+
+	df = (len(rows) - 1) * (len(cols) - 1)
+	return statistic, df, ChiSquareSurvival(statistic, df)
+}
+
+// ChiSquareSurvival is P(X ≥ x) for a chi-square distribution with df degrees of freedom
+func ChiSquareSurvival(x float64, df int) float64 {
+	if df <= 0 {
+		return 1
+	}
+	if x <= 0 {
+		return 1
+	}
+
+	// Closed forms for integer degrees of freedom: a finite series over e^(-x/2),
+	// plus the normal tail for odd df
+	half := x / 2
+	var sum float64
+	if df%2 == 0 {
+		term := 1.0
+		for i := range df / 2 {
+			if i > 0 {
+				term *= half / float64(i)
+			}
+			sum += term
+		}
+		return math.Min(1, math.Exp(-half)*sum)
+	}
+
+	term := math.Sqrt(x)
+	for i := 1; i <= (df-1)/2; i++ {
+		if i > 1 {
+			term *= x / float64(2*i-1)
+		}
+		sum += term
+	}
+	p := math.Erfc(math.Sqrt(half)) + math.Sqrt(2/math.Pi)*math.Exp(-half)*sum
+	return math.Min(1, p)
+}
+
+// Mean is the arithmetic mean of xs, 0 if empty
+func Mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// PermutationTest is the two-sided p-value for the difference in means between a and b
+// Samples are pooled and relabelled at random; the p-value is the share of relabellings whose
+// difference is at least as large as the observed one. Either side empty returns 1.
+func PermutationTest(a, b []float64, iterations int, seed uint64) float64 {
+	if len(a) == 0 || len(b) == 0 || iterations <= 0 {
+		return 1
+	}
+
+	// Tolerance keeps floating point noise from counting a tie as a smaller difference
+	observed := math.Abs(Mean(a)-Mean(b)) - 1e-12
+
+	pooled := append(append(make([]float64, 0, len(a)+len(b)), a...), b...)
+	var total float64
+	for _, x := range pooled {
+		total += x
+	}
+
+	rng := rand.New(rand.NewPCG(seed, seed))
+	extreme := 0
+	for range iterations {
+		rng.Shuffle(len(pooled), func(i, j int) { pooled[i], pooled[j] = pooled[j], pooled[i] })
+		var sumA float64
+		for _, x := range pooled[:len(a)] {
+			sumA += x
+		}
+		diff := sumA/float64(len(a)) - (total-sumA)/float64(len(b))
+		if math.Abs(diff) >= observed {
+			extreme++
+		}
+	}
+
+	// Counting the observed labelling keeps the p-value from ever being 0
+	return float64(extreme+1) / float64(iterations+1)
+}
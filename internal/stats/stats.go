@@ -0,0 +1,237 @@
+// Package stats attaches confidence intervals to leaderboard numbers
+// so orderings built from a handful of runs aren't presented as definitive.
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+// Z95 is the standard normal quantile for a two-sided 95% interval
+const Z95 = 1.959964
+
+// BootstrapIterations is how many resamples are drawn when estimating rank intervals
+const BootstrapIterations = 1000
+
+// Interval is a closed confidence interval
+type Interval struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// Wilson returns the Wilson score interval for wins out of runs
+// It stays within [0, 1] and behaves sensibly for tiny samples, unlike the normal approximation
+func Wilson(wins, runs int64, z float64) Interval {
+	if runs <= 0 {
+		return Interval{Low: 0, High: 1}
+	}
+	return WilsonRate(float64(wins)/float64(runs), float64(runs), z)
+}
+
+// WilsonRate is Wilson for an observed proportion p over a (possibly fractional) sample size n
+// Used for weighted win rates, where n is the effective sample size of the weights
+func WilsonRate(p, n, z float64) Interval {
+	if n <= 0 {
+		return Interval{Low: 0, High: 1}
+	}
+
+	z2 := z * z
+
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := z / (1 + z2/n) * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+
+	return Interval{
+		Low:  math.Max(0, center-margin),
+		High: math.Min(1, center+margin),
+	}
+}
+
+// Judgement is one judge's ordering of answers for a request, best first
+type Judgement struct {
+	RequestID string
+	Ranked    []string
+}
+
+// RankEstimate is a model's leaderboard position with its bootstrap interval
+type RankEstimate struct {
+	Score   float64  `json:"score"` // Mean normalized Borda score in [0, 1]
+	Rank    int      `json:"rank"`  // Position on the full sample, 1 = best
+	RankCI  Interval `json:"rank_ci"`
+	Samples int      `json:"judgements"`
+}
+
+// BootstrapRanks estimates each model's rank by resampling whole requests with replacement
+// Requests are the resampling unit because judgements within a request are correlated
+func BootstrapRanks(judgements []Judgement, iterations int, seed uint64) map[string]RankEstimate {
+	byRequest := make(map[string][]Judgement)
+	var requestIDs []string
+	for _, j := range judgements {
+		if _, ok := byRequest[j.RequestID]; !ok {
+			requestIDs = append(requestIDs, j.RequestID)
+		}
+		byRequest[j.RequestID] = append(byRequest[j.RequestID], j)
+	}
+	sort.Strings(requestIDs)
+
+	estimates := make(map[string]RankEstimate)
+	if len(requestIDs) == 0 {
+		return estimates
+	}
+
+	// Point estimate on the full sample
+	sums, counts := scoreRequests(requestIDs, byRequest)
+	means := meanScores(sums, counts)
+	for model, rank := range ranksOf(means) {
+		estimates[model] = RankEstimate{
+			Score:   means[model],
+			Rank:    rank,
+			Samples: counts[model],
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(seed, seed))
+	sampledRanks := make(map[string][]int)
+	sample := make([]string, len(requestIDs))
+	for range iterations {
+		for i := range sample {
+			sample[i] = requestIDs[rng.IntN(len(requestIDs))]
+		}
+		for model, rank := range ranksOf(meanScores(scoreRequests(sample, byRequest))) {
+			sampledRanks[model] = append(sampledRanks[model], rank)
+		}
+	}
+
+	for model, ranks := range sampledRanks {
+		sort.Ints(ranks)
+		e := estimates[model]
+		e.RankCI = Interval{
+			Low:  float64(percentile(ranks, 0.025)),
+			High: float64(percentile(ranks, 0.975)),
+		}
+		estimates[model] = e
+	}
+
+	return estimates
+}
+
+// scoreRequests sums normalized Borda scores per model over the given requests
+// A model ranked first scores 1, last scores 0, regardless of how many models were ranked
+func scoreRequests(requestIDs []string, byRequest map[string][]Judgement) (map[string]float64, map[string]int) {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, id := range requestIDs {
+		for _, j := range byRequest[id] {
+			if len(j.Ranked) < 2 {
+				continue
+			}
+			last := float64(len(j.Ranked) - 1)
+			for pos, model := range j.Ranked {
+				sums[model] += 1 - float64(pos)/last
+				counts[model]++
+			}
+		}
+	}
+	return sums, counts
+}
+
+func meanScores(sums map[string]float64, counts map[string]int) map[string]float64 {
+	means := make(map[string]float64, len(sums))
+	for model, sum := range sums {
+		means[model] = sum / float64(counts[model])
+	}
+	return means
+}
+
+// ranksOf turns scores into competition ranks (ties share the better position)
+func ranksOf(scores map[string]float64) map[string]int {
+	ranks := make(map[string]int, len(scores))
+	for model, score := range scores {
+		rank := 1
+		for _, other := range scores {
+			if other > score {
+				rank++
+			}
+		}
+		ranks[model] = rank
+	}
+	return ranks
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int, p float64) int {
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	idx = max(0, min(idx, len(sorted)-1))
+	return sorted[idx]
+}
+
+// LeaderboardEntry is a model variant's standing with confidence intervals
+type LeaderboardEntry struct {
+	ModelID   string   `json:"model_id"`
+	ModelName string   `json:"model_name"`
+	Runs      int64    `json:"runs"`
+	Wins      int64    `json:"wins"`
+	WinRate   float64  `json:"win_rate"`
+	WinRateCI Interval `json:"win_rate_ci"`
+
+	// Win rate with each request weighted by its estimated difficulty
+	WeightedWinRate   float64  `json:"weighted_win_rate"`
+	WeightedWinRateCI Interval `json:"weighted_win_rate_ci"`
+
+	RankEstimate
+}
+
+// Leaderboard builds the overall leaderboard from stored requests and rankings
+// Entries are ordered by the lower bound of their difficulty-weighted win-rate interval, so
+// well-sampled models aren't overtaken by lucky newcomers or by wins on easy questions
+func Leaderboard(ctx context.Context, database *db.DB) ([]LeaderboardEntry, error) {
+	rates, err := database.GetAllWinRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rankings, err := database.GetRankings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	judgements := make([]Judgement, 0, len(rankings))
+	for _, r := range rankings {
+		var ranked []string
+		if err := json.Unmarshal([]byte(r.RankedModels), &ranked); err != nil {
+			continue
+		}
+		judgements = append(judgements, Judgement{RequestID: r.RequestID, Ranked: ranked})
+	}
+	estimates := BootstrapRanks(judgements, BootstrapIterations, 1)
+
+	entries := make([]LeaderboardEntry, 0, len(rates))
+	for _, r := range rates {
+		e := LeaderboardEntry{
+			ModelID:      r.ModelID,
+			ModelName:    r.ModelName,
+			Runs:         r.Runs,
+			Wins:         r.Wins,
+			WinRateCI:    Wilson(r.Wins, r.Runs, Z95),
+			RankEstimate: estimates[r.ModelName],
+		}
+		if r.Runs > 0 {
+			e.WinRate = float64(r.Wins) / float64(r.Runs)
+		}
+		if r.WeightedRuns > 0 {
+			e.WeightedWinRate = r.WeightedWins / r.WeightedRuns
+		}
+		e.WeightedWinRateCI = WilsonRate(e.WeightedWinRate, r.EffectiveRuns, Z95)
+		entries = append(entries, e)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].WeightedWinRateCI.Low > entries[j].WeightedWinRateCI.Low
+	})
+
+	return entries, nil
+}
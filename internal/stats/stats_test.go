@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWilson(t *testing.T) {
+	tests := []struct {
+		name     string
+		wins     int64
+		runs     int64
+		wantLow  float64
+		wantHigh float64
+	}{
+		{"half of ten", 5, 10, 0.2366, 0.7634},
+		{"never won", 0, 10, 0, 0.2775},
+		{"always won", 10, 10, 0.7225, 1},
+		{"no runs", 0, 0, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Wilson(tt.wins, tt.runs, Z95)
+			if math.Abs(got.Low-tt.wantLow) > 1e-3 || math.Abs(got.High-tt.wantHigh) > 1e-3 {
+				t.Errorf("Wilson(%d, %d) = [%.4f, %.4f], want [%.4f, %.4f]",
+					tt.wins, tt.runs, got.Low, got.High, tt.wantLow, tt.wantHigh)
+			}
+		})
+	}
+}
+
+func TestWilsonNarrowsWithSamples(t *testing.T) {
+	small := Wilson(3, 5, Z95)
+	large := Wilson(300, 500, Z95)
+
+	if large.High-large.Low >= small.High-small.Low {
+		t.Errorf("Expected larger sample to give a narrower interval: small=%v large=%v", small, large)
+	}
+}
+
+func TestBootstrapRanks(t *testing.T) {
+	// grok is always first, gpt and claude trade places
+	var judgements []Judgement
+	for i, id := range []string{"r1", "r2", "r3", "r4", "r5", "r6"} {
+		ranked := []string{"grok", "gpt", "claude"}
+		if i%2 == 1 {
+			ranked = []string{"grok", "claude", "gpt"}
+		}
+		judgements = append(judgements, Judgement{RequestID: id, Ranked: ranked})
+	}
+
+	estimates := BootstrapRanks(judgements, 200, 1)
+
+	grok := estimates["grok"]
+	if grok.Rank != 1 || grok.RankCI.Low != 1 || grok.RankCI.High != 1 {
+		t.Errorf("Expected grok to be rank 1 with certainty, got %+v", grok)
+	}
+	if grok.Samples != 6 {
+		t.Errorf("Expected 6 judgements for grok, got %d", grok.Samples)
+	}
+
+	gpt := estimates["gpt"]
+	if gpt.RankCI.Low > 2 || gpt.RankCI.High < 3 {
+		t.Errorf("Expected gpt rank interval to span 2-3, got %+v", gpt.RankCI)
+	}
+}
+
+func TestBootstrapRanksEmpty(t *testing.T) {
+	if got := BootstrapRanks(nil, 100, 1); len(got) != 0 {
+		t.Errorf("Expected no estimates, got %v", got)
+	}
+}
+
+func TestChiSquareSurvival(t *testing.T) {
+	// Critical values at p = 0.05
+	critical := map[int]float64{1: 3.841, 2: 5.991, 3: 7.815, 4: 9.488, 5: 11.070}
+	for df, x := range critical {
+		if p := ChiSquareSurvival(x, df); math.Abs(p-0.05) > 1e-3 {
+			t.Errorf("ChiSquareSurvival(%.3f, %d) = %.4f, want 0.05", x, df, p)
+		}
+	}
+	if p := ChiSquareSurvival(0, 3); p != 1 {
+		t.Errorf("Expected p = 1 at 0, got %f", p)
+	}
+}
+
+func TestChiSquare(t *testing.T) {
+	// The empty last column is dropped, leaving one degree of freedom
+	statistic, df, p := ChiSquare([][]int64{{30, 10, 0}, {10, 30, 0}})
+	if df != 1 || math.Abs(statistic-20) > 1e-9 || p > 0.001 {
+		t.Errorf("ChiSquare = (%.2f, %d, %.4f), want (20, 1, < 0.001)", statistic, df, p)
+	}
+
+	if _, _, p := ChiSquare([][]int64{{5, 5}, {5, 5}}); math.Abs(p-1) > 1e-9 {
+		t.Errorf("Expected p = 1 for identical rows, got %f", p)
+	}
+	if _, _, p := ChiSquare([][]int64{{5, 5}, {0, 0}}); p != 1 {
+		t.Errorf("Expected p = 1 with a single non-empty row, got %f", p)
+	}
+}
+
+func TestPermutationTest(t *testing.T) {
+	a := []float64{1, 1.1, 0.9, 1, 1.05, 0.95, 1, 1.02}
+	b := []float64{2, 2.1, 1.9, 2, 2.05, 1.95, 2, 2.02}
+	if p := PermutationTest(a, b, 2000, 1); p > 0.01 {
+		t.Errorf("Expected a clear difference to be significant, got p = %f", p)
+	}
+	if p := PermutationTest(a, a, 2000, 1); p < 0.9 {
+		t.Errorf("Expected identical samples not to differ, got p = %f", p)
+	}
+	if p := PermutationTest(a, nil, 2000, 1); p != 1 {
+		t.Errorf("Expected p = 1 with an empty sample, got %f", p)
+	}
+	if PermutationTest(a, b, 500, 7) != PermutationTest(a, b, 500, 7) {
+		t.Error("Expected the same seed to give the same p-value")
+	}
+}
@@ -0,0 +1,127 @@
+package statshistory
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+)
+
+// Chart dimensions and the plot area inside them
+const (
+	chartWidth   = 960
+	chartHeight  = 420
+	plotLeft     = 80
+	plotRight    = chartWidth - 180 // Leaves room for the legend
+	plotTop      = 50
+	plotBottom   = chartHeight - 50
+	yGridLines   = 4
+	maxDayLabels = 8
+)
+
+var lineColors = []string{"#4f8cff", "#f5c542", "#3ecf8e", "#ff6b6b", "#b18cff", "#ff9f43", "#48dbfb", "#c0c7d1"}
+
+// Chart draws each model's per-day metric as a line over the days the series cover, as SVG
+// Days without requests are left out; a dashed line labeled with the new variant marks where a model's variant changed.
+func Chart(series []Series, metric string) ([]byte, error) {
+	if _, _, err := value(Point{}, metric); err != nil {
+		return nil, err
+	}
+
+	var days []string
+	top := 0.0
+	for _, s := range series {
+		for _, p := range s.Points {
+			if !slices.Contains(days, p.Day) {
+				days = append(days, p.Day)
+			}
+			if v, ok, _ := value(p, metric); ok {
+				top = max(top, v)
+			}
+		}
+	}
+	slices.Sort(days)
+	if metric == WinRate || top == 0 {
+		top = 1
+	} else {
+		top *= 1.1
+	}
+
+	x := func(day string) float64 {
+		if len(days) < 2 {
+			return (plotLeft + plotRight) / 2
+		}
+		return plotLeft + float64(slices.Index(days, day))*(plotRight-plotLeft)/float64(len(days)-1)
+	}
+	y := func(v float64) float64 {
+		return plotBottom - v/top*(plotBottom-plotTop)
+	}
+	label := func(v float64) string {
+		if metric == WinRate {
+			return fmt.Sprintf("%.0f%%", v*100)
+		}
+		return fmt.Sprintf("$%.4f", v)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#0f1117"/>`+"\n", chartWidth, chartHeight)
+	buf.WriteString(`<g font-family="Inter, Helvetica, Arial, sans-serif" font-size="13">` + "\n")
+
+	title := "Win rate per day"
+	if metric == AvgCost {
+		title = "Average cost per request per day"
+	}
+	fmt.Fprintf(&buf, `<text x="%d" y="30" font-size="18" font-weight="700" fill="#f4f5f7">%s</text>`+"\n", plotLeft, title)
+
+	// Horizontal grid with the metric's values
+	for i := 0; i <= yGridLines; i++ {
+		v := top * float64(i) / yGridLines
+		fmt.Fprintf(&buf, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#2a2f3a"/>`+"\n", plotLeft, y(v), plotRight, y(v))
+		fmt.Fprintf(&buf, `<text x="%d" y="%.1f" fill="#8b93a7" text-anchor="end">%s</text>`+"\n", plotLeft-10, y(v)+4, label(v))
+	}
+
+	// Day labels, thinned out to fit
+	step := max(1, (len(days)+maxDayLabels-1)/maxDayLabels)
+	for i, day := range days {
+		if i%step == 0 || i == len(days)-1 {
+			fmt.Fprintf(&buf, `<text x="%.1f" y="%d" fill="#8b93a7" text-anchor="middle">%s</text>`+"\n", x(day), plotBottom+25, day)
+		}
+	}
+
+	if len(days) == 0 {
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" fill="#8b93a7" text-anchor="middle">No snapshots yet</text>`+"\n", (plotLeft+plotRight)/2, (plotTop+plotBottom)/2)
+	}
+
+	for i, s := range series {
+		color := lineColors[i%len(lineColors)]
+
+		// Days without requests are skipped, connecting the days around them
+		var line []string
+		variant := ""
+		for _, p := range s.Points {
+			if v, ok, _ := value(p, metric); ok {
+				line = append(line, fmt.Sprintf("%.1f,%.1f", x(p.Day), y(v)))
+				fmt.Fprintf(&buf, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s %s: %s</title></circle>`+"\n",
+					x(p.Day), y(v), color, html.EscapeString(p.Model), p.Day, label(v))
+			}
+			if variant != "" && p.Model != variant {
+				fmt.Fprintf(&buf, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="%s" stroke-dasharray="4 4"/>`+"\n", x(p.Day), plotTop, x(p.Day), plotBottom, color)
+				fmt.Fprintf(&buf, `<text x="%.1f" y="%d" fill="%s" font-size="11" text-anchor="middle">%s</text>`+"\n", x(p.Day), plotTop-6, color, html.EscapeString(p.Model))
+			}
+			variant = p.Model
+		}
+		if len(line) > 1 {
+			fmt.Fprintf(&buf, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", strings.Join(line, " "), color)
+		}
+
+		// Legend
+		ly := plotTop + i*24
+		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="14" height="14" fill="%s"/>`+"\n", plotRight+20, ly, color)
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" fill="#f4f5f7">%s</text>`+"\n", plotRight+42, ly+12, html.EscapeString(s.ModelID))
+	}
+
+	buf.WriteString("</g>\n</svg>\n")
+	return buf.Bytes(), nil
+}
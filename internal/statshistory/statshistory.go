@@ -0,0 +1,129 @@
+// Package statshistory keeps daily snapshots of every model's aggregate stats and turns them into series
+// showing how win rates and average costs evolved, so the effect of a provider's model update shows up
+// as a change in the curve.
+package statshistory
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+// Metrics a chart can plot
+const (
+	WinRate = "win_rate" // Share of the day's requests the model won
+	AvgCost = "avg_cost" // Mean cost of the model's part in the day's requests, in dollars
+)
+
+// Point is a model's stats on one day
+// The totals are cumulative since the model was first used; the Day fields cover only the requests since the
+// previous snapshot and are nil for the first snapshot and for days without requests.
+type Point struct {
+	Day        string   `json:"day"`   // UTC date, YYYY-MM-DD
+	Model      string   `json:"model"` // Variant the model was last used as
+	Requests   int64    `json:"requests"`
+	Wins       int64    `json:"wins"`
+	WinRate    float64  `json:"win_rate"`
+	AvgCost    float64  `json:"avg_cost"`
+	DayWinRate *float64 `json:"day_win_rate"`
+	DayAvgCost *float64 `json:"day_avg_cost"`
+}
+
+// Series is one model's points, oldest first
+type Series struct {
+	ModelID string  `json:"model_id"`
+	Points  []Point `json:"points"`
+}
+
+// Build groups snapshots into one series per model, ordered by model ID
+func Build(snapshots []db.ModelStatsSnapshot) []Series {
+	byModel := make(map[string][]db.ModelStatsSnapshot)
+	for _, s := range snapshots {
+		byModel[s.ModelID] = append(byModel[s.ModelID], s)
+	}
+
+	series := make([]Series, 0, len(byModel))
+	for modelID, days := range byModel {
+		sort.Slice(days, func(i, j int) bool { return days[i].Day < days[j].Day })
+
+		points := make([]Point, 0, len(days))
+		for i, s := range days {
+			p := Point{Day: s.Day, Model: s.ModelName, Requests: s.TotalRequests, Wins: s.TotalWins}
+			if s.TotalRequests > 0 {
+				p.WinRate = float64(s.TotalWins) / float64(s.TotalRequests)
+				p.AvgCost = s.TotalCost / float64(s.TotalRequests)
+			}
+			// Costs recomputed at other rates can shrink the totals, which says nothing about the day
+			if i > 0 {
+				prev := days[i-1]
+				if requests := s.TotalRequests - prev.TotalRequests; requests > 0 {
+					winRate := float64(s.TotalWins-prev.TotalWins) / float64(requests)
+					avgCost := max(0, s.TotalCost-prev.TotalCost) / float64(requests)
+					p.DayWinRate, p.DayAvgCost = &winRate, &avgCost
+				}
+			}
+			points = append(points, p)
+		}
+		series = append(series, Series{ModelID: modelID, Points: points})
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].ModelID < series[j].ModelID })
+
+	return series
+}
+
+// Load builds the series from the snapshots of the last days days, today included
+func Load(ctx context.Context, database *db.DB, days int) ([]Series, error) {
+	snapshots, err := database.GetModelStatsHistory(ctx, time.Now().AddDate(0, 0, 1-days))
+	if err != nil {
+		return nil, err
+	}
+	return Build(snapshots), nil
+}
+
+// Start snapshots the model stats at startup and every interval until ctx is done
+// Each day keeps its last snapshot, so an interval of an hour or so keeps the day's close to its end.
+func Start(ctx context.Context, logger *slog.Logger, database *db.DB, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := database.SnapshotModelStats(ctx, time.Now()); err != nil {
+				logger.Warn("failed to snapshot model stats", slog.Any("error", err))
+			} else {
+				logger.Debug("model stats snapshotted")
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// value returns the metric's value on a point's day, false on days without requests
+func value(p Point, metric string) (float64, bool, error) {
+	switch metric {
+	case WinRate:
+		if p.DayWinRate == nil {
+			return 0, false, nil
+		}
+		return *p.DayWinRate, true, nil
+	case AvgCost:
+		if p.DayAvgCost == nil {
+			return 0, false, nil
+		}
+		return *p.DayAvgCost, true, nil
+	}
+	return 0, false, fmt.Errorf("unknown metric %q: must be %s or %s", metric, WinRate, AvgCost)
+}
@@ -0,0 +1,64 @@
+package statshistory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meedamian/fat/internal/db"
+)
+
+func snapshots() []db.ModelStatsSnapshot {
+	return []db.ModelStatsSnapshot{
+		{Day: "2025-03-02", ModelID: "grok", ModelName: "grok-4", TotalRequests: 4, TotalWins: 3, TotalCost: 0.4},
+		{Day: "2025-03-01", ModelID: "grok", ModelName: "grok-4", TotalRequests: 2, TotalWins: 2, TotalCost: 0.1},
+		{Day: "2025-03-03", ModelID: "grok", ModelName: "grok-4.1", TotalRequests: 4, TotalWins: 3, TotalCost: 0.4},
+		{Day: "2025-03-04", ModelID: "grok", ModelName: "grok-4.1", TotalRequests: 5, TotalWins: 3, TotalCost: 0.3},
+		{Day: "2025-03-01", ModelID: "gpt", ModelName: "gpt-5", TotalRequests: 1},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	series := Build(snapshots())
+
+	if len(series) != 2 || series[0].ModelID != "gpt" || series[1].ModelID != "grok" {
+		t.Fatalf("Expected a series per model ordered by ID, got %+v", series)
+	}
+	points := series[1].Points
+	if len(points) != 4 || points[0].Day != "2025-03-01" || points[3].Day != "2025-03-04" {
+		t.Fatalf("Expected grok's points oldest first, got %+v", points)
+	}
+
+	if points[0].WinRate != 1 || points[0].DayWinRate != nil {
+		t.Errorf("Expected the first snapshot to have totals but no day values, got %+v", points[0])
+	}
+	if p := points[1]; p.WinRate != 0.75 || p.DayWinRate == nil || *p.DayWinRate != 0.5 || *p.DayAvgCost < 0.1499 || *p.DayAvgCost > 0.1501 {
+		t.Errorf("Expected a day with 1 of 2 requests won at $0.15 each, got %+v", p)
+	}
+	if points[2].DayWinRate != nil {
+		t.Errorf("Expected no day values for a day without requests, got %+v", points[2])
+	}
+	if p := points[3]; p.DayAvgCost == nil || *p.DayAvgCost != 0 {
+		t.Errorf("Expected costs recomputed lower to count as nothing spent, got %+v", p)
+	}
+}
+
+func TestChart(t *testing.T) {
+	if _, err := Chart(nil, "elo"); err == nil {
+		t.Error("Expected an unknown metric to be rejected")
+	}
+
+	svg, err := Chart(Build(snapshots()), WinRate)
+	if err != nil {
+		t.Fatalf("Chart failed: %v", err)
+	}
+	for _, want := range []string{"<svg", "Win rate per day", "<polyline", ">grok-4.1</text>", "2025-03-04", ">gpt</text>"} {
+		if !strings.Contains(string(svg), want) {
+			t.Errorf("Expected the chart to contain %q", want)
+		}
+	}
+
+	empty, err := Chart(nil, AvgCost)
+	if err != nil || !strings.Contains(string(empty), "No snapshots yet") {
+		t.Errorf("Expected an empty chart to say so, got %s, %v", empty, err)
+	}
+}
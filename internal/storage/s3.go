@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meedamian/fat/internal/shared"
+)
+
+// DefaultRegion is used when an S3 bucket's region isn't configured
+const DefaultRegion = "us-east-1"
+
+// S3Config configures an S3-compatible bucket: AWS, Cloudflare R2, MinIO, Backblaze B2 and the like
+type S3Config struct {
+	Bucket    string
+	Endpoint  string // Defaults to AWS's endpoint for Region
+	Region    string
+	Prefix    string // Prepended to every key, e.g. "fat/"
+	AccessKey string
+	SecretKey string
+	PublicURL string // Base of the URLs handed out, e.g. a CDN in front of the bucket; defaults to the bucket's own
+}
+
+// S3 uploads to a bucket with path-style requests signed with AWS Signature Version 4
+type S3 struct {
+	cfg  S3Config
+	base *url.URL // Endpoint with the bucket, path-style
+	http *http.Client
+	now  func() time.Time
+}
+
+// NewS3 creates an S3 store; an empty bucket disables it and returns nil
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Bucket == "" {
+		return nil, nil
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("S3 needs an access key ID and a secret access key")
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+
+	base, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/") + "/" + cfg.Bucket)
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = base.String()
+	}
+
+	return &S3{cfg: cfg, base: base, http: shared.NewHTTPClient(2 * time.Minute), now: time.Now}, nil
+}
+
+// Put uploads data under the configured prefix
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, sha256Hex(data))
+	return s.do(req)
+}
+
+// Delete removes key from under the configured prefix; S3 reports success for missing keys too
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, sha256Hex(nil))
+	return s.do(req)
+}
+
+// objectURL is the path-style address of key in the bucket
+func (s *S3) objectURL(key string) string {
+	objectPath := s.base.Path + "/" + s.cfg.Prefix + key
+	u := *s.base
+	u.Path = objectPath
+	u.RawPath = escapePath(objectPath)
+	return u.String()
+}
+
+// do sends a signed request, turning any status but 200 or 204 into an error
+func (s *S3) do(req *http.Request) error {
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("S3 returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// URL is the public address of key
+func (s *S3) URL(key string) string {
+	return strings.TrimRight(s.cfg.PublicURL, "/") + "/" + escapePath(s.cfg.Prefix+key)
+}
+
+// sign adds AWS Signature Version 4 headers to req, signing every header already set
+func (s *S3) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// escapePath percent-encodes everything in a path but unreserved characters and slashes, as SigV4 expects
+func escapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,67 @@
+// Package storage publishes exports beyond the local h/ directory.
+// Exports are written locally first and then copied to a Store, whose public URL is what clients are given.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Store keeps published files under keys like "h/2025-01-02/1504_slug.html"
+type Store interface {
+	// Put uploads data under key, replacing what was there
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// URL is where key can be fetched once uploaded
+	URL(key string) string
+	// Delete removes key; removing a key that isn't there is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Key turns a local export path into the key it's stored under
+func Key(localPath string) string {
+	return path.Clean(filepath.ToSlash(localPath))
+}
+
+// ContentType guesses a file's MIME type from its extension
+func ContentType(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md":
+		return "text/markdown; charset=utf-8"
+	case ".json":
+		return "application/json; charset=utf-8"
+	}
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// UploadFiles uploads files under dir, keyed by their paths relative to it, optionally removing each once it's stored
+// Returns the keys uploaded before the first failure.
+func UploadFiles(ctx context.Context, store Store, dir string, paths []string, removeLocal bool) ([]string, error) {
+	keys := make([]string, 0, len(paths))
+	for _, rel := range paths {
+		p := filepath.Join(dir, rel)
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return keys, err
+		}
+		key := Key(rel)
+		if err := store.Put(ctx, key, data, ContentType(p)); err != nil {
+			return keys, fmt.Errorf("upload %s: %w", key, err)
+		}
+		keys = append(keys, key)
+
+		if removeLocal {
+			if err := os.Remove(p); err != nil {
+				return keys, err
+			}
+		}
+	}
+	return keys, nil
+}
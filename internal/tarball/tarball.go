@@ -0,0 +1,207 @@
+// Package tarball reads exports back out of gzipped tarballs, so results archived out of h/ stay reachable
+// without extracting them. Entries are named relative to h/, e.g. "2025-01-02/1504_slug.html"; a leading "./"
+// or "h/" is ignored, so both `tar -C h` and `tar` from the working directory produce usable archives.
+package tarball
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned for tarballs and entries that don't exist
+var ErrNotFound = errors.New("not found in archives")
+
+// maxEntrySize caps how much of one entry is read into memory
+const maxEntrySize = 64 << 20
+
+// Entry is a file in a tarball
+type Entry struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// listing is a tarball's entries as of the tarball's size and modification time
+type listing struct {
+	size    int64
+	modTime time.Time
+	entries []Entry
+}
+
+// Archives reads the tarballs in one directory, caching each listing until its tarball changes
+type Archives struct {
+	dir string
+
+	mu       sync.Mutex
+	listings map[string]listing
+}
+
+// New returns the archives in dir, which doesn't have to exist yet
+func New(dir string) *Archives {
+	return &Archives{dir: dir, listings: make(map[string]listing)}
+}
+
+// IsTarball reports whether a file name is a gzipped tarball
+func IsTarball(name string) bool {
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// Tarballs lists the names of the tarballs in the directory, sorted
+func (a *Archives) Tarballs() ([]string, error) {
+	files, err := os.ReadDir(a.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		if f.Type().IsRegular() && IsTarball(f.Name()) {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// List returns a tarball's files in the order they were archived
+func (a *Archives) List(tarball string) ([]Entry, error) {
+	p, err := a.path(tarball)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	cached, ok := a.listings[tarball]
+	a.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.entries, nil
+	}
+
+	var entries []Entry
+	err = walk(p, func(h *tar.Header, _ io.Reader) (bool, error) {
+		entries = append(entries, Entry{Name: entryName(h.Name), Size: h.Size, ModTime: h.ModTime})
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.listings[tarball] = listing{size: info.Size(), modTime: info.ModTime(), entries: entries}
+	a.mu.Unlock()
+	return entries, nil
+}
+
+// Read returns the contents of one file in a tarball
+func (a *Archives) Read(tarball, name string) ([]byte, error) {
+	p, err := a.path(tarball)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	found := false
+	err = walk(p, func(h *tar.Header, r io.Reader) (bool, error) {
+		if entryName(h.Name) != name {
+			return false, nil
+		}
+		if h.Size > maxEntrySize {
+			return true, fmt.Errorf("%s is too large to serve from an archive (%d bytes)", name, h.Size)
+		}
+		found = true
+		var readErr error
+		data, readErr = io.ReadAll(r)
+		return true, readErr
+	})
+	if errors.Is(err, os.ErrNotExist) || (err == nil && !found) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Find returns the tarball holding a file, the newest tarball by name if several do
+func (a *Archives) Find(name string) (string, error) {
+	tarballs, err := a.Tarballs()
+	if err != nil {
+		return "", err
+	}
+	for i := len(tarballs) - 1; i >= 0; i-- {
+		entries, err := a.List(tarballs[i])
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", tarballs[i], err)
+		}
+		for _, e := range entries {
+			if e.Name == name {
+				return tarballs[i], nil
+			}
+		}
+	}
+	return "", ErrNotFound
+}
+
+// path is where a tarball is, refusing names that would leave the directory
+func (a *Archives) path(tarball string) (string, error) {
+	if !IsTarball(tarball) || tarball != filepath.Base(tarball) {
+		return "", ErrNotFound
+	}
+	return filepath.Join(a.dir, tarball), nil
+}
+
+// walk calls fn with every regular file in a gzipped tarball until fn reports it's done
+func walk(p string, fn func(h *tar.Header, r io.Reader) (done bool, err error)) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to decompress %s: %w", filepath.Base(p), err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filepath.Base(p), err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		done, err := fn(h, tr)
+		if done || err != nil {
+			return err
+		}
+	}
+}
+
+// entryName is a tarball entry's path relative to h/
+func entryName(name string) string {
+	name = path.Clean(strings.TrimPrefix(name, "./"))
+	return strings.TrimPrefix(name, "h/")
+}
@@ -0,0 +1,80 @@
+package tarball
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTarball archives files (name -> contents) as dir/name
+func writeTarball(t *testing.T, dir, name string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("Failed to create tarball: %v", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for entry, contents := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: entry, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatalf("Failed to write entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tarball: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip: %v", err)
+	}
+}
+
+func TestArchives(t *testing.T) {
+	dir := t.TempDir()
+	a := New(dir)
+
+	if names, err := a.Tarballs(); err != nil || len(names) != 0 {
+		t.Errorf("Expected no tarballs yet, got %v (%v)", names, err)
+	}
+
+	writeTarball(t, dir, "2025-01.tar.gz", map[string]string{"h/2025-01-02/1504_why.html": "<p>Why</p>"})
+	writeTarball(t, dir, "2025-02.tgz", map[string]string{"./2025-02-03/0900_how.md": "# How"})
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a tarball"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	names, err := a.Tarballs()
+	if err != nil || len(names) != 2 || names[0] != "2025-01.tar.gz" || names[1] != "2025-02.tgz" {
+		t.Fatalf("Expected both tarballs, got %v (%v)", names, err)
+	}
+
+	entries, err := a.List("2025-01.tar.gz")
+	if err != nil || len(entries) != 1 || entries[0].Name != "2025-01-02/1504_why.html" {
+		t.Fatalf("Expected the entry named relative to h/, got %+v (%v)", entries, err)
+	}
+
+	data, err := a.Read("2025-02.tgz", "2025-02-03/0900_how.md")
+	if err != nil || string(data) != "# How" {
+		t.Errorf("Expected the entry's contents, got %q (%v)", data, err)
+	}
+	if _, err := a.Read("2025-02.tgz", "2025-02-03/missing.md"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing entry, got %v", err)
+	}
+	if _, err := a.List("../2025-01.tar.gz"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a path outside the directory, got %v", err)
+	}
+
+	if tarball, err := a.Find("2025-01-02/1504_why.html"); err != nil || tarball != "2025-01.tar.gz" {
+		t.Errorf("Expected the tarball holding the file, got %q (%v)", tarball, err)
+	}
+	if _, err := a.Find("2025-03-01/0000_none.html"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unarchived file, got %v", err)
+	}
+}
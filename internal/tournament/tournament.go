@@ -0,0 +1,108 @@
+// Package tournament pits model variants against each other in a single-elimination bracket.
+// Every match is one answer against another, decided by a fixed jury; the winner moves on
+// until one variant is left, so a handful of variants can be compared in a few head-to-heads.
+package tournament
+
+// Match is one head-to-head pairing of a bracket
+type Match struct {
+	Round  int    // 1-based
+	Slot   int    // 0-based position in the round, top to bottom
+	A      string // Variant with the better seed in round 1; the winner of the slot above it later on
+	B      string // Its opponent, empty for a bye
+	SeedA  int    // 1-based seed of A, 0 if there is no A
+	SeedB  int    // 1-based seed of B, 0 if there is no B
+	Winner string // Empty until the match is decided, and for a match neither side could play
+}
+
+// Bye reports whether a side of m has no opponent, so it advances without a match
+func (m Match) Bye() bool {
+	return m.A == "" || m.B == ""
+}
+
+// Rounds returns how many rounds a bracket of n entrants takes to find a winner
+func Rounds(n int) int {
+	rounds := 0
+	for size := 1; size < n; size *= 2 {
+		rounds++
+	}
+	return rounds
+}
+
+// Seed pairs entrants, best seed first, for round 1
+// The bracket is filled up to a power of two in standard order, so the top seeds can only meet in
+// the late rounds; the missing entrants are byes, which go to the top seeds.
+func Seed(entrants []string) []Match {
+	size := 1 << Rounds(len(entrants))
+	if size < 2 {
+		size = 2
+	}
+
+	// 1v8, 4v5, 2v7, 3v6 for 8: each seed is paired with the one the bracket size mirrors it to
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, 2*len(order))
+		for _, seed := range order {
+			next = append(next, seed, 2*len(order)+1-seed)
+		}
+		order = next
+	}
+
+	entrant := func(seed int) (string, int) {
+		if seed > len(entrants) {
+			return "", 0
+		}
+		return entrants[seed-1], seed
+	}
+	matches := make([]Match, 0, size/2)
+	for i := 0; i < size; i += 2 {
+		m := Match{Round: 1, Slot: i / 2}
+		m.A, m.SeedA = entrant(order[i])
+		m.B, m.SeedB = entrant(order[i+1])
+		if m.A == "" {
+			m.A, m.SeedA, m.B, m.SeedB = m.B, m.SeedB, "", 0
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// Next pairs the winners of a decided round, slot by slot, and returns nil after the final
+func Next(round []Match) []Match {
+	if len(round) < 2 {
+		return nil
+	}
+	seeds := make(map[string]int, 2*len(round))
+	for _, m := range round {
+		seeds[m.A], seeds[m.B] = m.SeedA, m.SeedB
+	}
+
+	matches := make([]Match, 0, len(round)/2)
+	for i := 0; i+1 < len(round); i += 2 {
+		m := Match{Round: round[i].Round + 1, Slot: i / 2, A: round[i].Winner, B: round[i+1].Winner}
+		if m.A == "" {
+			m.A, m.B = m.B, ""
+		}
+		m.SeedA, m.SeedB = seeds[m.A], seeds[m.B]
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// Decide returns the winner of m from the jury's votes for each side
+// A tie goes to the better seed, as the bracket already expected it to win.
+func Decide(m Match, votesA, votesB int) string {
+	switch {
+	case m.B == "":
+		return m.A
+	case m.A == "":
+		return m.B
+	case votesA > votesB:
+		return m.A
+	case votesB > votesA:
+		return m.B
+	case m.SeedB != 0 && m.SeedB < m.SeedA:
+		return m.B
+	default:
+		return m.A
+	}
+}
@@ -0,0 +1,54 @@
+package tournament
+
+import "testing"
+
+func TestRounds(t *testing.T) {
+	for n, want := range map[int]int{1: 0, 2: 1, 3: 2, 4: 2, 5: 3, 8: 3, 9: 4} {
+		if got := Rounds(n); got != want {
+			t.Errorf("Rounds(%d) = %d, expected %d", n, got, want)
+		}
+	}
+}
+
+func TestSeed(t *testing.T) {
+	matches := Seed([]string{"s1", "s2", "s3", "s4", "s5", "s6", "s7", "s8"})
+	want := [][2]string{{"s1", "s8"}, {"s4", "s5"}, {"s2", "s7"}, {"s3", "s6"}}
+	if len(matches) != len(want) {
+		t.Fatalf("Expected %d matches, got %+v", len(want), matches)
+	}
+	for i, m := range matches {
+		if m.A != want[i][0] || m.B != want[i][1] || m.Round != 1 || m.Slot != i {
+			t.Errorf("Match %d: expected %v, got %+v", i, want[i], m)
+		}
+	}
+
+	// Top seeds get the byes
+	matches = Seed([]string{"s1", "s2", "s3"})
+	if len(matches) != 2 || !matches[0].Bye() || matches[0].A != "s1" || matches[1].A != "s2" || matches[1].B != "s3" || matches[1].SeedB != 3 {
+		t.Errorf("Expected s1 to get a bye and s2 to face s3, got %+v", matches)
+	}
+}
+
+func TestNextAndDecide(t *testing.T) {
+	round := Seed([]string{"s1", "s2", "s3", "s4"}) // s1-s4, s2-s3
+	round[0].Winner = Decide(round[0], 1, 2)        // s4 upsets s1
+	round[1].Winner = Decide(round[1], 1, 1)        // tie goes to the better seed
+
+	final := Next(round)
+	if len(final) != 1 || final[0].A != "s4" || final[0].B != "s2" || final[0].SeedA != 4 || final[0].SeedB != 2 || final[0].Round != 2 {
+		t.Fatalf("Expected s4 to face s2 in the final, got %+v", final)
+	}
+	if winner := Decide(final[0], 2, 2); winner != "s2" {
+		t.Errorf("Expected the tie to go to the better seed s2, got %q", winner)
+	}
+	final[0].Winner = "s2"
+	if Next(final) != nil {
+		t.Error("Expected no round after the final")
+	}
+
+	// A match neither side could play leaves its opponent a bye
+	round[1].Winner = ""
+	if next := Next(round); !next[0].Bye() || Decide(next[0], 0, 0) != "s4" {
+		t.Errorf("Expected s4 to advance on a bye, got %+v", next)
+	}
+}
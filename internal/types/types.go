@@ -2,7 +2,9 @@ package types
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
+	"net/http"
 	"time"
 )
 
@@ -13,11 +15,62 @@ type Rate struct {
 	Out float64 `json:"out"` // output cost per token
 }
 
+// Pricing replaces list prices for a run, e.g. with negotiated or discounted rates
+type Pricing struct {
+	Multiplier float64         `json:"multiplier,omitempty"` // Scales list rates (0.8 for 20% off); 0 keeps them
+	Rates      map[string]Rate `json:"rates,omitempty"`      // Variant -> rate charged instead of its list rate, not scaled
+}
+
+// Apply returns the rate charged for variant, given its list rate; a nil Pricing charges list rates
+func (p *Pricing) Apply(variant string, list Rate) Rate {
+	if p == nil {
+		return list
+	}
+	if rate, ok := p.Rates[variant]; ok {
+		return rate
+	}
+	if p.Multiplier > 0 {
+		list.In *= p.Multiplier
+		list.Out *= p.Multiplier
+	}
+	return list
+}
+
+// Generation holds sampling parameters for model calls; unset fields keep the provider's defaults
+type Generation struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"top_p,omitempty"`
+	MaxTokens       int64    `json:"max_tokens,omitempty"`       // Output token cap, up to the variant's own
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"` // minimal, low, medium or high, for reasoning models
+}
+
+// Merge returns g with the fields set in over replacing its own
+func (g Generation) Merge(over Generation) Generation {
+	if over.Temperature != nil {
+		g.Temperature = over.Temperature
+	}
+	if over.TopP != nil {
+		g.TopP = over.TopP
+	}
+	if over.MaxTokens > 0 {
+		g.MaxTokens = over.MaxTokens
+	}
+	if over.ReasoningEffort != "" {
+		g.ReasoningEffort = over.ReasoningEffort
+	}
+	return g
+}
+
 // ModelVariant contains properties specific to a model variant
 // The variant name (API model name like "grok-4-fast") is the map key
 type ModelVariant struct {
-	MaxTok int64 // Max tokens for this variant
-	Rate   Rate  // Pricing for this variant
+	MaxTok     int64          // Max tokens for this variant
+	MaxOut     int64          // Max output tokens per reply, 0 if the provider doesn't document one
+	Rate       Rate           // Pricing for this variant
+	Extra      map[string]any // Provider-specific request body fields, e.g. {"reasoning_effort": "high"}
+	Thinking   bool           // Supports extended thinking with a token budget
+	Responses  bool           // Served through OpenAI's Responses API instead of Chat Completions
+	Generation Generation     // Default sampling parameters, under the configured ones
 }
 
 // ModelFamily contains common properties for a model family
@@ -30,14 +83,33 @@ type ModelFamily struct {
 
 // ModelInfo contains model configuration (runtime instance)
 type ModelInfo struct {
-	ID             string
+	ID             string // Agent ID, unique in a run: the family ID, or the variant name when the run has several of the family
+	Family         string // Family ID when it differs from ID; see FamilyID
 	Name           string
 	MaxTok         int64
+	MaxOut         int64 // Output token cap of the variant (and FAT_MAX_OUTPUT_TOKENS), 0 for none
 	BaseURL        string
 	APIKey         string
 	Client         any
 	Logger         *slog.Logger
 	RequestTimeout time.Duration
+	Persona        string         // Optional role injected as a system message
+	Headers        http.Header    // Extra headers sent with every provider request (e.g. for a gateway)
+	Extra          map[string]any // Provider-specific request body fields, merged into every request
+	Safety         string         // Gemini safety threshold (off, none, high, medium or low); empty keeps Google's defaults
+	ThinkingBudget int64          // Extended thinking tokens per call, part of MaxOut; 0 disables thinking
+	Responses      bool           // Call OpenAI's Responses API instead of Chat Completions
+	Structured     bool           // Ask for JSON replies (falling back to markdown parsing) instead of markdown sections
+	Pricing        *Pricing       // Run-specific rates used for cost; nil charges list rates
+	Generation     Generation     // Sampling parameters sent with every call
+}
+
+// FamilyID returns the ID of the family mi belongs to, whose provider, keys and rate limits its calls go through
+func (mi *ModelInfo) FamilyID() string {
+	if mi.Family != "" {
+		return mi.Family
+	}
+	return mi.ID
 }
 
 // DiscussionMessage represents a single message in a conversation thread
@@ -47,6 +119,19 @@ type DiscussionMessage struct {
 	Round   int
 }
 
+// Claim is a factual claim made in an answer, with a verifier's verdict on it
+type Claim struct {
+	Text   string `json:"text"`
+	Status string `json:"status"`         // supported, unsupported or uncertain
+	Note   string `json:"note,omitempty"` // Why the verifier doubts it, or what supports it
+}
+
+// Provenance is a paragraph of a synthesized answer with the answers it was drawn from
+type Provenance struct {
+	Paragraph string   `json:"paragraph"`
+	Sources   []string `json:"sources"` // Model variants whose answers the paragraph draws on; empty if the synthesizer named none
+}
+
 // Reply represents a model's response
 type Reply struct {
 	Answer       string
@@ -54,24 +139,61 @@ type Reply struct {
 	Discussion   map[string]string // Agent -> Message to be added to discussion
 	PrivateNotes string            // Private notes (never shared with other agents)
 	RawContent   string            // For logging/debugging
+	Transforms   []string          // Post-processing steps that changed this reply
+	Searches     []string          // Web search queries requested for the next round
+	SearchResult string            // Results of Searches, shown only to this model in the next round
+	Thinking     string            // Extended thinking behind the reply (never shared with other agents)
+	Schema       *SchemaCheck      // Validation against the question's answer schema, nil when it has none
+}
+
+// SchemaCheck is the outcome of validating an answer against the question's answer schema
+type SchemaCheck struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// PromptRequest is everything a model is prompted with in one call
+type PromptRequest struct {
+	Question   string
+	Meta       Meta
+	Replies    map[string]Reply                          // Agent -> its reply in the previous round
+	Discussion map[string]map[string][]DiscussionMessage // Agent -> other agent -> messages between them
+	Notes      map[int]string                            // Round -> private notes the model left itself
+	Overrides  Generation                                // Sampling parameters for this call only, laid over the model's own
 }
 
-// ModelResult holds the result of a model prompt
-type ModelResult struct {
-	Reply  Reply
-	TokIn  int64
-	TokOut int64
-	Prompt string // For logging
+// PromptResponse holds the result of a model prompt
+type PromptResponse struct {
+	Reply        Reply
+	TokIn        int64
+	TokOut       int64
+	Prompt       string          // For logging
+	FinishReason string          // Why the provider stopped generating, in its own words (e.g. "stop", "max_tokens")
+	ModelVersion string          // Exact model the provider answered with, which may be more specific than the variant asked for
+	Raw          json.RawMessage // Provider's response body, for debugging
 }
 
 // Meta contains metadata for prompt generation
 type Meta struct {
-	Round       int
-	TotalRounds int
-	OtherAgents []string // Agent count = len(OtherAgents) + 1
+	Round        int
+	TotalRounds  int
+	OtherAgents  []string          // Agent count = len(OtherAgents) + 1
+	MaxTok       int64             // Context window of the prompted model; 0 disables prompt trimming
+	Search       bool              // Web search is available, so the model may ask for searches
+	Structured   bool              // The reply is requested as a JSON object instead of markdown sections
+	AnswerSchema map[string]any    // JSON schema the answer must follow, nil for free text; implies Structured
+	Session      []Turn            // Earlier questions of a follow-up's session, oldest first
+	Summary      string            // Summary of rounds before the previous one, standing in for their discussion and notes
+	Positions    map[string]string // Agent name -> side it argues this round in a debate, the model's own included; nil outside debates
+}
+
+// Turn is an earlier question of a session and the answer it settled on
+type Turn struct {
+	Question string
+	Answer   string // Final answer of the winning model
 }
 
 // Model interface for all AI providers
 type Model interface {
-	Prompt(ctx context.Context, question string, meta Meta, replies map[string]Reply, discussion map[string]map[string][]DiscussionMessage, privateNotes map[int]string) (ModelResult, error)
+	Prompt(ctx context.Context, req PromptRequest) (PromptResponse, error)
 }
@@ -4,13 +4,20 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"time"
 )
 
-const answersDir = "answers"
+// answersDir is where conversation logs are written, under the data directory
+var answersDir = "answers"
 
 var startTS int64
 
+// SetDataDir moves the conversation logs to dir/answers
+func SetDataDir(dir string) {
+	answersDir = filepath.Join(dir, "answers")
+}
+
 func SetStartTS(ts int64) {
 	startTS = ts
 }
@@ -87,3 +94,20 @@ func LogCancellation(questionTS int64) error {
 
 	return nil
 }
+
+// RemoveLogs deletes every conversation log of a question, wherever the archiver has moved them
+func RemoveLogs(questionTS int64) error {
+	name := fmt.Sprintf("%d", questionTS)
+	dirs, err := filepath.Glob(filepath.Join(answersDir, "archive", "*", name))
+	if err != nil {
+		return err
+	}
+	dirs = append(dirs, filepath.Join(answersDir, name), filepath.Join(answersDir, "recent", name))
+
+	for _, dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove logs %s: %w", dir, err)
+		}
+	}
+	return nil
+}
@@ -125,3 +125,43 @@ func TestSetStartTS(t *testing.T) {
 		t.Errorf("Expected startTS %d, got %d", testTS, startTS)
 	}
 }
+
+func TestSetDataDir(t *testing.T) {
+	defer SetDataDir(".")
+
+	SetDataDir("/data")
+	if answersDir != filepath.Join("/data", "answers") {
+		t.Errorf("Expected the logs under /data, got %s", answersDir)
+	}
+}
+
+func TestRemoveLogs(t *testing.T) {
+	origWd, _ := os.Getwd()
+	testDir := t.TempDir()
+	os.Chdir(testDir)
+	defer os.Chdir(origWd)
+
+	dirs := []string{
+		filepath.Join(answersDir, "1735905600"),
+		filepath.Join(answersDir, "recent", "1735905600"),
+		filepath.Join(answersDir, "archive", "2025-01", "1735905600"),
+		filepath.Join(answersDir, "1735905601"),
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	if err := RemoveLogs(1735905600); err != nil {
+		t.Fatalf("RemoveLogs failed: %v", err)
+	}
+	for _, dir := range dirs[:3] {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("Expected %s removed", dir)
+		}
+	}
+	if _, err := os.Stat(dirs[3]); err != nil {
+		t.Errorf("Expected another question's logs kept, got %v", err)
+	}
+}
@@ -11,6 +11,10 @@ const galleryStage = document.getElementById('galleryStage');
 const modelOrder = ['grok', 'gpt', 'gemini', 'claude', 'deepseek', 'mistral'];
 let heroLayoutEnabled = false;
 let currentHeroId = null;
+// Request the next question can follow up on, set once a run finishes
+let lastRequestId = null;
+const followUpToggle = document.getElementById('followUpToggle');
+const followUpCheck = document.getElementById('followUpCheck');
 
 const cardElements = {
     grok: document.getElementById('grok'),
@@ -39,6 +43,12 @@ const costIndicators = {
     mistral: document.querySelector('.model-cost[data-model="mistral"]')
 };
 
+// How long each model has been answering the current round, from heartbeat messages
+const elapsedIndicators = {};
+Object.keys(cardElements).forEach(model => {
+    elapsedIndicators[model] = document.querySelector(`.model-elapsed[data-model="${model}"]`);
+});
+
 // Track cumulative costs per model for current request
 const modelCosts = {
     grok: 0,
@@ -56,6 +66,28 @@ function setCardStatus(model, icon = '') {
     indicator.classList.toggle('visible', Boolean(icon));
 }
 
+// Shows how long a model has been answering, or clears it when call is empty
+function setElapsed(model, call) {
+    const indicator = elapsedIndicators[model];
+    if (!indicator) return;
+    if (!call) {
+        indicator.textContent = '';
+        indicator.title = '';
+        indicator.classList.remove('visible', 'stalled');
+        return;
+    }
+    const seconds = Math.round(call.elapsed_ms / 1000);
+    indicator.textContent = call.queued ? `queued ${seconds}s` : `${seconds}s`;
+    indicator.title = call.queued
+        ? 'Waiting for the provider\'s rate limit'
+        : call.p95_ms ? `Usually done within ${Math.round(call.p95_ms / 1000)}s` : '';
+    if (call.stalled) {
+        indicator.title = `Over twice as slow as usual (${Math.round(call.p95_ms / 1000)}s) - the provider may be stuck; consider stopping the run`;
+    }
+    indicator.classList.add('visible');
+    indicator.classList.toggle('stalled', Boolean(call.stalled));
+}
+
 function formatCost(cost) {
     // Always show cost in cents with ¢ symbol
     const cents = cost * 100;
@@ -73,6 +105,56 @@ function updateCostIndicator(model, additionalCost) {
     }
 }
 
+// Replace the running costs with the run's final breakdown, which adds what each model spent ranking
+function showCostBreakdown(breakdown) {
+    if (!breakdown) return;
+    (breakdown.models || []).forEach(entry => {
+        const model = Object.keys(selectors).find(familyID => selectors[familyID]?.value === entry.model);
+        const indicator = costIndicators[model];
+        if (!indicator) return; // A judge that didn't answer
+        modelCosts[model] = entry.total;
+        indicator.textContent = formatCost(entry.total);
+        indicator.title = `Rounds ${formatCost(entry.rounds)} · ranking ${formatCost(entry.ranking)}`;
+        indicator.classList.add('visible');
+    });
+    updateCostColors();
+    submitBtn.title = `Total ${formatCost(breakdown.total)}: rounds ${formatCost(breakdown.rounds)}, ranking ${formatCost(breakdown.ranking)}`;
+}
+
+// List every judge's reason for every placement of an audited run
+function showAudit(justifications) {
+    const section = document.getElementById('auditSection');
+    const container = document.getElementById('auditContainer');
+    if (!section || !container) return;
+    container.innerHTML = '';
+    if (!justifications || justifications.length === 0) {
+        section.classList.add('hidden');
+        return;
+    }
+
+    const byJudge = {};
+    justifications.forEach(j => (byJudge[j.judge] = byJudge[j.judge] || []).push(j));
+    Object.keys(byJudge).sort().forEach(judge => {
+        const block = document.createElement('div');
+        block.className = 'audit-judge';
+        const heading = document.createElement('h3');
+        heading.textContent = judge;
+        const list = document.createElement('ol');
+        byJudge[judge].forEach(j => {
+            const item = document.createElement('li');
+            item.value = j.place;
+            const model = document.createElement('span');
+            model.className = 'audit-model';
+            model.textContent = j.model;
+            item.append(model, ' ' + j.reason);
+            list.appendChild(item);
+        });
+        block.append(heading, list);
+        container.appendChild(block);
+    });
+    section.classList.remove('hidden');
+}
+
 function updateCostColors() {
     // Get all non-zero costs
     const costs = Object.values(modelCosts).filter(c => c > 0);
@@ -121,11 +203,13 @@ function resetCosts() {
         const indicator = costIndicators[model];
         if (indicator) {
             indicator.textContent = '';
+            indicator.title = '';
             indicator.classList.remove('visible');
             indicator.style.backgroundColor = '';
             indicator.style.color = '';
         }
     }
+    submitBtn.title = '';
 }
 
 const outputs = {
@@ -137,6 +221,9 @@ const outputs = {
     mistral: document.getElementById('mistral-output')
 };
 
+// Selector value of a family left out of the run, sent as null
+const SKIP_FAMILY = 'skip';
+
 const selectors = {
     grok: document.getElementById('grok-selector'),
     gpt: document.getElementById('gpt-selector'),
@@ -176,6 +263,9 @@ function createEmptyModelState() {
         rationales: [],
         discussions: [],
         privateNotes: [],
+        diffs: [],
+        searches: [],
+        thinking: [],
         dots: [],
         displayedRound: null,
         currentRound: 0
@@ -190,6 +280,7 @@ function resetModelState(model, totalRounds) {
         state.rationales = new Array(totalRounds).fill(null);
         state.discussions = new Array(totalRounds).fill(null);
         state.privateNotes = new Array(totalRounds).fill(null);
+        state.diffs = new Array(totalRounds).fill(null);
         state.displayedRound = null;
         renderRoundDots(model);
         setCardStatus(model, '');
@@ -231,13 +322,16 @@ function renderRoundDots(model) {
     }
 }
 
-function markRoundCompleted(model, round, responseText, rationaleText, discussionData, privateNotesText) {
+function markRoundCompleted(model, round, responseText, rationaleText, discussionData, privateNotesText, diffSegments, searchQueries, thinkingText) {
     const state = modelState[model];
     if (!state) return;
     state.responses[round - 1] = responseText;
     state.rationales[round - 1] = rationaleText || '';
     state.discussions[round - 1] = discussionData || {};
     state.privateNotes[round - 1] = privateNotesText || '';
+    state.diffs[round - 1] = diffSegments || null;
+    state.searches[round - 1] = searchQueries || [];
+    state.thinking[round - 1] = thinkingText || '';
     const dot = state.dots[round - 1];
     if (dot) {
         dot.classList.add('filled');
@@ -269,6 +363,9 @@ function showRoundResponse(model, round) {
     const response = state.responses[round - 1];
     const rationale = state.rationales[round - 1];
     const privateNotes = state.privateNotes[round - 1];
+    const diffSegments = state.diffs[round - 1];
+    const searches = state.searches[round - 1];
+    const thinking = state.thinking[round - 1];
 
     const output = outputs[model];
     output.className = 'model-output';
@@ -304,6 +401,37 @@ function showRoundResponse(model, round) {
         output.appendChild(rationaleDiv);
     }
 
+    // Show what changed since the previous answer (collapsible)
+    if (diffSegments && diffSegments.some(s => s.op !== '=')) {
+        output.appendChild(buildDiffDetails(round, diffSegments));
+    }
+
+    // Show the web searches requested for the next round
+    if (searches && searches.length > 0) {
+        const searchDiv = document.createElement('div');
+        searchDiv.className = 'search-queries';
+        searchDiv.textContent = '🔍 Searched: ' + searches.join(' · ');
+        output.appendChild(searchDiv);
+    }
+
+    // Show extended thinking if present (collapsible)
+    if (thinking) {
+        const thinkingContainer = document.createElement('details');
+        thinkingContainer.className = 'private-notes-container';
+
+        const thinkingSummary = document.createElement('summary');
+        thinkingSummary.className = 'private-notes-summary';
+        thinkingSummary.textContent = '💭 Thinking';
+        thinkingContainer.appendChild(thinkingSummary);
+
+        const thinkingDiv = document.createElement('div');
+        thinkingDiv.className = 'private-notes-text';
+        thinkingDiv.textContent = thinking;
+        thinkingContainer.appendChild(thinkingDiv);
+
+        output.appendChild(thinkingContainer);
+    }
+
     // Show private notes if present (collapsible)
     if (privateNotes) {
         const notesContainer = document.createElement('details');
@@ -323,6 +451,40 @@ function showRoundResponse(model, round) {
     }
 }
 
+function buildDiffDetails(round, segments) {
+    const container = document.createElement('details');
+    container.className = 'answer-diff-container';
+
+    const summary = document.createElement('summary');
+    summary.className = 'answer-diff-summary';
+    container.appendChild(summary);
+
+    const body = document.createElement('div');
+    body.className = 'answer-diff-text';
+    let added = 0;
+    let removed = 0;
+    segments.forEach(segment => {
+        const words = (segment.text.match(/[\p{L}\p{N}_']+/gu) || []).length;
+        let node;
+        if (segment.op === '+') {
+            added += words;
+            node = document.createElement('ins');
+            node.textContent = segment.text;
+        } else if (segment.op === '-') {
+            removed += words;
+            node = document.createElement('del');
+            node.textContent = segment.text;
+        } else {
+            node = document.createTextNode(segment.text);
+        }
+        body.appendChild(node);
+    });
+    summary.textContent = `Changes in round ${round} (+${added} / −${removed} words)`;
+    container.appendChild(body);
+
+    return container;
+}
+
 function showLatestResponse(model) {
     const state = modelState[model];
     if (!state) return;
@@ -348,11 +510,28 @@ function updateConnectionStatus(status) {
     connectionStatus.className = 'connection-status ' + status;
 }
 
+// Access token for servers that gate asking questions, kept for this browser
+const tokenStorageKey = 'fatToken';
+
+function askForToken(reason) {
+    Object.values(cardElements).forEach(card => card.classList.remove('loading'));
+    submitBtn.disabled = false;
+    submitBtn.textContent = 'Launch Discussion';
+    setSelectorsEnabled(true);
+
+    const token = window.prompt(`${reason}\n\nEnter the server's token or your access key:`);
+    if (!token) return;
+    localStorage.setItem(tokenStorageKey, token.trim());
+    ws.close(); // Reconnects with the token
+}
+
 function initWebSocket() {
     updateConnectionStatus('connecting');
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
     const host = window.location.host;
-    ws = new WebSocket(`${protocol}//${host}/ws`);
+    const token = localStorage.getItem(tokenStorageKey);
+    const query = token ? `?token=${encodeURIComponent(token)}` : '';
+    ws = new WebSocket(`${protocol}//${host}/ws${query}`);
 
     ws.onopen = function (event) {
         console.log('WebSocket connected');
@@ -361,6 +540,13 @@ function initWebSocket() {
 
     ws.onmessage = function (event) {
         const data = JSON.parse(event.data);
+        // Agents named after their variant show on the card picking it
+        if (data.model) data.model = cardOf(data.model);
+        if (data.runner_up) data.runner_up = cardOf(data.runner_up);
+        ['gold', 'silver', 'bronze'].forEach(medal => {
+            if (Array.isArray(data[medal])) data[medal] = data[medal].map(cardOf);
+        });
+        (data.pending || []).forEach(call => { call.model = cardOf(call.model); });
         if (data.type === 'clear') {
             const total = parseInt(roundsSelect.value, 10) || 1;
             resetModelStates(total);
@@ -375,14 +561,17 @@ function initWebSocket() {
                 medals.forEach(m => m.remove());
                 setCardStatus(model, '');
             });
+            Object.keys(elapsedIndicators).forEach(model => setElapsed(model, null));
             conversationBoard.classList.remove('hidden');
             document.getElementById('discussionsSection')?.classList.add('hidden');
+            document.getElementById('auditSection')?.classList.add('hidden');
             activeDiscussionFilter = null;
             submitBtn.textContent = 'Starting...';
             resetHeroLayout();
         } else if (data.type === 'round_start') {
             submitBtn.textContent = `Round ${data.round}/${data.total}`;
             Object.values(cardElements).forEach(card => card.classList.add('loading'));
+            Object.keys(elapsedIndicators).forEach(model => setElapsed(model, null));
             ensureRounds(data.total);
             Object.keys(modelState).forEach(model => highlightCurrentRound(model, data.round));
         } else if (data.type === 'response') {
@@ -390,7 +579,8 @@ function initWebSocket() {
             if (output) {
                 cardElements[data.model].classList.remove('loading', 'error', 'winner');
                 setCardStatus(data.model, '');
-                markRoundCompleted(data.model, data.round, data.response, data.rationale, data.discussion, data.private_notes);
+                setElapsed(data.model, null);
+                markRoundCompleted(data.model, data.round, data.response, data.rationale, data.discussion, data.private_notes, data.diff, data.searches, data.thinking);
                 showRoundResponse(data.model, data.round);
                 setActiveDot(data.model, data.round);
 
@@ -404,6 +594,8 @@ function initWebSocket() {
                     buildDiscussionsSection();
                 }
             }
+        } else if (data.type === 'error' && data.unauthorized) {
+            askForToken(data.error);
         } else if (data.type === 'error') {
             const output = outputs[data.model];
             if (output) {
@@ -411,8 +603,35 @@ function initWebSocket() {
                 cardElements[data.model].classList.remove('loading');
                 cardElements[data.model].classList.add('error');
                 setCardStatus(data.model, '');
+                setElapsed(data.model, null);
                 output.textContent = `Error: ${data.error}`;
             }
+        } else if (data.type === 'heartbeat') {
+            // Only models still answering are listed
+            (data.pending || []).forEach(call => {
+                if (cardElements[call.model]?.classList.contains('loading')) {
+                    setElapsed(call.model, call);
+                }
+            });
+        } else if (data.type === 'stall') {
+            if (cardElements[data.model]?.classList.contains('loading')) {
+                setElapsed(data.model, { elapsed_ms: data.elapsed_ms, p95_ms: data.p95_ms, stalled: true });
+            }
+        } else if (data.type === 'converged') {
+            // The answers settled early - grey out the rounds that won't run
+            Object.values(modelState).forEach(state => {
+                state.dots.slice(data.round).forEach(dot => {
+                    dot.classList.add('skipped');
+                    dot.title = `Skipped: ${data.reason}`;
+                });
+            });
+        } else if (data.type === 'fallback') {
+            // The requested variant is gone - show the one that answers instead
+            const selector = selectors[data.model];
+            if (selector) {
+                selector.value = data.to;
+                selector.title = `${data.from} was not found, switched to ${data.to}`;
+            }
         } else if (data.type === 'loading') {
             const output = outputs[data.model];
             if (output) {
@@ -423,9 +642,17 @@ function initWebSocket() {
             }
         } else if (data.type === 'ranking_start') {
             submitBtn.textContent = 'Ranking...';
+            Object.keys(elapsedIndicators).forEach(model => setElapsed(model, null));
+        } else if (data.type === 'verification_start') {
+            submitBtn.textContent = 'Verifying...';
         } else if (data.type === 'winner') {
             Object.values(cardElements).forEach(card => card.classList.remove('loading'));
 
+            if (data.request_id) {
+                lastRequestId = data.request_id;
+                followUpToggle?.classList.remove('hidden');
+            }
+
             // Handle new medal system with arrays
             const goldIDs = data.gold || [];
             const silverIDs = data.silver || [];
@@ -479,6 +706,9 @@ function initWebSocket() {
             // Build and show discussions
             buildDiscussionsSection();
 
+            showCostBreakdown(data.cost_breakdown);
+            showAudit(data.audit);
+
             submitBtn.textContent = '✓ Complete';
             submitBtn.disabled = false;
             setSelectorsEnabled(true);
@@ -541,12 +771,17 @@ submitBtn.addEventListener('click', async function () {
         const selectedModels = getSelectedModels();
 
         // Send question via WebSocket with selected models
-        ws.send(JSON.stringify({
+        const message = {
             type: "question",
             question: question,
             rounds: parseInt(roundsSelect.value),
             models: selectedModels
-        }));
+        };
+        if (followUpCheck?.checked && lastRequestId) {
+            message.type = "follow_up";
+            message.parent_request_id = lastRequestId;
+        }
+        ws.send(JSON.stringify(message));
 
     } catch (error) {
         console.error('Error sending question:', error);
@@ -604,10 +839,25 @@ async function loadModels() {
                 selector.appendChild(option);
             });
 
+            // Leaves the family out of the run
+            const skip = document.createElement('option');
+            skip.value = SKIP_FAMILY;
+            skip.textContent = 'Skip';
+            selector.appendChild(skip);
+
             // Set default to active model
             if (familyData.active) {
                 selector.value = familyData.active;
             }
+
+            // Lets another variant of the family take part as an agent of its own
+            const add = document.createElement('button');
+            add.type = 'button';
+            add.className = 'variant-add';
+            add.textContent = '+';
+            add.title = 'Add another variant of this family';
+            add.addEventListener('click', () => addVariantCard(familyID));
+            cardElements[familyID]?.querySelector('.model-header-right')?.prepend(add);
         });
     } catch (error) {
         console.error('Failed to load models:', error);
@@ -622,6 +872,86 @@ function setSelectorsEnabled(enabled) {
     Object.values(selectors).forEach(selector => {
         selector.disabled = !enabled;
     });
+    document.querySelectorAll('.variant-add, .variant-remove').forEach(button => {
+        button.disabled = !enabled;
+    });
+}
+
+// Family a card belongs to; added cards are keyed family_n
+function familyOf(card) {
+    return selectors[card]?.dataset.family || card;
+}
+
+// Cards added so far, so added cards never share a key
+let addedCards = 0;
+
+// Add another card for family, whose variant competes against the family's other cards
+function addVariantCard(family) {
+    const original = cardElements[family];
+    const taken = Object.values(selectors).map(selector => selector.value);
+    const free = [...(selectors[family]?.options || [])]
+        .map(option => option.value)
+        .filter(value => value && value !== SKIP_FAMILY && !taken.includes(value));
+    if (!original || free.length === 0) return;
+
+    const key = `${family}_${++addedCards}`;
+    const card = original.cloneNode(true);
+    card.id = key;
+    card.className = 'model-card';
+    card.dataset.model = key;
+    card.querySelectorAll('[data-model]').forEach(el => { el.dataset.model = key; });
+    card.querySelectorAll('.medal-icon, .variant-add').forEach(el => el.remove());
+
+    const selector = card.querySelector('.model-selector');
+    selector.id = `${key}-selector`;
+    selector.title = '';
+    selector.querySelector(`option[value="${SKIP_FAMILY}"]`)?.remove();
+    selector.value = free[0];
+
+    const output = card.querySelector('.model-output');
+    output.id = `${key}-output`;
+    output.className = 'model-output';
+    output.innerHTML = '<p class="placeholder">Responses will appear here once the collaboration begins.</p>';
+
+    const elapsed = card.querySelector('.model-elapsed');
+    const cost = card.querySelector('.model-cost');
+    [elapsed, cost].forEach(indicator => {
+        indicator.textContent = '';
+        indicator.title = '';
+        indicator.removeAttribute('style');
+        indicator.classList.remove('visible', 'stalled');
+    });
+
+    const remove = document.createElement('button');
+    remove.type = 'button';
+    remove.className = 'variant-remove';
+    remove.textContent = '✕';
+    remove.title = 'Remove this variant';
+    remove.addEventListener('click', () => removeVariantCard(key));
+    card.querySelector('.model-header-right').prepend(remove);
+
+    // Right after the family's last card
+    const last = modelOrder.findLastIndex(id => familyOf(id) === family);
+    cardElements[modelOrder[last]].after(card);
+    modelOrder.splice(last + 1, 0, key);
+
+    cardElements[key] = card;
+    selectors[key] = selector;
+    outputs[key] = output;
+    statusIndicators[key] = card.querySelector('.model-status');
+    costIndicators[key] = cost;
+    elapsedIndicators[key] = elapsed;
+    modelCosts[key] = 0;
+    modelState[key] = createEmptyModelState();
+    renderRoundDots(key);
+}
+
+// Remove a card addVariantCard added
+function removeVariantCard(key) {
+    cardElements[key]?.remove();
+    modelOrder.splice(modelOrder.indexOf(key), 1);
+    [cardElements, selectors, outputs, statusIndicators, costIndicators, elapsedIndicators, modelCosts, modelState]
+        .forEach(dict => delete dict[key]);
 }
 
 function resetHeroLayout() {
@@ -704,12 +1034,44 @@ function moveCardToHero(cardId, isInitial = false) {
 //     moveCardToHero(id);
 // }
 
-// Get selected models
+// Card of every agent in the run, for agents not going by their card's key
+let agentCards = {};
+
+// The card showing agentID, which is the family ID unless the family runs several variants
+function cardOf(agentID) {
+    return agentCards[agentID] || agentID;
+}
+
+// Name to show for the agent on card, with its variant when its family runs several
+function cardLabel(card) {
+    const name = cardElements[card]?.querySelector('.model-name')?.textContent || card;
+    const variant = selectors[card]?.value;
+    return agentCards[variant] === card ? `${name} (${variant})` : name;
+}
+
+// Get selected models: a variant per family, a list of them for a family with added cards
 function getSelectedModels() {
     const selected = {};
-    Object.entries(selectors).forEach(([family, selector]) => {
-        if (selector.value) {
-            selected[family] = selector.value;
+    const cardByVariant = {};
+    Object.entries(selectors).forEach(([card, selector]) => {
+        const family = selector.dataset.family;
+        if (selector.value === SKIP_FAMILY) {
+            selected[family] ??= null;
+        } else if (selector.value) {
+            selected[family] = [...(selected[family] || []), selector.value];
+            cardByVariant[selector.value] = card;
+        }
+    });
+
+    // A family's only agent goes by the family ID, several by their variant names
+    agentCards = {};
+    Object.entries(selected).forEach(([family, variants]) => {
+        if (variants === null) return;
+        if (variants.length === 1) {
+            selected[family] = variants[0];
+            agentCards[family] = cardByVariant[variants[0]];
+        } else {
+            variants.forEach(variant => { agentCards[variant] = cardByVariant[variant]; });
         }
     });
     return selected;
@@ -764,13 +1126,18 @@ function buildDiscussionsSection() {
 
     // Helper to normalize agent name to model ID
     const normalizeToModelId = (agentName) => {
-        // If it's already a model ID, return it
-        if (modelState[agentName]) return agentName;
+        // If it's already an agent or model ID, return its card
+        if (agentCards[agentName] || modelState[agentName]) return cardOf(agentName);
 
-        // Try to extract model ID from full name or partial match
+        // A variant name picks the card running it
+        const byVariant = Object.keys(selectors).find(card => selectors[card].value === agentName);
+        if (byVariant) return byVariant;
+
+        // Try to extract model ID from full name or partial match, unless the family has several cards
         const lowerName = agentName.toLowerCase();
         for (const modelId of Object.keys(modelState)) {
-            if (lowerName.includes(modelId)) return modelId;
+            const cards = Object.keys(modelState).filter(card => familyOf(card) === modelId);
+            if (lowerName.includes(modelId) && cards.length === 1) return modelId;
         }
 
         // Fallback: return as-is
@@ -794,7 +1161,7 @@ function buildDiscussionsSection() {
                 if (!modelState[fromId] || !modelState[toId]) return;
 
                 // Create a normalized pair key (alphabetically sorted)
-                const pair = [fromId, toId].sort().join('-');
+                const pair = [fromId, toId].sort().join('|');
 
                 if (!pairConversations[pair]) {
                     pairConversations[pair] = [];
@@ -841,8 +1208,7 @@ function buildDiscussionsSection() {
     allModels.forEach(modelId => {
         const chip = document.createElement('button');
         chip.className = 'discussion-filter-chip' + (activeDiscussionFilter === modelId ? ' active' : '');
-        const modelName = cardElements[modelId]?.querySelector('.model-name')?.textContent || modelId;
-        chip.textContent = modelName;
+        chip.textContent = cardLabel(modelId);
         chip.addEventListener('click', () => {
             activeDiscussionFilter = modelId;
             buildDiscussionsSection();
@@ -855,12 +1221,12 @@ function buildDiscussionsSection() {
 
     // Filter pairs based on active filter
     const filteredPairs = activeDiscussionFilter
-        ? sortedPairs.filter(pair => pair.includes(activeDiscussionFilter))
+        ? sortedPairs.filter(pair => pair.split('|').includes(activeDiscussionFilter))
         : sortedPairs;
 
     filteredPairs.forEach(pair => {
         const messages = pairConversations[pair];
-        const [model1, model2] = pair.split('-');
+        const [model1, model2] = pair.split('|');
 
         // Sort messages chronologically (by round, then maintain order)
         messages.sort((a, b) => a.round - b.round);
@@ -871,8 +1237,8 @@ function buildDiscussionsSection() {
         const headerDiv = document.createElement('div');
         headerDiv.className = 'discussion-header';
 
-        const model1Name = cardElements[model1]?.querySelector('.model-name')?.textContent || model1;
-        const model2Name = cardElements[model2]?.querySelector('.model-name')?.textContent || model2;
+        const model1Name = cardLabel(model1);
+        const model2Name = cardLabel(model2);
 
         headerDiv.textContent = `${model1Name} ↔ ${model2Name}`;
         pairDiv.appendChild(headerDiv);
@@ -890,7 +1256,7 @@ function buildDiscussionsSection() {
             const isLeft = msg.from === model1;
             msgDiv.classList.add(isLeft ? 'msg-left' : 'msg-right');
 
-            const fromName = cardElements[msg.from]?.querySelector('.model-name')?.textContent || msg.from;
+            const fromName = cardLabel(msg.from);
 
             const bubbleDiv = document.createElement('div');
             bubbleDiv.className = 'message-bubble';
@@ -947,7 +1313,26 @@ if (questionInput) {
     });
 }
 
+// Point new users at the setup page while any provider is missing a key
+async function checkSetup() {
+    try {
+        const response = await fetch('/api/setup');
+        const setup = await response.json();
+        if (setup.complete) return;
+
+        const missing = setup.families.filter(f => !f.has_key).map(f => f.provider);
+        const banner = document.createElement('a');
+        banner.className = 'setup-banner';
+        banner.href = '/setup';
+        banner.textContent = `No API key for ${missing.join(', ')} - finish setup →`;
+        document.querySelector('.hero').after(banner);
+    } catch (error) {
+        console.error('Failed to check setup:', error);
+    }
+}
+
 // Initialize
 initWebSocket();
 loadModels();
+checkSetup();
 prefillRandomQuestion(true);
@@ -0,0 +1,177 @@
+// First-run setup: API keys and default model variants
+
+const familiesContainer = document.getElementById('setupFamilies');
+const envFileInput = document.getElementById('envFile');
+const validateBtn = document.getElementById('validateBtn');
+const saveBtn = document.getElementById('saveBtn');
+const messageEl = document.getElementById('setupMessage');
+
+let families = [];
+
+function setMessage(text, isError = false) {
+    messageEl.textContent = text;
+    messageEl.classList.toggle('error', isError);
+}
+
+function setFamilyStatus(familyID, text, state) {
+    const status = document.getElementById(`status-${familyID}`);
+    if (!status) return;
+    status.textContent = text;
+    status.dataset.state = state;
+}
+
+function renderFamilies() {
+    familiesContainer.innerHTML = '';
+
+    families.forEach(family => {
+        const row = document.createElement('div');
+        row.className = 'setup-family';
+
+        const name = document.createElement('div');
+        name.className = 'setup-family-name';
+        name.textContent = family.provider;
+
+        const keyInput = document.createElement('input');
+        keyInput.type = 'password';
+        keyInput.className = 'setup-input';
+        keyInput.id = `key-${family.id}`;
+        keyInput.autocomplete = 'off';
+        keyInput.placeholder = family.has_key ? `configured via ${family.source}` : family.env_var;
+
+        const variantSelect = document.createElement('select');
+        variantSelect.className = 'setup-input';
+        variantSelect.id = `default-${family.id}`;
+        family.variants.forEach(variant => {
+            const option = document.createElement('option');
+            option.value = variant;
+            option.textContent = variant;
+            variantSelect.appendChild(option);
+        });
+        variantSelect.value = family.default;
+
+        const status = document.createElement('span');
+        status.className = 'setup-status';
+        status.id = `status-${family.id}`;
+
+        row.append(name, keyInput, variantSelect, status);
+        familiesContainer.appendChild(row);
+
+        setFamilyStatus(family.id, family.has_key ? 'configured' : 'missing', family.has_key ? 'ok' : 'missing');
+    });
+}
+
+function showResults(results) {
+    Object.entries(results || {}).forEach(([familyID, result]) => {
+        setFamilyStatus(familyID, result.valid ? 'valid' : result.error, result.valid ? 'ok' : 'error');
+    });
+}
+
+async function postJSON(url, body) {
+    const headers = { 'Content-Type': 'application/json' };
+    const token = localStorage.getItem('fatToken'); // Saved by the main page once the server asked for it
+    if (token) {
+        headers['Authorization'] = `Bearer ${token}`;
+    }
+    const response = await fetch(url, {
+        method: 'POST',
+        headers,
+        body: JSON.stringify(body),
+    });
+    const data = await response.json();
+    if (response.status === 401) {
+        const entered = window.prompt(`${data.error}\n\nEnter the server's token or your access key:`);
+        if (entered) {
+            localStorage.setItem('fatToken', entered.trim());
+            return postJSON(url, body);
+        }
+    }
+    return { ok: response.ok, data };
+}
+
+async function loadStatus() {
+    try {
+        const response = await fetch('/api/setup');
+        const data = await response.json();
+        families = data.families;
+        renderFamilies();
+        if (data.complete) {
+            setMessage('Every provider has a key.');
+        }
+    } catch (error) {
+        console.error('Failed to load setup status:', error);
+        setMessage('Failed to load setup status.', true);
+    }
+}
+
+async function validateKeys() {
+    validateBtn.disabled = true;
+    setMessage('Checking keys with providers...');
+    try {
+        const { ok, data } = await postJSON('/api/setup/validate', {});
+        if (!ok) {
+            setMessage(data.error, true);
+            return;
+        }
+        showResults(data.results);
+        setMessage('Check complete.');
+    } finally {
+        validateBtn.disabled = false;
+    }
+}
+
+async function save() {
+    saveBtn.disabled = true;
+    try {
+        const keys = {};
+        const defaults = {};
+        families.forEach(family => {
+            const key = document.getElementById(`key-${family.id}`).value.trim();
+            if (key) keys[family.id] = key;
+            const variant = document.getElementById(`default-${family.id}`).value;
+            if (variant !== family.default) defaults[family.id] = variant;
+        });
+
+        if (Object.keys(defaults).length > 0) {
+            const { ok, data } = await postJSON('/api/setup/defaults', { defaults });
+            if (!ok) {
+                setMessage(data.error, true);
+                return;
+            }
+        }
+
+        if (Object.keys(keys).length > 0) {
+            setMessage('Checking keys with providers...');
+            const store = document.querySelector('input[name="store"]:checked').value;
+            const { ok, data } = await postJSON('/api/setup/keys', {
+                keys,
+                store,
+                env_file: envFileInput.value.trim(),
+            });
+            showResults(data.results);
+            if (!ok) {
+                setMessage(data.error, true);
+                return;
+            }
+            setMessage(`Saved ${data.saved.length} key(s) to ${data.file}.`);
+        } else {
+            setMessage('Saved.');
+        }
+
+        await loadStatus();
+    } catch (error) {
+        console.error('Failed to save setup:', error);
+        setMessage('Failed to save.', true);
+    } finally {
+        saveBtn.disabled = false;
+    }
+}
+
+document.querySelectorAll('input[name="store"]').forEach(radio => {
+    radio.addEventListener('change', () => {
+        envFileInput.disabled = radio.value !== 'env' || !radio.checked;
+    });
+});
+validateBtn.addEventListener('click', validateKeys);
+saveBtn.addEventListener('click', save);
+
+loadStatus();